@@ -1,14 +1,30 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/handlers"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/i18n"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/logging"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
 	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 	mcp_golang "github.com/metoro-io/mcp-golang"
 	"github.com/metoro-io/mcp-golang/transport/stdio"
 )
@@ -21,27 +37,225 @@ func main() {
 	clearCacheShort := flag.Bool("cc", false, "Clear the Flutter deprecations cache and exit (short)")
 	showCache := flag.Bool("show-cache", false, "Display the current Flutter deprecations cache and exit")
 	showCacheShort := flag.Bool("sc", false, "Display the current Flutter deprecations cache and exit (short)")
+	scan := flag.String("scan", "", "Scan the Dart files of this project or workspace for deprecated APIs and exit, with status 1 when any are found")
+	include := flag.String("include", "", "Comma separated globs of the files --scan checks, relative to the project, e.g. lib/**")
+	exclude := flag.String("exclude", "", "Comma separated globs of the files --scan leaves out, relative to the project, e.g. lib/generated/**")
+	format := flag.String("format", config.OUTPUT_FORMAT_TEXT, "Output format of --scan: text, json for a machine-readable report, or lsp for LSP publishDiagnostics params per file as JSON")
+	groupBy := flag.String("group-by", config.GROUP_BY_PACKAGE, "How the report of --scan groups the findings: package, file, rule (ranked by use) or severity")
+	incremental := flag.Bool("incremental", false, "Make --scan of a git project only check the files changed since its last full scan")
+	staged := flag.Bool("staged", false, "Make --scan only check the lines staged for commit (git diff --cached), e.g. in a pre-commit hook")
+	diffRange := flag.String("diff-range", "", "Make --scan only check the lines a git range (from..to or from...to, e.g. origin/main...HEAD) adds, reading the files at to")
+	diffFile := flag.String("diff", "", "Make --scan only check the lines a unified diff file (- for stdin) adds, reading the files from the project")
+	baselineFile := flag.String("baseline", "", "Make --scan leave out the findings recorded in this baseline file, failing only on new uses of deprecated APIs")
+	failOn := flag.String("fail-on", "", "Least severity that fails --scan: info, warning or error (default: $"+config.FAIL_ON_ENV+" or info); lower ones are reported as warnings")
+	writeBaseline := flag.String("write-baseline", "", "Record the findings of --scan in this baseline file for later --baseline runs and exit")
+	reviewPR := flag.String("review-pr", "", "Comment on the deprecated APIs a GitHub pull request (owner/name#number or its URL) adds, posting a review with $"+config.GITHUB_TOKEN_ENV+", and exit")
+	dryRun := flag.Bool("dry-run", false, "Print the comments --review-pr would post without posting them")
+	quiet := flag.Bool("quiet", false, "Print only results, without banners, progress, hints or logs below errors, e.g. for scripts with --scan --format json")
+	language := flag.String("language", os.Getenv(config.LANGUAGE_ENV), "Language of the reports and messages of --scan and the cache commands, and the default of the report tools: "+strings.Join(config.Languages(), ", ")+" (default: $"+config.LANGUAGE_ENV+" or en)")
 	help := flag.Bool("help", false, "Show help information")
 	helpShort := flag.Bool("h", false, "Show help information (short)")
 	verbose := flag.Bool("vvv", false, "Enable verbose logging")
+	persistStats := flag.Bool("persist-stats", false, "Persist server_stats tool statistics across restarts")
+	logFormat := flag.String("log-format", logging.FormatText, "Log output format (text or json)")
+	logLevel := flag.String("log-level", "info", "Minimum log level (debug, info, warn, error)")
+	logFile := flag.String("log-file", "", "Write logs to this file instead of stderr (rotated by size)")
+	logMaxSize := flag.Int("log-max-size", 10, "Maximum log file size in megabytes before rotation")
+	logMaxBackups := flag.Int("log-max-backups", 3, "Number of rotated log files to keep")
+	daemon := flag.Bool("daemon", false, "Refresh the cache in the background on a schedule instead of blocking at startup")
+	noAutoUpdate := flag.Bool("no-auto-update", envEnabled(config.NO_AUTO_UPDATE_ENV), "Start without updating the cache or downloading the --rules-url; it is only updated when asked, e.g. with update_flutter_deprecations or --update (default: $"+config.NO_AUTO_UPDATE_ENV+")")
+	refreshSchedule := flag.String("refresh-schedule", config.DEFAULT_REFRESH_SCHEDULE, "When --daemon refreshes the cache: @hourly, @daily, @every 6h, 03:30 or \"30 3 * * *\"")
+	teamDBURL := flag.String("team-db-url", "", "Share manual entries and suppressions through this team database URL (GET/PUT JSON)")
+	teamDBAuthHeader := flag.String("team-db-auth-header", config.TEAM_DB_AUTH_HEADER, "Request header carrying the team database credentials from $"+config.TEAM_DB_AUTH_ENV)
+	restAddr := flag.String("rest-addr", "", "Also serve the REST API (GET /deprecations, POST /check, POST /fixes, GET /version-info) on this address, e.g. 127.0.0.1:8080")
+	versionSources := flag.String("version-sources", strings.Join(config.DefaultVersionSources(), ","), "Comma separated Flutter version sources in priority order (cli, official, github)")
+	flutterSDK := flag.String("flutter-sdk", "", "Use the Flutter SDK at this path for version detection and local SDK scans instead of the flutter on PATH")
+	execTimeout := flag.Duration("exec-timeout", config.DEFAULT_EXEC_TIMEOUT, "Kill flutter, fvm and git commands that run longer than this, e.g. 45s (0 disables the timeout)")
+	maxParallelFetches := flag.Int("max-parallel-fetches", 0, "Send at most this many upstream HTTP requests at once (0 for no limit)")
+	maxParallelScans := flag.Int("max-parallel-scans", 0, "Check at most this many files at once across all project, file and SDK scans (0 for no limit)")
+	maxParallelExecs := flag.Int("max-parallel-execs", 0, "Run at most this many flutter, fvm and git commands at once (0 for no limit)")
+	maxScanFileSize := flag.Int("max-scan-file-size", config.MAX_SCAN_FILE_SIZE>>20, "Skip Flutter source files larger than this many megabytes in the deprecation scans")
+	previewChannel := flag.String("preview-channel", "", "Also scan the beta or master branch and mark the deprecations that have not reached stable yet as upcoming")
+	dockerMirrors := flag.String("docker-mirrors", "", "Comma separated registry=mirror pairs the Docker image checks use, e.g. docker.io=artifactory.example.com/docker-remote,ghcr.io=harbor.example.com/ghcr")
+	caFile := flag.String("ca-file", "", "PEM file with extra root certificates to trust, e.g. the CA of a TLS-intercepting corporate proxy")
+	insecureSkipVerify := flag.Bool("insecure-skip-verify", false, "Disable TLS certificate verification for all upstream calls (unsafe; for diagnosing proxies only)")
+	upstreamMirrors := flag.String("upstream-mirrors", "", "Comma separated host=URL pairs that replace upstream hosts with internal mirrors, e.g. raw.githubusercontent.com=https://mirror.example.com/github-raw")
+	airGapped := flag.Bool("air-gapped", false, "Never contact hosts without an --upstream-mirrors or --docker-mirrors entry")
+	noHTTPCache := flag.Bool("no-http-cache", false, "Download upstream files again on every update instead of revalidating the copies kept in the cache directory")
+	noScanCache := flag.Bool("no-scan-cache", false, "Check every file again on each project scan instead of reusing the results of unchanged files kept in the cache directory")
+	ruleProviders := flag.String("rule-providers", os.Getenv(config.RULE_PROVIDERS_ENV), "Comma separated rule providers the checks draw on in order of precedence, or the ones to leave out prefixed with - (default: $"+config.RULE_PROVIDERS_ENV+" or "+strings.Join(config.DefaultRuleProviders(), ",")+")")
+	rulesFile := flag.String("rules-file", "", "Comma separated YAML or JSON files, or directories of them, with team deprecation rules for the custom-yaml provider, reloaded when edited (default: "+config.CUSTOM_RULES_FILE+" in the cache directory)")
+	rulesURL := flag.String("rules-url", "", "URL of a YAML or JSON rules file the remote provider downloads on startup and with every cache refresh")
+	testRules := flag.Bool("test-rules", false, "Test the pattern rules against the fixtures of their rules files and the --rule-samples and exit, with status 1 on a false positive or negative")
+	ruleSamples := flag.String("rule-samples", "", "Dart sample file or directory whose lines name the APIs --test-rules should flag in a trailing // expect: comment")
+	dockerConfig := flag.String("docker-config", "", "Docker CLI config file with registry logins (default: $"+config.DOCKER_CONFIG_ENV+"/config.json or ~/.docker/config.json)")
 	flag.Parse()
 
-	// Configure logging based on verbose flag
+	// Configure logging; logs go to stderr or a log file so they never corrupt the stdio MCP stream
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --log-level: %v\n", err)
+		os.Exit(1)
+	}
 	if *verbose {
-		log.SetFlags(log.LstdFlags | log.Lshortfile)
-		log.Println("Verbose logging enabled")
-	} else {
-		// Disable logging for normal operation
-		log.SetOutput(os.Stderr)
+		level = slog.LevelDebug
+	} else if *quiet {
+		level = max(level, slog.LevelError)
 	}
+	var logOutput io.Writer = os.Stderr
+	if *logFile != "" {
+		rotatingFile, err := logging.NewRotatingFile(*logFile, int64(*logMaxSize)*1024*1024, *logMaxBackups)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --log-file: %v\n", err)
+			os.Exit(1)
+		}
+		defer rotatingFile.Close()
+		logOutput = rotatingFile
+	}
+	logger, err := logging.New(logOutput, *logFormat, level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --log-format: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	slog.Debug("Verbose logging enabled")
 
-	done := make(chan struct{})
+	// Cancel in-flight network and exec calls on Ctrl-C or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Initialize services
 	cacheService := services.NewCacheService()
 	apiService := services.NewFlutterAPIService()
+	transport, err := services.NewHTTPTransport(*caFile, *insecureSkipVerify)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --ca-file: %v\n", err)
+		os.Exit(1)
+	}
+	if *insecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "⚠️  WARNING: TLS certificate verification is disabled (--insecure-skip-verify). Upstream responses can be intercepted or forged; use --ca-file with your proxy's CA instead.")
+		slog.Warn("TLS certificate verification is disabled", "flag", "--insecure-skip-verify")
+	}
 	deprecationService := services.NewDeprecationService(cacheService, apiService)
-	versionInfoService := services.NewVersionInfoService(apiService)
+	sources, err := services.ParseVersionSources(*versionSources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --version-sources: %v\n", err)
+		os.Exit(1)
+	}
+	versionInfoService := services.NewVersionInfoService(apiService, sources...)
+	versionInfoService.SetAdvisoryService(apiService)
+	if *execTimeout < 0 {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --exec-timeout: %s is negative\n", *execTimeout)
+		os.Exit(1)
+	}
+	apiService.SetExecTimeout(*execTimeout)
+	versionInfoService.SetExecTimeout(*execTimeout)
+	if *maxScanFileSize <= 0 {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --max-scan-file-size: %d is not a positive number of megabytes\n", *maxScanFileSize)
+		os.Exit(1)
+	}
+	apiService.SetMaxScanFileSize(int64(*maxScanFileSize) << 20)
+	for name, limit := range map[string]int{"--max-parallel-fetches": *maxParallelFetches, "--max-parallel-scans": *maxParallelScans, "--max-parallel-execs": *maxParallelExecs} {
+		if limit < 0 {
+			fmt.Fprintf(os.Stderr, "❌ Invalid %s: %d is negative\n", name, limit)
+			os.Exit(1)
+		}
+	}
+	services.SetFileScanConcurrency(*maxParallelScans)
+	services.SetExecConcurrency(*maxParallelExecs)
+	switch *previewChannel {
+	case "", config.FLUTTER_CHANNEL_BETA, config.FLUTTER_CHANNEL_MASTER:
+		apiService.SetPreviewChannel(*previewChannel)
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Invalid --preview-channel: %q is not beta or master\n", *previewChannel)
+		os.Exit(1)
+	}
+	printer, err := i18n.NewPrinter(*language)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --language: %v\n", err)
+		os.Exit(1)
+	}
+	var sdkRoot string
+	if *flutterSDK != "" {
+		if sdkRoot, err = services.ResolveFlutterSDK(*flutterSDK); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --flutter-sdk: %v\n", err)
+			os.Exit(1)
+		}
+		versionInfoService.SetFlutterSDK(sdkRoot)
+	}
+	registries, err := services.LoadDockerRegistries(*dockerMirrors, *dockerConfig, os.Getenv(config.DOCKER_REGISTRY_USER_ENV), os.Getenv(config.DOCKER_REGISTRY_PASSWORD_ENV))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --docker-mirrors or --docker-config: %v\n", err)
+		os.Exit(1)
+	}
+	apiService.SetDockerRegistries(registries)
+	mirrors, err := services.ParseUpstreamMirrors(*upstreamMirrors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --upstream-mirrors: %v\n", err)
+		os.Exit(1)
+	}
+	if *airGapped {
+		if unmirrored := services.UnmirroredHosts(mirrors); len(unmirrored) > 0 {
+			slog.Warn("Air-gapped mode: features that need these hosts will fail", "unmirrored", strings.Join(unmirrored, ", "))
+		}
+	}
+	apiService.SetGitHubToken(os.Getenv(config.GITHUB_TOKEN_ENV))
+	// The registry mirrors and the team's rules host are internal, so air-gapped mode lets them through
+	allowedHosts := registries.MirrorHosts()
+	if *rulesURL != "" {
+		parsed, err := url.Parse(*rulesURL)
+		if err != nil || parsed.Host == "" {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --rules-url: %q is not an absolute URL\n", *rulesURL)
+			os.Exit(1)
+		}
+		allowedHosts = append(allowedHosts, parsed.Host)
+	}
+	upstreamTransport := services.NewMirrorTransport(services.NewLimitTransport(transport, *maxParallelFetches), mirrors, *airGapped, allowedHosts...)
+	if !*noHTTPCache {
+		upstreamTransport = services.NewHTTPCacheTransport(upstreamTransport, cacheService.Dir())
+	}
+	apiService.SetTransport(upstreamTransport)
+	var rulesPaths []string
+	for _, path := range strings.Split(*rulesFile, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if err := services.ValidateRulesFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --rules-file: %v\n", err)
+			os.Exit(1)
+		}
+		rulesPaths = append(rulesPaths, path)
+	}
+	if len(rulesPaths) == 0 {
+		rulesPaths = []string{filepath.Join(cacheService.Dir(), config.CUSTOM_RULES_FILE)}
+	}
+	providers, err := services.SelectRuleProviders(*ruleProviders,
+		services.NewBuiltinRuleProvider(),
+		services.NewCustomRuleProvider(rulesPaths...),
+		services.NewManualRuleProvider(cacheService),
+		services.NewRemoteRuleProvider(*rulesURL, filepath.Join(cacheService.Dir(), config.REMOTE_RULES_FILE), apiService),
+		services.NewSourceScanRuleProvider(cacheService),
+		services.NewFixDataRuleProvider(sdkRoot),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --rule-providers: %v\n", err)
+		os.Exit(1)
+	}
+	deprecationService.SetRuleProviders(providers...)
+	slog.Debug("Rule providers", "providers", strings.Join(deprecationService.RuleProviders(), ","))
+	projectScanService := services.NewProjectScanService(deprecationService)
+	if !*noScanCache {
+		projectScanService.SetScanCache(filepath.Join(cacheService.Dir(), config.SCAN_CACHE_FILE))
+	}
+	projectScanService.SetBaselineDir(filepath.Join(cacheService.Dir(), config.PROJECT_SCANS_DIR))
+	projectScanService.SetExecTimeout(*execTimeout)
+	diffScanService := services.NewDiffScanService(deprecationService)
+	diffScanService.SetExecTimeout(*execTimeout)
+	schedule, err := services.ParseSchedule(*refreshSchedule)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid --refresh-schedule: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Handle help flag
 	if *help || *helpShort {
@@ -54,143 +268,1206 @@ func main() {
 		fmt.Println("  --update, -u       Update the Flutter deprecations cache and exit")
 		fmt.Println("  --clear-cache, -cc Clear the Flutter deprecations cache and exit")
 		fmt.Println("  --show-cache, -sc  Display the current Flutter deprecations cache and exit")
+		fmt.Println("  --scan             Scan a project or workspace for deprecated APIs and exit (status 1 when found)")
+		fmt.Println("  --include          Comma separated globs of the files --scan checks, e.g. lib/**")
+		fmt.Println("  --exclude          Comma separated globs of the files --scan leaves out, e.g. lib/generated/**")
+		fmt.Println("  --format           Output format of --scan: text (default), a json report or lsp diagnostics JSON")
+		fmt.Println("  --group-by         Group the --scan report by package (default), file, rule or severity")
+		fmt.Println("  --incremental      Make --scan only check the files git reports as changed since the last full scan")
+		fmt.Println("  --staged           Make --scan only check the lines staged for commit, e.g. in a pre-commit hook")
+		fmt.Println("  --diff-range       Make --scan only check the lines a git range adds, e.g. origin/main...HEAD")
+		fmt.Println("  --diff             Make --scan only check the lines a unified diff file (- for stdin) adds")
+		fmt.Println("  --fail-on          Least severity that fails --scan: info (default), warning or error")
+		fmt.Println("  --baseline         Make --scan leave out the findings recorded in a baseline file")
+		fmt.Println("  --write-baseline   Record the findings of --scan in a baseline file and exit")
+		fmt.Println("  --review-pr        Post review comments on the deprecated APIs a pull request adds (owner/name#number) and exit")
+		fmt.Println("  --dry-run          Make --review-pr print the comments instead of posting them")
+		fmt.Println("  --quiet            Print only results: no banners, progress, hints or logs below errors")
+		fmt.Println("  --language         Language of the reports: en, de or es (default: $" + config.LANGUAGE_ENV + " or en)")
 		fmt.Println("  --help, -h         Show this help information")
-		fmt.Println("  --vvv              Enable verbose logging")
+		fmt.Println("  --vvv              Enable verbose logging (same as --log-level debug)")
+		fmt.Println("  --log-format       Log output format: text or json (default: text)")
+		fmt.Println("  --log-level        Minimum log level: debug, info, warn, error (default: info)")
+		fmt.Println("  --log-file         Write logs to a file instead of stderr")
+		fmt.Println("  --log-max-size     Log file size in MB before rotation (default: 10)")
+		fmt.Println("  --log-max-backups  Rotated log files to keep (default: 3)")
+		fmt.Println("  --persist-stats    Keep server_stats statistics across restarts")
+		fmt.Println("  --version-sources  Version sources in priority order (default: cli,official,github)")
+		fmt.Println("  --flutter-sdk      Flutter SDK directory to use instead of the flutter on PATH")
+		fmt.Println("  --exec-timeout     Kill flutter, fvm and git commands running longer than this (default: 30s, 0 disables)")
+		fmt.Println("  --max-parallel-fetches  Upstream HTTP requests in flight at once (default: 0, no limit)")
+		fmt.Println("  --max-parallel-scans    Files checked at once across all scans (default: 0, no limit)")
+		fmt.Println("  --max-parallel-execs    flutter, fvm and git commands running at once (default: 0, no limit)")
+		fmt.Println("  --max-scan-file-size  Skip source files over this many MB in the deprecation scans (default: 8)")
+		fmt.Println("  --preview-channel  Also scan beta or master and mark deprecations not yet in stable as upcoming")
+		fmt.Println("  --ca-file          PEM file with extra root certificates, e.g. a corporate proxy CA")
+		fmt.Println("  --insecure-skip-verify  Disable TLS certificate verification (unsafe, diagnostics only)")
+		fmt.Println("  --upstream-mirrors Replace upstream hosts with internal mirrors: host=URL pairs, comma separated")
+		fmt.Println("  --air-gapped       Never contact hosts that have no mirror configured")
+		fmt.Println("  --no-http-cache    Download upstream files again instead of revalidating cached copies")
+		fmt.Println("  --no-scan-cache    Check every file on each project scan instead of reusing unchanged files' results")
+		fmt.Println("  --docker-mirrors   Check Docker images on mirrors: registry=mirror pairs, comma separated")
+		fmt.Println("  --docker-config    Docker config file with registry logins (default: ~/.docker/config.json)")
+		fmt.Println("  --rule-providers   Rule providers in order of precedence, or -name to leave one out (default: all)")
+		fmt.Println("  --rules-file       YAML or JSON files or directories of team deprecation rules, reloaded when edited (default: rules.yaml in the cache directory)")
+		fmt.Println("  --rules-url        Download a rules file from this URL on startup and with every cache refresh")
+		fmt.Println("  --test-rules       Test the rules against their fixtures and the --rule-samples and exit (status 1 on failures)")
+		fmt.Println("  --rule-samples     Dart samples for --test-rules, annotated with // expect: API comments")
+		fmt.Println("  --daemon           Refresh the cache in the background on a schedule while serving")
+		fmt.Println("  --no-auto-update   Start without updating the cache; update it only when asked (or $" + config.NO_AUTO_UPDATE_ENV + "=true)")
+		fmt.Println("  --refresh-schedule Schedule for --daemon: @hourly, @daily, @every 6h, 03:30 or \"30 3 * * *\" (default: @daily)")
+		fmt.Println("  --team-db-url      Sync manual entries and suppressions with a team database URL")
+		fmt.Println("  --team-db-auth-header  Header sent with $" + config.TEAM_DB_AUTH_ENV + " as its value (default: Authorization)")
+		fmt.Println("  --rest-addr        Also serve the REST API on this address (e.g. 127.0.0.1:8080)")
 		fmt.Println("")
 		fmt.Println("Examples:")
 		fmt.Println("  server             Start the MCP server")
 		fmt.Println("  server -u          Update deprecations cache")
 		fmt.Println("  server -cc         Clear deprecations cache")
 		fmt.Println("  server -sc         Show current cache contents")
+		fmt.Println("  server --scan ~/src/shop --exclude 'lib/generated/**'   Scan a workspace, skipping generated code")
+		fmt.Println("  server --scan . --group-by rule   See which deprecated APIs a project uses most")
+		fmt.Println("  server --scan . --staged   Block a commit that adds deprecated APIs (pre-commit hook)")
+		fmt.Println("  server --scan . --diff-range origin/main...HEAD   Fail CI only on deprecated APIs a branch adds")
+		fmt.Println("  server --scan . --fail-on error   Only fail on APIs that are removed or about to be")
+		fmt.Println("  server --scan . --write-baseline baseline.json   Grandfather the current findings for --baseline")
+		fmt.Println("  server --scan . --quiet --format json   Print one JSON report for a script")
+		fmt.Println("  server --scan . --language de   Print the scan report in German")
+		fmt.Println("  server --review-pr acme/shop#42   Comment on the deprecated APIs pull request 42 adds")
 		fmt.Println("  server --vvv       Start with verbose logging")
+		fmt.Println("  server --vvv --log-file /tmp/flutter-mcp.log   Capture verbose logs when run by an MCP client")
+		fmt.Println("  server --version-sources official,github   Never consult the local Flutter CLI")
+		fmt.Println("  server --flutter-sdk /opt/flutter-3.27   Use this SDK on a CI agent with several installed")
+		fmt.Println("  server --docker-mirrors docker.io=artifactory.example.com/docker-remote   Check images on an Artifactory proxy")
+		fmt.Println("  server --rule-providers -source-scan,-fix-data   Check only against the built-in, team and manual rules")
+		fmt.Println("  server --test-rules --rules-file rules.yaml --rule-samples samples   Test team rules in their CI")
+		fmt.Println("  server --no-auto-update   Start instantly on a metered connection or in CI, with the cache as it is")
+		fmt.Println("  server --daemon --refresh-schedule 03:30   Refresh the cache every night at 03:30")
+		fmt.Println("  server --daemon --rest-addr 127.0.0.1:8080   Serve dashboards and bots over HTTP with a fresh cache")
 		return
 	}
 
 	// Handle clear cache flag
 	if *clearCache || *clearCacheShort {
-		fmt.Println("🗑️ Clearing Flutter deprecations cache...")
+		if !*quiet {
+			printer.Printf("🗑️ Clearing Flutter deprecations cache...\n")
+		}
 
-		if err := cacheService.Clear(); err != nil {
-			fmt.Printf("❌ Error clearing deprecations cache: %v\n", err)
+		if err := cacheService.ClearAll(); err != nil {
+			printer.Printf("❌ Error clearing %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Println("✅ Successfully cleared deprecations cache")
+		if !*quiet {
+			printer.Printf("✅ Successfully cleared deprecations cache\n")
+		}
 		return
 	}
 
 	// Handle show cache flag
 	if *showCache || *showCacheShort {
-		fmt.Println("📋 Flutter Deprecations Cache Contents")
+		printer.Printf("📋 Flutter Deprecations Cache Contents\n")
 		fmt.Println("=" + strings.Repeat("=", 40))
 
 		cache, err := cacheService.Load()
 		if err != nil {
-			fmt.Printf("❌ Error loading deprecations cache: %v\n", err)
-			fmt.Println("💡 Try running with --update to create the cache first")
+			printer.Printf("❌ Error loading deprecations cache: %v\n", err)
+			printer.Printf("💡 Try running with --update to create the cache first\n")
 			os.Exit(1)
 		}
 
-		if len(cache.Deprecations) == 0 {
-			fmt.Println("📭 No deprecations found in cache")
-			fmt.Println("💡 Try running with --update to populate the cache")
+		if len(cache.Deprecations) == 0 && len(cache.Manual) == 0 {
+			printer.Printf("📭 No deprecations found in cache\n")
+			printer.Printf("💡 Try running with --update to populate the cache\n")
 			return
 		}
 
-		fmt.Printf("📊 Cache Info:\n")
-		fmt.Printf("  Last Updated: %s\n", cache.LastUpdated.Format("2006-01-02 15:04:05"))
-		fmt.Printf("  Total Deprecations: %d\n", len(cache.Deprecations))
+		printer.Printf("📊 Cache Info:\n")
+		printer.Printf("  Last Updated: %s\n", cache.LastUpdated.Format("2006-01-02 15:04:05"))
+		printer.Printf("  Total Deprecations: %d\n", len(cache.Deprecations))
+		printer.Printf("  Manual Entries: %d\n", len(cache.Manual))
 		fmt.Println()
 
 		// Group deprecations by API name for better display
-		fmt.Printf("📜 Deprecations:\n")
+		printer.Printf("📜 Deprecations:\n")
 		for i, dep := range cache.Deprecations {
 			fmt.Printf("%d. 🔴 %s\n", i+1, dep.API)
 			if dep.Description != "" {
-				fmt.Printf("   📝 Description: %s\n", dep.Description)
+				printer.Printf("   📝 Description: %s\n", printer.Description(dep))
 			}
 			if dep.Replacement != "" {
-				fmt.Printf("   ✅ Replacement: %s\n", dep.Replacement)
+				printer.Printf("   ✅ Replacement: %s\n", dep.Replacement)
 			}
 			if dep.Version != "" {
-				fmt.Printf("   📅 Since version: %s\n", dep.Version)
+				printer.Printf("   📅 Since version: %s\n", dep.Version)
 			}
 			if dep.Example != "" {
-				fmt.Printf("   💡 Example: %s\n", dep.Example)
+				printer.Printf("   💡 Example: %s\n", dep.Example)
+			}
+			printer.Printf("   📖 Documentation: %s\n", services.DocumentationURL(dep))
+			if dep.SourceFile != "" {
+				printer.Printf("   🔗 Source: %s:%d\n", dep.SourceFile, dep.SourceLine)
+			}
+			if dep.Removed {
+				printer.Printf("   🪦 Removed upstream, last seen %s\n", dep.LastSeen.Format("2006-01-02"))
 			}
 			fmt.Println()
 		}
 
-		fmt.Printf("✨ Total: %d deprecations found\n", len(cache.Deprecations))
+		if len(cache.Manual) > 0 {
+			printer.Printf("✍️ Manual entries:\n")
+			for i, dep := range cache.Manual {
+				fmt.Printf("%d. 🔴 %s\n", i+1, dep.API)
+				if dep.Replacement != "" {
+					printer.Printf("   ✅ Replacement: %s\n", dep.Replacement)
+				}
+				printer.Printf("   📖 Documentation: %s\n", services.DocumentationURL(dep))
+				fmt.Println()
+			}
+		}
+
+		printer.Printf("✨ Total: %d deprecations found\n", len(cache.Deprecations)+len(cache.Manual))
 		return
 	}
 
 	// Handle update flag
 	if *update || *updateShort {
-		fmt.Println("🔄 Updating Flutter deprecations cache...")
+		if !*quiet {
+			printer.Printf("🔄 Updating Flutter deprecations cache...\n")
+		}
 
 		// Create a progress callback
 		progressCallback := func(message string) {
-			fmt.Printf("  %s\n", message)
+			if !*quiet {
+				fmt.Printf("  %s\n", message)
+			}
 		}
 
-		if err := deprecationService.UpdateCacheWithProgress(progressCallback, *verbose); err != nil {
-			fmt.Printf("❌ Error updating deprecations cache: %v\n", err)
+		var previousUpdate time.Time
+		if previous, err := cacheService.Load(); err == nil {
+			previousUpdate = previous.LastUpdated
+		}
+
+		if err := deprecationService.UpdateCacheWithProgress(ctx, progressCallback); err != nil {
+			printer.Printf("❌ Error updating deprecations cache: %v\n", err)
 			os.Exit(1)
 		}
 
 		cache, err := cacheService.Load()
 		if err != nil {
-			fmt.Printf("❌ Cache updated but failed to load for verification: %v\n", err)
+			printer.Printf("❌ Cache updated but failed to load for verification: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("✅ Successfully updated deprecations cache. Found %d deprecations. Last updated: %s\n",
+		if *quiet {
+			return
+		}
+		printer.Printf("✅ Successfully updated deprecations cache. Found %d deprecations. Last updated: %s\n",
 			len(cache.Deprecations), cache.LastUpdated.Format("2006-01-02 15:04:05"))
+		if changes := cache.LastChanges; changes != nil && !cache.LastUpdated.Equal(previousUpdate) && !changes.From.IsZero() {
+			printCacheChanges(printer, changes)
+		}
 		return
 	}
 
+	// Handle scan flag
+	if *scan != "" {
+		outputFormat, err := services.ParseOutputFormat(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --format: %v\n", err)
+			os.Exit(1)
+		}
+		changes := 0
+		for _, set := range []bool{*staged, *diffRange != "", *diffFile != ""} {
+			if set {
+				changes++
+			}
+		}
+		if changes > 1 || changes == 1 && (*incremental || outputFormat == config.OUTPUT_FORMAT_LSP) {
+			exitScan(outputFormat, "Invalid --staged, --diff-range or --diff: only one can be given and not with --incremental or --format lsp")
+		}
+		if *writeBaseline != "" && (*baselineFile != "" || changes > 0 || outputFormat != config.OUTPUT_FORMAT_TEXT) {
+			exitScan(outputFormat, "Invalid --write-baseline: it cannot be combined with --baseline, --staged, --diff-range, --diff or --format lsp or json")
+		}
+		if *baselineFile != "" && changes > 0 {
+			exitScan(outputFormat, "Invalid --baseline: --staged, --diff-range and --diff only check new lines already")
+		}
+		grouping, err := services.ParseGroupBy(*groupBy)
+		if err != nil {
+			exitScan(outputFormat, "Invalid --group-by: %v", err)
+		}
+		if grouping != config.GROUP_BY_PACKAGE && (changes > 0 || outputFormat != config.OUTPUT_FORMAT_TEXT) {
+			exitScan(outputFormat, "Invalid --group-by: it only applies to the text report of a project scan, not to --staged, --diff-range, --diff or --format lsp or json")
+		}
+		if *failOn == "" {
+			*failOn = os.Getenv(config.FAIL_ON_ENV)
+		}
+		threshold, err := services.ParseSeverity(*failOn)
+		if err != nil {
+			exitScan(outputFormat, "Invalid --fail-on: %v", err)
+		}
+		// Keep stdout to the JSON for editors and scripts; the hint goes to stderr there
+		var hint io.Writer = os.Stdout
+		switch {
+		case *quiet:
+			hint = io.Discard
+		case outputFormat != config.OUTPUT_FORMAT_TEXT:
+			hint = os.Stderr
+		}
+		if cache, err := cacheService.Load(); err != nil || cache.LastUpdated.IsZero() {
+			printer.Fprintf(hint, "💡 No deprecations cache yet, only the built-in rules apply; run with --update first to check the scanned ones too\n")
+		}
+
+		filter := models.PathFilter{Include: strings.Split(*include, ","), Exclude: strings.Split(*exclude, ",")}
+		if changes > 0 {
+			var result *models.DiffScanResult
+			switch {
+			case *staged:
+				result, err = diffScanService.ScanStaged(ctx, *scan, "", filter)
+			case *diffRange != "":
+				result, err = diffScanService.ScanRange(ctx, *scan, *diffRange, "", filter)
+			default:
+				var diff []byte
+				if *diffFile == "-" {
+					diff, err = io.ReadAll(os.Stdin)
+				} else {
+					diff, err = os.ReadFile(*diffFile)
+				}
+				if err != nil {
+					exitScan(outputFormat, "Invalid --diff: %v", err)
+				}
+				result, err = diffScanService.ScanDiff(ctx, *scan, string(diff), "", filter)
+			}
+			if err != nil {
+				exitScan(outputFormat, "Error scanning %s: %v", *scan, err)
+			}
+			suppressions, err := services.NewSuppressionService(filepath.Join(cacheService.Dir(), config.SUPPRESSIONS_FILE)).Suppressions(*scan)
+			if err != nil {
+				exitScan(outputFormat, "Error loading suppressions: %v", err)
+			}
+			if outputFormat == config.OUTPUT_FORMAT_JSON {
+				if printScanReport(services.DiffScanReport(result, suppressedAPIs(suppressions), threshold)) > 0 {
+					os.Exit(1)
+				}
+				return
+			}
+			if printDiffScan(printer, result, suppressions, threshold) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		scanProject := projectScanService.Scan
+		if *incremental {
+			scanProject = projectScanService.ScanChanged
+		}
+		result, err := scanProject(ctx, *scan, "", filter)
+		if err != nil {
+			exitScan(outputFormat, "Error scanning %s: %v", *scan, err)
+		}
+		if *writeBaseline != "" {
+			baseline, err := deprecationService.FindingsBaseline(result, "")
+			if err == nil {
+				err = services.WriteFindingsBaseline(*writeBaseline, baseline)
+			}
+			if err != nil {
+				exitScan(outputFormat, "Error writing baseline: %v", err)
+			}
+			files := make(map[string]bool)
+			for _, finding := range baseline.Findings {
+				files[finding.Path] = true
+			}
+			printer.Printf("📌 Recorded %d findings in %d files in %s; --scan with --baseline %s now only fails on new ones\n", len(baseline.Findings), len(files), *writeBaseline, *writeBaseline)
+			return
+		}
+		if *baselineFile != "" {
+			baseline, err := services.LoadFindingsBaseline(*baselineFile)
+			if err != nil {
+				exitScan(outputFormat, "Invalid --baseline: %v", err)
+			}
+			if err := deprecationService.ApplyFindingsBaseline(result, baseline, ""); err != nil {
+				exitScan(outputFormat, "Invalid --baseline: %v", err)
+			}
+		}
+		suppressions, err := services.NewSuppressionService(filepath.Join(cacheService.Dir(), config.SUPPRESSIONS_FILE)).Suppressions(*scan)
+		if err != nil {
+			exitScan(outputFormat, "Error loading suppressions: %v", err)
+		}
+
+		switch outputFormat {
+		case config.OUTPUT_FORMAT_LSP:
+			if printProjectDiagnostics(deprecationService, result, suppressions, threshold) > 0 {
+				os.Exit(1)
+			}
+			return
+		case config.OUTPUT_FORMAT_JSON:
+			if printScanReport(services.ProjectScanReport(result, suppressedAPIs(suppressions), threshold)) > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		if printProjectScan(printer, result, suppressions, threshold, grouping) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle review-pr flag
+	prReviewService := services.NewPRReviewService(apiService, deprecationService)
+	if *reviewPR != "" {
+		repo, number, err := services.ParsePullRequest(*reviewPR)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --review-pr: %v\n", err)
+			os.Exit(1)
+		}
+		// The current directory is the checkout of the repository in CI, whose suppressions apply
+		suppressions, err := services.NewSuppressionService(filepath.Join(cacheService.Dir(), config.SUPPRESSIONS_FILE)).Suppressions(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error loading suppressions: %v\n", err)
+			os.Exit(1)
+		}
+		result, err := prReviewService.Review(ctx, repo, number, "", suppressedAPIs(suppressions), !*dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reviewing %s#%d: %v\n", repo, number, err)
+			os.Exit(1)
+		}
+		printPRReview(printer, result)
+		return
+	}
+
+	// Handle test-rules flag
+	if *testRules {
+		if *rulesURL != "" {
+			deprecationService.RefreshRuleProviders(ctx)
+		}
+		result, err := deprecationService.TestRules(ctx, config.TARGET_FLUTTER, *ruleSamples, "", "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error testing rules: %v\n", err)
+			os.Exit(1)
+		}
+		if failures := printRuleTest(printer, result); failures > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Collect tool and upstream statistics for the server_stats tool
+	statsPath := ""
+	if *persistStats {
+		statsPath = filepath.Join(cacheService.Dir(), config.STATS_FILE)
+	}
+	statsService := services.NewStatsService(statsPath)
+	if statsPath != "" {
+		go statsService.FlushEvery(ctx, config.STATS_FLUSH_INTERVAL)
+	}
+	apiService.SetStatsRecorder(statsService)
+	versionInfoService.SetStatsRecorder(statsService)
+	cacheService.SetStatsRecorder(statsService)
+	deprecationService.SetStatsRecorder(statsService)
+
 	// Initialize handlers
-	mcpHandlers := handlers.NewMCPHandlers(deprecationService, versionInfoService, cacheService)
+	guideService := services.NewMigrationGuideService(apiService, deprecationService)
+	localSDKService := services.NewLocalSDKService()
+	localSDKService.SetFlutterSDK(sdkRoot)
+	suppressionService := services.NewSuppressionService(filepath.Join(cacheService.Dir(), config.SUPPRESSIONS_FILE))
+	whatsNewService := services.NewWhatsNewService(apiService, cacheService, guideService)
+	releaseHistoryService := services.NewReleaseHistoryService(apiService)
+	handlerOptions := []handlers.Option{
+		handlers.WithPrinter(printer),
+		handlers.WithStatsService(statsService),
+		handlers.WithRateLimitService(apiService),
+		handlers.WithUpstreamCheckService(apiService),
+		handlers.WithMigrationGuideService(guideService),
+		handlers.WithProjectScanService(projectScanService),
+		handlers.WithDependencyAuditService(services.NewDependencyAuditService(apiService)),
+		handlers.WithDiffScanService(diffScanService),
+		handlers.WithMinimumVersionService(services.NewMinimumVersionService()),
+		handlers.WithSDKConstraintService(services.NewSDKConstraintService(apiService)),
+		handlers.WithPinRecommendationService(services.NewPinRecommendationService(apiService)),
+		handlers.WithSuppressionService(suppressionService),
+		handlers.WithDockerfileService(services.NewDockerfileService(apiService)),
+		handlers.WithCIWorkflowService(services.NewCIWorkflowService(apiService)),
+		handlers.WithWebCheckService(services.NewWebCheckService(apiService)),
+		handlers.WithRunnerTemplateService(services.NewRunnerTemplateService(localSDKService)),
+		handlers.WithLocalSDKService(localSDKService),
+		handlers.WithSDKScanService(services.NewSDKScanService(apiService, localSDKService, cacheService.Dir())),
+		handlers.WithWhatsNewService(whatsNewService),
+		handlers.WithReleaseHistoryService(releaseHistoryService),
+		handlers.WithUpgradeDigestService(services.NewUpgradeDigestService(apiService, releaseHistoryService, localSDKService, whatsNewService)),
+		handlers.WithRepoScanService(services.NewRepoScanService(apiService, cacheService)),
+		handlers.WithPRReviewService(prReviewService),
+		handlers.WithCacheClearService(cacheService),
+		handlers.WithCacheStatusService(cacheService),
+	}
+
+	// Share manual entries and suppressions with the team database
+	var teamSync *services.TeamSyncService
+	if *teamDBURL != "" {
+		teamSync = services.NewTeamSyncService(*teamDBURL, *teamDBAuthHeader, os.Getenv(config.TEAM_DB_AUTH_ENV), cacheService, suppressionService)
+		teamSync.SetStatsRecorder(statsService)
+		teamSync.SetTransport(transport)
+		handlerOptions = append(handlerOptions, handlers.WithTeamSyncService(teamSync))
+	}
+
+	// Initialize MCP server; its transport also carries the sampling requests of draft_replacements,
+	// the elicitation requests that confirm changes to local state, the roots/list requests that
+	// find the active project and the output schemas and structured results of the tools
+	clientTransport := handlers.NewClientTransport(stdio.NewStdioServerTransport())
+	server := mcp_golang.NewServer(clientTransport)
+	handlerOptions = append(handlerOptions,
+		handlers.WithReplacementDraftService(services.NewReplacementDraftService(cacheService, apiService, clientTransport)),
+		handlers.WithConfirmer(clientTransport),
+		handlers.WithProjectRootService(services.NewProjectRootService(clientTransport)),
+	)
 
-	// Initialize MCP server
-	server := mcp_golang.NewServer(stdio.NewStdioServerTransport())
+	var mcpHandlers *handlers.MCPHandlers
+	partitions := &partitionResources{uris: make(map[string]bool)}
+	announceCache := func() { announceCacheUpdate(server, mcpHandlers, partitions) }
+	handlerOptions = append(handlerOptions, handlers.WithCacheChangedNotifier(announceCache))
+	mcpHandlers = handlers.NewMCPHandlers(deprecationService, versionInfoService, cacheService, handlerOptions...)
 
-	// Update deprecations cache on startup
-	if err := deprecationService.UpdateCache(); err != nil {
-		fmt.Printf("Warning: Failed to update deprecations cache: %v\n", err)
+	// Expose the cache, and its slice of each release and category, as resources; re-registering
+	// the cache tells clients that fresh data is available
+	if err := registerCacheResource(server, mcpHandlers, partitions); err != nil {
+		panic(err)
 	}
 
 	// Register MCP tools
-	err := server.RegisterTool(
+	registerTool(server, clientTransport, statsService,
 		"check_flutter_deprecations",
-		"Check Flutter code for deprecated APIs and get suggestions for replacements. Provide the code snippet to analyze.",
-		mcpHandlers.CheckFlutterDeprecations)
-	if err != nil {
-		panic(err)
-	}
+		"Check Flutter code for deprecated APIs and outdated Dart syntax (pre-null-safety constructs, new, @dart pragmas) and get suggestions for replacements. Provide the code snippet to analyze. Large files can be sent gzip compressed and base64 encoded with encoding: gzip+base64. Pass target: dart for pure Dart packages (servers, CLIs) to apply only the Dart SDK and syntax rules. APIs suppressed with suppress_deprecation are hidden unless include_suppressed is true.",
+		mcpHandlers.CheckFlutterDeprecations,
+		models.CheckResponse{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_flutter_files",
+		"Check several Dart files or snippets for deprecated APIs in one call. Pass files as a list of {path or code, name}; relative paths start at project_path. Results are reported per entry, and an entry that cannot be read does not fail the others.",
+		mcpHandlers.CheckFlutterFiles,
+		models.CheckFilesResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_flutter_project",
+		"Scan every Dart file of a local Flutter project for deprecated APIs, skipping paths listed in .gitignore and .flutter-deprecations-ignore files. Melos and pub workspaces and monorepos with a packages directory are split into their packages: the report has a section per package and the workspace totals. group_by lists the findings per file, per deprecated API ranked by use, or per severity instead. audit_dependencies adds the pub.dev security advisories that affect the packages pubspec.lock locks. project_path defaults to the first Flutter project under the roots the client exposes.",
+		mcpHandlers.CheckFlutterProject,
+		models.ScanReport{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_changed_lines",
+		"Check only the lines a change adds to a local project for deprecated APIs, so CI blocks on new uses without failing on old ones: pass a unified diff (files read from project_path), a git range such as origin/main...HEAD (files read at its end) or staged: true. The project's ignore files and include and exclude globs apply. project_path defaults to the first Flutter project under the roots the client exposes.",
+		mcpHandlers.CheckChangedLines,
+		models.ScanReport{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_code_against_version",
+		"Check Flutter code against a target Flutter version and report only the deprecations that apply there. Pass current_version to separate APIs deprecated during the upgrade from ones that were already deprecated.",
+		mcpHandlers.CheckCodeAgainstVersion,
+		models.VersionCheckResult{})
 
-	err = server.RegisterTool(
+	registerTool(server, clientTransport, statsService,
+		"check_version_matrix",
+		"Check Flutter code or a project against several target Flutter versions at once, such as the range a plugin supports, and return a matrix of which deprecations and newer APIs apply to which target.",
+		mcpHandlers.CheckVersionMatrix,
+		models.VersionMatrix{})
+
+	registerTool(server, clientTransport, statsService,
+		"infer_minimum_flutter_version",
+		"Infer the minimum Flutter (and Dart) version a code snippet or project needs from the APIs it uses (e.g. withValues, PopScope, ScaffoldMessenger) and suggest pubspec.yaml SDK constraints.",
+		mcpHandlers.InferMinimumFlutterVersion,
+		models.MinimumVersionResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"suggest_sdk_constraints",
+		"Edit the environment: block of a pubspec.yaml (pubspec contents or project_path, default: the first Flutter project under the client's roots) to the sdk and flutter constraints of a target Flutter release (target_version, default: the latest stable) and the Dart SDK it bundles. Returns the edited block and a unified diff ready to apply; constraints that already require the target are kept.",
+		mcpHandlers.SuggestSDKConstraints,
+		models.SDKConstraintSuggestion{})
+
+	registerTool(server, clientTransport, statsService,
+		"recommend_flutter_pin",
+		"Recommend the Flutter release to pin a project to: the newest stable release that every pub.dev dependency of its pubspec.yaml (pubspec contents or project_path, default: the first Flutter project under the client's roots) supports within its version constraint, judged by the SDK constraints of the published versions. Names the dependencies that hold the project back and the versions that would unblock them.",
+		mcpHandlers.RecommendFlutterPin,
+		models.PinRecommendation{})
+
+	registerTool(server, clientTransport, statsService,
+		"migrate_code",
+		"Rewrite Flutter code by applying every known mechanical replacement for deprecated APIs. Returns the migrated code, the changes made and the deprecations that still need a manual fix.",
+		mcpHandlers.MigrateCode,
+		models.MigrationResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"get_analyzer_fixes",
+		"Report the deprecated APIs in a Dart file (file path and optionally its unsaved code) in the Dart analyzer plugin protocol: the result of edit.getFixes with an AnalysisError per use and the SourceChange quick fixes of the mechanical replacements. Offsets are in UTF-16 code units so editor plugins can offer the fixes directly.",
+		mcpHandlers.GetAnalyzerFixes,
+		models.AnalyzerFixesResult{})
+
+	registerTool(server, clientTransport, statsService,
 		"list_flutter_deprecations",
 		"Get a list of all known Flutter deprecations from the cache. Optionally filter by version or API name.",
-		mcpHandlers.ListFlutterDeprecations)
-	if err != nil {
-		panic(err)
-	}
+		mcpHandlers.ListFlutterDeprecations,
+		models.DeprecationsResponse{})
+
+	registerTool(server, clientTransport, statsService,
+		"get_deprecation_details",
+		"Look up everything known about one deprecated API by its exact name (e.g. ColorScheme.background): replacement, version, category, severity, example, documentation link and where the entry came from.",
+		mcpHandlers.GetDeprecationDetails,
+		models.DeprecationDetails{})
+
+	registerTool(server, clientTransport, statsService,
+		"explain_deprecation",
+		"Explain how to migrate away from a deprecated Flutter API: the deprecation message, the matching flutter/website migration guide excerpt and a worked before/after example.",
+		mcpHandlers.ExplainDeprecation,
+		models.DeprecationExplanation{})
+
+	registerTool(server, clientTransport, statsService,
+		"list_breaking_changes_between",
+		"List the documented Flutter breaking changes between two versions (from exclusive, to inclusive) in chronological order with links to their migration guides: the checklist to work through before upgrading.",
+		mcpHandlers.ListBreakingChangesBetween,
+		models.BreakingChangesResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"compare_flutter_versions",
+		"Compare the deprecations of two Flutter versions installed on this machine (FVM versions and other local SDKs) entirely offline: the APIs newly deprecated in the newer version and the deprecated APIs whose annotation is gone, usually because they were removed. Each version's framework source is scanned once and stored.",
+		mcpHandlers.CompareFlutterVersions,
+		models.VersionComparison{})
+
+	registerTool(server, clientTransport, statsService,
+		"deprecations_introduced_in",
+		"List only the deprecations first introduced in one Flutter release (e.g. 3.27.0, or 3.27 for the newest installed 3.27 release), read offline from the SDKs installed on this machine: the APIs annotated in that release and in no older installed SDK. Use it to scope an upgrade PR to exactly what the release deprecated.",
+		mcpHandlers.DeprecationsIntroducedIn,
+		models.IntroducedDeprecations{})
+
+	registerTool(server, clientTransport, statsService,
+		"whats_new_in_flutter",
+		"Summarize a Flutter release (default: the latest stable): the deprecations it introduced, its documented breaking changes and the replacement APIs it recommends, assembled from the deprecations cache, the GitHub release notes and the breaking changes index.",
+		mcpHandlers.WhatsNewInFlutter,
+		models.ReleaseDigest{})
+
+	registerTool(server, clientTransport, statsService,
+		"list_flutter_releases",
+		"List the stable Flutter release history, newest first: version, release date and Dart SDK version of each release (limit, default 20; since, e.g. 3.22). Use it to answer when a release shipped or to plan an upgrade timeline.",
+		mcpHandlers.ListFlutterReleases,
+		models.ReleaseHistory{})
+
+	registerTool(server, clientTransport, statsService,
+		"upgrade_digest",
+		"Read before running flutter upgrade: consolidate the release notes of every stable release between the installed Flutter version (from, default: the version the project at project_path pins, else the active SDK) and the latest stable, with the deprecations, breaking changes and replacement APIs of each release line in between.",
+		mcpHandlers.UpgradeDigest,
+		models.UpgradeDigest{})
+
+	registerTool(server, clientTransport, statsService,
+		"search_deprecations",
+		"Search known Flutter deprecations with a free-text query (e.g. snackbar, opacity). Matches API names, descriptions and replacements case-insensitively with typo-tolerant fuzzy ranking.",
+		mcpHandlers.SearchDeprecations,
+		models.DeprecationSearchResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"deprecation_stats",
+		"Summarize the deprecations cache without listing every entry: totals, counts by Flutter release, category, severity and source, and the most recently introduced deprecations (recent, default 10).",
+		mcpHandlers.DeprecationStats,
+		models.DeprecationStats{})
+
+	registerTool(server, clientTransport, statsService,
+		"cache_changes",
+		"Show what the last cache refresh changed: deprecations added, removed (annotation gone upstream) and modified (replacement, description, version, category or severity), compared with the refresh before.",
+		mcpHandlers.CacheChanges,
+		models.CacheChanges{})
 
-	err = server.RegisterTool(
+	registerTool(server, clientTransport, statsService,
+		"add_deprecation",
+		"Add a custom deprecation entry (API, replacement, description, example) that check_flutter_deprecations and the other tools will report. Custom entries are stored separately and survive cache updates.",
+		mcpHandlers.AddDeprecation,
+		models.ManualDeprecationResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"draft_replacements",
+		"Ask the model of the MCP client, through sampling, to draft the replacement of scanned deprecations whose @Deprecated message names none (limit, default 5, or one api). It gets the deprecation message and the source around the annotation. Drafts are stored in the cache flagged as AI-generated, shown as unverified and never applied automatically. Needs a client that supports sampling.",
+		mcpHandlers.DraftReplacements,
+		models.ReplacementDrafts{})
+
+	registerTool(server, clientTransport, statsService,
+		"test_deprecation_rules",
+		"Test the pattern rules against the match and no_match fixtures of their rules files and against annotated Dart samples (a file or directory path or inline code) whose lines name the APIs they should be flagged for in a trailing // expect: comment. Reports the false positives, the false negatives and the rules without fixtures.",
+		mcpHandlers.TestDeprecationRules,
+		models.RuleTestResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"scan_repo_deprecations",
+		"Scan the lib directory of a Dart package in any GitHub repository (owner/name or URL, optional ref and path), such as a company's fork of a plugin, for @Deprecated annotations. With save: true the entries are stored next to the custom ones so the checks report them in code importing the package.",
+		mcpHandlers.ScanRepoDeprecations,
+		models.RepoScanOutcome{})
+
+	registerTool(server, clientTransport, statsService,
+		"review_pull_request",
+		"Comment on the deprecated APIs a GitHub pull request adds: the changed Dart files are checked at the head commit and every added line using a deprecated API gets a review comment with a suggested rewrite when one is known. Lines that earlier reviews commented on are skipped. With post: true the comments are published as a review using the server's GITHUB_TOKEN; otherwise they are only listed.",
+		mcpHandlers.ReviewPullRequest,
+		models.PRReviewResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"suppress_deprecation",
+		"Mark a deprecated API as acknowledged or won't fix, for this machine or for one project (project_path). Suppressed APIs are left out of check_flutter_deprecations and list_flutter_deprecations unless include_suppressed is true. Pass remove: true to undo. Clients that support elicitation ask the user before a project suppression file is written.",
+		mcpHandlers.SuppressDeprecation,
+		models.SuppressionChange{})
+
+	registerTool(server, clientTransport, statsService,
+		"sync_team_database",
+		"Pull the manual entries and machine-wide suppressions shared by your team from the configured team database (--team-db-url), replacing the local ones. Clients that support elicitation ask the user first.",
+		mcpHandlers.SyncTeamDatabase,
+		models.TeamDatabase{})
+
+	registerTool(server, clientTransport, statsService,
+		"cache_status",
+		"Report the state of the deprecations cache: file size, last update and age, whether the 24h TTL has expired, entry counts by source and the Flutter refs that were scanned. Use it to decide whether to run update_flutter_deprecations before answering.",
+		mcpHandlers.CacheStatus,
+		models.CacheStatus{})
+
+	registerTool(server, clientTransport, statsService,
+		"update_flutter_deprecations",
+		"Refresh the Flutter deprecations cache by rescanning the source code of Flutter and its first-party plugins. Skipped when the cache is still fresh. Pass directories (framework libraries or plugins such as material or go_router) or sources (rule sources such as fix-data or remote; source-scan rescans everything) to refresh only that part straight away.",
+		mcpHandlers.UpdateFlutterDeprecations,
+		models.CacheUpdateResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"clear_flutter_deprecations_cache",
+		"Delete the deprecations cache, manual entries included, with the cached upstream responses and scan results, like --clear-cache, to reset a corrupted or stale cache. Clients that support elicitation ask the user to confirm; with other clients it needs confirm: true and without it only reports what would be deleted.",
+		mcpHandlers.ClearFlutterDeprecationsCache,
+		models.CacheClearResult{})
+
+	registerTool(server, clientTransport, statsService,
 		"check_flutter_version_info",
 		"Get the latest Flutter version and check availability in FVM and Docker images (instrumentisto/flutter and cirrusci/flutter).",
-		mcpHandlers.CheckFlutterVersionInfo)
-	if err != nil {
-		panic(err)
+		mcpHandlers.CheckFlutterVersionInfo,
+		models.FlutterVersionInfo{})
+
+	registerTool(server, clientTransport, statsService,
+		"generate_dockerfile",
+		"Generate a Dockerfile (and docker-compose snippet for web) that builds a Flutter app at a given version (default: latest stable) for web, apk, appbundle or linux, using whichever base image (instrumentisto or cirruslabs) publishes that tag.",
+		mcpHandlers.GenerateDockerfile,
+		models.DockerfileResult{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_ci_workflow",
+		"Check the Flutter versions pinned in GitHub Actions workflows (subosito/flutter-action, matrix versions, Flutter containers) or GitLab CI images and report which are outdated, unavailable or floating, with suggested updates. Pass a CI file, a project directory or the file contents.",
+		mcpHandlers.CheckCIWorkflow,
+		models.CIWorkflowReport{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_flutter_web",
+		"Check a Flutter project's web setup for deprecated patterns: the html renderer and removed --web-renderer flags in build scripts and CI, legacy index.html bootstraps (main.dart.js script tag, loadEntrypoint, serviceWorkerVersion) and dart:html or dart:js imports due for package:web. Suggestions match the project's Flutter version (version argument, .fvmrc or pubspec.yaml pin, else latest stable). Pass a project directory, a file or its contents.",
+		mcpHandlers.CheckFlutterWeb,
+		models.WebCheckReport{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_desktop_runners",
+		"Compare a project's windows, linux and macos runner folders with the templates flutter create generates in the target Flutter version (version argument, project pin, else the active SDK) and flag stale template code such as CreateAndShow windows, hard-coded Runner.rc versions and @NSApplicationMain. Needs a local SDK of that version for the full comparison; without one only the known stale patterns are checked. project_path defaults to the first Flutter project under the roots the client exposes.",
+		mcpHandlers.CheckDesktopRunners,
+		models.RunnerDriftReport{})
+
+	registerTool(server, clientTransport, statsService,
+		"list_flutter_sdks",
+		"List every Flutter SDK on the machine (PATH, FVM cache, puro environments, common install paths) with its version and channel, mark the active flutter, and warn when it differs from the version a project pins in .fvmrc, .puro.json or pubspec.yaml.",
+		mcpHandlers.ListFlutterSDKs,
+		models.LocalSDKReport{})
+
+	registerTool(server, clientTransport, statsService,
+		"rate_limit_status",
+		"Show the GitHub API quota of the server: requests remaining and the limit, when it resets, and whether a GITHUB_TOKEN is used. Use it to tell whether a failed update or scan is a rate limit problem and when to retry.",
+		mcpHandlers.RateLimitStatus,
+		models.RateLimitStatus{})
+
+	registerTool(server, clientTransport, statsService,
+		"check_upstreams",
+		"Probe the upstream hosts the server depends on (GitHub API, raw.githubusercontent.com, storage.googleapis.com, Docker Hub, GHCR and pub.dev) with short timeouts and report per endpoint whether it is reachable, its HTTP status and latency. Use it to tell whether a failing update, scan or version check is caused by the network, a proxy or a mirror.",
+		mcpHandlers.CheckUpstreams,
+		models.UpstreamChecks{})
+
+	registerTool(server, clientTransport, statsService,
+		"server_stats",
+		"Get per-tool invocation counts and latencies plus upstream call timings (GitHub, Docker Hub, official releases API, local flutter/fvm) to see where slow responses come from.",
+		mcpHandlers.ServerStats,
+		models.ServerStats{})
+
+	// Serve the REST API next to the MCP stdio transport, sharing the same handlers
+	if *restAddr != "" {
+		listener, err := net.Listen("tcp", *restAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid --rest-addr: %v\n", err)
+			os.Exit(1)
+		}
+		go serveREST(ctx, listener, mcpHandlers)
 	}
 
-	fmt.Println("Flutter Deprecations MCP Server started. Waiting for requests...")
+	// Pick up edits to the custom rules files without a restart
+	deprecationService.WatchRuleProviders(ctx)
+
+	slog.Info("Flutter Deprecations MCP Server started. Waiting for requests...")
 	err = server.Serve()
 	if err != nil {
 		panic(err)
 	}
 
-	<-done
+	// Update the cache in the background so a cold cache does not hold up the first tool calls
+	go func() {
+		pullTeamDatabase(ctx, teamSync, *teamDBURL)
+		if *rulesURL != "" && !*noAutoUpdate {
+			deprecationService.RefreshRuleProviders(ctx)
+		}
+		// A rate limited update waits for the quota to reset, across restarts too
+		var backoff *services.RateLimitBackoffError
+		if *noAutoUpdate {
+			slog.Info("Automatic cache update disabled; run update_flutter_deprecations or --update to refresh the cache")
+		} else if err := services.UpdateUnlessRateLimited(ctx, cacheService.Dir(), deprecationService.UpdateCache); errors.As(err, &backoff) {
+			slog.Info("Skipping the startup cache update until the GitHub rate limit resets", "reset", backoff.Until.Format("2006-01-02 15:04:05"))
+		} else if err != nil {
+			slog.Warn("Failed to update deprecations cache", "error", err)
+		} else {
+			announceCache()
+		}
+
+		if *daemon {
+			// Keep the cache (and team database) current on schedule while serving
+			slog.Info("Daemon mode enabled", "refresh_schedule", *refreshSchedule)
+			services.NewRefreshScheduler(schedule, func(ctx context.Context) error {
+				pullTeamDatabase(ctx, teamSync, *teamDBURL)
+				if err := services.UpdateUnlessRateLimited(ctx, cacheService.Dir(), deprecationService.RefreshCache); err != nil {
+					return err
+				}
+				announceCache()
+				return nil
+			}).Run(ctx)
+		}
+	}()
+
+	// Serve until interrupted or terminated, then keep the statistics recorded since the last flush
+	<-ctx.Done()
+	statsService.Flush()
+}
+
+// partitionResources tracks the cache partitions registered as resources, so that a refresh only
+// registers the new ones and deregisters those left without entries
+type partitionResources struct {
+	mu   sync.Mutex
+	uris map[string]bool
+}
+
+// registerCacheResource registers the deprecations cache as an MCP resource, together with the
+// deprecations resource template and a resource for each Flutter release and category it holds.
+// While the server runs, registering the cache again sends notifications/resources/list_changed
+// to connected clients.
+func registerCacheResource(server *mcp_golang.Server, mcpHandlers *handlers.MCPHandlers, partitions *partitionResources) error {
+	if err := server.RegisterResourceTemplate(config.DEPRECATIONS_RESOURCE_TEMPLATE,
+		"Flutter deprecations by release and category",
+		"The deprecations of one Flutter release (major.minor, e.g. 3.27) and category (e.g. material), as JSON. unknown stands for entries without a version or category.",
+		"application/json"); err != nil {
+		return err
+	}
+
+	partitions.mu.Lock()
+	defer partitions.mu.Unlock()
+	if current, err := mcpHandlers.DeprecationPartitions(); err != nil {
+		slog.Debug("Failed to list the deprecation partitions", "error", err)
+	} else {
+		listed := make(map[string]bool, len(current))
+		for _, partition := range current {
+			listed[partition.URI] = true
+			if partitions.uris[partition.URI] {
+				continue
+			}
+			if err := server.RegisterResource(partition.URI,
+				fmt.Sprintf("Flutter deprecations %s/%s", partition.Version, partition.Category),
+				fmt.Sprintf("The deprecations of Flutter release %s in category %s, as JSON.", partition.Version, partition.Category),
+				"application/json",
+				mcpHandlers.DeprecationPartitionResource(partition.Version, partition.Category)); err != nil {
+				return err
+			}
+			partitions.uris[partition.URI] = true
+		}
+		for uri := range partitions.uris {
+			if !listed[uri] {
+				if err := server.DeregisterResource(uri); err != nil {
+					return err
+				}
+				delete(partitions.uris, uri)
+			}
+		}
+	}
+
+	return server.RegisterResource(config.CACHE_RESOURCE_URI,
+		"Flutter deprecations cache",
+		"All known Flutter deprecations, including manual entries, as JSON. Re-announced whenever the cache is refreshed.",
+		"application/json",
+		mcpHandlers.DeprecationsResource)
+}
+
+// announceCacheUpdate tells connected clients that the deprecations cache has fresh data
+func announceCacheUpdate(server *mcp_golang.Server, mcpHandlers *handlers.MCPHandlers, partitions *partitionResources) {
+	if err := registerCacheResource(server, mcpHandlers, partitions); err != nil {
+		slog.Debug("Failed to notify clients of the cache update", "error", err)
+		return
+	}
+	slog.Debug("Notified clients of the cache update")
+}
+
+// serveREST serves the REST API on listener until ctx is cancelled
+func serveREST(ctx context.Context, listener net.Listener, mcpHandlers *handlers.MCPHandlers) {
+	server := &http.Server{
+		Handler:           mcpHandlers.RESTHandler(),
+		ReadHeaderTimeout: config.REST_READ_HEADER_TIMEOUT,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("REST API listening", "addr", listener.Addr().String())
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		slog.Error("REST API stopped", "error", err)
+	}
+}
+
+// pullTeamDatabase refreshes the shared manual entries and suppressions when a team database is configured
+func pullTeamDatabase(ctx context.Context, teamSync *services.TeamSyncService, url string) {
+	if teamSync == nil {
+		return
+	}
+	if _, err := teamSync.Pull(ctx); err != nil {
+		slog.Warn("Failed to sync team database", "url", url, "error", err)
+	}
+}
+
+// envEnabled reports whether the environment variable turns a boolean setting on: true, 1 or yes
+func envEnabled(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "true", "1", "yes":
+		return true
+	}
+	return false
+}
+
+// printCacheChanges lists what the refresh that just finished added, removed and modified
+func printCacheChanges(p *i18n.Printer, changes *models.CacheChanges) {
+	p.Printf("\n📝 Changes since %s: %d added, %d removed, %d modified\n",
+		changes.From.Format("2006-01-02 15:04:05"), len(changes.Added), len(changes.Removed), len(changes.Modified))
+	for _, dep := range changes.Added {
+		p.Printf("  ➕ %s\n", dep.API)
+	}
+	for _, dep := range changes.Removed {
+		p.Printf("  ➖ %s\n", dep.API)
+	}
+	for _, change := range changes.Modified {
+		p.Printf("  ✏️ %s (%s)\n", change.After.API, strings.Join(change.Fields, ", "))
+	}
+}
+
+// printProjectScan prints the findings of a --scan run per package, or grouped by groupBy, leaving
+// out suppressed APIs, and returns how many deprecations it found at or above the threshold severity
+func printProjectScan(p *i18n.Printer, result *models.ProjectScanResult, suppressions []models.Suppression, threshold string, groupBy string) int {
+	suppressed := suppressedAPIs(suppressions)
+
+	files, total, failing, affected, hidden := 0, 0, 0, 0, 0
+	for _, pkg := range result.Packages {
+		files += pkg.Files
+	}
+	p.Printf("🔎 Scanned %s: %d package(s), %d Dart files\n", result.ProjectPath, len(result.Packages), files)
+	switch {
+	case result.FullScanReason != "":
+		p.Printf("💡 Checked every file: %s\n", result.FullScanReason)
+	case result.BaseCommit != "":
+		p.Printf("⚡ Checked %d changed file(s) again, reusing the full scan at %s\n", result.Changed, services.ShortCommit(result.BaseCommit))
+	}
+	if result.Cached > 0 {
+		p.Printf("♻️ Reused the results of %d unchanged files\n", result.Cached)
+	}
+
+	var grouped []models.ProjectFinding
+	var unreadable []models.FileCheckResult
+	for _, pkg := range result.Packages {
+		if groupBy == config.GROUP_BY_PACKAGE {
+			p.Printf("\n📦 %s (%s), %d Dart files\n", pkg.Name, pkg.Path, pkg.Files)
+		}
+		for _, finding := range pkg.Findings {
+			if finding.Error != "" && groupBy != config.GROUP_BY_PACKAGE {
+				unreadable = append(unreadable, finding)
+				continue
+			}
+			if finding.Error != "" {
+				p.Printf("  ⚠️ %s: %s\n", finding.Name, finding.Error)
+				continue
+			}
+			var active []models.Deprecation
+			for _, dep := range finding.Deprecations {
+				if suppressed[dep.API] {
+					hidden++
+				} else {
+					active = append(active, dep)
+				}
+			}
+			if len(active) == 0 {
+				continue
+			}
+			affected++
+			total += len(active)
+			if groupBy != config.GROUP_BY_PACKAGE {
+				for _, dep := range services.ByUrgency(active) {
+					grouped = append(grouped, models.ProjectFinding{Package: pkg.Name, File: finding.Name, Deprecation: dep})
+				}
+				continue
+			}
+			p.Printf("  %s\n", finding.Name)
+			for _, dep := range services.ByUrgency(active) {
+				mark := severityMark(dep, threshold, &failing)
+				if dep.Replacement != "" {
+					p.Printf("    %s %s → %s%s\n", mark, dep.API, dep.Replacement, ageSuffix(p, dep))
+				} else {
+					p.Printf("    %s %s%s\n", mark, dep.API, ageSuffix(p, dep))
+				}
+			}
+		}
+	}
+	if groupBy != config.GROUP_BY_PACKAGE {
+		failing = printFindingGroups(p, services.GroupFindings(grouped, groupBy), groupBy, threshold)
+		if len(unreadable) > 0 {
+			fmt.Println()
+		}
+		for _, finding := range unreadable {
+			p.Printf("⚠️ %s: %s\n", finding.Name, finding.Error)
+		}
+	}
+
+	fmt.Println()
+	if hidden > 0 {
+		p.Printf("🙈 %d suppressed deprecation(s) hidden\n", hidden)
+	}
+	if result.Baselined > 0 {
+		p.Printf("📌 %d finding(s) recorded in the baseline left out\n", result.Baselined)
+	}
+	printBelowThreshold(p, total-failing, threshold)
+	if total == 0 {
+		p.Printf("✅ No deprecated APIs found\n")
+	} else {
+		p.Printf("✨ Total: %d deprecations in %d files\n", total, affected)
+	}
+	return failing
+}
+
+// printFindingGroups prints the findings of a --scan run grouped by file, deprecated API or
+// severity, and returns how many of them are at or above the threshold severity
+func printFindingGroups(p *i18n.Printer, groups []models.FindingGroup, groupBy string, threshold string) int {
+	failing := 0
+	for _, group := range groups {
+		switch groupBy {
+		case config.GROUP_BY_FILE:
+			p.Printf("\n📄 %s\n", group.Name)
+		case config.GROUP_BY_RULE:
+			dep := group.Findings[0].Deprecation
+			if dep.Replacement != "" {
+				p.Printf("\n📋 %s → %s%s: %d file(s)\n", dep.API, dep.Replacement, ageSuffix(p, dep), len(group.Findings))
+			} else {
+				p.Printf("\n📋 %s%s: %d file(s)\n", dep.API, ageSuffix(p, dep), len(group.Findings))
+			}
+		default:
+			p.Printf("\n🚦 %s: %d deprecation(s)\n", group.Name, len(group.Findings))
+		}
+
+		for _, finding := range group.Findings {
+			dep := finding.Deprecation
+			mark := severityMark(dep, threshold, &failing)
+			switch {
+			case groupBy == config.GROUP_BY_RULE:
+				p.Printf("  %s %s\n", mark, finding.File)
+			case groupBy == config.GROUP_BY_FILE && dep.Replacement != "":
+				p.Printf("  %s %s → %s%s\n", mark, dep.API, dep.Replacement, ageSuffix(p, dep))
+			case groupBy == config.GROUP_BY_FILE:
+				p.Printf("  %s %s%s\n", mark, dep.API, ageSuffix(p, dep))
+			case dep.Replacement != "":
+				p.Printf("  %s %s: %s → %s%s\n", mark, finding.File, dep.API, dep.Replacement, ageSuffix(p, dep))
+			default:
+				p.Printf("  %s %s: %s%s\n", mark, finding.File, dep.API, ageSuffix(p, dep))
+			}
+		}
+	}
+	return failing
+}
+
+// printDiffScan prints the findings of a --scan run limited to a change such as the staged one,
+// leaving out suppressed APIs, and returns how many it printed at or above the threshold severity
+func printDiffScan(p *i18n.Printer, result *models.DiffScanResult, suppressions []models.Suppression, threshold string) int {
+	suppressed := suppressedAPIs(suppressions)
+
+	change := p.Change(result.Change)
+	p.Printf("🔎 Checked the %s of %d Dart file(s) in %s\n", change, result.Files, result.ProjectPath)
+	total, failing, hidden := 0, 0, 0
+	for _, finding := range result.Findings {
+		dep := finding.Deprecation
+		if suppressed[dep.API] {
+			hidden++
+			continue
+		}
+		total++
+		mark := severityMark(dep, threshold, &failing)
+		if dep.Replacement != "" {
+			p.Printf("  %s:%d:%d: %s %s → %s%s\n", finding.Path, finding.Line, finding.Column, mark, dep.API, dep.Replacement, ageSuffix(p, dep))
+		} else {
+			p.Printf("  %s:%d:%d: %s %s%s\n", finding.Path, finding.Line, finding.Column, mark, dep.API, ageSuffix(p, dep))
+		}
+	}
+
+	fmt.Println()
+	if hidden > 0 {
+		p.Printf("🙈 %d suppressed deprecation(s) hidden\n", hidden)
+	}
+	printBelowThreshold(p, total-failing, threshold)
+	if total == 0 {
+		p.Printf("✅ No deprecated APIs in the %s\n", change)
+	} else {
+		p.Printf("✨ Total: %d deprecations in the %s\n", total, change)
+	}
+	return failing
+}
+
+// severityMark returns the mark of a finding: red when its severity reaches the threshold and
+// fails the scan, which it counts in failing, and yellow when it is only a warning
+func severityMark(dep models.Deprecation, threshold string, failing *int) string {
+	if !services.SeverityAtLeast(dep, threshold) {
+		return "🟡"
+	}
+	*failing++
+	return "🔴"
+}
+
+// ageSuffix describes how long ago a finding's API was deprecated, when that is known
+func ageSuffix(p *i18n.Printer, dep models.Deprecation) string {
+	if age := p.Age(dep); age != "" {
+		return " (" + age + ")"
+	}
+	return ""
+}
+
+// printBelowThreshold notes the findings that were reported without failing the scan
+func printBelowThreshold(p *i18n.Printer, below int, threshold string) {
+	if below > 0 {
+		p.Printf("🟡 %d deprecation(s) below the --fail-on %s severity do not fail the scan\n", below, threshold)
+	}
+}
+
+// printPRReview prints the comments of a --review-pr run and the review they were posted in
+func printPRReview(p *i18n.Printer, result *models.PRReviewResult) {
+	p.Printf("🔎 Reviewed %s#%d at %s: %d changed Dart files\n", result.Repository, result.Number, services.ShortCommit(result.HeadSHA), result.Files)
+	if result.Existing > 0 {
+		p.Printf("♻️ Skipped %d use(s) earlier reviews already commented on\n", result.Existing)
+	}
+	for _, comment := range result.Comments {
+		p.Printf("  🔴 %s:%d: %s\n", comment.Path, comment.Line, strings.Join(comment.APIs, ", "))
+	}
+	if result.Omitted > 0 {
+		p.Printf("⚠️ %d more line(s) left out; one review holds at most %d comments\n", result.Omitted, config.MAX_PR_REVIEW_COMMENTS)
+	}
+
+	fmt.Println()
+	switch {
+	case len(result.Comments) == 0:
+		p.Printf("✅ No deprecated APIs on the lines this pull request adds\n")
+	case result.ReviewURL != "":
+		p.Printf("✅ Posted %d comment(s): %s\n", len(result.Comments), result.ReviewURL)
+	default:
+		p.Printf("✨ %d comment(s) to post; run without --dry-run to post them\n", len(result.Comments))
+	}
+}
+
+// printRuleTest prints the failures of a --test-rules run and returns how many there are
+func printRuleTest(p *i18n.Printer, result *models.RuleTestResult) int {
+	p.Printf("🧪 Tested %d rules: %d fixtures, %d samples with %d expectations\n", result.Rules, result.Fixtures, result.Samples, result.Expectations)
+	for _, failure := range result.Failures {
+		location := p.Sprintf("fixture")
+		if failure.File != "" {
+			location = fmt.Sprintf("%s:%d", failure.File, failure.Line)
+		}
+		kind := p.Sprintf("false positive")
+		if failure.Kind == config.RULE_TEST_FALSE_NEGATIVE {
+			kind = p.Sprintf("false negative")
+		}
+		p.Printf("  🔴 %s: %s %s: %s\n", failure.API, kind, location, failure.Code)
+	}
+	if len(result.Untested) > 0 {
+		p.Printf("💡 %d rule(s) without fixtures: %s\n", len(result.Untested), strings.Join(result.Untested, ", "))
+	}
+
+	fmt.Println()
+	if len(result.Failures) == 0 {
+		p.Printf("✅ No false positives or false negatives\n")
+	} else {
+		p.Printf("❌ %d rule test(s) failed\n", len(result.Failures))
+	}
+	return len(result.Failures)
+}
+
+// printScanReport prints the JSON report of a --scan run and returns how many of its findings fail
+// the scan
+func printScanReport(report *models.ScanReport) int {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		exitScan(config.OUTPUT_FORMAT_JSON, "Error encoding report: %v", err)
+	}
+	fmt.Println(string(data))
+	return report.Failing
+}
+
+// exitScan reports an error of a --scan run and exits with status 1. With --format json the error
+// is a JSON document on stdout, so that scripts always read one; otherwise it goes to stderr.
+func exitScan(outputFormat string, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if outputFormat == config.OUTPUT_FORMAT_JSON {
+		data, _ := json.Marshal(models.ErrorResponse{Error: message})
+		fmt.Println(string(data))
+	} else {
+		fmt.Fprintf(os.Stderr, "❌ %s\n", message)
+	}
+	os.Exit(1)
+}
+
+// suppressedAPIs returns the set of the suppressed APIs
+func suppressedAPIs(suppressions []models.Suppression) map[string]bool {
+	suppressed := make(map[string]bool, len(suppressions))
+	for _, suppression := range suppressions {
+		suppressed[suppression.API] = true
+	}
+	return suppressed
+}
+
+// printProjectDiagnostics prints the findings of a --scan run as a JSON list of LSP
+// publishDiagnostics params, leaving out suppressed APIs, and returns how many diagnostics it printed
+// at or above the threshold severity
+func printProjectDiagnostics(deprecationService *services.DeprecationService, result *models.ProjectScanResult, suppressions []models.Suppression, threshold string) int {
+	files, err := deprecationService.ProjectDiagnostics(result, "", suppressedAPIs(suppressions))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error building diagnostics: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error encoding diagnostics: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+
+	// LSP severities run from 1 for errors to 3 for information
+	failing := 0
+	for _, file := range files {
+		for _, diagnostic := range file.Diagnostics {
+			if diagnostic.Severity <= services.LSPSeverity(threshold) {
+				failing++
+			}
+		}
+	}
+	return failing
+}
+
+// registerTool registers a tool whose calls are recorded in the usage statistics and whose
+// structured results are declared to clients with the JSON schema of output
+func registerTool[T any](server *mcp_golang.Server, clientTransport *handlers.ClientTransport, stats services.StatsServiceInterface, name string, description string, handler func(context.Context, T) (*mcp_golang.ToolResponse, error), output any) {
+	if err := clientTransport.DeclareOutputSchema(name, output); err != nil {
+		panic(err)
+	}
+	if err := server.RegisterTool(name, description, handlers.Instrument(stats, name, handler)); err != nil {
+		panic(err)
+	}
 }
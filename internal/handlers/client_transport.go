@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/invopop/jsonschema"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// clientRequestIDBase keeps the ids of the requests the server sends apart from those of the client
+const clientRequestIDBase transport.RequestId = 1 << 40
+
+// outputSchemaReflector reflects the output schemas of the tools with the settings the server
+// reflects their input schemas with, so that nothing is required and null fields can be left out
+var outputSchemaReflector = jsonschema.Reflector{
+	Anonymous:                  true,
+	AllowAdditionalProperties:  true,
+	RequiredFromJSONSchemaTags: true,
+	DoNotReference:             true,
+	ExpandedStruct:             true,
+}
+
+// toolCallKey is the context key of the toolCall a tools/call request is handled in
+type toolCallKey struct{}
+
+// toolListKey is the context key that marks the handling of a tools/list request
+type toolListKey struct{}
+
+// toolCall carries the structured result a tool handler records for the response to its call, and
+// whether the tool declares an output schema, without which the call does not need one
+type toolCall struct {
+	tool       string
+	declared   bool
+	structured any
+}
+
+// ClientTransport wraps the transport of the MCP server to send sampling/createMessage,
+// elicitation/create and roots/list requests to the client, and to declare the output schemas and
+// structured results of the tools, which the server itself has no API for. It reads the
+// capabilities the client declares in its initialize request and takes the responses to its own
+// requests out of the message stream before the server sees them.
+type ClientTransport struct {
+	transport.Transport
+
+	mu          sync.Mutex
+	sampling    bool
+	elicitation bool
+	roots       bool
+	nextID      transport.RequestId
+	pending     map[transport.RequestId]chan *transport.BaseJsonRpcMessage
+
+	// rootDirs caches the roots of the client until it announces a change, which bumps rootsVersion
+	rootDirs     []string
+	rootsVersion int
+
+	// outputSchemas holds the JSON schema of the structured result of each tool that declares one
+	outputSchemas map[string]json.RawMessage
+}
+
+// NewClientTransport wraps inner so that requests can be sent to the client through it
+func NewClientTransport(inner transport.Transport) *ClientTransport {
+	return &ClientTransport{
+		Transport:     inner,
+		nextID:        clientRequestIDBase,
+		pending:       make(map[transport.RequestId]chan *transport.BaseJsonRpcMessage),
+		outputSchemas: make(map[string]json.RawMessage),
+	}
+}
+
+// SetMessageHandler installs handler for the messages that are not responses to the requests of
+// the server
+func (c *ClientTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	c.Transport.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+		var id transport.RequestId
+		switch message.Type {
+		case transport.BaseMessageTypeJSONRPCRequestType:
+			switch message.JsonRpcRequest.Method {
+			case "initialize":
+				c.readCapabilities(message.JsonRpcRequest.Params)
+			case "tools/list":
+				ctx = context.WithValue(ctx, toolListKey{}, true)
+			case "tools/call":
+				var call struct {
+					Name string `json:"name"`
+				}
+				json.Unmarshal(message.JsonRpcRequest.Params, &call)
+				c.mu.Lock()
+				_, declared := c.outputSchemas[call.Name]
+				c.mu.Unlock()
+				ctx = context.WithValue(ctx, toolCallKey{}, &toolCall{tool: call.Name, declared: declared})
+			}
+		case transport.BaseMessageTypeJSONRPCNotificationType:
+			if message.JsonRpcNotification.Method == "notifications/roots/list_changed" {
+				c.mu.Lock()
+				c.rootDirs = nil
+				c.rootsVersion++
+				c.mu.Unlock()
+			}
+		case transport.BaseMessageTypeJSONRPCResponseType:
+			id = message.JsonRpcResponse.Id
+		case transport.BaseMessageTypeJSONRPCErrorType:
+			id = message.JsonRpcError.Id
+		}
+		if id >= clientRequestIDBase {
+			c.mu.Lock()
+			waiting := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if waiting != nil {
+				waiting <- message
+				return
+			}
+		}
+		handler(ctx, message)
+	})
+}
+
+// Send sends message to the client. The responses to tools/list requests get the output schemas of
+// the tools, and those to tools/call requests the structured result their handler recorded.
+func (c *ClientTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	if message.Type == transport.BaseMessageTypeJSONRPCResponseType {
+		var result json.RawMessage
+		var err error
+		if call, ok := ctx.Value(toolCallKey{}).(*toolCall); ok {
+			result, err = c.addStructuredContent(message.JsonRpcResponse.Result, call)
+		} else if ctx.Value(toolListKey{}) != nil {
+			result, err = c.addOutputSchemas(message.JsonRpcResponse.Result)
+		}
+		if err != nil {
+			return err
+		}
+		if result != nil {
+			response := *message.JsonRpcResponse
+			response.Result = result
+			message = transport.NewBaseMessageResponse(&response)
+		}
+	}
+	return c.Transport.Send(ctx, message)
+}
+
+// DeclareOutputSchema reflects the JSON schema of output, a struct or a pointer to one, as the
+// schema of the structured results of tool. From then on a call of tool that records no structured
+// result, because it failed or was refused, is answered as an error.
+func (c *ClientTransport) DeclareOutputSchema(tool string, output any) error {
+	kind := reflect.TypeOf(output)
+	if kind != nil && kind.Kind() == reflect.Ptr {
+		kind = kind.Elem()
+	}
+	if kind == nil || kind.Kind() != reflect.Struct {
+		return fmt.Errorf("the output of %s is a %v instead of a struct", tool, kind)
+	}
+	data, err := json.Marshal(outputSchemaReflector.Reflect(output))
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.outputSchemas[tool] = data
+	c.mu.Unlock()
+	return nil
+}
+
+// addOutputSchemas adds the declared output schemas to the tools of a tools/list result
+func (c *ClientTransport) addOutputSchemas(result json.RawMessage) (json.RawMessage, error) {
+	var list map[string]json.RawMessage
+	var tools []map[string]json.RawMessage
+	if err := json.Unmarshal(result, &list); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(list["tools"], &tools); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	for _, tool := range tools {
+		var name string
+		json.Unmarshal(tool["name"], &name)
+		if schema, ok := c.outputSchemas[name]; ok {
+			tool["outputSchema"] = schema
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(tools)
+	if err != nil {
+		return nil, err
+	}
+	list["tools"] = data
+	return json.Marshal(list)
+}
+
+// addStructuredContent adds the structured result call recorded to its tools/call result, or marks
+// the result as an error when the tool declares an output schema but recorded none
+func (c *ClientTransport) addStructuredContent(result json.RawMessage, call *toolCall) (json.RawMessage, error) {
+	if !call.declared {
+		return nil, nil
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, err
+	}
+	if string(response["isError"]) == "true" {
+		return nil, nil
+	}
+	if call.structured == nil {
+		response["isError"] = json.RawMessage("true")
+		return json.Marshal(response)
+	}
+	structured, err := withoutNulls(call.structured)
+	if err != nil {
+		return nil, err
+	}
+	response["structuredContent"] = structured
+	return json.Marshal(response)
+}
+
+// withoutNulls encodes value as JSON with its null fields, such as empty slices and unset
+// pointers, left out, which the output schemas allow since they require no field
+func withoutNulls(value any) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(dropNulls(decoded))
+}
+
+// dropNulls removes the null fields of the objects in a decoded JSON value
+func dropNulls(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if field == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = dropNulls(field)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = dropNulls(item)
+		}
+	}
+	return value
+}
+
+// setStructuredContent records value as the structured result of the tool call ctx belongs to,
+// which the response carries next to its text. Outside a tools/call request, such as in the REST
+// API, it does nothing.
+func setStructuredContent(ctx context.Context, value any) {
+	if call, ok := ctx.Value(toolCallKey{}).(*toolCall); ok {
+		call.structured = value
+	}
+}
+
+// toolFailed tells whether the tool call ctx belongs to recorded no structured result although its
+// tool declares an output schema, so that its response is sent as an error
+func toolFailed(ctx context.Context) bool {
+	call, ok := ctx.Value(toolCallKey{}).(*toolCall)
+	return ok && call.declared && call.structured == nil
+}
+
+// readCapabilities records whether the client declares sampling, elicitation and roots support
+func (c *ClientTransport) readCapabilities(params json.RawMessage) {
+	var initialize struct {
+		Capabilities struct {
+			Sampling    *json.RawMessage `json:"sampling"`
+			Elicitation *json.RawMessage `json:"elicitation"`
+			Roots       *json.RawMessage `json:"roots"`
+		} `json:"capabilities"`
+	}
+	valid := json.Unmarshal(params, &initialize) == nil
+	c.mu.Lock()
+	c.sampling = valid && initialize.Capabilities.Sampling != nil
+	c.elicitation = valid && initialize.Capabilities.Elicitation != nil
+	c.roots = valid && initialize.Capabilities.Roots != nil
+	c.rootDirs = nil
+	c.rootsVersion++
+	c.mu.Unlock()
+}
+
+// SamplingSupported reports whether the connected client declared sampling support
+func (c *ClientTransport) SamplingSupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sampling
+}
+
+// ElicitationSupported reports whether the connected client declared elicitation support
+func (c *ClientTransport) ElicitationSupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.elicitation
+}
+
+// RootsSupported reports whether the connected client declared roots support
+func (c *ClientTransport) RootsSupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.roots
+}
+
+// Roots returns the local directories of the file:// roots the client exposes, in its order. The
+// list is kept until the client sends notifications/roots/list_changed.
+func (c *ClientTransport) Roots(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	supported, cached, version := c.roots, c.rootDirs, c.rootsVersion
+	c.mu.Unlock()
+	if !supported {
+		return nil, fmt.Errorf("the connected MCP client does not expose roots")
+	}
+	if cached != nil {
+		return append([]string(nil), cached...), nil
+	}
+
+	result, err := c.request(ctx, "roots/list", map[string]any{}, config.ROOTS_TIMEOUT)
+	if err != nil {
+		return nil, fmt.Errorf("roots request failed: %v", err)
+	}
+	var answer struct {
+		Roots []struct {
+			URI string `json:"uri"`
+		} `json:"roots"`
+	}
+	if err := json.Unmarshal(result, &answer); err != nil {
+		return nil, fmt.Errorf("invalid roots response: %v", err)
+	}
+	dirs := []string{}
+	for _, root := range answer.Roots {
+		link, err := url.Parse(root.URI)
+		if err != nil || link.Scheme != "file" || link.Path == "" {
+			continue
+		}
+		dirs = append(dirs, filepath.FromSlash(link.Path))
+	}
+
+	// A change announced while the client answered makes the answer stale, so it is not kept
+	c.mu.Lock()
+	if c.rootsVersion == version {
+		c.rootDirs = dirs
+	}
+	c.mu.Unlock()
+	return append([]string(nil), dirs...), nil
+}
+
+// CreateMessage asks the client to have its model answer prompt, and returns the text of the
+// answer and the model that wrote it. The client may show the request to the user, who can edit or
+// reject it, so the call waits up to config.SAMPLING_TIMEOUT.
+func (c *ClientTransport) CreateMessage(ctx context.Context, systemPrompt string, prompt string, maxTokens int) (string, string, error) {
+	if !c.SamplingSupported() {
+		return "", "", fmt.Errorf("the connected MCP client does not support sampling")
+	}
+	result, err := c.request(ctx, "sampling/createMessage", map[string]any{
+		"messages": []map[string]any{
+			{"role": "user", "content": map[string]string{"type": "text", "text": prompt}},
+		},
+		"systemPrompt": systemPrompt,
+		"maxTokens":    maxTokens,
+	}, config.SAMPLING_TIMEOUT)
+	if err != nil {
+		return "", "", fmt.Errorf("sampling request failed: %v", err)
+	}
+
+	var answer struct {
+		Model   string `json:"model"`
+		Content struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(result, &answer); err != nil {
+		return "", "", fmt.Errorf("invalid sampling response: %v", err)
+	}
+	if answer.Content.Type != "text" {
+		return "", "", fmt.Errorf("the client answered with %s content instead of text", answer.Content.Type)
+	}
+	return answer.Content.Text, answer.Model, nil
+}
+
+// Confirm asks the user, through the client, whether to go ahead with the change message
+// describes, and reports whether they accepted. Declining and dismissing the request both count as
+// a refusal. The call waits up to config.ELICITATION_TIMEOUT for the user to answer.
+func (c *ClientTransport) Confirm(ctx context.Context, message string) (bool, error) {
+	if !c.ElicitationSupported() {
+		return false, fmt.Errorf("the connected MCP client does not support elicitation")
+	}
+	result, err := c.request(ctx, "elicitation/create", map[string]any{
+		"message": message,
+		"requestedSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"confirm": map[string]any{
+					"type":        "boolean",
+					"title":       "Go ahead",
+					"description": "Apply the change described above",
+					"default":     true,
+				},
+			},
+			"required": []string{"confirm"},
+		},
+	}, config.ELICITATION_TIMEOUT)
+	if err != nil {
+		return false, fmt.Errorf("confirmation request failed: %v", err)
+	}
+
+	var answer struct {
+		Action  string `json:"action"`
+		Content struct {
+			Confirm *bool `json:"confirm"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(result, &answer); err != nil {
+		return false, fmt.Errorf("invalid elicitation response: %v", err)
+	}
+	return answer.Action == "accept" && (answer.Content.Confirm == nil || *answer.Content.Confirm), nil
+}
+
+// request sends method to the client and waits up to timeout for the result. When ctx ends first,
+// the client is told that the server no longer waits for it.
+func (c *ClientTransport) request(ctx context.Context, method string, params any, timeout time.Duration) (json.RawMessage, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	waiting := make(chan *transport.BaseJsonRpcMessage, 1)
+	c.pending[id] = waiting
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := c.Send(ctx, transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{
+		Id:      id,
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  data,
+	})); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.cancelRequest(id, ctx.Err())
+		return nil, fmt.Errorf("no answer: %v", ctx.Err())
+	case message := <-waiting:
+		if message.Type == transport.BaseMessageTypeJSONRPCErrorType {
+			return nil, fmt.Errorf("the client declined it: %s", message.JsonRpcError.Error.Message)
+		}
+		return message.JsonRpcResponse.Result, nil
+	}
+}
+
+// cancelRequest tells the client that the server no longer waits for the answer to request id
+func (c *ClientTransport) cancelRequest(id transport.RequestId, reason error) {
+	params, err := json.Marshal(map[string]any{"requestId": id, "reason": reason.Error()})
+	if err != nil {
+		return
+	}
+	c.Send(context.Background(), transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{
+		Jsonrpc: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  params,
+	}))
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/metoro-io/mcp-golang/transport"
+)
+
+// loopbackTransport hands the messages the server sends to reply, playing the client
+type loopbackTransport struct {
+	mu      sync.Mutex
+	handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)
+	sent    []*transport.BaseJsonRpcMessage
+	reply   func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage
+}
+
+func (l *loopbackTransport) Start(ctx context.Context) error     { return nil }
+func (l *loopbackTransport) Close() error                        { return nil }
+func (l *loopbackTransport) SetCloseHandler(handler func())      {}
+func (l *loopbackTransport) SetErrorHandler(handler func(error)) {}
+
+func (l *loopbackTransport) SetMessageHandler(handler func(ctx context.Context, message *transport.BaseJsonRpcMessage)) {
+	l.handler = handler
+}
+
+func (l *loopbackTransport) Send(ctx context.Context, message *transport.BaseJsonRpcMessage) error {
+	l.mu.Lock()
+	l.sent = append(l.sent, message)
+	l.mu.Unlock()
+	if message.Type == transport.BaseMessageTypeJSONRPCRequestType && l.reply != nil {
+		if answer := l.reply(message.JsonRpcRequest); answer != nil {
+			go l.handler(context.Background(), answer)
+		}
+	}
+	return nil
+}
+
+func TestClientTransport(t *testing.T) {
+	initialize := func(capabilities string) *transport.BaseJsonRpcMessage {
+		return transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{Id: 1, Jsonrpc: "2.0", Method: "initialize",
+			Params: json.RawMessage(`{"protocolVersion": "2024-11-05", "capabilities": ` + capabilities + `}`)})
+	}
+	newTransport := func(reply func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage) (*ClientTransport, *loopbackTransport, *[]*transport.BaseJsonRpcMessage) {
+		inner := &loopbackTransport{reply: reply}
+		sampling := NewClientTransport(inner)
+		var received []*transport.BaseJsonRpcMessage
+		sampling.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+			received = append(received, message)
+		})
+		return sampling, inner, &received
+	}
+
+	t.Run("Sends sampling requests and takes out their responses", func(t *testing.T) {
+		sampling, inner, received := newTransport(func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage {
+			if !strings.Contains(string(request.Params), `"systemPrompt":"Be brief"`) || !strings.Contains(string(request.Params), `"maxTokens":50`) {
+				t.Errorf("Unexpected sampling params %s", request.Params)
+			}
+			return transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Id: request.Id, Jsonrpc: "2.0",
+				Result: json.RawMessage(`{"role": "assistant", "content": {"type": "text", "text": "ColorScheme.surface"}, "model": "test-model"}`)})
+		})
+		inner.handler(context.Background(), initialize(`{"sampling": {}}`))
+		if !sampling.SamplingSupported() || len(*received) != 1 {
+			t.Fatalf("Expected the initialize request to pass through and declare sampling, got %v", *received)
+		}
+
+		text, model, err := sampling.CreateMessage(context.Background(), "Be brief", "What replaces ColorScheme.background?", 50)
+		if err != nil || text != "ColorScheme.surface" || model != "test-model" {
+			t.Errorf("Expected the answer of the client, got %q, %q, %v", text, model, err)
+		}
+		if len(*received) != 1 || inner.sent[0].JsonRpcRequest.Method != "sampling/createMessage" {
+			t.Errorf("Expected the response to be kept from the server, got %v", *received)
+		}
+	})
+
+	t.Run("Reports declined requests", func(t *testing.T) {
+		sampling, inner, _ := newTransport(func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage {
+			return transport.NewBaseMessageError(&transport.BaseJSONRPCError{Id: request.Id, Jsonrpc: "2.0",
+				Error: transport.BaseJSONRPCErrorInner{Code: -1, Message: "User rejected sampling request"}})
+		})
+		inner.handler(context.Background(), initialize(`{"sampling": {}}`))
+		if _, _, err := sampling.CreateMessage(context.Background(), "", "prompt", 50); err == nil || !strings.Contains(err.Error(), "User rejected") {
+			t.Errorf("Expected the rejection, got %v", err)
+		}
+	})
+
+	t.Run("Needs the sampling capability", func(t *testing.T) {
+		sampling, inner, _ := newTransport(nil)
+		inner.handler(context.Background(), initialize(`{"roots": {}}`))
+		if _, _, err := sampling.CreateMessage(context.Background(), "", "prompt", 50); sampling.SamplingSupported() || err == nil || len(inner.sent) != 0 {
+			t.Errorf("Expected no request without the sampling capability, got %v", err)
+		}
+	})
+
+	t.Run("Asks the user to confirm through elicitation", func(t *testing.T) {
+		action := "accept"
+		confirm := true
+		elicitation, inner, _ := newTransport(func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage {
+			if request.Method != "elicitation/create" || !strings.Contains(string(request.Params), `"message":"Clear the cache?"`) || !strings.Contains(string(request.Params), `"type":"boolean"`) {
+				t.Errorf("Unexpected elicitation request %s %s", request.Method, request.Params)
+			}
+			result, _ := json.Marshal(map[string]any{"action": action, "content": map[string]bool{"confirm": confirm}})
+			return transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Id: request.Id, Jsonrpc: "2.0", Result: result})
+		})
+		inner.handler(context.Background(), initialize(`{"elicitation": {}}`))
+		if !elicitation.ElicitationSupported() || elicitation.SamplingSupported() {
+			t.Fatal("Expected only the elicitation capability to be declared")
+		}
+
+		for _, answer := range []struct {
+			action   string
+			confirm  bool
+			expected bool
+		}{{"accept", true, true}, {"accept", false, false}, {"decline", true, false}, {"cancel", false, false}} {
+			action, confirm = answer.action, answer.confirm
+			if accepted, err := elicitation.Confirm(context.Background(), "Clear the cache?"); err != nil || accepted != answer.expected {
+				t.Errorf("Expected %s with confirm %v to give %v, got %v, %v", answer.action, answer.confirm, answer.expected, accepted, err)
+			}
+		}
+	})
+
+	t.Run("Needs the elicitation capability", func(t *testing.T) {
+		elicitation, inner, _ := newTransport(nil)
+		inner.handler(context.Background(), initialize(`{"sampling": {}}`))
+		if _, err := elicitation.Confirm(context.Background(), "Clear the cache?"); elicitation.ElicitationSupported() || err == nil || len(inner.sent) != 0 {
+			t.Errorf("Expected no request without the elicitation capability, got %v", err)
+		}
+	})
+
+	t.Run("Lists the roots until the client changes them", func(t *testing.T) {
+		uris := `[{"uri": "file:///work/app", "name": "app"}, {"uri": "https://example.com/repo"}]`
+		requests := 0
+		roots, inner, received := newTransport(func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage {
+			requests++
+			return transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Id: request.Id, Jsonrpc: "2.0",
+				Result: json.RawMessage(`{"roots": ` + uris + `}`)})
+		})
+		inner.handler(context.Background(), initialize(`{"roots": {"listChanged": true}}`))
+		if !roots.RootsSupported() {
+			t.Fatal("Expected the roots capability to be declared")
+		}
+
+		for i := 0; i < 2; i++ {
+			if dirs, err := roots.Roots(context.Background()); err != nil || strings.Join(dirs, ",") != filepath.FromSlash("/work/app") {
+				t.Errorf("Expected the file root only, got %v, %v", dirs, err)
+			}
+		}
+		if requests != 1 {
+			t.Errorf("Expected the roots to be listed once, got %d requests", requests)
+		}
+
+		uris = `[{"uri": "file:///work/other"}]`
+		inner.handler(context.Background(), transport.NewBaseMessageNotification(&transport.BaseJSONRPCNotification{Jsonrpc: "2.0", Method: "notifications/roots/list_changed"}))
+		if dirs, err := roots.Roots(context.Background()); err != nil || strings.Join(dirs, ",") != filepath.FromSlash("/work/other") || requests != 2 {
+			t.Errorf("Expected the changed roots to be listed again, got %v, %v", dirs, err)
+		}
+		if len(*received) != 2 {
+			t.Errorf("Expected the initialize request and the notification to reach the server, got %d messages", len(*received))
+		}
+	})
+
+	t.Run("Cancels requests the client does not answer", func(t *testing.T) {
+		sampling, inner, _ := newTransport(func(request *transport.BaseJSONRPCRequest) *transport.BaseJsonRpcMessage { return nil })
+		inner.handler(context.Background(), initialize(`{"sampling": {}}`))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, _, err := sampling.CreateMessage(ctx, "", "prompt", 50); err == nil {
+			t.Error("Expected an error for a cancelled request")
+		}
+		if last := inner.sent[len(inner.sent)-1]; last.Type != transport.BaseMessageTypeJSONRPCNotificationType || last.JsonRpcNotification.Method != "notifications/cancelled" {
+			t.Errorf("Expected the request to be cancelled, got %+v", last)
+		}
+	})
+
+	t.Run("Declares output schemas and adds structured results", func(t *testing.T) {
+		type status struct {
+			Name  string   `json:"name"`
+			Notes []string `json:"notes"`
+		}
+		inner := &loopbackTransport{}
+		tools := NewClientTransport(inner)
+		if err := tools.DeclareOutputSchema("status", status{}); err != nil {
+			t.Fatalf("Expected the schema to be declared, got %v", err)
+		}
+		if err := tools.DeclareOutputSchema("names", []string{}); err == nil {
+			t.Error("Expected a schema that is not an object to be rejected")
+		}
+		tools.SetMessageHandler(func(ctx context.Context, message *transport.BaseJsonRpcMessage) {
+			request := message.JsonRpcRequest
+			result := `{"tools": [{"name": "status", "inputSchema": {"type": "object"}}]}`
+			if request.Method == "tools/call" {
+				if strings.Contains(string(request.Params), `"succeed":true`) {
+					setStructuredContent(ctx, status{Name: "ok"})
+				}
+				result = `{"content": [{"type": "text", "text": "ok"}]}`
+			}
+			tools.Send(ctx, transport.NewBaseMessageResponse(&transport.BaseJSONRPCResponse{Id: request.Id, Jsonrpc: "2.0", Result: json.RawMessage(result)}))
+		})
+		send := func(method, params string) map[string]any {
+			inner.handler(context.Background(), transport.NewBaseMessageRequest(&transport.BaseJSONRPCRequest{Id: 2, Jsonrpc: "2.0", Method: method, Params: json.RawMessage(params)}))
+			var result map[string]any
+			if err := json.Unmarshal(inner.sent[len(inner.sent)-1].JsonRpcResponse.Result, &result); err != nil {
+				t.Fatalf("Expected a JSON result, got %v", err)
+			}
+			return result
+		}
+
+		listed := send("tools/list", `{}`)["tools"].([]any)[0].(map[string]any)
+		if schema, ok := listed["outputSchema"].(map[string]any); !ok || schema["type"] != "object" || schema["properties"].(map[string]any)["name"] == nil {
+			t.Errorf("Expected the output schema to be listed, got %v", listed)
+		}
+		called := send("tools/call", `{"name": "status", "arguments": {"succeed":true}}`)
+		if structured, ok := called["structuredContent"].(map[string]any); !ok || structured["name"] != "ok" || len(structured) != 1 || called["isError"] != nil {
+			t.Errorf("Expected the structured result without null fields, got %v", called)
+		}
+		failed := send("tools/call", `{"name": "status", "arguments": {"succeed": false}}`)
+		if failed["isError"] != true || failed["structuredContent"] != nil {
+			t.Errorf("Expected a call without a structured result to be an error, got %v", failed)
+		}
+	})
+}
@@ -1,11 +1,20 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/jger/mcp-flutter-deprecations-server/internal/i18n"
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
 	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 	mcp_golang "github.com/metoro-io/mcp-golang"
 )
 
@@ -14,99 +23,3641 @@ type MCPHandlers struct {
 	deprecationService services.DeprecationServiceInterface
 	versionInfoService services.VersionInfoServiceInterface
 	cacheService       services.CacheServiceInterface
+	cacheClear         services.CacheClearServiceInterface
+	cacheStatus        services.CacheStatusServiceInterface
+	statsService       services.StatsServiceInterface
+	rateLimits         services.RateLimitServiceInterface
+	upstreams          services.UpstreamCheckServiceInterface
+	guideService       services.MigrationGuideServiceInterface
+	projectScans       services.ProjectScanServiceInterface
+	dependencyAudits   services.DependencyAuditServiceInterface
+	diffScans          services.DiffScanServiceInterface
+	minimumVersion     services.MinimumVersionServiceInterface
+	sdkConstraints     services.SDKConstraintServiceInterface
+	pinRecommendations services.PinRecommendationServiceInterface
+	suppressions       services.SuppressionServiceInterface
+	teamSync           services.TeamSyncServiceInterface
+	cacheChanged       func()
+	dockerfiles        services.DockerfileServiceInterface
+	ciWorkflows        services.CIWorkflowServiceInterface
+	webChecks          services.WebCheckServiceInterface
+	runnerTemplates    services.RunnerTemplateServiceInterface
+	localSDKs          services.LocalSDKServiceInterface
+	whatsNew           services.WhatsNewServiceInterface
+	upgradeDigests     services.UpgradeDigestServiceInterface
+	replacementDrafts  services.ReplacementDraftServiceInterface
+	releaseHistory     services.ReleaseHistoryServiceInterface
+	repoScans          services.RepoScanServiceInterface
+	prReviews          services.PRReviewServiceInterface
+	sdkScans           services.SDKScanServiceInterface
+	confirmer          services.ConfirmerInterface
+	projectRoots       services.ProjectRootServiceInterface
+	printer            *i18n.Printer
+}
+
+// Option configures optional MCPHandlers dependencies
+type Option func(*MCPHandlers)
+
+// WithStatsService provides the statistics reported by the server_stats tool
+func WithStatsService(statsService services.StatsServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.statsService = statsService
+	}
+}
+
+// WithUpstreamCheckService provides the upstream probes of the check_upstreams tool
+func WithUpstreamCheckService(upstreams services.UpstreamCheckServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.upstreams = upstreams
+	}
+}
+
+// WithRateLimitService provides the GitHub API quota reported by the rate_limit_status tool
+func WithRateLimitService(rateLimits services.RateLimitServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.rateLimits = rateLimits
+	}
+}
+
+// WithMigrationGuideService provides the migration guides used by the explain_deprecation tool
+func WithMigrationGuideService(guideService services.MigrationGuideServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.guideService = guideService
+	}
+}
+
+// WithProjectScanService provides the project scanner used by the check_flutter_project tool
+func WithProjectScanService(projectScans services.ProjectScanServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.projectScans = projectScans
+	}
+}
+
+// WithDependencyAuditService provides the pub.dev advisory lookups of check_flutter_project's
+// audit_dependencies
+func WithDependencyAuditService(dependencyAudits services.DependencyAuditServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.dependencyAudits = dependencyAudits
+	}
+}
+
+// WithReplacementDraftService provides the sampling requests of the draft_replacements tool
+func WithReplacementDraftService(replacementDrafts services.ReplacementDraftServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.replacementDrafts = replacementDrafts
+	}
+}
+
+// WithDiffScanService provides the change scanner used by the check_changed_lines tool
+func WithDiffScanService(diffScans services.DiffScanServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.diffScans = diffScans
+	}
+}
+
+// WithMinimumVersionService provides the API version data used by the infer_minimum_flutter_version tool
+func WithMinimumVersionService(minimumVersion services.MinimumVersionServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.minimumVersion = minimumVersion
+	}
+}
+
+// WithSDKConstraintService provides the pubspec.yaml edits of the suggest_sdk_constraints tool
+func WithSDKConstraintService(sdkConstraints services.SDKConstraintServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.sdkConstraints = sdkConstraints
+	}
+}
+
+// WithPinRecommendationService provides the dependency checks of the recommend_flutter_pin tool
+func WithPinRecommendationService(pinRecommendations services.PinRecommendationServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.pinRecommendations = pinRecommendations
+	}
+}
+
+// WithSuppressionService provides the acknowledged deprecations hidden from check and list output
+func WithSuppressionService(suppressions services.SuppressionServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.suppressions = suppressions
+	}
+}
+
+// WithTeamSyncService shares manual entries and machine-wide suppressions through a team database
+func WithTeamSyncService(teamSync services.TeamSyncServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.teamSync = teamSync
+	}
+}
+
+// WithDockerfileService provides the Dockerfile templates used by the generate_dockerfile tool
+func WithDockerfileService(dockerfiles services.DockerfileServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.dockerfiles = dockerfiles
+	}
+}
+
+// WithCIWorkflowService provides the CI configuration checks used by the check_ci_workflow tool
+func WithCIWorkflowService(ciWorkflows services.CIWorkflowServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.ciWorkflows = ciWorkflows
+	}
+}
+
+// WithWebCheckService provides the web setup checks used by the check_flutter_web tool
+func WithWebCheckService(webChecks services.WebCheckServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.webChecks = webChecks
+	}
+}
+
+// WithRunnerTemplateService provides the template comparison used by the check_desktop_runners tool
+func WithRunnerTemplateService(runnerTemplates services.RunnerTemplateServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.runnerTemplates = runnerTemplates
+	}
+}
+
+// WithLocalSDKService provides the SDK detection used by the list_flutter_sdks tool
+func WithLocalSDKService(localSDKs services.LocalSDKServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.localSDKs = localSDKs
+	}
+}
+
+// WithWhatsNewService provides the release digests used by the whats_new_in_flutter tool
+func WithWhatsNewService(whatsNew services.WhatsNewServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.whatsNew = whatsNew
+	}
+}
+
+// WithUpgradeDigestService provides the consolidated release notes used by the upgrade_digest tool
+func WithUpgradeDigestService(upgradeDigests services.UpgradeDigestServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.upgradeDigests = upgradeDigests
+	}
+}
+
+// WithReleaseHistoryService provides the stable releases listed by the list_flutter_releases tool
+func WithReleaseHistoryService(releaseHistory services.ReleaseHistoryServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.releaseHistory = releaseHistory
+	}
+}
+
+// WithRepoScanService provides the GitHub scanner used by the scan_repo_deprecations tool
+func WithRepoScanService(repoScans services.RepoScanServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.repoScans = repoScans
+	}
+}
+
+// WithPRReviewService provides the pull request reviewer used by the review_pull_request tool
+func WithPRReviewService(prReviews services.PRReviewServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.prReviews = prReviews
+	}
+}
+
+// WithSDKScanService provides the per-version deprecations used by the compare_flutter_versions
+// and deprecations_introduced_in tools
+func WithSDKScanService(sdkScans services.SDKScanServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.sdkScans = sdkScans
+	}
+}
+
+// WithCacheClearService provides the cache reset of the clear_flutter_deprecations_cache tool
+func WithCacheClearService(cacheClear services.CacheClearServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.cacheClear = cacheClear
+	}
+}
+
+// WithCacheStatusService provides the cache file details of the cache_status tool
+func WithCacheStatusService(cacheStatus services.CacheStatusServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.cacheStatus = cacheStatus
+	}
+}
+
+// WithCacheChangedNotifier provides a callback run after a tool refreshes the deprecations cache
+func WithCacheChangedNotifier(notify func()) Option {
+	return func(h *MCPHandlers) {
+		h.cacheChanged = notify
+	}
+}
+
+// WithConfirmer provides the elicitation requests that ask the user to confirm the tools that
+// change local state
+func WithConfirmer(confirmer services.ConfirmerInterface) Option {
+	return func(h *MCPHandlers) {
+		h.confirmer = confirmer
+	}
+}
+
+// WithProjectRootService provides the project under the roots of the client that project scan and
+// pubspec tools default to
+func WithProjectRootService(projectRoots services.ProjectRootServiceInterface) Option {
+	return func(h *MCPHandlers) {
+		h.projectRoots = projectRoots
+	}
+}
+
+// WithPrinter sets the language of the reports whose call does not choose one (default: English)
+func WithPrinter(printer *i18n.Printer) Option {
+	return func(h *MCPHandlers) {
+		h.printer = printer
+	}
+}
+
+// Instrument wraps a tool handler so every call is timed and counted under the tool's name. As the
+// handlers answer failures with an explanation rather than an error, a call that records no
+// structured result, and so is answered as an error, counts as failed as well.
+func Instrument[T any](stats services.StatsServiceInterface, tool string, handler func(context.Context, T) (*mcp_golang.ToolResponse, error)) func(context.Context, T) (*mcp_golang.ToolResponse, error) {
+	if stats == nil {
+		return handler
+	}
+	return func(ctx context.Context, args T) (*mcp_golang.ToolResponse, error) {
+		start := time.Now()
+		response, err := handler(ctx, args)
+		stats.RecordTool(tool, time.Since(start), err != nil || toolFailed(ctx))
+		return response, err
+	}
+}
+
+// bufferPool recycles the buffers used to render tool responses
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool, dropping oversized ones so they can be collected
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 1<<20 {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// writeDeprecations renders a numbered markdown list of deprecations in the language of p
+func writeDeprecations(buf *bytes.Buffer, p *i18n.Printer, deprecations []models.Deprecation) {
+	for i, dep := range deprecations {
+		fmt.Fprintf(buf, "%d. **%s**\n", i+1, dep.API)
+		if dep.Replacement != "" {
+			p.Fprintf(buf, "   - Replacement: %s%s\n", dep.Replacement, draftedNote(p, dep))
+		}
+		p.Fprintf(buf, "   - Description: %s\n", p.Description(dep))
+		if dep.Package != "" {
+			p.Fprintf(buf, "   - Package: %s\n", dep.Package)
+		}
+		if dep.Example != "" {
+			p.Fprintf(buf, "   - Example: %s\n", dep.Example)
+		}
+		if dep.Version != "" {
+			p.Fprintf(buf, "   - Since version: %s\n", dep.Version)
+		}
+		if age := p.Age(dep); age != "" {
+			p.Fprintf(buf, "   - Age: %s\n", age)
+		}
+		if dep.Compatible != "" {
+			p.Fprintf(buf, "   - Also supporting releases before %s: %s\n", dep.Version, dep.Compatible)
+		}
+		if dep.Removed || dep.Upcoming {
+			p.Fprintf(buf, "   - Status: %s\n", describeStatus(p, dep))
+		}
+		p.Fprintf(buf, "   - Documentation: %s\n", services.DocumentationURL(dep))
+		buf.WriteString("\n")
+	}
+}
+
+// draftedNote flags a replacement drafted by the client model instead of read from the annotation
+func draftedNote(p *i18n.Printer, dep models.Deprecation) string {
+	if dep.ReplacementDraftedBy == "" {
+		return ""
+	}
+	return p.Sprintf(" (drafted by %s, unverified)", dep.ReplacementDraftedBy)
+}
+
+// writeSuppressed lists the suppressed deprecations when requested, or notes how many were hidden
+func writeSuppressed(buf *bytes.Buffer, budget *resultBudget, suppressed []models.Deprecation, include bool) {
+	if len(suppressed) == 0 {
+		return
+	}
+	if include {
+		budget.p.Fprintf(buf, "## Suppressed\n\n")
+		budget.writeDeprecations(buf, suppressed)
+		return
+	}
+	budget.p.Fprintf(buf, "%d suppressed deprecation(s) hidden; pass include_suppressed: true to show them.\n", len(suppressed))
+}
+
+// resultBudget caps how many findings a response lists across all of its sections, so that a
+// large cache or project stays within the caller's context. Findings beyond the budget are only
+// counted: each section notes how many it left out and writeFooter sums them up. The sections are
+// written in the language of p.
+type resultBudget struct {
+	remaining int
+	summary   bool
+	listed    int
+	omitted   int
+	p         *i18n.Printer
+}
+
+// newResultBudget creates the budget for the max_results and summary_only arguments of a call
+// whose report is written by p
+func newResultBudget(limits models.ResultLimits, p *i18n.Printer) *resultBudget {
+	budget := &resultBudget{remaining: limits.MaxResults, summary: limits.SummaryOnly, p: p}
+	if budget.remaining <= 0 {
+		budget.remaining = config.DEFAULT_MAX_RESULTS
+	}
+	if budget.summary {
+		budget.remaining = 0
+	}
+	return budget
+}
+
+// take returns how many of a section's n findings fit in the budget and counts the rest as omitted
+func (b *resultBudget) take(n int) int {
+	shown := min(n, b.remaining)
+	b.remaining -= shown
+	b.listed += shown
+	b.omitted += n - shown
+	return shown
+}
+
+// writeOmitted notes the findings of a section that did not fit in the budget
+func (b *resultBudget) writeOmitted(buf *bytes.Buffer, shown int, total int) {
+	if shown == total {
+		return
+	}
+	if b.summary {
+		b.p.Fprintf(buf, "%d findings not listed (summary_only).\n\n", total)
+		return
+	}
+	b.p.Fprintf(buf, "%d more findings omitted (showing %d of %d).\n\n", total-shown, shown, total)
+}
+
+// writeDeprecations lists the deprecations that fit in the budget
+func (b *resultBudget) writeDeprecations(buf *bytes.Buffer, deprecations []models.Deprecation) {
+	shown := b.take(len(deprecations))
+	writeDeprecations(buf, b.p, deprecations[:shown])
+	b.writeOmitted(buf, shown, len(deprecations))
+}
+
+// writeFooter totals the findings left out of the response and tells how to get them
+func (b *resultBudget) writeFooter(buf *bytes.Buffer) {
+	switch {
+	case b.omitted == 0:
+	case b.summary:
+		b.p.Fprintf(buf, "\nSummary only: %d findings counted, none listed. Call again without summary_only to list them.\n", b.omitted)
+	default:
+		b.p.Fprintf(buf, "\n%d more findings omitted (showing %d of %d). Raise max_results (default %d) to list more, or pass summary_only: true for the counts only.\n",
+			b.omitted, b.listed, b.listed+b.omitted, config.DEFAULT_MAX_RESULTS)
+	}
+}
+
+// checkCode runs the Flutter or the Dart checks on code, as selected by a target argument
+func (h *MCPHandlers) checkCode(code string, target string) ([]models.Deprecation, error) {
+	target, err := services.ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if target == config.TARGET_DART {
+		return h.deprecationService.CheckDartCode(code), nil
+	}
+	return h.deprecationService.CheckCodeForDeprecations(code), nil
+}
+
+// splitSuppressed separates the deprecations suppressed on this machine or in the project from the rest
+func (h *MCPHandlers) splitSuppressed(deprecations []models.Deprecation, projectPath string) ([]models.Deprecation, []models.Deprecation, error) {
+	if h.suppressions == nil {
+		return deprecations, nil, nil
+	}
+	suppressions, err := h.suppressions.Suppressions(projectPath)
+	if err != nil || len(suppressions) == 0 {
+		return deprecations, nil, err
+	}
+
+	suppressedAPIs := make(map[string]bool, len(suppressions))
+	for _, suppression := range suppressions {
+		suppressedAPIs[suppression.API] = true
+	}
+
+	var active, suppressed []models.Deprecation
+	for _, dep := range deprecations {
+		if suppressedAPIs[dep.API] {
+			suppressed = append(suppressed, dep)
+		} else {
+			active = append(active, dep)
+		}
+	}
+	return active, suppressed, nil
+}
+
+// shareWithTeam applies a change to the manual entries or machine-wide suppressions between pulling
+// from and pushing to the team database, when one is configured. It returns the change's own error
+// and a note about the sync for the tool response.
+func (h *MCPHandlers) shareWithTeam(ctx context.Context, change func() error) (string, error) {
+	if h.teamSync == nil {
+		return "", change()
+	}
+
+	if _, err := h.teamSync.Pull(ctx); err != nil {
+		if changeErr := change(); changeErr != nil {
+			return "", changeErr
+		}
+		return fmt.Sprintf("\n\nWarning: the team database could not be reached (%v); the change is saved locally only and the next sync will replace it.", err), nil
+	}
+	if err := change(); err != nil {
+		return "", err
+	}
+	if err := h.teamSync.Push(ctx); err != nil {
+		return fmt.Sprintf("\n\nWarning: the change is saved locally but could not be shared with the team database: %v", err), nil
+	}
+	return "\n\nShared with the team database.", nil
+}
+
+// confirmChange asks the user to confirm the change summary describes when the client supports
+// elicitation. It reports whether the user was asked and whether they accepted.
+func (h *MCPHandlers) confirmChange(ctx context.Context, summary string) (bool, bool, error) {
+	if h.confirmer == nil || !h.confirmer.ElicitationSupported() {
+		return false, false, nil
+	}
+	accepted, err := h.confirmer.Confirm(ctx, summary)
+	return true, accepted, err
+}
+
+// projectPath returns projectPath or, when it is empty, the first Flutter project under the roots
+// of the client. Without a project root service an empty path stays empty; the error says why no
+// project was found under the roots.
+func (h *MCPHandlers) projectPath(ctx context.Context, projectPath string) (string, error) {
+	projectPath = strings.TrimSpace(projectPath)
+	if projectPath != "" || h.projectRoots == nil {
+		return projectPath, nil
+	}
+	return h.projectRoots.ActiveProject(ctx)
+}
+
+// reportPrinter returns the printer of a report's language argument, or the server's default
+// when the call does not choose one
+func (h *MCPHandlers) reportPrinter(args models.ReportLanguage) (*i18n.Printer, error) {
+	if args.Language != "" {
+		return i18n.NewPrinter(args.Language)
+	}
+	if h.printer != nil {
+		return h.printer, nil
+	}
+	return i18n.English, nil
+}
+
+// requireProjectPath is projectPath for the tools that cannot run without a project
+func (h *MCPHandlers) requireProjectPath(ctx context.Context, projectPath string) (string, error) {
+	path, err := h.projectPath(ctx, projectPath)
+	if err != nil {
+		return "", fmt.Errorf("project_path is required (%v)", err)
+	}
+	if path == "" {
+		return "", fmt.Errorf("project_path is required")
+	}
+	return path, nil
+}
+
+// notifyCacheChanged runs the cache changed callback when one is configured
+func (h *MCPHandlers) notifyCacheChanged() {
+	if h.cacheChanged != nil {
+		h.cacheChanged()
+	}
 }
 
 // NewMCPHandlers creates a new MCP handlers instance
-func NewMCPHandlers(deprecationService services.DeprecationServiceInterface, versionInfoService services.VersionInfoServiceInterface, cacheService services.CacheServiceInterface) *MCPHandlers {
-	return &MCPHandlers{
+func NewMCPHandlers(deprecationService services.DeprecationServiceInterface, versionInfoService services.VersionInfoServiceInterface, cacheService services.CacheServiceInterface, options ...Option) *MCPHandlers {
+	h := &MCPHandlers{
 		deprecationService: deprecationService,
 		versionInfoService: versionInfoService,
 		cacheService:       cacheService,
 	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// CheckFlutterDeprecations handles the check_flutter_deprecations tool
+func (h *MCPHandlers) CheckFlutterDeprecations(ctx context.Context, args models.CheckDeprecationsArgs) (*mcp_golang.ToolResponse, error) {
+	p, err := h.reportPrinter(args.ReportLanguage)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error choosing the report language: %v", err)),
+		), nil
+	}
+	code, err := services.DecodeCode(args.Code, args.Encoding)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error decoding code: %v", err)),
+		), nil
+	}
+	args.Code = code
+
+	found, err := h.checkCode(args.Code, args.Target)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking code: %v", err)),
+		), nil
+	}
+	deprecations, suppressed, err := h.splitSuppressed(found, args.ProjectPath)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+		), nil
+	}
+	deprecations = services.ByUrgency(deprecations)
+
+	response := models.CheckResponse{Deprecations: nonNil(deprecations), SuppressedCount: len(suppressed)}
+	if args.IncludeSuppressed {
+		response.Suppressed = suppressed
+	}
+	setStructuredContent(ctx, response)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	budget := newResultBudget(args.ResultLimits, p)
+	if len(deprecations) == 0 && (!args.IncludeSuppressed || len(suppressed) == 0) {
+		p.Fprintf(buf, "No deprecated APIs found in the provided code.")
+		if len(suppressed) > 0 {
+			buf.WriteString("\n\n")
+			writeSuppressed(buf, budget, suppressed, false)
+		}
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	p.Fprintf(buf, "Found deprecated APIs:\n\n")
+	shown := budget.take(len(deprecations))
+	writeDeprecations(buf, p, deprecations[:shown])
+	budget.writeOmitted(buf, shown, len(deprecations))
+
+	// Preview the mechanical fixes on the user's own lines rather than the canned examples, for
+	// the deprecations that are listed
+	reported := make(map[string]bool)
+	for _, dep := range deprecations[:shown] {
+		reported[dep.API] = true
+	}
+	if args.IncludeSuppressed {
+		for _, dep := range suppressed[:min(len(suppressed), budget.remaining)] {
+			reported[dep.API] = true
+		}
+	}
+	var previews []models.FixPreview
+	for _, preview := range h.deprecationService.MigrateCode(args.Code).Previews {
+		for _, api := range preview.APIs {
+			if reported[api] {
+				previews = append(previews, preview)
+				break
+			}
+		}
+	}
+	if len(previews) > 0 {
+		p.Fprintf(buf, "## Suggested fixes (apply them with migrate_code)\n\n")
+		writeFixPreviews(buf, previews)
+	}
+
+	writeSuppressed(buf, budget, suppressed, args.IncludeSuppressed)
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CheckFlutterFiles handles the check_flutter_files tool
+func (h *MCPHandlers) CheckFlutterFiles(ctx context.Context, args models.CheckFilesArgs) (*mcp_golang.ToolResponse, error) {
+	p, err := h.reportPrinter(args.ReportLanguage)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error choosing the report language: %v", err)),
+		), nil
+	}
+	results, err := h.deprecationService.CheckFiles(ctx, args.Files, args.ProjectPath, args.Target)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking files: %v", err)),
+		), nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	body := getBuffer()
+	defer putBuffer(body)
+
+	budget := newResultBudget(args.ResultLimits, p)
+	affected, failed := 0, 0
+	checked := models.CheckFilesResult{Files: make([]models.FileCheckResult, len(results))}
+	for i, result := range results {
+		checked.Files[i] = result
+		if result.Error != "" {
+			failed++
+			fmt.Fprintf(body, "## %s\n\n", result.Name)
+			p.Fprintf(body, "Error reading entry: %s\n\n", result.Error)
+			continue
+		}
+
+		deprecations, suppressed, err := h.splitSuppressed(result.Deprecations, args.ProjectPath)
+		if err != nil {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+			), nil
+		}
+		if args.IncludeSuppressed {
+			deprecations = append(deprecations, suppressed...)
+			suppressed = nil
+		}
+		deprecations = services.ByUrgency(deprecations)
+		checked.Files[i].Deprecations = nonNil(deprecations)
+		if len(deprecations) > 0 {
+			affected++
+		}
+
+		fmt.Fprintf(body, "## %s (%d)\n\n", result.Name, len(deprecations))
+		if len(deprecations) == 0 {
+			p.Fprintf(body, "No deprecated APIs found.\n\n")
+		}
+		budget.writeDeprecations(body, deprecations)
+		if len(suppressed) > 0 {
+			writeSuppressed(body, budget, suppressed, false)
+			body.WriteString("\n")
+		}
+	}
+
+	p.Fprintf(buf, "Checked %d entries: %d with deprecated APIs, %d could not be read\n\n", len(results), affected, failed)
+	buf.Write(body.Bytes())
+	budget.writeFooter(buf)
+	setStructuredContent(ctx, checked)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(strings.TrimRight(buf.String(), "\n") + "\n"),
+	), nil
+}
+
+// CheckFlutterProject handles the check_flutter_project tool
+func (h *MCPHandlers) CheckFlutterProject(ctx context.Context, args models.CheckProjectArgs) (*mcp_golang.ToolResponse, error) {
+	if h.projectScans == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Project scans are not enabled on this server."),
+		), nil
+	}
+
+	p, err := h.reportPrinter(args.ReportLanguage)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error choosing the report language: %v", err)),
+		), nil
+	}
+	format, err := services.ParseOutputFormat(args.Format)
+	if err == nil {
+		args.GroupBy, err = services.ParseGroupBy(args.GroupBy)
+	}
+	if err == nil {
+		args.ProjectPath, err = h.requireProjectPath(ctx, args.ProjectPath)
+	}
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error scanning project: %v", err)),
+		), nil
+	}
+
+	scan := h.projectScans.Scan
+	if args.Incremental {
+		scan = h.projectScans.ScanChanged
+	}
+	result, err := scan(ctx, args.ProjectPath, args.Target, args.PathFilter)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error scanning project: %v", err)),
+		), nil
+	}
+	if args.Baseline != "" {
+		path := args.Baseline
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(args.ProjectPath, path)
+		}
+		baseline, err := services.LoadFindingsBaseline(path)
+		if err == nil {
+			err = h.deprecationService.ApplyFindingsBaseline(result, baseline, args.Target)
+		}
+		if err != nil {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Error loading baseline: %v", err)),
+			), nil
+		}
+	}
+
+	var audit *models.DependencyAudit
+	var auditErr error
+	if args.AuditDependencies && format != config.OUTPUT_FORMAT_LSP {
+		if h.dependencyAudits == nil {
+			auditErr = fmt.Errorf("dependency audits are not enabled on this server")
+		} else {
+			audit, auditErr = h.dependencyAudits.Audit(ctx, result.ProjectPath)
+		}
+	}
+
+	// Every format carries the JSON report as the structured result
+	hidden, err := h.hiddenAPIs(result, args)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+		), nil
+	}
+	report := services.ProjectScanReport(result, hidden, config.SEVERITY_INFO)
+	report.Audit = audit
+
+	switch format {
+	case config.OUTPUT_FORMAT_LSP:
+		return h.projectDiagnostics(ctx, result, args.Target, hidden, report)
+	case config.OUTPUT_FORMAT_JSON:
+		return h.projectReport(ctx, report, auditErr)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	body := getBuffer()
+	defer putBuffer(body)
+
+	type packageTotals struct{ files, affected, deprecations, suppressed, failed int }
+	totals := make([]packageTotals, len(result.Packages))
+	var workspace packageTotals
+	// The findings of the other groupings, with the files that could not be read
+	var grouped []models.ProjectFinding
+	unreadable := getBuffer()
+	defer putBuffer(unreadable)
+
+	budget := newResultBudget(args.ResultLimits, p)
+	for i, pkg := range result.Packages {
+		counts := &totals[i]
+		counts.files = pkg.Files
+		section := getBuffer()
+		for _, finding := range pkg.Findings {
+			if finding.Error != "" {
+				counts.failed++
+				fmt.Fprintf(section, "### %s\n\n", finding.Name)
+				p.Fprintf(section, "Error reading file: %s\n\n", finding.Error)
+				fmt.Fprintf(unreadable, "- %s: %s\n", finding.Name, finding.Error)
+				continue
+			}
+
+			deprecations, suppressed, err := h.splitSuppressed(finding.Deprecations, result.ProjectPath)
+			if err != nil {
+				putBuffer(section)
+				return mcp_golang.NewToolResponse(
+					mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+				), nil
+			}
+			if args.IncludeSuppressed {
+				deprecations = append(deprecations, suppressed...)
+				suppressed = nil
+			}
+			counts.suppressed += len(suppressed)
+			if len(deprecations) == 0 {
+				continue
+			}
+			counts.affected++
+			counts.deprecations += len(deprecations)
+			if args.GroupBy != config.GROUP_BY_PACKAGE {
+				for _, dep := range services.ByUrgency(deprecations) {
+					grouped = append(grouped, models.ProjectFinding{Package: pkg.Name, File: finding.Name, Deprecation: dep})
+				}
+				continue
+			}
+			fmt.Fprintf(section, "### %s (%d)\n\n", finding.Name, len(deprecations))
+			budget.writeDeprecations(section, services.ByUrgency(deprecations))
+		}
+
+		workspace.files += counts.files
+		workspace.affected += counts.affected
+		workspace.deprecations += counts.deprecations
+		workspace.failed += counts.failed
+		workspace.suppressed += counts.suppressed
+		if args.GroupBy != config.GROUP_BY_PACKAGE {
+			putBuffer(section)
+			continue
+		}
+
+		p.Fprintf(body, "## %s (%s): %d deprecations in %d of %d Dart files\n\n", pkg.Name, pkg.Path, counts.deprecations, counts.affected, counts.files)
+		body.Write(section.Bytes())
+		putBuffer(section)
+		if counts.failed > 0 {
+			p.Fprintf(body, "%d file(s) could not be read.\n\n", counts.failed)
+		}
+		if counts.suppressed > 0 {
+			p.Fprintf(body, "%d suppressed deprecation(s) hidden; pass include_suppressed: true to show them.\n\n", counts.suppressed)
+		}
+	}
+	if args.GroupBy != config.GROUP_BY_PACKAGE {
+		writeFindingGroups(body, budget, services.GroupFindings(grouped, args.GroupBy), args.GroupBy)
+		if unreadable.Len() > 0 {
+			p.Fprintf(body, "## Files that could not be read (%d)\n\n", workspace.failed)
+			fmt.Fprintf(body, "%s\n", unreadable.String())
+		}
+		if workspace.suppressed > 0 {
+			p.Fprintf(body, "%d suppressed deprecation(s) hidden; pass include_suppressed: true to show them.\n\n", workspace.suppressed)
+		}
+	}
+
+	p.Fprintf(buf, "Scanned %s", result.ProjectPath)
+	switch result.Workspace {
+	case config.WORKSPACE_PACKAGES:
+		p.Fprintf(buf, " (packages directory)")
+	case "":
+	default:
+		p.Fprintf(buf, " (%s workspace)", result.Workspace)
+	}
+	p.Fprintf(buf, ": %d package(s), %d Dart files, %d deprecations in %d files\n\n", len(result.Packages), workspace.files, workspace.deprecations, workspace.affected)
+	if len(args.Include) > 0 || len(args.Exclude) > 0 {
+		p.Fprintf(buf, "Scope: include %s; exclude %s\n\n", globList(args.Include, p.Sprintf("every Dart file")), globList(args.Exclude, p.Sprintf("nothing")))
+	}
+	switch {
+	case result.FullScanReason != "":
+		p.Fprintf(buf, "Checked every file instead of only the changed ones: %s.\n\n", result.FullScanReason)
+	case result.BaseCommit != "":
+		p.Fprintf(buf, "Incremental scan: %d changed file(s) checked again; the others reuse the full scan at %s.\n\n", result.Changed, services.ShortCommit(result.BaseCommit))
+	}
+	if result.Cached > 0 {
+		p.Fprintf(buf, "%d unchanged files reused the results of an earlier scan.\n\n", result.Cached)
+	}
+	if result.Baselined > 0 {
+		p.Fprintf(buf, "%d findings recorded in the baseline %s are left out.\n\n", result.Baselined, args.Baseline)
+	}
+	buf.Write(body.Bytes())
+
+	if len(result.Packages) > 1 {
+		p.Fprintf(buf, "## Workspace totals\n\n")
+		p.Fprintf(buf, "| Package | Path | Dart files | Files with deprecations | Deprecations |\n")
+		buf.WriteString("|---|---|---|---|---|\n")
+		for i, pkg := range result.Packages {
+			fmt.Fprintf(buf, "| %s | %s | %d | %d | %d |\n", pkg.Name, pkg.Path, totals[i].files, totals[i].affected, totals[i].deprecations)
+		}
+		fmt.Fprintf(buf, "| **%s** | | %d | %d | %d |\n", p.Sprintf("Total"), workspace.files, workspace.affected, workspace.deprecations)
+	}
+	if args.AuditDependencies {
+		writeDependencyAudit(buf, budget, audit, auditErr)
+	}
+	budget.writeFooter(buf)
+	setStructuredContent(ctx, report)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(strings.TrimRight(buf.String(), "\n") + "\n"),
+	), nil
+}
+
+// writeFindingGroups lists the findings of a project scan grouped by file, deprecated API or
+// severity, as far as they fit in the budget
+func writeFindingGroups(buf *bytes.Buffer, budget *resultBudget, groups []models.FindingGroup, groupBy string) {
+	for _, group := range groups {
+		switch groupBy {
+		case config.GROUP_BY_FILE:
+			deprecations := make([]models.Deprecation, len(group.Findings))
+			for i, finding := range group.Findings {
+				deprecations[i] = finding.Deprecation
+			}
+			fmt.Fprintf(buf, "## %s (%d)\n\n", group.Name, len(deprecations))
+			budget.writeDeprecations(buf, deprecations)
+			continue
+		case config.GROUP_BY_RULE:
+			dep := group.Findings[0].Deprecation
+			budget.p.Fprintf(buf, "## %s: used in %d file(s)\n\n", group.Name, len(group.Findings))
+			if dep.Replacement != "" {
+				budget.p.Fprintf(buf, "Replacement: %s\n", dep.Replacement)
+			}
+			if age := budget.p.Age(dep); age != "" {
+				budget.p.Fprintf(buf, "Age: %s\n", age)
+			}
+			budget.p.Fprintf(buf, "Documentation: %s\n\n", services.DocumentationURL(dep))
+		default:
+			fmt.Fprintf(buf, "## %s (%d)\n\n", group.Name, len(group.Findings))
+		}
+
+		shown := budget.take(len(group.Findings))
+		for _, finding := range group.Findings[:shown] {
+			if groupBy == config.GROUP_BY_RULE {
+				fmt.Fprintf(buf, "- %s\n", finding.File)
+				continue
+			}
+			fmt.Fprintf(buf, "- %s: **%s**", finding.File, finding.Deprecation.API)
+			if finding.Deprecation.Replacement != "" {
+				fmt.Fprintf(buf, " → %s", finding.Deprecation.Replacement)
+			}
+			buf.WriteString("\n")
+		}
+		if shown > 0 {
+			buf.WriteString("\n")
+		}
+		budget.writeOmitted(buf, shown, len(group.Findings))
+	}
+}
+
+// hiddenAPIs returns the suppressed APIs a check_flutter_project scan found, which its reports
+// leave out unless they are asked for
+func (h *MCPHandlers) hiddenAPIs(result *models.ProjectScanResult, args models.CheckProjectArgs) (map[string]bool, error) {
+	hidden := make(map[string]bool)
+	if args.IncludeSuppressed {
+		return hidden, nil
+	}
+	var found []models.Deprecation
+	for _, pkg := range result.Packages {
+		for _, finding := range pkg.Findings {
+			found = append(found, finding.Deprecations...)
+		}
+	}
+	_, suppressed, err := h.splitSuppressed(found, result.ProjectPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range suppressed {
+		hidden[dep.API] = true
+	}
+	return hidden, nil
+}
+
+// projectReport renders the report of a check_flutter_project scan as the JSON report of --scan
+// --format json, in which every finding fails the scan
+func (h *MCPHandlers) projectReport(ctx context.Context, report *models.ScanReport, auditErr error) (*mcp_golang.ToolResponse, error) {
+	if auditErr != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error auditing dependencies: %v", auditErr)),
+		), nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error encoding report: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, report)
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(string(data)),
+	), nil
+}
+
+// writeDependencyAudit adds the dependency advisories section of a check_flutter_project report
+func writeDependencyAudit(buf *bytes.Buffer, budget *resultBudget, audit *models.DependencyAudit, err error) {
+	p := budget.p
+	p.Fprintf(buf, "\n## Dependency advisories\n\n")
+	if err != nil {
+		p.Fprintf(buf, "The dependencies could not be audited: %v.\n", err)
+		return
+	}
+	p.Fprintf(buf, "%d advisories affect the %d pub.dev packages locked in %s.\n\n", len(audit.Advisories), audit.Packages, strings.Join(audit.LockFiles, ", "))
+	shown := budget.take(len(audit.Advisories))
+	for _, advisory := range audit.Advisories[:shown] {
+		kind := p.Sprintf("transitive")
+		if advisory.Direct {
+			kind = p.Sprintf("direct")
+		}
+		severity := ""
+		if advisory.Severity != "" {
+			severity = fmt.Sprintf("[%s] ", advisory.Severity)
+		}
+		fmt.Fprintf(buf, "- %s**%s %s** (%s): %s: %s\n", severity, advisory.Package, advisory.Version, kind, advisory.ID, advisory.Summary)
+		if advisory.Fixed != "" {
+			p.Fprintf(buf, "  Fixed in %s; upgrade with flutter pub upgrade %s. %s\n", advisory.Fixed, advisory.Package, advisory.URL)
+		} else {
+			p.Fprintf(buf, "  No fixed version published. %s\n", advisory.URL)
+		}
+	}
+	if shown > 0 {
+		buf.WriteString("\n")
+	}
+	budget.writeOmitted(buf, shown, len(audit.Advisories))
+	for _, auditErr := range audit.Errors {
+		p.Fprintf(buf, "Not checked: %s\n", auditErr)
+	}
+}
+
+// projectDiagnostics renders a check_flutter_project scan as the LSP diagnostics of each affected
+// file, leaving out the hidden APIs, with report as the structured result
+func (h *MCPHandlers) projectDiagnostics(ctx context.Context, result *models.ProjectScanResult, target string, hidden map[string]bool, report *models.ScanReport) (*mcp_golang.ToolResponse, error) {
+	diagnostics, err := h.deprecationService.ProjectDiagnostics(result, target, hidden)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error building diagnostics: %v", err)),
+		), nil
+	}
+	data, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error encoding diagnostics: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, report)
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(string(data)),
+	), nil
+}
+
+// CheckChangedLines handles the check_changed_lines tool
+func (h *MCPHandlers) CheckChangedLines(ctx context.Context, args models.CheckChangedLinesArgs) (*mcp_golang.ToolResponse, error) {
+	if h.diffScans == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Changed line checks are not enabled on this server."),
+		), nil
+	}
+
+	p, err := h.reportPrinter(args.ReportLanguage)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error choosing the report language: %v", err)),
+		), nil
+	}
+
+	selected := 0
+	for _, set := range []bool{args.Diff != "", args.Range != "", args.Staged} {
+		if set {
+			selected++
+		}
+	}
+	var result *models.DiffScanResult
+	projectPath, err := h.requireProjectPath(ctx, args.ProjectPath)
+	args.ProjectPath = projectPath
+	switch {
+	case err != nil:
+	case selected > 1:
+		err = fmt.Errorf("pass only one of diff, range and staged")
+	case args.Diff != "":
+		result, err = h.diffScans.ScanDiff(ctx, args.ProjectPath, args.Diff, args.Target, args.PathFilter)
+	case args.Range != "":
+		result, err = h.diffScans.ScanRange(ctx, args.ProjectPath, args.Range, args.Target, args.PathFilter)
+	case args.Staged:
+		result, err = h.diffScans.ScanStaged(ctx, args.ProjectPath, args.Target, args.PathFilter)
+	default:
+		err = fmt.Errorf("pass a diff, a range or staged: true")
+	}
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking changed lines: %v", err)),
+		), nil
+	}
+
+	found := make([]models.Deprecation, len(result.Findings))
+	for i, finding := range result.Findings {
+		found[i] = finding.Deprecation
+	}
+	_, suppressed, err := h.splitSuppressed(found, result.ProjectPath)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+		), nil
+	}
+	hidden := make(map[string]bool)
+	if !args.IncludeSuppressed {
+		for _, dep := range suppressed {
+			hidden[dep.API] = true
+		}
+	}
+	var findings []models.LineFinding
+	for _, finding := range result.Findings {
+		if !hidden[finding.Deprecation.API] {
+			findings = append(findings, finding)
+		}
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	change := p.Change(result.Change)
+	p.Fprintf(buf, "Checked the %s of %d Dart files in %s: %d deprecations\n\n", change, result.Files, result.ProjectPath, len(findings))
+	if len(args.Include) > 0 || len(args.Exclude) > 0 {
+		p.Fprintf(buf, "Scope: include %s; exclude %s\n\n", globList(args.Include, p.Sprintf("every Dart file")), globList(args.Exclude, p.Sprintf("nothing")))
+	}
+	if len(findings) == 0 {
+		p.Fprintf(buf, "No deprecated APIs on the lines the %s add.\n", change)
+	}
+
+	budget := newResultBudget(args.ResultLimits, p)
+	shown := budget.take(len(findings))
+	for _, finding := range findings[:shown] {
+		dep := finding.Deprecation
+		fmt.Fprintf(buf, "- %s:%d:%d: **%s**", finding.Path, finding.Line, finding.Column, dep.API)
+		if dep.Replacement != "" {
+			fmt.Fprintf(buf, " → %s", dep.Replacement)
+		}
+		buf.WriteString("\n")
+	}
+	if shown > 0 {
+		buf.WriteString("\n")
+	}
+	budget.writeOmitted(buf, shown, len(findings))
+	if len(result.Findings) > len(findings) {
+		p.Fprintf(buf, "%d suppressed deprecation(s) hidden; pass include_suppressed: true to show them.\n", len(result.Findings)-len(findings))
+	}
+	budget.writeFooter(buf)
+	setStructuredContent(ctx, services.DiffScanReport(result, hidden, config.SEVERITY_INFO))
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(strings.TrimRight(buf.String(), "\n") + "\n"),
+	), nil
+}
+
+// globList formats the globs of a path filter, or fallback when there are none
+func globList(globs []string, fallback string) string {
+	if len(globs) == 0 {
+		return fallback
+	}
+	return "`" + strings.Join(globs, "`, `") + "`"
+}
+
+// CheckCodeAgainstVersion handles the check_code_against_version tool
+func (h *MCPHandlers) CheckCodeAgainstVersion(ctx context.Context, args models.CheckCodeAgainstVersionArgs) (*mcp_golang.ToolResponse, error) {
+	result, err := h.deprecationService.CheckCodeAgainstVersion(args.Code, args.TargetVersion, args.CurrentVersion)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking code against Flutter %s: %v", args.TargetVersion, err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+
+	if len(result.Introduced) == 0 && len(result.AlreadyPresent) == 0 && len(result.Undated) == 0 && len(result.Unavailable) == 0 {
+		message := fmt.Sprintf("No deprecated APIs relevant to Flutter %s found in the provided code.", result.TargetVersion)
+		if result.NotYet > 0 {
+			message += fmt.Sprintf(" %d API(s) used here are deprecated in later versions.", result.NotYet)
+		}
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(message),
+		), nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	if result.CurrentVersion != "" {
+		fmt.Fprintf(buf, "Deprecations relevant when moving from Flutter %s to %s:\n\n", result.CurrentVersion, result.TargetVersion)
+		fmt.Fprintf(buf, "## Deprecated between %s and %s\n\n", result.CurrentVersion, result.TargetVersion)
+	} else {
+		fmt.Fprintf(buf, "Deprecations relevant on Flutter %s:\n\n## Deprecated as of %s\n\n", result.TargetVersion, result.TargetVersion)
+	}
+	if len(result.Introduced) == 0 {
+		buf.WriteString("None.\n\n")
+	}
+	budget.writeDeprecations(buf, result.Introduced)
+
+	if result.CurrentVersion != "" && len(result.AlreadyPresent) > 0 {
+		fmt.Fprintf(buf, "## Already deprecated in %s\n\n", result.CurrentVersion)
+		budget.writeDeprecations(buf, result.AlreadyPresent)
+	}
+
+	if len(result.Undated) > 0 {
+		buf.WriteString("## Deprecated in an unknown version\n\n")
+		budget.writeDeprecations(buf, result.Undated)
+	}
+
+	if len(result.Unavailable) > 0 {
+		fmt.Fprintf(buf, "## Not available in %s\n\n", result.TargetVersion)
+		shown := budget.take(len(result.Unavailable))
+		for _, requirement := range result.Unavailable[:shown] {
+			fmt.Fprintf(buf, "- %s: needs Flutter %s (line %d)\n", requirement.API, requirement.Version, requirement.Line)
+			if requirement.Alternative != "" {
+				fmt.Fprintf(buf, "  → %s\n", requirement.Alternative)
+			}
+		}
+		buf.WriteString("\n")
+		budget.writeOmitted(buf, shown, len(result.Unavailable))
+	}
+
+	if result.NotYet > 0 {
+		fmt.Fprintf(buf, "%d other API(s) used here are only deprecated after %s and were left out.\n", result.NotYet, result.TargetVersion)
+	}
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CheckVersionMatrix handles the check_version_matrix tool
+func (h *MCPHandlers) CheckVersionMatrix(ctx context.Context, args models.VersionMatrixArgs) (*mcp_golang.ToolResponse, error) {
+	if args.ProjectPath == "" && strings.TrimSpace(args.Code) == "" {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Please provide either code or project_path."),
+		), nil
+	}
+	matrix, err := h.deprecationService.VersionMatrix(ctx, args.Code, args.ProjectPath, args.PathFilter, args.TargetVersions)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking version matrix: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, matrix)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	scanned := ""
+	if matrix.FilesScanned > 0 {
+		scanned = fmt.Sprintf(" in %d Dart files", matrix.FilesScanned)
+	}
+	if len(matrix.Rows) == 0 {
+		fmt.Fprintf(buf, "No deprecated or version-specific Flutter APIs found%s; the code fits Flutter %s.\n", scanned, strings.Join(matrix.Targets, ", "))
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	fmt.Fprintf(buf, "Version matrix for Flutter %s%s\n\n", strings.Join(matrix.Targets, ", "), scanned)
+	buf.WriteString("| API | Since |")
+	for _, target := range matrix.Targets {
+		fmt.Fprintf(buf, " %s |", target)
+	}
+	buf.WriteString("\n|---|---|" + strings.Repeat("---|", len(matrix.Targets)) + "\n")
+	deprecated := make([]int, len(matrix.Targets))
+	unavailable := make([]int, len(matrix.Targets))
+	for _, row := range matrix.Rows {
+		api, since := row.API, "deprecated in "+valueOrUnknown(row.Version)
+		if row.Introduced {
+			since = "introduced in " + row.Version
+		} else if row.Replacement != "" {
+			api += " → " + row.Replacement
+		}
+		fmt.Fprintf(buf, "| %s | %s |", api, since)
+		for i, status := range row.Statuses {
+			fmt.Fprintf(buf, " %s |", status)
+			switch status {
+			case config.MATRIX_DEPRECATED:
+				deprecated[i]++
+			case config.MATRIX_UNAVAILABLE:
+				unavailable[i]++
+			}
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n## Per target\n\n")
+	for i, target := range matrix.Targets {
+		fmt.Fprintf(buf, "- %s: %d deprecated, %d unavailable\n", target, deprecated[i], unavailable[i])
+	}
+
+	notes := getBuffer()
+	defer putBuffer(notes)
+	for _, row := range matrix.Rows {
+		var note string
+		switch {
+		case row.Introduced && row.Statuses[0] != config.MATRIX_UNAVAILABLE:
+			continue
+		case row.Introduced && row.Replacement != "":
+			note = fmt.Sprintf("needs Flutter %s; on older targets write %s", row.Version, row.Replacement)
+		case row.Introduced:
+			note = fmt.Sprintf("needs Flutter %s; raise the oldest target or avoid it", row.Version)
+		case row.Compatible != "":
+			note = fmt.Sprintf("deprecated in %s; also supporting releases before it: %s", row.Version, row.Compatible)
+		default:
+			continue
+		}
+		fmt.Fprintf(notes, "- %s %s", row.API, note)
+		if row.File != "" {
+			fmt.Fprintf(notes, " (first used at %s:%d)", row.File, row.Line)
+		}
+		notes.WriteString("\n")
+	}
+	if notes.Len() > 0 {
+		fmt.Fprintf(buf, "\n## Supporting the older targets\n\n%s", notes.String())
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// InferMinimumFlutterVersion handles the infer_minimum_flutter_version tool
+func (h *MCPHandlers) InferMinimumFlutterVersion(ctx context.Context, args models.InferMinimumVersionArgs) (*mcp_golang.ToolResponse, error) {
+	if h.minimumVersion == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Minimum version inference is not enabled on this server."),
+		), nil
+	}
+
+	var result *models.MinimumVersionResult
+	switch {
+	case args.ProjectPath != "":
+		var err error
+		result, err = h.minimumVersion.InferFromProject(ctx, args.ProjectPath, args.PathFilter)
+		if err != nil {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Error analyzing project: %v", err)),
+			), nil
+		}
+	case strings.TrimSpace(args.Code) != "":
+		result = h.minimumVersion.InferFromCode(args.Code)
+	default:
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Please provide either code or project_path."),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if len(result.Requirements) == 0 {
+		buf.WriteString("No version-specific Flutter APIs found")
+		if result.FilesScanned > 0 {
+			fmt.Fprintf(buf, " in %d Dart files", result.FilesScanned)
+		}
+		buf.WriteString(".\n")
+	} else {
+		fmt.Fprintf(buf, "Minimum Flutter version: %s (Dart %s)\n\n", result.MinimumFlutter, result.MinimumDart)
+		buf.WriteString("## APIs that require a minimum version\n\n")
+		for _, requirement := range result.Requirements {
+			location := fmt.Sprintf("line %d", requirement.Line)
+			if requirement.File != "" {
+				location = fmt.Sprintf("%s:%d", requirement.File, requirement.Line)
+			}
+			fmt.Fprintf(buf, "- %s: Flutter %s (first used at %s)\n", requirement.API, requirement.Version, location)
+			if requirement.Alternative != "" {
+				fmt.Fprintf(buf, "  → %s\n", requirement.Alternative)
+			}
+		}
+		fmt.Fprintf(buf, "\n## Suggested pubspec.yaml constraints\n\n```yaml\nenvironment:\n  sdk: \">=%s <4.0.0\"\n  flutter: \">=%s\"\n```\n", result.MinimumDart, result.MinimumFlutter)
+	}
+
+	if result.DeclaredSDK != "" || result.DeclaredFlutter != "" {
+		fmt.Fprintf(buf, "\nCurrently declared: sdk %s, flutter %s\n", valueOrUnknown(result.DeclaredSDK), valueOrUnknown(result.DeclaredFlutter))
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// SuggestSDKConstraints handles the suggest_sdk_constraints tool
+func (h *MCPHandlers) SuggestSDKConstraints(ctx context.Context, args models.SDKConstraintArgs) (*mcp_golang.ToolResponse, error) {
+	if h.sdkConstraints == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("SDK constraint suggestions are not enabled on this server."),
+		), nil
+	}
+	if strings.TrimSpace(args.Pubspec) == "" {
+		projectPath, err := h.projectPath(ctx, args.ProjectPath)
+		if projectPath == "" {
+			message := "Please provide either pubspec or project_path."
+			if err != nil {
+				message = fmt.Sprintf("Please provide either pubspec or project_path; %v.", err)
+			}
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(message)), nil
+		}
+		args.ProjectPath = projectPath
+	}
+
+	suggestion, err := h.sdkConstraints.Suggest(ctx, args.Pubspec, args.ProjectPath, args.TargetVersion)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error suggesting SDK constraints: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, suggestion)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "SDK constraints for Flutter %s (Dart %s)\n\n", suggestion.TargetFlutter, suggestion.TargetDart)
+	fmt.Fprintf(buf, "- sdk: %s → %s\n", valueOrUnknown(suggestion.CurrentSDK), suggestion.SuggestedSDK)
+	if suggestion.SuggestedFlutter != "" {
+		fmt.Fprintf(buf, "- flutter: %s → %s\n", valueOrUnknown(suggestion.CurrentFlutter), suggestion.SuggestedFlutter)
+	}
+	for _, note := range suggestion.Notes {
+		fmt.Fprintf(buf, "\nNote: %s.\n", note)
+	}
+
+	fmt.Fprintf(buf, "\n## Edited environment block\n\n```yaml\n%s```\n", suggestion.Block)
+	if suggestion.Patch == "" {
+		buf.WriteString("\n✅ The pubspec.yaml already has these constraints; nothing to change.\n")
+	} else {
+		fmt.Fprintf(buf, "\n## Patch\n\nApply with `git apply` from the project root:\n\n```diff\n%s```\n", suggestion.Patch)
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// TestDeprecationRules handles the test_deprecation_rules tool
+func (h *MCPHandlers) TestDeprecationRules(ctx context.Context, args models.RuleTestArgs) (*mcp_golang.ToolResponse, error) {
+	result, err := h.deprecationService.TestRules(ctx, args.Target, args.Samples, args.Code, args.Rule)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error testing deprecation rules: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Rule test for %s code: %d rules, %d fixtures, %d samples with %d expectations\n", result.Target, result.Rules, result.Fixtures, result.Samples, result.Expectations)
+	if len(result.Failures) == 0 {
+		buf.WriteString("\n✅ No false positives or false negatives.\n")
+	}
+	for _, kind := range []struct{ kind, title string }{
+		{config.RULE_TEST_FALSE_POSITIVE, "False positives"},
+		{config.RULE_TEST_FALSE_NEGATIVE, "False negatives"},
+	} {
+		var failures []models.RuleTestFailure
+		for _, failure := range result.Failures {
+			if failure.Kind == kind.kind {
+				failures = append(failures, failure)
+			}
+		}
+		if len(failures) == 0 {
+			continue
+		}
+		fmt.Fprintf(buf, "\n## %s (%d)\n\n", kind.title, len(failures))
+		for _, failure := range failures {
+			label := "**" + failure.API + "**"
+			if failure.Source != "" {
+				label += " (" + failure.Source + ")"
+			}
+			location := "fixture"
+			if failure.File != "" {
+				location = fmt.Sprintf("%s:%d", failure.File, failure.Line)
+			} else if failure.Line > 0 {
+				location = fmt.Sprintf("code line %d", failure.Line)
+			}
+			fmt.Fprintf(buf, "- %s %s: `%s`\n", label, location, failure.Code)
+		}
+	}
+	if len(result.Untested) > 0 {
+		fmt.Fprintf(buf, "\nRules without fixtures (%d): %s\n", len(result.Untested), strings.Join(result.Untested, ", "))
+		buf.WriteString("Add `tests:` with `match` and `no_match` code to them in their rules file.\n")
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// RecommendFlutterPin handles the recommend_flutter_pin tool
+func (h *MCPHandlers) RecommendFlutterPin(ctx context.Context, args models.PinRecommendationArgs) (*mcp_golang.ToolResponse, error) {
+	if h.pinRecommendations == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Pin recommendations are not enabled on this server."),
+		), nil
+	}
+	if strings.TrimSpace(args.Pubspec) == "" {
+		projectPath, err := h.projectPath(ctx, args.ProjectPath)
+		if projectPath == "" {
+			message := "Please provide either pubspec or project_path."
+			if err != nil {
+				message = fmt.Sprintf("Please provide either pubspec or project_path; %v.", err)
+			}
+			return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(message)), nil
+		}
+		args.ProjectPath = projectPath
+	}
+
+	recommendation, err := h.pinRecommendations.Recommend(ctx, args.Pubspec, args.ProjectPath)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error recommending a Flutter pin: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, recommendation)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("Flutter pin recommendation\n\n")
+	switch {
+	case recommendation.Recommended == "":
+		buf.WriteString("❌ No stable Flutter release satisfies every dependency. Upgrade the dependencies below first.\n")
+	case recommendation.Recommended == recommendation.LatestStable:
+		fmt.Fprintf(buf, "✅ Every checked dependency supports the latest stable release: pin Flutter %s (Dart %s).\n", recommendation.Recommended, recommendation.RecommendedDart)
+	default:
+		fmt.Fprintf(buf, "📌 Pin Flutter %s (Dart %s), the newest stable release every checked dependency supports.\n", recommendation.Recommended, recommendation.RecommendedDart)
+	}
+	fmt.Fprintf(buf, "   Latest stable: %s (Dart %s)\n", recommendation.LatestStable, recommendation.LatestDart)
+	if recommendation.CurrentFlutter != "" {
+		fmt.Fprintf(buf, "   Current flutter constraint: %s\n", recommendation.CurrentFlutter)
+	}
+
+	var blocking, supported, failed []models.DependencySupport
+	for _, dep := range recommendation.Dependencies {
+		switch {
+		case dep.Error != "":
+			failed = append(failed, dep)
+		case dep.Blocking:
+			blocking = append(blocking, dep)
+		default:
+			supported = append(supported, dep)
+		}
+	}
+
+	if len(blocking) > 0 {
+		fmt.Fprintf(buf, "\n## Holding back the upgrade (%d)\n\n", len(blocking))
+		for _, dep := range blocking {
+			if dep.NewestFlutter == "" {
+				fmt.Fprintf(buf, "- **%s** %s: no version within the constraint runs on a stable release\n", dep.Package, dep.Constraint)
+			} else {
+				fmt.Fprintf(buf, "- **%s** %s: %s needs sdk %s, up to Flutter %s\n", dep.Package, dep.Constraint, dep.Version, dep.SDK, dep.NewestFlutter)
+			}
+			if dep.Upgrade != "" {
+				fmt.Fprintf(buf, "   Upgrade: %s supports Flutter %s\n", dep.Upgrade, recommendation.LatestStable)
+			}
+		}
+	}
+	if len(supported) > 0 {
+		fmt.Fprintf(buf, "\n## Supporting Flutter %s (%d)\n\n", recommendation.LatestStable, len(supported))
+		for _, dep := range supported {
+			fmt.Fprintf(buf, "- %s %s: %s (sdk %s)\n", dep.Package, dep.Constraint, dep.Version, dep.SDK)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(buf, "\n## Could not be checked (%d)\n\n", len(failed))
+		for _, dep := range failed {
+			fmt.Fprintf(buf, "- %s %s: %s\n", dep.Package, dep.Constraint, dep.Error)
+		}
+	}
+	if len(recommendation.Skipped) > 0 {
+		fmt.Fprintf(buf, "\nNot on pub.dev: %s\n", strings.Join(recommendation.Skipped, ", "))
+	}
+	for _, note := range recommendation.Notes {
+		fmt.Fprintf(buf, "\nNote: %s.\n", note)
+	}
+	buf.WriteString("\nOnly direct dependencies are checked; `flutter pub upgrade --dry-run` on the pinned release confirms the transitive ones.\n")
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// GetAnalyzerFixes handles the get_analyzer_fixes tool
+func (h *MCPHandlers) GetAnalyzerFixes(ctx context.Context, args models.AnalyzerFixesArgs) (*mcp_golang.ToolResponse, error) {
+	result, err := h.analyzerFixes(args)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error getting analyzer fixes: %v", err)),
+		), nil
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error encoding analyzer fixes: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(string(data)),
+	), nil
+}
+
+// analyzerFixes finds the analyzer plugin fixes of a get_analyzer_fixes call or POST /fixes
+// request, leaving out the suppressed APIs unless they are asked for
+func (h *MCPHandlers) analyzerFixes(args models.AnalyzerFixesArgs) (*models.AnalyzerFixesResult, error) {
+	if strings.TrimSpace(args.File) == "" {
+		return nil, fmt.Errorf("no file given")
+	}
+	code, err := services.DecodeCode(args.Code, args.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	result, err := h.deprecationService.AnalyzerFixes(args.File, code, args.Target)
+	if err != nil || args.IncludeSuppressed {
+		return result, err
+	}
+
+	deprecations := make([]models.Deprecation, len(result.Fixes))
+	for i, fixes := range result.Fixes {
+		deprecations[i] = models.Deprecation{API: fixes.API}
+	}
+	_, suppressed, err := h.splitSuppressed(deprecations, args.ProjectPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading suppressions: %v", err)
+	}
+	hidden := make(map[string]bool, len(suppressed))
+	for _, dep := range suppressed {
+		hidden[dep.API] = true
+	}
+	active := result.Fixes[:0]
+	for _, fixes := range result.Fixes {
+		if !hidden[fixes.API] {
+			active = append(active, fixes)
+		}
+	}
+	result.Fixes = active
+	return result, nil
+}
+
+// MigrateCode handles the migrate_code tool
+func (h *MCPHandlers) MigrateCode(ctx context.Context, args models.CheckCodeArgs) (*mcp_golang.ToolResponse, error) {
+	result := h.deprecationService.MigrateCode(args.Code)
+	setStructuredContent(ctx, result)
+
+	if len(result.Changes) == 0 && len(result.Manual) == 0 {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("No deprecated APIs found in the provided code."),
+		), nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("## Migrated code\n\n```dart\n")
+	buf.WriteString(result.Code)
+	if !strings.HasSuffix(result.Code, "\n") {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("```\n\n## Applied changes\n\n")
+	if len(result.Changes) == 0 {
+		buf.WriteString("None.\n")
+	}
+	for _, change := range result.Changes {
+		fmt.Fprintf(buf, "- Line %d: `%s` → `%s` (%s)\n", change.Line, change.Before, change.After, change.API)
+	}
+	if len(result.Previews) > 0 {
+		buf.WriteString("\n## Before/after preview\n\n")
+		writeFixPreviews(buf, result.Previews)
+	}
+
+	buf.WriteString("\n## Needs manual migration\n\n")
+	if len(result.Manual) == 0 {
+		buf.WriteString("None.\n")
+	}
+	for _, pending := range result.Manual {
+		fmt.Fprintf(buf, "- Line %d: **%s**: %s", pending.Line, pending.API, pending.Reason)
+		if pending.Replacement != "" {
+			fmt.Fprintf(buf, " (suggested replacement: %s)", pending.Replacement)
+		}
+		if pending.DocURL != "" {
+			fmt.Fprintf(buf, ", see %s", pending.DocURL)
+		}
+		buf.WriteString("\n")
+	}
+
+	for _, template := range result.Templates {
+		fmt.Fprintf(buf, "\n## Template: %s → %s\n\n", template.API, template.Replacement)
+		writeMigrationTemplate(buf, template)
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ListFlutterDeprecations handles the list_flutter_deprecations tool
+func (h *MCPHandlers) ListFlutterDeprecations(ctx context.Context, args models.ListDeprecationsArgs) (*mcp_golang.ToolResponse, error) {
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading deprecations: %v", err)),
+		), nil
+	}
+
+	if len(cache.Deprecations) == 0 && len(cache.Manual) == 0 {
+		setStructuredContent(ctx, models.DeprecationsResponse{LastUpdated: cache.LastUpdated, Deprecations: []models.Deprecation{}, Manual: []models.Deprecation{}})
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("No deprecations found in cache. Try updating the cache first."),
+		), nil
+	}
+
+	sort.Slice(cache.Deprecations, func(i, j int) bool {
+		return cache.Deprecations[i].API < cache.Deprecations[j].API
+	})
+
+	deprecations, suppressed, err := h.splitSuppressed(cache.Deprecations, args.ProjectPath)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+		), nil
+	}
+	manual, suppressedManual, _ := h.splitSuppressed(cache.Manual, args.ProjectPath)
+	suppressed = append(suppressed, suppressedManual...)
+
+	response := models.DeprecationsResponse{LastUpdated: cache.LastUpdated, Deprecations: nonNil(deprecations), Manual: nonNil(manual)}
+	if args.IncludeSuppressed {
+		response.Suppressed = suppressed
+	}
+	setStructuredContent(ctx, response)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	fmt.Fprintf(buf, "Flutter Deprecations (Last updated: %s)\n\n", cache.LastUpdated.Format("2006-01-02 15:04:05"))
+	budget.writeDeprecations(buf, deprecations)
+
+	if len(manual) > 0 {
+		buf.WriteString("## Manual entries\n\n")
+		budget.writeDeprecations(buf, manual)
+	}
+	writeSuppressed(buf, budget, suppressed, args.IncludeSuppressed)
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// GetDeprecationDetails handles the get_deprecation_details tool
+func (h *MCPHandlers) GetDeprecationDetails(ctx context.Context, args models.DeprecationDetailsArgs) (*mcp_golang.ToolResponse, error) {
+	if strings.TrimSpace(args.API) == "" {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Please provide an API name, e.g. ColorScheme.background."),
+		), nil
+	}
+
+	deprecations := h.deprecationService.FindDeprecations(args.API)
+	setStructuredContent(ctx, models.DeprecationDetails{API: args.API, Deprecations: nonNil(deprecations)})
+	if len(deprecations) == 0 {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("No deprecation found for %q. Use list_flutter_deprecations to browse known entries.", args.API)),
+		), nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	for i, dep := range deprecations {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "## %s\n\n", dep.API)
+		fmt.Fprintf(buf, "- Replacement: %s%s\n", valueOrUnknown(dep.Replacement), draftedNote(i18n.English, dep))
+		fmt.Fprintf(buf, "- Description: %s\n", valueOrUnknown(dep.Description))
+		fmt.Fprintf(buf, "- Since version: %s\n", valueOrUnknown(dep.Version))
+		fmt.Fprintf(buf, "- Category: %s\n", valueOrUnknown(dep.Category))
+		if dep.Package != "" {
+			fmt.Fprintf(buf, "- Package: %s\n", dep.Package)
+		}
+		fmt.Fprintf(buf, "- Severity: %s\n", valueOrUnknown(dep.Severity))
+		if dep.Example != "" {
+			fmt.Fprintf(buf, "- Example: %s\n", dep.Example)
+		}
+		fmt.Fprintf(buf, "- Documentation: %s\n", services.DocumentationURL(dep))
+		fmt.Fprintf(buf, "- Provenance: %s\n", describeSource(dep.Source))
+		if dep.SourceFile != "" {
+			fmt.Fprintf(buf, "- Source: %s:%d (%s)\n", dep.SourceFile, dep.SourceLine, services.SourceURL(dep))
+		}
+		if !dep.FirstSeen.IsZero() {
+			fmt.Fprintf(buf, "- First seen: %s\n", dep.FirstSeen.Format("2006-01-02"))
+			fmt.Fprintf(buf, "- Status: %s\n", describeStatus(i18n.English, dep))
+		}
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ExplainDeprecation handles the explain_deprecation tool
+func (h *MCPHandlers) ExplainDeprecation(ctx context.Context, args models.DeprecationDetailsArgs) (*mcp_golang.ToolResponse, error) {
+	if h.guideService == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Migration guides are not enabled on this server."),
+		), nil
+	}
+	if strings.TrimSpace(args.API) == "" {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Please provide an API name, e.g. RaisedButton."),
+		), nil
+	}
+
+	explanation, err := h.guideService.ExplainDeprecation(ctx, args.API)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error explaining deprecation: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, explanation)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	dep := explanation.Deprecation
+	fmt.Fprintf(buf, "# %s\n\n## Deprecation\n\n", dep.API)
+	fmt.Fprintf(buf, "- Message: %s\n", valueOrUnknown(dep.Description))
+	fmt.Fprintf(buf, "- Replacement: %s\n", valueOrUnknown(dep.Replacement))
+	fmt.Fprintf(buf, "- Since version: %s\n", valueOrUnknown(dep.Version))
+	fmt.Fprintf(buf, "- Documentation: %s\n", services.DocumentationURL(dep))
+	if dep.SourceFile != "" {
+		fmt.Fprintf(buf, "- Source: %s:%d (%s)\n", dep.SourceFile, dep.SourceLine, services.SourceURL(dep))
+	}
+
+	buf.WriteString("\n## Migration guide\n\n")
+	if explanation.GuideURL != "" {
+		fmt.Fprintf(buf, "[%s](%s)\n\n%s\n", explanation.GuideTitle, explanation.GuideURL, explanation.GuideExcerpt)
+	} else {
+		fmt.Fprintf(buf, "Not available: %s\n", explanation.GuideError)
+	}
+
+	buf.WriteString("\n## Example\n\n")
+	if explanation.Before == "" && explanation.After == "" {
+		buf.WriteString("No worked example available.\n")
+	} else {
+		fmt.Fprintf(buf, "Before:\n\n```dart\n%s\n```\n\nAfter:\n\n```dart\n%s\n```\n", explanation.Before, explanation.After)
+	}
+
+	if explanation.Template != nil {
+		fmt.Fprintf(buf, "\n## Template: %s → %s\n\n", explanation.Template.API, explanation.Template.Replacement)
+		writeMigrationTemplate(buf, *explanation.Template)
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ListBreakingChangesBetween handles the list_breaking_changes_between tool
+func (h *MCPHandlers) ListBreakingChangesBetween(ctx context.Context, args models.BreakingChangesArgs) (*mcp_golang.ToolResponse, error) {
+	if h.guideService == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Migration guides are not enabled on this server."),
+		), nil
+	}
+
+	result, err := h.guideService.BreakingChangesBetween(ctx, args.From, args.To)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error listing breaking changes: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Breaking changes from Flutter %s to %s (source: %s)\n\n", result.From, result.To, result.Source)
+	if result.UpstreamError != "" {
+		fmt.Fprintf(buf, "Note: the flutter/website index was unavailable (%s); showing the curated list, which only covers major changes.\n\n", result.UpstreamError)
+	}
+
+	if len(result.Changes) == 0 {
+		buf.WriteString("No breaking changes documented for this range.\n")
+	}
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	shown := budget.take(len(result.Changes))
+	version := ""
+	for _, change := range result.Changes[:shown] {
+		if change.Version != version {
+			if version != "" {
+				buf.WriteString("\n")
+			}
+			version = change.Version
+			fmt.Fprintf(buf, "## Flutter %s\n\n", version)
+		}
+		if change.URL != "" {
+			fmt.Fprintf(buf, "- [ ] [%s](%s)\n", change.Title, change.URL)
+		} else {
+			fmt.Fprintf(buf, "- [ ] %s\n", change.Title)
+		}
+	}
+	if shown > 0 && shown < len(result.Changes) {
+		buf.WriteString("\n")
+	}
+	budget.writeOmitted(buf, shown, len(result.Changes))
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CompareFlutterVersions handles the compare_flutter_versions tool
+func (h *MCPHandlers) CompareFlutterVersions(ctx context.Context, args models.CompareVersionsArgs) (*mcp_golang.ToolResponse, error) {
+	if h.sdkScans == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Local SDK scanning is not enabled on this server."),
+		), nil
+	}
+
+	comparison, err := h.sdkScans.Compare(ctx, args.From, args.To)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error comparing Flutter versions: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, comparison)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Deprecations from Flutter %s to %s, read from the local SDKs\n\n", comparison.From, comparison.To)
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	fmt.Fprintf(buf, "## Newly deprecated in %s (%d)\n\n", comparison.To, len(comparison.Deprecated))
+	if len(comparison.Deprecated) == 0 {
+		buf.WriteString("None.\n\n")
+	}
+	budget.writeDeprecations(buf, comparison.Deprecated)
+
+	fmt.Fprintf(buf, "## No longer annotated in %s (%d)\n\n", comparison.To, len(comparison.Removed))
+	if len(comparison.Removed) == 0 {
+		buf.WriteString("None.\n\n")
+	} else {
+		fmt.Fprintf(buf, "These were deprecated in %s and their @Deprecated annotation is gone, usually because the API was removed: code still using them no longer compiles.\n\n", comparison.From)
+	}
+	shown := budget.take(len(comparison.Removed))
+	for _, dep := range comparison.Removed[:shown] {
+		if dep.Replacement != "" {
+			fmt.Fprintf(buf, "- **%s** → %s\n", dep.API, dep.Replacement)
+		} else {
+			fmt.Fprintf(buf, "- **%s**\n", dep.API)
+		}
+	}
+	if shown > 0 {
+		buf.WriteString("\n")
+	}
+	budget.writeOmitted(buf, shown, len(comparison.Removed))
+
+	fmt.Fprintf(buf, "%d deprecations are present in both versions.\n", comparison.Unchanged)
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// DeprecationsIntroducedIn handles the deprecations_introduced_in tool
+func (h *MCPHandlers) DeprecationsIntroducedIn(ctx context.Context, args models.IntroducedInArgs) (*mcp_golang.ToolResponse, error) {
+	if h.sdkScans == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Local SDK scanning is not enabled on this server."),
+		), nil
+	}
+
+	introduced, err := h.sdkScans.IntroducedIn(ctx, args.Version)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error listing introduced deprecations: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, introduced)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Deprecations introduced in Flutter %s (%d)\n", introduced.Version, len(introduced.Deprecations))
+	fmt.Fprintf(buf, "Compared with %s, the newest installed version before it. Releases between the two that are not installed are counted towards %s.\n\n", introduced.Previous, introduced.Version)
+	if len(introduced.Deprecations) == 0 {
+		buf.WriteString("None.\n")
+	}
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	budget.writeDeprecations(buf, introduced.Deprecations)
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// WhatsNewInFlutter handles the whats_new_in_flutter tool
+func (h *MCPHandlers) WhatsNewInFlutter(ctx context.Context, args models.WhatsNewArgs) (*mcp_golang.ToolResponse, error) {
+	if h.whatsNew == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Release digests are not enabled on this server."),
+		), nil
+	}
+
+	digest, err := h.whatsNew.WhatsNew(ctx, args.Version)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error summarizing Flutter release: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, digest)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "What's new in Flutter %s (since %s)\n\n", digest.Version, digest.Previous)
+	fmt.Fprintf(buf, "Release notes: %s\n\n", digest.ReleaseNotesURL)
+	for _, upstreamError := range digest.UpstreamErrors {
+		fmt.Fprintf(buf, "Note: %s. The digest may be incomplete.\n\n", upstreamError)
+	}
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	fmt.Fprintf(buf, "## New deprecations (%d)\n\n", len(digest.Deprecations))
+	if len(digest.Deprecations) == 0 {
+		buf.WriteString("No deprecations from this release are cached; run update_flutter_deprecations to fetch them.\n\n")
+	}
+	budget.writeDeprecations(buf, digest.Deprecations)
+
+	fmt.Fprintf(buf, "## Breaking changes (%d)\n\n", len(digest.BreakingChanges))
+	if len(digest.BreakingChanges) == 0 {
+		buf.WriteString("No breaking changes documented for this release.\n")
+	}
+	shown := budget.take(len(digest.BreakingChanges))
+	for _, change := range digest.BreakingChanges[:shown] {
+		if change.URL != "" {
+			fmt.Fprintf(buf, "- [%s](%s)\n", change.Title, change.URL)
+		} else {
+			fmt.Fprintf(buf, "- %s\n", change.Title)
+		}
+	}
+	buf.WriteString("\n")
+	budget.writeOmitted(buf, shown, len(digest.BreakingChanges))
+
+	fmt.Fprintf(buf, "## New replacement APIs (%d)\n\n", len(digest.Replacements))
+	if len(digest.Replacements) == 0 {
+		buf.WriteString("No replacement APIs named by this release's deprecations.\n")
+	}
+	shown = budget.take(len(digest.Replacements))
+	for _, replacement := range digest.Replacements[:shown] {
+		fmt.Fprintf(buf, "- %s replaces %s\n", replacement.API, strings.Join(replacement.Replaces, ", "))
+	}
+	if shown > 0 && shown < len(digest.Replacements) {
+		buf.WriteString("\n")
+	}
+	budget.writeOmitted(buf, shown, len(digest.Replacements))
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// UpgradeDigest handles the upgrade_digest tool
+func (h *MCPHandlers) UpgradeDigest(ctx context.Context, args models.UpgradeDigestArgs) (*mcp_golang.ToolResponse, error) {
+	if h.upgradeDigests == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Upgrade digests are not enabled on this server."),
+		), nil
+	}
+
+	digest, err := h.upgradeDigests.UpgradeDigest(ctx, args.From, args.ProjectPath)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error building upgrade digest: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, digest)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if len(digest.Releases) == 0 {
+		fmt.Fprintf(buf, "Flutter %s (%s) is up to date: no stable release is newer.\n", digest.From, digest.FromSource)
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	fmt.Fprintf(buf, "# Upgrading Flutter %s to %s\n\n", digest.From, digest.To)
+	fmt.Fprintf(buf, "Installed version from: %s\n", digest.FromSource)
+	fmt.Fprintf(buf, "Stable releases to go through: %d\n\n", len(digest.Releases))
+	for _, upstreamError := range digest.UpstreamErrors {
+		fmt.Fprintf(buf, "Note: %s. The digest may be incomplete.\n\n", upstreamError)
+	}
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	fmt.Fprintf(buf, "## Deprecations (%d)\n\n", len(digest.Deprecations))
+	if len(digest.Deprecations) == 0 {
+		buf.WriteString("No deprecations from these releases are cached; run update_flutter_deprecations to fetch them.\n\n")
+	}
+	budget.writeDeprecations(buf, digest.Deprecations)
+
+	fmt.Fprintf(buf, "## Breaking changes (%d)\n\n", len(digest.BreakingChanges))
+	if len(digest.BreakingChanges) == 0 {
+		buf.WriteString("No breaking changes documented for these releases.\n")
+	}
+	shown := budget.take(len(digest.BreakingChanges))
+	for _, change := range digest.BreakingChanges[:shown] {
+		if change.URL != "" {
+			fmt.Fprintf(buf, "- %s: [%s](%s)\n", change.Version, change.Title, change.URL)
+		} else {
+			fmt.Fprintf(buf, "- %s: %s\n", change.Version, change.Title)
+		}
+	}
+	buf.WriteString("\n")
+	budget.writeOmitted(buf, shown, len(digest.BreakingChanges))
+
+	fmt.Fprintf(buf, "## Replacement APIs (%d)\n\n", len(digest.Replacements))
+	if len(digest.Replacements) == 0 {
+		buf.WriteString("No replacement APIs named by these deprecations.\n\n")
+	}
+	shown = budget.take(len(digest.Replacements))
+	for _, replacement := range digest.Replacements[:shown] {
+		fmt.Fprintf(buf, "- %s replaces %s\n", replacement.API, strings.Join(replacement.Replaces, ", "))
+	}
+	if shown > 0 {
+		buf.WriteString("\n")
+	}
+	budget.writeOmitted(buf, shown, len(digest.Replacements))
+
+	buf.WriteString("## Release notes\n\n")
+	for _, release := range digest.Releases {
+		fmt.Fprintf(buf, "### Flutter %s", release.Version)
+		if !release.ReleaseDate.IsZero() {
+			fmt.Fprintf(buf, " (%s)", release.ReleaseDate.Format("2006-01-02"))
+		}
+		buf.WriteString("\n\n")
+		if release.DartSDKVersion != "" {
+			fmt.Fprintf(buf, "Dart SDK: %s\n", release.DartSDKVersion)
+		}
+		if release.ReleaseNotesURL != "" {
+			fmt.Fprintf(buf, "Release notes: %s\n", release.ReleaseNotesURL)
+		}
+		if release.Notes == "" {
+			buf.WriteString("No notes published with the GitHub release.\n\n")
+		} else if budget.summary {
+			buf.WriteString("GitHub release notes left out in summary mode.\n\n")
+		} else {
+			fmt.Fprintf(buf, "%s\n\n", release.Notes)
+		}
+	}
+
+	fmt.Fprintf(buf, "When ready, run `flutter upgrade` or point your version manager at %s, then run check_flutter_project.\n", digest.To)
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ListFlutterReleases handles the list_flutter_releases tool
+func (h *MCPHandlers) ListFlutterReleases(ctx context.Context, args models.ListFlutterReleasesArgs) (*mcp_golang.ToolResponse, error) {
+	if h.releaseHistory == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("The release history is not enabled on this server."),
+		), nil
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = config.DEFAULT_RELEASE_HISTORY
+	}
+
+	history, err := h.releaseHistory.StableReleases(ctx, limit, args.Since)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error listing Flutter releases: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, history)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("# Flutter stable releases\n\n")
+	source := "official releases API"
+	if history.Source == config.VERSION_SOURCE_GITHUB {
+		source = "GitHub releases (the official releases API was unavailable; Dart SDK versions are unknown)"
+	}
+	fmt.Fprintf(buf, "Source: %s\n", source)
+	if history.Latest != "" {
+		fmt.Fprintf(buf, "Latest stable: %s\n", history.Latest)
+	}
+	fmt.Fprintf(buf, "Showing %d of %d stable releases", len(history.Releases), history.Total)
+	if args.Since != "" {
+		fmt.Fprintf(buf, " since %s", args.Since)
+	}
+	buf.WriteString(", newest first.\n\n")
+	if len(history.Releases) == 0 {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	buf.WriteString("| Version | Release date | Dart SDK |\n")
+	buf.WriteString("|---|---|---|\n")
+	for _, release := range history.Releases {
+		date := "unknown"
+		if !release.ReleaseDate.IsZero() {
+			date = release.ReleaseDate.Format("2006-01-02")
+		}
+		fmt.Fprintf(buf, "| %s | %s | %s |\n", release.Version, date, valueOrUnknown(release.DartSDKVersion))
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// SearchDeprecations handles the search_deprecations tool
+func (h *MCPHandlers) SearchDeprecations(ctx context.Context, args models.SearchDeprecationsArgs) (*mcp_golang.ToolResponse, error) {
+	if strings.TrimSpace(args.Query) == "" {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Please provide a search query, e.g. snackbar or opacity."),
+		), nil
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = config.DEFAULT_SEARCH_RESULTS
+	}
+
+	matches := h.deprecationService.SearchDeprecations(args.Query, limit)
+	setStructuredContent(ctx, models.DeprecationSearchResult{Query: args.Query, Matches: matches})
+	if len(matches) == 0 {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("No deprecations match %q.", args.Query)),
+		), nil
+	}
+
+	deprecations := make([]models.Deprecation, len(matches))
+	for i, match := range matches {
+		deprecations[i] = match.Deprecation
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Deprecations matching %q (best match first):\n\n", args.Query)
+	writeDeprecations(buf, i18n.English, deprecations)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// writeFixPreviews renders each rewritten block of the user's code as a diff against the original
+func writeFixPreviews(buf *bytes.Buffer, previews []models.FixPreview) {
+	for _, preview := range previews {
+		fmt.Fprintf(buf, "Line %d (%s):\n\n```diff\n", preview.Line, strings.Join(preview.APIs, ", "))
+		for _, line := range strings.Split(preview.Before, "\n") {
+			fmt.Fprintf(buf, "- %s\n", line)
+		}
+		for _, line := range strings.Split(preview.After, "\n") {
+			fmt.Fprintf(buf, "+ %s\n", line)
+		}
+		buf.WriteString("```\n\n")
+	}
+}
+
+// writeMigrationTemplate formats where each old constructor parameter goes, followed by the
+// complete before and after code
+func writeMigrationTemplate(buf *bytes.Buffer, template models.MigrationTemplate) {
+	buf.WriteString("Parameters:\n\n")
+	for _, parameter := range template.Parameters {
+		fmt.Fprintf(buf, "- `%s` → %s\n", parameter.Old, parameter.New)
+	}
+	fmt.Fprintf(buf, "\nBefore:\n\n```dart\n%s\n```\n\nAfter:\n\n```dart\n%s\n```\n", template.Before, template.After)
+}
+
+// valueOrUnknown substitutes a placeholder for fields that were not recorded
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// describeStatus tells whether a scanned entry is still annotated upstream
+func describeStatus(p *i18n.Printer, dep models.Deprecation) string {
+	if dep.Removed {
+		return p.Sprintf("removed upstream (annotation last seen %s), usually because the API itself was removed", dep.LastSeen.Format("2006-01-02"))
+	}
+	if dep.Upcoming {
+		return p.Sprintf("upcoming: deprecated on the %s channel (confirmed %s) but not yet in stable", dep.Channel, dep.LastSeen.Format("2006-01-02"))
+	}
+	return p.Sprintf("still deprecated (confirmed upstream %s)", dep.LastSeen.Format("2006-01-02"))
+}
+
+// describeSource explains where a deprecation entry came from
+func describeSource(source string) string {
+	switch source {
+	case config.DEPRECATION_SOURCE_BUILTIN:
+		return "built-in pattern shipped with this server"
+	case config.DEPRECATION_SOURCE_FLUTTER:
+		return "@Deprecated annotation in the Flutter framework source"
+	case config.DEPRECATION_SOURCE_PACKAGES:
+		return "@Deprecated annotation in a first-party plugin from flutter/packages"
+	case config.DEPRECATION_SOURCE_RELEASE_NOTES:
+		return "Flutter release notes"
+	case config.DEPRECATION_SOURCE_MANUAL:
+		return "added manually with add_deprecation"
+	case config.DEPRECATION_SOURCE_REPO:
+		return "@Deprecated annotation in a GitHub repository scanned with scan_repo_deprecations"
+	case "":
+		return "unknown (cached before provenance was recorded; run update_flutter_deprecations)"
+	default:
+		return source
+	}
+}
+
+// DeprecationStats handles the deprecation_stats tool
+func (h *MCPHandlers) DeprecationStats(ctx context.Context, args models.DeprecationStatsArgs) (*mcp_golang.ToolResponse, error) {
+	recent := args.Recent
+	if recent <= 0 {
+		recent = config.DEFAULT_STATS_RECENT
+	}
+
+	stats, err := h.deprecationService.DeprecationStats(recent)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading deprecations: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, stats)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("# Deprecation statistics\n\n")
+	if stats.LastUpdated.IsZero() {
+		buf.WriteString("The cache has never been updated; run update_flutter_deprecations.\n")
+	} else {
+		fmt.Fprintf(buf, "Cache last updated: %s\n", stats.LastUpdated.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(buf, "Total entries: %d (%d manual)\n", stats.Total, stats.Manual)
+	if stats.Removed > 0 {
+		fmt.Fprintf(buf, "Removed upstream: %d (no longer annotated in the latest scan)\n", stats.Removed)
+	}
+	if stats.Upcoming > 0 {
+		fmt.Fprintf(buf, "Upcoming: %d (deprecated on the preview channel but not yet in stable)\n", stats.Upcoming)
+	}
+	if stats.Total == 0 {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	versions := make([]string, 0, len(stats.ByVersion))
+	for version := range stats.ByVersion {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		result, ok := services.CompareVersions(versions[i], versions[j])
+		if !ok {
+			// Releases before "unknown"
+			return versions[j] == "unknown" && versions[i] != "unknown"
+		}
+		return result > 0
+	})
+	buf.WriteString("\n## By Flutter release\n\n")
+	for _, version := range versions {
+		fmt.Fprintf(buf, "- %s: %d\n", version, stats.ByVersion[version])
+	}
+
+	writeCounts(buf, "By category", stats.ByCategory)
+	writeCounts(buf, "By severity", stats.BySeverity)
+	writeCounts(buf, "By source", stats.BySource)
+
+	buf.WriteString("\n## Most recently introduced\n\n")
+	if len(stats.Recent) == 0 {
+		buf.WriteString("No entries record the version that deprecated them.\n")
+	}
+	writeDeprecations(buf, i18n.English, stats.Recent)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CacheChanges handles the cache_changes tool
+func (h *MCPHandlers) CacheChanges(ctx context.Context, args models.CacheChangesArgs) (*mcp_golang.ToolResponse, error) {
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error loading deprecations: %v", err)),
+		), nil
+	}
+
+	changes := cache.LastChanges
+	if changes == nil {
+		setStructuredContent(ctx, models.CacheChanges{Added: []models.Deprecation{}, Removed: []models.Deprecation{}, Modified: []models.DeprecationChange{}})
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("No refresh has been recorded yet. Changes are tracked from the next update_flutter_deprecations or scheduled refresh."),
+		), nil
+	}
+
+	setStructuredContent(ctx, changes)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if changes.From.IsZero() {
+		fmt.Fprintf(buf, "The last refresh (%s) filled an empty cache with %d deprecations; changes are listed from the next refresh on.\n",
+			changes.To.Format("2006-01-02 15:04:05"), changes.Total)
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	fmt.Fprintf(buf, "# Changes from %s to %s\n\n", changes.From.Format("2006-01-02 15:04:05"), changes.To.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(buf, "%d added, %d removed, %d modified (%d deprecations cached)\n", len(changes.Added), len(changes.Removed), len(changes.Modified), changes.Total)
+	if len(changes.Added) == 0 && len(changes.Removed) == 0 && len(changes.Modified) == 0 {
+		buf.WriteString("\nNothing changed upstream.\n")
+	}
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	if len(changes.Added) > 0 {
+		buf.WriteString("\n## Added\n\n")
+		budget.writeDeprecations(buf, changes.Added)
+	}
+	if len(changes.Removed) > 0 {
+		buf.WriteString("\n## Removed\n\n")
+		budget.writeDeprecations(buf, changes.Removed)
+	}
+	if len(changes.Modified) > 0 {
+		buf.WriteString("\n## Modified\n\n")
+		shown := budget.take(len(changes.Modified))
+		for _, change := range changes.Modified[:shown] {
+			fmt.Fprintf(buf, "- **%s** (%s)\n", change.After.API, strings.Join(change.Fields, ", "))
+			for _, field := range change.Fields {
+				before, after := changedValues(change, field)
+				fmt.Fprintf(buf, "  - %s: %s → %s\n", field, valueOrUnknown(before), valueOrUnknown(after))
+			}
+		}
+		if shown > 0 && shown < len(changes.Modified) {
+			buf.WriteString("\n")
+		}
+		budget.writeOmitted(buf, shown, len(changes.Modified))
+	}
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// changedValues returns the old and new value of a field named in a DeprecationChange
+func changedValues(change models.DeprecationChange, field string) (string, string) {
+	switch field {
+	case "replacement":
+		return change.Before.Replacement, change.After.Replacement
+	case "description":
+		return change.Before.Description, change.After.Description
+	case "version":
+		return change.Before.Version, change.After.Version
+	case "category":
+		return change.Before.Category, change.After.Category
+	case "severity":
+		return change.Before.Severity, change.After.Severity
+	default:
+		return "", ""
+	}
+}
+
+// writeCounts renders a section of counts, largest first
+func writeCounts(buf *bytes.Buffer, title string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(buf, "\n## %s\n\n", title)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "- %s: %d\n", key, counts[key])
+	}
+}
+
+// AddDeprecation handles the add_deprecation tool
+func (h *MCPHandlers) AddDeprecation(ctx context.Context, args models.AddDeprecationArgs) (*mcp_golang.ToolResponse, error) {
+	var replaced bool
+	note, err := h.shareWithTeam(ctx, func() error {
+		var err error
+		replaced, err = h.deprecationService.AddManualDeprecation(models.Deprecation{
+			API:         args.API,
+			Replacement: args.Replacement,
+			Description: args.Description,
+			Example:     args.Example,
+			Version:     args.Version,
+			DocURL:      args.DocURL,
+		})
+		return err
+	})
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error adding deprecation: %v", err)),
+		), nil
+	}
+
+	setStructuredContent(ctx, models.ManualDeprecationResult{API: strings.TrimSpace(args.API), Updated: replaced})
+
+	action := "Added"
+	if replaced {
+		action = "Updated"
+	}
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(fmt.Sprintf("%s manual deprecation for %s. It is kept when the cache is refreshed.%s", action, strings.TrimSpace(args.API), note)),
+	), nil
+}
+
+// DraftReplacements handles the draft_replacements tool
+func (h *MCPHandlers) DraftReplacements(ctx context.Context, args models.DraftReplacementsArgs) (*mcp_golang.ToolResponse, error) {
+	if h.replacementDrafts == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Replacement drafts are not enabled on this server."),
+		), nil
+	}
+
+	result, err := h.replacementDrafts.DraftReplacements(ctx, args.API, args.Limit)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error drafting replacements: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+	if len(result.Drafts) == 0 {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Every scanned deprecation has a replacement; there is nothing to draft."),
+		), nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	stored := 0
+	for _, draft := range result.Drafts {
+		if draft.Replacement != "" {
+			stored++
+		}
+	}
+	buf.WriteString("# Drafted replacements\n\n")
+	if result.Model != "" {
+		fmt.Fprintf(buf, "Drafted by: %s\n", result.Model)
+	}
+	fmt.Fprintf(buf, "Asked about %d deprecations without a replacement: %d drafts stored, %d deprecations still without one.\n\n", len(result.Drafts), stored, result.Pending)
+	for _, draft := range result.Drafts {
+		name := draft.API
+		if draft.Package != "" {
+			name += " (" + draft.Package + ")"
+		}
+		switch {
+		case draft.Error != "":
+			fmt.Fprintf(buf, "- **%s**: not drafted: %s\n", name, draft.Error)
+		case draft.Replacement == "":
+			fmt.Fprintf(buf, "- **%s**: the model found no replacement\n", name)
+		default:
+			fmt.Fprintf(buf, "- **%s** → %s\n", name, draft.Replacement)
+		}
+		fmt.Fprintf(buf, "  Deprecation message: %s\n", draft.Description)
+	}
+
+	if stored > 0 {
+		h.notifyCacheChanged()
+		buf.WriteString("\nThe drafts are AI-generated and unverified. They are flagged as drafted in the cache, never applied by migrate_code or get_analyzer_fixes, and dropped when the annotation changes. Check them against the API documentation before migrating.\n")
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ScanRepoDeprecations handles the scan_repo_deprecations tool
+func (h *MCPHandlers) ScanRepoDeprecations(ctx context.Context, args models.ScanRepoArgs) (*mcp_golang.ToolResponse, error) {
+	if h.repoScans == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Repository scans are not enabled on this server."),
+		), nil
+	}
+
+	result, err := h.repoScans.Scan(ctx, args.Repo, args.Ref, args.Path, args.Package)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error scanning repository: %v", err)),
+		), nil
+	}
+	outcome := &models.RepoScanOutcome{Scan: *result}
+	setStructuredContent(ctx, outcome)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Scanned %s", result.Repository)
+	if result.Path != "" {
+		fmt.Fprintf(buf, " in %s", result.Path)
+	}
+	fmt.Fprintf(buf, " (package %s): %d Dart files, %d deprecations\n\n", result.Package, result.Files, len(result.Deprecations))
+	if result.Truncated {
+		fmt.Fprintf(buf, "Note: the scan stopped after %d files; pass a path to scan the rest of the repository separately.\n\n", config.MAX_REPO_SCAN_FILES)
+	}
+	if len(result.Deprecations) == 0 {
+		buf.WriteString("No @Deprecated annotations found.\n")
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+	writeDeprecations(buf, i18n.English, result.Deprecations)
+
+	if !args.Save {
+		buf.WriteString("Pass save: true to store these entries so the checks report them.\n")
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	var saved int
+	note, err := h.shareWithTeam(ctx, func() error {
+		var err error
+		saved, err = h.repoScans.Save(result)
+		return err
+	})
+	if err != nil {
+		outcome.SaveError = err.Error()
+		fmt.Fprintf(buf, "Error saving deprecations: %v\n", err)
+	} else {
+		outcome.Saved = saved
+		fmt.Fprintf(buf, "Saved %d entries, replacing any from an earlier scan of %s. The checks report them in code that imports package:%s/ and they are kept when the cache is refreshed.%s\n", saved, result.Package, result.Package, note)
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ReviewPullRequest handles the review_pull_request tool
+func (h *MCPHandlers) ReviewPullRequest(ctx context.Context, args models.ReviewPullRequestArgs) (*mcp_golang.ToolResponse, error) {
+	if h.prReviews == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Pull request reviews are not enabled on this server."),
+		), nil
+	}
+
+	hidden := make(map[string]bool)
+	if !args.IncludeSuppressed && h.suppressions != nil {
+		suppressions, err := h.suppressions.Suppressions(args.ProjectPath)
+		if err != nil {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Error loading suppressions: %v", err)),
+			), nil
+		}
+		for _, suppression := range suppressions {
+			hidden[suppression.API] = true
+		}
+	}
+
+	result, err := h.prReviews.Review(ctx, args.Repo, args.Number, args.Target, hidden, args.Post)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error reviewing pull request: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Reviewed %s#%d at %s: %d changed Dart files, %d comments\n\n", result.Repository, result.Number, services.ShortCommit(result.HeadSHA), result.Files, len(result.Comments))
+	if result.Existing > 0 {
+		fmt.Fprintf(buf, "Skipped %d uses that earlier reviews already commented on.\n\n", result.Existing)
+	}
+	if len(result.Comments) == 0 {
+		buf.WriteString("No deprecated APIs on the lines this pull request adds.\n")
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	for _, comment := range result.Comments {
+		fmt.Fprintf(buf, "%s:%d: %s\n", comment.Path, comment.Line, strings.Join(comment.APIs, ", "))
+	}
+	buf.WriteString("\n")
+	if result.Omitted > 0 {
+		fmt.Fprintf(buf, "Note: one review holds at most %d comments; %d more lines were left out.\n\n", config.MAX_PR_REVIEW_COMMENTS, result.Omitted)
+	}
+	if result.ReviewURL != "" {
+		fmt.Fprintf(buf, "Posted the review: %s\n", result.ReviewURL)
+	} else {
+		buf.WriteString("Pass post: true to publish these comments as a review of the pull request.\n")
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// SuppressDeprecation handles the suppress_deprecation tool
+func (h *MCPHandlers) SuppressDeprecation(ctx context.Context, args models.SuppressDeprecationArgs) (*mcp_golang.ToolResponse, error) {
+	if h.suppressions == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Suppressions are not enabled on this server."),
+		), nil
+	}
+
+	// Suppressions match exact API names, so store the name the deprecation is known by
+	api := strings.TrimSpace(args.API)
+	known := h.deprecationService.FindDeprecations(api)
+	if len(known) > 0 {
+		api = known[0].API
+	}
+
+	// Project suppressions live in the project and are shared through its repository instead
+	scope := "on this machine"
+	share := h.shareWithTeam
+	if args.ProjectPath != "" {
+		scope = "in " + args.ProjectPath
+		share = func(ctx context.Context, change func() error) (string, error) { return "", change() }
+	}
+
+	// Project suppressions are written into the repository of the project, so the user confirms them
+	if args.ProjectPath != "" {
+		file := filepath.Join(args.ProjectPath, config.PROJECT_SUPPRESSIONS_FILE)
+		summary := fmt.Sprintf("Suppress %s in %s? This writes the suppression to %s, which is shared through the repository of the project.", api, args.ProjectPath, file)
+		if args.Remove {
+			summary = fmt.Sprintf("Remove the suppression for %s from %s? This rewrites %s.", api, args.ProjectPath, file)
+		}
+		asked, accepted, err := h.confirmChange(ctx, summary)
+		if err != nil {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Error asking for confirmation: %v", err)),
+			), nil
+		}
+		if asked && !accepted {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Left %s unchanged: the user did not confirm the change.", file)),
+			), nil
+		}
+	}
+
+	if args.Remove {
+		var removed bool
+		note, err := share(ctx, func() error {
+			var err error
+			removed, err = h.suppressions.Unsuppress(api, args.ProjectPath)
+			return err
+		})
+		if err != nil {
+			return mcp_golang.NewToolResponse(
+				mcp_golang.NewTextContent(fmt.Sprintf("Error removing suppression: %v", err)),
+			), nil
+		}
+		setStructuredContent(ctx, models.SuppressionChange{API: api, ProjectPath: args.ProjectPath, Changed: removed, Known: len(known) > 0})
+		message := fmt.Sprintf("%s was not suppressed %s.", api, scope)
+		if removed {
+			message = fmt.Sprintf("Removed the suppression for %s %s.", api, scope)
+		}
+		return mcp_golang.NewToolResponse(mcp_golang.NewTextContent(message + note)), nil
+	}
+
+	note, err := share(ctx, func() error {
+		_, err := h.suppressions.Suppress(api, args.Reason, args.ProjectPath)
+		return err
+	})
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error suppressing deprecation: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, models.SuppressionChange{API: api, ProjectPath: args.ProjectPath, Suppressed: true, Changed: true, Known: len(known) > 0})
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Suppressed %s %s. It is left out of check_flutter_deprecations and list_flutter_deprecations unless include_suppressed is true.", api, scope)
+	if len(known) == 0 {
+		fmt.Fprintf(buf, "\n\nNote: %s does not match any known deprecation yet.", api)
+	}
+	buf.WriteString(note)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// SyncTeamDatabase handles the sync_team_database tool
+func (h *MCPHandlers) SyncTeamDatabase(ctx context.Context, args models.NoArguments) (*mcp_golang.ToolResponse, error) {
+	if h.teamSync == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("No team database is configured. Start the server with --team-db-url to share manual entries and suppressions."),
+		), nil
+	}
+
+	manual := 0
+	if h.cacheService != nil {
+		if cache, err := h.cacheService.Load(); err == nil {
+			manual = len(cache.Manual)
+		}
+	}
+	asked, accepted, err := h.confirmChange(ctx, fmt.Sprintf("Sync with the team database? This replaces the %d local manual entries and the machine-wide suppressions with those of the team database.", manual))
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error asking for confirmation: %v", err)),
+		), nil
+	}
+	if asked && !accepted {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Did not sync with the team database: the user did not confirm the change."),
+		), nil
+	}
+
+	db, err := h.teamSync.Pull(ctx)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error syncing team database: %v", err)),
+		), nil
+	}
+	h.notifyCacheChanged()
+	setStructuredContent(ctx, db)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(fmt.Sprintf("Synced with the team database: %d manual entries and %d suppressions (last updated: %s).",
+			len(db.Manual), len(db.Suppressions), db.UpdatedAt.Format("2006-01-02 15:04:05"))),
+	), nil
+}
+
+// UpdateFlutterDeprecations handles the update_flutter_deprecations tool
+func (h *MCPHandlers) UpdateFlutterDeprecations(ctx context.Context, args models.UpdateDeprecationsArgs) (*mcp_golang.ToolResponse, error) {
+	if len(args.Directories) > 0 || len(args.Sources) > 0 {
+		return h.updateScoped(ctx, args)
+	}
+
+	if err := h.deprecationService.UpdateCache(ctx); err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error updating deprecations cache: %v", err)),
+		), nil
+	}
+
+	h.notifyCacheChanged()
+
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Cache updated but failed to load for verification: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, models.CacheUpdateResult{Deprecations: len(cache.Deprecations), LastUpdated: cache.LastUpdated})
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(fmt.Sprintf("Successfully updated deprecations cache. Found %d deprecations. Last updated: %s",
+			len(cache.Deprecations), cache.LastUpdated.Format("2006-01-02 15:04:05"))),
+	), nil
+}
+
+// CacheStatus handles the cache_status tool
+func (h *MCPHandlers) CacheStatus(ctx context.Context, args models.NoArguments) (*mcp_golang.ToolResponse, error) {
+	if h.cacheStatus == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Cache status is not enabled on this server."),
+		), nil
+	}
+
+	status, err := h.cacheStatus.Status()
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error reading the cache status: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, status)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("# Cache status\n\n")
+	if !status.Exists {
+		fmt.Fprintf(buf, "No cache file at %s. Run update_flutter_deprecations to build it.\n", status.Path)
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	fmt.Fprintf(buf, "File: %s (%d bytes)\n", status.Path, status.SizeBytes)
+	if status.LastUpdated.IsZero() {
+		buf.WriteString("Last updated: never\n")
+	} else {
+		fmt.Fprintf(buf, "Last updated: %s (%s ago)\n", status.LastUpdated.Format("2006-01-02 15:04:05"), status.Age.Round(time.Minute))
+	}
+	if status.Expired {
+		fmt.Fprintf(buf, "Freshness: expired (TTL %s); run update_flutter_deprecations before relying on the results\n", status.TTL)
+	} else {
+		fmt.Fprintf(buf, "Freshness: fresh (TTL %s); update_flutter_deprecations rescans after %s\n", status.TTL, status.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(buf, "Entries: %d deprecations, %d manual\n", status.Deprecations, status.Manual)
+	if status.Removed > 0 {
+		fmt.Fprintf(buf, "Removed upstream: %d (no longer annotated in the latest scan)\n", status.Removed)
+	}
+
+	if len(status.BySource) > 0 {
+		writeCounts(buf, "By source", status.BySource)
+	}
+	if len(status.Refs) > 0 {
+		writeCounts(buf, "Scanned refs", status.Refs)
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// updateScoped refreshes the directories and sources of a scoped update_flutter_deprecations call
+func (h *MCPHandlers) updateScoped(ctx context.Context, args models.UpdateDeprecationsArgs) (*mcp_golang.ToolResponse, error) {
+	if err := h.deprecationService.UpdateCacheScoped(ctx, args.Directories, args.Sources); err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error updating deprecations cache: %v", err)),
+		), nil
+	}
+
+	h.notifyCacheChanged()
+
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Cache updated but failed to load for verification: %v", err)),
+		), nil
+	}
+
+	parts := append(append([]string{}, args.Directories...), args.Sources...)
+	setStructuredContent(ctx, models.CacheUpdateResult{Refreshed: parts, Deprecations: len(cache.Deprecations), LastUpdated: cache.LastUpdated})
+
+	refreshed := strings.Join(parts, ", ")
+	lastUpdated := "never"
+	if !cache.LastUpdated.IsZero() {
+		lastUpdated = cache.LastUpdated.Format("2006-01-02 15:04:05")
+	}
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(fmt.Sprintf("Successfully refreshed %s. Found %d deprecations. Last full update: %s",
+			refreshed, len(cache.Deprecations), lastUpdated)),
+	), nil
 }
 
-// CheckFlutterDeprecations handles the check_flutter_deprecations tool
-func (h *MCPHandlers) CheckFlutterDeprecations(args models.CheckCodeArgs) (*mcp_golang.ToolResponse, error) {
-	deprecations := h.deprecationService.CheckCodeForDeprecations(args.Code)
+// ClearFlutterDeprecationsCache handles the clear_flutter_deprecations_cache tool
+func (h *MCPHandlers) ClearFlutterDeprecationsCache(ctx context.Context, args models.ClearCacheArgs) (*mcp_golang.ToolResponse, error) {
+	if h.cacheClear == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Clearing the cache is not enabled on this server."),
+		), nil
+	}
 
-	if len(deprecations) == 0 {
+	var deprecations, manual int
+	if cache, err := h.cacheService.Load(); err == nil {
+		deprecations, manual = len(cache.Deprecations), len(cache.Manual)
+	}
+	// A client that supports elicitation asks the user, whose answer replaces the confirm argument
+	asked, accepted, err := h.confirmChange(ctx, fmt.Sprintf("Clear the deprecations cache? This deletes %d deprecations and %d manual entries with the cached upstream responses and scan results.", deprecations, manual))
+	if err != nil {
 		return mcp_golang.NewToolResponse(
-			mcp_golang.NewTextContent("No deprecated APIs found in the provided code."),
+			mcp_golang.NewTextContent(fmt.Sprintf("Error asking for confirmation: %v", err)),
+		), nil
+	}
+	if asked && !accepted {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Did not clear the deprecations cache: the user did not confirm the change."),
+		), nil
+	}
+	if !asked && !args.Confirm {
+		setStructuredContent(ctx, models.CacheClearResult{Deprecations: deprecations, Manual: manual})
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("This deletes the deprecations cache (%d deprecations and %d manual entries) with the cached upstream responses and scan results. Call clear_flutter_deprecations_cache again with confirm: true to clear it.", deprecations, manual)),
 		), nil
 	}
 
-	result := "Found deprecated APIs:\n\n"
-	for i, dep := range deprecations {
-		result += fmt.Sprintf("%d. **%s**\n", i+1, dep.API)
-		if dep.Replacement != "" {
-			result += fmt.Sprintf("   - Replacement: %s\n", dep.Replacement)
+	if err := h.cacheClear.ClearAll(); err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error clearing %v", err)),
+		), nil
+	}
+
+	h.notifyCacheChanged()
+	setStructuredContent(ctx, models.CacheClearResult{Cleared: true, Deprecations: deprecations, Manual: manual})
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(fmt.Sprintf("Successfully cleared the deprecations cache, removing %d deprecations and %d manual entries. Run update_flutter_deprecations to rebuild it.", deprecations, manual)),
+	), nil
+}
+
+// DeprecationsResource serves the deprecations cache resource as JSON
+func (h *MCPHandlers) DeprecationsResource(ctx context.Context) (*mcp_golang.ResourceResponse, error) {
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading deprecations: %v", err)
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return mcp_golang.NewResourceResponse(
+		mcp_golang.NewTextEmbeddedResource(config.CACHE_RESOURCE_URI, string(data), "application/json"),
+	), nil
+}
+
+// DeprecationPartitions lists the slices of the deprecations cache, one per Flutter release and
+// category, that are served as resources of the deprecations resource template
+func (h *MCPHandlers) DeprecationPartitions() ([]models.DeprecationPartition, error) {
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading deprecations: %v", err)
+	}
+	return services.DeprecationPartitions(cache), nil
+}
+
+// DeprecationPartitionResource returns the handler of the resource serving the deprecations of one
+// Flutter release and category as JSON. The cache is read on every request, so a partition that a
+// refresh emptied is served without entries until it is deregistered.
+func (h *MCPHandlers) DeprecationPartitionResource(version string, category string) func(ctx context.Context) (*mcp_golang.ResourceResponse, error) {
+	return func(ctx context.Context) (*mcp_golang.ResourceResponse, error) {
+		cache, err := h.cacheService.Load()
+		if err != nil {
+			return nil, fmt.Errorf("error loading deprecations: %v", err)
 		}
-		result += fmt.Sprintf("   - Description: %s\n", dep.Description)
-		if dep.Example != "" {
-			result += fmt.Sprintf("   - Example: %s\n", dep.Example)
+
+		partition := models.DeprecationPartition{
+			URI:          services.DeprecationPartitionURI(version, category),
+			Version:      version,
+			Category:     category,
+			LastUpdated:  cache.LastUpdated,
+			Deprecations: []models.Deprecation{},
 		}
-		if dep.Version != "" {
-			result += fmt.Sprintf("   - Since version: %s\n", dep.Version)
+		for _, candidate := range services.DeprecationPartitions(cache) {
+			if candidate.Version == version && candidate.Category == category {
+				partition = candidate
+				break
+			}
+		}
+
+		data, err := json.MarshalIndent(partition, "", "  ")
+		if err != nil {
+			return nil, err
 		}
-		result += "\n"
+		return mcp_golang.NewResourceResponse(
+			mcp_golang.NewTextEmbeddedResource(partition.URI, string(data), "application/json"),
+		), nil
+	}
+}
+
+// GenerateDockerfile handles the generate_dockerfile tool
+func (h *MCPHandlers) GenerateDockerfile(ctx context.Context, args models.GenerateDockerfileArgs) (*mcp_golang.ToolResponse, error) {
+	if h.dockerfiles == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Dockerfile generation is not enabled on this server."),
+		), nil
+	}
+
+	result, err := h.dockerfiles.Generate(ctx, args.Version, args.Target, args.Image)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error generating Dockerfile: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, result)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Dockerfile for a Flutter %s %s build on %s\n\n", result.Version, result.Target, result.Image)
+	for _, image := range result.Unavailable {
+		fmt.Fprintf(buf, "Note: %s is not published, so it was skipped.\n\n", image)
+	}
+	fmt.Fprintf(buf, "## Dockerfile\n\n```dockerfile\n%s```\n\n", result.Dockerfile)
+	if result.Compose != "" {
+		fmt.Fprintf(buf, "## docker-compose.yml\n\n```yaml\n%s```\n\n", result.Compose)
 	}
+	fmt.Fprintf(buf, "## .dockerignore\n\n```\n%s```\n\n", result.Dockerignore)
+	fmt.Fprintf(buf, "## Usage\n\n```bash\n%s\n```\n", result.Usage)
 
 	return mcp_golang.NewToolResponse(
-		mcp_golang.NewTextContent(result),
+		mcp_golang.NewTextContent(buf.String()),
 	), nil
 }
 
-// ListFlutterDeprecations handles the list_flutter_deprecations tool
-func (h *MCPHandlers) ListFlutterDeprecations(args models.NoArguments) (*mcp_golang.ToolResponse, error) {
-	cache, err := h.cacheService.Load()
+// CheckCIWorkflow handles the check_ci_workflow tool
+func (h *MCPHandlers) CheckCIWorkflow(ctx context.Context, args models.CheckCIWorkflowArgs) (*mcp_golang.ToolResponse, error) {
+	if h.ciWorkflows == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("CI workflow checks are not enabled on this server."),
+		), nil
+	}
+
+	var report *models.CIWorkflowReport
+	var err error
+	switch {
+	case strings.TrimSpace(args.Content) != "":
+		report, err = h.ciWorkflows.CheckContent(ctx, args.Content)
+	case strings.TrimSpace(args.Path) != "":
+		report, err = h.ciWorkflows.CheckPath(ctx, strings.TrimSpace(args.Path))
+	default:
+		err = fmt.Errorf("either path or content is required")
+	}
 	if err != nil {
 		return mcp_golang.NewToolResponse(
-			mcp_golang.NewTextContent(fmt.Sprintf("Error loading deprecations: %v", err)),
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking CI workflow: %v", err)),
 		), nil
 	}
+	setStructuredContent(ctx, report)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Flutter versions in CI (%d file(s) scanned)\n\n", report.FilesScanned)
+	if report.LatestStable != "" {
+		fmt.Fprintf(buf, "Latest stable release: %s\n\n", report.LatestStable)
+	} else if report.LatestError != "" {
+		fmt.Fprintf(buf, "Latest stable release could not be determined: %s\n\n", report.LatestError)
+	}
 
-	if len(cache.Deprecations) == 0 {
+	if len(report.Pins) == 0 {
+		buf.WriteString("No Flutter setup actions or Flutter images found.\n")
 		return mcp_golang.NewToolResponse(
-			mcp_golang.NewTextContent("No deprecations found in cache. Try updating the cache first."),
+			mcp_golang.NewTextContent(buf.String()),
 		), nil
 	}
 
-	result := fmt.Sprintf("Flutter Deprecations (Last updated: %s)\n\n", cache.LastUpdated.Format("2006-01-02 15:04:05"))
+	sections := []struct{ status, title string }{
+		{config.CI_PIN_UNAVAILABLE, "Unavailable"},
+		{config.CI_PIN_OUTDATED, "Outdated"},
+		{config.CI_PIN_FLOATING, "Floating"},
+		{config.CI_PIN_UNKNOWN, "Unknown"},
+		{config.CI_PIN_CURRENT, "Current"},
+	}
+	for _, section := range sections {
+		var pins []models.CIFlutterPin
+		for _, pin := range report.Pins {
+			if pin.Status == section.status {
+				pins = append(pins, pin)
+			}
+		}
+		if len(pins) == 0 {
+			continue
+		}
 
-	sort.Slice(cache.Deprecations, func(i, j int) bool {
-		return cache.Deprecations[i].API < cache.Deprecations[j].API
-	})
+		fmt.Fprintf(buf, "## %s\n\n", section.title)
+		for _, pin := range pins {
+			location := fmt.Sprintf("line %d", pin.Line)
+			if pin.File != "" {
+				location = fmt.Sprintf("%s:%d", pin.File, pin.Line)
+			}
+			version := pin.Version
+			if version == "" {
+				version = "(not pinned)"
+			}
+			fmt.Fprintf(buf, "- %s %s (%s", pin.Source, version, location)
+			if pin.Job != "" {
+				fmt.Fprintf(buf, ", job %s", pin.Job)
+			}
+			if pin.Channel != "" {
+				fmt.Fprintf(buf, ", channel %s", pin.Channel)
+			}
+			buf.WriteString(")\n")
+			if pin.Suggestion != "" {
+				fmt.Fprintf(buf, "  → %s\n", pin.Suggestion)
+			}
+		}
+		buf.WriteString("\n")
+	}
 
-	for i, dep := range cache.Deprecations {
-		result += fmt.Sprintf("%d. **%s**\n", i+1, dep.API)
-		if dep.Replacement != "" {
-			result += fmt.Sprintf("   - Replacement: %s\n", dep.Replacement)
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CheckFlutterWeb handles the check_flutter_web tool
+func (h *MCPHandlers) CheckFlutterWeb(ctx context.Context, args models.CheckFlutterWebArgs) (*mcp_golang.ToolResponse, error) {
+	if h.webChecks == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Web checks are not enabled on this server."),
+		), nil
+	}
+
+	var report *models.WebCheckReport
+	var err error
+	switch {
+	case strings.TrimSpace(args.Content) != "":
+		report, err = h.webChecks.CheckContent(ctx, args.Content, args.Version)
+	case strings.TrimSpace(args.Path) != "":
+		report, err = h.webChecks.CheckPath(ctx, strings.TrimSpace(args.Path), args.Version)
+	default:
+		err = fmt.Errorf("either path or content is required")
+	}
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking web setup: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, report)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Flutter web setup (%d file(s) scanned)\n\n", report.FilesScanned)
+	if report.FlutterVersion != "" {
+		fmt.Fprintf(buf, "Suggestions for Flutter %s (%s)\n\n", report.FlutterVersion, report.VersionSource)
+	} else if report.VersionError != "" {
+		fmt.Fprintf(buf, "Flutter version could not be determined: %s; suggestions are for the latest releases\n\n", report.VersionError)
+	}
+
+	if len(report.Findings) == 0 {
+		buf.WriteString("No deprecated web renderer flags, index.html bootstraps or web libraries found.\n")
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	sections := []struct{ severity, title string }{
+		{config.SEVERITY_ERROR, "Removed"},
+		{config.SEVERITY_WARNING, "Deprecated"},
+		{config.SEVERITY_INFO, "Superseded"},
+	}
+	for _, section := range sections {
+		var findings []models.WebFinding
+		for _, finding := range report.Findings {
+			if finding.Severity == section.severity {
+				findings = append(findings, finding)
+			}
 		}
-		result += fmt.Sprintf("   - Description: %s\n", dep.Description)
-		if dep.Example != "" {
-			result += fmt.Sprintf("   - Example: %s\n", dep.Example)
+		if len(findings) == 0 {
+			continue
 		}
-		if dep.Version != "" {
-			result += fmt.Sprintf("   - Since version: %s\n", dep.Version)
+
+		fmt.Fprintf(buf, "## %s\n\n", section.title)
+		shown := budget.take(len(findings))
+		for _, finding := range findings[:shown] {
+			location := fmt.Sprintf("line %d", finding.Line)
+			if finding.File != "" {
+				location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+			}
+			fmt.Fprintf(buf, "- %s (%s): `%s`\n", finding.API, location, finding.Match)
+			fmt.Fprintf(buf, "  → %s\n", finding.Suggestion)
+			if finding.DocURL != "" {
+				fmt.Fprintf(buf, "  Documentation: %s\n", finding.DocURL)
+			}
+		}
+		buf.WriteString("\n")
+		budget.writeOmitted(buf, shown, len(findings))
+	}
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CheckDesktopRunners handles the check_desktop_runners tool
+func (h *MCPHandlers) CheckDesktopRunners(ctx context.Context, args models.CheckDesktopRunnersArgs) (*mcp_golang.ToolResponse, error) {
+	if h.runnerTemplates == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Desktop runner checks are not enabled on this server."),
+		), nil
+	}
+
+	projectPath, err := h.requireProjectPath(ctx, args.ProjectPath)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking desktop runners: %v", err)),
+		), nil
+	}
+
+	report, err := h.runnerTemplates.Check(ctx, projectPath, args.Version, args.Platforms)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error checking desktop runners: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, report)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Desktop runners of %s (%s)\n\n", report.ProjectPath, strings.Join(report.Platforms, ", "))
+	if report.FlutterVersion != "" {
+		fmt.Fprintf(buf, "Compared with the Flutter %s templates (%s)", report.FlutterVersion, report.VersionSource)
+		if report.SDKPath != "" {
+			fmt.Fprintf(buf, " from %s", report.SDKPath)
+		}
+		buf.WriteString("\n\n")
+	}
+	if len(report.Warnings) > 0 {
+		buf.WriteString("## Warnings\n\n")
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(buf, "- %s\n", warning)
+		}
+		buf.WriteString("\n")
+	}
+
+	var missing, stale []models.RunnerFileDrift
+	current := 0
+	for _, file := range report.Files {
+		switch file.Status {
+		case config.RUNNER_FILE_MISSING:
+			missing = append(missing, file)
+		case config.RUNNER_FILE_STALE:
+			stale = append(stale, file)
+		default:
+			current++
+		}
+	}
+
+	if len(report.Issues) == 0 && len(missing) == 0 && len(stale) == 0 {
+		if current > 0 {
+			fmt.Fprintf(buf, "All %d runner file(s) match the templates.\n", current)
+		} else {
+			buf.WriteString("No stale runner template code found.\n")
+		}
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(buf.String()),
+		), nil
+	}
+
+	budget := newResultBudget(args.ResultLimits, i18n.English)
+	if len(report.Issues) > 0 {
+		buf.WriteString("## Known stale template code\n\n")
+		shown := budget.take(len(report.Issues))
+		for _, issue := range report.Issues[:shown] {
+			location := issue.File
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+			}
+			fmt.Fprintf(buf, "- %s (%s, changed in Flutter %s)\n", issue.API, location, issue.Version)
+			fmt.Fprintf(buf, "  → %s\n", issue.Message)
+		}
+		buf.WriteString("\n")
+		budget.writeOmitted(buf, shown, len(report.Issues))
+	}
+
+	if len(missing) > 0 {
+		buf.WriteString("## Missing files\n\n")
+		shown := budget.take(len(missing))
+		for _, file := range missing[:shown] {
+			fmt.Fprintf(buf, "- %s\n", file.File)
+		}
+		buf.WriteString("\n")
+		budget.writeOmitted(buf, shown, len(missing))
+	}
+
+	if len(stale) > 0 {
+		buf.WriteString("## Generated differently\n\n")
+		shown := budget.take(len(stale))
+		for _, file := range stale[:shown] {
+			fmt.Fprintf(buf, "- %s (%d line(s) added, %d removed or customized)\n", file.File, len(file.Added), len(file.Removed))
+			for _, line := range file.Added[:min(len(file.Added), config.RUNNER_SAMPLE_LINES)] {
+				fmt.Fprintf(buf, "  + %s\n", line)
+			}
+			for _, line := range file.Removed[:min(len(file.Removed), config.RUNNER_SAMPLE_LINES)] {
+				fmt.Fprintf(buf, "  - %s\n", line)
+			}
+		}
+		buf.WriteString("\n")
+		budget.writeOmitted(buf, shown, len(stale))
+	}
+	if current > 0 {
+		fmt.Fprintf(buf, "%d other runner file(s) match the templates.\n\n", current)
+	}
+
+	buf.WriteString("To regenerate a runner, move the platform folder away, run flutter create --platforms=<platform> . and re-apply your customizations from the old folder.\n")
+	budget.writeFooter(buf)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// ListFlutterSDKs handles the list_flutter_sdks tool
+func (h *MCPHandlers) ListFlutterSDKs(ctx context.Context, args models.ListFlutterSDKsArgs) (*mcp_golang.ToolResponse, error) {
+	if h.localSDKs == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Local SDK detection is not enabled on this server."),
+		), nil
+	}
+
+	report, err := h.localSDKs.Detect(ctx, strings.TrimSpace(args.ProjectPath))
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error detecting Flutter SDKs: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, report)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Found %d Flutter SDK(s)\n\n", len(report.SDKs))
+	for _, sdk := range report.SDKs {
+		version := sdk.Version
+		if version == "" {
+			version = "unknown version"
+		}
+		fmt.Fprintf(buf, "- %s", version)
+		if sdk.Channel != "" {
+			fmt.Fprintf(buf, " (channel %s)", sdk.Channel)
+		}
+		fmt.Fprintf(buf, " at %s", sdk.Path)
+		if sdk.Active {
+			buf.WriteString(" [active]")
+		}
+		buf.WriteString("\n")
+
+		sources := strings.Join(sdk.Sources, ", ")
+		if sdk.Name != "" {
+			sources += " " + sdk.Name
+		}
+		fmt.Fprintf(buf, "  Found via: %s\n", sources)
+	}
+
+	if report.PinnedBy != "" {
+		pinned := report.PinnedVersion
+		if pinned == "" {
+			pinned = "an environment that is not installed"
+		}
+		fmt.Fprintf(buf, "\nProject pin: %s (from %s)\n", pinned, report.PinnedBy)
+	} else if report.ProjectPath != "" {
+		buf.WriteString("\nThe project does not pin a Flutter version.\n")
+	}
+
+	if len(report.Warnings) > 0 {
+		buf.WriteString("\n## Warnings\n\n")
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(buf, "- %s\n", warning)
 		}
-		result += "\n"
 	}
 
 	return mcp_golang.NewToolResponse(
-		mcp_golang.NewTextContent(result),
+		mcp_golang.NewTextContent(buf.String()),
 	), nil
 }
 
 // CheckFlutterVersionInfo handles the check_flutter_version_info tool
-func (h *MCPHandlers) CheckFlutterVersionInfo(args models.NoArguments) (*mcp_golang.ToolResponse, error) {
-	info, err := h.versionInfoService.GetFlutterVersionInfo()
+func (h *MCPHandlers) CheckFlutterVersionInfo(ctx context.Context, args models.NoArguments) (*mcp_golang.ToolResponse, error) {
+	info, err := h.versionInfoService.GetFlutterVersionInfo(ctx)
 	if err != nil {
 		return mcp_golang.NewToolResponse(
 			mcp_golang.NewTextContent(fmt.Sprintf("Error getting Flutter version info: %v", err)),
 		), nil
 	}
+	setStructuredContent(ctx, info)
 
 	return mcp_golang.NewToolResponse(
 		mcp_golang.NewTextContent(info.Details),
 	), nil
 }
+
+// ServerStats handles the server_stats tool
+func (h *MCPHandlers) ServerStats(ctx context.Context, args models.NoArguments) (*mcp_golang.ToolResponse, error) {
+	if h.statsService == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Usage statistics are not enabled on this server."),
+		), nil
+	}
+
+	stats := h.statsService.Snapshot()
+	setStructuredContent(ctx, stats)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "Server Statistics (since %s, uptime %s)\n\n", stats.Since.Format("2006-01-02 15:04:05"), time.Since(stats.Since).Round(time.Second))
+
+	buf.WriteString("## Tools\n\n")
+	writeCallStats(buf, "Tool", stats.Tools)
+
+	buf.WriteString("\n## Upstream Calls\n\n")
+	buf.WriteString("Latency of GitHub, Docker Hub, official releases API and local exec calls made on behalf of tools.\n\n")
+	writeCallStats(buf, "Upstream", stats.Upstreams)
+
+	buf.WriteString("\n## Deprecations Cache\n\n")
+	writeCacheStats(buf, stats.Cache)
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// RateLimitStatus handles the rate_limit_status tool
+func (h *MCPHandlers) RateLimitStatus(ctx context.Context, args models.NoArguments) (*mcp_golang.ToolResponse, error) {
+	if h.rateLimits == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Rate limit reporting is not enabled on this server."),
+		), nil
+	}
+
+	status, err := h.rateLimits.RateLimitStatus(ctx)
+	if err != nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent(fmt.Sprintf("Error getting the GitHub rate limit: %v", err)),
+		), nil
+	}
+	setStructuredContent(ctx, status)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("GitHub API Rate Limit\n\n")
+	if status.Authenticated {
+		fmt.Fprintf(buf, "Authenticated: yes (token from %s)\n", config.GITHUB_TOKEN_ENV)
+	} else {
+		fmt.Fprintf(buf, "Authenticated: no (set %s for 5,000 requests per hour instead of 60)\n", config.GITHUB_TOKEN_ENV)
+	}
+	fmt.Fprintf(buf, "Remaining: %d of %d (%d used)\n", status.Remaining, status.Limit, status.Used)
+	resetIn := time.Until(status.Reset).Round(time.Minute)
+	if resetIn > 0 {
+		fmt.Fprintf(buf, "Resets: %s (in %s)\n", status.Reset.UTC().Format("2006-01-02 15:04 MST"), resetIn)
+	} else {
+		fmt.Fprintf(buf, "Resets: %s\n", status.Reset.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	fmt.Fprintf(buf, "Source: %s at %s\n", status.Source, status.ObservedAt.Format("2006-01-02 15:04:05"))
+	if status.Error != "" {
+		fmt.Fprintf(buf, "\n⚠️ The rate_limit endpoint failed (%s); the quota may have changed since.\n", status.Error)
+	}
+
+	if status.Remaining == 0 && resetIn > 0 {
+		fmt.Fprintf(buf, "\n❌ The quota is used up: cache updates and GitHub scans fail until the reset. Retry in %s", resetIn)
+		if !status.Authenticated {
+			fmt.Fprintf(buf, " or set %s", config.GITHUB_TOKEN_ENV)
+		}
+		buf.WriteString(".\n")
+	} else {
+		buf.WriteString("\n✅ Quota available: a failed update is not caused by the GitHub rate limit.\n")
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// CheckUpstreams handles the check_upstreams tool
+func (h *MCPHandlers) CheckUpstreams(ctx context.Context, args models.NoArguments) (*mcp_golang.ToolResponse, error) {
+	if h.upstreams == nil {
+		return mcp_golang.NewToolResponse(
+			mcp_golang.NewTextContent("Upstream checks are not enabled on this server."),
+		), nil
+	}
+
+	checks := h.upstreams.CheckUpstreams(ctx)
+	setStructuredContent(ctx, models.UpstreamChecks{Endpoints: checks})
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("# Upstream endpoints\n\n")
+	buf.WriteString("| Endpoint | Status | Latency (ms) | URL |\n")
+	buf.WriteString("|---|---|---|---|\n")
+	var unreachable []string
+	for _, check := range checks {
+		status := fmt.Sprintf("✅ %d", check.Status)
+		if !check.Reachable {
+			unreachable = append(unreachable, check.Name)
+			status = "❌ " + check.Error
+			if check.Error == "" {
+				status = fmt.Sprintf("❌ %d", check.Status)
+			}
+		}
+		fmt.Fprintf(buf, "| %s | %s | %d | %s |\n", check.Name, status, check.Latency.Milliseconds(), check.URL)
+	}
+
+	if len(unreachable) == 0 {
+		buf.WriteString("\nAll upstream endpoints are reachable; a failing tool is not caused by the network. Check rate_limit_status for the GitHub quota.\n")
+	} else {
+		fmt.Fprintf(buf, "\n%d of %d endpoints are unreachable: %s. The tools that rely on them fail until the network, proxy (--ca-file) or mirrors (--upstream-mirrors) are fixed.\n",
+			len(unreachable), len(checks), strings.Join(unreachable, ", "))
+	}
+
+	return mcp_golang.NewToolResponse(
+		mcp_golang.NewTextContent(buf.String()),
+	), nil
+}
+
+// writeCacheStats renders how the deprecations cache was loaded and updated
+func writeCacheStats(buf *bytes.Buffer, stats models.CacheStats) {
+	if stats.Loads == 0 && stats.Rebuilds+stats.ScopedRefreshes+stats.FreshSkips == 0 {
+		buf.WriteString("No cache loads recorded yet.\n")
+		return
+	}
+
+	fmt.Fprintf(buf, "Loads: %d (%d from memory, %d from disk, %d without a cache file)\n", stats.Loads, stats.MemoryHits, stats.DiskReads, stats.Missing)
+	if stats.Loads > 0 {
+		fmt.Fprintf(buf, "Memory hit rate: %.1f%%\n", 100*float64(stats.MemoryHits)/float64(stats.Loads))
+	}
+	fmt.Fprintf(buf, "Stale serves: %d (older than the %s TTL or never fully updated)\n", stats.StaleServes, config.CACHE_DURATION)
+	if stats.MaxServedAge > 0 {
+		fmt.Fprintf(buf, "Oldest data served: %s old\n", stats.MaxServedAge.Round(time.Minute))
+	}
+	fmt.Fprintf(buf, "Updates: %d rebuilds, %d scoped refreshes, %d skipped as fresh\n", stats.Rebuilds, stats.ScopedRefreshes, stats.FreshSkips)
+	if !stats.LastRebuild.IsZero() {
+		fmt.Fprintf(buf, "Last rebuild: %s\n", stats.LastRebuild.Format("2006-01-02 15:04:05"))
+	}
+	if stats.StaleServes > 0 {
+		buf.WriteString("\nStale data was served; run with --daemon to rebuild the cache on a --refresh-schedule, or call update_flutter_deprecations.\n")
+	}
+}
+
+// writeCallStats renders call statistics as a markdown table sorted by name
+func writeCallStats(buf *bytes.Buffer, label string, entries map[string]*models.CallStats) {
+	if len(entries) == 0 {
+		buf.WriteString("No calls recorded yet.\n")
+		return
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(buf, "| %s | Calls | Errors | Avg (ms) | Max (ms) |\n", label)
+	buf.WriteString("|---|---|---|---|---|\n")
+	for _, name := range names {
+		entry := entries[name]
+		avg := 0.0
+		if entry.Calls > 0 {
+			avg = entry.TotalMillis / float64(entry.Calls)
+		}
+		fmt.Fprintf(buf, "| %s | %d | %d | %.1f | %.1f |\n", name, entry.Calls, entry.Errors, avg, entry.MaxMillis)
+	}
+}
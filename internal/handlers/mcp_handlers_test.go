@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/jger/mcp-flutter-deprecations-server/internal/i18n"
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 // MockCacheService for testing
@@ -28,179 +36,2866 @@ func (m *MockCacheService) Save(cache *models.DeprecationCache) error {
 	return nil
 }
 
+// MockCacheClearService empties a MockCacheService, or fails with err
+type MockCacheClearService struct {
+	cache *MockCacheService
+	err   error
+}
+
+func (m *MockCacheClearService) ClearAll() error {
+	if m.err != nil {
+		return m.err
+	}
+	m.cache.cache = &models.DeprecationCache{}
+	return nil
+}
+
+// MockCacheStatusService reports a fixed cache status
+type MockCacheStatusService struct {
+	status *models.CacheStatus
+}
+
+func (m *MockCacheStatusService) Status() (*models.CacheStatus, error) {
+	return m.status, nil
+}
+
 // MockDeprecationService for testing
 type MockDeprecationService struct {
 	deprecations []models.Deprecation
+	migration    models.MigrationResult
+	versionCheck *models.VersionCheckResult
+	stats        *models.DeprecationStats
+	// scoped records the directories and sources of the last scoped update
+	scoped []string
+}
+
+func (m *MockDeprecationService) CheckCodeForDeprecations(code string) []models.Deprecation {
+	return m.deprecations
+}
+
+func (m *MockDeprecationService) CheckDartCode(code string) []models.Deprecation {
+	var found []models.Deprecation
+	for _, dep := range m.deprecations {
+		if strings.HasPrefix(dep.Category, "dart:") {
+			found = append(found, dep)
+		}
+	}
+	return found
+}
+
+func (m *MockDeprecationService) RulesFingerprint(target string) string {
+	return target
+}
+
+func (m *MockDeprecationService) CheckFiles(ctx context.Context, entries []models.CheckFileEntry, projectPath string, target string) ([]models.FileCheckResult, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no files given")
+	}
+	var results []models.FileCheckResult
+	for _, entry := range entries {
+		switch {
+		case entry.Path == "missing.dart":
+			results = append(results, models.FileCheckResult{Name: entry.Path, Path: entry.Path, Error: "open missing.dart: no such file or directory"})
+		case strings.Contains(entry.Code, "deprecated"):
+			results = append(results, models.FileCheckResult{Name: entry.Name, Deprecations: m.deprecations})
+		default:
+			results = append(results, models.FileCheckResult{Name: entry.Name})
+		}
+	}
+	return results, nil
+}
+
+func (m *MockDeprecationService) FindDeprecations(api string) []models.Deprecation {
+	var found []models.Deprecation
+	for _, dep := range m.deprecations {
+		if dep.API == api {
+			found = append(found, dep)
+		}
+	}
+	return found
+}
+
+func (m *MockDeprecationService) SearchDeprecations(query string, limit int) []models.DeprecationMatch {
+	var matches []models.DeprecationMatch
+	for _, dep := range m.deprecations {
+		if strings.Contains(strings.ToLower(dep.API), strings.ToLower(query)) {
+			matches = append(matches, models.DeprecationMatch{Deprecation: dep, Score: 1})
+		}
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func (m *MockDeprecationService) MigrateCode(code string) models.MigrationResult {
+	return m.migration
+}
+
+func (m *MockDeprecationService) AnalyzerFixes(file string, code string, target string) (*models.AnalyzerFixesResult, error) {
+	if file == "/missing.dart" {
+		return nil, fmt.Errorf("open /missing.dart: no such file or directory")
+	}
+	var result models.AnalyzerFixesResult
+	for _, dep := range m.CheckCodeForDeprecations(code) {
+		offset := strings.Index(code, dep.API)
+		fixes := models.AnalysisErrorFixes{
+			API:   dep.API,
+			Error: models.AnalysisError{Severity: "WARNING", Code: "flutter_deprecated_api", Message: dep.API, Location: models.AnalysisLocation{File: file, Offset: offset, Length: len(dep.API)}},
+		}
+		if dep.Replacement != "" {
+			fixes.Error.HasFix = true
+			fixes.Fixes = []models.PrioritizedSourceChange{{Priority: 50, Change: models.SourceChange{Edits: []models.SourceFileEdit{{File: file, Edits: []models.SourceEdit{{Offset: offset, Length: len(dep.API), Replacement: dep.Replacement}}}}}}}
+		}
+		result.Fixes = append(result.Fixes, fixes)
+	}
+	return &result, nil
+}
+
+func (m *MockDeprecationService) FindingsBaseline(result *models.ProjectScanResult, target string) (*models.FindingsBaseline, error) {
+	baseline := &models.FindingsBaseline{}
+	for _, pkg := range result.Packages {
+		for _, finding := range pkg.Findings {
+			for _, dep := range finding.Deprecations {
+				baseline.Findings = append(baseline.Findings, models.BaselineFinding{Path: finding.Name, API: dep.API, Count: 1})
+			}
+		}
+	}
+	return baseline, nil
+}
+
+func (m *MockDeprecationService) ApplyFindingsBaseline(result *models.ProjectScanResult, baseline *models.FindingsBaseline, target string) error {
+	recorded := make(map[string]bool, len(baseline.Findings))
+	for _, finding := range baseline.Findings {
+		recorded[finding.Path+" "+finding.API] = true
+	}
+	for i := range result.Packages {
+		for j := range result.Packages[i].Findings {
+			finding := &result.Packages[i].Findings[j]
+			var deprecations []models.Deprecation
+			for _, dep := range finding.Deprecations {
+				if recorded[finding.Name+" "+dep.API] {
+					result.Baselined++
+				} else {
+					deprecations = append(deprecations, dep)
+				}
+			}
+			finding.Deprecations = deprecations
+		}
+	}
+	return nil
+}
+
+func (m *MockDeprecationService) ProjectDiagnostics(result *models.ProjectScanResult, target string, hidden map[string]bool) ([]models.PublishDiagnosticsParams, error) {
+	files := []models.PublishDiagnosticsParams{}
+	for _, pkg := range result.Packages {
+		for _, finding := range pkg.Findings {
+			var diagnostics []models.Diagnostic
+			for _, dep := range finding.Deprecations {
+				if !hidden[dep.API] {
+					diagnostics = append(diagnostics, models.Diagnostic{Code: dep.API, Severity: 2, Source: "flutter-deprecations", Message: dep.API})
+				}
+			}
+			if len(diagnostics) > 0 {
+				files = append(files, models.PublishDiagnosticsParams{URI: "file:///ws/" + finding.Name, Diagnostics: diagnostics})
+			}
+		}
+	}
+	return files, nil
+}
+
+func (m *MockDeprecationService) CheckCodeAgainstVersion(code string, target string, current string) (*models.VersionCheckResult, error) {
+	if m.versionCheck == nil {
+		return nil, fmt.Errorf("invalid target version %q", target)
+	}
+	return m.versionCheck, nil
+}
+
+func (m *MockDeprecationService) VersionMatrix(ctx context.Context, code string, projectPath string, filter models.PathFilter, targets []string) (*models.VersionMatrix, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no target versions given")
+	}
+	if projectPath != "" {
+		return &models.VersionMatrix{Targets: targets, FilesScanned: 4}, nil
+	}
+	return &models.VersionMatrix{
+		Targets: []string{"3.22.0", "3.27.0"},
+		Rows: []models.VersionMatrixRow{
+			{API: "RaisedButton", Replacement: "ElevatedButton", Version: "2.0.0", Statuses: []string{"deprecated", "deprecated"}},
+			{API: "Color.withOpacity", Replacement: "Color.withValues(alpha: $1)", Compatible: "Color.withAlpha", Version: "3.27.0", Statuses: []string{"ok", "deprecated"}},
+			{API: "Color.withValues", Replacement: "Color.withOpacity", Version: "3.27.0", Introduced: true, Statuses: []string{"unavailable", "ok"}},
+		},
+	}, nil
+}
+
+func (m *MockDeprecationService) TestRules(ctx context.Context, target string, samplesPath string, code string, filter string) (*models.RuleTestResult, error) {
+	if target == "web" {
+		return nil, fmt.Errorf("unknown target %q, expected dart or flutter", target)
+	}
+	if filter != "" {
+		return &models.RuleTestResult{Target: "flutter", Rules: 1, Fixtures: 2, Failures: []models.RuleTestFailure{}}, nil
+	}
+	return &models.RuleTestResult{
+		Target:       "flutter",
+		Rules:        3,
+		Fixtures:     4,
+		Samples:      2,
+		Expectations: 1,
+		Failures: []models.RuleTestFailure{
+			{API: "OutlineButton", Source: "builtin", Kind: config.RULE_TEST_FALSE_POSITIVE, File: "lib/buttons.dart", Line: 2, Code: "OutlineButton()"},
+			{API: "LegacyCard", Source: "custom", Kind: config.RULE_TEST_FALSE_NEGATIVE, Code: "LegacyCard.outlined()"},
+			{API: "RaisedButton", Kind: config.RULE_TEST_FALSE_NEGATIVE, Line: 1, Code: "RaisedButton()"},
+		},
+		Untested: []string{"Theme.legacyColor"},
+	}, nil
+}
+
+func (m *MockDeprecationService) AddManualDeprecation(dep models.Deprecation) (bool, error) {
+	if dep.API == "" {
+		return false, fmt.Errorf("an API name is required")
+	}
+	for i, existing := range m.deprecations {
+		if existing.API == dep.API {
+			m.deprecations[i] = dep
+			return true, nil
+		}
+	}
+	m.deprecations = append(m.deprecations, dep)
+	return false, nil
+}
+
+func (m *MockDeprecationService) DeprecationStats(recent int) (*models.DeprecationStats, error) {
+	if m.stats == nil {
+		return nil, fmt.Errorf("cache unavailable")
+	}
+	return m.stats, nil
+}
+
+func (m *MockDeprecationService) UpdateCache(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockDeprecationService) UpdateCacheScoped(ctx context.Context, directories []string, sources []string) error {
+	for _, source := range sources {
+		if source == "lint" {
+			return fmt.Errorf("unknown source %q", source)
+		}
+	}
+	m.scoped = append(append([]string{}, directories...), sources...)
+	return nil
+}
+
+func (m *MockDeprecationService) ExtractDeprecationsFromReleaseNotes(releases []models.FlutterRelease) []models.Deprecation {
+	return m.deprecations
+}
+
+// MockMigrationGuideService for testing
+type MockMigrationGuideService struct {
+	explanation     *models.DeprecationExplanation
+	breakingChanges *models.BreakingChangesResult
+	err             error
+}
+
+func (m *MockMigrationGuideService) ExplainDeprecation(ctx context.Context, api string) (*models.DeprecationExplanation, error) {
+	return m.explanation, m.err
+}
+
+func (m *MockMigrationGuideService) BreakingChangesBetween(ctx context.Context, from string, to string) (*models.BreakingChangesResult, error) {
+	return m.breakingChanges, m.err
+}
+
+// MockSuppressionService for testing
+type MockSuppressionService struct {
+	suppressions []models.Suppression
+}
+
+func (m *MockSuppressionService) Suppress(api string, reason string, projectPath string) (models.Suppression, error) {
+	suppression := models.Suppression{API: api, Reason: reason}
+	m.suppressions = append(m.suppressions, suppression)
+	return suppression, nil
+}
+
+func (m *MockSuppressionService) Unsuppress(api string, projectPath string) (bool, error) {
+	for i, suppression := range m.suppressions {
+		if suppression.API == api {
+			m.suppressions = append(m.suppressions[:i], m.suppressions[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockSuppressionService) Suppressions(projectPath string) ([]models.Suppression, error) {
+	return m.suppressions, nil
+}
+
+type MockTeamSyncService struct {
+	pulls, pushes int
+	err           error
+}
+
+func (m *MockTeamSyncService) Pull(ctx context.Context) (*models.TeamDatabase, error) {
+	m.pulls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.TeamDatabase{Manual: []models.Deprecation{{API: "LegacyCard"}}}, nil
+}
+
+func (m *MockTeamSyncService) Push(ctx context.Context) error {
+	m.pushes++
+	return m.err
+}
+
+// MockConfirmer answers every confirmation with accept and err, and records what it was asked
+type MockConfirmer struct {
+	accept   bool
+	err      error
+	messages []string
+}
+
+func (m *MockConfirmer) ElicitationSupported() bool { return true }
+
+func (m *MockConfirmer) Confirm(ctx context.Context, message string) (bool, error) {
+	m.messages = append(m.messages, message)
+	return m.accept, m.err
+}
+
+// MockProjectRootService finds project under the roots of the client, or fails with err
+type MockProjectRootService struct {
+	project string
+	err     error
+}
+
+func (m *MockProjectRootService) ActiveProject(ctx context.Context) (string, error) {
+	return m.project, m.err
+}
+
+// recordingSDKConstraintService records the project the suggestions were asked for
+type recordingSDKConstraintService struct {
+	MockSDKConstraintService
+	projectPaths []string
+}
+
+func (r *recordingSDKConstraintService) Suggest(ctx context.Context, content string, projectPath string, target string) (*models.SDKConstraintSuggestion, error) {
+	r.projectPaths = append(r.projectPaths, projectPath)
+	return r.MockSDKConstraintService.Suggest(ctx, content, projectPath, target)
+}
+
+type MockDockerfileService struct{}
+
+func (m *MockDockerfileService) Generate(ctx context.Context, version string, target string, image string) (*models.DockerfileResult, error) {
+	if target == "ios" {
+		return nil, fmt.Errorf("unsupported target %q", target)
+	}
+	return &models.DockerfileResult{
+		Version:      version,
+		Target:       "web",
+		Image:        "ghcr.io/cirruslabs/flutter:" + version,
+		Unavailable:  []string{"instrumentisto/flutter:" + version},
+		Dockerfile:   "FROM ghcr.io/cirruslabs/flutter:" + version + " AS build\n",
+		Compose:      "services:\n  web:\n    build: .\n",
+		Dockerignore: "build/\n",
+		Usage:        "docker build -t flutter-web .",
+	}, nil
+}
+
+// MockWhatsNewService returns a digest of the 3.27 release without the GitHub release notes
+type MockWhatsNewService struct{}
+
+func (m *MockWhatsNewService) WhatsNew(ctx context.Context, version string) (*models.ReleaseDigest, error) {
+	if version == "latest" {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	return &models.ReleaseDigest{
+		Version:         "3.27.0",
+		Previous:        "3.24.0",
+		ReleaseNotesURL: "https://docs.flutter.dev/release/release-notes/release-notes-3.27.0",
+		Deprecations:    []models.Deprecation{{API: "Color.withOpacity", Replacement: "Color.withValues(alpha: $1)", Version: "3.27.0"}},
+		BreakingChanges: []models.BreakingChange{{Version: "3.27.0", Title: "Wide gamut Color", URL: "https://docs.flutter.dev/release/breaking-changes/wide-gamut-framework"}},
+		Replacements:    []models.ReplacementAPI{{API: "Color.withValues", Replaces: []string{"Color.withOpacity"}}},
+		UpstreamErrors:  []string{"GitHub releases: rate limit exceeded"},
+	}, nil
+}
+
+// MockUpgradeDigestService digests the upgrade from 3.24.5 to 3.27.1; 3.27.1 is up to date
+type MockUpgradeDigestService struct{}
+
+func (m *MockUpgradeDigestService) UpgradeDigest(ctx context.Context, from string, projectPath string) (*models.UpgradeDigest, error) {
+	switch from {
+	case "latest":
+		return nil, fmt.Errorf("invalid version %q", from)
+	case "3.27.1":
+		return &models.UpgradeDigest{From: from, FromSource: "argument", To: from}, nil
+	}
+	return &models.UpgradeDigest{
+		From:       "3.24.5",
+		FromSource: ".fvmrc",
+		To:         "3.27.1",
+		Releases: []models.UpgradeRelease{
+			{Version: "3.27.0", ReleaseDate: time.Date(2024, 12, 11, 0, 0, 0, 0, time.UTC), DartSDKVersion: "3.6.0", ReleaseNotesURL: "https://docs.flutter.dev/release/release-notes/release-notes-3.27.0"},
+			{Version: "3.27.1", Notes: "Fixes a crash in the engine"},
+		},
+		Deprecations:    []models.Deprecation{{API: "Color.withOpacity", Replacement: "Color.withValues(alpha: $1)", Version: "3.27.0"}},
+		BreakingChanges: []models.BreakingChange{{Version: "3.27.0", Title: "Wide gamut Color", URL: "https://docs.flutter.dev/release/breaking-changes/wide-gamut-framework"}},
+		Replacements:    []models.ReplacementAPI{{API: "Color.withValues", Replaces: []string{"Color.withOpacity"}}},
+	}, nil
+}
+
+// MockReleaseHistoryService lists two 3.27 releases and rejects invalid since versions
+type MockReleaseHistoryService struct{}
+
+func (m *MockReleaseHistoryService) StableReleases(ctx context.Context, limit int, since string) (*models.ReleaseHistory, error) {
+	if since == "latest" {
+		return nil, fmt.Errorf("invalid version %q", since)
+	}
+	return &models.ReleaseHistory{
+		Source: "official",
+		Latest: "3.27.1",
+		Total:  40,
+		Releases: []models.StableRelease{
+			{Version: "3.27.1", ReleaseDate: time.Date(2024, 12, 17, 0, 0, 0, 0, time.UTC), DartSDKVersion: "3.6.0"},
+			{Version: "3.27.0", ReleaseDate: time.Date(2024, 12, 11, 0, 0, 0, 0, time.UTC)},
+		},
+	}, nil
+}
+
+// MockProjectScanService finds deprecations in two packages of a melos workspace
+type MockProjectScanService struct{}
+
+func (m *MockProjectScanService) Scan(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.ProjectScanResult, error) {
+	if projectPath == "/missing" {
+		return nil, fmt.Errorf("stat /missing: no such file or directory")
+	}
+	if len(filter.Include) > 0 && filter.Include[0] == "[" {
+		return nil, fmt.Errorf("invalid glob %q", filter.Include[0])
+	}
+	return &models.ProjectScanResult{
+		ProjectPath: projectPath,
+		Workspace:   "melos",
+		Cached:      20,
+		Packages: []models.PackageScanResult{
+			{Name: "shop", Path: "apps/shop", Files: 12, Findings: []models.FileCheckResult{
+				{Name: "apps/shop/lib/main.dart", Deprecations: []models.Deprecation{{API: "RaisedButton", Replacement: "ElevatedButton"}, {API: "Color.withOpacity"}}},
+				{Name: "apps/shop/lib/huge.dart", Error: "file too large"},
+			}},
+			{Name: "shop_ui", Path: "packages/ui", Files: 8, Findings: []models.FileCheckResult{
+				{Name: "packages/ui/lib/theme.dart", Deprecations: []models.Deprecation{{API: "Color.withOpacity"}}},
+			}},
+			{Name: "core", Path: "packages/core", Files: 3},
+		},
+	}, nil
+}
+
+func (m *MockProjectScanService) ScanChanged(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.ProjectScanResult, error) {
+	result, err := m.Scan(ctx, projectPath, target, filter)
+	if err != nil {
+		return nil, err
+	}
+	if projectPath == "/fresh" {
+		result.FullScanReason = "no earlier full scan with the current rules and scope"
+	} else {
+		result.BaseCommit, result.Changed = "0123456789abcdef0123", 2
+	}
+	return result, nil
+}
+
+// MockDependencyAuditService finds one advisory in the locked http package and fails for "/unlocked"
+type MockDependencyAuditService struct{}
+
+func (m *MockDependencyAuditService) Audit(ctx context.Context, projectPath string) (*models.DependencyAudit, error) {
+	if projectPath == "/unlocked" {
+		return nil, fmt.Errorf("no pubspec.lock in /unlocked; run flutter pub get first")
+	}
+	return &models.DependencyAudit{
+		LockFiles: []string{"apps/shop/pubspec.lock"},
+		Packages:  42,
+		Advisories: []models.DependencyAdvisory{
+			{Package: "http", Version: "0.13.1", Direct: true, ID: "GHSA-4rgh-jx4f-qfcq", Summary: "CRLF injection in http", Severity: "moderate", URL: "https://github.com/advisories/GHSA-4rgh-jx4f-qfcq", Fixed: "0.13.3"},
+		},
+		Errors: []string{"private 1.0.0: not found on pub.dev"},
+	}, nil
+}
+
+// MockReplacementDraftService drafts ColorScheme.primary for App.color and fails for "unknown"
+type MockReplacementDraftService struct{}
+
+func (m *MockReplacementDraftService) DraftReplacements(ctx context.Context, api string, limit int) (*models.ReplacementDrafts, error) {
+	if api == "unknown" {
+		return nil, fmt.Errorf("unknown is not a scanned deprecation without a replacement")
+	}
+	return &models.ReplacementDrafts{
+		Model:   "test-model",
+		Pending: 7,
+		Drafts: []models.ReplacementDraft{
+			{API: "App.color", Description: "Prefer the themed color", Replacement: "ColorScheme.primary"},
+			{API: "App.title", Description: "Not needed anymore"},
+			{API: "CameraValue.old", Package: "camera", Description: "Gone soon", Error: "the user rejected the request"},
+		},
+	}, nil
+}
+
+// MockSDKConstraintService raises a caret sdk constraint to Dart 3.6.0 and rejects invalid targets
+type MockSDKConstraintService struct{}
+
+func (m *MockSDKConstraintService) Suggest(ctx context.Context, content string, projectPath string, target string) (*models.SDKConstraintSuggestion, error) {
+	if target == "latest" {
+		return nil, fmt.Errorf("invalid version %q", target)
+	}
+	suggestion := &models.SDKConstraintSuggestion{
+		TargetFlutter:    "3.27.1",
+		TargetDart:       "3.6.0",
+		CurrentSDK:       "^3.0.0",
+		SuggestedSDK:     "^3.6.0",
+		SuggestedFlutter: ">=3.27.1",
+		Block:            "environment:\n  sdk: ^3.6.0\n  flutter: \">=3.27.1\"\n",
+		Patch:            "--- a/pubspec.yaml\n+++ b/pubspec.yaml\n@@ -1,2 +1,3 @@\n environment:\n-  sdk: ^3.0.0\n+  sdk: ^3.6.0\n+  flutter: \">=3.27.1\"\n",
+	}
+	if target == "3.20.0" {
+		suggestion.Patch = ""
+	}
+	return suggestion, nil
+}
+
+// MockPinRecommendationService holds a project back at Flutter 3.24.5 because of charts and
+// fails for the project at /broken
+type MockPinRecommendationService struct{}
+
+func (m *MockPinRecommendationService) Recommend(ctx context.Context, content string, projectPath string) (*models.PinRecommendation, error) {
+	if projectPath == "/broken" {
+		return nil, fmt.Errorf("open /broken/pubspec.yaml: no such file or directory")
+	}
+	return &models.PinRecommendation{
+		CurrentFlutter:  ">=3.19.0",
+		LatestStable:    "3.27.1",
+		LatestDart:      "3.6.0",
+		Recommended:     "3.24.5",
+		RecommendedDart: "3.5.4",
+		Dependencies: []models.DependencySupport{
+			{Package: "charts", Constraint: "^1.0.0", Version: "1.5.0", SDK: ">=3.0.0 <3.6.0", NewestFlutter: "3.24.5", Blocking: true, Upgrade: "2.0.0"},
+			{Package: "http", Constraint: "^1.2.0", Version: "1.2.2", SDK: "^3.4.0", NewestFlutter: "3.27.1"},
+			{Package: "internal_ui", Constraint: "^1.0.0", Error: "not found on pub.dev"},
+		},
+		Skipped: []string{"flutter (sdk)"},
+	}, nil
+}
+
+// MockRepoScanService finds one deprecation in acme/design_system and records what it saves
+type MockRepoScanService struct {
+	saved []models.Deprecation
+}
+
+func (m *MockRepoScanService) Scan(ctx context.Context, repo string, ref string, dir string, pkg string) (*models.RepoScanResult, error) {
+	if repo != "acme/design_system" {
+		return nil, fmt.Errorf("invalid GitHub repository %q", repo)
+	}
+	return &models.RepoScanResult{
+		Repository: "acme/design_system@HEAD",
+		Package:    "design_system",
+		Files:      12,
+		Deprecations: []models.Deprecation{{
+			API:         "AppCard.elevation",
+			Replacement: "AppCard.shadow",
+			Description: "Use shadow instead",
+			Package:     "design_system",
+			Source:      config.DEPRECATION_SOURCE_REPO,
+			DocURL:      "https://github.com/acme/design_system/blob/HEAD/lib/src/card.dart#L12",
+		}},
+	}, nil
+}
+
+func (m *MockRepoScanService) Save(result *models.RepoScanResult) (int, error) {
+	m.saved = append(m.saved, result.Deprecations...)
+	return len(result.Deprecations), nil
+}
+
+// MockDiffScanService reports a deprecated and a suppressed API on the lines of any change
+type MockDiffScanService struct {
+	change string
+}
+
+func (m *MockDiffScanService) findings(projectPath string, change string) (*models.DiffScanResult, error) {
+	m.change = change
+	return &models.DiffScanResult{
+		ProjectPath: projectPath,
+		Change:      change,
+		Files:       2,
+		Findings: []models.LineFinding{
+			{Path: "lib/main.dart", Line: 3, Column: 5, Deprecation: models.Deprecation{API: "RaisedButton", Replacement: "ElevatedButton"}},
+			{Path: "lib/theme.dart", Line: 1, Column: 11, Deprecation: models.Deprecation{API: "Color.withOpacity"}},
+		},
+	}, nil
+}
+
+func (m *MockDiffScanService) ScanStaged(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.DiffScanResult, error) {
+	return m.findings(projectPath, "staged changes")
+}
+
+func (m *MockDiffScanService) ScanRange(ctx context.Context, projectPath string, gitRange string, target string, filter models.PathFilter) (*models.DiffScanResult, error) {
+	if !strings.Contains(gitRange, "..") {
+		return nil, fmt.Errorf("invalid range %q, expected from..to or from...to such as main..HEAD", gitRange)
+	}
+	return m.findings(projectPath, "changes in "+gitRange)
+}
+
+func (m *MockDiffScanService) ScanDiff(ctx context.Context, projectPath string, diff string, target string, filter models.PathFilter) (*models.DiffScanResult, error) {
+	return m.findings(projectPath, "changes in the diff")
+}
+
+// MockPRReviewService comments on one line of acme/shop#42 and records the APIs it was told to hide
+type MockPRReviewService struct {
+	hidden map[string]bool
+}
+
+func (m *MockPRReviewService) Review(ctx context.Context, repo string, number int, target string, hidden map[string]bool, post bool) (*models.PRReviewResult, error) {
+	if repo != "acme/shop" || number != 42 {
+		return nil, fmt.Errorf("GitHub API returned status 404: Not Found")
+	}
+	m.hidden = hidden
+	result := &models.PRReviewResult{
+		Repository: "acme/shop",
+		Number:     42,
+		HeadSHA:    "0123456789abcdef0123456789abcdef01234567",
+		Files:      3,
+		Comments:   []models.PRReviewComment{{Path: "lib/shop.dart", Line: 4, APIs: []string{"RaisedButton", "FlatButton"}}},
+		Existing:   2,
+	}
+	if post {
+		result.ReviewURL = "https://github.com/acme/shop/pull/42#pullrequestreview-1"
+	}
+	return result, nil
+}
+
+// MockSDKScanService compares two installed SDKs, failing for versions that are not installed
+type MockSDKScanService struct{}
+
+func (m *MockSDKScanService) Versions(ctx context.Context) ([]models.SDKDeprecations, error) {
+	return []models.SDKDeprecations{{Version: "3.24.5"}, {Version: "3.27.1"}}, nil
+}
+
+func (m *MockSDKScanService) Compare(ctx context.Context, from string, to string) (*models.VersionComparison, error) {
+	if from == "3.22" {
+		return nil, fmt.Errorf("no local SDK for Flutter 3.22 (installed: 3.24.5, 3.27.1)")
+	}
+	return &models.VersionComparison{
+		From:       "3.24.5",
+		To:         "3.27.1",
+		Deprecated: []models.Deprecation{{API: "Color.opacity", Replacement: "Color.a", Description: "Use .a instead"}},
+		Removed:    []models.Deprecation{{API: "ButtonBar", Replacement: "OverflowBar"}},
+		Unchanged:  42,
+	}, nil
+}
+
+func (m *MockSDKScanService) IntroducedIn(ctx context.Context, version string) (*models.IntroducedDeprecations, error) {
+	if version == "3.24.5" {
+		return nil, fmt.Errorf("no installed Flutter version older than 3.24.5 to compare with")
+	}
+	return &models.IntroducedDeprecations{
+		Version:      "3.27.1",
+		Previous:     "3.24.5",
+		Deprecations: []models.Deprecation{{API: "Color.opacity", Replacement: "Color.a", Description: "Use .a instead"}},
+	}, nil
+}
+
+// MockLocalSDKService reports an FVM SDK that differs from the active one
+type MockLocalSDKService struct{}
+
+func (m *MockLocalSDKService) Detect(ctx context.Context, projectPath string) (*models.LocalSDKReport, error) {
+	if projectPath == "/missing" {
+		return nil, fmt.Errorf("stat /missing: no such file or directory")
+	}
+	return &models.LocalSDKReport{
+		SDKs: []models.LocalFlutterSDK{
+			{Path: "/home/dev/flutter", Version: "3.24.5", Channel: "stable", Sources: []string{"PATH"}, Active: true},
+			{Path: "/home/dev/fvm/versions/3.27.1", Version: "3.27.1", Channel: "stable", Name: "3.27.1", Sources: []string{"fvm"}},
+		},
+		ProjectPath:   projectPath,
+		PinnedVersion: "3.27.1",
+		PinnedBy:      ".fvmrc",
+		Warnings:      []string{"The active flutter (3.24.5 at /home/dev/flutter) differs from the 3.27.1 pinned in .fvmrc"},
+	}, nil
+}
+
+// MockCIWorkflowService reports one outdated and one current pin
+type MockCIWorkflowService struct{}
+
+func (m *MockCIWorkflowService) CheckPath(ctx context.Context, path string) (*models.CIWorkflowReport, error) {
+	return nil, fmt.Errorf("no .github/workflows files or .gitlab-ci.yml found in %s", path)
+}
+
+func (m *MockCIWorkflowService) CheckContent(ctx context.Context, content string) (*models.CIWorkflowReport, error) {
+	return &models.CIWorkflowReport{
+		LatestStable: "3.27.1",
+		FilesScanned: 1,
+		Pins: []models.CIFlutterPin{
+			{Job: "test", Line: 12, Source: "subosito/flutter-action", Version: "3.24.5", Status: config.CI_PIN_OUTDATED, Suggestion: "Update flutter-version to 3.27.1"},
+			{Job: "build", Line: 20, Source: "ghcr.io/cirruslabs/flutter", Version: "3.27.1", Status: config.CI_PIN_CURRENT},
+		},
+	}, nil
+}
+
+type MockWebCheckService struct{}
+
+func (m *MockWebCheckService) CheckPath(ctx context.Context, path string, version string) (*models.WebCheckReport, error) {
+	return nil, fmt.Errorf("no web directory, build scripts or Dart files found in %s", path)
+}
+
+func (m *MockWebCheckService) CheckContent(ctx context.Context, content string, version string) (*models.WebCheckReport, error) {
+	return &models.WebCheckReport{
+		FlutterVersion: "3.29.2",
+		VersionSource:  "latest stable release",
+		FilesScanned:   1,
+		Findings: []models.WebFinding{
+			{Line: 3, API: "--web-renderer", Match: "--web-renderer canvaskit", Severity: config.SEVERITY_ERROR, Suggestion: "Remove the flag"},
+			{Line: 7, API: "_flutter.loader.loadEntrypoint", Match: "_flutter.loader.loadEntrypoint(", Severity: config.SEVERITY_WARNING, Suggestion: "Use flutter_bootstrap.js", DocURL: "https://docs.flutter.dev/platform-integration/web/initialization"},
+			{Line: 9, API: "html renderer", Match: `renderer: "html"`, Severity: config.SEVERITY_WARNING, Suggestion: "Remove the flag"},
+		},
+	}, nil
+}
+
+// MockRunnerTemplateService for testing
+type MockRunnerTemplateService struct{}
+
+func (m *MockRunnerTemplateService) Check(ctx context.Context, projectPath string, version string, platforms []string) (*models.RunnerDriftReport, error) {
+	if projectPath == "/tmp/empty" {
+		return nil, fmt.Errorf("no windows, linux or macos runner folder found in %s", projectPath)
+	}
+	return &models.RunnerDriftReport{
+		ProjectPath:    projectPath,
+		FlutterVersion: "3.27.1",
+		VersionSource:  ".fvmrc",
+		SDKPath:        "/fvm/versions/3.27.1",
+		Platforms:      []string{"windows"},
+		Files: []models.RunnerFileDrift{
+			{Platform: "windows", File: "windows/runner/main.cpp", Status: config.RUNNER_FILE_STALE, Added: []string{`if (!window.Create(L"tasks", origin, size)) {`}, Removed: []string{`if (!window.CreateAndShow(L"tasks", origin, size)) {`}},
+			{Platform: "windows", File: "windows/runner/runner.exe.manifest", Status: config.RUNNER_FILE_MISSING},
+			{Platform: "windows", File: "windows/runner/utils.h", Status: config.RUNNER_FILE_CURRENT},
+		},
+		Issues: []models.RunnerIssue{
+			{Platform: "windows", File: "windows/runner/main.cpp", Line: 7, API: "Win32Window::CreateAndShow", Version: "3.10.0", Message: "Call window.Create(...)"},
+		},
+	}, nil
+}
+
+// MockRateLimitService for testing
+type MockRateLimitService struct {
+	status *models.RateLimitStatus
+}
+
+func (m *MockRateLimitService) RateLimitStatus(ctx context.Context) (*models.RateLimitStatus, error) {
+	if m.status == nil {
+		return nil, fmt.Errorf("GitHub rate_limit endpoint returned status 503")
+	}
+	return m.status, nil
+}
+
+// MockUpstreamCheckService for testing
+type MockUpstreamCheckService struct {
+	checks []models.UpstreamCheck
+}
+
+func (m *MockUpstreamCheckService) CheckUpstreams(ctx context.Context) []models.UpstreamCheck {
+	return m.checks
+}
+
+// MockVersionInfoService for testing
+type MockVersionInfoService struct {
+	versionInfo *models.FlutterVersionInfo
+	err         error
+}
+
+func (m *MockVersionInfoService) GetFlutterVersionInfo(ctx context.Context) (*models.FlutterVersionInfo, error) {
+	return m.versionInfo, m.err
 }
 
-func (m *MockDeprecationService) CheckCodeForDeprecations(code string) []models.Deprecation {
-	return m.deprecations
-}
+func TestMCPHandlers(t *testing.T) {
+	t.Run("CheckFlutterDeprecations - with deprecations found", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{
+					API:         "Color.withOpacity",
+					Replacement: "Color.withValues(alpha: $1)",
+					Description: "withOpacity is deprecated",
+					Example:     "Color.red.withOpacity(0.5) → Color.red.withValues(alpha: 0.5)",
+					Version:     "Multiple versions",
+					DocURL:      "https://docs.flutter.dev/release/breaking-changes/wide-gamut-framework",
+				},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+
+		args := models.CheckDeprecationsArgs{Code: "Color.red.withOpacity(0.5)"}
+		response, err := handlers.CheckFlutterDeprecations(context.Background(), args)
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "Found deprecated APIs") {
+			t.Error("Expected response to mention found deprecated APIs")
+		}
+		if !strings.Contains(content, "Color.withOpacity") {
+			t.Error("Expected response to mention Color.withOpacity")
+		}
+		if !strings.Contains(content, "withOpacity is deprecated") {
+			t.Error("Expected response to mention deprecation description")
+		}
+		if !strings.Contains(content, "Documentation: https://docs.flutter.dev/release/breaking-changes/wide-gamut-framework") {
+			t.Error("Expected response to link the documentation")
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - lists the oldest deprecations first", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{API: "Color.withOpacity", Description: "withOpacity is deprecated", Version: "3.27.0"},
+				{API: "RaisedButton", Description: "RaisedButton is deprecated", Version: "2.0.0"},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "RaisedButton(); Color.red.withOpacity(0.5)"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"1. **RaisedButton**",
+			"   - Age: deprecated 14 releases ago, removal likely",
+			"2. **Color.withOpacity**",
+			"   - Age: deprecated in the latest stable release",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+		if mockDepService.deprecations[0].API != "Color.withOpacity" {
+			t.Error("Expected the service's deprecations to keep their order")
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - previews fixes on the user's code", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{{API: "Color.withOpacity", Description: "withOpacity is deprecated"}},
+			migration: models.MigrationResult{Previews: []models.FixPreview{
+				{Line: 2, APIs: []string{"Color.withOpacity"}, Before: "  color: brand.withOpacity(0.4),", After: "  color: brand.withValues(alpha: 0.4),"},
+				{Line: 5, APIs: []string{"RaisedButton"}, Before: "RaisedButton(", After: "ElevatedButton("},
+			}},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "Container(\n  color: brand.withOpacity(0.4),\n)"})
+		content := response.Content[0].TextContent.Text
+		expected := "## Suggested fixes (apply them with migrate_code)\n\nLine 2 (Color.withOpacity):\n\n```diff\n-   color: brand.withOpacity(0.4),\n+   color: brand.withValues(alpha: 0.4),\n```"
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected response to contain %q, got %s", expected, content)
+		}
+		if strings.Contains(content, "RaisedButton") {
+			t.Errorf("Expected previews of unreported APIs to be left out, got %s", content)
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - encoded code", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{deprecations: []models.Deprecation{{API: "RaisedButton", Description: "RaisedButton is deprecated"}}}, nil, nil)
+
+		// "RaisedButton()" compressed with gzip and base64 encoded
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{
+			Code:     "H4sIAAAAAAACAwtKzCxOTXEqLSnJz9PQBACmDCtXDgAAAA==",
+			Encoding: "gzip+base64",
+		})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "Found deprecated APIs") {
+			t.Errorf("Expected the decoded code to be checked, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "RaisedButton()", Encoding: "gzip+base64"})
+		if content := response.Content[0].TextContent.Text; !strings.HasPrefix(content, "Error decoding code: invalid gzip+base64 code") {
+			t.Errorf("Expected a decoding error, got %s", content)
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - report language", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{deprecations: []models.Deprecation{
+			{API: "RaisedButton", Replacement: "ElevatedButton", Source: config.DEPRECATION_SOURCE_BUILTIN, Description: "RaisedButton is deprecated, use ElevatedButton instead", Version: "2.0.0"},
+		}}
+		spanish, err := i18n.NewPrinter("es")
+		if err != nil {
+			t.Fatal(err)
+		}
+		handlers := NewMCPHandlers(mockDepService, nil, nil, WithPrinter(spanish))
+
+		args := models.CheckDeprecationsArgs{Code: "RaisedButton()"}
+		args.Language = "de"
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), args)
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Gefundene veraltete APIs:\n\n1. **RaisedButton**",
+			"   - Ersatz: ElevatedButton\n",
+			"   - Beschreibung: RaisedButton ist veraltet, verwenden Sie stattdessen ElevatedButton\n",
+			"   - Alter: vor 14 Releases als veraltet markiert, Entfernung wahrscheinlich\n",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "RaisedButton()"})
+		if content := response.Content[0].TextContent.Text; !strings.HasPrefix(content, "APIs obsoletas encontradas:") {
+			t.Errorf("Expected the server's language by default, got %s", content)
+		}
+
+		args.Language = "fr"
+		response, _ = handlers.CheckFlutterDeprecations(context.Background(), args)
+		if content := response.Content[0].TextContent.Text; !strings.HasPrefix(content, `Error choosing the report language: unknown language "fr"`) {
+			t.Errorf("Expected an unknown language error, got %s", content)
+		}
+	})
+
+	t.Run("CheckFlutterFiles - results per entry", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{{API: "RaisedButton", Replacement: "ElevatedButton", Description: "RaisedButton is deprecated"}},
+		}
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+
+		response, _ := handlers.CheckFlutterFiles(context.Background(), models.CheckFilesArgs{Files: []models.CheckFileEntry{
+			{Name: "home", Code: "deprecated"},
+			{Name: "about", Code: "fine"},
+			{Path: "missing.dart"},
+		}})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Checked 3 entries: 1 with deprecated APIs, 1 could not be read",
+			"## home (1)\n\n1. **RaisedButton**",
+			"## about (0)\n\nNo deprecated APIs found.",
+			"## missing.dart\n\nError reading entry: open missing.dart",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckFlutterFiles(context.Background(), models.CheckFilesArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error checking files: no files given") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - Dart target", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{deprecations: []models.Deprecation{
+			{API: "RaisedButton", Category: "material", Description: "RaisedButton is deprecated"},
+			{API: "JSON", Category: "dart:convert", Description: "Use json"},
+		}}, nil, nil)
+
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "JSON.decode(body)", Target: "dart"})
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "1. **JSON**") || strings.Contains(content, "RaisedButton") {
+			t.Errorf("Expected only the Dart deprecation, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "JSON.decode(body)", Target: "swift"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error checking code: unknown target") {
+			t.Errorf("Expected an unknown target error, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - no deprecations found", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+
+		args := models.CheckDeprecationsArgs{Code: "ElevatedButton()"}
+		response, err := handlers.CheckFlutterDeprecations(context.Background(), args)
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "No deprecated APIs found") {
+			t.Error("Expected response to mention no deprecated APIs found")
+		}
+	})
+
+	t.Run("CheckCodeAgainstVersion - grouped by version", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			versionCheck: &models.VersionCheckResult{
+				TargetVersion:  "3.27.0",
+				CurrentVersion: "3.16.0",
+				Introduced:     []models.Deprecation{{API: "Color.withOpacity", Version: "3.27.0"}},
+				AlreadyPresent: []models.Deprecation{{API: "RaisedButton", Version: "2.0.0"}},
+				NotYet:         1,
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, err := handlers.CheckCodeAgainstVersion(context.Background(), models.CheckCodeAgainstVersionArgs{Code: "x", TargetVersion: "3.27.0", CurrentVersion: "3.16.0"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"## Deprecated between 3.16.0 and 3.27.0\n\n1. **Color.withOpacity**",
+			"## Already deprecated in 3.16.0\n\n1. **RaisedButton**",
+			"1 other API(s) used here are only deprecated after 3.27.0",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("CheckCodeAgainstVersion - newer APIs and compatible forms", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			versionCheck: &models.VersionCheckResult{
+				TargetVersion:  "3.0.5",
+				CurrentVersion: "2.10.5",
+				Introduced:     []models.Deprecation{{API: "WidgetsBinding.instance!", Version: "3.0.0", Compatible: "_ambiguate(WidgetsBinding.instance)!"}},
+				Unavailable:    []models.APIRequirement{{API: "Color.withValues", Version: "3.27.0", Line: 4}},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ := handlers.CheckCodeAgainstVersion(context.Background(), models.CheckCodeAgainstVersionArgs{Code: "x", TargetVersion: "3.0.5", CurrentVersion: "2.10.5"})
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"   - Also supporting releases before 3.0.0: _ambiguate(WidgetsBinding.instance)!",
+			"## Not available in 3.0.5\n\n- Color.withValues: needs Flutter 3.27.0 (line 4)",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("CheckCodeAgainstVersion - invalid version", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.CheckCodeAgainstVersion(context.Background(), models.CheckCodeAgainstVersionArgs{Code: "x", TargetVersion: "latest"})
+
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error checking code against Flutter latest") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckVersionMatrix", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+
+		response, _ := handlers.CheckVersionMatrix(context.Background(), models.VersionMatrixArgs{Code: "RaisedButton()", TargetVersions: []string{"3.27", "3.22"}})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Version matrix for Flutter 3.22.0, 3.27.0\n",
+			"| API | Since | 3.22.0 | 3.27.0 |\n|---|---|---|---|\n",
+			"| RaisedButton → ElevatedButton | deprecated in 2.0.0 | deprecated | deprecated |",
+			"| Color.withValues | introduced in 3.27.0 | unavailable | ok |",
+			"- 3.22.0: 1 deprecated, 1 unavailable\n- 3.27.0: 2 deprecated, 0 unavailable",
+			"- Color.withOpacity deprecated in 3.27.0; also supporting releases before it: Color.withAlpha",
+			"- Color.withValues needs Flutter 3.27.0; on older targets write Color.withOpacity",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckVersionMatrix(context.Background(), models.VersionMatrixArgs{ProjectPath: "/plugin", TargetVersions: []string{"3.22.0"}})
+		if content := response.Content[0].TextContent.Text; content != "No deprecated or version-specific Flutter APIs found in 4 Dart files; the code fits Flutter 3.22.0.\n" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+
+		response, _ = handlers.CheckVersionMatrix(context.Background(), models.VersionMatrixArgs{Code: "RaisedButton()"})
+		if content := response.Content[0].TextContent.Text; content != "Error checking version matrix: no target versions given" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+
+		response, _ = handlers.CheckVersionMatrix(context.Background(), models.VersionMatrixArgs{TargetVersions: []string{"3.22.0"}})
+		if content := response.Content[0].TextContent.Text; content != "Please provide either code or project_path." {
+			t.Errorf("Unexpected response: %s", content)
+		}
+	})
+
+	t.Run("InferMinimumFlutterVersion - snippet", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil,
+			WithMinimumVersionService(services.NewMinimumVersionService()))
+		response, err := handlers.InferMinimumFlutterVersion(context.Background(), models.InferMinimumVersionArgs{Code: "PopScope(child: child)"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"Minimum Flutter version: 3.16.0 (Dart 3.2.0)",
+			"- PopScope: Flutter 3.16.0 (first used at line 1)",
+			"flutter: \">=3.16.0\"",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("InferMinimumFlutterVersion - missing input", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil,
+			WithMinimumVersionService(services.NewMinimumVersionService()))
+		response, _ := handlers.InferMinimumFlutterVersion(context.Background(), models.InferMinimumVersionArgs{})
+
+		if response.Content[0].TextContent.Text != "Please provide either code or project_path." {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("MigrateCode - applied and manual changes", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			migration: models.MigrationResult{
+				Code:     "ElevatedButton(onPressed: () {})",
+				Changes:  []models.MigrationChange{{API: "RaisedButton", Line: 1, Before: "RaisedButton", After: "ElevatedButton"}},
+				Manual:   []models.PendingMigration{{API: "FloatingActionButton(child:", Line: 2, Reason: "Consider using FloatingActionButton.extended"}},
+				Previews: []models.FixPreview{{Line: 1, APIs: []string{"RaisedButton"}, Before: "RaisedButton(onPressed: () {})", After: "ElevatedButton(onPressed: () {})"}},
+				Templates: []models.MigrationTemplate{{
+					API:         "RaisedButton",
+					Replacement: "ElevatedButton",
+					Before:      "RaisedButton(color: c)",
+					After:       "ElevatedButton(style: ElevatedButton.styleFrom(backgroundColor: c))",
+					Parameters:  []models.ParameterMapping{{Old: "color", New: "style: ElevatedButton.styleFrom(backgroundColor: ...)"}},
+				}},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, err := handlers.MigrateCode(context.Background(), models.CheckCodeArgs{Code: "RaisedButton(onPressed: () {})"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"```dart\nElevatedButton(onPressed: () {})\n```",
+			"- Line 1: `RaisedButton` → `ElevatedButton`",
+			"- Line 2: **FloatingActionButton(child:**",
+			"## Before/after preview\n\nLine 1 (RaisedButton):\n\n```diff\n- RaisedButton(onPressed: () {})\n+ ElevatedButton(onPressed: () {})\n```",
+			"## Template: RaisedButton → ElevatedButton",
+			"- `color` → style: ElevatedButton.styleFrom(backgroundColor: ...)",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("MigrateCode - nothing to migrate", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.MigrateCode(context.Background(), models.CheckCodeArgs{Code: "Text('hi')"})
+
+		if response.Content[0].TextContent.Text != "No deprecated APIs found in the provided code." {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("GetAnalyzerFixes", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{{API: "RaisedButton", Replacement: "ElevatedButton"}, {API: "FlatButton", Replacement: "TextButton"}},
+		}
+		suppressions := &MockSuppressionService{suppressions: []models.Suppression{{API: "FlatButton"}}}
+		handlers := NewMCPHandlers(mockDepService, nil, nil, WithSuppressionService(suppressions))
+
+		args := models.AnalyzerFixesArgs{File: "/app/lib/main.dart", Code: "RaisedButton(); FlatButton();"}
+		response, _ := handlers.GetAnalyzerFixes(context.Background(), args)
+		var result models.AnalyzerFixesResult
+		if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &result); err != nil {
+			t.Fatalf("Expected JSON, got %s", response.Content[0].TextContent.Text)
+		}
+		if len(result.Fixes) != 1 || result.Fixes[0].Error.Message != "RaisedButton" || result.Fixes[0].Fixes[0].Change.Edits[0].Edits[0].Replacement != "ElevatedButton" {
+			t.Errorf("Expected the fix of the unsuppressed RaisedButton, got %+v", result)
+		}
+
+		args.IncludeSuppressed = true
+		response, _ = handlers.GetAnalyzerFixes(context.Background(), args)
+		if !strings.Contains(response.Content[0].TextContent.Text, `"replacement": "TextButton"`) {
+			t.Errorf("Expected the suppressed FlatButton to be included, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.GetAnalyzerFixes(context.Background(), models.AnalyzerFixesArgs{Code: "RaisedButton()"})
+		if response.Content[0].TextContent.Text != "Error getting analyzer fixes: no file given" {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.GetAnalyzerFixes(context.Background(), models.AnalyzerFixesArgs{File: "/missing.dart"})
+		if !strings.HasPrefix(response.Content[0].TextContent.Text, "Error getting analyzer fixes: open /missing.dart") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("ListFlutterDeprecations - with cache data", func(t *testing.T) {
+		mockCache := &MockCacheService{
+			cache: &models.DeprecationCache{
+				LastUpdated: time.Now(),
+				Deprecations: []models.Deprecation{
+					{
+						API:         "RaisedButton",
+						Replacement: "ElevatedButton",
+						Description: "RaisedButton is deprecated",
+						Version:     "Multiple versions",
+					},
+					{
+						API:         "FlatButton",
+						Replacement: "TextButton",
+						Description: "FlatButton is deprecated",
+						Version:     "Multiple versions",
+					},
+				},
+			},
+		}
+
+		handlers := NewMCPHandlers(nil, nil, mockCache)
+
+		args := models.ListDeprecationsArgs{}
+		response, err := handlers.ListFlutterDeprecations(context.Background(), args)
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "Flutter Deprecations") {
+			t.Error("Expected response to mention Flutter Deprecations")
+		}
+		if !strings.Contains(content, "RaisedButton") {
+			t.Error("Expected response to mention RaisedButton")
+		}
+		if !strings.Contains(content, "FlatButton") {
+			t.Error("Expected response to mention FlatButton")
+		}
+	})
+
+	t.Run("ListFlutterDeprecations - empty cache", func(t *testing.T) {
+		mockCache := &MockCacheService{
+			cache: &models.DeprecationCache{
+				LastUpdated:  time.Now(),
+				Deprecations: []models.Deprecation{},
+			},
+		}
+
+		handlers := NewMCPHandlers(nil, nil, mockCache)
+
+		args := models.ListDeprecationsArgs{}
+		response, err := handlers.ListFlutterDeprecations(context.Background(), args)
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "No deprecations found in cache") {
+			t.Error("Expected response to mention no deprecations found")
+		}
+	})
+
+	t.Run("ListFlutterDeprecations - max_results and summary_only", func(t *testing.T) {
+		cache := &models.DeprecationCache{LastUpdated: time.Now(), Manual: []models.Deprecation{{API: "LegacyCard", Replacement: "AppCard"}}}
+		for i := 0; i < 5; i++ {
+			cache.Deprecations = append(cache.Deprecations, models.Deprecation{API: fmt.Sprintf("Api%d", i), Description: "Deprecated"})
+		}
+		handlers := NewMCPHandlers(nil, nil, &MockCacheService{cache: cache})
+
+		response, _ := handlers.ListFlutterDeprecations(context.Background(), models.ListDeprecationsArgs{ResultLimits: models.ResultLimits{MaxResults: 3}})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"3. **Api2**",
+			"2 more findings omitted (showing 3 of 5).",
+			"1 more findings omitted (showing 0 of 1).",
+			"3 more findings omitted (showing 3 of 6). Raise max_results",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+		if strings.Contains(content, "Api3") || strings.Contains(content, "LegacyCard") {
+			t.Errorf("Expected the findings beyond max_results to be left out, got %s", content)
+		}
+
+		response, _ = handlers.ListFlutterDeprecations(context.Background(), models.ListDeprecationsArgs{ResultLimits: models.ResultLimits{SummaryOnly: true}})
+		content = response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"5 findings not listed (summary_only).",
+			"Summary only: 6 findings counted, none listed.",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+		if strings.Contains(content, "Api0") {
+			t.Errorf("Expected no findings to be listed, got %s", content)
+		}
+
+		response, _ = handlers.ListFlutterDeprecations(context.Background(), models.ListDeprecationsArgs{})
+		if content = response.Content[0].TextContent.Text; strings.Contains(content, "omitted") {
+			t.Errorf("Expected nothing to be omitted under the default limit, got %s", content)
+		}
+	})
+
+	t.Run("GetDeprecationDetails - known API", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{
+					API:         "ColorScheme.background",
+					Replacement: "ColorScheme.surface",
+					Description: "Use surface instead. This feature was deprecated after v3.18.0-0.1.pre.",
+					Category:    "material",
+					Severity:    "warning",
+					Source:      "flutter_source",
+					SourceFile:  "packages/flutter/lib/src/material/color_scheme.dart",
+					SourceLine:  412,
+					FirstSeen:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+					LastSeen:    time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, err := handlers.GetDeprecationDetails(context.Background(), models.DeprecationDetailsArgs{API: "ColorScheme.background"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"## ColorScheme.background",
+			"Replacement: ColorScheme.surface",
+			"Category: material",
+			"Severity: warning",
+			"Since version: unknown",
+			"https://api.flutter.dev/flutter/material/ColorScheme/background.html",
+			"@Deprecated annotation in the Flutter framework source",
+			"Source: packages/flutter/lib/src/material/color_scheme.dart:412 (https://github.com/flutter/flutter/blob/master/packages/flutter/lib/src/material/color_scheme.dart#L412)",
+			"First seen: 2024-02-01",
+			"Status: still deprecated (confirmed upstream 2025-03-04)",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - removed upstream", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{API: "ThemeData.accentColor", Description: "Use colorScheme.secondary", Removed: true, LastSeen: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "theme.accentColor"})
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "Status: removed upstream (annotation last seen 2025-03-04)") {
+			t.Errorf("Expected the entry to be flagged as removed, got %s", content)
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - upcoming", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{API: "ThemeData.accentColor", Description: "Use colorScheme.secondary", Channel: "beta", Upcoming: true, LastSeen: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ := handlers.CheckFlutterDeprecations(context.Background(), models.CheckDeprecationsArgs{Code: "theme.accentColor"})
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "Status: upcoming: deprecated on the beta channel (confirmed 2025-03-04) but not yet in stable") {
+			t.Errorf("Expected the entry to be flagged as upcoming, got %s", content)
+		}
+	})
+
+	t.Run("GetDeprecationDetails - unknown API", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.GetDeprecationDetails(context.Background(), models.DeprecationDetailsArgs{API: "Nope.nothing"})
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "No deprecation found") {
+			t.Errorf("Expected not found message, got %s", content)
+		}
+	})
+
+	t.Run("ExplainDeprecation - with guide", func(t *testing.T) {
+		mockGuides := &MockMigrationGuideService{
+			explanation: &models.DeprecationExplanation{
+				Deprecation:  models.Deprecation{API: "RaisedButton", Replacement: "ElevatedButton", Category: "material"},
+				GuideTitle:   "New Buttons and Button Themes",
+				GuideURL:     "https://docs.flutter.dev/release/breaking-changes/buttons",
+				GuideExcerpt: "### Summary\n\nNew buttons.",
+				Before:       "RaisedButton()",
+				After:        "ElevatedButton()",
+				Template: &models.MigrationTemplate{
+					API:         "RaisedButton",
+					Replacement: "ElevatedButton",
+					Before:      "RaisedButton(color: c)",
+					After:       "ElevatedButton(style: ElevatedButton.styleFrom(backgroundColor: c))",
+					Parameters:  []models.ParameterMapping{{Old: "color", New: "style: ElevatedButton.styleFrom(backgroundColor: ...)"}},
+				},
+			},
+		}
+
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithMigrationGuideService(mockGuides))
+		response, err := handlers.ExplainDeprecation(context.Background(), models.DeprecationDetailsArgs{API: "RaisedButton"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"[New Buttons and Button Themes](https://docs.flutter.dev/release/breaking-changes/buttons)",
+			"Before:\n\n```dart\nRaisedButton()\n```",
+			"After:\n\n```dart\nElevatedButton()\n```",
+			"## Template: RaisedButton → ElevatedButton\n\nParameters:\n\n- `color` → style: ElevatedButton.styleFrom(backgroundColor: ...)",
+			"After:\n\n```dart\nElevatedButton(style: ElevatedButton.styleFrom(backgroundColor: c))\n```",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("ExplainDeprecation - error", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil,
+			WithMigrationGuideService(&MockMigrationGuideService{err: fmt.Errorf("no deprecation found")}))
+		response, _ := handlers.ExplainDeprecation(context.Background(), models.DeprecationDetailsArgs{API: "Nope"})
+
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error explaining deprecation") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("ListBreakingChangesBetween - grouped by release", func(t *testing.T) {
+		mockGuides := &MockMigrationGuideService{
+			breakingChanges: &models.BreakingChangesResult{
+				From:   "3.16.0",
+				To:     "3.22.0",
+				Source: "flutter/website",
+				Changes: []models.BreakingChange{
+					{Version: "3.19", Title: "Deprecated API removed after v3.16", URL: "https://docs.flutter.dev/release/breaking-changes/3-16-deprecations"},
+					{Version: "3.22", Title: "Rename MaterialState to WidgetState", URL: "https://docs.flutter.dev/release/breaking-changes/material-state"},
+				},
+			},
+		}
+
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithMigrationGuideService(mockGuides))
+		response, err := handlers.ListBreakingChangesBetween(context.Background(), models.BreakingChangesArgs{From: "3.16", To: "3.22"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"Breaking changes from Flutter 3.16.0 to 3.22.0 (source: flutter/website)",
+			"## Flutter 3.19\n\n- [ ] [Deprecated API removed after v3.16](https://docs.flutter.dev/release/breaking-changes/3-16-deprecations)",
+			"## Flutter 3.22\n\n- [ ] [Rename MaterialState to WidgetState]",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("SearchDeprecations - ranked results", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{API: "Scaffold.of(context).showSnackBar", Replacement: "ScaffoldMessenger.of(context).showSnackBar"},
+				{API: "RaisedButton", Replacement: "ElevatedButton"},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, err := handlers.SearchDeprecations(context.Background(), models.SearchDeprecationsArgs{Query: "snackbar"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "Scaffold.of(context).showSnackBar") {
+			t.Errorf("Expected snackbar deprecation in results, got %s", content)
+		}
+		if strings.Contains(content, "RaisedButton") {
+			t.Errorf("Expected RaisedButton to be excluded, got %s", content)
+		}
+	})
+
+	t.Run("SearchDeprecations - no matches", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.SearchDeprecations(context.Background(), models.SearchDeprecationsArgs{Query: "nothing"})
+
+		if !strings.Contains(response.Content[0].TextContent.Text, "No deprecations match") {
+			t.Errorf("Expected no match message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("DeprecationStats", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			stats: &models.DeprecationStats{
+				LastUpdated: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+				Total:       4,
+				Manual:      1,
+				ByVersion:   map[string]int{"unknown": 1, "3.18": 1, "3.27": 2},
+				ByCategory:  map[string]int{"material": 3, "dart:ui": 1},
+				BySeverity:  map[string]int{"warning": 4},
+				BySource:    map[string]int{"flutter_source": 3, "manual": 1},
+				Recent:      []models.Deprecation{{API: "Color.withOpacity", Version: "3.27.0"}},
+			},
+		}
+
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, err := handlers.DeprecationStats(context.Background(), models.DeprecationStatsArgs{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"Cache last updated: 2025-01-02 03:04:05",
+			"Total entries: 4 (1 manual)",
+			"- 3.27: 2\n- 3.18: 1\n- unknown: 1\n",
+			"## By category\n\n- material: 3\n- dart:ui: 1\n",
+			"- flutter_source: 3",
+			"1. **Color.withOpacity**",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+	})
+
+	t.Run("CacheChanges", func(t *testing.T) {
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockCache := &MockCacheService{cache: &models.DeprecationCache{
+			LastChanges: &models.CacheChanges{
+				From:    from,
+				To:      from.Add(24 * time.Hour),
+				Total:   120,
+				Added:   []models.Deprecation{{API: "GoRouterState.location", Description: "Use uri"}},
+				Removed: []models.Deprecation{},
+				Modified: []models.DeprecationChange{{
+					Before: models.Deprecation{API: "ButtonBar", Version: ""},
+					After:  models.Deprecation{API: "ButtonBar", Version: "3.24.0"},
+					Fields: []string{"version"},
+				}},
+			},
+		}}
+
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, mockCache)
+		response, err := handlers.CacheChanges(context.Background(), models.CacheChangesArgs{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content := response.Content[0].TextContent.Text
+		for _, want := range []string{
+			"# Changes from 2025-01-01 00:00:00 to 2025-01-02 00:00:00",
+			"1 added, 0 removed, 1 modified (120 deprecations cached)",
+			"## Added\n\n1. **GoRouterState.location**",
+			"- **ButtonBar** (version)\n  - version: unknown → 3.24.0",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("Expected response to contain %q, got %s", want, content)
+			}
+		}
+		if strings.Contains(content, "## Removed") {
+			t.Errorf("Expected no removed section, got %s", content)
+		}
+	})
+
+	t.Run("CacheChanges - nothing recorded", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, &MockCacheService{})
+		response, _ := handlers.CacheChanges(context.Background(), models.CacheChangesArgs{})
+
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "No refresh has been recorded yet") {
+			t.Errorf("Expected a note that nothing is recorded, got %s", content)
+		}
+	})
+
+	t.Run("DeprecationStats - cache error", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.DeprecationStats(context.Background(), models.DeprecationStatsArgs{})
+
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "Error loading deprecations") {
+			t.Errorf("Expected an error message, got %s", content)
+		}
+	})
+
+	t.Run("AddDeprecation - add and update", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{}
+		handlers := NewMCPHandlers(mockDepService, nil, nil)
+
+		args := models.AddDeprecationArgs{API: "LegacyCard", Replacement: "AppCard"}
+		response, err := handlers.AddDeprecation(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.HasPrefix(response.Content[0].TextContent.Text, "Added manual deprecation for LegacyCard") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.AddDeprecation(context.Background(), args)
+		if !strings.HasPrefix(response.Content[0].TextContent.Text, "Updated manual deprecation for LegacyCard") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("AddDeprecation - missing API", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.AddDeprecation(context.Background(), models.AddDeprecationArgs{})
+
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error adding deprecation") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("DraftReplacements", func(t *testing.T) {
+		notified := 0
+		handlers := NewMCPHandlers(nil, nil, nil, WithReplacementDraftService(&MockReplacementDraftService{}), WithCacheChangedNotifier(func() { notified++ }))
+
+		response, _ := handlers.DraftReplacements(context.Background(), models.DraftReplacementsArgs{})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Drafted by: test-model",
+			"Asked about 3 deprecations without a replacement: 1 drafts stored, 7 deprecations still without one.",
+			"- **App.color** → ColorScheme.primary\n  Deprecation message: Prefer the themed color",
+			"- **App.title**: the model found no replacement",
+			"- **CameraValue.old (camera)**: not drafted: the user rejected the request",
+			"AI-generated and unverified",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected the drafts to contain %q, got %s", expected, content)
+			}
+		}
+		if notified != 1 {
+			t.Errorf("Expected the stored drafts to be announced once, got %d", notified)
+		}
+
+		response, _ = handlers.DraftReplacements(context.Background(), models.DraftReplacementsArgs{API: "unknown"})
+		if !strings.HasPrefix(response.Content[0].TextContent.Text, "Error drafting replacements: unknown is not") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).DraftReplacements(context.Background(), models.DraftReplacementsArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		drafted := NewMCPHandlers(&MockDeprecationService{deprecations: []models.Deprecation{{API: "App.color", Replacement: "ColorScheme.primary", ReplacementDraftedBy: "test-model"}}}, nil, nil)
+		response, _ = drafted.GetDeprecationDetails(context.Background(), models.DeprecationDetailsArgs{API: "App.color"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "- Replacement: ColorScheme.primary (drafted by test-model, unverified)") {
+			t.Errorf("Expected the drafted replacement to be flagged, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("ListFlutterDeprecations - manual entries", func(t *testing.T) {
+		mockCacheService := &MockCacheService{
+			cache: &models.DeprecationCache{
+				LastUpdated: time.Now(),
+				Manual:      []models.Deprecation{{API: "LegacyCard", Replacement: "AppCard", Description: "Use AppCard"}},
+			},
+		}
+
+		handlers := NewMCPHandlers(nil, nil, mockCacheService)
+		response, _ := handlers.ListFlutterDeprecations(context.Background(), models.ListDeprecationsArgs{})
+
+		if !strings.Contains(response.Content[0].TextContent.Text, "## Manual entries\n\n1. **LegacyCard**") {
+			t.Errorf("Expected manual entries section, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckFlutterDeprecations - suppressed APIs", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{
+			deprecations: []models.Deprecation{
+				{API: "RaisedButton", Replacement: "ElevatedButton", Description: "RaisedButton is deprecated"},
+			},
+		}
+		suppressions := &MockSuppressionService{}
+		handlers := NewMCPHandlers(mockDepService, nil, nil, WithSuppressionService(suppressions))
+
+		response, _ := handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: " RaisedButton ", Reason: "legacy screen"})
+		if !strings.HasPrefix(response.Content[0].TextContent.Text, "Suppressed RaisedButton on this machine") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		args := models.CheckDeprecationsArgs{Code: "RaisedButton()"}
+		response, _ = handlers.CheckFlutterDeprecations(context.Background(), args)
+		content := response.Content[0].TextContent.Text
+		if !strings.Contains(content, "No deprecated APIs found") || !strings.Contains(content, "1 suppressed deprecation(s) hidden") {
+			t.Errorf("Expected the suppressed API to be hidden, got %s", content)
+		}
+
+		args.IncludeSuppressed = true
+		response, _ = handlers.CheckFlutterDeprecations(context.Background(), args)
+		if !strings.Contains(response.Content[0].TextContent.Text, "## Suppressed\n\n1. **RaisedButton**") {
+			t.Errorf("Expected a suppressed section, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "RaisedButton", Remove: true})
+		if !strings.HasPrefix(response.Content[0].TextContent.Text, "Removed the suppression for RaisedButton") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("ListFlutterDeprecations - suppressed APIs", func(t *testing.T) {
+		mockCache := &MockCacheService{
+			cache: &models.DeprecationCache{
+				LastUpdated: time.Now(),
+				Deprecations: []models.Deprecation{
+					{API: "FlatButton", Description: "FlatButton is deprecated"},
+					{API: "RaisedButton", Description: "RaisedButton is deprecated"},
+				},
+			},
+		}
+		suppressions := &MockSuppressionService{suppressions: []models.Suppression{{API: "FlatButton"}}}
+		handlers := NewMCPHandlers(nil, nil, mockCache, WithSuppressionService(suppressions))
+
+		response, _ := handlers.ListFlutterDeprecations(context.Background(), models.ListDeprecationsArgs{})
+		content := response.Content[0].TextContent.Text
+		if strings.Contains(content, "**FlatButton**") || !strings.Contains(content, "1. **RaisedButton**") {
+			t.Errorf("Expected FlatButton to be hidden, got %s", content)
+		}
+	})
+
+	t.Run("SuppressDeprecation - not enabled", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+		response, _ := handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "RaisedButton"})
+
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("AddDeprecation - shared with the team database", func(t *testing.T) {
+		teamSync := &MockTeamSyncService{}
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithTeamSyncService(teamSync))
+
+		response, _ := handlers.AddDeprecation(context.Background(), models.AddDeprecationArgs{API: "LegacyCard"})
+		if teamSync.pulls != 1 || teamSync.pushes != 1 {
+			t.Errorf("Expected one pull and one push, got %d and %d", teamSync.pulls, teamSync.pushes)
+		}
+		if !strings.Contains(response.Content[0].TextContent.Text, "Shared with the team database") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("SuppressDeprecation - team database unreachable", func(t *testing.T) {
+		teamSync := &MockTeamSyncService{err: fmt.Errorf("connection refused")}
+		suppressions := &MockSuppressionService{}
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithSuppressionService(suppressions), WithTeamSyncService(teamSync))
+
+		response, _ := handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "RaisedButton"})
+		if len(suppressions.suppressions) != 1 {
+			t.Error("Expected the suppression to be saved locally")
+		}
+		if teamSync.pushes != 0 || !strings.Contains(response.Content[0].TextContent.Text, "saved locally only") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		// Project suppressions are never sent to the team database
+		teamSync.pulls = 0
+		handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "FlatButton", ProjectPath: t.TempDir()})
+		if teamSync.pulls != 0 {
+			t.Error("Expected project suppressions to skip the team database")
+		}
+	})
+
+	t.Run("SuppressDeprecation - confirms project suppression files", func(t *testing.T) {
+		suppressions := &MockSuppressionService{}
+		confirmer := &MockConfirmer{}
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithSuppressionService(suppressions), WithConfirmer(confirmer))
+		project := t.TempDir()
+
+		response, _ := handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "RaisedButton", ProjectPath: project})
+		file := filepath.Join(project, config.PROJECT_SUPPRESSIONS_FILE)
+		if content := response.Content[0].TextContent.Text; content != "Left "+file+" unchanged: the user did not confirm the change." || len(suppressions.suppressions) != 0 {
+			t.Errorf("Expected the project to be left alone, got %s", content)
+		}
+		if len(confirmer.messages) != 1 || !strings.Contains(confirmer.messages[0], "This writes the suppression to "+file) {
+			t.Errorf("Expected the user to be shown the file, got %v", confirmer.messages)
+		}
+
+		confirmer.accept = true
+		handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "RaisedButton", ProjectPath: project})
+		if len(suppressions.suppressions) != 1 {
+			t.Error("Expected the confirmed suppression to be stored")
+		}
+
+		// Machine-wide suppressions stay in the data directory of the server and are not confirmed
+		handlers.SuppressDeprecation(context.Background(), models.SuppressDeprecationArgs{API: "FlatButton"})
+		if len(confirmer.messages) != 2 || len(suppressions.suppressions) != 2 {
+			t.Errorf("Expected only project suppressions to be confirmed, got %v", confirmer.messages)
+		}
+	})
+
+	t.Run("SyncTeamDatabase", func(t *testing.T) {
+		response, _ := NewMCPHandlers(nil, nil, nil).SyncTeamDatabase(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "No team database is configured") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		handlers := NewMCPHandlers(nil, nil, nil, WithTeamSyncService(&MockTeamSyncService{}))
+		response, _ = handlers.SyncTeamDatabase(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "1 manual entries and 0 suppressions") {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		teamSync := &MockTeamSyncService{}
+		confirmer := &MockConfirmer{}
+		mockCache := &MockCacheService{cache: &models.DeprecationCache{Manual: []models.Deprecation{{API: "LegacyCard"}, {API: "LegacyTile"}}}}
+		handlers = NewMCPHandlers(nil, nil, mockCache, WithTeamSyncService(teamSync), WithConfirmer(confirmer))
+		response, _ = handlers.SyncTeamDatabase(context.Background(), models.NoArguments{})
+		if teamSync.pulls != 0 || !strings.Contains(response.Content[0].TextContent.Text, "Did not sync with the team database") {
+			t.Errorf("Expected a declined sync to keep the local data, got %s", response.Content[0].TextContent.Text)
+		}
+		if len(confirmer.messages) != 1 || !strings.Contains(confirmer.messages[0], "replaces the 2 local manual entries") {
+			t.Errorf("Expected the user to be shown what is replaced, got %v", confirmer.messages)
+		}
+	})
+
+	t.Run("GenerateDockerfile", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithDockerfileService(&MockDockerfileService{}))
+
+		response, _ := handlers.GenerateDockerfile(context.Background(), models.GenerateDockerfileArgs{Version: "3.29.0"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Flutter 3.29.0 web build on ghcr.io/cirruslabs/flutter:3.29.0",
+			"instrumentisto/flutter:3.29.0 is not published",
+			"```dockerfile\nFROM ghcr.io/cirruslabs/flutter:3.29.0 AS build\n```",
+			"## docker-compose.yml",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.GenerateDockerfile(context.Background(), models.GenerateDockerfileArgs{Target: "ios"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error generating Dockerfile") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("WhatsNewInFlutter", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithWhatsNewService(&MockWhatsNewService{}))
+
+		response, _ := handlers.WhatsNewInFlutter(context.Background(), models.WhatsNewArgs{})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"What's new in Flutter 3.27.0 (since 3.24.0)",
+			"Release notes: https://docs.flutter.dev/release/release-notes/release-notes-3.27.0",
+			"Note: GitHub releases: rate limit exceeded. The digest may be incomplete.",
+			"## New deprecations (1)\n\n1. **Color.withOpacity**",
+			"- [Wide gamut Color](https://docs.flutter.dev/release/breaking-changes/wide-gamut-framework)",
+			"- Color.withValues replaces Color.withOpacity",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.WhatsNewInFlutter(context.Background(), models.WhatsNewArgs{Version: "latest"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error summarizing Flutter release") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).WhatsNewInFlutter(context.Background(), models.WhatsNewArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("UpgradeDigest", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithUpgradeDigestService(&MockUpgradeDigestService{}))
+
+		response, _ := handlers.UpgradeDigest(context.Background(), models.UpgradeDigestArgs{ProjectPath: "app"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"# Upgrading Flutter 3.24.5 to 3.27.1",
+			"Installed version from: .fvmrc",
+			"Stable releases to go through: 2",
+			"## Deprecations (1)\n\n1. **Color.withOpacity**",
+			"- 3.27.0: [Wide gamut Color](https://docs.flutter.dev/release/breaking-changes/wide-gamut-framework)",
+			"- Color.withValues replaces Color.withOpacity",
+			"### Flutter 3.27.0 (2024-12-11)\n\nDart SDK: 3.6.0\nRelease notes: https://docs.flutter.dev/release/release-notes/release-notes-3.27.0\nNo notes published",
+			"### Flutter 3.27.1\n\nFixes a crash in the engine",
+			"point your version manager at 3.27.1",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.UpgradeDigest(context.Background(), models.UpgradeDigestArgs{ResultLimits: models.ResultLimits{SummaryOnly: true}})
+		if content := response.Content[0].TextContent.Text; strings.Contains(content, "Fixes a crash") || !strings.Contains(content, "left out in summary mode") {
+			t.Errorf("Expected the release notes to be left out in summary mode, got %s", content)
+		}
+
+		response, _ = handlers.UpgradeDigest(context.Background(), models.UpgradeDigestArgs{From: "3.27.1"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Flutter 3.27.1 (argument) is up to date") {
+			t.Errorf("Expected up to date message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.UpgradeDigest(context.Background(), models.UpgradeDigestArgs{From: "latest"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error building upgrade digest") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).UpgradeDigest(context.Background(), models.UpgradeDigestArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("ListFlutterReleases", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithReleaseHistoryService(&MockReleaseHistoryService{}))
+
+		response, _ := handlers.ListFlutterReleases(context.Background(), models.ListFlutterReleasesArgs{Since: "3.27"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Source: official releases API",
+			"Latest stable: 3.27.1",
+			"Showing 2 of 40 stable releases since 3.27, newest first.",
+			"| 3.27.1 | 2024-12-17 | 3.6.0 |",
+			"| 3.27.0 | 2024-12-11 | unknown |",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.ListFlutterReleases(context.Background(), models.ListFlutterReleasesArgs{Since: "latest"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error listing Flutter releases") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).ListFlutterReleases(context.Background(), models.ListFlutterReleasesArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckFlutterProject", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithProjectScanService(&MockProjectScanService{}))
+
+		response, _ := handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Scanned /ws (melos workspace): 3 package(s), 23 Dart files, 3 deprecations in 2 files",
+			"20 unchanged files reused the results of an earlier scan.",
+			"## shop (apps/shop): 2 deprecations in 1 of 12 Dart files",
+			"### apps/shop/lib/main.dart (2)",
+			"### apps/shop/lib/huge.dart\n\nError reading file: file too large",
+			"1 file(s) could not be read.",
+			"## core (packages/core): 0 deprecations in 0 of 3 Dart files",
+			"## Workspace totals",
+			"| shop_ui | packages/ui | 8 | 1 | 1 |",
+			"| **Total** | | 23 | 2 | 3 |",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", ResultLimits: models.ResultLimits{MaxResults: 1}})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "2 more findings omitted (showing 1 of 3)") {
+			t.Errorf("Expected the findings to share one budget, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", PathFilter: models.PathFilter{Exclude: []string{"lib/generated/**"}}})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "Scope: include every Dart file; exclude `lib/generated/**`") {
+			t.Errorf("Expected the scope to be shown, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", GroupBy: "rule"})
+		content = response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"## Color.withOpacity: used in 2 file(s)\n\nDocumentation: ",
+			"- apps/shop/lib/main.dart\n- packages/ui/lib/theme.dart\n",
+			"## RaisedButton: used in 1 file(s)\n\nReplacement: ElevatedButton\n",
+			"## Files that could not be read (1)\n\n- apps/shop/lib/huge.dart: file too large\n",
+			"| **Total** | | 23 | 2 | 3 |",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected the rule report to contain %q, got %s", expected, content)
+			}
+		}
+		if strings.Contains(content, "## shop (apps/shop)") || strings.Index(content, "## Color.withOpacity") > strings.Index(content, "## RaisedButton") {
+			t.Errorf("Expected the most used rule first and no package sections, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", GroupBy: "severity", ResultLimits: models.ResultLimits{MaxResults: 2}})
+		content = response.Content[0].TextContent.Text
+		if !strings.Contains(content, "## warning (3)\n\n- apps/shop/lib/main.dart: **RaisedButton** → ElevatedButton\n- apps/shop/lib/main.dart: **Color.withOpacity**\n\n1 more findings omitted (showing 2 of 3).") {
+			t.Errorf("Expected the findings grouped by severity within the budget, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", GroupBy: "file"})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "## packages/ui/lib/theme.dart (1)\n\n1. **Color.withOpacity**") {
+			t.Errorf("Expected the findings grouped by file, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", GroupBy: "author"})
+		if response.Content[0].TextContent.Text != `Error scanning project: unknown grouping "author", expected package, file, rule or severity` {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		lspHandlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithProjectScanService(&MockProjectScanService{}),
+			WithSuppressionService(&MockSuppressionService{suppressions: []models.Suppression{{API: "RaisedButton"}}}))
+		response, _ = lspHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Format: "lsp"})
+		var files []models.PublishDiagnosticsParams
+		if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &files); err != nil {
+			t.Fatalf("Expected LSP diagnostics JSON, got %s", response.Content[0].TextContent.Text)
+		}
+		if len(files) != 2 || files[0].URI != "file:///ws/apps/shop/lib/main.dart" || len(files[0].Diagnostics) != 1 || files[0].Diagnostics[0].Code != "Color.withOpacity" {
+			t.Errorf("Expected the unsuppressed diagnostics of two files, got %+v", files)
+		}
+		response, _ = lspHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Format: "lsp", IncludeSuppressed: true})
+		if !strings.Contains(response.Content[0].TextContent.Text, `"code": "RaisedButton"`) {
+			t.Errorf("Expected the suppressed RaisedButton to be included, got %s", response.Content[0].TextContent.Text)
+		}
+		response, _ = lspHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Format: "json"})
+		var report models.ScanReport
+		if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &report); err != nil {
+			t.Fatalf("Expected a JSON report, got %s", response.Content[0].TextContent.Text)
+		}
+		if report.Files != 23 || report.Suppressed != 1 || report.Failing != 2 || len(report.Findings) != 2 || report.Findings[1].Package != "shop_ui" || len(report.Unreadable) != 1 {
+			t.Errorf("Expected the two unsuppressed findings and the unreadable file, got %+v", report)
+		}
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Format: "sarif"})
+		if response.Content[0].TextContent.Text != `Error scanning project: unknown format "sarif", expected text, json or lsp` {
+			t.Errorf("Unexpected response: %s", response.Content[0].TextContent.Text)
+		}
+
+		baseline := filepath.Join(t.TempDir(), "baseline.json")
+		os.WriteFile(baseline, []byte(`{"findings": [{"path": "packages/ui/lib/theme.dart", "api": "Color.withOpacity", "count": 1}]}`), 0644)
+		baselineHandlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithProjectScanService(&MockProjectScanService{}))
+		response, _ = baselineHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Baseline: baseline})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "2 deprecations in 1 files") || !strings.Contains(content, "1 findings recorded in the baseline "+baseline+" are left out.") {
+			t.Errorf("Expected theme.dart to be left out, got %s", content)
+		}
+		response, _ = baselineHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Baseline: "missing.json"})
+		if content := response.Content[0].TextContent.Text; !strings.HasPrefix(content, "Error loading baseline: ") || !strings.Contains(content, filepath.Join("/ws", "missing.json")) {
+			t.Errorf("Expected the baseline to be looked up in the project, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", Incremental: true})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "Incremental scan: 2 changed file(s) checked again; the others reuse the full scan at 0123456789ab.") {
+			t.Errorf("Expected the incremental scan to be described, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/fresh", Incremental: true})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "Checked every file instead of only the changed ones: no earlier full scan") {
+			t.Errorf("Expected the full scan to be explained, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", PathFilter: models.PathFilter{Include: []string{"["}}})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error scanning project: invalid glob") {
+			t.Errorf("Expected the invalid glob to be reported, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/missing"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error scanning project") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		auditHandlers := NewMCPHandlers(nil, nil, nil, WithProjectScanService(&MockProjectScanService{}), WithDependencyAuditService(&MockDependencyAuditService{}))
+		response, _ = auditHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", AuditDependencies: true})
+		content = response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"## Dependency advisories\n\n1 advisories affect the 42 pub.dev packages locked in apps/shop/pubspec.lock.",
+			"- [moderate] **http 0.13.1** (direct): GHSA-4rgh-jx4f-qfcq: CRLF injection in http\n  Fixed in 0.13.3; upgrade with flutter pub upgrade http. https://github.com/advisories/GHSA-4rgh-jx4f-qfcq",
+			"Not checked: private 1.0.0: not found on pub.dev",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected the audit to contain %q, got %s", expected, content)
+			}
+		}
+		response, _ = auditHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", AuditDependencies: true, Format: "json"})
+		report = models.ScanReport{}
+		if err := json.Unmarshal([]byte(response.Content[0].TextContent.Text), &report); err != nil || report.Audit == nil || len(report.Audit.Advisories) != 1 {
+			t.Errorf("Expected the audit in the JSON report, got %s", response.Content[0].TextContent.Text)
+		}
+		response, _ = auditHandlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/unlocked", AuditDependencies: true})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "The dependencies could not be audited: no pubspec.lock in /unlocked; run flutter pub get first.") {
+			t.Errorf("Expected the missing lock file to be reported, got %s", content)
+		}
+		response, _ = handlers.CheckFlutterProject(context.Background(), models.CheckProjectArgs{ProjectPath: "/ws", AuditDependencies: true})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "dependency audits are not enabled on this server") {
+			t.Errorf("Expected the audit to be unavailable, got %s", content)
+		}
+	})
+
+	t.Run("SuggestSDKConstraints", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithSDKConstraintService(&MockSDKConstraintService{}))
+
+		response, _ := handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{Pubspec: "environment:\n  sdk: ^3.0.0\n"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"SDK constraints for Flutter 3.27.1 (Dart 3.6.0)",
+			"- sdk: ^3.0.0 → ^3.6.0",
+			"- flutter: unknown → >=3.27.1",
+			"## Edited environment block",
+			"```diff\n--- a/pubspec.yaml",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{ProjectPath: "/app", TargetVersion: "3.20.0"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "nothing to change") {
+			t.Errorf("Expected nothing to change, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{ProjectPath: "/app", TargetVersion: "latest"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error suggesting SDK constraints") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Please provide either pubspec or project_path") {
+			t.Errorf("Expected missing input message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{Pubspec: "name: app"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("TestDeprecationRules", func(t *testing.T) {
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil)
+
+		response, _ := handlers.TestDeprecationRules(context.Background(), models.RuleTestArgs{Samples: "/samples", Code: "RaisedButton() // expect: RaisedButton"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Rule test for flutter code: 3 rules, 4 fixtures, 2 samples with 1 expectations\n",
+			"## False positives (1)\n\n- **OutlineButton** (builtin) lib/buttons.dart:2: `OutlineButton()`\n",
+			"## False negatives (2)\n\n- **LegacyCard** (custom) fixture: `LegacyCard.outlined()`\n- **RaisedButton** code line 1: `RaisedButton()`\n",
+			"Rules without fixtures (1): Theme.legacyColor\n",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.TestDeprecationRules(context.Background(), models.RuleTestArgs{Rule: "RaisedButton"})
+		if content := response.Content[0].TextContent.Text; content != "Rule test for flutter code: 1 rules, 2 fixtures, 0 samples with 0 expectations\n\n✅ No false positives or false negatives.\n" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+
+		response, _ = handlers.TestDeprecationRules(context.Background(), models.RuleTestArgs{Target: "web"})
+		if content := response.Content[0].TextContent.Text; content != `Error testing deprecation rules: unknown target "web", expected dart or flutter` {
+			t.Errorf("Unexpected response: %s", content)
+		}
+	})
+
+	t.Run("RecommendFlutterPin", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithPinRecommendationService(&MockPinRecommendationService{}))
+
+		response, _ := handlers.RecommendFlutterPin(context.Background(), models.PinRecommendationArgs{ProjectPath: "/app"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"📌 Pin Flutter 3.24.5 (Dart 3.5.4)",
+			"Latest stable: 3.27.1 (Dart 3.6.0)",
+			"Current flutter constraint: >=3.19.0",
+			"## Holding back the upgrade (1)\n\n- **charts** ^1.0.0: 1.5.0 needs sdk >=3.0.0 <3.6.0, up to Flutter 3.24.5\n   Upgrade: 2.0.0 supports Flutter 3.27.1",
+			"## Supporting Flutter 3.27.1 (1)\n\n- http ^1.2.0: 1.2.2 (sdk ^3.4.0)",
+			"## Could not be checked (1)\n\n- internal_ui ^1.0.0: not found on pub.dev",
+			"Not on pub.dev: flutter (sdk)",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.RecommendFlutterPin(context.Background(), models.PinRecommendationArgs{ProjectPath: "/broken"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error recommending a Flutter pin") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.RecommendFlutterPin(context.Background(), models.PinRecommendationArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Please provide either pubspec or project_path") {
+			t.Errorf("Expected missing input message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).RecommendFlutterPin(context.Background(), models.PinRecommendationArgs{Pubspec: "name: app"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("ScanRepoDeprecations", func(t *testing.T) {
+		mockScans := &MockRepoScanService{}
+		handlers := NewMCPHandlers(nil, nil, nil, WithRepoScanService(mockScans))
 
-func (m *MockDeprecationService) UpdateCache() error {
-	return nil
-}
+		response, _ := handlers.ScanRepoDeprecations(context.Background(), models.ScanRepoArgs{Repo: "acme/design_system"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Scanned acme/design_system@HEAD (package design_system): 12 Dart files, 1 deprecations",
+			"1. **AppCard.elevation**",
+			"Documentation: https://github.com/acme/design_system/blob/HEAD/lib/src/card.dart#L12",
+			"Pass save: true",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+		if len(mockScans.saved) != 0 {
+			t.Errorf("Expected nothing to be saved without save, got %+v", mockScans.saved)
+		}
 
-func (m *MockDeprecationService) ExtractDeprecationsFromReleaseNotes(releases []models.FlutterRelease) []models.Deprecation {
-	return m.deprecations
-}
+		response, _ = handlers.ScanRepoDeprecations(context.Background(), models.ScanRepoArgs{Repo: "acme/design_system", Save: true})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Saved 1 entries") || len(mockScans.saved) != 1 {
+			t.Errorf("Expected the entry to be saved, got %s", response.Content[0].TextContent.Text)
+		}
 
-// MockVersionInfoService for testing
-type MockVersionInfoService struct {
-	versionInfo *models.FlutterVersionInfo
-	err         error
-}
+		response, _ = handlers.ScanRepoDeprecations(context.Background(), models.ScanRepoArgs{Repo: "design_system"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error scanning repository") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
 
-func (m *MockVersionInfoService) GetFlutterVersionInfo() (*models.FlutterVersionInfo, error) {
-	return m.versionInfo, m.err
-}
+	t.Run("ReviewPullRequest", func(t *testing.T) {
+		mockReviews := &MockPRReviewService{}
+		suppressions := &MockSuppressionService{suppressions: []models.Suppression{{API: "withOpacity"}}}
+		handlers := NewMCPHandlers(nil, nil, nil, WithPRReviewService(mockReviews), WithSuppressionService(suppressions))
 
-func TestMCPHandlers(t *testing.T) {
-	t.Run("CheckFlutterDeprecations - with deprecations found", func(t *testing.T) {
-		mockDepService := &MockDeprecationService{
-			deprecations: []models.Deprecation{
-				{
-					API:         "Color.withOpacity",
-					Replacement: "Color.withValues(alpha: $1)",
-					Description: "withOpacity is deprecated",
-					Example:     "Color.red.withOpacity(0.5) → Color.red.withValues(alpha: 0.5)",
-					Version:     "Multiple versions",
-				},
-			},
+		response, _ := handlers.ReviewPullRequest(context.Background(), models.ReviewPullRequestArgs{Repo: "acme/shop", Number: 42})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Reviewed acme/shop#42 at 0123456789ab: 3 changed Dart files, 1 comments",
+			"Skipped 2 uses that earlier reviews already commented on.",
+			"lib/shop.dart:4: RaisedButton, FlatButton",
+			"Pass post: true",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+		if !mockReviews.hidden["withOpacity"] {
+			t.Errorf("Expected the suppressed APIs to be hidden, got %v", mockReviews.hidden)
 		}
 
-		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ = handlers.ReviewPullRequest(context.Background(), models.ReviewPullRequestArgs{Repo: "acme/shop", Number: 42, Post: true, IncludeSuppressed: true})
+		content = response.Content[0].TextContent.Text
+		if !strings.Contains(content, "Posted the review: https://github.com/acme/shop/pull/42#pullrequestreview-1") || len(mockReviews.hidden) != 0 {
+			t.Errorf("Expected the posted review with nothing hidden, got %s (%v)", content, mockReviews.hidden)
+		}
 
-		args := models.CheckCodeArgs{Code: "Color.red.withOpacity(0.5)"}
-		response, err := handlers.CheckFlutterDeprecations(args)
+		response, _ = handlers.ReviewPullRequest(context.Background(), models.ReviewPullRequestArgs{Repo: "acme/shop", Number: 7})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error reviewing pull request: GitHub API returned status 404") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
 
-		if err != nil {
-			t.Fatalf("Expected no error, got %v", err)
+		response, _ = NewMCPHandlers(nil, nil, nil).ReviewPullRequest(context.Background(), models.ReviewPullRequestArgs{Repo: "acme/shop", Number: 42})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
 		}
+	})
 
+	t.Run("CheckChangedLines", func(t *testing.T) {
+		mockScans := &MockDiffScanService{}
+		suppressions := &MockSuppressionService{suppressions: []models.Suppression{{API: "Color.withOpacity"}}}
+		handlers := NewMCPHandlers(nil, nil, nil, WithDiffScanService(mockScans), WithSuppressionService(suppressions))
+
+		response, _ := handlers.CheckChangedLines(context.Background(), models.CheckChangedLinesArgs{ProjectPath: "/app", Range: "origin/main...HEAD"})
 		content := response.Content[0].TextContent.Text
-		if !strings.Contains(content, "Found deprecated APIs") {
-			t.Error("Expected response to mention found deprecated APIs")
+		for _, expected := range []string{
+			"Checked the changes in origin/main...HEAD of 2 Dart files in /app: 1 deprecations",
+			"- lib/main.dart:3:5: **RaisedButton** → ElevatedButton",
+			"1 suppressed deprecation(s) hidden",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
 		}
-		if !strings.Contains(content, "Color.withOpacity") {
-			t.Error("Expected response to mention Color.withOpacity")
+		if strings.Contains(content, "lib/theme.dart") {
+			t.Errorf("Expected the suppressed API to be left out, got %s", content)
 		}
-		if !strings.Contains(content, "withOpacity is deprecated") {
-			t.Error("Expected response to mention deprecation description")
+
+		response, _ = handlers.CheckChangedLines(context.Background(), models.CheckChangedLinesArgs{ProjectPath: "/app", Staged: true, IncludeSuppressed: true, ResultLimits: models.ResultLimits{MaxResults: 1}})
+		content = response.Content[0].TextContent.Text
+		if mockScans.change != "staged changes" || !strings.Contains(content, "2 deprecations") || !strings.Contains(content, "1 more findings omitted (showing 1 of 2).") {
+			t.Errorf("Expected both staged findings with one listed, got %s", content)
+		}
+
+		handlers.CheckChangedLines(context.Background(), models.CheckChangedLinesArgs{ProjectPath: "/app", Diff: "--- a/x\n"})
+		if mockScans.change != "changes in the diff" {
+			t.Errorf("Expected the diff to be checked, got %q", mockScans.change)
+		}
+
+		for _, args := range []models.CheckChangedLinesArgs{
+			{ProjectPath: "/app"},
+			{ProjectPath: "/app", Range: "main..HEAD", Staged: true},
+			{ProjectPath: "/app", Range: "main"},
+		} {
+			response, _ = handlers.CheckChangedLines(context.Background(), args)
+			if !strings.Contains(response.Content[0].TextContent.Text, "Error checking changed lines: ") {
+				t.Errorf("Expected an error for %+v, got %s", args, response.Content[0].TextContent.Text)
+			}
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).CheckChangedLines(context.Background(), models.CheckChangedLinesArgs{ProjectPath: "/app", Staged: true})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected not enabled message, got %s", response.Content[0].TextContent.Text)
 		}
 	})
 
-	t.Run("CheckFlutterDeprecations - no deprecations found", func(t *testing.T) {
-		mockDepService := &MockDeprecationService{
-			deprecations: []models.Deprecation{},
+	t.Run("CompareFlutterVersions", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithSDKScanService(&MockSDKScanService{}))
+
+		response, _ := handlers.CompareFlutterVersions(context.Background(), models.CompareVersionsArgs{From: "3.24", To: "3.27"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Deprecations from Flutter 3.24.5 to 3.27.1, read from the local SDKs",
+			"## Newly deprecated in 3.27.1 (1)\n\n1. **Color.opacity**",
+			"## No longer annotated in 3.27.1 (1)",
+			"- **ButtonBar** → OverflowBar",
+			"42 deprecations are present in both versions.",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
 		}
 
-		handlers := NewMCPHandlers(mockDepService, nil, nil)
+		response, _ = handlers.CompareFlutterVersions(context.Background(), models.CompareVersionsArgs{From: "3.24", To: "3.27", ResultLimits: models.ResultLimits{MaxResults: 1}})
+		content = response.Content[0].TextContent.Text
+		if !strings.Contains(content, "1. **Color.opacity**") || strings.Contains(content, "- **ButtonBar**") || !strings.Contains(content, "1 more findings omitted (showing 0 of 1).") {
+			t.Errorf("Expected the removed APIs to be left out, got %s", content)
+		}
+
+		response, _ = handlers.CompareFlutterVersions(context.Background(), models.CompareVersionsArgs{From: "3.22", To: "3.27"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error comparing Flutter versions: no local SDK for Flutter 3.22") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
 
-		args := models.CheckCodeArgs{Code: "ElevatedButton()"}
-		response, err := handlers.CheckFlutterDeprecations(args)
+	t.Run("DeprecationsIntroducedIn", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithSDKScanService(&MockSDKScanService{}))
 
-		if err != nil {
-			t.Fatalf("Expected no error, got %v", err)
+		response, _ := handlers.DeprecationsIntroducedIn(context.Background(), models.IntroducedInArgs{Version: "3.27"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Deprecations introduced in Flutter 3.27.1 (1)",
+			"Compared with 3.24.5, the newest installed version before it.",
+			"1. **Color.opacity**",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.DeprecationsIntroducedIn(context.Background(), models.IntroducedInArgs{Version: "3.24.5"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error listing introduced deprecations: no installed Flutter version older") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).DeprecationsIntroducedIn(context.Background(), models.IntroducedInArgs{Version: "3.27"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected a disabled message, got %s", response.Content[0].TextContent.Text)
 		}
+	})
 
+	t.Run("ListFlutterSDKs", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithLocalSDKService(&MockLocalSDKService{}))
+
+		response, _ := handlers.ListFlutterSDKs(context.Background(), models.ListFlutterSDKsArgs{ProjectPath: "/home/dev/app"})
 		content := response.Content[0].TextContent.Text
-		if !strings.Contains(content, "No deprecated APIs found") {
-			t.Error("Expected response to mention no deprecated APIs found")
+		for _, expected := range []string{
+			"Found 2 Flutter SDK(s)",
+			"- 3.24.5 (channel stable) at /home/dev/flutter [active]\n  Found via: PATH",
+			"Found via: fvm 3.27.1",
+			"Project pin: 3.27.1 (from .fvmrc)",
+			"## Warnings\n\n- The active flutter (3.24.5 at /home/dev/flutter) differs",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.ListFlutterSDKs(context.Background(), models.ListFlutterSDKsArgs{ProjectPath: "/missing"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error detecting Flutter SDKs") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
 		}
 	})
 
-	t.Run("ListFlutterDeprecations - with cache data", func(t *testing.T) {
+	t.Run("CheckCIWorkflow", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithCIWorkflowService(&MockCIWorkflowService{}))
+
+		response, _ := handlers.CheckCIWorkflow(context.Background(), models.CheckCIWorkflowArgs{Content: "jobs: {}"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Latest stable release: 3.27.1",
+			"## Outdated\n\n- subosito/flutter-action 3.24.5 (line 12, job test)\n  → Update flutter-version to 3.27.1",
+			"## Current\n\n- ghcr.io/cirruslabs/flutter 3.27.1 (line 20, job build)",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckCIWorkflow(context.Background(), models.CheckCIWorkflowArgs{Path: "/tmp/empty"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error checking CI workflow") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = handlers.CheckCIWorkflow(context.Background(), models.CheckCIWorkflowArgs{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "either path or content is required") {
+			t.Errorf("Expected a missing input error, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckFlutterWeb", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithWebCheckService(&MockWebCheckService{}))
+
+		response, _ := handlers.CheckFlutterWeb(context.Background(), models.CheckFlutterWebArgs{Content: "<html></html>"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Suggestions for Flutter 3.29.2 (latest stable release)",
+			"## Removed\n\n- --web-renderer (line 3): `--web-renderer canvaskit`\n  → Remove the flag",
+			"## Deprecated\n\n- _flutter.loader.loadEntrypoint (line 7): `_flutter.loader.loadEntrypoint(`\n  → Use flutter_bootstrap.js\n  Documentation: https://docs.flutter.dev/platform-integration/web/initialization",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckFlutterWeb(context.Background(), models.CheckFlutterWebArgs{Content: "<html></html>", ResultLimits: models.ResultLimits{MaxResults: 2}})
+		if content := response.Content[0].TextContent.Text; strings.Contains(content, "html renderer") || !strings.Contains(content, "1 more findings omitted (showing 1 of 2)") {
+			t.Errorf("Expected the last finding to be omitted, got %s", content)
+		}
+
+		response, _ = handlers.CheckFlutterWeb(context.Background(), models.CheckFlutterWebArgs{Path: "/tmp/empty"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error checking web setup") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).CheckFlutterWeb(context.Background(), models.CheckFlutterWebArgs{Path: "."})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected a disabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckDesktopRunners", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil, WithRunnerTemplateService(&MockRunnerTemplateService{}))
+
+		response, _ := handlers.CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{ProjectPath: "/app"})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Compared with the Flutter 3.27.1 templates (.fvmrc) from /fvm/versions/3.27.1",
+			"## Known stale template code\n\n- Win32Window::CreateAndShow (windows/runner/main.cpp:7, changed in Flutter 3.10.0)\n  → Call window.Create(...)",
+			"## Missing files\n\n- windows/runner/runner.exe.manifest",
+			"- windows/runner/main.cpp (1 line(s) added, 1 removed or customized)\n  + if (!window.Create(",
+			"1 other runner file(s) match the templates.",
+			"flutter create --platforms=<platform> .",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		response, _ = handlers.CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{ProjectPath: "/app", ResultLimits: models.ResultLimits{MaxResults: 2}})
+		if content := response.Content[0].TextContent.Text; strings.Contains(content, "removed or customized") || !strings.Contains(content, "1 more findings omitted (showing 2 of 3)") {
+			t.Errorf("Expected the changed files to be omitted, got %s", content)
+		}
+
+		response, _ = handlers.CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{ProjectPath: "/tmp/empty"})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error checking desktop runners") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil).CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{ProjectPath: "."})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected a disabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("Project tools - default to the project under the roots of the client", func(t *testing.T) {
+		constraints := &recordingSDKConstraintService{}
+		roots := &MockProjectRootService{project: "/work/app"}
+		handlers := NewMCPHandlers(nil, nil, nil, WithSDKConstraintService(constraints), WithRunnerTemplateService(&MockRunnerTemplateService{}), WithProjectRootService(roots))
+
+		handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{})
+		handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{ProjectPath: "/other"})
+		handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{Pubspec: "name: app"})
+		if strings.Join(constraints.projectPaths, ",") != "/work/app,/other," {
+			t.Errorf("Expected only a missing project_path to default to the found project, got %q", constraints.projectPaths)
+		}
+
+		response, _ := handlers.CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{})
+		if content := response.Content[0].TextContent.Text; strings.Contains(content, "Error") {
+			t.Errorf("Expected the found project to be checked, got %s", content)
+		}
+
+		roots.project, roots.err = "", fmt.Errorf("no Flutter project found under the roots of the client (/work)")
+		response, _ = handlers.CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{})
+		if content := response.Content[0].TextContent.Text; content != "Error checking desktop runners: project_path is required (no Flutter project found under the roots of the client (/work))" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+		response, _ = handlers.SuggestSDKConstraints(context.Background(), models.SDKConstraintArgs{})
+		if content := response.Content[0].TextContent.Text; content != "Please provide either pubspec or project_path; no Flutter project found under the roots of the client (/work)." {
+			t.Errorf("Unexpected response: %s", content)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil, WithRunnerTemplateService(&MockRunnerTemplateService{})).CheckDesktopRunners(context.Background(), models.CheckDesktopRunnersArgs{})
+		if content := response.Content[0].TextContent.Text; content != "Error checking desktop runners: project_path is required" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+	})
+
+	t.Run("UpdateFlutterDeprecations - success", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{}
 		mockCache := &MockCacheService{
 			cache: &models.DeprecationCache{
 				LastUpdated: time.Now(),
 				Deprecations: []models.Deprecation{
-					{
-						API:         "RaisedButton",
-						Replacement: "ElevatedButton",
-						Description: "RaisedButton is deprecated",
-						Version:     "Multiple versions",
-					},
-					{
-						API:         "FlatButton",
-						Replacement: "TextButton",
-						Description: "FlatButton is deprecated",
-						Version:     "Multiple versions",
-					},
+					{API: "TestAPI", Replacement: "NewAPI"},
 				},
 			},
 		}
 
-		handlers := NewMCPHandlers(nil, nil, mockCache)
+		handlers := NewMCPHandlers(mockDepService, nil, mockCache)
 
-		args := models.NoArguments{}
-		response, err := handlers.ListFlutterDeprecations(args)
+		args := models.UpdateDeprecationsArgs{}
+		response, err := handlers.UpdateFlutterDeprecations(context.Background(), args)
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
 
 		content := response.Content[0].TextContent.Text
-		if !strings.Contains(content, "Flutter Deprecations") {
-			t.Error("Expected response to mention Flutter Deprecations")
+		if !strings.Contains(content, "Successfully updated deprecations cache") {
+			t.Error("Expected response to mention successful update")
 		}
-		if !strings.Contains(content, "RaisedButton") {
-			t.Error("Expected response to mention RaisedButton")
+		if !strings.Contains(content, "Found 1 deprecations") {
+			t.Error("Expected response to mention found deprecations count")
 		}
-		if !strings.Contains(content, "FlatButton") {
-			t.Error("Expected response to mention FlatButton")
+	})
+
+	t.Run("CacheStatus", func(t *testing.T) {
+		updated := time.Now().Add(-30 * time.Hour)
+		status := &models.CacheStatus{
+			Path:         "/tmp/deprecations.json",
+			Exists:       true,
+			SizeBytes:    2048,
+			LastUpdated:  updated,
+			Age:          30 * time.Hour,
+			TTL:          24 * time.Hour,
+			Expired:      true,
+			ExpiresAt:    updated.Add(24 * time.Hour),
+			Deprecations: 3,
+			Manual:       1,
+			BySource:     map[string]int{"flutter_source": 2, "manual": 1, "builtin": 1},
+			Refs:         map[string]int{"flutter/flutter@stable": 2},
+		}
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, &MockCacheService{}, WithCacheStatusService(&MockCacheStatusService{status: status}))
+
+		response, _ := handlers.CacheStatus(context.Background(), models.NoArguments{})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{"/tmp/deprecations.json (2048 bytes)", "(30h0m0s ago)", "expired (TTL 24h0m0s)", "3 deprecations, 1 manual", "- flutter_source: 2", "## Scanned refs\n\n- flutter/flutter@stable: 2"} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected %q in the status, got %s", expected, content)
+			}
+		}
+
+		status.Expired, status.Age = false, time.Hour
+		response, _ = handlers.CacheStatus(context.Background(), models.NoArguments{})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "fresh (TTL 24h0m0s); update_flutter_deprecations rescans after") {
+			t.Errorf("Expected a fresh cache, got %s", content)
+		}
+
+		response, _ = NewMCPHandlers(&MockDeprecationService{}, nil, &MockCacheService{}, WithCacheStatusService(&MockCacheStatusService{status: &models.CacheStatus{Path: "/tmp/deprecations.json", Expired: true}})).CacheStatus(context.Background(), models.NoArguments{})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "No cache file at /tmp/deprecations.json. Run update_flutter_deprecations") {
+			t.Errorf("Expected a missing cache, got %s", content)
+		}
+
+		response, _ = NewMCPHandlers(&MockDeprecationService{}, nil, &MockCacheService{}).CacheStatus(context.Background(), models.NoArguments{})
+		if content := response.Content[0].TextContent.Text; content != "Cache status is not enabled on this server." {
+			t.Errorf("Unexpected response: %s", content)
 		}
 	})
 
-	t.Run("ListFlutterDeprecations - empty cache", func(t *testing.T) {
+	t.Run("ClearFlutterDeprecationsCache", func(t *testing.T) {
+		mockCache := &MockCacheService{cache: &models.DeprecationCache{
+			Deprecations: []models.Deprecation{{API: "RaisedButton"}, {API: "FlatButton"}},
+			Manual:       []models.Deprecation{{API: "LegacyCard"}},
+		}}
+		notified := 0
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, mockCache, WithCacheClearService(&MockCacheClearService{cache: mockCache}), WithCacheChangedNotifier(func() { notified++ }))
+
+		response, _ := handlers.ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "(2 deprecations and 1 manual entries)") || !strings.Contains(content, "confirm: true") || len(mockCache.cache.Deprecations) != 2 {
+			t.Errorf("Expected the cache to be kept without confirmation, got %s", content)
+		}
+
+		response, _ = handlers.ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{Confirm: true})
+		if content := response.Content[0].TextContent.Text; content != "Successfully cleared the deprecations cache, removing 2 deprecations and 1 manual entries. Run update_flutter_deprecations to rebuild it." {
+			t.Errorf("Unexpected response: %s", content)
+		}
+		if len(mockCache.cache.Deprecations) != 0 || notified != 1 {
+			t.Errorf("Expected the cache to be cleared and announced, got %+v and %d notifications", mockCache.cache, notified)
+		}
+
+		failing := NewMCPHandlers(&MockDeprecationService{}, nil, mockCache, WithCacheClearService(&MockCacheClearService{err: fmt.Errorf("cached scan results: permission denied")}))
+		response, _ = failing.ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{Confirm: true})
+		if content := response.Content[0].TextContent.Text; content != "Error clearing cached scan results: permission denied" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+
+		response, _ = NewMCPHandlers(&MockDeprecationService{}, nil, mockCache).ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{Confirm: true})
+		if content := response.Content[0].TextContent.Text; content != "Clearing the cache is not enabled on this server." {
+			t.Errorf("Unexpected response: %s", content)
+		}
+	})
+
+	t.Run("ClearFlutterDeprecationsCache - asks the user through elicitation", func(t *testing.T) {
+		mockCache := &MockCacheService{cache: &models.DeprecationCache{Deprecations: []models.Deprecation{{API: "RaisedButton"}}}}
+		confirmer := &MockConfirmer{}
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, mockCache, WithCacheClearService(&MockCacheClearService{cache: mockCache}), WithConfirmer(confirmer))
+
+		// The answer of the user overrides the confirm argument of the model
+		response, _ := handlers.ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{Confirm: true})
+		if content := response.Content[0].TextContent.Text; content != "Did not clear the deprecations cache: the user did not confirm the change." || len(mockCache.cache.Deprecations) != 1 {
+			t.Errorf("Expected the cache to be kept, got %s", content)
+		}
+		if len(confirmer.messages) != 1 || !strings.Contains(confirmer.messages[0], "This deletes 1 deprecations and 0 manual entries") {
+			t.Errorf("Expected the user to be shown what is deleted, got %v", confirmer.messages)
+		}
+
+		confirmer.accept = true
+		response, _ = handlers.ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{})
+		if content := response.Content[0].TextContent.Text; !strings.HasPrefix(content, "Successfully cleared the deprecations cache") || len(mockCache.cache.Deprecations) != 0 {
+			t.Errorf("Expected the confirmed cache to be cleared, got %s", content)
+		}
+
+		confirmer.err = fmt.Errorf("confirmation request failed: no answer")
+		response, _ = handlers.ClearFlutterDeprecationsCache(context.Background(), models.ClearCacheArgs{Confirm: true})
+		if content := response.Content[0].TextContent.Text; content != "Error asking for confirmation: confirmation request failed: no answer" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+	})
+
+	t.Run("UpdateFlutterDeprecations - notifies cache changes", func(t *testing.T) {
+		mockCache := &MockCacheService{cache: &models.DeprecationCache{LastUpdated: time.Now()}}
+		notified := 0
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, mockCache, WithCacheChangedNotifier(func() { notified++ }))
+
+		handlers.UpdateFlutterDeprecations(context.Background(), models.UpdateDeprecationsArgs{})
+		if notified != 1 {
+			t.Errorf("Expected one notification, got %d", notified)
+		}
+	})
+
+	t.Run("UpdateFlutterDeprecations - scoped", func(t *testing.T) {
+		mockDepService := &MockDeprecationService{}
+		mockCache := &MockCacheService{cache: &models.DeprecationCache{Deprecations: []models.Deprecation{{API: "RaisedButton"}}}}
+		notified := 0
+		handlers := NewMCPHandlers(mockDepService, nil, mockCache, WithCacheChangedNotifier(func() { notified++ }))
+
+		response, _ := handlers.UpdateFlutterDeprecations(context.Background(), models.UpdateDeprecationsArgs{Directories: []string{"material", "widgets"}, Sources: []string{"fix-data"}})
+		if content := response.Content[0].TextContent.Text; content != "Successfully refreshed material, widgets, fix-data. Found 1 deprecations. Last full update: never" {
+			t.Errorf("Unexpected response: %s", content)
+		}
+		if strings.Join(mockDepService.scoped, ",") != "material,widgets,fix-data" || notified != 1 {
+			t.Errorf("Expected a scoped update to be announced, got %v and %d notifications", mockDepService.scoped, notified)
+		}
+
+		response, _ = handlers.UpdateFlutterDeprecations(context.Background(), models.UpdateDeprecationsArgs{Sources: []string{"lint"}})
+		if content := response.Content[0].TextContent.Text; content != `Error updating deprecations cache: unknown source "lint"` {
+			t.Errorf("Unexpected response: %s", content)
+		}
+	})
+
+	t.Run("DeprecationsResource", func(t *testing.T) {
 		mockCache := &MockCacheService{
 			cache: &models.DeprecationCache{
 				LastUpdated:  time.Now(),
-				Deprecations: []models.Deprecation{},
+				Deprecations: []models.Deprecation{{API: "RaisedButton", Replacement: "ElevatedButton"}},
 			},
 		}
-
 		handlers := NewMCPHandlers(nil, nil, mockCache)
 
-		args := models.NoArguments{}
-		response, err := handlers.ListFlutterDeprecations(args)
-
+		response, err := handlers.DeprecationsResource(context.Background())
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
-
-		content := response.Content[0].TextContent.Text
-		if !strings.Contains(content, "No deprecations found in cache") {
-			t.Error("Expected response to mention no deprecations found")
+		resource := response.Contents[0].TextResourceContents
+		if resource.Uri != config.CACHE_RESOURCE_URI || !strings.Contains(resource.Text, `"api": "RaisedButton"`) {
+			t.Errorf("Unexpected resource %+v", resource)
 		}
 	})
 
-	t.Run("UpdateFlutterDeprecations - success", func(t *testing.T) {
-		mockDepService := &MockDeprecationService{}
+	t.Run("DeprecationPartitionResource", func(t *testing.T) {
 		mockCache := &MockCacheService{
 			cache: &models.DeprecationCache{
 				LastUpdated: time.Now(),
 				Deprecations: []models.Deprecation{
-					{API: "TestAPI", Replacement: "NewAPI"},
+					{API: "Color.withOpacity", Version: "3.27.0", Category: "dart:ui"},
+					{API: "ThemeData.dialogBackgroundColor", Version: "3.27.0-0.1.pre", Category: "material"},
 				},
 			},
 		}
+		handlers := NewMCPHandlers(nil, nil, mockCache)
 
-		handlers := NewMCPHandlers(mockDepService, nil, mockCache)
+		partitions, err := handlers.DeprecationPartitions()
+		if err != nil || len(partitions) != 2 || partitions[1].URI != "flutter-deprecations://deprecations/3.27/material" {
+			t.Fatalf("Expected the dart:ui and material partitions of 3.27, got %+v, %v", partitions, err)
+		}
 
-		args := models.NoArguments{}
-		response, err := handlers.UpdateFlutterDeprecations(args)
+		response, err := handlers.DeprecationPartitionResource("3.27", "material")(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resource := response.Contents[0].TextResourceContents
+		if resource.Uri != partitions[1].URI || !strings.Contains(resource.Text, `"api": "ThemeData.dialogBackgroundColor"`) || strings.Contains(resource.Text, "Color.withOpacity") {
+			t.Errorf("Expected only the 3.27 material deprecation, got %+v", resource)
+		}
+
+		response, err = handlers.DeprecationPartitionResource("3.24", "material")(context.Background())
+		if err != nil || !strings.Contains(response.Contents[0].TextResourceContents.Text, `"deprecations": []`) {
+			t.Errorf("Expected an emptied partition to be served without entries, got %+v, %v", response, err)
+		}
+	})
 
+	t.Run("ServerStats - reports instrumented calls", func(t *testing.T) {
+		stats := services.NewStatsService("")
+		handlers := NewMCPHandlers(&MockDeprecationService{}, nil, nil, WithStatsService(stats))
+
+		check := Instrument(stats, "check_flutter_deprecations", handlers.CheckFlutterDeprecations)
+		if _, err := check(context.Background(), models.CheckDeprecationsArgs{Code: "Text('hi')"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		stats.RecordUpstream(services.UpstreamGitHub, 1500*time.Millisecond, false)
+
+		response, err := handlers.ServerStats(context.Background(), models.NoArguments{})
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
 
 		content := response.Content[0].TextContent.Text
-		if !strings.Contains(content, "Successfully updated deprecations cache") {
-			t.Error("Expected response to mention successful update")
+		if !strings.Contains(content, "| check_flutter_deprecations | 1 | 0 |") {
+			t.Errorf("Expected tool row in stats, got: %s", content)
 		}
-		if !strings.Contains(content, "Found 1 deprecations") {
-			t.Error("Expected response to mention found deprecations count")
+
+		// A failure is answered with its explanation, and counts as an error through the structured
+		// result it does not record
+		for _, target := range []string{"web", ""} {
+			call := context.WithValue(context.Background(), toolCallKey{}, &toolCall{tool: "check_flutter_deprecations", declared: true})
+			if _, err := check(call, models.CheckDeprecationsArgs{Code: "Text('hi')", Target: target}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if tool := stats.Snapshot().Tools["check_flutter_deprecations"]; tool == nil || tool.Calls != 3 || tool.Errors != 1 {
+			t.Errorf("Expected the failed call to count as an error, got %+v", tool)
+		}
+		if !strings.Contains(content, "| github_api | 1 | 0 | 1500.0 | 1500.0 |") {
+			t.Errorf("Expected upstream row in stats, got: %s", content)
+		}
+		if !strings.Contains(content, "No cache loads recorded yet.") {
+			t.Errorf("Expected an empty cache section, got: %s", content)
+		}
+
+		stats.RecordCacheLoad(services.CacheLoadMemory, time.Now().Add(-time.Hour))
+		stats.RecordCacheLoad(services.CacheLoadMemory, time.Now().Add(-30*time.Hour))
+		stats.RecordCacheLoad(services.CacheLoadDisk, time.Now())
+		stats.RecordCacheLoad(services.CacheLoadMissing, time.Time{})
+		stats.RecordCacheUpdate(services.CacheUpdateRebuild)
+		stats.RecordCacheUpdate(services.CacheUpdateFresh)
+		response, _ = handlers.ServerStats(context.Background(), models.NoArguments{})
+		content = response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Loads: 4 (2 from memory, 1 from disk, 1 without a cache file)",
+			"Memory hit rate: 50.0%",
+			"Stale serves: 1 (older than the 24h0m0s TTL",
+			"Oldest data served: 30h0m0s old",
+			"Updates: 1 rebuilds, 0 scoped refreshes, 1 skipped as fresh",
+			"Stale data was served; run with --daemon",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected the cache section to contain %q, got: %s", expected, content)
+			}
+		}
+	})
+
+	t.Run("ServerStats - disabled", func(t *testing.T) {
+		handlers := NewMCPHandlers(nil, nil, nil)
+		response, _ := handlers.ServerStats(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Error("Expected stats to be reported as disabled")
+		}
+	})
+
+	t.Run("RateLimitStatus", func(t *testing.T) {
+		reset := time.Now().Add(25 * time.Minute)
+		handlers := NewMCPHandlers(nil, nil, nil, WithRateLimitService(&MockRateLimitService{status: &models.RateLimitStatus{
+			Limit: 60, Used: 60, Reset: reset, Source: services.RateLimitSourceResponse, ObservedAt: time.Now(), Error: "status 503",
+		}}))
+
+		response, _ := handlers.RateLimitStatus(context.Background(), models.NoArguments{})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"Authenticated: no (set GITHUB_TOKEN",
+			"Remaining: 0 of 60 (60 used)",
+			"Source: last GitHub API response",
+			"The rate_limit endpoint failed (status 503)",
+			"The quota is used up",
+			"or set GITHUB_TOKEN.",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		handlers = NewMCPHandlers(nil, nil, nil, WithRateLimitService(&MockRateLimitService{status: &models.RateLimitStatus{
+			Authenticated: true, Limit: 5000, Remaining: 4990, Used: 10, Reset: reset, Source: services.RateLimitSourceEndpoint,
+		}}))
+		response, _ = handlers.RateLimitStatus(context.Background(), models.NoArguments{})
+		if content := response.Content[0].TextContent.Text; !strings.Contains(content, "Authenticated: yes") || !strings.Contains(content, "Quota available") {
+			t.Errorf("Expected an available authenticated quota, got %s", content)
+		}
+
+		response, _ = NewMCPHandlers(nil, nil, nil, WithRateLimitService(&MockRateLimitService{})).RateLimitStatus(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "Error getting the GitHub rate limit") {
+			t.Errorf("Expected error message, got %s", response.Content[0].TextContent.Text)
+		}
+		response, _ = NewMCPHandlers(nil, nil, nil).RateLimitStatus(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected a disabled message, got %s", response.Content[0].TextContent.Text)
+		}
+	})
+
+	t.Run("CheckUpstreams", func(t *testing.T) {
+		upstreams := &MockUpstreamCheckService{checks: []models.UpstreamCheck{
+			{Name: "GitHub API", URL: "https://api.github.com/rate_limit", Reachable: true, Status: 200, Latency: 120 * time.Millisecond},
+			{Name: "Docker Hub", URL: "https://hub.docker.com/v2/repositories/instrumentisto/flutter", Latency: 5 * time.Second, Error: "timed out after 5s"},
+			{Name: "Flutter releases", URL: "https://storage.googleapis.com/releases_linux.json", Status: 502, Latency: 80 * time.Millisecond},
+		}}
+		response, _ := NewMCPHandlers(nil, nil, nil, WithUpstreamCheckService(upstreams)).CheckUpstreams(context.Background(), models.NoArguments{})
+		content := response.Content[0].TextContent.Text
+		for _, expected := range []string{
+			"| GitHub API | ✅ 200 | 120 | https://api.github.com/rate_limit |",
+			"| Docker Hub | ❌ timed out after 5s | 5000 |",
+			"| Flutter releases | ❌ 502 | 80 |",
+			"2 of 3 endpoints are unreachable: Docker Hub, Flutter releases.",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Expected response to contain %q, got %s", expected, content)
+			}
+		}
+
+		upstreams.checks = upstreams.checks[:1]
+		response, _ = NewMCPHandlers(nil, nil, nil, WithUpstreamCheckService(upstreams)).CheckUpstreams(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "All upstream endpoints are reachable") {
+			t.Errorf("Expected every endpoint to be reachable, got %s", response.Content[0].TextContent.Text)
+		}
+		response, _ = NewMCPHandlers(nil, nil, nil).CheckUpstreams(context.Background(), models.NoArguments{})
+		if !strings.Contains(response.Content[0].TextContent.Text, "not enabled") {
+			t.Errorf("Expected a disabled message, got %s", response.Content[0].TextContent.Text)
 		}
 	})
 
@@ -217,7 +2912,7 @@ func TestMCPHandlers(t *testing.T) {
 		handlers := NewMCPHandlers(nil, mockVersionService, nil)
 
 		args := models.NoArguments{}
-		response, err := handlers.CheckFlutterVersionInfo(args)
+		response, err := handlers.CheckFlutterVersionInfo(context.Background(), args)
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -240,7 +2935,7 @@ func TestMCPHandlers(t *testing.T) {
 		handlers := NewMCPHandlers(nil, mockVersionService, nil)
 
 		args := models.NoArguments{}
-		response, err := handlers.CheckFlutterVersionInfo(args)
+		response, err := handlers.CheckFlutterVersionInfo(context.Background(), args)
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -264,3 +2959,24 @@ type MockError struct {
 func (e *MockError) Error() string {
 	return e.message
 }
+
+func BenchmarkListFlutterDeprecations(b *testing.B) {
+	cache := &models.DeprecationCache{LastUpdated: time.Now()}
+	for i := 0; i < 1000; i++ {
+		cache.Deprecations = append(cache.Deprecations, models.Deprecation{
+			API:         fmt.Sprintf("Widget%d.property", i),
+			Replacement: fmt.Sprintf("Widget%d.replacement", i),
+			Description: "Scanned deprecation used for benchmarking",
+			Version:     "3.19.0",
+		})
+	}
+	handlers := NewMCPHandlers(nil, nil, &MockCacheService{cache: cache})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := handlers.ListFlutterDeprecations(context.Background(), models.ListDeprecationsArgs{}); err != nil {
+			b.Fatalf("Expected no error, got %v", err)
+		}
+	}
+}
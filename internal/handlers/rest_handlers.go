@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// RESTHandler serves the deprecation data as JSON for tools that do not speak MCP. It shares the
+// services, suppressions and statistics of the MCP tools.
+//
+//	GET  /deprecations   the cache; ?include_suppressed=true as for list_flutter_deprecations
+//	POST /check          {"code": "...", "encoding": "gzip+base64", "include_suppressed": true} or the code as plain text
+//	POST /fixes          {"file": "/abs/path.dart", "code": "...", ...} as for get_analyzer_fixes but with code required, answered in the analyzer plugin format
+//	GET  /version-info   the latest Flutter version and its FVM and Docker availability
+//	GET  /metrics        the server_stats statistics: tool and upstream calls, cache loads and updates
+func (h *MCPHandlers) RESTHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /deprecations", h.instrumentREST("GET /deprecations", h.restDeprecations))
+	mux.HandleFunc("POST /check", h.instrumentREST("POST /check", h.restCheck))
+	mux.HandleFunc("POST /fixes", h.instrumentREST("POST /fixes", h.restFixes))
+	mux.HandleFunc("GET /version-info", h.instrumentREST("GET /version-info", h.restVersionInfo))
+	mux.HandleFunc("GET /metrics", h.instrumentREST("GET /metrics", h.restMetrics))
+	return mux
+}
+
+// rejectProjectPath refuses a project_path. Unlike the MCP tools, the REST API applies only the
+// machine-wide suppressions, because reading the suppressions under a path the client names would
+// let anyone who can reach it probe any path the server can.
+func rejectProjectPath(projectPath string) error {
+	if strings.TrimSpace(projectPath) != "" {
+		return fmt.Errorf("project_path is not supported: the REST API applies only the machine-wide suppressions")
+	}
+	return nil
+}
+
+// restDeprecations handles GET /deprecations
+func (h *MCPHandlers) restDeprecations(w http.ResponseWriter, r *http.Request) (int, error) {
+	if err := rejectProjectPath(r.URL.Query().Get("project_path")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	cache, err := h.cacheService.Load()
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error loading deprecations: %v", err)
+	}
+
+	sort.Slice(cache.Deprecations, func(i, j int) bool {
+		return cache.Deprecations[i].API < cache.Deprecations[j].API
+	})
+	deprecations, suppressed, err := h.splitSuppressed(cache.Deprecations, "")
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error loading suppressions: %v", err)
+	}
+	manual, suppressedManual, _ := h.splitSuppressed(cache.Manual, "")
+
+	response := models.DeprecationsResponse{
+		LastUpdated:  cache.LastUpdated,
+		Deprecations: nonNil(deprecations),
+		Manual:       nonNil(manual),
+	}
+	if includeSuppressed, _ := strconv.ParseBool(r.URL.Query().Get("include_suppressed")); includeSuppressed {
+		response.Suppressed = append(suppressed, suppressedManual...)
+	}
+	return writeJSON(w, http.StatusOK, response)
+}
+
+// restCheck handles POST /check
+func (h *MCPHandlers) restCheck(w http.ResponseWriter, r *http.Request) (int, error) {
+	body, status, err := readRESTBody(w, r)
+	if err != nil {
+		return status, err
+	}
+
+	var args models.CheckDeprecationsArgs
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(body, &args); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid JSON body: %v", err)
+		}
+	} else {
+		args.Code = string(body)
+	}
+	if err := rejectProjectPath(args.ProjectPath); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if args.Code, err = services.DecodeCode(args.Code, args.Encoding); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if strings.TrimSpace(args.Code) == "" {
+		return http.StatusBadRequest, fmt.Errorf("no code to check")
+	}
+	if args.Target == "" {
+		args.Target = r.URL.Query().Get("target")
+	}
+
+	found, err := h.checkCode(args.Code, args.Target)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	deprecations, suppressed, err := h.splitSuppressed(found, "")
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error loading suppressions: %v", err)
+	}
+
+	response := models.CheckResponse{Deprecations: nonNil(deprecations), SuppressedCount: len(suppressed)}
+	if args.IncludeSuppressed {
+		response.Suppressed = suppressed
+	}
+	return writeJSON(w, http.StatusOK, response)
+}
+
+// restFixes handles POST /fixes
+func (h *MCPHandlers) restFixes(w http.ResponseWriter, r *http.Request) (int, error) {
+	body, status, err := readRESTBody(w, r)
+	if err != nil {
+		return status, err
+	}
+
+	var args models.AnalyzerFixesArgs
+	if err := json.Unmarshal(body, &args); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid JSON body: %v", err)
+	}
+	if args.Target == "" {
+		args.Target = r.URL.Query().Get("target")
+	}
+	// Unlike get_analyzer_fixes, the REST API never reads the file, which would let anyone who can
+	// reach it read or probe any path the server can
+	if args.Code == "" && strings.TrimSpace(args.File) != "" {
+		return http.StatusBadRequest, fmt.Errorf("no code given: POST /fixes does not read files, send their contents as code")
+	}
+	if err := rejectProjectPath(args.ProjectPath); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	result, err := h.analyzerFixes(args)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return writeJSON(w, http.StatusOK, result)
+}
+
+// readRESTBody reads a request body within REST_MAX_BODY, decompressing a gzip body as it is
+// read, and returns the status of the failure when it cannot
+func readRESTBody(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	// A gzip body is decompressed as it is read, within the same limit as plain bodies
+	var reader io.Reader = http.MaxBytesReader(w, r.Body, config.REST_MAX_BODY)
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid gzip request body: %v", err)
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, config.REST_MAX_BODY+1)
+	}
+	body, err := io.ReadAll(reader)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit)
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("error reading request body: %v", err)
+	}
+	if len(body) > config.REST_MAX_BODY {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("decompressed request body exceeds %d bytes", config.REST_MAX_BODY)
+	}
+	return body, http.StatusOK, nil
+}
+
+// restVersionInfo handles GET /version-info
+func (h *MCPHandlers) restVersionInfo(w http.ResponseWriter, r *http.Request) (int, error) {
+	info, err := h.versionInfoService.GetFlutterVersionInfo(r.Context())
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("error getting Flutter version info: %v", err)
+	}
+	return writeJSON(w, http.StatusOK, info)
+}
+
+// restMetrics handles GET /metrics
+func (h *MCPHandlers) restMetrics(w http.ResponseWriter, r *http.Request) (int, error) {
+	if h.statsService == nil {
+		return http.StatusNotFound, fmt.Errorf("usage statistics are not enabled on this server")
+	}
+	return writeJSON(w, http.StatusOK, h.statsService.Snapshot())
+}
+
+// instrumentREST turns an endpoint into an http.HandlerFunc that reports failures as JSON errors
+// and records the call in the usage statistics next to the MCP tools
+func (h *MCPHandlers) instrumentREST(route string, endpoint func(http.ResponseWriter, *http.Request) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		status, err := endpoint(w, r)
+		if err != nil {
+			writeJSON(w, status, models.ErrorResponse{Error: err.Error()})
+		}
+		if h.statsService != nil {
+			h.statsService.RecordTool("rest "+route, time.Since(start), err != nil)
+		}
+		slog.Debug("REST request", "route", route, "status", status, "duration", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// writeJSON writes value as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, value any) (int, error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		slog.Debug("Failed to write REST response", "error", err)
+	}
+	return status, nil
+}
+
+// nonNil returns an empty slice for nil so that JSON responses contain [] instead of null
+func nonNil(deprecations []models.Deprecation) []models.Deprecation {
+	if deprecations == nil {
+		return []models.Deprecation{}
+	}
+	return deprecations
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+)
+
+func TestRESTHandler(t *testing.T) {
+	mockCache := &MockCacheService{
+		cache: &models.DeprecationCache{
+			LastUpdated:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Deprecations: []models.Deprecation{{API: "RaisedButton", Replacement: "ElevatedButton"}, {API: "FlatButton", Replacement: "TextButton"}},
+			Manual:       []models.Deprecation{{API: "LegacyCard", Replacement: "AppCard"}},
+		},
+	}
+	mockDeprecations := &MockDeprecationService{
+		deprecations: []models.Deprecation{{API: "RaisedButton", Replacement: "ElevatedButton"}, {API: "FlatButton", Replacement: "TextButton"}},
+	}
+	versionInfo := &MockVersionInfoService{versionInfo: &models.FlutterVersionInfo{LatestVersion: "3.27.1", Source: "official"}}
+	suppressions := &MockSuppressionService{suppressions: []models.Suppression{{API: "FlatButton"}}}
+
+	handlers := NewMCPHandlers(mockDeprecations, versionInfo, mockCache, WithSuppressionService(suppressions))
+	server := httptest.NewServer(handlers.RESTHandler())
+	defer server.Close()
+
+	t.Run("GET /deprecations", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/deprecations?include_suppressed=true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var body models.DeprecationsResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || len(body.Deprecations) != 1 || body.Deprecations[0].API != "RaisedButton" {
+			t.Errorf("Unexpected response %d: %+v", resp.StatusCode, body)
+		}
+		if len(body.Manual) != 1 || len(body.Suppressed) != 1 || body.Suppressed[0].API != "FlatButton" {
+			t.Errorf("Expected the manual entry and the suppressed FlatButton, got %+v", body)
+		}
+	})
+
+	t.Run("POST /check", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/check", "application/json", strings.NewReader(`{"code": "RaisedButton()"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var body models.CheckResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if len(body.Deprecations) != 1 || body.SuppressedCount != 1 || body.Suppressed != nil {
+			t.Errorf("Unexpected check response %+v", body)
+		}
+
+		resp, err = http.Post(server.URL+"/check", "text/plain", strings.NewReader("  "))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var failure models.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&failure)
+		if resp.StatusCode != http.StatusBadRequest || failure.Error != "no code to check" {
+			t.Errorf("Expected a 400 for an empty body, got %d %+v", resp.StatusCode, failure)
+		}
+	})
+
+	t.Run("project_path is refused", func(t *testing.T) {
+		// Suppressions under a path the client names would let it probe any path the server can read
+		for _, request := range []struct{ method, path, body string }{
+			{http.MethodGet, "/deprecations?project_path=/etc", ""},
+			{http.MethodPost, "/check", `{"code": "RaisedButton()", "project_path": "/etc"}`},
+			{http.MethodPost, "/fixes", `{"file": "/app/lib/main.dart", "code": "RaisedButton()", "project_path": "/etc"}`},
+		} {
+			req, _ := http.NewRequest(request.method, server.URL+request.path, strings.NewReader(request.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var failure models.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&failure)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest || !strings.HasPrefix(failure.Error, "project_path is not supported") {
+				t.Errorf("%s %s: expected a 400 for project_path, got %d %+v", request.method, request.path, resp.StatusCode, failure)
+			}
+		}
+	})
+
+	t.Run("POST /check with a gzip body", func(t *testing.T) {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write([]byte("RaisedButton()"))
+		gz.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/check", &compressed)
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set("Content-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var body models.CheckResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		if resp.StatusCode != http.StatusOK || len(body.Deprecations) != 1 {
+			t.Errorf("Expected the decompressed body to be checked, got %d %+v", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("POST /fixes", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/fixes", "application/json", strings.NewReader(`{"file": "/app/lib/main.dart", "code": "RaisedButton()"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var body models.AnalyzerFixesResult
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || len(body.Fixes) != 1 || body.Fixes[0].Error.Location.File != "/app/lib/main.dart" {
+			t.Errorf("Expected the unsuppressed RaisedButton, got %d %+v", resp.StatusCode, body)
+		}
+
+		resp, err = http.Post(server.URL+"/fixes", "application/json", strings.NewReader(`{"code": "RaisedButton()"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var failure models.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&failure)
+		if resp.StatusCode != http.StatusBadRequest || failure.Error != "no file given" {
+			t.Errorf("Expected a 400 without a file, got %d %+v", resp.StatusCode, failure)
+		}
+
+		// The file is only a name for the locations, it is never read
+		resp, err = http.Post(server.URL+"/fixes", "application/json", strings.NewReader(`{"file": "/etc/passwd"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		failure = models.ErrorResponse{}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		if resp.StatusCode != http.StatusBadRequest || !strings.HasPrefix(failure.Error, "no code given") {
+			t.Errorf("Expected a 400 without code, got %d %+v", resp.StatusCode, failure)
+		}
+	})
+
+	t.Run("GET /version-info", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/version-info")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var info models.FlutterVersionInfo
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil || info.LatestVersion != "3.27.1" {
+			t.Errorf("Unexpected version info %+v (%v)", info, err)
+		}
+
+		versionInfo.err = fmt.Errorf("rate limited")
+		resp, err = http.Get(server.URL + "/version-info")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("Expected 502 when the upstream fails, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("GET /metrics", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected 404 without statistics, got %d", resp.StatusCode)
+		}
+
+		stats := services.NewStatsService("")
+		stats.RecordCacheLoad(services.CacheLoadMemory, time.Now())
+		withStats := httptest.NewServer(NewMCPHandlers(mockDeprecations, versionInfo, mockCache, WithStatsService(stats)).RESTHandler())
+		defer withStats.Close()
+		resp, err = http.Get(withStats.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var metrics models.ServerStats
+		if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil || metrics.Cache.Loads != 1 || metrics.Cache.MemoryHits != 1 {
+			t.Errorf("Expected the cache statistics, got %+v (%v)", metrics, err)
+		}
+	})
+
+	t.Run("unknown routes and methods", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/deprecations", "text/plain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405, got %d", resp.StatusCode)
+		}
+	})
+}
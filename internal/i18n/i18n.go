@@ -0,0 +1,185 @@
+// Package i18n translates the reports and command line messages, as well as the descriptions of
+// the built-in rules, for teams that do not work in English. The message catalogs are keyed by the
+// English text, so a message without a translation is printed in English.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// localeFiles holds the message catalog of each language other than English
+//
+//go:embed locales/*.yaml
+var localeFiles embed.FS
+
+// catalog is the format of a locale file: the translations of the messages, keyed by their
+// English text, and of the descriptions of the built-in rules, keyed by their API
+type catalog struct {
+	Messages map[string]string `yaml:"messages"`
+	Rules    map[string]string `yaml:"rules"`
+}
+
+// Printer formats the messages of one language
+type Printer struct {
+	language string
+	catalog  catalog
+}
+
+// English prints the messages as they are written
+var English = &Printer{language: config.LANGUAGE_ENGLISH}
+
+// printers holds the printer of each language, loaded once
+var printers = mustLoadPrinters()
+
+// mustLoadPrinters parses the locale files; an invalid file is a programming error
+func mustLoadPrinters() map[string]*Printer {
+	printers := map[string]*Printer{config.LANGUAGE_ENGLISH: English}
+	for _, language := range config.Languages() {
+		if language == config.LANGUAGE_ENGLISH {
+			continue
+		}
+		data, err := localeFiles.ReadFile("locales/" + language + ".yaml")
+		if err != nil {
+			panic(err)
+		}
+		printer := &Printer{language: language}
+		if err := yaml.Unmarshal(data, &printer.catalog); err != nil {
+			panic(fmt.Errorf("locale %s: %v", language, err))
+		}
+		printers[language] = printer
+	}
+	return printers
+}
+
+// ParseLanguage normalizes a language such as de, ES or a locale such as de_DE.UTF-8 to one of
+// config.Languages, with English for an empty one
+func ParseLanguage(language string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(language))
+	if cut := strings.IndexAny(name, "-_."); cut >= 0 {
+		name = name[:cut]
+	}
+	if name == "" {
+		return config.LANGUAGE_ENGLISH, nil
+	}
+	if _, ok := printers[name]; !ok {
+		return "", fmt.Errorf("unknown language %q, expected %s", language, strings.Join(config.Languages(), ", "))
+	}
+	return name, nil
+}
+
+// NewPrinter returns the printer of a language accepted by ParseLanguage
+func NewPrinter(language string) (*Printer, error) {
+	language, err := ParseLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	return printers[language], nil
+}
+
+// Language returns the language the printer prints in
+func (p *Printer) Language() string {
+	return p.language
+}
+
+// Sprintf formats the translation of format. The markup that leads the message, such as a
+// markdown heading, a list bullet or an emoji, and the line breaks that end it are not part of
+// the catalog key and are kept as they are.
+func (p *Printer) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(p.translate(format), args...)
+}
+
+// Fprintf writes the translation of format to w, as Sprintf formats it
+func (p *Printer) Fprintf(w io.Writer, format string, args ...any) {
+	io.WriteString(w, p.Sprintf(format, args...))
+}
+
+// Printf writes the translation of format to the standard output, as Sprintf formats it
+func (p *Printer) Printf(format string, args ...any) {
+	p.Fprintf(os.Stdout, format, args...)
+}
+
+// Description returns the description of dep in the language of the printer: the translation of
+// a built-in rule, or of the description generated for a rule without one; other descriptions,
+// such as the messages of the scanned @Deprecated annotations, are kept in English.
+func (p *Printer) Description(dep models.Deprecation) string {
+	if dep.Source == config.DEPRECATION_SOURCE_BUILTIN {
+		if description, ok := p.catalog.Rules[dep.API]; ok {
+			return description
+		}
+	}
+	switch dep.Description {
+	case dep.API + " is deprecated, use " + dep.Replacement + " instead":
+		return p.Sprintf("%s is deprecated, use %s instead", dep.API, dep.Replacement)
+	case dep.API + " is deprecated":
+		return p.Sprintf("%s is deprecated", dep.API)
+	}
+	return dep.Description
+}
+
+// Age describes how many stable releases ago dep was deprecated, as services.DeprecationAge does,
+// in the language of the printer
+func (p *Printer) Age(dep models.Deprecation) string {
+	ago, ok := services.ReleasesAgo(dep)
+	switch {
+	case !ok:
+		return ""
+	case ago == 0:
+		return p.Sprintf("deprecated in the latest stable release")
+	case ago == 1:
+		return p.Sprintf("deprecated 1 release ago")
+	case ago < config.REMOVAL_LIKELY_RELEASES:
+		return p.Sprintf("deprecated %d releases ago", ago)
+	default:
+		return p.Sprintf("deprecated %d releases ago, removal likely", ago)
+	}
+}
+
+// Change translates the change a scan of changed lines checked, such as the staged changes or
+// the changes in a git range
+func (p *Printer) Change(change string) string {
+	switch change {
+	case "staged changes":
+		return p.Sprintf("staged changes")
+	case "changes in the diff":
+		return p.Sprintf("changes in the diff")
+	}
+	if gitRange, ok := strings.CutPrefix(change, "changes in "); ok {
+		return p.Sprintf("changes in %s", gitRange)
+	}
+	return change
+}
+
+// translate looks up the translation of a message, keeping its leading markup and line breaks
+func (p *Printer) translate(format string) string {
+	if len(p.catalog.Messages) == 0 {
+		return format
+	}
+	prefix, key, suffix := MessageKey(format)
+	if translated, ok := p.catalog.Messages[key]; ok {
+		return prefix + translated + suffix
+	}
+	return format
+}
+
+// MessageKey splits a message into the leading markup, the catalog key and the trailing
+// whitespace. The markup is everything before the first letter, digit or format verb.
+func MessageKey(format string) (prefix string, key string, suffix string) {
+	start := strings.IndexFunc(format, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '%' || r == '(' || r == '"'
+	})
+	if start < 0 {
+		return format, "", ""
+	}
+	end := len(strings.TrimRightFunc(format, unicode.IsSpace))
+	return format[:start], format[start:end], format[end:]
+}
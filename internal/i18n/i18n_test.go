@@ -0,0 +1,217 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestParseLanguage(t *testing.T) {
+	for _, test := range []struct {
+		language string
+		expected string
+	}{
+		{"", config.LANGUAGE_ENGLISH},
+		{"en", config.LANGUAGE_ENGLISH},
+		{"de", config.LANGUAGE_GERMAN},
+		{" ES ", config.LANGUAGE_SPANISH},
+		{"de_DE.UTF-8", config.LANGUAGE_GERMAN},
+		{"es-MX", config.LANGUAGE_SPANISH},
+	} {
+		got, err := ParseLanguage(test.language)
+		if err != nil || got != test.expected {
+			t.Errorf("ParseLanguage(%q): expected %q, got %q (%v)", test.language, test.expected, got, err)
+		}
+	}
+	if _, err := ParseLanguage("fr"); err == nil || !strings.Contains(err.Error(), "en, de, es") {
+		t.Errorf("Expected an error listing the languages, got %v", err)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	p, err := NewPrinter(config.LANGUAGE_GERMAN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := p.Sprintf("## Suppressed\n\n"), "## Unterdrückt\n\n"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got, expected := p.Sprintf("🙈 %d suppressed deprecation(s) hidden\n", 2), "🙈 2 unterdrückte veraltete API(s) ausgeblendet\n"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got, expected := p.Sprintf("Not in the catalog %d\n", 1), "Not in the catalog 1\n"; got != expected {
+		t.Errorf("Expected a message without a translation in English, got %q", got)
+	}
+	if got, expected := English.Sprintf("## Suppressed\n\n"), "## Suppressed\n\n"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestDescription(t *testing.T) {
+	p, err := NewPrinter(config.LANGUAGE_SPANISH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		dep      models.Deprecation
+		expected string
+	}{
+		{models.Deprecation{API: "RaisedButton", Source: config.DEPRECATION_SOURCE_BUILTIN, Description: "RaisedButton is deprecated, use ElevatedButton instead"}, "RaisedButton está obsoleto, usa ElevatedButton en su lugar"},
+		{models.Deprecation{API: "Foo", Replacement: "Bar", Description: "Foo is deprecated, use Bar instead"}, "Foo está obsoleto, usa Bar en su lugar"},
+		{models.Deprecation{API: "Foo", Description: "Foo is deprecated"}, "Foo está obsoleto"},
+		{models.Deprecation{API: "Foo", Source: config.DEPRECATION_SOURCE_FLUTTER, Description: "Use Bar instead. This feature was deprecated after v3.0."}, "Use Bar instead. This feature was deprecated after v3.0."},
+	} {
+		if got := p.Description(test.dep); got != test.expected {
+			t.Errorf("Description(%s): expected %q, got %q", test.dep.API, test.expected, got)
+		}
+	}
+}
+
+// TestBuiltinRuleDescriptions checks that every built-in rule is described in every language
+func TestBuiltinRuleDescriptions(t *testing.T) {
+	provider := services.NewBuiltinRuleProvider()
+	for _, language := range config.Languages() {
+		p, err := NewPrinter(language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range []string{config.TARGET_FLUTTER, config.TARGET_DART} {
+			_, apis := provider.Rules(target)
+			for _, dep := range apis {
+				description := p.Description(dep)
+				if language != config.LANGUAGE_ENGLISH && description == dep.Description {
+					t.Errorf("%s: no description of %s", language, dep.API)
+				}
+			}
+		}
+	}
+}
+
+// formatVerbs matches the verbs of a format string, which a translation has to keep in order
+var formatVerbs = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogs(t *testing.T) {
+	german, spanish := printers[config.LANGUAGE_GERMAN].catalog, printers[config.LANGUAGE_SPANISH].catalog
+	if !reflect.DeepEqual(sortedKeys(german.Messages), sortedKeys(spanish.Messages)) {
+		t.Errorf("Expected the same messages in both catalogs")
+	}
+	if !reflect.DeepEqual(sortedKeys(german.Rules), sortedKeys(spanish.Rules)) {
+		t.Errorf("Expected the same rules in both catalogs")
+	}
+	for _, language := range []string{config.LANGUAGE_GERMAN, config.LANGUAGE_SPANISH} {
+		for key, translated := range printers[language].catalog.Messages {
+			expected, got := formatVerbs.FindAllString(key, -1), formatVerbs.FindAllString(translated, -1)
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("%s: %q translates the verbs %v of %q to %v", language, translated, expected, key, got)
+			}
+			if prefix, _, suffix := MessageKey(key); prefix != "" || suffix != "" {
+				t.Errorf("%s: the key %q starts with markup or ends with white space", language, key)
+			}
+		}
+	}
+}
+
+// TestTranslatedMessages checks that the catalogs translate every message the reports and the
+// command line print through a printer
+func TestTranslatedMessages(t *testing.T) {
+	messages := make(map[string]bool)
+	for _, dir := range []string{".", "../handlers", "../../cmd/server"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ast.Inspect(parsed, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				format, ok := printedFormat(call)
+				if !ok {
+					return true
+				}
+				// Formats of verbs and symbols only, such as a finding's line, have nothing to translate
+				if _, key, _ := MessageKey(format); strings.IndexFunc(formatVerbs.ReplaceAllString(key, ""), unicode.IsLetter) >= 0 {
+					messages[key] = true
+				}
+				return true
+			})
+		}
+	}
+	if len(messages) == 0 {
+		t.Fatal("Expected to find the messages of the reports")
+	}
+	for _, language := range []string{config.LANGUAGE_GERMAN, config.LANGUAGE_SPANISH} {
+		for _, key := range sortedKeys(messages) {
+			if _, ok := printers[language].catalog.Messages[key]; !ok {
+				t.Errorf("%s: no translation of %q", language, key)
+			}
+		}
+	}
+}
+
+// printedFormat returns the format of a call of a printer's Sprintf, Fprintf or Printf, which is
+// named p or printer
+func printedFormat(call *ast.CallExpr) (string, bool) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	index := 0
+	switch selector.Sel.Name {
+	case "Sprintf", "Printf":
+	case "Fprintf":
+		index = 1
+	default:
+		return "", false
+	}
+	switch receiver := selector.X.(type) {
+	case *ast.Ident:
+		if receiver.Name != "p" && receiver.Name != "printer" {
+			return "", false
+		}
+	case *ast.SelectorExpr:
+		if receiver.Sel.Name != "p" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	if len(call.Args) <= index {
+		return "", false
+	}
+	literal, ok := call.Args[index].(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	format, err := strconv.Unquote(literal.Value)
+	return format, err == nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
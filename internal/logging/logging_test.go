@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	t.Run("JSON format writes one object per record", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := New(&buf, FormatJSON, slog.LevelInfo)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		logger.Debug("hidden")
+		logger.Warn("Failed to scan directory", "directory", "material/")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("Expected 1 record above the info level, got %d", len(lines))
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if record["level"] != "WARN" || record["directory"] != "material/" {
+			t.Errorf("Unexpected record: %v", record)
+		}
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		if _, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo); err == nil {
+			t.Error("Expected error for unknown format")
+		}
+	})
+
+	t.Run("ParseLevel", func(t *testing.T) {
+		level, err := ParseLevel("debug")
+		if err != nil || level != slog.LevelDebug {
+			t.Errorf("Expected debug level, got %v (%v)", level, err)
+		}
+		if _, err := ParseLevel("loud"); err == nil {
+			t.Error("Expected error for unknown level")
+		}
+	})
+}
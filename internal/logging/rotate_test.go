@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("Rotates when the size limit is reached", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "server.log")
+		writer, err := NewRotatingFile(path, 20, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer writer.Close()
+
+		for _, line := range []string{"first line 0001\n", "second line 002\n", "third line 0003\n", "fourth line 004\n"} {
+			if _, err := writer.Write([]byte(line)); err != nil {
+				t.Fatalf("Expected no error writing, got %v", err)
+			}
+		}
+
+		current, _ := os.ReadFile(path)
+		newest, _ := os.ReadFile(path + ".1")
+		oldest, _ := os.ReadFile(path + ".2")
+		if string(current) != "fourth line 004\n" {
+			t.Errorf("Unexpected current log contents: %q", current)
+		}
+		if string(newest) != "third line 0003\n" || string(oldest) != "second line 002\n" {
+			t.Errorf("Unexpected backups: %q, %q", newest, oldest)
+		}
+		if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+			t.Error("Expected only 2 backups to be kept")
+		}
+	})
+
+	t.Run("Appends to an existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server.log")
+		os.WriteFile(path, []byte("existing\n"), 0644)
+
+		writer, err := NewRotatingFile(path, 1024, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		writer.Write([]byte("appended\n"))
+		writer.Close()
+
+		contents, _ := os.ReadFile(path)
+		if !strings.HasPrefix(string(contents), "existing\n") {
+			t.Errorf("Expected existing contents to be preserved, got %q", contents)
+		}
+	})
+
+	t.Run("Keeps logging when a rotation fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server.log")
+		writer, err := NewRotatingFile(path, 20, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer writer.Close()
+
+		// A non-empty directory in the way of the backup makes the rename fail
+		os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755)
+
+		writer.Write([]byte("first line 0001\n"))
+		if _, err := writer.Write([]byte("second line 002\n")); err == nil {
+			t.Error("Expected the failed rotation to be reported")
+		}
+		if _, err := writer.Write([]byte("third line 0003\n")); err == nil {
+			t.Error("Expected the rotation to be retried and fail again")
+		}
+
+		contents, _ := os.ReadFile(path)
+		if string(contents) != "first line 0001\nsecond line 002\nthird line 0003\n" {
+			t.Errorf("Expected every line in the log file, got %q", contents)
+		}
+
+		os.RemoveAll(path + ".1")
+		if _, err := writer.Write([]byte("fourth line 004\n")); err != nil {
+			t.Fatalf("Expected the rotation to succeed once the backup is free, got %v", err)
+		}
+		current, _ := os.ReadFile(path)
+		if string(current) != "fourth line 004\n" {
+			t.Errorf("Unexpected current log contents: %q", current)
+		}
+	})
+
+	t.Run("Rejects a non-positive size", func(t *testing.T) {
+		if _, err := NewRotatingFile(filepath.Join(t.TempDir(), "x.log"), 0, 1); err == nil {
+			t.Error("Expected error for zero size limit")
+		}
+	})
+}
@@ -1,64 +1,46 @@
 package models
 
-import "time"
-
-// FlutterRelease represents a Flutter release from GitHub API
-type FlutterRelease struct {
-	Name        string `json:"name"`
-	TagName     string `json:"tag_name"`
-	PublishedAt string `json:"published_at"`
-	Body        string `json:"body"`
-	Prerelease  bool   `json:"prerelease"`
-}
-
-// FlutterOfficialRelease represents a release from the official Flutter releases API
-type FlutterOfficialRelease struct {
-	Hash           string `json:"hash"`
-	Channel        string `json:"channel"`
-	Version        string `json:"version"`
-	DartSDKVersion string `json:"dart_sdk_version"`
-	DartSDKArch    string `json:"dart_sdk_arch"`
-	ReleaseDate    string `json:"release_date"`
-	Archive        string `json:"archive"`
-	SHA256         string `json:"sha256"`
-}
-
-// FlutterReleasesResponse represents the complete response from the official Flutter releases API
-type FlutterReleasesResponse struct {
-	BaseURL        string `json:"base_url"`
-	CurrentRelease struct {
-		Beta   string `json:"beta"`
-		Dev    string `json:"dev"`
-		Stable string `json:"stable"`
-	} `json:"current_release"`
-	Releases []FlutterOfficialRelease `json:"releases"`
-}
-
-// Deprecation represents a deprecated Flutter API
-type Deprecation struct {
-	API         string `json:"api"`
-	Replacement string `json:"replacement"`
-	Version     string `json:"version"`
-	Description string `json:"description"`
-	Example     string `json:"example,omitempty"`
-}
+import (
+	"time"
 
-// DeprecationCache represents the local cache structure
-type DeprecationCache struct {
-	LastUpdated  time.Time     `json:"last_updated"`
-	Deprecations []Deprecation `json:"deprecations"`
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/flutter"
+)
+
+// The deprecation and release data of the public API, defined in pkg/flutter
+type (
+	Deprecation        = flutter.Deprecation
+	DeprecationCache   = flutter.DeprecationCache
+	CacheChanges       = flutter.CacheChanges
+	DeprecationChange  = flutter.DeprecationChange
+	DeprecationMatch   = flutter.DeprecationMatch
+	VersionCheckResult = flutter.VersionCheckResult
+	APIRequirement     = flutter.APIRequirement
+	MigrationResult    = flutter.MigrationResult
+	MigrationChange    = flutter.MigrationChange
+	PendingMigration   = flutter.PendingMigration
+	FixPreview         = flutter.FixPreview
+	MigrationTemplate  = flutter.MigrationTemplate
+	ParameterMapping   = flutter.ParameterMapping
+
+	FlutterRelease          = flutter.GitHubRelease
+	FlutterOfficialRelease  = flutter.Release
+	FlutterReleasesResponse = flutter.Releases
+	FlutterVersionInfo      = flutter.VersionInfo
+	AdvisoryCheck           = flutter.AdvisoryCheck
+	SecurityAdvisory        = flutter.SecurityAdvisory
+)
+
+// ResultLimits are the arguments shared by the tools that list findings, which keep responses
+// within the caller's context budget
+type ResultLimits struct {
+	MaxResults  int  `json:"max_results,omitempty" jsonschema:"description=Maximum number of findings to list across all sections (default 100); the rest are counted"`
+	SummaryOnly bool `json:"summary_only,omitempty" jsonschema:"description=Only report the counts of each section without listing the findings"`
 }
 
-// FlutterVersionInfo contains version and availability information
-type FlutterVersionInfo struct {
-	LatestVersion    string `json:"latest_version"`
-	FVMInstalled     bool   `json:"fvm_installed"`
-	FVMVersionExists bool   `json:"fvm_version_exists"`
-	DockerImages     struct {
-		Instrumentisto bool `json:"instrumentisto"`
-		CirrusLabs     bool `json:"cirruslabs"`
-	} `json:"docker_images"`
-	Details string `json:"details"`
+// ReportLanguage is the argument of the tools whose report can be written in another language
+// than English. The structured result is the same in every language.
+type ReportLanguage struct {
+	Language string `json:"language,omitempty" jsonschema:"description=Language of the report text: en or de or es (default: the --language of the server)"`
 }
 
 // CheckCodeArgs represents the input for code checking
@@ -66,5 +48,1135 @@ type CheckCodeArgs struct {
 	Code string `json:"code"`
 }
 
+// CheckDeprecationsArgs represents the input for the check_flutter_deprecations tool
+type CheckDeprecationsArgs struct {
+	Code              string `json:"code"`
+	Encoding          string `json:"encoding,omitempty" jsonschema:"description=How code is encoded: gzip+base64 or base64 for large files (default: plain text)"`
+	ProjectPath       string `json:"project_path,omitempty" jsonschema:"description=Flutter project whose suppressions apply in addition to the machine-wide ones"`
+	IncludeSuppressed bool   `json:"include_suppressed,omitempty" jsonschema:"description=Also report deprecations that were suppressed with suppress_deprecation"`
+	Target            string `json:"target,omitempty" jsonschema:"description=Kind of code: flutter (default) or dart for pure Dart packages such as servers and CLIs; dart only applies the Dart SDK and syntax rules"`
+	ResultLimits
+	ReportLanguage
+}
+
+// CheckFileEntry is one file or snippet of a check_flutter_files call
+type CheckFileEntry struct {
+	Name     string `json:"name,omitempty" jsonschema:"description=Label of the entry in the results (default: the path)"`
+	Path     string `json:"path,omitempty" jsonschema:"description=Dart file to read; relative paths start at project_path"`
+	Code     string `json:"code,omitempty" jsonschema:"description=Code to check instead of reading a file"`
+	Encoding string `json:"encoding,omitempty" jsonschema:"description=How code is encoded: gzip+base64 or base64 (default: plain text)"`
+}
+
+// CheckFilesArgs represents the input for the check_flutter_files tool
+type CheckFilesArgs struct {
+	Files             []CheckFileEntry `json:"files" jsonschema:"required,description=Files or snippets to check; each needs a path or code"`
+	ProjectPath       string           `json:"project_path,omitempty" jsonschema:"description=Flutter project whose suppressions apply and that relative paths start at"`
+	IncludeSuppressed bool             `json:"include_suppressed,omitempty" jsonschema:"description=Also report deprecations that were suppressed with suppress_deprecation"`
+	Target            string           `json:"target,omitempty" jsonschema:"description=Kind of code: flutter (default) or dart for pure Dart packages such as servers and CLIs; dart only applies the Dart SDK and syntax rules"`
+	ResultLimits
+	ReportLanguage
+}
+
+// FileCheckResult holds the deprecations found in one entry of a check_flutter_files call, or
+// why it could not be checked
+type FileCheckResult struct {
+	Name         string        `json:"name"`
+	Path         string        `json:"path,omitempty"`
+	Deprecations []Deprecation `json:"deprecations"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// PathFilter scopes a project scan to the files matching one of Include, when given, and none of
+// Exclude. The globs are relative to the project root and ** matches any number of directories.
+type PathFilter struct {
+	Include []string `json:"include,omitempty" jsonschema:"description=Glob patterns of the files to scan relative to project_path such as lib/** (default: every Dart file)"`
+	Exclude []string `json:"exclude,omitempty" jsonschema:"description=Glob patterns of the files to leave out relative to project_path such as lib/generated/**"`
+}
+
+// CheckProjectArgs represents the input for the check_flutter_project tool
+type CheckProjectArgs struct {
+	ProjectPath       string `json:"project_path,omitempty" jsonschema:"description=Flutter project or melos or pub workspace root to scan (default: the first Flutter project under the roots of the client)"`
+	IncludeSuppressed bool   `json:"include_suppressed,omitempty" jsonschema:"description=Also report deprecations that were suppressed with suppress_deprecation"`
+	Target            string `json:"target,omitempty" jsonschema:"description=Kind of code: flutter (default) or dart for pure Dart packages such as servers and CLIs; dart only applies the Dart SDK and syntax rules"`
+	Incremental       bool   `json:"incremental,omitempty" jsonschema:"description=Only check the files git reports as changed or untracked since the last full scan and reuse its results for the rest"`
+	Format            string `json:"format,omitempty" jsonschema:"description=Output format: text (default) or json for a machine-readable report or lsp for a JSON list of LSP publishDiagnostics params per file"`
+	Baseline          string `json:"baseline,omitempty" jsonschema:"description=Findings baseline written by --scan with --write-baseline relative to project_path; the findings it lists are left out"`
+	GroupBy           string `json:"group_by,omitempty" jsonschema:"description=How the report groups the findings: package (default) or file or rule to rank the deprecated APIs by use or severity"`
+	AuditDependencies bool   `json:"audit_dependencies,omitempty" jsonschema:"description=Also look up the pub.dev security advisories of every package the pubspec.lock files lock"`
+	PathFilter
+	ResultLimits
+	ReportLanguage
+}
+
+// DependencyAdvisory is a pub.dev security advisory that affects the version of a package a
+// project's pubspec.lock locks. Fixed is the first version with the fix, when the advisory names
+// one, and Direct tells whether the project depends on the package itself.
+type DependencyAdvisory struct {
+	Package  string   `json:"package"`
+	Version  string   `json:"version"`
+	Direct   bool     `json:"direct"`
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Summary  string   `json:"summary"`
+	Severity string   `json:"severity,omitempty"`
+	URL      string   `json:"url"`
+	Fixed    string   `json:"fixed,omitempty"`
+}
+
+// DependencyAudit lists the advisories affecting the hosted packages of a project's lock files.
+// Packages counts the packages looked up and Errors those that could not be.
+type DependencyAudit struct {
+	LockFiles  []string             `json:"lock_files"`
+	Packages   int                  `json:"packages"`
+	Advisories []DependencyAdvisory `json:"advisories"`
+	Errors     []string             `json:"errors,omitempty"`
+}
+
+// PackageScanResult holds the Dart files scanned in one package of a project. Path is relative to
+// the project root, "." for the root package, and Findings only lists the files with deprecations
+// or that could not be read.
+type PackageScanResult struct {
+	Name     string            `json:"name"`
+	Path     string            `json:"path"`
+	Files    int               `json:"files"`
+	Findings []FileCheckResult `json:"findings"`
+}
+
+// ProjectScanResult holds the packages of a check_flutter_project scan. Workspace is how the
+// packages were found: melos, pub or packages, and empty for a single package. Cached counts the
+// files that were unchanged since an earlier scan, whose results were reused. An incremental scan
+// sets BaseCommit, the commit of the full scan it built on, and Changed, the files it checked
+// again, or FullScanReason when it had to check every file. Baselined counts the findings a
+// findings baseline left out.
+type ProjectScanResult struct {
+	ProjectPath    string              `json:"project_path"`
+	Workspace      string              `json:"workspace,omitempty"`
+	Packages       []PackageScanResult `json:"packages"`
+	Cached         int                 `json:"cached,omitempty"`
+	BaseCommit     string              `json:"base_commit,omitempty"`
+	Changed        int                 `json:"changed,omitempty"`
+	FullScanReason string              `json:"full_scan_reason,omitempty"`
+	Baselined      int                 `json:"baselined,omitempty"`
+}
+
+// ProjectFinding is a deprecated API a project scan found in a file of one of its packages. File
+// is relative to the project root.
+type ProjectFinding struct {
+	Package     string      `json:"package"`
+	File        string      `json:"file"`
+	Deprecation Deprecation `json:"deprecation"`
+}
+
+// FindingGroup is a section of a grouped project scan report: the findings in one package or
+// file, of one deprecated API or of one severity, as Name says
+type FindingGroup struct {
+	Name     string           `json:"name"`
+	Findings []ProjectFinding `json:"findings"`
+}
+
+// BaselineFinding is a deprecated API a project file used when the findings baseline was written,
+// with the number of uses. Path is relative to the project root.
+type BaselineFinding struct {
+	Path  string `json:"path"`
+	API   string `json:"api"`
+	Count int    `json:"count"`
+}
+
+// FindingsBaseline lists the findings of a project scan that later scans leave out, so that only
+// new uses of deprecated APIs fail them
+type FindingsBaseline struct {
+	Findings []BaselineFinding `json:"findings"`
+}
+
+// ListDeprecationsArgs represents the input for the list_flutter_deprecations tool
+type ListDeprecationsArgs struct {
+	ProjectPath       string `json:"project_path,omitempty" jsonschema:"description=Flutter project whose suppressions apply in addition to the machine-wide ones"`
+	IncludeSuppressed bool   `json:"include_suppressed,omitempty" jsonschema:"description=Also list deprecations that were suppressed with suppress_deprecation"`
+	ResultLimits
+}
+
+// SuppressDeprecationArgs represents the input for acknowledging a deprecation
+type SuppressDeprecationArgs struct {
+	API         string `json:"api" jsonschema:"required,description=Deprecated API name to suppress such as RaisedButton"`
+	Reason      string `json:"reason,omitempty" jsonschema:"description=Why the deprecation is acknowledged or won't be fixed"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"description=Flutter project to store the suppression in; omit to suppress on this machine"`
+	Remove      bool   `json:"remove,omitempty" jsonschema:"description=Remove the suppression instead of adding it"`
+}
+
+// Suppression marks a deprecated API as acknowledged so it is left out of check and list output
+type Suppression struct {
+	API          string    `json:"api"`
+	Reason       string    `json:"reason,omitempty"`
+	SuppressedAt time.Time `json:"suppressed_at"`
+	// Scope is "machine" or "project" depending on the file the suppression was read from
+	Scope string `json:"-"`
+}
+
+// SuppressionList is the on-disk format of a suppression file
+type SuppressionList struct {
+	Suppressions []Suppression `json:"suppressions"`
+}
+
+// TeamDatabase is the document shared through a team database endpoint: the curated manual
+// entries and the machine-wide suppressions every developer's server should agree on
+type TeamDatabase struct {
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Manual       []Deprecation `json:"manual"`
+	Suppressions []Suppression `json:"suppressions"`
+}
+
+// GenerateDockerfileArgs represents the input for generating a Flutter build Dockerfile
+type GenerateDockerfileArgs struct {
+	Version string `json:"version,omitempty" jsonschema:"description=Flutter version to build with such as 3.27.1 (defaults to the latest stable release)"`
+	Target  string `json:"target,omitempty" jsonschema:"description=What to build: web (default) or apk or appbundle or linux"`
+	Image   string `json:"image,omitempty" jsonschema:"description=Preferred base image: instrumentisto or cirruslabs; the other one is used when the preferred image has no tag for the version"`
+}
+
+// DockerfileResult is a generated Dockerfile together with the base image it was written for
+type DockerfileResult struct {
+	Version      string   `json:"version"`
+	Target       string   `json:"target"`
+	Image        string   `json:"image"`
+	Unavailable  []string `json:"unavailable,omitempty"`
+	Dockerfile   string   `json:"dockerfile"`
+	Compose      string   `json:"compose,omitempty"`
+	Dockerignore string   `json:"dockerignore"`
+	Usage        string   `json:"usage"`
+}
+
+// CheckCIWorkflowArgs represents the input for checking the Flutter versions pinned in CI configuration
+type CheckCIWorkflowArgs struct {
+	Path    string `json:"path,omitempty" jsonschema:"description=CI file or project directory; directories are searched for .github/workflows/*.yml and .gitlab-ci.yml"`
+	Content string `json:"content,omitempty" jsonschema:"description=CI file contents to check instead of a path"`
+}
+
+// CIFlutterPin is one place in a CI file that selects a Flutter version
+type CIFlutterPin struct {
+	File       string `json:"file,omitempty"`
+	Job        string `json:"job,omitempty"`
+	Line       int    `json:"line"`
+	Source     string `json:"source"`
+	Version    string `json:"version,omitempty"`
+	Channel    string `json:"channel,omitempty"`
+	Status     string `json:"status"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// CIWorkflowReport lists the Flutter versions pinned in CI files and how they compare to the latest stable release
+type CIWorkflowReport struct {
+	LatestStable string         `json:"latest_stable,omitempty"`
+	LatestError  string         `json:"latest_error,omitempty"`
+	FilesScanned int            `json:"files_scanned"`
+	Pins         []CIFlutterPin `json:"pins"`
+}
+
+// CheckFlutterWebArgs represents the input for checking a project's web setup for deprecated patterns
+type CheckFlutterWebArgs struct {
+	Path    string `json:"path,omitempty" jsonschema:"description=Flutter project directory or a single file such as web/index.html or a build script"`
+	Content string `json:"content,omitempty" jsonschema:"description=File contents to check instead of a path such as an index.html"`
+	Version string `json:"version,omitempty" jsonschema:"description=Flutter version the suggestions are for such as 3.22.0 (defaults to the project's pinned version then the latest stable release)"`
+	ResultLimits
+}
+
+// WebFinding is one deprecated web renderer flag, index.html bootstrap or web library use
+type WebFinding struct {
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line"`
+	API        string `json:"api"`
+	Match      string `json:"match"`
+	Severity   string `json:"severity"`
+	Suggestion string `json:"suggestion"`
+	DocURL     string `json:"doc_url,omitempty"`
+}
+
+// WebCheckReport lists the deprecated web patterns of a project with the approach that replaces
+// them in FlutterVersion, which is empty when no version could be determined
+type WebCheckReport struct {
+	FlutterVersion string       `json:"flutter_version,omitempty"`
+	VersionSource  string       `json:"version_source,omitempty"`
+	VersionError   string       `json:"version_error,omitempty"`
+	FilesScanned   int          `json:"files_scanned"`
+	Findings       []WebFinding `json:"findings"`
+}
+
+// CheckDesktopRunnersArgs represents the input for comparing desktop runners with the flutter create templates
+type CheckDesktopRunnersArgs struct {
+	ProjectPath string   `json:"project_path,omitempty" jsonschema:"description=Flutter project directory with windows or linux or macos runner folders (default: the first Flutter project under the roots of the client)"`
+	Version     string   `json:"version,omitempty" jsonschema:"description=Flutter version whose templates to compare with such as 3.27.1 or 3.27 (defaults to the project's pinned version then the active SDK)"`
+	Platforms   []string `json:"platforms,omitempty" jsonschema:"description=Runner folders to check: windows or linux or macos (defaults to every folder present)"`
+	ResultLimits
+}
+
+// RunnerFileDrift compares one runner file with the template flutter create generates it from
+type RunnerFileDrift struct {
+	Platform string   `json:"platform"`
+	File     string   `json:"file"`
+	Status   string   `json:"status"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// RunnerIssue is runner code from an older template that a newer Flutter release generates differently
+type RunnerIssue struct {
+	Platform string `json:"platform"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	API      string `json:"api"`
+	Version  string `json:"version"`
+	Message  string `json:"message"`
+}
+
+// RunnerDriftReport lists how the desktop runners of a project differ from the templates of
+// FlutterVersion; Files is empty when no local SDK of that version provides the templates
+type RunnerDriftReport struct {
+	ProjectPath    string            `json:"project_path"`
+	FlutterVersion string            `json:"flutter_version,omitempty"`
+	VersionSource  string            `json:"version_source,omitempty"`
+	SDKPath        string            `json:"sdk_path,omitempty"`
+	Platforms      []string          `json:"platforms"`
+	Files          []RunnerFileDrift `json:"files"`
+	Issues         []RunnerIssue     `json:"issues"`
+	Warnings       []string          `json:"warnings,omitempty"`
+}
+
+// DeprecationsResponse is the body of the REST API's GET /deprecations
+type DeprecationsResponse struct {
+	LastUpdated  time.Time     `json:"last_updated"`
+	Deprecations []Deprecation `json:"deprecations"`
+	Manual       []Deprecation `json:"manual"`
+	Suppressed   []Deprecation `json:"suppressed,omitempty"`
+}
+
+// CheckResponse is the body of the REST API's POST /check
+type CheckResponse struct {
+	Deprecations    []Deprecation `json:"deprecations"`
+	Suppressed      []Deprecation `json:"suppressed,omitempty"`
+	SuppressedCount int           `json:"suppressed_count"`
+}
+
+// ErrorResponse is the body of a failed REST API request, and the JSON document a --scan with
+// --format json prints when it fails
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ListFlutterSDKsArgs represents the input for listing the Flutter SDKs installed on the machine
+type ListFlutterSDKsArgs struct {
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"description=Flutter project whose pinned version (.fvmrc or .puro.json or pubspec.yaml) is compared to the active flutter"`
+}
+
+// LocalFlutterSDK is a Flutter SDK found on the machine
+type LocalFlutterSDK struct {
+	Path    string   `json:"path"`
+	Version string   `json:"version,omitempty"`
+	Channel string   `json:"channel,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Sources []string `json:"sources"`
+	Active  bool     `json:"active"`
+}
+
+// LocalSDKReport lists the Flutter SDKs on the machine and how they relate to a project's pin
+type LocalSDKReport struct {
+	SDKs          []LocalFlutterSDK `json:"sdks"`
+	ProjectPath   string            `json:"project_path,omitempty"`
+	PinnedVersion string            `json:"pinned_version,omitempty"`
+	PinnedBy      string            `json:"pinned_by,omitempty"`
+	Warnings      []string          `json:"warnings,omitempty"`
+}
+
+// SDKDeprecations are the @Deprecated annotations in the framework source of one local SDK,
+// scanned once per Flutter version
+type SDKDeprecations struct {
+	Version      string        `json:"version"`
+	Channel      string        `json:"channel,omitempty"`
+	SDKPath      string        `json:"sdk_path"`
+	ScannedAt    time.Time     `json:"scanned_at"`
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// CompareVersionsArgs represents the input for comparing the deprecations of two local SDKs
+type CompareVersionsArgs struct {
+	From string `json:"from" jsonschema:"required,description=Installed Flutter version to compare from such as 3.24.5 or 3.24"`
+	To   string `json:"to" jsonschema:"required,description=Installed Flutter version to compare to such as 3.27.1 or 3.27"`
+	ResultLimits
+}
+
+// VersionComparison is the difference between the deprecations of two local SDKs. Deprecated
+// lists the APIs annotated in To but not in From and Removed those whose annotation is gone in
+// To, which usually means the API was removed.
+type VersionComparison struct {
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Deprecated []Deprecation `json:"deprecated"`
+	Removed    []Deprecation `json:"removed"`
+	Unchanged  int           `json:"unchanged"`
+}
+
+// IntroducedInArgs represents the input for listing the deprecations a Flutter release introduced
+type IntroducedInArgs struct {
+	Version string `json:"version" jsonschema:"required,description=Installed Flutter release such as 3.27.0 or 3.27 for the newest installed 3.27 release"`
+	ResultLimits
+}
+
+// IntroducedDeprecations are the deprecations first annotated in one local SDK: absent from
+// every installed SDK older than it, of which Previous is the newest
+type IntroducedDeprecations struct {
+	Version      string        `json:"version"`
+	Previous     string        `json:"previous"`
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// ScanRepoArgs represents the input for scanning a GitHub repository of a Dart package
+type ScanRepoArgs struct {
+	Repo    string `json:"repo" jsonschema:"required,description=GitHub repository as owner/name or its URL"`
+	Ref     string `json:"ref,omitempty" jsonschema:"description=Branch or tag or commit to scan (default: the default branch)"`
+	Path    string `json:"path,omitempty" jsonschema:"description=Directory of the package within the repository (default: the repository root)"`
+	Package string `json:"package,omitempty" jsonschema:"description=Package name used to match imports (default: the name in pubspec.yaml)"`
+	Save    bool   `json:"save,omitempty" jsonschema:"description=Store the entries next to the manual ones so the checks report them"`
+}
+
+// RepoScanResult lists the deprecations found in the lib directory of a Dart package on GitHub.
+// Truncated is set when the file limit stopped the scan early.
+type RepoScanResult struct {
+	Repository   string        `json:"repository"`
+	Path         string        `json:"path,omitempty"`
+	Package      string        `json:"package"`
+	Files        int           `json:"files"`
+	Truncated    bool          `json:"truncated,omitempty"`
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// LineFinding is a use of a deprecated API at a line and UTF-16 column of a project file, whose
+// path is relative to the project root
+type LineFinding struct {
+	Path        string      `json:"path"`
+	Line        int         `json:"line"`
+	Column      int         `json:"column"`
+	Deprecation Deprecation `json:"deprecation"`
+}
+
+// ScanReport is the machine-readable report of a project scan or changed line check: the findings
+// that were not suppressed or left out by a baseline, how many of them fail the scan at the FailOn
+// severity, and the files that could not be read. Files counts the Dart files checked and Change
+// names the change a changed line check covered.
+type ScanReport struct {
+	ProjectPath string           `json:"project_path"`
+	Change      string           `json:"change,omitempty"`
+	Files       int              `json:"files"`
+	FailOn      string           `json:"fail_on"`
+	Findings    []ReportFinding  `json:"findings"`
+	Failing     int              `json:"failing"`
+	Suppressed  int              `json:"suppressed"`
+	Baselined   int              `json:"baselined"`
+	Unreadable  []UnreadableFile `json:"unreadable,omitempty"`
+	Audit       *DependencyAudit `json:"dependency_audit,omitempty"`
+}
+
+// ReportFinding is a deprecated API used in a file of a ScanReport, whose path is relative to the
+// project root. Project scans set Package and changed line checks the Line and UTF-16 Column.
+type ReportFinding struct {
+	Package     string      `json:"package,omitempty"`
+	Path        string      `json:"path"`
+	Line        int         `json:"line,omitempty"`
+	Column      int         `json:"column,omitempty"`
+	Deprecation Deprecation `json:"deprecation"`
+	Age         string      `json:"age,omitempty"`
+	Fails       bool        `json:"fails"`
+}
+
+// UnreadableFile is a file a scan could not check, and why
+type UnreadableFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// DiffScanResult lists the deprecated APIs used on the lines a change, such as the staged one,
+// adds to a project. Files counts the changed Dart files that were checked.
+type DiffScanResult struct {
+	ProjectPath string        `json:"project_path"`
+	Change      string        `json:"change"`
+	Files       int           `json:"files"`
+	Findings    []LineFinding `json:"findings"`
+}
+
+// CheckChangedLinesArgs represents the input for checking only the lines a change adds to a
+// project. Exactly one of Diff, Range and Staged selects the change.
+type CheckChangedLinesArgs struct {
+	ProjectPath       string `json:"project_path,omitempty" jsonschema:"description=Flutter project or workspace root the change applies to (default: the first Flutter project under the roots of the client)"`
+	Diff              string `json:"diff,omitempty" jsonschema:"description=Unified diff to check such as the output of git diff or diff -u with paths relative to project_path; the files are read from project_path"`
+	Range             string `json:"range,omitempty" jsonschema:"description=Git range to check as from..to or from...to such as origin/main...HEAD; the files are read at to"`
+	Staged            bool   `json:"staged,omitempty" jsonschema:"description=Check the lines staged for commit"`
+	Target            string `json:"target,omitempty" jsonschema:"description=Kind of code: flutter (default) or dart for pure Dart packages such as servers and CLIs; dart only applies the Dart SDK and syntax rules"`
+	IncludeSuppressed bool   `json:"include_suppressed,omitempty" jsonschema:"description=Also report deprecations that were suppressed with suppress_deprecation"`
+	PathFilter
+	ResultLimits
+	ReportLanguage
+}
+
+// ReviewPullRequestArgs represents the input for reviewing a GitHub pull request
+type ReviewPullRequestArgs struct {
+	Repo              string `json:"repo" jsonschema:"required,description=GitHub repository as owner/name or its URL"`
+	Number            int    `json:"number" jsonschema:"required,description=Number of the pull request"`
+	Target            string `json:"target,omitempty" jsonschema:"description=Kind of code: flutter (default) or dart for pure Dart packages such as servers and CLIs; dart only applies the Dart SDK and syntax rules"`
+	ProjectPath       string `json:"project_path,omitempty" jsonschema:"description=Local checkout of the repository whose suppressions apply (default: only the machine-wide ones)"`
+	IncludeSuppressed bool   `json:"include_suppressed,omitempty" jsonschema:"description=Also comment on uses of suppressed APIs"`
+	Post              bool   `json:"post,omitempty" jsonschema:"description=Publish the comments as a review instead of only listing them"`
+}
+
+// PRReviewComment is a review comment on a line a pull request added, naming the deprecated APIs used there
+type PRReviewComment struct {
+	Path string   `json:"path"`
+	Line int      `json:"line"`
+	APIs []string `json:"apis"`
+	Body string   `json:"body"`
+}
+
+// PRReviewResult lists the comments for the deprecated APIs a pull request adds. Existing counts the
+// uses that earlier reviews already commented on and Omitted the ones beyond MAX_PR_REVIEW_COMMENTS.
+// ReviewURL is set once the review is posted.
+type PRReviewResult struct {
+	Repository string            `json:"repository"`
+	Number     int               `json:"number"`
+	HeadSHA    string            `json:"head_sha"`
+	Files      int               `json:"files"`
+	Comments   []PRReviewComment `json:"comments"`
+	Existing   int               `json:"existing,omitempty"`
+	Omitted    int               `json:"omitted,omitempty"`
+	ReviewURL  string            `json:"review_url,omitempty"`
+}
+
+// AddDeprecationArgs represents the input for adding a manual deprecation entry
+type AddDeprecationArgs struct {
+	API         string `json:"api" jsonschema:"required,description=Deprecated API name such as LegacyCard or Theme.of"`
+	Replacement string `json:"replacement,omitempty" jsonschema:"description=API to use instead"`
+	Description string `json:"description,omitempty" jsonschema:"description=Why the API is deprecated"`
+	Example     string `json:"example,omitempty" jsonschema:"description=Migration example such as LegacyCard() → AppCard()"`
+	Version     string `json:"version,omitempty" jsonschema:"description=Version in which the API was deprecated"`
+	DocURL      string `json:"doc_url,omitempty" jsonschema:"description=Link to the documentation or migration guide"`
+}
+
+// DeprecationDetailsArgs represents the input for looking up a single deprecated API
+type DeprecationDetailsArgs struct {
+	API string `json:"api" jsonschema:"required,description=Exact API name such as ColorScheme.background"`
+}
+
+// SearchDeprecationsArgs represents the input for a free-text deprecation search
+type SearchDeprecationsArgs struct {
+	Query string `json:"query" jsonschema:"required,description=Free-text query such as snackbar or opacity"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Maximum number of results (default 10)"`
+}
+
+// DeprecationStatsArgs represents the input for the cache summary
+type DeprecationStatsArgs struct {
+	Recent int `json:"recent,omitempty" jsonschema:"description=Number of most recently introduced deprecations to list (default 10)"`
+}
+
+// DeprecationStats summarizes the deprecations cache. ByVersion counts entries per Flutter release
+// (major.minor) and uses "unknown" for entries without a version. Upcoming counts the entries only
+// deprecated on the preview channel.
+type DeprecationStats struct {
+	LastUpdated time.Time      `json:"last_updated"`
+	Total       int            `json:"total"`
+	Manual      int            `json:"manual"`
+	Removed     int            `json:"removed"`
+	Upcoming    int            `json:"upcoming"`
+	ByVersion   map[string]int `json:"by_version"`
+	ByCategory  map[string]int `json:"by_category"`
+	BySeverity  map[string]int `json:"by_severity"`
+	BySource    map[string]int `json:"by_source"`
+	Recent      []Deprecation  `json:"recent"`
+}
+
+// DeprecationPartition holds the cached and manual deprecations of one Flutter release (major.minor)
+// and category, with "unknown" for entries that record neither
+type DeprecationPartition struct {
+	URI          string        `json:"uri"`
+	Version      string        `json:"version"`
+	Category     string        `json:"category"`
+	LastUpdated  time.Time     `json:"last_updated"`
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// CheckCodeAgainstVersionArgs represents the input for checking code against a target Flutter version
+type CheckCodeAgainstVersionArgs struct {
+	Code           string `json:"code" jsonschema:"required,description=Flutter code snippet to analyze"`
+	TargetVersion  string `json:"target_version" jsonschema:"required,description=Flutter version the code should run on such as 3.27.0"`
+	CurrentVersion string `json:"current_version,omitempty" jsonschema:"description=Flutter version the code currently targets; separates newly deprecated APIs from older ones"`
+	ResultLimits
+}
+
+// VersionMatrixArgs represents the input for checking code against several target Flutter versions
+type VersionMatrixArgs struct {
+	Code           string   `json:"code,omitempty" jsonschema:"description=Flutter code snippet to analyze"`
+	ProjectPath    string   `json:"project_path,omitempty" jsonschema:"description=Path to a Flutter project or package directory to analyze instead of a snippet"`
+	TargetVersions []string `json:"target_versions" jsonschema:"required,description=Flutter versions the code should support such as the oldest and newest releases a plugin claims"`
+	PathFilter
+}
+
+// InferMinimumVersionArgs represents the input for inferring the minimum supported Flutter version
+type InferMinimumVersionArgs struct {
+	Code        string `json:"code,omitempty" jsonschema:"description=Flutter code snippet to analyze"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"description=Path to a Flutter project directory to analyze instead of a snippet"`
+	PathFilter
+}
+
+// MinimumVersionResult contains the minimum Flutter version inferred from API usage
+type MinimumVersionResult struct {
+	MinimumFlutter  string           `json:"minimum_flutter,omitempty"`
+	MinimumDart     string           `json:"minimum_dart,omitempty"`
+	Requirements    []APIRequirement `json:"requirements"`
+	FilesScanned    int              `json:"files_scanned,omitempty"`
+	DeclaredSDK     string           `json:"declared_sdk,omitempty"`
+	DeclaredFlutter string           `json:"declared_flutter,omitempty"`
+}
+
+// SDKConstraintArgs represents the input for suggesting the SDK constraints of a pubspec.yaml
+type SDKConstraintArgs struct {
+	Pubspec       string `json:"pubspec,omitempty" jsonschema:"description=Contents of the pubspec.yaml to edit"`
+	ProjectPath   string `json:"project_path,omitempty" jsonschema:"description=Path to a project directory whose pubspec.yaml is read instead (default: the first Flutter project under the roots of the client)"`
+	TargetVersion string `json:"target_version,omitempty" jsonschema:"description=Flutter release to target such as 3.27.1 (default: the latest stable release)"`
+}
+
+// SDKConstraintSuggestion is the environment: block of a pubspec.yaml edited for a target Flutter
+// release and the Dart SDK it bundles. SuggestedFlutter is empty for packages that do not depend on
+// Flutter. Block is the edited block and Patch a unified diff of the pubspec.yaml, empty when the
+// constraints already fit. Notes explain constraints that were kept or versions that were assumed.
+type SDKConstraintSuggestion struct {
+	TargetFlutter    string   `json:"target_flutter"`
+	TargetDart       string   `json:"target_dart"`
+	CurrentSDK       string   `json:"current_sdk,omitempty"`
+	CurrentFlutter   string   `json:"current_flutter,omitempty"`
+	SuggestedSDK     string   `json:"suggested_sdk"`
+	SuggestedFlutter string   `json:"suggested_flutter,omitempty"`
+	Block            string   `json:"block"`
+	Patch            string   `json:"patch,omitempty"`
+	Notes            []string `json:"notes,omitempty"`
+}
+
+// VersionMatrixRow is a deprecated API the code uses, or an API it uses that first ships in
+// Version, and its status on each target of a VersionMatrix, in the order of the targets. File and
+// Line locate the first use in a project.
+type VersionMatrixRow struct {
+	API         string   `json:"api"`
+	Replacement string   `json:"replacement,omitempty"`
+	Compatible  string   `json:"compatible,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Introduced  bool     `json:"introduced,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Line        int      `json:"line,omitempty"`
+	Statuses    []string `json:"statuses"`
+}
+
+// VersionMatrix tells on which of several target Flutter versions, oldest first, the APIs a
+// codebase uses are deprecated or not available yet
+type VersionMatrix struct {
+	Targets      []string           `json:"targets"`
+	Rows         []VersionMatrixRow `json:"rows"`
+	FilesScanned int                `json:"files_scanned,omitempty"`
+}
+
+// PinRecommendationArgs represents the input for recommending the Flutter release to pin a project to
+type PinRecommendationArgs struct {
+	Pubspec     string `json:"pubspec,omitempty" jsonschema:"description=Contents of the pubspec.yaml whose dependencies are checked"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"description=Path to a project directory whose pubspec.yaml is read instead (default: the first Flutter project under the roots of the client)"`
+}
+
+// DependencySupport is how far a pub.dev dependency of a pubspec.yaml lets the project upgrade
+// Flutter. Version is the newest release within Constraint that runs on NewestFlutter, the newest
+// stable Flutter release any of them supports, and SDK its Dart SDK constraint. Blocking marks
+// dependencies that hold the project back from the latest stable release; Upgrade is then the
+// oldest release outside Constraint that supports it, if any. Error explains a dependency that
+// could not be checked.
+type DependencySupport struct {
+	Package       string `json:"package"`
+	Constraint    string `json:"constraint"`
+	Dev           bool   `json:"dev,omitempty"`
+	Version       string `json:"version,omitempty"`
+	SDK           string `json:"sdk,omitempty"`
+	NewestFlutter string `json:"newest_flutter,omitempty"`
+	Blocking      bool   `json:"blocking,omitempty"`
+	Upgrade       string `json:"upgrade,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// PinRecommendation is the newest stable Flutter release, and the Dart SDK it bundles, that every
+// checked dependency of a pubspec.yaml supports. Recommended is empty when no stable release
+// satisfies them all. Skipped lists the dependencies that do not come from pub.dev, such as SDK,
+// path and git dependencies, as "name (source)".
+type PinRecommendation struct {
+	CurrentFlutter  string              `json:"current_flutter,omitempty"`
+	LatestStable    string              `json:"latest_stable"`
+	LatestDart      string              `json:"latest_dart"`
+	Recommended     string              `json:"recommended,omitempty"`
+	RecommendedDart string              `json:"recommended_dart,omitempty"`
+	Dependencies    []DependencySupport `json:"dependencies"`
+	Skipped         []string            `json:"skipped,omitempty"`
+	Notes           []string            `json:"notes,omitempty"`
+}
+
+// RuleTestArgs represents the input for testing the deprecation rules against their fixtures and samples
+type RuleTestArgs struct {
+	Samples string `json:"samples,omitempty" jsonschema:"description=Path to a Dart sample file or directory whose lines name the deprecated APIs they should be flagged for in a trailing // expect: API comment"`
+	Code    string `json:"code,omitempty" jsonschema:"description=Dart sample annotated with // expect: comments like the sample files"`
+	Target  string `json:"target,omitempty" jsonschema:"description=Kind of code the rules are tested for: flutter (default) or dart"`
+	Rule    string `json:"rule,omitempty" jsonschema:"description=Only test the rules whose API contains this text"`
+}
+
+// RuleTestFailure is a fixture or sample line a rule gets wrong: a false positive flags code it
+// should not, a false negative misses code it should flag. File and Line locate sample lines.
+type RuleTestFailure struct {
+	API    string `json:"api"`
+	Source string `json:"source,omitempty"`
+	Kind   string `json:"kind"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Code   string `json:"code"`
+}
+
+// RuleTestResult contains the outcome of testing the pattern rules against the fixtures of the
+// rules files and the annotated samples. Untested lists the pattern rules without fixtures.
+type RuleTestResult struct {
+	Target       string            `json:"target"`
+	Rules        int               `json:"rules"`
+	Fixtures     int               `json:"fixtures"`
+	Samples      int               `json:"samples"`
+	Expectations int               `json:"expectations"`
+	Failures     []RuleTestFailure `json:"failures"`
+	Untested     []string          `json:"untested,omitempty"`
+}
+
+// AnalyzerFixesArgs represents the input for the get_analyzer_fixes tool
+type AnalyzerFixesArgs struct {
+	File              string `json:"file" jsonschema:"required,description=Absolute path of the Dart file; it is read when code is empty and names the file in the locations and edits"`
+	Code              string `json:"code,omitempty" jsonschema:"description=Current contents of the file such as an unsaved editor buffer (default: read from file)"`
+	Encoding          string `json:"encoding,omitempty" jsonschema:"description=How code is encoded: gzip+base64 or base64 for large files (default: plain text)"`
+	ProjectPath       string `json:"project_path,omitempty" jsonschema:"description=Flutter project whose suppressions apply in addition to the machine-wide ones"`
+	IncludeSuppressed bool   `json:"include_suppressed,omitempty" jsonschema:"description=Also report deprecations that were suppressed with suppress_deprecation"`
+	Target            string `json:"target,omitempty" jsonschema:"description=Kind of code: flutter (default) or dart for pure Dart packages such as servers and CLIs; dart only applies the Dart SDK and syntax rules"`
+}
+
+// AnalyzerFixesResult is the result of the edit.getFixes request of the analyzer plugin protocol,
+// listing every deprecated use in a file with the quick fixes known for it
+type AnalyzerFixesResult struct {
+	Fixes []AnalysisErrorFixes `json:"fixes"`
+}
+
+// AnalysisErrorFixes pairs an analysis error with the fixes that resolve it. API is the
+// deprecated API the error reports, which is not part of the protocol.
+type AnalysisErrorFixes struct {
+	Error AnalysisError             `json:"error"`
+	Fixes []PrioritizedSourceChange `json:"fixes"`
+	API   string                    `json:"-"`
+}
+
+// AnalysisError is a diagnostic of the analyzer plugin protocol. Severity is INFO, WARNING or
+// ERROR and offsets count UTF-16 code units, as in Dart strings.
+type AnalysisError struct {
+	Severity   string           `json:"severity"`
+	Type       string           `json:"type"`
+	Location   AnalysisLocation `json:"location"`
+	Message    string           `json:"message"`
+	Correction string           `json:"correction,omitempty"`
+	Code       string           `json:"code"`
+	URL        string           `json:"url,omitempty"`
+	HasFix     bool             `json:"hasFix"`
+}
+
+// AnalysisLocation is a range of a file; lines and columns are 1-based
+type AnalysisLocation struct {
+	File        string `json:"file"`
+	Offset      int    `json:"offset"`
+	Length      int    `json:"length"`
+	StartLine   int    `json:"startLine"`
+	StartColumn int    `json:"startColumn"`
+	EndLine     int    `json:"endLine"`
+	EndColumn   int    `json:"endColumn"`
+}
+
+// PrioritizedSourceChange is a fix offered for an analysis error; editors list higher priorities first
+type PrioritizedSourceChange struct {
+	Priority int          `json:"priority"`
+	Change   SourceChange `json:"change"`
+}
+
+// SourceChange is a set of edits that make up a fix, described by Message
+type SourceChange struct {
+	Message string           `json:"message"`
+	Edits   []SourceFileEdit `json:"edits"`
+	ID      string           `json:"id,omitempty"`
+}
+
+// SourceFileEdit holds the edits of one file. FileStamp is the modification time of the file the
+// edits were computed for in milliseconds, or 0 for contents that were passed in.
+type SourceFileEdit struct {
+	File      string       `json:"file"`
+	FileStamp int64        `json:"fileStamp"`
+	Edits     []SourceEdit `json:"edits"`
+}
+
+// SourceEdit replaces Length UTF-16 code units at Offset with Replacement
+type SourceEdit struct {
+	Offset      int    `json:"offset"`
+	Length      int    `json:"length"`
+	Replacement string `json:"replacement"`
+}
+
+// PublishDiagnosticsParams holds the LSP diagnostics of one file, as sent with
+// textDocument/publishDiagnostics
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Diagnostic is an LSP diagnostic. Severity is 1 for errors, 2 for warnings and 3 for
+// information; Code is the deprecated API.
+type Diagnostic struct {
+	Range              Range                          `json:"range"`
+	Severity           int                            `json:"severity"`
+	Code               string                         `json:"code"`
+	CodeDescription    *CodeDescription               `json:"codeDescription,omitempty"`
+	Source             string                         `json:"source"`
+	Message            string                         `json:"message"`
+	Tags               []int                          `json:"tags,omitempty"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// Range is an LSP range; lines and characters are 0-based and characters count UTF-16 code units
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Position is a position in an LSP document
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// CodeDescription links the documentation of a diagnostic's code
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+// DiagnosticRelatedInformation points at a location related to a diagnostic
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// Location is a range of a document, named by its URI
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// DeprecationExplanation combines a deprecation with its migration guide context
+type DeprecationExplanation struct {
+	Deprecation
+	GuideTitle   string             `json:"guide_title,omitempty"`
+	GuideURL     string             `json:"guide_url,omitempty"`
+	GuideExcerpt string             `json:"guide_excerpt,omitempty"`
+	GuideError   string             `json:"guide_error,omitempty"`
+	Before       string             `json:"before,omitempty"`
+	After        string             `json:"after,omitempty"`
+	Template     *MigrationTemplate `json:"template,omitempty"`
+}
+
+// BreakingChangesArgs represents the input for listing breaking changes between two Flutter versions
+type BreakingChangesArgs struct {
+	From string `json:"from" jsonschema:"required,description=Flutter version you are upgrading from such as 3.16"`
+	To   string `json:"to" jsonschema:"required,description=Flutter version you are upgrading to such as 3.27"`
+	ResultLimits
+}
+
+// BreakingChange is a documented breaking change in a Flutter release
+type BreakingChange struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Slug    string `json:"slug,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// BreakingChangesResult lists the breaking changes between two Flutter versions, oldest first
+type BreakingChangesResult struct {
+	From          string           `json:"from"`
+	To            string           `json:"to"`
+	Source        string           `json:"source"`
+	UpstreamError string           `json:"upstream_error,omitempty"`
+	Changes       []BreakingChange `json:"changes"`
+}
+
+// WhatsNewArgs represents the input for summarizing a Flutter release
+type WhatsNewArgs struct {
+	Version string `json:"version,omitempty" jsonschema:"description=Flutter release such as 3.27 (default: the latest stable release)"`
+	ResultLimits
+}
+
+// ListFlutterReleasesArgs represents the input for listing the stable release history
+type ListFlutterReleasesArgs struct {
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Number of most recent stable releases to list (default 20)"`
+	Since string `json:"since,omitempty" jsonschema:"description=Only list releases from this version on such as 3.22"`
+}
+
+// StableRelease is one release of the stable release history. DartSDKVersion and Hash are empty
+// when the history comes from the GitHub releases, which do not record them.
+type StableRelease struct {
+	Version        string    `json:"version"`
+	ReleaseDate    time.Time `json:"release_date"`
+	DartSDKVersion string    `json:"dart_sdk_version,omitempty"`
+	Hash           string    `json:"hash,omitempty"`
+}
+
+// ReleaseHistory lists stable releases newest first. Source is the version source it was read
+// from, Latest the current stable release and Total the number of releases matched before the
+// limit was applied.
+type ReleaseHistory struct {
+	Source   string          `json:"source"`
+	Latest   string          `json:"latest"`
+	Total    int             `json:"total"`
+	Releases []StableRelease `json:"releases"`
+}
+
+// ReplacementAPI is an API that a release recommends in place of the ones it deprecated
+type ReplacementAPI struct {
+	API      string   `json:"api"`
+	Replaces []string `json:"replaces"`
+}
+
+// ReleaseDigest summarizes what a Flutter release deprecated, broke and introduced as replacements.
+// Previous is the stable release it is compared with; UpstreamErrors lists the sources that could
+// not be reached, leaving the digest incomplete.
+type ReleaseDigest struct {
+	Version         string           `json:"version"`
+	Previous        string           `json:"previous"`
+	ReleaseNotesURL string           `json:"release_notes_url"`
+	Deprecations    []Deprecation    `json:"deprecations"`
+	BreakingChanges []BreakingChange `json:"breaking_changes"`
+	Replacements    []ReplacementAPI `json:"replacements"`
+	UpstreamErrors  []string         `json:"upstream_errors,omitempty"`
+}
+
+// UpgradeDigestArgs represents the input for summarizing an upgrade to the latest stable release
+type UpgradeDigestArgs struct {
+	From        string `json:"from,omitempty" jsonschema:"description=Installed Flutter version such as 3.24.5 (default: the version the project pins or else the active SDK)"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"description=Flutter project whose pinned version (.fvmrc or .puro.json or pubspec.yaml) is the installed one"`
+	ResultLimits
+}
+
+// UpgradeRelease is one stable release an upgrade passes through. Notes are its GitHub release
+// notes; ReleaseNotesURL is set on the first release of each new release line.
+type UpgradeRelease struct {
+	Version         string    `json:"version"`
+	ReleaseDate     time.Time `json:"release_date"`
+	DartSDKVersion  string    `json:"dart_sdk_version,omitempty"`
+	Notes           string    `json:"notes,omitempty"`
+	ReleaseNotesURL string    `json:"release_notes_url,omitempty"`
+}
+
+// UpgradeDigest consolidates the stable releases between From, the installed version, and To, the
+// newest of them, oldest first, with the deprecations, breaking changes and replacement APIs of
+// every release line it moves past. FromSource tells where the installed version was found.
+type UpgradeDigest struct {
+	From            string           `json:"from"`
+	FromSource      string           `json:"from_source"`
+	To              string           `json:"to"`
+	Latest          string           `json:"latest,omitempty"`
+	Releases        []UpgradeRelease `json:"releases"`
+	Deprecations    []Deprecation    `json:"deprecations"`
+	BreakingChanges []BreakingChange `json:"breaking_changes"`
+	Replacements    []ReplacementAPI `json:"replacements"`
+	UpstreamErrors  []string         `json:"upstream_errors,omitempty"`
+}
+
+// DraftReplacementsArgs represents the input for drafting replacements of scanned deprecations
+type DraftReplacementsArgs struct {
+	API   string `json:"api,omitempty" jsonschema:"description=Only draft the replacement of this exact API name such as ColorScheme.background"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Maximum number of deprecations to ask the client model about (default 5 and at most 20)"`
+}
+
+// ReplacementDraft is the answer of the client model for one deprecation. Replacement is empty
+// when the model found none, and Error is set when the request failed.
+type ReplacementDraft struct {
+	API         string `json:"api"`
+	Package     string `json:"package,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description"`
+	Replacement string `json:"replacement,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ReplacementDrafts lists the drafts of one draft_replacements call. Pending counts the scanned
+// deprecations still without a replacement, drafted or curated, after the call.
+type ReplacementDrafts struct {
+	Model   string             `json:"model,omitempty"`
+	Drafts  []ReplacementDraft `json:"drafts"`
+	Pending int                `json:"pending"`
+}
+
+// CacheChangesArgs represents the input for the cache_changes tool
+type CacheChangesArgs struct {
+	ResultLimits
+}
+
+// UpdateDeprecationsArgs represents the input for updating the deprecations cache. Without
+// directories or sources the whole dataset is refreshed once the cache has expired; with them only
+// those parts are, straight away.
+type UpdateDeprecationsArgs struct {
+	Directories []string `json:"directories,omitempty" jsonschema:"description=Only rescan these framework libraries or first-party plugins such as material or go_router; the entries of the others are kept"`
+	Sources     []string `json:"sources,omitempty" jsonschema:"description=Only refresh these rule sources such as fix-data or remote; source-scan rescans every library"`
+}
+
+// ClearCacheArgs represents the input for clearing the deprecations cache
+type ClearCacheArgs struct {
+	Confirm bool `json:"confirm" jsonschema:"required,description=Must be true to clear the cache when the client cannot ask the user to confirm; without it the tool only says what would be deleted"`
+}
+
+// CacheStatus describes the deprecations cache file. Refs counts the scanned entries by the
+// repository and branch they were found on, such as flutter/flutter@stable. Expired is set when the
+// cache is missing, has never been updated or is older than TTL, in which case the next update
+// rescans upstream.
+type CacheStatus struct {
+	Path         string         `json:"path"`
+	Exists       bool           `json:"exists"`
+	SizeBytes    int64          `json:"size_bytes"`
+	LastUpdated  time.Time      `json:"last_updated"`
+	Age          time.Duration  `json:"age"`
+	TTL          time.Duration  `json:"ttl"`
+	Expired      bool           `json:"expired"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	Deprecations int            `json:"deprecations"`
+	Manual       int            `json:"manual"`
+	Removed      int            `json:"removed"`
+	BySource     map[string]int `json:"by_source"`
+	Refs         map[string]int `json:"refs"`
+}
+
+// UpstreamCheck is the result of probing one upstream host. Reachable is set when the host
+// answered with any status below 500; Error says why it did not otherwise.
+type UpstreamCheck struct {
+	Name      string        `json:"name"`
+	URL       string        `json:"url"`
+	Reachable bool          `json:"reachable"`
+	Status    int           `json:"status,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+}
+
 // NoArguments represents empty arguments for tools that don't need parameters
-type NoArguments struct{}
\ No newline at end of file
+type NoArguments struct{}
+
+// CallStats aggregates the invocations of one MCP tool or upstream dependency
+type CallStats struct {
+	Calls       int64     `json:"calls"`
+	Errors      int64     `json:"errors"`
+	TotalMillis float64   `json:"total_ms"`
+	MaxMillis   float64   `json:"max_ms"`
+	LastCalled  time.Time `json:"last_called"`
+}
+
+// RateLimitStatus is the GitHub API quota of the server. Source says whether it was read from the
+// rate_limit endpoint or, when that failed, from the headers of the last GitHub API response.
+type RateLimitStatus struct {
+	Authenticated bool      `json:"authenticated"`
+	Limit         int       `json:"limit"`
+	Remaining     int       `json:"remaining"`
+	Used          int       `json:"used"`
+	Reset         time.Time `json:"reset"`
+	Source        string    `json:"source"`
+	ObservedAt    time.Time `json:"observed_at"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// CacheStats counts how the deprecations cache is read and updated, to tune its TTL on real
+// traffic. Every load is either a memory hit, a disk read or missing, when there is no cache file;
+// StaleServes counts the loads whose data was past the TTL or never fully updated, and
+// MaxServedAge is the oldest data a load returned. Updates either rebuild the cache, refresh part
+// of it, or are skipped because it is fresh.
+type CacheStats struct {
+	Loads           int64         `json:"loads"`
+	MemoryHits      int64         `json:"memory_hits"`
+	DiskReads       int64         `json:"disk_reads"`
+	Missing         int64         `json:"missing"`
+	StaleServes     int64         `json:"stale_serves"`
+	MaxServedAge    time.Duration `json:"max_served_age"`
+	Rebuilds        int64         `json:"rebuilds"`
+	ScopedRefreshes int64         `json:"scoped_refreshes"`
+	FreshSkips      int64         `json:"fresh_skips"`
+	LastRebuild     time.Time     `json:"last_rebuild,omitempty"`
+}
+
+// ServerStats contains usage and latency statistics collected by the server
+type ServerStats struct {
+	Since     time.Time             `json:"since"`
+	Tools     map[string]*CallStats `json:"tools"`
+	Upstreams map[string]*CallStats `json:"upstreams"`
+	Cache     CacheStats            `json:"cache"`
+}
+
+// CheckFilesResult is the structured result of check_flutter_files: the entries in the order they
+// were given, each listing the deprecations that are not suppressed, or all of them when the
+// suppressed ones are asked for
+type CheckFilesResult struct {
+	Files []FileCheckResult `json:"files"`
+}
+
+// DeprecationDetails is the structured result of get_deprecation_details: every entry known by
+// the API name
+type DeprecationDetails struct {
+	API          string        `json:"api"`
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// DeprecationSearchResult is the structured result of search_deprecations, best match first
+type DeprecationSearchResult struct {
+	Query   string             `json:"query"`
+	Matches []DeprecationMatch `json:"matches"`
+}
+
+// ManualDeprecationResult is the structured result of add_deprecation. Updated is set when the
+// entry replaced an earlier manual entry for the API.
+type ManualDeprecationResult struct {
+	API     string `json:"api"`
+	Updated bool   `json:"updated"`
+}
+
+// RepoScanOutcome is the structured result of scan_repo_deprecations. Saved counts the entries
+// stored with save: true, and SaveError says why they could not be stored.
+type RepoScanOutcome struct {
+	Scan      RepoScanResult `json:"scan"`
+	Saved     int            `json:"saved"`
+	SaveError string         `json:"save_error,omitempty"`
+}
+
+// SuppressionChange is the structured result of suppress_deprecation. ProjectPath is empty for a
+// machine-wide suppression. Suppressed tells whether the API is suppressed after the call and
+// Changed whether the call changed anything; Known is set when the API names a known deprecation.
+type SuppressionChange struct {
+	API         string `json:"api"`
+	ProjectPath string `json:"project_path,omitempty"`
+	Suppressed  bool   `json:"suppressed"`
+	Changed     bool   `json:"changed"`
+	Known       bool   `json:"known"`
+}
+
+// CacheUpdateResult is the structured result of update_flutter_deprecations. Refreshed lists the
+// directories and sources of a scoped update, and LastUpdated is the last full update, zero when
+// there was none.
+type CacheUpdateResult struct {
+	Refreshed    []string  `json:"refreshed,omitempty"`
+	Deprecations int       `json:"deprecations"`
+	LastUpdated  time.Time `json:"last_updated,omitzero"`
+}
+
+// CacheClearResult is the structured result of clear_flutter_deprecations_cache. Cleared is unset
+// when confirm was not passed, in which case the counts are what clearing would delete.
+type CacheClearResult struct {
+	Cleared      bool `json:"cleared"`
+	Deprecations int  `json:"deprecations"`
+	Manual       int  `json:"manual"`
+}
+
+// UpstreamChecks is the structured result of check_upstreams, with an entry per probed endpoint
+type UpstreamChecks struct {
+	Endpoints []UpstreamCheck `json:"endpoints"`
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// analyzerEdit is a replacement of the bytes start to end of a file
+type analyzerEdit struct {
+	start, end  int
+	replacement string
+}
+
+// deprecatedUse is a use of a deprecated API at the bytes start to end of a file, with the
+// mechanical replacements that fix it
+type deprecatedUse struct {
+	dep        models.Deprecation
+	start, end int
+	edits      []analyzerEdit
+}
+
+// AnalyzerFixes reports every deprecated use in the code of file in the format of the analyzer
+// plugin protocol, with a fix for each use a mechanical replacement is known for, as migrate_code
+// applies them. Empty code is read from file. target selects the Flutter (default) or Dart rules.
+func (d *DeprecationService) AnalyzerFixes(file string, code string, target string) (*models.AnalyzerFixesResult, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	var stamp int64
+	if code == "" {
+		if code, err = readCheckFile(file, ""); err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(file); err == nil {
+			stamp = info.ModTime().UnixMilli()
+		}
+	}
+
+	positions := newDartPositions(code)
+	result := &models.AnalyzerFixesResult{Fixes: []models.AnalysisErrorFixes{}}
+	for _, use := range d.deprecatedUses(code, target) {
+		fixes := models.AnalysisErrorFixes{Error: analysisError(use.dep, positions.location(file, use.start, use.end)), Fixes: []models.PrioritizedSourceChange{}, API: use.dep.API}
+		for _, edit := range use.edits {
+			fixes.Fixes = append(fixes.Fixes, positions.sourceChange(file, stamp, use.dep, edit))
+		}
+		fixes.Error.HasFix = len(fixes.Fixes) > 0
+		result.Fixes = append(result.Fixes, fixes)
+	}
+	return result, nil
+}
+
+// deprecatedUses finds every use of a deprecated API in code, in the order of the file, for a
+// target returned by ParseTarget. Like migrate_code, only the rewrites of pattern rules and renames
+// of whole classes come with replacements.
+func (d *DeprecationService) deprecatedUses(code string, target string) []deprecatedUse {
+	rules, entries := d.rules(target)
+
+	var uses []deprecatedUse
+	add := func(dep models.Deprecation, start int, end int, edits []analyzerEdit) {
+		use := deprecatedUse{dep: dep, start: start, end: end}
+		for _, edit := range edits {
+			if edit.start < end && start < edit.end {
+				use.edits = append(use.edits, edit)
+			}
+		}
+		uses = append(uses, use)
+	}
+
+	reported := make(map[string]bool)
+	for _, rule := range rules {
+		matches := rule.pattern.FindAllStringIndex(code, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		reported[rule.deprecation.API] = true
+		var edits []analyzerEdit
+		if rule.rewrite != nil {
+			for _, match := range rule.rewrite.FindAllStringSubmatchIndex(code, -1) {
+				edits = append(edits, analyzerEdit{start: match[0], end: match[1], replacement: string(rule.rewrite.ExpandString(nil, rule.template, code, match))})
+			}
+		}
+		for _, match := range matches {
+			add(rule.deprecation, match[0], match[1], edits)
+		}
+	}
+
+	for _, dep := range append(entries, patternDeprecations(rules)...) {
+		if dep.API == "" || reported[dep.API] || !strings.Contains(code, dep.API) || !usesPackage(code, dep.Package) {
+			continue
+		}
+		reported[dep.API] = true
+		// A drafted replacement is unverified, so it is suggested without an edit
+		if dep.ReplacementDraftedBy == "" && identifierPattern.MatchString(dep.API) && identifierPattern.MatchString(dep.Replacement) {
+			pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(dep.API) + `\b`)
+			for _, match := range pattern.FindAllStringIndex(code, -1) {
+				add(dep, match[0], match[1], []analyzerEdit{{start: match[0], end: match[1], replacement: dep.Replacement}})
+			}
+			continue
+		}
+		for from := 0; ; {
+			i := strings.Index(code[from:], dep.API)
+			if i < 0 {
+				break
+			}
+			add(dep, from+i, from+i+len(dep.API), nil)
+			from += i + len(dep.API)
+		}
+	}
+
+	sort.SliceStable(uses, func(i, j int) bool {
+		return uses[i].start < uses[j].start
+	})
+	return uses
+}
+
+// analysisError describes the deprecated use of dep at location as the analyzer reports deprecated members
+func analysisError(dep models.Deprecation, location models.AnalysisLocation) models.AnalysisError {
+	severity := strings.ToUpper(dep.Severity)
+	if severity == "" {
+		severity = strings.ToUpper(config.SEVERITY_WARNING)
+	}
+	return models.AnalysisError{
+		Severity:   severity,
+		Type:       config.ANALYZER_ERROR_TYPE,
+		Location:   location,
+		Message:    deprecatedUseMessage(dep),
+		Correction: replacementHint(dep),
+		Code:       config.ANALYZER_ERROR_CODE,
+		URL:        DocumentationURL(dep),
+	}
+}
+
+// deprecatedUseMessage describes a use of dep in the words of the analyzer's deprecated member hint
+func deprecatedUseMessage(dep models.Deprecation) string {
+	message := fmt.Sprintf("'%s' is deprecated and shouldn't be used.", dep.API)
+	// Descriptions generated from the API alone would repeat the message
+	if description := strings.TrimSpace(dep.Description); description != "" && !strings.HasPrefix(description, dep.API+" is deprecated") {
+		message += " " + strings.TrimSuffix(description, ".") + "."
+	}
+	return message
+}
+
+// replacementHint suggests the replacement of dep, or returns "" when it has none
+func replacementHint(dep models.Deprecation) string {
+	if dep.Replacement == "" {
+		return ""
+	}
+	if dep.ReplacementDraftedBy != "" {
+		return fmt.Sprintf("Try replacing the use of the deprecated member with %s (drafted by %s, unverified).", dep.Replacement, dep.ReplacementDraftedBy)
+	}
+	return fmt.Sprintf("Try replacing the use of the deprecated member with %s.", dep.Replacement)
+}
+
+// dartPositions converts byte offsets into a file's contents to the UTF-16 offsets, lines and
+// columns of the analyzer plugin protocol
+type dartPositions struct {
+	code       string
+	lineStarts []int
+	ascii      bool
+}
+
+// newDartPositions indexes the lines of code
+func newDartPositions(code string) *dartPositions {
+	p := &dartPositions{code: code, lineStarts: []int{0}, ascii: true}
+	for i := 0; i < len(code); i++ {
+		switch {
+		case code[i] == '\n':
+			p.lineStarts = append(p.lineStarts, i+1)
+		case code[i] >= utf8.RuneSelf:
+			p.ascii = false
+		}
+	}
+	return p
+}
+
+// units returns the number of UTF-16 code units of the bytes from to to
+func (p *dartPositions) units(from int, to int) int {
+	if p.ascii {
+		return to - from
+	}
+	n := 0
+	for _, r := range p.code[from:to] {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// lineColumn returns the 1-based line and UTF-16 column of a byte offset
+func (p *dartPositions) lineColumn(offset int) (int, int) {
+	line := sort.SearchInts(p.lineStarts, offset+1)
+	return line, p.units(p.lineStarts[line-1], offset) + 1
+}
+
+// location returns the location of the bytes start to end of file
+func (p *dartPositions) location(file string, start int, end int) models.AnalysisLocation {
+	location := models.AnalysisLocation{File: file, Offset: p.units(0, start), Length: p.units(start, end)}
+	location.StartLine, location.StartColumn = p.lineColumn(start)
+	location.EndLine, location.EndColumn = p.lineColumn(end)
+	return location
+}
+
+// sourceChange returns the fix applying edit for dep
+func (p *dartPositions) sourceChange(file string, stamp int64, dep models.Deprecation, edit analyzerEdit) models.PrioritizedSourceChange {
+	message := fmt.Sprintf("Migrate %s", dep.API)
+	if dep.Replacement != "" {
+		message = fmt.Sprintf("Replace %s with %s", dep.API, dep.Replacement)
+	}
+	return models.PrioritizedSourceChange{
+		Priority: config.ANALYZER_FIX_PRIORITY,
+		Change: models.SourceChange{
+			Message: message,
+			ID:      config.ANALYZER_FIX_ID,
+			Edits: []models.SourceFileEdit{{
+				File:      file,
+				FileStamp: stamp,
+				Edits:     []models.SourceEdit{{Offset: p.units(0, edit.start), Length: p.units(edit.start, edit.end), Replacement: edit.replacement}},
+			}},
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestAnalyzerFixes(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	if err := cacheService.Save(&models.DeprecationCache{Manual: []models.Deprecation{
+		{API: "LegacyCard", Replacement: "AppCard", Source: config.DEPRECATION_SOURCE_MANUAL},
+		{API: "legacyHelper", Description: "Call the service directly.", Source: config.DEPRECATION_SOURCE_MANUAL},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+	code := "// 🎨 theme\nfinal b = RaisedButton(onPressed: save);\nCard(child: LegacyCard());\nlegacyHelper();\n"
+
+	t.Run("Reports each use with its fixes", func(t *testing.T) {
+		result, err := depService.AnalyzerFixes("/app/lib/main.dart", code, "")
+		if err != nil {
+			t.Fatalf("AnalyzerFixes failed: %v", err)
+		}
+		if len(result.Fixes) != 3 {
+			t.Fatalf("Expected 3 errors, got %+v", result.Fixes)
+		}
+
+		button := result.Fixes[0]
+		if button.API != "RaisedButton" || button.Error.Severity != "ERROR" || button.Error.Code != config.ANALYZER_ERROR_CODE || !button.Error.HasFix {
+			t.Errorf("Expected a fixable RaisedButton error, got %+v", button.Error)
+		}
+		// The emoji takes two UTF-16 code units but four bytes
+		expected := models.AnalysisLocation{File: "/app/lib/main.dart", Offset: 22, Length: 12, StartLine: 2, StartColumn: 11, EndLine: 2, EndColumn: 23}
+		if button.Error.Location != expected {
+			t.Errorf("Expected location %+v, got %+v", expected, button.Error.Location)
+		}
+		if len(button.Fixes) != 1 || button.Fixes[0].Change.Message != "Replace RaisedButton with ElevatedButton" {
+			t.Fatalf("Expected one replacement fix, got %+v", button.Fixes)
+		}
+		edit := button.Fixes[0].Change.Edits[0]
+		if edit.File != "/app/lib/main.dart" || edit.FileStamp != 0 || len(edit.Edits) != 1 {
+			t.Fatalf("Expected one edit of the file, got %+v", edit)
+		}
+		if e := edit.Edits[0]; e.Offset != 22 || e.Length != 12 || e.Replacement != "ElevatedButton" {
+			t.Errorf("Expected RaisedButton to be replaced, got %+v", e)
+		}
+
+		card := result.Fixes[1]
+		if card.API != "LegacyCard" || card.Error.Location.StartLine != 3 || card.Error.Location.StartColumn != 13 || len(card.Fixes) != 1 {
+			t.Errorf("Expected a fixable LegacyCard error on line 3, got %+v", card)
+		} else if e := card.Fixes[0].Change.Edits[0].Edits[0]; e.Replacement != "AppCard" || e.Length != 10 {
+			t.Errorf("Expected LegacyCard to be renamed, got %+v", e)
+		}
+
+		helper := result.Fixes[2]
+		if helper.Error.HasFix || len(helper.Fixes) != 0 || helper.Error.Severity != "WARNING" {
+			t.Errorf("Expected legacyHelper to have no fix, got %+v", helper)
+		}
+		if helper.Error.Message != "'legacyHelper' is deprecated and shouldn't be used. Call the service directly." {
+			t.Errorf("Unexpected message %q", helper.Error.Message)
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{`"hasFix":true`, `"startColumn":11`, `"fileStamp":0`, `"priority":50`} {
+			if !strings.Contains(string(data), field) {
+				t.Errorf("Expected the JSON to contain %s, got %s", field, data)
+			}
+		}
+		if strings.Contains(string(data), `"API"`) {
+			t.Errorf("Expected the API to be left out of the JSON, got %s", data)
+		}
+	})
+
+	t.Run("Reads the file when no code is given", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "lib", "main.dart")
+		writeFile(t, file, "FlatButton()\n")
+		result, err := depService.AnalyzerFixes(file, "", "")
+		if err != nil {
+			t.Fatalf("AnalyzerFixes failed: %v", err)
+		}
+		if len(result.Fixes) != 1 || result.Fixes[0].Fixes[0].Change.Edits[0].FileStamp == 0 {
+			t.Errorf("Expected a fix stamped with the file's modification time, got %+v", result.Fixes)
+		}
+
+		if _, err := depService.AnalyzerFixes(filepath.Join(t.TempDir(), "missing.dart"), "", ""); err == nil {
+			t.Error("Expected an error for a missing file")
+		}
+	})
+
+	t.Run("Applies the rules of the target", func(t *testing.T) {
+		result, err := depService.AnalyzerFixes("/server/bin/main.dart", code, config.TARGET_DART)
+		if err != nil {
+			t.Fatalf("AnalyzerFixes failed: %v", err)
+		}
+		for _, fixes := range result.Fixes {
+			if fixes.API == "RaisedButton" {
+				t.Errorf("Expected no Flutter rules for Dart code, got %+v", fixes)
+			}
+		}
+
+		if _, err := depService.AnalyzerFixes("/app/lib/main.dart", code, "kotlin"); err == nil {
+			t.Error("Expected an error for an unknown target")
+		}
+	})
+
+	t.Run("Returns an empty list for clean code", func(t *testing.T) {
+		result, err := depService.AnalyzerFixes("/app/lib/main.dart", "Text('fine')\n", "")
+		if err != nil {
+			t.Fatalf("AnalyzerFixes failed: %v", err)
+		}
+		if data, _ := json.Marshal(result); string(data) != `{"fixes":[]}` {
+			t.Errorf("Expected an empty fixes list, got %s", data)
+		}
+	})
+}
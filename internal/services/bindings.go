@@ -0,0 +1,12 @@
+package services
+
+// bindingsNonNullableFlutter is the release that made the binding singletons non-nullable
+const bindingsNonNullableFlutter = "3.0.0"
+
+// bindingInstance matches the singleton of a framework binding, such as WidgetsBinding.instance.
+// The rules in rules/bindings.yaml detect the null assertions and checks Flutter 2 needed on it.
+const bindingInstance = `\b(?:Widgets|Scheduler|Services|Renderer|Gesture|Painting|Semantics)Binding\.instance`
+
+// bindingsCompatible is the form that compiles on both sides of the change: the helper turns the
+// instance back into a nullable value, so the ! is needed before 3.0 and harmless after it
+const bindingsCompatible = "_ambiguate(WidgetsBinding.instance)!.addPostFrameCallback(...) with T? _ambiguate<T>(T? value) => value;"
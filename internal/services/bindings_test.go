@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBindingRules(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+
+	t.Run("Detects the Flutter 2 null handling", func(t *testing.T) {
+		found := make(map[string]bool)
+		code := "WidgetsBinding.instance!.addPostFrameCallback((_) => scroll());\n" +
+			"SchedulerBinding.instance?.scheduleFrame();\n" +
+			"if (ServicesBinding.instance != null) {}\n"
+		for _, dep := range depService.CheckCodeForDeprecations(code) {
+			found[dep.API] = true
+		}
+		if !found["WidgetsBinding.instance!"] || !found["WidgetsBinding.instance == null"] {
+			t.Errorf("Expected the assertion and the null check to be detected, got %v", found)
+		}
+
+		current := "WidgetsBinding.instance.addPostFrameCallback((_) => scroll());\n" +
+			"_ambiguate(SchedulerBinding.instance)!.scheduleFrame();\n" +
+			"final ready = TestWidgetsFlutterBinding.instance!.inTest;\n"
+		if result := depService.CheckCodeForDeprecations(current); len(result) != 0 {
+			t.Errorf("Expected the Flutter 3 and compatible forms to pass, got %+v", result)
+		}
+	})
+
+	t.Run("Migrates the assertions", func(t *testing.T) {
+		result := depService.MigrateCode("WidgetsBinding.instance!.addObserver(this);\nSchedulerBinding.instance?.scheduleFrame();\nreturn RendererBinding.instance!;\n")
+		expected := "WidgetsBinding.instance.addObserver(this);\nSchedulerBinding.instance.scheduleFrame();\nreturn RendererBinding.instance;\n"
+		if result.Code != expected {
+			t.Errorf("Expected rewritten code:\n%s\ngot:\n%s", expected, result.Code)
+		}
+	})
+
+	t.Run("Suggests the form for the version range", func(t *testing.T) {
+		// Moving from 2.10 to 3.0 while still supporting 2.10 needs the compatible form
+		result, err := depService.CheckCodeAgainstVersion("WidgetsBinding.instance!.addObserver(this);", "3.0.5", "2.10.5")
+		if err != nil {
+			t.Fatalf("CheckCodeAgainstVersion failed: %v", err)
+		}
+		if len(result.Introduced) != 1 || !strings.Contains(result.Introduced[0].Compatible, "_ambiguate") {
+			t.Errorf("Expected the compatible form for the range, got %+v", result.Introduced)
+		}
+
+		// The non-nullable form does not compile on Flutter 2
+		result, err = depService.CheckCodeAgainstVersion("WidgetsBinding.instance.addObserver(this);", "2.10.5", "")
+		if err != nil {
+			t.Fatalf("CheckCodeAgainstVersion failed: %v", err)
+		}
+		if len(result.Unavailable) != 1 || result.Unavailable[0].Version != bindingsNonNullableFlutter || !strings.Contains(result.Unavailable[0].Alternative, "WidgetsBinding.instance!") {
+			t.Errorf("Expected the non-nullable instance to need Flutter 3.0, got %+v", result.Unavailable)
+		}
+	})
+}
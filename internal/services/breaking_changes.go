@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// Patterns used to read the release sections of the flutter/website breaking changes index
+var (
+	releaseHeadingPattern = regexp.MustCompile(`^#{2,4}\s+Released in Flutter (\d+\.\d+(?:\.\d+)?)`)
+	otherHeadingPattern   = regexp.MustCompile(`^#{2,4}\s+`)
+	changeItemPattern     = regexp.MustCompile(`^\s*[*-]\s+\[(.+?)\](?:\[\]|\((/release/breaking-changes/[a-z0-9-]+)\))?`)
+)
+
+// curatedBreakingChanges is used when the flutter/website index cannot be fetched
+var curatedBreakingChanges = []models.BreakingChange{
+	{Version: "1.22.0", Title: "New Buttons and Button Themes", Slug: "buttons"},
+	{Version: "2.0.0", Title: "SnackBars managed by the ScaffoldMessenger", Slug: "scaffold-messenger"},
+	{Version: "3.10.0", Title: "Deprecated API removed after v3.7", Slug: "3-7-deprecations"},
+	{Version: "3.13.0", Title: "Deprecated API removed after v3.10", Slug: "3-10-deprecations"},
+	{Version: "3.16.0", Title: "Deprecated API removed after v3.13", Slug: "3-13-deprecations"},
+	{Version: "3.16.0", Title: "Migrate to Material 3", Slug: "material-3-migration"},
+	{Version: "3.16.0", Title: "Android predictive back", Slug: "android-predictive-back"},
+	{Version: "3.19.0", Title: "Deprecated API removed after v3.16", Slug: "3-16-deprecations"},
+	{Version: "3.19.0", Title: "Deprecated imperative apply of Flutter's Gradle plugins", Slug: "flutter-gradle-plugin-apply"},
+	{Version: "3.22.0", Title: "Deprecated API removed after v3.19", Slug: "3-19-deprecations"},
+	{Version: "3.22.0", Title: "Rename MaterialState to WidgetState", Slug: "material-state"},
+	{Version: "3.24.0", Title: "Deprecated API removed after v3.22", Slug: "3-22-deprecations"},
+	{Version: "3.27.0", Title: "Deprecated API removed after v3.24", Slug: "3-24-deprecations"},
+	{Version: "3.27.0", Title: "Wide gamut Color", Slug: "wide-gamut-framework"},
+}
+
+// BreakingChangesBetween lists the breaking changes released after from and up to and including to,
+// oldest first. The flutter/website index is used when reachable, the curated list otherwise.
+func (m *MigrationGuideService) BreakingChangesBetween(ctx context.Context, from string, to string) (*models.BreakingChangesResult, error) {
+	fromVersion, ok := parseVersion(from)
+	if !ok {
+		return nil, fmt.Errorf("invalid from version %q", from)
+	}
+	toVersion, ok := parseVersion(to)
+	if !ok {
+		return nil, fmt.Errorf("invalid to version %q", to)
+	}
+	if fromVersion.Compare(toVersion) > 0 {
+		return nil, fmt.Errorf("from version %s is newer than to version %s", from, to)
+	}
+
+	result := &models.BreakingChangesResult{
+		From:   fromVersion.String(),
+		To:     toVersion.String(),
+		Source: "flutter/website",
+	}
+
+	changes := curatedBreakingChanges
+	if index, err := m.guide(ctx, "index"); err != nil {
+		result.Source = "curated"
+		result.UpstreamError = err.Error()
+	} else if parsed := parseBreakingChangesIndex(index); len(parsed) > 0 {
+		changes = parsed
+	} else {
+		result.Source = "curated"
+		result.UpstreamError = "breaking changes index has no release sections"
+	}
+
+	for _, change := range changes {
+		version, ok := parseVersion(change.Version)
+		if !ok || version.Compare(fromVersion) <= 0 || version.Compare(toVersion) > 0 {
+			continue
+		}
+		if change.Slug != "" {
+			change.URL = config.BREAKING_CHANGES_DOCS_URL + change.Slug
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	sort.SliceStable(result.Changes, func(i, j int) bool {
+		cmp, _ := CompareVersions(result.Changes[i].Version, result.Changes[j].Version)
+		return cmp < 0
+	})
+
+	return result, nil
+}
+
+// parseBreakingChangesIndex reads the "Released in Flutter X.Y" sections of the breaking changes index
+func parseBreakingChangesIndex(index string) []models.BreakingChange {
+	slugs := make(map[string]string)
+	for _, link := range guideLinkPattern.FindAllStringSubmatch(index, -1) {
+		slugs[link[1]] = link[2]
+	}
+
+	var changes []models.BreakingChange
+	version := ""
+	for _, line := range strings.Split(index, "\n") {
+		if matches := releaseHeadingPattern.FindStringSubmatch(line); matches != nil {
+			version = matches[1]
+			continue
+		}
+		if otherHeadingPattern.MatchString(line) {
+			version = ""
+			continue
+		}
+		if version == "" {
+			continue
+		}
+
+		matches := changeItemPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		slug := strings.TrimPrefix(matches[2], "/release/breaking-changes/")
+		if slug == "" {
+			slug = slugs[matches[1]]
+		}
+		changes = append(changes, models.BreakingChange{
+			Version: version,
+			Title:   strings.ReplaceAll(matches[1], "`", ""),
+			Slug:    slug,
+		})
+	}
+	return changes
+}
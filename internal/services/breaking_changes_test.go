@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+const testReleaseIndex = `
+## Not yet released to stable
+
+* [Upcoming change][]
+
+## Released in Flutter 3.27
+
+* [Deprecate ` + "`Color.withOpacity`" + `](/release/breaking-changes/wide-gamut-framework)
+
+## Released in Flutter 3.22
+
+* [Rename MaterialState to WidgetState][]
+
+## Released in Flutter 3.19
+
+* [Deprecated API removed after v3.16][]
+
+## Released in Flutter 3.16
+
+* [Migrate to Material 3][]
+
+[Upcoming change]: /release/breaking-changes/upcoming
+[Rename MaterialState to WidgetState]: /release/breaking-changes/material-state
+[Deprecated API removed after v3.16]: /release/breaking-changes/3-16-deprecations
+[Migrate to Material 3]: /release/breaking-changes/material-3-migration
+`
+
+func TestBreakingChangesBetween(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+
+	t.Run("From the flutter/website index", func(t *testing.T) {
+		mockAPI := &MockFlutterAPIService{websiteFiles: map[string]string{"src/content/release/breaking-changes/index.md": testReleaseIndex}}
+		result, err := NewMigrationGuideService(mockAPI, depService).BreakingChangesBetween(context.Background(), "3.16", "3.27")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.Source != "flutter/website" {
+			t.Errorf("Expected flutter/website source, got %s (%s)", result.Source, result.UpstreamError)
+		}
+		if len(result.Changes) != 3 {
+			t.Fatalf("Expected 3 changes after 3.16 up to 3.27, got %+v", result.Changes)
+		}
+
+		expected := []string{"3-16-deprecations", "material-state", "wide-gamut-framework"}
+		for i, slug := range expected {
+			if result.Changes[i].Slug != slug {
+				t.Errorf("Expected change %d to be %s, got %s", i, slug, result.Changes[i].Slug)
+			}
+		}
+		if result.Changes[2].Title != "Deprecate Color.withOpacity" {
+			t.Errorf("Expected backticks to be stripped from titles, got %q", result.Changes[2].Title)
+		}
+		if result.Changes[0].URL != "https://docs.flutter.dev/release/breaking-changes/3-16-deprecations" {
+			t.Errorf("Unexpected URL %s", result.Changes[0].URL)
+		}
+	})
+
+	t.Run("Falls back to the curated list", func(t *testing.T) {
+		result, err := NewMigrationGuideService(&MockFlutterAPIService{}, depService).BreakingChangesBetween(context.Background(), "3.24.0", "3.27.0")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.Source != "curated" || result.UpstreamError == "" {
+			t.Errorf("Expected curated source with upstream error, got %s / %q", result.Source, result.UpstreamError)
+		}
+		for _, change := range result.Changes {
+			if change.Version != "3.27.0" {
+				t.Errorf("Expected only 3.27.0 changes, got %+v", change)
+			}
+		}
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		service := NewMigrationGuideService(&MockFlutterAPIService{}, depService)
+		if _, err := service.BreakingChangesBetween(context.Background(), "3.27", "3.16"); err == nil {
+			t.Error("Expected error when from is newer than to")
+		}
+		if _, err := service.BreakingChangesBetween(context.Background(), "stable", "3.16"); err == nil {
+			t.Error("Expected error for invalid version")
+		}
+	})
+}
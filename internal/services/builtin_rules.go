@@ -0,0 +1,51 @@
+package services
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// builtinRuleFiles holds the built-in rules, in the format of --rules-file
+//
+//go:embed rules/*.yaml
+var builtinRuleFiles embed.FS
+
+// builtinRuleFileNames lists the files of builtinRuleFiles in the order their rules are checked
+var builtinRuleFileNames = []string{"flutter.yaml", "bindings.yaml", "flutter_test.yaml", "dart_sdk.yaml", "dart_language.yaml"}
+
+// builtinRuleSet holds the compiled built-in rules, loaded once
+var builtinRuleSet = mustLoadBuiltinRules()
+
+// builtinRules holds the known deprecation patterns, compiled once and kept in a stable order
+var builtinRules = builtinRuleSet.patterns[config.TARGET_FLUTTER]
+
+// dartRules are the rules that apply to pure Dart packages: the Dart SDK and syntax rules
+var dartRules = builtinRuleSet.patterns[config.TARGET_DART]
+
+// mustLoadBuiltinRules parses the built-in rule files; an invalid file is a programming error
+func mustLoadBuiltinRules() ruleSet {
+	set, err := loadBuiltinRules()
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// loadBuiltinRules parses the built-in rule files in order
+func loadBuiltinRules() (ruleSet, error) {
+	set := newRuleSet()
+	for _, name := range builtinRuleFileNames {
+		data, err := builtinRuleFiles.ReadFile("rules/" + name)
+		if err != nil {
+			return ruleSet{}, err
+		}
+		rules, err := parseRuleFile(data, config.DEPRECATION_SOURCE_BUILTIN)
+		if err != nil {
+			return ruleSet{}, fmt.Errorf("built-in rules %s: %v", name, err)
+		}
+		set.add(rules)
+	}
+	return set, nil
+}
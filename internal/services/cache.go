@@ -2,26 +2,67 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
 	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 // CacheService handles local cache operations
-type CacheService struct{}
+type CacheService struct {
+	// dir overrides the default cache directory when set
+	dir string
+
+	// The last cache read from or written to disk, reused while the file is unchanged
+	mu          sync.Mutex
+	memo        *models.DeprecationCache
+	memoModTime time.Time
+	memoSize    int64
+
+	stats CacheRecorder
+}
 
 // NewCacheService creates a new cache service instance
 func NewCacheService() *CacheService {
 	return &CacheService{}
 }
 
+// NewCacheServiceInDir creates a cache service that keeps its files in dir instead of the default
+// ~/.flutter-deprecations (%APPDATA%\flutter-deprecations on Windows)
+func NewCacheServiceInDir(dir string) *CacheService {
+	return &CacheService{dir: dir}
+}
+
+// SetStatsRecorder reports every load of the cache to recorder
+func (c *CacheService) SetStatsRecorder(recorder CacheRecorder) {
+	c.stats = recorder
+}
+
+// observe reports a load that returned cache when a recorder is set
+func (c *CacheService) observe(result string, cache *models.DeprecationCache) {
+	if c.stats != nil {
+		c.stats.RecordCacheLoad(result, cache.LastUpdated)
+	}
+}
+
 // getCacheDir returns the cache directory path
 func (c *CacheService) getCacheDir() string {
+	if c.dir != "" {
+		return c.dir
+	}
 	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".flutter-deprecations")
+	return defaultCacheDir(runtime.GOOS, homeDir, os.Getenv)
+}
+
+// Dir returns the directory holding the cache and other server state files
+func (c *CacheService) Dir() string {
+	return c.getCacheDir()
 }
 
 // ensureCacheDir creates the cache directory if it doesn't exist
@@ -33,8 +74,19 @@ func (c *CacheService) ensureCacheDir() error {
 func (c *CacheService) Load() (*models.DeprecationCache, error) {
 	cachePath := filepath.Join(c.getCacheDir(), config.CACHE_FILE)
 
-	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
-		return &models.DeprecationCache{Deprecations: []models.Deprecation{}}, nil
+	stat, err := os.Stat(cachePath)
+	if os.IsNotExist(err) {
+		cache := &models.DeprecationCache{Deprecations: []models.Deprecation{}}
+		c.observe(CacheLoadMissing, cache)
+		return cache, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil && c.memo != nil && stat.ModTime().Equal(c.memoModTime) && stat.Size() == c.memoSize {
+		c.observe(CacheLoadMemory, c.memo)
+		return copyCache(c.memo), nil
 	}
 
 	data, err := ioutil.ReadFile(cachePath)
@@ -45,10 +97,38 @@ func (c *CacheService) Load() (*models.DeprecationCache, error) {
 	var cache models.DeprecationCache
 	err = json.Unmarshal(data, &cache)
 	if err != nil {
-		return &models.DeprecationCache{Deprecations: []models.Deprecation{}}, nil
+		cache = models.DeprecationCache{Deprecations: []models.Deprecation{}}
+		c.observe(CacheLoadDisk, &cache)
+		return &cache, nil
+	}
+
+	c.remember(cachePath, &cache)
+	c.observe(CacheLoadDisk, &cache)
+	return copyCache(&cache), nil
+}
+
+// remember stores the cache as the in-memory copy of the file at cachePath; the caller holds c.mu
+func (c *CacheService) remember(cachePath string, cache *models.DeprecationCache) {
+	stat, err := os.Stat(cachePath)
+	if err != nil {
+		c.memo = nil
+		return
 	}
+	c.memo = copyCache(cache)
+	c.memoModTime = stat.ModTime()
+	c.memoSize = stat.Size()
+}
 
-	return &cache, nil
+// copyCache returns a copy whose deprecation slices can be modified without affecting the original
+func copyCache(cache *models.DeprecationCache) *models.DeprecationCache {
+	copied := *cache
+	copied.Deprecations = make([]models.Deprecation, len(cache.Deprecations))
+	copy(copied.Deprecations, cache.Deprecations)
+	if cache.Manual != nil {
+		copied.Manual = make([]models.Deprecation, len(cache.Manual))
+		copy(copied.Manual, cache.Manual)
+	}
+	return &copied
 }
 
 // Save saves the deprecation cache to disk
@@ -63,7 +143,16 @@ func (c *CacheService) Save(cache *models.DeprecationCache) error {
 		return err
 	}
 
-	return ioutil.WriteFile(cachePath, data, 0644)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ioutil.WriteFile(cachePath, data, 0644); err != nil {
+		c.memo = nil
+		return err
+	}
+
+	c.remember(cachePath, cache)
+	return nil
 }
 
 // Clear removes all cached data by deleting the cache file
@@ -74,5 +163,81 @@ func (c *CacheService) Clear() error {
 		return nil // Cache file doesn't exist, nothing to clear
 	}
 
+	c.mu.Lock()
+	c.memo = nil
+	c.mu.Unlock()
+
 	return os.Remove(cachePath)
 }
+
+// packagesRef is the branch of flutter/packages the plugins are scanned on, as in
+// config.FLUTTER_PACKAGES_RAW_URL
+const packagesRef = "flutter/packages@main"
+
+// Status reports the size, age and contents of the deprecations cache file without changing it
+func (c *CacheService) Status() (*models.CacheStatus, error) {
+	status := &models.CacheStatus{
+		Path:     filepath.Join(c.getCacheDir(), config.CACHE_FILE),
+		TTL:      config.CACHE_DURATION,
+		BySource: make(map[string]int),
+		Refs:     make(map[string]int),
+	}
+
+	stat, err := os.Stat(status.Path)
+	if os.IsNotExist(err) {
+		status.Expired = true
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Exists = true
+	status.SizeBytes = stat.Size()
+
+	cache, err := c.Load()
+	if err != nil {
+		return nil, err
+	}
+	status.LastUpdated = cache.LastUpdated
+	status.Deprecations = len(cache.Deprecations)
+	status.Manual = len(cache.Manual)
+	status.Removed = countRemoved(cache.Deprecations)
+	for _, entries := range [][]models.Deprecation{cache.Deprecations, cache.Manual} {
+		for _, dep := range entries {
+			status.BySource[statsKey(dep.Source)]++
+		}
+	}
+	for _, dep := range cache.Deprecations {
+		switch dep.Source {
+		case config.DEPRECATION_SOURCE_FLUTTER:
+			status.Refs["flutter/flutter@"+frameworkChannel(dep)]++
+		case config.DEPRECATION_SOURCE_PACKAGES:
+			status.Refs[packagesRef]++
+		}
+	}
+
+	if cache.LastUpdated.IsZero() {
+		status.Expired = true
+		return status, nil
+	}
+	status.Age = time.Since(cache.LastUpdated)
+	status.Expired = status.Age >= config.CACHE_DURATION
+	status.ExpiresAt = cache.LastUpdated.Add(config.CACHE_DURATION)
+	return status, nil
+}
+
+// ClearAll removes the deprecations cache, manual entries included, together with the cached
+// upstream responses and scan results kept next to it. The rules files, suppressions and
+// statistics in the cache directory are kept.
+func (c *CacheService) ClearAll() error {
+	if err := c.Clear(); err != nil {
+		return fmt.Errorf("deprecations cache: %v", err)
+	}
+	if err := ClearHTTPCache(c.Dir()); err != nil {
+		return fmt.Errorf("cached upstream responses: %v", err)
+	}
+	if err := ClearScanCache(c.Dir()); err != nil {
+		return fmt.Errorf("cached scan results: %v", err)
+	}
+	return nil
+}
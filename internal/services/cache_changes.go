@@ -0,0 +1,82 @@
+package services
+
+import (
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// diffDeprecations compares the cached deprecations before and after a refresh. Entries newly
+// flagged as removed upstream count as removed; changes to the line of an annotation or to when it
+// was seen are not modifications. Filling an empty cache records no changes, only the total.
+func diffDeprecations(from time.Time, to time.Time, before []models.Deprecation, after []models.Deprecation) *models.CacheChanges {
+	changes := &models.CacheChanges{
+		To:       to,
+		Total:    len(after),
+		Added:    []models.Deprecation{},
+		Removed:  []models.Deprecation{},
+		Modified: []models.DeprecationChange{},
+	}
+	if from.IsZero() || len(before) == 0 {
+		return changes
+	}
+	changes.From = from
+
+	previous := make(map[string]models.Deprecation, len(before))
+	for _, dep := range before {
+		if _, seen := previous[seenKey(dep)]; !seen {
+			previous[seenKey(dep)] = dep
+		}
+	}
+
+	current := make(map[string]bool, len(after))
+	for _, dep := range after {
+		key := seenKey(dep)
+		if current[key] {
+			continue
+		}
+		current[key] = true
+
+		old, existed := previous[key]
+		switch {
+		case !existed || (old.Removed && !dep.Removed):
+			changes.Added = append(changes.Added, dep)
+		case dep.Removed && !old.Removed:
+			changes.Removed = append(changes.Removed, dep)
+		default:
+			if fields := changedFields(old, dep); len(fields) > 0 {
+				changes.Modified = append(changes.Modified, models.DeprecationChange{Before: old, After: dep, Fields: fields})
+			}
+		}
+	}
+
+	for _, dep := range before {
+		key := seenKey(dep)
+		if !current[key] && !dep.Removed {
+			current[key] = true
+			changes.Removed = append(changes.Removed, dep)
+		}
+	}
+
+	return changes
+}
+
+// changedFields names the user-facing details that differ between two versions of an entry
+func changedFields(before models.Deprecation, after models.Deprecation) []string {
+	var fields []string
+	for _, field := range []struct {
+		name          string
+		before, after string
+	}{
+		{"replacement", before.Replacement, after.Replacement},
+		{"description", before.Description, after.Description},
+		{"version", before.Version, after.Version},
+		{"category", before.Category, after.Category},
+		{"severity", before.Severity, after.Severity},
+	} {
+		if field.before != field.after {
+			fields = append(fields, field.name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestDiffDeprecations(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	before := []models.Deprecation{
+		{API: "ColorScheme.background", Replacement: "ColorScheme.surface", Source: "flutter_source", SourceLine: 10},
+		{API: "ThemeData.accentColor", Source: "flutter_source"},
+		{API: "ButtonBar", Description: "Use OverflowBar", Source: "flutter_source"},
+		{API: "OldBuiltin", Source: "builtin"},
+		{API: "RenderBox.oldLayout", Source: "flutter_source", Removed: true},
+	}
+	after := []models.Deprecation{
+		{API: "ColorScheme.background", Replacement: "ColorScheme.surface", Source: "flutter_source", SourceLine: 14},
+		{API: "ThemeData.accentColor", Source: "flutter_source", Removed: true},
+		{API: "ButtonBar", Description: "Use OverflowBar instead", Version: "3.24.0", Source: "flutter_source"},
+		{API: "GoRouterState.location", Package: "go_router", Source: "flutter_packages"},
+		{API: "RenderBox.oldLayout", Source: "flutter_source", Removed: true},
+	}
+
+	changes := diffDeprecations(from, to, before, after)
+
+	if !changes.From.Equal(from) || !changes.To.Equal(to) || changes.Total != 5 {
+		t.Errorf("Unexpected range or total: %+v", changes)
+	}
+	if len(changes.Added) != 1 || changes.Added[0].API != "GoRouterState.location" {
+		t.Errorf("Expected the go_router entry to be added, got %+v", changes.Added)
+	}
+	removed := make(map[string]bool)
+	for _, dep := range changes.Removed {
+		removed[dep.API] = true
+	}
+	if len(changes.Removed) != 2 || !removed["ThemeData.accentColor"] || !removed["OldBuiltin"] {
+		t.Errorf("Expected accentColor and OldBuiltin to be removed, got %+v", changes.Removed)
+	}
+	if len(changes.Modified) != 1 || changes.Modified[0].After.API != "ButtonBar" {
+		t.Fatalf("Expected ButtonBar to be modified, got %+v", changes.Modified)
+	}
+	if fields := changes.Modified[0].Fields; len(fields) != 2 || fields[0] != "description" || fields[1] != "version" {
+		t.Errorf("Expected description and version to change, got %v", fields)
+	}
+
+	t.Run("Filling an empty cache lists nothing", func(t *testing.T) {
+		initial := diffDeprecations(time.Time{}, to, nil, after)
+		if !initial.From.IsZero() || initial.Total != 5 || len(initial.Added) != 0 {
+			t.Errorf("Expected only the total for the first fill, got %+v", initial)
+		}
+	})
+
+	t.Run("RefreshCache records the changes", func(t *testing.T) {
+		cacheService := &CacheService{dir: t.TempDir()}
+		api := &MockFlutterAPIService{sourceDeps: []models.Deprecation{{API: "ColorScheme.background", Category: "material", Source: "flutter_source"}}}
+		depService := NewDeprecationService(cacheService, api)
+
+		if err := depService.RefreshCache(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		api.sourceDeps = append(api.sourceDeps, models.Deprecation{API: "ThemeData.accentColor", Category: "material", Source: "flutter_source"})
+		if err := depService.RefreshCache(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		cache, _ := cacheService.Load()
+		if cache.LastChanges == nil || len(cache.LastChanges.Added) != 1 || cache.LastChanges.Added[0].API != "ThemeData.accentColor" {
+			t.Errorf("Expected accentColor to be recorded as added, got %+v", cache.LastChanges)
+		}
+		if !cache.LastChanges.To.Equal(cache.LastUpdated) {
+			t.Errorf("Expected the changes to end at the refresh time")
+		}
+	})
+}
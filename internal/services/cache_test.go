@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 // TestCacheServiceImpl implements CacheServiceInterface for testing
@@ -141,4 +142,104 @@ func TestCacheService(t *testing.T) {
 			t.Error("Expected cache directory to be created")
 		}
 	})
+
+	t.Run("Memoized load returns independent copies", func(t *testing.T) {
+		service := &CacheService{dir: t.TempDir()}
+		err := service.Save(&models.DeprecationCache{
+			LastUpdated:  time.Now(),
+			Deprecations: []models.Deprecation{{API: "TestAPI"}},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error saving cache, got %v", err)
+		}
+
+		first, err := service.Load()
+		if err != nil {
+			t.Fatalf("Expected no error loading cache, got %v", err)
+		}
+		first.Deprecations[0].API = "Mutated"
+
+		second, err := service.Load()
+		if err != nil {
+			t.Fatalf("Expected no error loading cache, got %v", err)
+		}
+		if second.Deprecations[0].API != "TestAPI" {
+			t.Errorf("Expected memoized cache to be unaffected by caller mutation, got '%s'", second.Deprecations[0].API)
+		}
+	})
+}
+
+func TestCacheServiceClearAll(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCacheServiceInDir(dir)
+	if err := cache.Save(&models.DeprecationCache{Manual: []models.Deprecation{{API: "LegacyCard"}}}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, config.HTTP_CACHE_DIR, "entry.json"), "{}")
+	writeFile(t, filepath.Join(dir, config.SCAN_CACHE_FILE), "{}")
+	writeFile(t, filepath.Join(dir, config.CUSTOM_RULES_FILE), "rules: []")
+
+	if err := cache.ClearAll(); err != nil {
+		t.Fatalf("ClearAll failed: %v", err)
+	}
+	for _, name := range []string{config.CACHE_FILE, config.HTTP_CACHE_DIR, config.SCAN_CACHE_FILE} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, config.CUSTOM_RULES_FILE)); err != nil {
+		t.Errorf("Expected the rules file to be kept, got %v", err)
+	}
+	if loaded, err := cache.Load(); err == nil && len(loaded.Manual) != 0 {
+		t.Errorf("Expected the remembered cache to be dropped, got %+v", loaded)
+	}
+	if err := cache.ClearAll(); err != nil {
+		t.Errorf("Expected clearing an empty cache directory to succeed, got %v", err)
+	}
+}
+
+func TestCacheServiceStatus(t *testing.T) {
+	cache := NewCacheServiceInDir(t.TempDir())
+	status, err := cache.Status()
+	if err != nil || status.Exists || !status.Expired {
+		t.Fatalf("Expected a missing cache to be expired, got %+v (%v)", status, err)
+	}
+
+	updated := time.Now().Add(-2 * time.Hour)
+	if err := cache.Save(&models.DeprecationCache{
+		LastUpdated: updated,
+		Deprecations: []models.Deprecation{
+			{API: "RaisedButton", Source: config.DEPRECATION_SOURCE_FLUTTER, Channel: config.FLUTTER_CHANNEL_STABLE},
+			{API: "FlatButton", Source: config.DEPRECATION_SOURCE_FLUTTER},
+			{API: "Geolocator.old", Source: config.DEPRECATION_SOURCE_PACKAGES, Removed: true},
+			{API: "Theme.accentColor", Source: config.DEPRECATION_SOURCE_BUILTIN},
+		},
+		Manual: []models.Deprecation{{API: "LegacyCard", Source: config.DEPRECATION_SOURCE_MANUAL}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = cache.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !status.Exists || status.SizeBytes == 0 || status.Expired || status.Deprecations != 4 || status.Manual != 1 || status.Removed != 1 {
+		t.Errorf("Expected a fresh cache with 4 deprecations and 1 manual entry, got %+v", status)
+	}
+	if status.Age < 2*time.Hour || !status.ExpiresAt.Equal(updated.Add(config.CACHE_DURATION)) {
+		t.Errorf("Expected the age and expiry to follow the last update, got %s and %s", status.Age, status.ExpiresAt)
+	}
+	if status.BySource[config.DEPRECATION_SOURCE_FLUTTER] != 2 || status.BySource[config.DEPRECATION_SOURCE_MANUAL] != 1 {
+		t.Errorf("Unexpected counts by source: %+v", status.BySource)
+	}
+	if status.Refs["flutter/flutter@stable"] != 1 || status.Refs["flutter/flutter@master"] != 1 || status.Refs["flutter/packages@main"] != 1 || len(status.Refs) != 3 {
+		t.Errorf("Unexpected scanned refs: %+v", status.Refs)
+	}
+
+	if err := cache.Save(&models.DeprecationCache{LastUpdated: time.Now().Add(-config.CACHE_DURATION)}); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ = cache.Status(); !status.Expired {
+		t.Errorf("Expected a cache as old as the TTL to be expired, got %+v", status)
+	}
 }
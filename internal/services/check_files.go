@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// CheckFiles checks a batch of files and snippets, returning one result per entry in order.
+// Relative paths are read from projectPath; an entry that cannot be read reports its error
+// instead of failing the whole batch. target selects the Flutter (default) or Dart checks.
+func (d *DeprecationService) CheckFiles(ctx context.Context, entries []models.CheckFileEntry, projectPath string, target string) ([]models.FileCheckResult, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no files given")
+	}
+	if len(entries) > config.MAX_CHECK_FILES {
+		return nil, fmt.Errorf("%d files given, at most %d can be checked in one call", len(entries), config.MAX_CHECK_FILES)
+	}
+
+	results := make([]models.FileCheckResult, 0, len(entries))
+	names := make(map[string]int)
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := models.FileCheckResult{Name: strings.TrimSpace(entry.Name), Path: strings.TrimSpace(entry.Path)}
+		if result.Name == "" {
+			result.Name = result.Path
+		}
+		if result.Name == "" {
+			result.Name = fmt.Sprintf("snippet %d", i+1)
+		}
+		// Results are keyed by name, so repeated names are numbered
+		if names[result.Name]++; names[result.Name] > 1 {
+			result.Name = fmt.Sprintf("%s (%d)", result.Name, names[result.Name])
+		}
+
+		if err := fileScanSlots.acquire(ctx); err != nil {
+			return nil, err
+		}
+		code := entry.Code
+		switch {
+		case code != "" && result.Path != "":
+			result.Error = "give either path or code, not both"
+		case code == "" && result.Path == "":
+			result.Error = "no path or code given"
+		case code == "":
+			var err error
+			if code, err = readCheckFile(result.Path, projectPath); err != nil {
+				result.Error = err.Error()
+			}
+		default:
+			var err error
+			if code, err = DecodeCode(code, entry.Encoding); err != nil {
+				result.Error = err.Error()
+			}
+		}
+		if result.Error == "" {
+			result.Deprecations = d.checkCodeForTarget(code, target)
+		}
+		fileScanSlots.release()
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// readCheckFile reads a file to check, resolving relative paths against projectPath and refusing
+// files over MAX_CHECK_FILE_SIZE
+func readCheckFile(path string, projectPath string) (string, error) {
+	if !filepath.IsAbs(path) && projectPath != "" {
+		path = filepath.Join(projectPath, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > config.MAX_CHECK_FILE_SIZE {
+		return "", fmt.Errorf("%s is %d bytes, larger than the %d byte limit", path, info.Size(), config.MAX_CHECK_FILE_SIZE)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestCheckFiles(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, "lib"), 0755)
+	os.WriteFile(filepath.Join(project, "lib", "home.dart"), []byte("RaisedButton(onPressed: save)\n"), 0644)
+	os.WriteFile(filepath.Join(project, "lib", "huge.dart"), make([]byte, config.MAX_CHECK_FILE_SIZE+1), 0644)
+
+	t.Run("Checks each entry separately", func(t *testing.T) {
+		results, err := depService.CheckFiles(context.Background(), []models.CheckFileEntry{
+			{Path: "lib/home.dart"},
+			{Name: "theme", Code: "Color.red.withOpacity(0.5)"},
+			{Name: "theme", Code: "Text('fine')"},
+			{Code: "Text('fine')"},
+			{Path: "lib/missing.dart"},
+			{Path: "lib/huge.dart"},
+			{Path: "lib/home.dart", Code: "Text('fine')"},
+		}, project, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(results) != 7 {
+			t.Fatalf("Expected one result per entry, got %+v", results)
+		}
+
+		if results[0].Name != "lib/home.dart" || len(results[0].Deprecations) != 1 || results[0].Deprecations[0].API != "RaisedButton" {
+			t.Errorf("Expected RaisedButton in lib/home.dart, got %+v", results[0])
+		}
+		if results[1].Name != "theme" || len(results[1].Deprecations) != 1 || results[1].Deprecations[0].API != "Color.withOpacity" {
+			t.Errorf("Expected withOpacity in the theme snippet, got %+v", results[1])
+		}
+		if results[2].Name != "theme (2)" || len(results[2].Deprecations) != 0 {
+			t.Errorf("Expected the repeated name to be numbered, got %+v", results[2])
+		}
+		if results[3].Name != "snippet 4" {
+			t.Errorf("Expected an unnamed snippet to be named by position, got %q", results[3].Name)
+		}
+		if results[4].Error == "" || results[4].Deprecations != nil {
+			t.Errorf("Expected the missing file to report an error, got %+v", results[4])
+		}
+		if !strings.Contains(results[5].Error, "byte limit") {
+			t.Errorf("Expected the oversized file to be refused, got %q", results[5].Error)
+		}
+		if !strings.Contains(results[6].Error, "either path or code") {
+			t.Errorf("Expected an entry with path and code to be rejected, got %q", results[6].Error)
+		}
+	})
+
+	t.Run("Applies only the Dart checks to Dart code", func(t *testing.T) {
+		results, err := depService.CheckFiles(context.Background(), []models.CheckFileEntry{
+			{Path: "lib/home.dart"},
+			{Code: "final data = JSON.decode(body);"},
+		}, project, "dart")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(results[0].Deprecations) != 0 {
+			t.Errorf("Expected the Flutter rules to be skipped, got %+v", results[0].Deprecations)
+		}
+		if len(results[1].Deprecations) != 1 || results[1].Deprecations[0].API != "JSON" {
+			t.Errorf("Expected the dart:convert constant to be reported, got %+v", results[1].Deprecations)
+		}
+
+		if _, err := depService.CheckFiles(context.Background(), []models.CheckFileEntry{{Path: "lib/home.dart"}}, project, "kotlin"); err == nil || !strings.Contains(err.Error(), "unknown target") {
+			t.Errorf("Expected an unknown target error, got %v", err)
+		}
+	})
+
+	t.Run("Limits the batch size", func(t *testing.T) {
+		if _, err := depService.CheckFiles(context.Background(), nil, "", ""); err == nil {
+			t.Error("Expected an error without entries")
+		}
+		entries := make([]models.CheckFileEntry, config.MAX_CHECK_FILES+1)
+		if _, err := depService.CheckFiles(context.Background(), entries, "", ""); err == nil || !strings.Contains(err.Error(), "at most") {
+			t.Errorf("Expected the batch limit error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// flutterActions maps the GitHub Actions that install Flutter to the input holding the version
+var flutterActions = map[string]string{
+	"subosito/flutter-action":       "flutter-version",
+	"flutter-actions/setup-flutter": "version",
+}
+
+// retiredFlutterImages maps Flutter images that are no longer updated to their successors
+var retiredFlutterImages = map[string]string{
+	"cirrusci/flutter": config.DOCKER_IMAGE_CIRRUSLABS,
+}
+
+// matrixReferencePattern matches a workflow expression such as ${{ matrix.flutter-version }}
+var matrixReferencePattern = regexp.MustCompile(`^\$\{\{\s*matrix\.([\w-]+)\s*\}\}$`)
+
+// CIWorkflowService reports the Flutter versions pinned in GitHub Actions and GitLab CI files
+type CIWorkflowService struct {
+	apiService FlutterAPIServiceInterface
+}
+
+// NewCIWorkflowService creates a new CI workflow service instance
+func NewCIWorkflowService(apiService FlutterAPIServiceInterface) *CIWorkflowService {
+	return &CIWorkflowService{apiService: apiService}
+}
+
+// CheckPath checks a CI file, or every GitHub workflow and .gitlab-ci.yml in a project directory
+func (c *CIWorkflowService) CheckPath(ctx context.Context, path string) (*models.CIWorkflowReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	root := filepath.Dir(path)
+	if info.IsDir() {
+		root = path
+		files = nil
+		for _, pattern := range []string{".github/workflows/*.yml", ".github/workflows/*.yaml", ".gitlab-ci.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(path, pattern))
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .github/workflows files or .gitlab-ci.yml found in %s", path)
+		}
+	}
+
+	var pins []models.CIFlutterPin
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		relative, err := filepath.Rel(root, file)
+		if err != nil {
+			relative = file
+		}
+		found, err := parseCIFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", relative, err)
+		}
+		for i := range found {
+			found[i].File = relative
+		}
+		pins = append(pins, found...)
+	}
+
+	return c.report(ctx, len(files), pins), nil
+}
+
+// CheckContent checks the contents of a single CI file
+func (c *CIWorkflowService) CheckContent(ctx context.Context, content string) (*models.CIWorkflowReport, error) {
+	pins, err := parseCIFile([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	return c.report(ctx, 1, pins), nil
+}
+
+// report classifies every pin against the latest stable release and the published versions
+func (c *CIWorkflowService) report(ctx context.Context, filesScanned int, pins []models.CIFlutterPin) *models.CIWorkflowReport {
+	report := &models.CIWorkflowReport{FilesScanned: filesScanned, Pins: pins}
+
+	latest, err := c.apiService.GetLatestStableVersion(ctx)
+	if err == nil && latest == "" {
+		err = fmt.Errorf("no stable release found")
+	}
+	if err != nil {
+		report.LatestError = err.Error()
+	}
+	report.LatestStable = latest
+
+	// Any released version can be installed by the setup actions, whatever its channel
+	published := make(map[string]bool)
+	if releases, err := c.apiService.FetchOfficialReleases(ctx); err == nil {
+		for _, release := range releases.Releases {
+			published[release.Version] = true
+		}
+	}
+
+	for i := range report.Pins {
+		if report.Pins[i].Status == "" {
+			c.classify(ctx, &report.Pins[i], latest, published)
+		}
+	}
+
+	sort.SliceStable(report.Pins, func(i, j int) bool {
+		if report.Pins[i].File != report.Pins[j].File {
+			return report.Pins[i].File < report.Pins[j].File
+		}
+		return report.Pins[i].Line < report.Pins[j].Line
+	})
+	return report
+}
+
+// classify sets the status of a pin and suggests an update where one is needed
+func (c *CIWorkflowService) classify(ctx context.Context, pin *models.CIFlutterPin, latest string, published map[string]bool) {
+	_, isAction := flutterActions[pin.Source]
+	version := strings.TrimPrefix(strings.TrimSpace(pin.Version), "v")
+	example := latest
+	if example == "" {
+		example = "3.x.y"
+	}
+
+	if successor, retired := retiredFlutterImages[pin.Source]; retired {
+		pin.Status = config.CI_PIN_OUTDATED
+		pin.Suggestion = fmt.Sprintf("%s is no longer updated; switch to %s:%s", pin.Source, successor, example)
+		return
+	}
+
+	switch {
+	case version == "" || version == "latest" || version == "stable" || version == "any":
+		pin.Status = config.CI_PIN_FLOATING
+		pin.Suggestion = fmt.Sprintf("Pin an exact version such as %s for reproducible builds", example)
+		return
+	case strings.ContainsAny(version, "x*"):
+		pin.Status = config.CI_PIN_FLOATING
+		prefix := strings.TrimRight(version[:strings.IndexAny(version, "x*")], ".")
+		if latest != "" && !strings.HasPrefix(latest, prefix+".") {
+			pin.Status = config.CI_PIN_OUTDATED
+			pin.Suggestion = fmt.Sprintf("%s no longer matches the latest stable release; use %s", pin.Version, latest)
+		}
+		return
+	}
+
+	// Image tags are checked against their registry, action versions against the releases list
+	if !isAction {
+		if isCheckedFlutterImage(pin.Source) && !c.apiService.CheckDockerImageExists(ctx, pin.Source, version) {
+			pin.Status = config.CI_PIN_UNAVAILABLE
+			pin.Suggestion = fmt.Sprintf("%s:%s is not published", pin.Source, version)
+			if latest != "" {
+				pin.Suggestion += fmt.Sprintf("; use %s:%s", pin.Source, latest)
+			}
+			return
+		}
+	} else if len(published) > 0 && !published[version] {
+		pin.Status = config.CI_PIN_UNAVAILABLE
+		pin.Suggestion = fmt.Sprintf("Flutter %s was never released", version)
+		if latest != "" {
+			pin.Suggestion += fmt.Sprintf("; use %s", latest)
+		}
+		return
+	}
+
+	cmp, ok := CompareVersions(version, latest)
+	switch {
+	case latest == "" || !ok:
+		pin.Status = config.CI_PIN_UNKNOWN
+	case cmp < 0:
+		pin.Status = config.CI_PIN_OUTDATED
+		if isAction {
+			pin.Suggestion = fmt.Sprintf("Update %s to %s", flutterActions[pin.Source], latest)
+		} else {
+			pin.Suggestion = fmt.Sprintf("Update to %s:%s", pin.Source, latest)
+		}
+	default:
+		pin.Status = config.CI_PIN_CURRENT
+	}
+}
+
+// isCheckedFlutterImage reports whether the server can check the tags of an image
+func isCheckedFlutterImage(image string) bool {
+	return image == config.DOCKER_IMAGE_INSTRUMENTISTO || image == config.DOCKER_IMAGE_CIRRUSLABS
+}
+
+// parseCIFile finds the Flutter version pins in a GitHub Actions workflow or a GitLab CI file
+func parseCIFile(data []byte) ([]models.CIFlutterPin, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a YAML mapping at the top level")
+	}
+
+	if jobs := yamlValue(root, "jobs"); jobs != nil && jobs.Kind == yaml.MappingNode {
+		return githubPins(jobs), nil
+	}
+	return gitlabPins(root), nil
+}
+
+// githubPins collects setup action versions and Flutter container images from workflow jobs
+func githubPins(jobs *yaml.Node) []models.CIFlutterPin {
+	var pins []models.CIFlutterPin
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		name, job := jobs.Content[i].Value, jobs.Content[i+1]
+		if job.Kind != yaml.MappingNode {
+			continue
+		}
+
+		pins = append(pins, imagePins(name, yamlValue(job, "container"))...)
+		if services := yamlValue(job, "services"); services != nil && services.Kind == yaml.MappingNode {
+			for j := 1; j < len(services.Content); j += 2 {
+				pins = append(pins, imagePins(name, services.Content[j])...)
+			}
+		}
+
+		steps := yamlValue(job, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		matrix := yamlValue(yamlValue(job, "strategy"), "matrix")
+		for _, step := range steps.Content {
+			uses := yamlValue(step, "uses")
+			if uses == nil {
+				continue
+			}
+			action, _, _ := strings.Cut(uses.Value, "@")
+			input, ok := flutterActions[action]
+			if !ok {
+				continue
+			}
+
+			with := yamlValue(step, "with")
+			pin := models.CIFlutterPin{Job: name, Line: uses.Line, Source: action}
+			if channel := yamlValue(with, "channel"); channel != nil {
+				pin.Channel = channel.Value
+			}
+			if file := yamlValue(with, "flutter-version-file"); file != nil {
+				pin.Line = file.Line
+				pin.Status = config.CI_PIN_UNKNOWN
+				pin.Suggestion = fmt.Sprintf("The version is read from %s; check its environment constraints", file.Value)
+				pins = append(pins, pin)
+				continue
+			}
+
+			version := yamlValue(with, input)
+			if version == nil {
+				pins = append(pins, pin)
+				continue
+			}
+			pin.Line = version.Line
+			for _, value := range matrixValues(version.Value, matrix) {
+				pin.Version = value
+				pins = append(pins, pin)
+			}
+		}
+	}
+	return pins
+}
+
+// gitlabPins collects the Flutter images used by the default image and by every job
+func gitlabPins(root *yaml.Node) []models.CIFlutterPin {
+	pins := imagePins("", yamlValue(root, "image"))
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, job := root.Content[i].Value, root.Content[i+1]
+		if job.Kind != yaml.MappingNode {
+			continue
+		}
+		pins = append(pins, imagePins(name, yamlValue(job, "image"))...)
+		if services := yamlValue(job, "services"); services != nil && services.Kind == yaml.SequenceNode {
+			for _, service := range services.Content {
+				pins = append(pins, imagePins(name, service)...)
+			}
+		}
+	}
+	return pins
+}
+
+// imagePins returns a pin for an image reference that ships Flutter. The reference is either a
+// string or a mapping with an image (GitHub) or name (GitLab) key.
+func imagePins(job string, node *yaml.Node) []models.CIFlutterPin {
+	if node != nil && node.Kind == yaml.MappingNode {
+		if image := yamlValue(node, "image"); image != nil {
+			node = image
+		} else {
+			node = yamlValue(node, "name")
+		}
+	}
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return nil
+	}
+
+	image, tag := splitImage(node.Value)
+	if !strings.HasSuffix(image, "/flutter") {
+		return nil
+	}
+	return []models.CIFlutterPin{{Job: job, Line: node.Line, Source: image, Version: tag}}
+}
+
+// splitImage separates an image reference into its repository and tag, ignoring registry ports
+func splitImage(reference string) (string, string) {
+	reference = strings.TrimPrefix(reference, "docker.io/")
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i], reference[i+1:]
+	}
+	return reference, ""
+}
+
+// matrixValues expands a ${{ matrix.name }} reference into the values the job matrix lists for it
+func matrixValues(value string, matrix *yaml.Node) []string {
+	matches := matrixReferencePattern.FindStringSubmatch(value)
+	if matches == nil || matrix == nil {
+		return []string{value}
+	}
+
+	var values []string
+	if list := yamlValue(matrix, matches[1]); list != nil && list.Kind == yaml.SequenceNode {
+		for _, item := range list.Content {
+			values = append(values, item.Value)
+		}
+	}
+	if include := yamlValue(matrix, "include"); include != nil && include.Kind == yaml.SequenceNode {
+		for _, entry := range include.Content {
+			if item := yamlValue(entry, matches[1]); item != nil {
+				values = append(values, item.Value)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return []string{value}
+	}
+	return values
+}
+
+// yamlValue returns the value stored under key in a mapping node, or nil
+func yamlValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+const githubWorkflow = `name: CI
+on: [push]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        flutter-version: ["3.24.5", "3.27.1"]
+    steps:
+      - uses: actions/checkout@v4
+      - uses: subosito/flutter-action@v2
+        with:
+          flutter-version: ${{ matrix.flutter-version }}
+          channel: stable
+      - run: flutter test
+  analyze:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: subosito/flutter-action@v2
+        with:
+          channel: stable
+  build:
+    runs-on: ubuntu-latest
+    container:
+      image: instrumentisto/flutter:3.21.9
+    steps:
+      - uses: subosito/flutter-action@v2
+        with:
+          flutter-version: 3.99.0
+`
+
+const gitlabCI = `image: cirrusci/flutter:3.7.0
+
+stages: [test]
+
+test:
+  image:
+    name: ghcr.io/cirruslabs/flutter:3.x
+  script:
+    - flutter test
+
+build:
+  image: ghcr.io/cirruslabs/flutter:3.27.1
+  script:
+    - flutter build web
+`
+
+func TestCIWorkflowService(t *testing.T) {
+	apiService := &MockFlutterAPIService{
+		latestStable: "3.27.1",
+		officialReleases: &models.FlutterReleasesResponse{
+			Releases: []models.FlutterOfficialRelease{
+				{Channel: "stable", Version: "3.27.1"},
+				{Channel: "stable", Version: "3.24.5"},
+				{Channel: "stable", Version: "3.21.9"},
+			},
+		},
+		dockerResults: map[string]bool{
+			config.DOCKER_IMAGE_CIRRUSLABS + ":3.27.1": true,
+		},
+	}
+	service := NewCIWorkflowService(apiService)
+	ctx := context.Background()
+
+	t.Run("GitHub Actions workflow", func(t *testing.T) {
+		report, err := service.CheckContent(ctx, githubWorkflow)
+		if err != nil {
+			t.Fatalf("CheckContent failed: %v", err)
+		}
+		if report.LatestStable != "3.27.1" {
+			t.Errorf("Expected latest stable 3.27.1, got %q", report.LatestStable)
+		}
+
+		expected := []struct{ job, version, status string }{
+			{"test", "3.24.5", config.CI_PIN_OUTDATED},
+			{"test", "3.27.1", config.CI_PIN_CURRENT},
+			{"analyze", "", config.CI_PIN_FLOATING},
+			{"build", "3.21.9", config.CI_PIN_UNAVAILABLE},
+			{"build", "3.99.0", config.CI_PIN_UNAVAILABLE},
+		}
+		if len(report.Pins) != len(expected) {
+			t.Fatalf("Expected %d pins, got %+v", len(expected), report.Pins)
+		}
+		for _, want := range expected {
+			found := false
+			for _, pin := range report.Pins {
+				if pin.Job == want.job && pin.Version == want.version {
+					found = true
+					if pin.Status != want.status {
+						t.Errorf("Expected %s %q to be %s, got %s (%s)", want.job, want.version, want.status, pin.Status, pin.Suggestion)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Expected a pin for %s %q", want.job, want.version)
+			}
+		}
+
+		for _, pin := range report.Pins {
+			if pin.Version == "3.24.5" && (pin.Line != 13 || pin.Suggestion != "Update flutter-version to 3.27.1") {
+				t.Errorf("Unexpected matrix pin %+v", pin)
+			}
+			if pin.Source == config.DOCKER_IMAGE_INSTRUMENTISTO && !strings.Contains(pin.Suggestion, "instrumentisto/flutter:3.27.1") {
+				t.Errorf("Expected an image update suggestion, got %q", pin.Suggestion)
+			}
+		}
+	})
+
+	t.Run("GitLab CI images", func(t *testing.T) {
+		report, err := service.CheckContent(ctx, gitlabCI)
+		if err != nil {
+			t.Fatalf("CheckContent failed: %v", err)
+		}
+		if len(report.Pins) != 3 {
+			t.Fatalf("Expected 3 pins, got %+v", report.Pins)
+		}
+		if pin := report.Pins[0]; pin.Source != "cirrusci/flutter" || pin.Status != config.CI_PIN_OUTDATED || !strings.Contains(pin.Suggestion, config.DOCKER_IMAGE_CIRRUSLABS) {
+			t.Errorf("Expected the retired cirrusci image to be flagged, got %+v", pin)
+		}
+		if pin := report.Pins[1]; pin.Job != "test" || pin.Status != config.CI_PIN_FLOATING {
+			t.Errorf("Expected a floating 3.x tag, got %+v", pin)
+		}
+		if pin := report.Pins[2]; pin.Job != "build" || pin.Status != config.CI_PIN_CURRENT {
+			t.Errorf("Expected a current image, got %+v", pin)
+		}
+	})
+
+	t.Run("CheckPath scans a project directory", func(t *testing.T) {
+		dir := t.TempDir()
+		workflows := filepath.Join(dir, ".github", "workflows")
+		if err := os.MkdirAll(workflows, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(workflows, "ci.yaml"), []byte(githubWorkflow), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".gitlab-ci.yml"), []byte(gitlabCI), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := service.CheckPath(ctx, dir)
+		if err != nil {
+			t.Fatalf("CheckPath failed: %v", err)
+		}
+		if report.FilesScanned != 2 || len(report.Pins) != 8 {
+			t.Errorf("Expected 8 pins from 2 files, got %d from %d", len(report.Pins), report.FilesScanned)
+		}
+		if report.Pins[0].File != ".github/workflows/ci.yaml" {
+			t.Errorf("Expected paths relative to the project, got %q", report.Pins[0].File)
+		}
+
+		if _, err := service.CheckPath(ctx, t.TempDir()); err == nil {
+			t.Error("Expected an error for a directory without CI files")
+		}
+	})
+
+	t.Run("unknown latest release", func(t *testing.T) {
+		report, err := NewCIWorkflowService(&MockFlutterAPIService{}).CheckContent(ctx, githubWorkflow)
+		if err != nil {
+			t.Fatalf("CheckContent failed: %v", err)
+		}
+		if report.LatestError == "" {
+			t.Error("Expected the missing latest release to be reported")
+		}
+		for _, pin := range report.Pins {
+			if pin.Version == "3.27.1" && pin.Status != config.CI_PIN_UNKNOWN {
+				t.Errorf("Expected an unknown status without a latest release, got %s", pin.Status)
+			}
+		}
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		if _, err := service.CheckContent(ctx, "jobs: [unclosed"); err == nil {
+			t.Error("Expected an error for invalid YAML")
+		}
+	})
+}
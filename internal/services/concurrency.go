@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// slots bounds how many callers hold one at a time. A nil slots never blocks, which is what a
+// limit of 0 means.
+type slots chan struct{}
+
+// newSlots returns n slots, or nil when n is not positive
+func newSlots(n int) slots {
+	if n <= 0 {
+		return nil
+	}
+	return make(slots, n)
+}
+
+// acquire waits for a free slot until ctx ends
+func (s slots) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (s slots) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// execSlots and fileScanSlots are shared by every service, so that their limits hold for the whole
+// server however many tool calls run at once
+var (
+	execSlots     slots
+	fileScanSlots slots
+)
+
+// SetExecConcurrency runs at most n external commands, such as flutter, fvm and git, at once; the
+// others wait for a free slot. 0 removes the limit. Call it before the services are used.
+func SetExecConcurrency(n int) {
+	execSlots = newSlots(n)
+}
+
+// SetFileScanConcurrency checks at most n files at once in the project, file and SDK scans; each
+// also reads its file while it holds the slot. 0 removes the limit. Call it before the services are
+// used.
+func SetFileScanConcurrency(n int) {
+	fileScanSlots = newSlots(n)
+}
+
+// limitTransport lets at most a fixed number of requests be in flight at once, counting a request
+// until its response body is closed
+type limitTransport struct {
+	base  http.RoundTripper
+	slots slots
+}
+
+// NewLimitTransport wraps base so that at most n requests are in flight at once; the others wait
+// for a free slot until their context ends. n of 0 returns base unchanged. Wrap the transport that
+// talks to the network, below the mirror transport, so requests air-gapped mode refuses do not
+// wait for a slot.
+func NewLimitTransport(base http.RoundTripper, n int) http.RoundTripper {
+	if n <= 0 {
+		return base
+	}
+	return &limitTransport{base: base, slots: newSlots(n)}
+}
+
+// RoundTrip implements http.RoundTripper
+func (l *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := l.slots.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := l.base.RoundTrip(req)
+	if err != nil {
+		l.slots.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: l.slots.release}
+	return resp, nil
+}
+
+// releasingBody frees the slot of its request once it is closed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestLimitTransport(t *testing.T) {
+	var inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			if old := atomic.LoadInt32(&peak); n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewLimitTransport(http.DefaultTransport, 2)}
+	done := make(chan error)
+	for i := 0; i < 6; i++ {
+		go func() {
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			done <- err
+		}()
+	}
+	for i := 0; i < 6; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", peak)
+	}
+
+	// A request waiting for a slot gives up with its context
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	second, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Body.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("Expected the request to time out waiting for a slot while both are held")
+	}
+
+	if NewLimitTransport(http.DefaultTransport, 0) != http.DefaultTransport {
+		t.Error("Expected a limit of 0 to leave the transport alone")
+	}
+}
+
+func TestExecConcurrency(t *testing.T) {
+	SetExecConcurrency(1)
+	defer SetExecConcurrency(0)
+
+	if err := execSlots.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := runExec(ctx, 0, "true"); err != context.DeadlineExceeded {
+		t.Errorf("Expected the command to wait for the held slot, got %v", err)
+	}
+	execSlots.release()
+
+	if _, err := runExec(context.Background(), 0, "true"); err != nil {
+		t.Errorf("Expected the command to run once the slot is free, got %v", err)
+	}
+	if len(execSlots) != 0 {
+		t.Errorf("Expected the slot to be released, %d held", len(execSlots))
+	}
+}
+
+func TestFileScanConcurrency(t *testing.T) {
+	SetFileScanConcurrency(1)
+	defer SetFileScanConcurrency(0)
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	entries := []models.CheckFileEntry{{Name: "main.dart", Code: "RaisedButton();"}}
+
+	if err := fileScanSlots.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := service.CheckFiles(ctx, entries, "", ""); err != context.DeadlineExceeded {
+		t.Errorf("Expected the check to wait for the held slot, got %v", err)
+	}
+	fileScanSlots.release()
+
+	results, err := service.CheckFiles(context.Background(), entries, "", "")
+	if err != nil || len(results) != 1 || len(results[0].Deprecations) == 0 {
+		t.Errorf("Expected the check to run once the slot is free, got %+v, %v", results, err)
+	}
+	if len(fileScanSlots) != 0 {
+		t.Errorf("Expected the slot to be released, %d held", len(fileScanSlots))
+	}
+}
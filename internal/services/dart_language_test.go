@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestDartLanguageRules(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+
+	t.Run("Detects outdated Dart syntax", func(t *testing.T) {
+		code := "// @dart=2.9\n" +
+			"typedef void OnTap(int index);\n" +
+			"class Tile extends StatelessWidget {\n" +
+			"  Tile({@required this.label, int count: 0});\n" +
+			"  Widget build(BuildContext context) => new Text(label);\n" +
+			"}\n" +
+			"final items = List<String>();\n"
+
+		found := make(map[string]bool)
+		for _, dep := range depService.CheckCodeForDeprecations(code) {
+			found[dep.API] = true
+		}
+		for _, api := range []string{"// @dart=2.x", "new keyword", "List()", "@required", "named parameter default with :", "typedef ReturnType Name(...)"} {
+			if !found[api] {
+				t.Errorf("Expected %q to be detected, got %v", api, found)
+			}
+		}
+
+		modern := "typedef OnTap = void Function(int index);\n" +
+			"Tile({required this.label, int count = 0});\n" +
+			"final items = <String>[];\n" +
+			"switch (value) { case Mode.dark: break; }\n" +
+			"Padding(padding: EdgeInsets.zero, child: child)\n"
+		if result := depService.CheckCodeForDeprecations(modern); len(result) != 0 {
+			t.Errorf("Expected modern syntax to pass, got %+v", result)
+		}
+	})
+
+	t.Run("Migrates what can be rewritten", func(t *testing.T) {
+		code := "// @dart=2.9\n" +
+			"typedef void OnTap(int index);\n" +
+			"Tile({@required this.label, int count: 0});\n" +
+			"final items = new List<String>();\n" +
+			"final text = new Text('Hi');\n"
+
+		result := depService.MigrateCode(code)
+
+		expected := "// @dart=2.9\n" +
+			"typedef OnTap = void Function(int index);\n" +
+			"Tile({required this.label, int count = 0});\n" +
+			"final items = <String>[];\n" +
+			"final text = Text('Hi');\n"
+		if result.Code != expected {
+			t.Errorf("Expected rewritten code:\n%s\ngot:\n%s", expected, result.Code)
+		}
+		if len(result.Manual) != 1 || result.Manual[0].API != "// @dart=2.x" || result.Manual[0].Line != 1 {
+			t.Errorf("Expected the pragma to need a manual migration, got %+v", result.Manual)
+		}
+	})
+}
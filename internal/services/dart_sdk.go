@@ -0,0 +1,36 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ParseTarget normalizes the target argument of the check tools, defaulting to Flutter code
+func ParseTarget(target string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(target)) {
+	case "", config.TARGET_FLUTTER:
+		return config.TARGET_FLUTTER, nil
+	case config.TARGET_DART:
+		return config.TARGET_DART, nil
+	default:
+		return "", fmt.Errorf("unknown target %q, expected dart or flutter", target)
+	}
+}
+
+// CheckDartCode analyzes pure Dart code, such as a server or CLI package, for deprecated Dart SDK
+// APIs and syntax. The Flutter rules and the entries scanned from the Flutter sources are skipped;
+// manual entries and scanned repositories still apply, since they may be Dart packages.
+func (d *DeprecationService) CheckDartCode(code string) []models.Deprecation {
+	return d.checkRules(code, config.TARGET_DART)
+}
+
+// checkCodeForTarget runs the checks of a target returned by ParseTarget
+func (d *DeprecationService) checkCodeForTarget(code string, target string) []models.Deprecation {
+	if target == config.TARGET_DART {
+		return d.CheckDartCode(code)
+	}
+	return d.CheckCodeForDeprecations(code)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestDartSDKRules(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+
+	code := "import 'dart:html';\n" +
+		"import 'package:shelf/shelf.dart';\n" +
+		"final payload = JSON.decode(body);\n" +
+		"final bytes = UTF8.encode(text);\n" +
+		"final port = int.parse(value, onError: (_) => 8080);\n" +
+		"if (status == HttpStatus.NOT_FOUND) {}\n" +
+		"try { run(); } on CastError catch (e) { print(e); }\n" +
+		"Widget build() => RaisedButton(child: child);\n" +
+		"final items = new List<String>();\n"
+
+	t.Run("Detects deprecated Dart SDK APIs", func(t *testing.T) {
+		found := make(map[string]bool)
+		for _, dep := range depService.CheckDartCode(code) {
+			found[dep.API] = true
+		}
+		for _, api := range []string{"dart:html", "JSON", "UTF8", "int.parse(onError:)", "HttpStatus.UPPER_CASE", "CastError", "new keyword", "List()"} {
+			if !found[api] {
+				t.Errorf("Expected %q to be detected, got %v", api, found)
+			}
+		}
+		if found["RaisedButton"] {
+			t.Error("Expected the Flutter rules to be skipped for Dart code")
+		}
+
+		modern := "import 'package:web/web.dart';\n" +
+			"final payload = json.decode(body);\n" +
+			"final port = int.tryParse(value) ?? 8080;\n" +
+			"if (status == HttpStatus.notFound) {}\n" +
+			"try { run(); } on TypeError catch (e) { print(e); }\n"
+		if result := depService.CheckDartCode(modern); len(result) != 0 {
+			t.Errorf("Expected modern Dart to pass, got %+v", result)
+		}
+	})
+
+	t.Run("Applies manual entries but not the cached Flutter entries", func(t *testing.T) {
+		err := cacheService.Save(&models.DeprecationCache{
+			Deprecations: []models.Deprecation{{API: "Shelf.serve", Source: config.DEPRECATION_SOURCE_FLUTTER}},
+			Manual:       []models.Deprecation{{API: "legacyHandler", Replacement: "handler", Source: config.DEPRECATION_SOURCE_MANUAL}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to save cache: %v", err)
+		}
+		found := make(map[string]bool)
+		for _, dep := range depService.CheckDartCode("Shelf.serve(legacyHandler);") {
+			found[dep.API] = true
+		}
+		if !found["legacyHandler"] || found["Shelf.serve"] {
+			t.Errorf("Expected only the manual entry, got %v", found)
+		}
+	})
+
+	t.Run("Migrates the renamed constants", func(t *testing.T) {
+		result := depService.MigrateCode("final payload = JSON.decode(body);\non CastError catch (e) {}\n")
+		if expected := "final payload = json.decode(body);\non TypeError catch (e) {}\n"; result.Code != expected {
+			t.Errorf("Expected rewritten code:\n%s\ngot:\n%s", expected, result.Code)
+		}
+	})
+
+	t.Run("Parses the target", func(t *testing.T) {
+		for input, expected := range map[string]string{"": config.TARGET_FLUTTER, "Flutter": config.TARGET_FLUTTER, " dart ": config.TARGET_DART} {
+			if got, err := ParseTarget(input); err != nil || got != expected {
+				t.Errorf("ParseTarget(%q): expected %s, got %s (%v)", input, expected, got, err)
+			}
+		}
+		if _, err := ParseTarget("kotlin"); err == nil {
+			t.Error("Expected an error for an unknown target")
+		}
+	})
+}
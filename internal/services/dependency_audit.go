@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// auditFetchWorkers is how many pub.dev packages a dependency audit looks up at once
+const auditFetchWorkers = 8
+
+// pubspecLock holds the parts of a pubspec.lock the dependency audit reads
+type pubspecLock struct {
+	Packages map[string]struct {
+		Dependency  string `yaml:"dependency"`
+		Source      string `yaml:"source"`
+		Version     string `yaml:"version"`
+		Description any    `yaml:"description"`
+	} `yaml:"packages"`
+}
+
+// lockedPackage is a hosted pub.dev package at the version a lock file locks
+type lockedPackage struct {
+	name    string
+	version string
+	direct  bool
+}
+
+// osvAdvisory is the part of an OSV advisory, as pub.dev serves them, that the audit reads
+type osvAdvisory struct {
+	ID               string   `json:"id"`
+	Aliases          []string `json:"aliases"`
+	Summary          string   `json:"summary"`
+	Withdrawn        string   `json:"withdrawn"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Affected []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+}
+
+// DependencyAuditService checks the pub.dev packages a project locks against the security
+// advisories pub.dev publishes for them
+type DependencyAuditService struct {
+	apiService *FlutterAPIService
+}
+
+// NewDependencyAuditService creates a new dependency audit service instance
+func NewDependencyAuditService(apiService *FlutterAPIService) *DependencyAuditService {
+	return &DependencyAuditService{apiService: apiService}
+}
+
+// Audit reads the pubspec.lock of the project at projectPath and of each package of a workspace,
+// and looks up the advisories of every hosted package they lock, direct and transitive. A package
+// that cannot be looked up is recorded in Errors instead of failing the audit.
+func (d *DependencyAuditService) Audit(ctx context.Context, projectPath string) (*models.DependencyAudit, error) {
+	dirs := []string{projectPath}
+	if _, packages, err := discoverPackages(ctx, projectPath, walkOptions{}); err == nil {
+		dirs = append(dirs, packages...)
+	}
+
+	audit := &models.DependencyAudit{Advisories: []models.DependencyAdvisory{}}
+	locked := make(map[string]*lockedPackage)
+	for _, dir := range dirs {
+		path := filepath.Join(dir, config.PUBSPEC_LOCK_FILE)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var lock pubspecLock
+		if err := yaml.Unmarshal(data, &lock); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", relativeSlash(projectPath, path), err)
+		}
+		audit.LockFiles = append(audit.LockFiles, relativeSlash(projectPath, path))
+		for name, entry := range lock.Packages {
+			if entry.Source != "hosted" || !hostedOnPubDev(entry.Description) {
+				continue
+			}
+			key := name + "@" + entry.Version
+			if locked[key] == nil {
+				locked[key] = &lockedPackage{name: name, version: entry.Version}
+			}
+			locked[key].direct = locked[key].direct || strings.HasPrefix(entry.Dependency, "direct")
+		}
+	}
+	if len(audit.LockFiles) == 0 {
+		return nil, fmt.Errorf("no %s in %s; run flutter pub get first", config.PUBSPEC_LOCK_FILE, projectPath)
+	}
+
+	packages := make([]lockedPackage, 0, len(locked))
+	for _, pkg := range locked {
+		packages = append(packages, *pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].name != packages[j].name {
+			return packages[i].name < packages[j].name
+		}
+		return packages[i].version < packages[j].version
+	})
+	if len(packages) > config.MAX_AUDIT_DEPENDENCIES {
+		audit.Errors = append(audit.Errors, fmt.Sprintf("only the first %d of %d locked packages were checked", config.MAX_AUDIT_DEPENDENCIES, len(packages)))
+		packages = packages[:config.MAX_AUDIT_DEPENDENCIES]
+	}
+	audit.Packages = len(packages)
+
+	found := make([][]models.DependencyAdvisory, len(packages))
+	errs := make([]error, len(packages))
+	workers := make(chan struct{}, auditFetchWorkers)
+	var wg sync.WaitGroup
+	for i := range packages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			found[i], errs[i] = d.checkPackage(ctx, packages[i])
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, pkg := range packages {
+		if errs[i] != nil {
+			audit.Errors = append(audit.Errors, fmt.Sprintf("%s %s: %v", pkg.name, pkg.version, errs[i]))
+			continue
+		}
+		audit.Advisories = append(audit.Advisories, found[i]...)
+	}
+	return audit, nil
+}
+
+// checkPackage returns the pub.dev advisories that affect the locked version of pkg
+func (d *DependencyAuditService) checkPackage(ctx context.Context, pkg lockedPackage) ([]models.DependencyAdvisory, error) {
+	version, ok := parsePackageVersion(pkg.version)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", pkg.version)
+	}
+	resp, err := d.apiService.get(ctx, fmt.Sprintf(config.PUB_ADVISORIES_URL, url.PathEscape(pkg.name)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("not found on pub.dev")
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("pub.dev returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Advisories []osvAdvisory `json:"advisories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid pub.dev response: %v", err)
+	}
+
+	var advisories []models.DependencyAdvisory
+	for _, advisory := range body.Advisories {
+		if advisory.Withdrawn != "" {
+			continue
+		}
+		affected, fixed := false, ""
+		for _, entry := range advisory.Affected {
+			if entry.Package.Name != pkg.name {
+				continue
+			}
+			for _, listed := range entry.Versions {
+				if v, ok := parsePackageVersion(listed); ok && v.Compare(version) == 0 {
+					affected = true
+				}
+			}
+			for _, r := range entry.Ranges {
+				if hit, fix := osvRangeAffects(r.Events, version); hit {
+					affected, fixed = true, fix
+				}
+			}
+		}
+		if !affected {
+			continue
+		}
+		severity := strings.ToLower(advisory.DatabaseSpecific.Severity)
+		advisories = append(advisories, models.DependencyAdvisory{
+			Package:  pkg.name,
+			Version:  pkg.version,
+			Direct:   pkg.direct,
+			ID:       advisory.ID,
+			Aliases:  advisory.Aliases,
+			Summary:  advisory.Summary,
+			Severity: severity,
+			URL:      advisoryURL(advisory.ID),
+			Fixed:    fixed,
+		})
+	}
+	return advisories, nil
+}
+
+// osvRangeAffects reports whether version falls into one of the introduced to fixed, or to
+// last_affected, spans of an OSV range, and the version that fixes it
+func osvRangeAffects(events []map[string]string, version semanticVersion) (bool, string) {
+	inside := false
+	for _, event := range events {
+		if introduced, ok := event["introduced"]; ok {
+			v, parsed := parsePackageVersion(introduced)
+			inside = introduced == "0" || parsed && version.Compare(v) >= 0
+			continue
+		}
+		if !inside {
+			continue
+		}
+		if fixed, ok := event["fixed"]; ok {
+			if v, parsed := parsePackageVersion(fixed); parsed && version.Compare(v) < 0 {
+				return true, fixed
+			}
+			inside = false
+		}
+		if last, ok := event["last_affected"]; ok {
+			if v, parsed := parsePackageVersion(last); parsed && version.Compare(v) <= 0 {
+				return true, ""
+			}
+			inside = false
+		}
+	}
+	return inside, ""
+}
+
+// hostedOnPubDev reports whether the description of a hosted lock entry points at pub.dev, which
+// older lock files leave out or name pub.dartlang.org
+func hostedOnPubDev(description any) bool {
+	entry, ok := description.(map[string]any)
+	if !ok {
+		return true
+	}
+	hosted, _ := entry["url"].(string)
+	return hosted == "" || strings.Contains(hosted, "pub.dev") || strings.Contains(hosted, "pub.dartlang.org")
+}
+
+// advisoryURL links a GitHub advisory to its GitHub page and any other advisory to OSV
+func advisoryURL(id string) string {
+	if strings.HasPrefix(id, "GHSA-") {
+		return fmt.Sprintf(config.GITHUB_ADVISORY_URL, id)
+	}
+	return fmt.Sprintf(config.ADVISORY_DETAILS_URL, id)
+}
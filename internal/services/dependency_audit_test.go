@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDependencyAudit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/packages/http/advisories":
+			w.Write([]byte(`{"advisories": [
+				{"id": "GHSA-4rgh-jx4f-qfcq", "aliases": ["CVE-2020-35669"], "summary": "CRLF injection in http",
+				 "database_specific": {"severity": "MODERATE"},
+				 "affected": [{"package": {"ecosystem": "Pub", "name": "http"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.13.3"}]}]}]},
+				{"id": "GHSA-wwww", "summary": "Withdrawn", "withdrawn": "2023-01-01T00:00:00Z",
+				 "affected": [{"package": {"name": "http"}, "ranges": [{"events": [{"introduced": "0"}]}]}]},
+				{"id": "OSV-old", "summary": "Only 0.11", "affected": [{"package": {"name": "http"}, "versions": ["0.11.0"]}]}
+			]}`))
+		case "/api/packages/archive/advisories":
+			w.Write([]byte(`{"advisories": [{"id": "OSV-2024-1", "summary": "Path traversal",
+				"affected": [{"package": {"name": "archive"}, "ranges": [{"events": [{"introduced": "3.0.0"}, {"last_affected": "3.3.1"}]}]}]}]}`))
+		case "/api/packages/path/advisories":
+			w.Write([]byte(`{"advisories": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	service := NewDependencyAuditService(&FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}})
+
+	lock := func(packages string) string {
+		return "packages:\n" + packages + "sdks:\n  dart: \">=3.0.0 <4.0.0\"\n"
+	}
+	hosted := func(name string, version string, dependency string) string {
+		return "  " + name + ":\n    dependency: \"" + dependency + "\"\n    description:\n      name: " + name + "\n      url: \"https://pub.dev\"\n    source: hosted\n    version: \"" + version + "\"\n"
+	}
+
+	t.Run("Reports the advisories of the locked versions", func(t *testing.T) {
+		project := t.TempDir()
+		writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: app\n")
+		writeFile(t, filepath.Join(project, "pubspec.lock"), lock(
+			hosted("http", "0.13.1", "direct main")+
+				hosted("archive", "3.3.1", "transitive")+
+				hosted("path", "1.9.0", "transitive")+
+				hosted("missing", "1.0.0", "transitive")+
+				"  flutter:\n    dependency: \"direct main\"\n    description: flutter\n    source: sdk\n    version: \"0.0.0\"\n"+
+				"  private:\n    dependency: \"direct main\"\n    description:\n      name: private\n      url: \"https://pub.example.com\"\n    source: hosted\n    version: \"1.0.0\"\n"))
+
+		audit, err := service.Audit(context.Background(), project)
+		if err != nil {
+			t.Fatalf("Audit failed: %v", err)
+		}
+		if audit.Packages != 4 || len(audit.LockFiles) != 1 || audit.LockFiles[0] != "pubspec.lock" {
+			t.Errorf("Expected the four pub.dev packages of pubspec.lock, got %+v", audit)
+		}
+		if len(audit.Advisories) != 2 {
+			t.Fatalf("Expected the archive and http advisories, got %+v", audit.Advisories)
+		}
+		archive, httpAdvisory := audit.Advisories[0], audit.Advisories[1]
+		if archive.Package != "archive" || archive.Direct || archive.Fixed != "" || archive.URL != "https://osv.dev/vulnerability/OSV-2024-1" {
+			t.Errorf("Expected the unfixed transitive archive advisory, got %+v", archive)
+		}
+		if httpAdvisory.ID != "GHSA-4rgh-jx4f-qfcq" || !httpAdvisory.Direct || httpAdvisory.Fixed != "0.13.3" || httpAdvisory.Severity != "moderate" || httpAdvisory.URL != "https://github.com/advisories/GHSA-4rgh-jx4f-qfcq" {
+			t.Errorf("Expected the direct http advisory fixed in 0.13.3, got %+v", httpAdvisory)
+		}
+		if len(audit.Errors) != 1 || !strings.Contains(audit.Errors[0], "missing 1.0.0: not found on pub.dev") {
+			t.Errorf("Expected the unknown package to be reported, got %v", audit.Errors)
+		}
+	})
+
+	t.Run("Reads the lock files of the workspace packages", func(t *testing.T) {
+		project := t.TempDir()
+		writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: root\n")
+		writeFile(t, filepath.Join(project, "packages", "a", "pubspec.yaml"), "name: a\n")
+		writeFile(t, filepath.Join(project, "packages", "a", "pubspec.lock"), lock(hosted("http", "1.2.0", "direct main")))
+		writeFile(t, filepath.Join(project, "packages", "b", "pubspec.yaml"), "name: b\n")
+		writeFile(t, filepath.Join(project, "packages", "b", "pubspec.lock"), lock(hosted("http", "1.2.0", "transitive")+hosted("path", "1.9.0", "direct main")))
+
+		audit, err := service.Audit(context.Background(), project)
+		if err != nil {
+			t.Fatalf("Audit failed: %v", err)
+		}
+		if strings.Join(audit.LockFiles, ",") != "packages/a/pubspec.lock,packages/b/pubspec.lock" || audit.Packages != 2 || len(audit.Advisories) != 0 {
+			t.Errorf("Expected each locked package to be checked once, got %+v", audit)
+		}
+	})
+
+	t.Run("Needs a lock file", func(t *testing.T) {
+		if _, err := service.Audit(context.Background(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "flutter pub get") {
+			t.Errorf("Expected a missing pubspec.lock error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ReleasesAgo counts the stable releases of the release table that shipped after the one that
+// first contained dep's deprecation, so 0 means the latest one. It returns false for entries
+// without a Flutter version, such as those of plugins or manual entries, and for the removed and
+// upcoming ones, which have no age to speak of.
+func ReleasesAgo(dep models.Deprecation) (int, bool) {
+	switch {
+	case dep.Version == "" || dep.Package != "" || dep.Removed || dep.Upcoming:
+		return 0, false
+	case dep.Source == config.DEPRECATION_SOURCE_MANUAL || dep.Source == config.DEPRECATION_SOURCE_REPO:
+		return 0, false
+	}
+
+	// The table is newest first; a pre-release version ships in the next stable release
+	ago := -1
+	for _, pair := range dartForFlutter {
+		cmp, ok := CompareVersions(pair.flutter, dep.Version)
+		if !ok {
+			return 0, false
+		}
+		if cmp < 0 {
+			break
+		}
+		ago++
+	}
+	return max(ago, 0), true
+}
+
+// DeprecationAge describes how many stable releases ago dep was deprecated, such as "deprecated
+// 6 releases ago, removal likely", or returns "" when ReleasesAgo does not know
+func DeprecationAge(dep models.Deprecation) string {
+	ago, ok := ReleasesAgo(dep)
+	switch {
+	case !ok:
+		return ""
+	case ago == 0:
+		return "deprecated in the latest stable release"
+	case ago == 1:
+		return "deprecated 1 release ago"
+	case ago < config.REMOVAL_LIKELY_RELEASES:
+		return fmt.Sprintf("deprecated %d releases ago", ago)
+	default:
+		return fmt.Sprintf("deprecated %d releases ago, removal likely", ago)
+	}
+}
+
+// ByUrgency returns deprecations ordered by how soon they need attention: the APIs removed
+// upstream first, then the oldest deprecations. Entries of unknown age follow, in their original
+// order. The slice passed in, which may be shared with a cache, is left as it is.
+func ByUrgency(deprecations []models.Deprecation) []models.Deprecation {
+	deprecations = slices.Clone(deprecations)
+	urgency := func(dep models.Deprecation) int {
+		if dep.Removed {
+			return len(dartForFlutter)
+		}
+		if ago, ok := ReleasesAgo(dep); ok {
+			return ago
+		}
+		return -1
+	}
+	sort.SliceStable(deprecations, func(i, j int) bool {
+		return urgency(deprecations[i]) > urgency(deprecations[j])
+	})
+	return deprecations
+}
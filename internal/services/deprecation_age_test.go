@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestDeprecationAge(t *testing.T) {
+	for _, test := range []struct {
+		dep      models.Deprecation
+		expected string
+	}{
+		{models.Deprecation{Version: "3.27.0"}, "deprecated in the latest stable release"},
+		{models.Deprecation{Version: "3.26.0-0.1.pre"}, "deprecated in the latest stable release"},
+		{models.Deprecation{Version: "3.32.0"}, "deprecated in the latest stable release"},
+		{models.Deprecation{Version: "3.24.0"}, "deprecated 1 release ago"},
+		{models.Deprecation{Version: "3.19.0-0.3.pre"}, "deprecated 3 releases ago"},
+		{models.Deprecation{Version: "2.0.0"}, "deprecated 14 releases ago, removal likely"},
+		{models.Deprecation{Version: "2.1.0", Package: "go_router"}, ""},
+		{models.Deprecation{Version: "1.0.0", Source: config.DEPRECATION_SOURCE_MANUAL}, ""},
+		{models.Deprecation{Version: "3.24.0", Upcoming: true}, ""},
+		{models.Deprecation{Version: "later"}, ""},
+		{models.Deprecation{}, ""},
+	} {
+		if got := DeprecationAge(test.dep); got != test.expected {
+			t.Errorf("DeprecationAge(%+v): expected %q, got %q", test.dep, test.expected, got)
+		}
+	}
+}
+
+func TestByUrgency(t *testing.T) {
+	deprecations := []models.Deprecation{
+		{API: "Manual", Source: config.DEPRECATION_SOURCE_MANUAL, Version: "1.0.0"},
+		{API: "Recent", Version: "3.27.0"},
+		{API: "Old", Version: "2.0.0"},
+		{API: "Gone", Version: "3.24.0", Removed: true},
+		{API: "Plugin", Package: "camera"},
+		{API: "Middle", Version: "3.16.0"},
+	}
+	sorted := ByUrgency(deprecations)
+	if deprecations[0].API != "Manual" {
+		t.Error("Expected the original slice to be left as it is")
+	}
+
+	var order []string
+	for _, dep := range sorted {
+		order = append(order, dep.API)
+	}
+	if expected := []string{"Gone", "Old", "Middle", "Recent", "Manual", "Plugin"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected %v, got %v", expected, order)
+	}
+}
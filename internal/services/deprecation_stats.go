@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// unknownStatsKey counts the entries that do not record a version, category, severity or source
+const unknownStatsKey = "unknown"
+
+// DeprecationStats summarizes the cached and manual deprecations and lists up to recent of the
+// most recently introduced ones, newest first
+func (d *DeprecationService) DeprecationStats(recent int) (*models.DeprecationStats, error) {
+	cache, err := d.cacheService.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.DeprecationStats{
+		LastUpdated: cache.LastUpdated,
+		Total:       len(cache.Deprecations) + len(cache.Manual),
+		Manual:      len(cache.Manual),
+		Removed:     countRemoved(cache.Deprecations),
+		Upcoming:    countUpcoming(cache.Deprecations),
+		ByVersion:   make(map[string]int),
+		ByCategory:  make(map[string]int),
+		BySeverity:  make(map[string]int),
+		BySource:    make(map[string]int),
+	}
+
+	type datedDeprecation struct {
+		deprecation models.Deprecation
+		version     semanticVersion
+	}
+	var dated []datedDeprecation
+
+	for _, entries := range [][]models.Deprecation{cache.Deprecations, cache.Manual} {
+		for _, dep := range entries {
+			release := unknownStatsKey
+			if version, ok := parseVersion(dep.Version); ok {
+				release = fmt.Sprintf("%d.%d", version.Major, version.Minor)
+				dated = append(dated, datedDeprecation{dep, version})
+			}
+			stats.ByVersion[release]++
+			stats.ByCategory[statsKey(dep.Category)]++
+			stats.BySeverity[statsKey(dep.Severity)]++
+			stats.BySource[statsKey(dep.Source)]++
+		}
+	}
+
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].version.Compare(dated[j].version) > 0
+	})
+	for _, entry := range dated {
+		if len(stats.Recent) >= recent {
+			break
+		}
+		stats.Recent = append(stats.Recent, entry.deprecation)
+	}
+
+	return stats, nil
+}
+
+// DeprecationPartitions splits the cached and manual deprecations by Flutter release (major.minor)
+// and category, the keys of the by_version and by_category counts of DeprecationStats. The
+// partitions are ordered newest release first, with the entries without a version last, and by
+// category within a release.
+func DeprecationPartitions(cache *models.DeprecationCache) []models.DeprecationPartition {
+	type partitionKey struct{ version, category string }
+	partitions := make(map[partitionKey]*models.DeprecationPartition)
+	lines := make(map[string]semanticVersion)
+	for _, entries := range [][]models.Deprecation{cache.Deprecations, cache.Manual} {
+		for _, dep := range entries {
+			key := partitionKey{unknownStatsKey, statsKey(dep.Category)}
+			if version, ok := parseVersion(dep.Version); ok {
+				key.version = fmt.Sprintf("%d.%d", version.Major, version.Minor)
+				lines[key.version] = semanticVersion{Major: version.Major, Minor: version.Minor}
+			}
+			if partitions[key] == nil {
+				partitions[key] = &models.DeprecationPartition{
+					URI:         DeprecationPartitionURI(key.version, key.category),
+					Version:     key.version,
+					Category:    key.category,
+					LastUpdated: cache.LastUpdated,
+				}
+			}
+			partitions[key].Deprecations = append(partitions[key].Deprecations, dep)
+		}
+	}
+
+	result := make([]models.DeprecationPartition, 0, len(partitions))
+	for _, partition := range partitions {
+		result = append(result, *partition)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Version != b.Version {
+			lineA, datedA := lines[a.Version]
+			lineB, datedB := lines[b.Version]
+			if datedA != datedB {
+				return datedA
+			}
+			return lineA.Compare(lineB) > 0
+		}
+		return a.Category < b.Category
+	})
+	return result
+}
+
+// DeprecationPartitionURI returns the URI of the resource serving the partition of a Flutter release
+// (major.minor) and category
+func DeprecationPartitionURI(version string, category string) string {
+	return fmt.Sprintf(config.DEPRECATIONS_RESOURCE_URI, url.PathEscape(version), url.PathEscape(category))
+}
+
+// statsKey groups entries without a value under unknownStatsKey
+func statsKey(value string) string {
+	if value == "" {
+		return unknownStatsKey
+	}
+	return value
+}
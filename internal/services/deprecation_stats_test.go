@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestDeprecationStats(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, &MockFlutterAPIService{})
+
+	if err := cacheService.Save(&models.DeprecationCache{
+		LastUpdated: time.Now(),
+		Deprecations: []models.Deprecation{
+			{API: "ColorScheme.background", Version: "3.18.0-0.1.pre", Category: "material", Severity: "warning", Source: "flutter_source"},
+			{API: "Color.withOpacity", Version: "3.27.0", Category: "dart:ui", Severity: "warning", Source: "builtin"},
+			{API: "ThemeData.dialogBackgroundColor", Version: "3.27.0-0.1.pre", Category: "material", Severity: "warning", Source: "flutter_source"},
+			{API: "RaisedButton", Version: "Multiple versions", Category: "material", Severity: "error", Source: "builtin"},
+		},
+		Manual: []models.Deprecation{
+			{API: "LegacyCard", Source: "manual", Severity: "warning"},
+		},
+	}); err != nil {
+		t.Fatalf("Expected no error saving cache, got %v", err)
+	}
+
+	stats, err := depService.DeprecationStats(2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if stats.Total != 5 || stats.Manual != 1 {
+		t.Errorf("Expected 5 entries with 1 manual, got %d/%d", stats.Total, stats.Manual)
+	}
+	for key, expected := range map[string]int{"3.27": 2, "3.18": 1, "unknown": 2} {
+		if stats.ByVersion[key] != expected {
+			t.Errorf("Expected %d entries for %s, got %v", expected, key, stats.ByVersion)
+		}
+	}
+	if stats.ByCategory["material"] != 3 || stats.ByCategory["unknown"] != 1 {
+		t.Errorf("Unexpected categories %v", stats.ByCategory)
+	}
+	if stats.BySeverity["warning"] != 4 || stats.BySeverity["error"] != 1 {
+		t.Errorf("Unexpected severities %v", stats.BySeverity)
+	}
+	if stats.BySource["builtin"] != 2 || stats.BySource["manual"] != 1 {
+		t.Errorf("Unexpected sources %v", stats.BySource)
+	}
+
+	if len(stats.Recent) != 2 || stats.Recent[0].API != "Color.withOpacity" || stats.Recent[1].API != "ThemeData.dialogBackgroundColor" {
+		t.Errorf("Expected the two 3.27 entries newest first, got %+v", stats.Recent)
+	}
+}
+
+func TestDeprecationPartitions(t *testing.T) {
+	partitions := DeprecationPartitions(&models.DeprecationCache{
+		Deprecations: []models.Deprecation{
+			{API: "ColorScheme.background", Version: "3.18.0-0.1.pre", Category: "material"},
+			{API: "Color.withOpacity", Version: "3.27.0", Category: "dart:ui"},
+			{API: "ThemeData.dialogBackgroundColor", Version: "3.27.0-0.1.pre", Category: "material"},
+			{API: "RaisedButton", Version: "Multiple versions", Category: "material"},
+			{API: "Old.api", Version: "3.3.0", Category: "widgets"},
+		},
+		Manual: []models.Deprecation{{API: "LegacyCard"}},
+	})
+
+	var uris []string
+	for _, partition := range partitions {
+		uris = append(uris, fmt.Sprintf("%s(%d)", partition.URI, len(partition.Deprecations)))
+	}
+	expected := []string{
+		"flutter-deprecations://deprecations/3.27/dart:ui(1)",
+		"flutter-deprecations://deprecations/3.27/material(1)",
+		"flutter-deprecations://deprecations/3.18/material(1)",
+		"flutter-deprecations://deprecations/3.3/widgets(1)",
+		"flutter-deprecations://deprecations/unknown/material(1)",
+		"flutter-deprecations://deprecations/unknown/unknown(1)",
+	}
+	if strings.Join(uris, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the partitions newest release first, got %v", uris)
+	}
+	if partitions[1].Version != "3.27" || partitions[1].Category != "material" || partitions[1].Deprecations[0].API != "ThemeData.dialogBackgroundColor" {
+		t.Errorf("Expected the 3.27 material partition to hold its entry, got %+v", partitions[1])
+	}
+}
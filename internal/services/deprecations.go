@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -15,55 +17,58 @@ import (
 type DeprecationService struct {
 	cacheService CacheServiceInterface
 	apiService   FlutterAPIServiceInterface
+	providers    []RuleProvider
+	stats        CacheRecorder
 }
 
-// NewDeprecationService creates a new deprecation service instance
+// NewDeprecationService creates a new deprecation service instance drawing on the built-in rules
+// and the manual and scanned entries of the cache; SetRuleProviders registers others
 func NewDeprecationService(cacheService CacheServiceInterface, apiService FlutterAPIServiceInterface) *DeprecationService {
 	return &DeprecationService{
 		cacheService: cacheService,
 		apiService:   apiService,
+		providers:    []RuleProvider{NewBuiltinRuleProvider(), NewManualRuleProvider(cacheService), NewSourceScanRuleProvider(cacheService)},
 	}
 }
 
-// getDeprecationPatterns returns known deprecation patterns
-func (d *DeprecationService) getDeprecationPatterns() map[string]models.Deprecation {
-	return map[string]models.Deprecation{
-		`Color\.\w+\.withOpacity\(([^)]+)\)`: {
-			API:         "Color.withOpacity",
-			Replacement: "Color.withValues(alpha: $1)",
-			Description: "withOpacity is deprecated, use withValues instead",
-			Example:     "Color.red.withOpacity(0.5) → Color.red.withValues(alpha: 0.5)",
-		},
-		`RaisedButton`: {
-			API:         "RaisedButton",
-			Replacement: "ElevatedButton",
-			Description: "RaisedButton is deprecated, use ElevatedButton instead",
-			Example:     "RaisedButton → ElevatedButton",
-		},
-		`FlatButton`: {
-			API:         "FlatButton",
-			Replacement: "TextButton",
-			Description: "FlatButton is deprecated, use TextButton instead",
-			Example:     "FlatButton → TextButton",
-		},
-		`OutlineButton`: {
-			API:         "OutlineButton",
-			Replacement: "OutlinedButton",
-			Description: "OutlineButton is deprecated, use OutlinedButton instead",
-			Example:     "OutlineButton → OutlinedButton",
-		},
-		`Scaffold\.of\(context\)\.showSnackBar`: {
-			API:         "Scaffold.of(context).showSnackBar",
-			Replacement: "ScaffoldMessenger.of(context).showSnackBar",
-			Description: "Direct showSnackBar on Scaffold is deprecated",
-			Example:     "Scaffold.of(context).showSnackBar → ScaffoldMessenger.of(context).showSnackBar",
-		},
-		`FloatingActionButton\(child:`: {
-			API:         "FloatingActionButton(child:",
-			Replacement: "FloatingActionButton with specific constructors",
-			Description: "Consider using FloatingActionButton.extended or other specific constructors",
-		},
+// deprecationRule pairs a precompiled code pattern with the deprecation it detects. Rules with a
+// rewrite pattern can be migrated mechanically; followUp flags code that still needs manual review
+// after the rewrite (matched by followUpPattern).
+type deprecationRule struct {
+	pattern         *regexp.Regexp
+	deprecation     models.Deprecation
+	rewrite         *regexp.Regexp
+	template        string
+	followUpPattern *regexp.Regexp
+	followUp        string
+	// matches and nonMatches are the fixtures of the rule test: code the pattern must and must not match
+	matches    []string
+	nonMatches []string
+}
+
+// releaseNotePatterns match real Flutter API deprecations mentioned in release notes
+var releaseNotePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)deprecated[:\s]+([A-Z][a-zA-Z0-9_.]*)\s*(?:in favor of|replaced by|use)\s+([A-Z][a-zA-Z0-9_.]*)`),
+	regexp.MustCompile(`(?i)([A-Z][a-zA-Z0-9_.]*)\s+(?:is\s+)?deprecated[,\s]*(?:use|replaced by)\s+([A-Z][a-zA-Z0-9_.]*)`),
+	regexp.MustCompile(`(?i)\*\*Breaking change\*\*[^*]*deprecated[^*]*([A-Z][a-zA-Z0-9_.]*)[^*]*([A-Z][a-zA-Z0-9_.]*)?`),
+}
+
+// knownDeprecations returns the built-in rules as cache entries
+func (d *DeprecationService) knownDeprecations() []models.Deprecation {
+	return patternDeprecations(builtinRules)
+}
+
+// patternDeprecations returns the deprecations of pattern rules as entries
+func patternDeprecations(patterns []deprecationRule) []models.Deprecation {
+	deprecations := make([]models.Deprecation, 0, len(patterns))
+	for _, rule := range patterns {
+		dep := rule.deprecation
+		if dep.Version == "" {
+			dep.Version = "Multiple versions"
+		}
+		deprecations = append(deprecations, dep)
 	}
+	return deprecations
 }
 
 // isVersionFromLast18Months checks if a version is from the last 18 months
@@ -81,160 +86,502 @@ func (d *DeprecationService) isVersionFromLast18Months(publishedAt string) bool
 func (d *DeprecationService) ExtractDeprecationsFromReleaseNotes(releases []models.FlutterRelease) []models.Deprecation {
 	var deprecations []models.Deprecation
 
-	// More specific patterns for real Flutter API deprecations
-	patterns := []string{
-		`(?i)deprecated[:\s]+([A-Z][a-zA-Z0-9_.]*)\s*(?:in favor of|replaced by|use)\s+([A-Z][a-zA-Z0-9_.]*)`,
-		`(?i)([A-Z][a-zA-Z0-9_.]*)\s+(?:is\s+)?deprecated[,\s]*(?:use|replaced by)\s+([A-Z][a-zA-Z0-9_.]*)`,
-		`(?i)\*\*Breaking change\*\*[^*]*deprecated[^*]*([A-Z][a-zA-Z0-9_.]*)[^*]*([A-Z][a-zA-Z0-9_.]*)?`,
-	}
-
 	for _, release := range releases {
 		if !d.isVersionFromLast18Months(release.PublishedAt) {
 			continue
 		}
 
 		version := d.apiService.ParseVersionFromRelease(release)
-		body := release.Body
-
-		for _, pattern := range patterns {
-			regex := regexp.MustCompile(pattern)
-			matches := regex.FindAllStringSubmatch(body, -1)
-			for _, match := range matches {
-				if len(match) >= 2 {
-					api := strings.TrimSpace(match[1])
-					replacement := ""
-					if len(match) >= 3 && match[2] != "" {
-						replacement = strings.TrimSpace(match[2])
-					}
-
-					// Filter out obviously wrong matches
-					if len(api) < 3 || !strings.Contains(api, ".") && len(api) < 5 {
-						continue
-					}
-
-					deprecation := models.Deprecation{
-						API:         api,
-						Replacement: replacement,
-						Version:     version,
-						Description: fmt.Sprintf("Deprecated in Flutter %s", version),
-					}
-					deprecations = append(deprecations, deprecation)
-				}
-			}
-		}
+		deprecations = append(deprecations, releaseNoteDeprecations(version, release.Body)...)
 	}
 
 	// Add the known deprecation patterns
-	for _, templateDep := range d.getDeprecationPatterns() {
-		dep := templateDep
-		dep.Version = "Multiple versions"
-		deprecations = append(deprecations, dep)
-	}
+	return append(deprecations, d.knownDeprecations()...)
+}
+
+// releaseNoteDeprecations extracts the deprecations mentioned in the notes of one release
+func releaseNoteDeprecations(version string, body string) []models.Deprecation {
+	var deprecations []models.Deprecation
+	for _, regex := range releaseNotePatterns {
+		matches := regex.FindAllStringSubmatch(body, -1)
+		for _, match := range matches {
+			if len(match) >= 2 {
+				api := strings.TrimSpace(match[1])
+				replacement := ""
+				if len(match) >= 3 && match[2] != "" {
+					replacement = strings.TrimSpace(match[2])
+				}
 
+				// Filter out obviously wrong matches
+				if len(api) < 3 || !strings.Contains(api, ".") && len(api) < 5 {
+					continue
+				}
+
+				deprecation := models.Deprecation{
+					API:         api,
+					Replacement: replacement,
+					Version:     version,
+					Description: fmt.Sprintf("Deprecated in Flutter %s", version),
+					Severity:    config.SEVERITY_WARNING,
+					Source:      config.DEPRECATION_SOURCE_RELEASE_NOTES,
+				}
+				deprecation.DocURL = apiDocumentationURL(deprecation)
+				deprecations = append(deprecations, deprecation)
+			}
+		}
+	}
 	return deprecations
 }
 
 // CheckCodeForDeprecations analyzes code for deprecated APIs
 func (d *DeprecationService) CheckCodeForDeprecations(code string) []models.Deprecation {
+	return d.checkRules(code, config.TARGET_FLUTTER)
+}
+
+// checkRules returns the deprecations of the pattern rules that match code and of the entries
+// whose API it mentions, for a target returned by ParseTarget
+func (d *DeprecationService) checkRules(code string, target string) []models.Deprecation {
 	var foundDeprecations []models.Deprecation
 
-	for regexPattern, deprecation := range d.getDeprecationPatterns() {
-		regex := regexp.MustCompile(regexPattern)
-		if regex.MatchString(code) {
-			foundDeprecations = append(foundDeprecations, deprecation)
+	patterns, entries := d.rules(target)
+	for _, rule := range patterns {
+		if rule.pattern.MatchString(code) {
+			foundDeprecations = append(foundDeprecations, rule.deprecation)
+		}
+	}
+	for _, dep := range entries {
+		if dep.API != "" && strings.Contains(code, dep.API) && usesPackage(code, dep.Package) {
+			foundDeprecations = append(foundDeprecations, dep)
+		}
+	}
+
+	return foundDeprecations
+}
+
+// usesPackage reports whether code can reference the APIs of a flutter/packages plugin: snippets
+// without imports are given the benefit of the doubt, files with imports must import the package
+func usesPackage(code string, pkg string) bool {
+	if pkg == "" || !strings.Contains(code, "import ") {
+		return true
+	}
+	return strings.Contains(code, "package:"+pkg+"/")
+}
+
+// FindDeprecations returns every known entry for the exact API name, falling back to a
+// case-insensitive match when nothing matches exactly
+func (d *DeprecationService) FindDeprecations(api string) []models.Deprecation {
+	api = strings.TrimSpace(api)
+	if api == "" {
+		return nil
+	}
+
+	var exact, folded []models.Deprecation
+	for _, dep := range d.allDeprecations() {
+		if dep.API == api {
+			exact = append(exact, dep)
+		} else if strings.EqualFold(dep.API, api) {
+			folded = append(folded, dep)
+		}
+	}
+
+	if len(exact) > 0 {
+		return exact
+	}
+	return folded
+}
+
+// allDeprecations returns the entries of the rule providers followed by their pattern rules, without
+// duplicates
+func (d *DeprecationService) allDeprecations() []models.Deprecation {
+	patterns, entries := d.rules(config.TARGET_FLUTTER)
+	candidates := append(entries, patternDeprecations(patterns)...)
+
+	deprecations := candidates[:0]
+	seen := make(map[string]bool, len(candidates))
+	for _, dep := range candidates {
+		key := dep.API + "\x00" + dep.Source + "\x00" + dep.Description
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deprecations = append(deprecations, dep)
+	}
+	return deprecations
+}
+
+// AddManualDeprecation stores a user-provided deprecation in the manual section of the cache and
+// reports whether it replaced an earlier manual entry for the same API
+func (d *DeprecationService) AddManualDeprecation(dep models.Deprecation) (bool, error) {
+	dep.API = strings.TrimSpace(dep.API)
+	if dep.API == "" {
+		return false, fmt.Errorf("an API name is required")
+	}
+	dep.Source = config.DEPRECATION_SOURCE_MANUAL
+	if dep.Severity == "" {
+		dep.Severity = config.SEVERITY_WARNING
+	}
+	if dep.DocURL == "" {
+		dep.DocURL = apiDocumentationURL(dep)
+	}
+	if dep.Description == "" {
+		dep.Description = fmt.Sprintf("%s is deprecated", dep.API)
+		if dep.Replacement != "" {
+			dep.Description += fmt.Sprintf(", use %s instead", dep.Replacement)
 		}
 	}
 
 	cache, err := d.cacheService.Load()
-	if err == nil {
-		for _, dep := range cache.Deprecations {
-			if dep.API != "" && strings.Contains(code, dep.API) {
-				foundDeprecations = append(foundDeprecations, dep)
-			}
+	if err != nil {
+		return false, err
+	}
+
+	replaced := false
+	for i, existing := range cache.Manual {
+		if existing.API == dep.API {
+			cache.Manual[i] = dep
+			replaced = true
+			break
 		}
 	}
+	if !replaced {
+		cache.Manual = append(cache.Manual, dep)
+	}
 
-	return foundDeprecations
+	return replaced, d.cacheService.Save(cache)
+}
+
+// DocumentationURL returns the documentation link recorded for a deprecation, building the API
+// reference link for entries cached without one
+func DocumentationURL(dep models.Deprecation) string {
+	if dep.DocURL != "" {
+		return dep.DocURL
+	}
+	return apiDocumentationURL(dep)
+}
+
+// SourceURL links the @Deprecated annotation of a scanned entry on GitHub, or returns "" for
+// entries that were not scanned from source
+func SourceURL(dep models.Deprecation) string {
+	if dep.SourceFile == "" {
+		return ""
+	}
+	base := config.FLUTTER_SOURCE_BLOB_URL + frameworkChannel(dep) + "/"
+	switch dep.Source {
+	case config.DEPRECATION_SOURCE_PACKAGES:
+		base = config.PACKAGES_SOURCE_BLOB_URL
+	case config.DEPRECATION_SOURCE_REPO:
+		repo, ref, _ := strings.Cut(dep.Repository, "@")
+		base = config.GITHUB_URL + repo + "/blob/" + ref + "/"
+	}
+	link := base + dep.SourceFile
+	if dep.SourceLine > 0 {
+		link += fmt.Sprintf("#L%d", dep.SourceLine)
+	}
+	return link
+}
+
+// apiDocumentationURL builds the api.flutter.dev link for a deprecation, or the pub.dev link for a
+// plugin, falling back to a documentation search when the library is unknown
+func apiDocumentationURL(dep models.Deprecation) string {
+	name := dep.API
+	if i := strings.IndexAny(name, "( "); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Trim(name, ".")
+
+	if dep.Package != "" {
+		base := config.PUB_DOCS_URL + dep.Package + "/latest/" + dep.Package + "/"
+		if name == "" {
+			return base
+		}
+		parts := strings.SplitN(name, ".", 2)
+		if len(parts) == 1 {
+			return base + parts[0] + "-class.html"
+		}
+		return base + parts[0] + "/" + parts[1] + ".html"
+	}
+
+	library := strings.ReplaceAll(dep.Category, ":", "-")
+	if library == "" || name == "" {
+		return "https://api.flutter.dev/flutter/search.html?q=" + url.QueryEscape(dep.API)
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) == 1 {
+		return fmt.Sprintf("https://api.flutter.dev/flutter/%s/%s-class.html", library, parts[0])
+	}
+	return fmt.Sprintf("https://api.flutter.dev/flutter/%s/%s/%s.html", library, parts[0], parts[1])
+}
+
+// SetStatsRecorder reports the outcome of every cache update to recorder
+func (d *DeprecationService) SetStatsRecorder(recorder CacheRecorder) {
+	d.stats = recorder
+}
+
+// observeUpdate reports an update of the cache when a recorder is set
+func (d *DeprecationService) observeUpdate(result string) {
+	if d.stats != nil {
+		d.stats.RecordCacheUpdate(result)
+	}
 }
 
 // UpdateCache updates the deprecations cache
-func (d *DeprecationService) UpdateCache() error {
+func (d *DeprecationService) UpdateCache(ctx context.Context) error {
 	cache, err := d.cacheService.Load()
 	if err != nil {
 		return err
 	}
 
 	if time.Since(cache.LastUpdated) < config.CACHE_DURATION {
+		d.observeUpdate(CacheUpdateFresh)
 		return nil
 	}
 
+	return d.RefreshCache(ctx)
+}
+
+// RefreshCache rescans Flutter's source code and replaces the scanned deprecations, however fresh the cache is
+func (d *DeprecationService) RefreshCache(ctx context.Context) error {
+	d.RefreshRuleProviders(ctx)
+
 	// Fetch deprecations from Flutter source code
-	sourceDeprecations, err := d.apiService.FetchFlutterSourceDeprecations()
+	sourceDeprecations, err := d.apiService.FetchFlutterSourceDeprecations(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch source deprecations: %v", err)
+		return fmt.Errorf("failed to fetch source deprecations: %w", err)
 	}
 
-	// Add the known deprecation patterns
-	knownDeprecations := d.getDeprecationPatterns()
-	for _, templateDep := range knownDeprecations {
-		dep := templateDep
-		dep.Version = "Multiple versions"
-		sourceDeprecations = append(sourceDeprecations, dep)
+	return d.storeScan(sourceDeprecations, true)
+}
+
+// UpdateCacheScoped refreshes part of the dataset, however fresh the cache is. The framework
+// libraries and first-party plugins named in directories, as listed by SourceLibraries, are
+// rescanned and the entries of the others kept. The rule providers named in sources are refreshed,
+// and the source-scan source rescans every library. Only a rescan of every library counts as an
+// update of the cache, so the next update still refreshes what a scoped one left out.
+func (d *DeprecationService) UpdateCacheScoped(ctx context.Context, directories []string, sources []string) error {
+	if _, _, err := parseSourceLibraries(directories); err != nil {
+		return err
+	}
+
+	refreshers := make(map[string]RuleRefresher)
+	names := []string{config.RULE_PROVIDER_SOURCE_SCAN}
+	for _, provider := range d.providers {
+		if refresher, ok := provider.(RuleRefresher); ok {
+			refreshers[provider.Name()] = refresher
+			names = append(names, provider.Name())
+		}
+	}
+	full := false
+	var refresh []string
+	for _, source := range sources {
+		name := strings.ToLower(strings.TrimSpace(source))
+		if name == config.RULE_PROVIDER_SOURCE_SCAN {
+			full = true
+			continue
+		}
+		if refreshers[name] == nil {
+			return fmt.Errorf("unknown source %q, expected one of: %s", source, strings.Join(names, ", "))
+		}
+		refresh = append(refresh, name)
+	}
+
+	for _, name := range refresh {
+		if err := refreshers[name].Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to refresh %s: %v", name, err)
+		}
+	}
+
+	var sourceDeprecations []models.Deprecation
+	var err error
+	switch {
+	case full:
+		sourceDeprecations, err = d.apiService.FetchFlutterSourceDeprecations(ctx)
+	case len(directories) > 0:
+		sourceDeprecations, err = d.apiService.FetchSourceDeprecationsIn(ctx, directories)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch source deprecations: %w", err)
 	}
+	return d.storeScan(sourceDeprecations, full)
+}
+
+// storeScan merges scanned deprecations into the cache with trackSeen, which keeps the entries of
+// the libraries the scan did not cover, and records what changed. complete marks a scan of every
+// library, which resets the cache age.
+func (d *DeprecationService) storeScan(sourceDeprecations []models.Deprecation, complete bool) error {
+	// Load after the scan so manual entries added while it ran are kept
+	cache, err := d.cacheService.Load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	sourceDeprecations = trackSeen(cache.Deprecations, sourceDeprecations, now)
+
+	// Add the known deprecation patterns
+	sourceDeprecations = append(sourceDeprecations, d.knownDeprecations()...)
 
+	cache.LastChanges = diffDeprecations(cache.LastUpdated, now, cache.Deprecations, sourceDeprecations)
 	cache.Deprecations = sourceDeprecations
-	cache.LastUpdated = time.Now()
+	result := CacheUpdateScoped
+	if complete {
+		cache.LastUpdated = now
+		result = CacheUpdateRebuild
+	}
 
-	return d.cacheService.Save(cache)
+	if err := d.cacheService.Save(cache); err != nil {
+		return err
+	}
+	d.observeUpdate(result)
+	return nil
 }
 
 // UpdateCacheWithProgress updates the deprecations cache with progress reporting
-func (d *DeprecationService) UpdateCacheWithProgress(progressCallback func(string), verbose bool) error {
+func (d *DeprecationService) UpdateCacheWithProgress(ctx context.Context, progressCallback func(string)) error {
 	cache, err := d.cacheService.Load()
 	if err != nil {
 		return err
 	}
 
 	if time.Since(cache.LastUpdated) < config.CACHE_DURATION {
+		d.observeUpdate(CacheUpdateFresh)
 		progressCallback("Cache is up to date, skipping update")
-		if verbose {
-			log.Printf("Cache last updated: %s, duration threshold: %s", cache.LastUpdated.Format("2006-01-02 15:04:05"), config.CACHE_DURATION)
-		}
+		slog.Debug("Cache is fresh", "last_updated", cache.LastUpdated.Format("2006-01-02 15:04:05"), "duration_threshold", config.CACHE_DURATION)
 		return nil
 	}
 
+	d.RefreshRuleProviders(ctx)
+
 	progressCallback("🖻 Scanning Flutter source code for @Deprecated annotations...")
-	if verbose {
-		log.Println("Starting Flutter source code scan")
-	}
+	slog.Debug("Starting Flutter source code scan")
 
 	// Fetch deprecations from Flutter source code
-	sourceDeprecations, err := d.apiService.FetchFlutterSourceDeprecationsWithProgress(progressCallback, verbose)
+	sourceDeprecations, err := d.apiService.FetchFlutterSourceDeprecationsWithProgress(ctx, progressCallback)
 	if err != nil {
-		return fmt.Errorf("failed to fetch source deprecations: %v", err)
+		return fmt.Errorf("failed to fetch source deprecations: %w", err)
 	}
 
 	progressCallback(fmt.Sprintf("📊 Found %d deprecations from source code", len(sourceDeprecations)))
-	if verbose {
-		log.Printf("Found %d deprecations from source scan", len(sourceDeprecations))
+	slog.Debug("Source scan finished", "deprecations", len(sourceDeprecations))
+
+	now := time.Now()
+	sourceDeprecations = trackSeen(cache.Deprecations, sourceDeprecations, now)
+	if removed := countRemoved(sourceDeprecations); removed > 0 {
+		progressCallback(fmt.Sprintf("🪦 %d deprecations are no longer annotated upstream (API removed)", removed))
 	}
 
 	progressCallback("📁 Adding known deprecation patterns...")
 	// Add the known deprecation patterns
-	knownDeprecations := d.getDeprecationPatterns()
-	for _, templateDep := range knownDeprecations {
-		dep := templateDep
-		dep.Version = "Multiple versions"
-		sourceDeprecations = append(sourceDeprecations, dep)
-	}
+	sourceDeprecations = append(sourceDeprecations, d.knownDeprecations()...)
 
 	progressCallback(fmt.Sprintf("💾 Saving %d total deprecations to cache...", len(sourceDeprecations)))
-	if verbose {
-		log.Printf("Saving %d deprecations to cache", len(sourceDeprecations))
-	}
+	slog.Debug("Saving deprecations to cache", "deprecations", len(sourceDeprecations))
 
+	cache.LastChanges = diffDeprecations(cache.LastUpdated, now, cache.Deprecations, sourceDeprecations)
 	cache.Deprecations = sourceDeprecations
-	cache.LastUpdated = time.Now()
+	cache.LastUpdated = now
+
+	if err := d.cacheService.Save(cache); err != nil {
+		return err
+	}
+	d.observeUpdate(CacheUpdateRebuild)
+	return nil
+}
+
+// trackSeen carries the first-seen time of previously scanned entries over to a new scan and marks
+// every scanned entry as confirmed now. Previously scanned entries missing from the scan are kept:
+// as removed when the scan covered their library, unchanged when it did not (for example because
+// the directory listing failed), so a partial scan does not report APIs as removed. Upcoming
+// entries, and framework entries scanned from master before stable was, are dropped instead when
+// their library was covered: they were never in stable, so stable cannot have removed them. A
+// drafted replacement is carried over while the annotation still names none and is unchanged.
+func trackSeen(previous []models.Deprecation, scanned []models.Deprecation, now time.Time) []models.Deprecation {
+	firstSeen := make(map[string]time.Time, len(previous))
+	drafted := make(map[string]models.Deprecation)
+	for _, dep := range previous {
+		if isScanned(dep) && !dep.FirstSeen.IsZero() {
+			firstSeen[seenKey(dep)] = dep.FirstSeen
+		}
+		if isScanned(dep) && dep.ReplacementDraftedBy != "" {
+			drafted[seenKey(dep)] = dep
+		}
+	}
+
+	found := make(map[string]bool, len(scanned))
+	libraries := make(map[string]bool)
+	tracked := make([]models.Deprecation, 0, len(scanned))
+	for _, dep := range scanned {
+		key := seenKey(dep)
+		found[key] = true
+		libraries[dep.Source+"\x00"+dep.Category] = true
+
+		dep.FirstSeen = now
+		if seen, ok := firstSeen[key]; ok {
+			dep.FirstSeen = seen
+		}
+		dep.LastSeen = now
+		if draft, ok := drafted[key]; ok && dep.Replacement == "" && dep.Description == draft.Description {
+			dep.Replacement, dep.ReplacementDraftedBy = draft.Replacement, draft.ReplacementDraftedBy
+		}
+		tracked = append(tracked, dep)
+	}
+
+	for _, dep := range previous {
+		if !isScanned(dep) || found[seenKey(dep)] {
+			continue
+		}
+		found[seenKey(dep)] = true
+		if libraries[dep.Source+"\x00"+dep.Category] {
+			if dep.Upcoming || (dep.Source == config.DEPRECATION_SOURCE_FLUTTER && dep.Channel == "" && !dep.Removed) {
+				continue
+			}
+			dep.Removed = true
+		}
+		tracked = append(tracked, dep)
+	}
+
+	return tracked
+}
+
+// isScanned reports whether an entry comes from scanning upstream source, as opposed to the
+// built-in rules that are regenerated on every refresh
+func isScanned(dep models.Deprecation) bool {
+	return dep.Source == config.DEPRECATION_SOURCE_FLUTTER || dep.Source == config.DEPRECATION_SOURCE_PACKAGES
+}
+
+// frameworkChannel is the flutter/flutter branch an entry was scanned from. Entries cached before
+// the branch was recorded were scanned from master.
+func frameworkChannel(dep models.Deprecation) string {
+	if dep.Channel == "" {
+		return config.FLUTTER_CHANNEL_MASTER
+	}
+	return dep.Channel
+}
+
+// seenKey identifies a scanned entry across refreshes
+func seenKey(dep models.Deprecation) string {
+	return dep.Source + "\x00" + dep.Package + "\x00" + dep.API
+}
+
+// countRemoved counts the entries whose annotation is gone upstream
+func countRemoved(deprecations []models.Deprecation) int {
+	removed := 0
+	for _, dep := range deprecations {
+		if dep.Removed {
+			removed++
+		}
+	}
+	return removed
+}
 
-	return d.cacheService.Save(cache)
+// countUpcoming counts the entries only deprecated on the preview channel so far
+func countUpcoming(deprecations []models.Deprecation) int {
+	upcoming := 0
+	for _, dep := range deprecations {
+		if dep.Upcoming {
+			upcoming++
+		}
+	}
+	return upcoming
 }
@@ -1,15 +1,19 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 func TestDeprecationService(t *testing.T) {
-	// Create mock services
-	cacheService := NewCacheService()
+	// Create mock services backed by an isolated cache directory
+	cacheService := &CacheService{dir: t.TempDir()}
 	apiService := NewFlutterAPIService()
 	depService := NewDeprecationService(cacheService, apiService)
 
@@ -75,6 +79,293 @@ func TestDeprecationService(t *testing.T) {
 		}
 	})
 
+	t.Run("FindDeprecations", func(t *testing.T) {
+		if err := cacheService.Save(&models.DeprecationCache{
+			LastUpdated: time.Now(),
+			Deprecations: []models.Deprecation{
+				{API: "ColorScheme.background", Replacement: "ColorScheme.surface", Source: "flutter_source"},
+			},
+		}); err != nil {
+			t.Fatalf("Expected no error saving cache, got %v", err)
+		}
+		defer cacheService.Clear()
+
+		found := depService.FindDeprecations("ColorScheme.background")
+		if len(found) != 1 || found[0].Replacement != "ColorScheme.surface" {
+			t.Errorf("Expected cached ColorScheme.background entry, got %+v", found)
+		}
+
+		builtin := depService.FindDeprecations("raisedbutton")
+		if len(builtin) != 1 || builtin[0].API != "RaisedButton" || builtin[0].Source != "builtin" {
+			t.Errorf("Expected case-insensitive match on built-in RaisedButton, got %+v", builtin)
+		}
+
+		if found := depService.FindDeprecations("ColorScheme"); len(found) != 0 {
+			t.Errorf("Expected no partial matches, got %+v", found)
+		}
+	})
+
+	t.Run("Plugin deprecations need the plugin import", func(t *testing.T) {
+		if err := cacheService.Save(&models.DeprecationCache{
+			LastUpdated: time.Now(),
+			Deprecations: []models.Deprecation{
+				{API: "GoRouterState.location", Replacement: "GoRouterState.uri", Package: "go_router", Source: "flutter_packages"},
+			},
+		}); err != nil {
+			t.Fatalf("Expected no error saving cache, got %v", err)
+		}
+		defer cacheService.Clear()
+
+		testCases := []struct {
+			name     string
+			code     string
+			expected bool
+		}{
+			{"snippet without imports", "final path = GoRouterState.location;", true},
+			{"file importing the plugin", "import 'package:go_router/go_router.dart';\nfinal path = GoRouterState.location;", true},
+			{"file importing other packages", "import 'package:my_router/my_router.dart';\nfinal path = GoRouterState.location;", false},
+		}
+		for _, tc := range testCases {
+			found := false
+			for _, dep := range depService.CheckCodeForDeprecations(tc.code) {
+				found = found || dep.API == "GoRouterState.location"
+			}
+			if found != tc.expected {
+				t.Errorf("%s: expected found=%v, got %v", tc.name, tc.expected, found)
+			}
+		}
+	})
+
+	t.Run("SourceURL", func(t *testing.T) {
+		framework := models.Deprecation{Source: "flutter_source", SourceFile: "packages/flutter/lib/src/material/app.dart", SourceLine: 42}
+		if got := SourceURL(framework); got != "https://github.com/flutter/flutter/blob/master/packages/flutter/lib/src/material/app.dart#L42" {
+			t.Errorf("Unexpected framework source URL %s", got)
+		}
+		plugin := models.Deprecation{Source: "flutter_packages", SourceFile: "packages/go_router/lib/src/state.dart", SourceLine: 7}
+		if got := SourceURL(plugin); got != "https://github.com/flutter/packages/blob/main/packages/go_router/lib/src/state.dart#L7" {
+			t.Errorf("Unexpected plugin source URL %s", got)
+		}
+		if got := SourceURL(models.Deprecation{Source: "builtin"}); got != "" {
+			t.Errorf("Expected no source URL for built-in rules, got %s", got)
+		}
+	})
+
+	t.Run("RefreshCache tracks when entries were seen", func(t *testing.T) {
+		trackedCache := &CacheService{dir: t.TempDir()}
+		api := &MockFlutterAPIService{sourceDeps: []models.Deprecation{
+			{API: "ColorScheme.background", Category: "material", Source: "flutter_source", Channel: "stable"},
+			{API: "ThemeData.accentColor", Category: "material", Source: "flutter_source", Channel: "stable"},
+			{API: "RenderBox.oldLayout", Category: "rendering", Source: "flutter_source", Channel: "stable"},
+		}}
+		trackedService := NewDeprecationService(trackedCache, api)
+
+		if err := trackedService.RefreshCache(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		first, _ := trackedCache.Load()
+		firstSeen := first.Deprecations[0].FirstSeen
+		if firstSeen.IsZero() || !first.Deprecations[0].LastSeen.Equal(firstSeen) {
+			t.Fatalf("Expected first and last seen to be set on the first scan, got %+v", first.Deprecations[0])
+		}
+
+		// accentColor is gone from a scanned library; the rendering directory failed to scan
+		api.sourceDeps = api.sourceDeps[:1]
+		time.Sleep(10 * time.Millisecond)
+		if err := trackedService.RefreshCache(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, _ := trackedCache.Load()
+
+		entries := make(map[string]models.Deprecation)
+		for _, dep := range second.Deprecations {
+			entries[dep.API] = dep
+		}
+		background := entries["ColorScheme.background"]
+		if !background.FirstSeen.Equal(firstSeen) || !background.LastSeen.After(firstSeen) || background.Removed {
+			t.Errorf("Expected background to keep its first seen time and be confirmed again, got %+v", background)
+		}
+		if accent := entries["ThemeData.accentColor"]; !accent.Removed || !accent.LastSeen.Equal(firstSeen) {
+			t.Errorf("Expected accentColor to be flagged as removed, got %+v", accent)
+		}
+		if layout := entries["RenderBox.oldLayout"]; layout.Removed || layout.API == "" {
+			t.Errorf("Expected the unscanned rendering entry to be kept as is, got %+v", layout)
+		}
+		if builtin := entries["RaisedButton"]; builtin.Removed || !builtin.FirstSeen.IsZero() {
+			t.Errorf("Expected built-in rules to stay untracked, got %+v", builtin)
+		}
+	})
+
+	t.Run("UpdateCacheScoped only rescans the named libraries", func(t *testing.T) {
+		scopedCache := &CacheService{dir: t.TempDir()}
+		api := &MockFlutterAPIService{sourceDeps: []models.Deprecation{
+			{API: "ThemeData.accentColor", Category: "material", Source: "flutter_source", Channel: "stable"},
+			{API: "RenderBox.oldLayout", Category: "rendering", Source: "flutter_source", Channel: "stable"},
+		}}
+		scopedService := NewDeprecationService(scopedCache, api)
+		if err := scopedService.RefreshCache(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		first, _ := scopedCache.Load()
+
+		// Both libraries changed upstream, but only material is rescanned
+		api.sourceDeps = []models.Deprecation{
+			{API: "ColorScheme.background", Category: "material", Source: "flutter_source", Channel: "stable"},
+			{API: "RenderBox.newLayout", Category: "rendering", Source: "flutter_source", Channel: "stable"},
+		}
+		if err := scopedService.UpdateCacheScoped(context.Background(), []string{"material"}, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, _ := scopedCache.Load()
+		entries := make(map[string]models.Deprecation)
+		for _, dep := range second.Deprecations {
+			entries[dep.API] = dep
+		}
+		if _, ok := entries["ColorScheme.background"]; !ok || !entries["ThemeData.accentColor"].Removed {
+			t.Errorf("Expected material to be rescanned, got %+v", second.Deprecations)
+		}
+		if layout := entries["RenderBox.oldLayout"]; layout.Removed || layout.API == "" || entries["RenderBox.newLayout"].API != "" {
+			t.Errorf("Expected rendering to be left as is, got %+v", second.Deprecations)
+		}
+		if !second.LastUpdated.Equal(first.LastUpdated) || second.LastChanges == nil || len(second.LastChanges.Added) != 1 {
+			t.Errorf("Expected a scoped update to record its changes but keep the cache age, got %+v", second)
+		}
+
+		if err := scopedService.UpdateCacheScoped(context.Background(), nil, []string{"source-scan"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if third, _ := scopedCache.Load(); !third.LastUpdated.After(first.LastUpdated) {
+			t.Errorf("Expected a source-scan refresh to update the cache age, got %s", third.LastUpdated)
+		}
+
+		if err := scopedService.UpdateCacheScoped(context.Background(), []string{"materials"}, nil); err == nil || !strings.Contains(err.Error(), `unknown library "materials", expected one of: widgets, material`) {
+			t.Errorf("Expected an unknown library error, got %v", err)
+		}
+		if err := scopedService.UpdateCacheScoped(context.Background(), nil, []string{"fix-data"}); err == nil || !strings.Contains(err.Error(), `unknown source "fix-data", expected one of: source-scan`) {
+			t.Errorf("Expected fix-data to be unknown without its provider, got %v", err)
+		}
+	})
+
+	t.Run("trackSeen settles upcoming entries", func(t *testing.T) {
+		earlier := time.Now().Add(-48 * time.Hour)
+		now := time.Now()
+		previous := []models.Deprecation{
+			{API: "App.color", Category: "material", Source: "flutter_source", Channel: "beta", Upcoming: true, FirstSeen: earlier},
+			{API: "App.title", Category: "material", Source: "flutter_source", Channel: "beta", Upcoming: true, FirstSeen: earlier},
+			{API: "App.legacy", Category: "material", Source: "flutter_source", FirstSeen: earlier},
+			{API: "App.gone", Category: "material", Source: "flutter_source", Removed: true, FirstSeen: earlier},
+		}
+		scanned := []models.Deprecation{
+			{API: "App.color", Category: "material", Source: "flutter_source", Channel: "stable"},
+		}
+
+		entries := make(map[string]models.Deprecation)
+		for _, dep := range trackSeen(previous, scanned, now) {
+			entries[dep.API] = dep
+		}
+		if color := entries["App.color"]; color.Upcoming || color.Channel != "stable" || !color.FirstSeen.Equal(earlier) {
+			t.Errorf("Expected App.color to have landed in stable and keep its first seen time, got %+v", color)
+		}
+		if title, ok := entries["App.title"]; ok {
+			t.Errorf("Expected the upcoming entry gone from the preview branch to be dropped, got %+v", title)
+		}
+		if legacy, ok := entries["App.legacy"]; ok {
+			t.Errorf("Expected the master entry missing from stable to be dropped, got %+v", legacy)
+		}
+		if gone := entries["App.gone"]; !gone.Removed {
+			t.Errorf("Expected the removed entry to stay removed, got %+v", gone)
+		}
+	})
+
+	t.Run("trackSeen keeps drafted replacements of unchanged annotations", func(t *testing.T) {
+		previous := []models.Deprecation{
+			{API: "App.kept", Description: "Use the new API", Source: "flutter_source", Replacement: "App.next", ReplacementDraftedBy: "claude"},
+			{API: "App.changed", Description: "Old message", Source: "flutter_source", Replacement: "App.guess", ReplacementDraftedBy: "claude"},
+			{API: "App.curated", Description: "Use App.better", Source: "flutter_source", Replacement: "App.guess", ReplacementDraftedBy: "claude"},
+		}
+		scanned := []models.Deprecation{
+			{API: "App.kept", Description: "Use the new API", Source: "flutter_source"},
+			{API: "App.changed", Description: "New message", Source: "flutter_source"},
+			{API: "App.curated", Description: "Use App.better", Source: "flutter_source", Replacement: "App.better"},
+		}
+
+		entries := make(map[string]models.Deprecation)
+		for _, dep := range trackSeen(previous, scanned, time.Now()) {
+			entries[dep.API] = dep
+		}
+		if kept := entries["App.kept"]; kept.Replacement != "App.next" || kept.ReplacementDraftedBy != "claude" {
+			t.Errorf("Expected the draft to be carried over, got %+v", kept)
+		}
+		if changed := entries["App.changed"]; changed.Replacement != "" || changed.ReplacementDraftedBy != "" {
+			t.Errorf("Expected the draft of a changed annotation to be dropped, got %+v", changed)
+		}
+		if curated := entries["App.curated"]; curated.Replacement != "App.better" || curated.ReplacementDraftedBy != "" {
+			t.Errorf("Expected the replacement of the annotation to win, got %+v", curated)
+		}
+	})
+
+	t.Run("DocumentationURL", func(t *testing.T) {
+		testCases := []struct {
+			dep      models.Deprecation
+			expected string
+		}{
+			{models.Deprecation{API: "ColorScheme.background", Category: "material"}, "https://api.flutter.dev/flutter/material/ColorScheme/background.html"},
+			{models.Deprecation{API: "RaisedButton", Category: "material"}, "https://api.flutter.dev/flutter/material/RaisedButton-class.html"},
+			{models.Deprecation{API: "Color.withOpacity", Category: "dart:ui"}, "https://api.flutter.dev/flutter/dart-ui/Color/withOpacity.html"},
+			{models.Deprecation{API: "Theme.of"}, "https://api.flutter.dev/flutter/search.html?q=Theme.of"},
+			{models.Deprecation{API: "RaisedButton", Category: "material", DocURL: "https://docs.flutter.dev/release/breaking-changes/buttons"}, "https://docs.flutter.dev/release/breaking-changes/buttons"},
+			{models.Deprecation{API: "GoRouterState.location", Category: "go_router", Package: "go_router"}, "https://pub.dev/documentation/go_router/latest/go_router/GoRouterState/location.html"},
+			{models.Deprecation{API: "WebView", Category: "webview_flutter", Package: "webview_flutter"}, "https://pub.dev/documentation/webview_flutter/latest/webview_flutter/WebView-class.html"},
+		}
+
+		for _, tc := range testCases {
+			if got := DocumentationURL(tc.dep); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		}
+	})
+
+	t.Run("AddManualDeprecation", func(t *testing.T) {
+		manualCache := &CacheService{dir: t.TempDir()}
+		manualService := NewDeprecationService(manualCache, &MockFlutterAPIService{})
+
+		replaced, err := manualService.AddManualDeprecation(models.Deprecation{API: " LegacyCard ", Replacement: "AppCard"})
+		if err != nil || replaced {
+			t.Fatalf("Expected a new entry, got replaced=%v err=%v", replaced, err)
+		}
+		replaced, err = manualService.AddManualDeprecation(models.Deprecation{API: "LegacyCard", Replacement: "AppCardV2"})
+		if err != nil || !replaced {
+			t.Fatalf("Expected the entry to be replaced, got replaced=%v err=%v", replaced, err)
+		}
+		if _, err := manualService.AddManualDeprecation(models.Deprecation{API: "  "}); err == nil {
+			t.Error("Expected an error for an empty API")
+		}
+
+		// Automatic updates rewrite the scanned deprecations but keep the manual section
+		if err := manualService.UpdateCache(context.Background()); err != nil {
+			t.Fatalf("UpdateCache failed: %v", err)
+		}
+
+		cache, err := manualCache.Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if len(cache.Manual) != 1 {
+			t.Fatalf("Expected 1 manual entry, got %+v", cache.Manual)
+		}
+		dep := cache.Manual[0]
+		if dep.API != "LegacyCard" || dep.Replacement != "AppCardV2" || dep.Source != config.DEPRECATION_SOURCE_MANUAL {
+			t.Errorf("Unexpected manual entry %+v", dep)
+		}
+		if dep.Description != "LegacyCard is deprecated, use AppCardV2 instead" {
+			t.Errorf("Unexpected default description %q", dep.Description)
+		}
+
+		found := manualService.CheckCodeForDeprecations("return LegacyCard(child: body);")
+		if len(found) != 1 || found[0].API != "LegacyCard" {
+			t.Errorf("Expected the manual entry to be detected, got %+v", found)
+		}
+	})
+
 	t.Run("ExtractDeprecationsFromReleaseNotes", func(t *testing.T) {
 		testReleases := []models.FlutterRelease{
 			{
@@ -156,3 +447,54 @@ func TestDeprecationService(t *testing.T) {
 		}
 	})
 }
+
+// benchmarkSnippet is a representative widget build method mixing deprecated and modern APIs
+const benchmarkSnippet = `
+class LegacyPage extends StatelessWidget {
+  @override
+  Widget build(BuildContext context) {
+    return Scaffold(
+      body: Column(children: [
+        RaisedButton(onPressed: () {}, child: Text('Save')),
+        FlatButton(onPressed: () {}, child: Text('Cancel')),
+        Container(color: Colors.red.withOpacity(0.5)),
+        ElevatedButton(onPressed: () {}, child: Text('Modern')),
+      ]),
+      floatingActionButton: FloatingActionButton(child: Icon(Icons.add), onPressed: () {
+        Scaffold.of(context).showSnackBar(SnackBar(content: Text('Added')));
+      }),
+    );
+  }
+}
+`
+
+// newBenchmarkDeprecationService returns a service backed by a temp cache holding n scanned entries
+func newBenchmarkDeprecationService(b *testing.B, n int) *DeprecationService {
+	b.Helper()
+	cacheService := &CacheService{dir: b.TempDir()}
+	cache := &models.DeprecationCache{LastUpdated: time.Now()}
+	for i := 0; i < n; i++ {
+		cache.Deprecations = append(cache.Deprecations, models.Deprecation{
+			API:         fmt.Sprintf("Widget%d.property%d", i, i),
+			Replacement: fmt.Sprintf("Widget%d.replacement", i),
+			Description: "Scanned deprecation used for benchmarking",
+		})
+	}
+	if err := cacheService.Save(cache); err != nil {
+		b.Fatalf("Failed to seed cache: %v", err)
+	}
+	return NewDeprecationService(cacheService, NewFlutterAPIService())
+}
+
+func BenchmarkCheckCodeForDeprecations(b *testing.B) {
+	for _, size := range []int{0, 1000} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			depService := newBenchmarkDeprecationService(b, size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				depService.CheckCodeForDeprecations(benchmarkSnippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// hunkRangePattern matches the header of a hunk, capturing the old line count, the first new line
+// and the new line count
+var hunkRangePattern = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// diffFile is a file of a unified diff with the lines of its new version the diff adds
+type diffFile struct {
+	path  string
+	added map[int]bool
+}
+
+// DiffScanService checks only the lines a change adds to a project for deprecated APIs, so that
+// a pre-commit hook or CI job blocks on new uses without scanning the whole project
+type DiffScanService struct {
+	deprecationService *DeprecationService
+	execTimeout        time.Duration
+}
+
+// NewDiffScanService creates a new diff scan service instance
+func NewDiffScanService(deprecationService *DeprecationService) *DiffScanService {
+	return &DiffScanService{deprecationService: deprecationService, execTimeout: config.DEFAULT_EXEC_TIMEOUT}
+}
+
+// SetExecTimeout kills git commands that run longer than timeout
+func (s *DiffScanService) SetExecTimeout(timeout time.Duration) {
+	s.execTimeout = timeout
+}
+
+// ScanStaged checks the lines staged for commit in the Dart files below projectPath, reading the
+// files as they are staged rather than from the working tree. The project's ignore files and
+// filter select the files like a project scan. target selects the Flutter (default) or Dart rules.
+func (s *DiffScanService) ScanStaged(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.DiffScanResult, error) {
+	return s.scanChange(ctx, projectPath, target, filter, "staged changes",
+		func() (string, error) {
+			return s.gitDiff(ctx, projectPath, "--cached")
+		},
+		func(file string) (string, error) {
+			// ./ makes the path relative to projectPath instead of the repository root
+			return s.git(ctx, projectPath, "show", ":./"+file)
+		})
+}
+
+// ScanRange checks the lines a git range of the repository holding projectPath adds, like
+// ScanStaged. from..to compares the two commits and from...to the commit to with where it forked
+// from from; a missing to means HEAD. The files are read as they are at to.
+func (s *DiffScanService) ScanRange(ctx context.Context, projectPath string, gitRange string, target string, filter models.PathFilter) (*models.DiffScanResult, error) {
+	to, err := parseGitRange(gitRange)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanChange(ctx, projectPath, target, filter, "changes in "+strings.TrimSpace(gitRange),
+		func() (string, error) {
+			return s.gitDiff(ctx, projectPath, strings.TrimSpace(gitRange))
+		},
+		func(file string) (string, error) {
+			return s.git(ctx, projectPath, "show", to+":./"+file)
+		})
+}
+
+// ScanDiff checks the lines a unified diff adds, like ScanStaged, reading the files from the
+// project at projectPath, which has to hold the new versions. The paths of the diff are relative
+// to projectPath, as git diff prints them when run there with --relative.
+func (s *DiffScanService) ScanDiff(ctx context.Context, projectPath string, diff string, target string, filter models.PathFilter) (*models.DiffScanResult, error) {
+	if strings.TrimSpace(diff) == "" {
+		return nil, fmt.Errorf("the diff is empty")
+	}
+	return s.scanChange(ctx, projectPath, target, filter, "changes in the diff",
+		func() (string, error) {
+			return diff, nil
+		},
+		func(file string) (string, error) {
+			return readCheckFile(filepath.FromSlash(file), projectPath)
+		})
+}
+
+// scanChange checks the lines the diff with the name change adds to the Dart files that a project
+// scan of projectPath would check, reading the new version of each file with read
+func (s *DiffScanService) scanChange(ctx context.Context, projectPath string, target string, filter models.PathFilter, change string, diff func() (string, error), read func(file string) (string, error)) (*models.DiffScanResult, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := newPathFilter(projectPath, filter)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := diff()
+	if err != nil {
+		return nil, fmt.Errorf("reading the %s: %v", change, err)
+	}
+
+	opts := walkOptions{ignore: newProjectIgnore(projectPath), filter: paths}
+	result := &models.DiffScanResult{ProjectPath: projectPath, Change: change, Findings: []models.LineFinding{}}
+	for _, file := range parseUnifiedDiff(patch) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(file.added) == 0 || !opts.selects(projectPath, filepath.Join(projectPath, filepath.FromSlash(file.path))) {
+			continue
+		}
+		result.Files++
+		code, err := read(file.path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", file.path, err)
+		}
+		result.Findings = append(result.Findings, s.deprecationService.lineFindings(file.path, code, file.added, target)...)
+	}
+	return result, nil
+}
+
+// gitDiff returns the diff of the files below dir that the revisions in args add, modify or rename,
+// with the paths relative to dir and no context lines. A moved file only adds the lines it changed.
+func (s *DiffScanService) gitDiff(ctx context.Context, dir string, args ...string) (string, error) {
+	diffArgs := []string{"diff", "--find-renames", "--relative", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "-U0", "--diff-filter=AMR"}
+	return s.git(ctx, dir, append(append(diffArgs, args...), "--")...)
+}
+
+// git runs git in dir and returns its output
+func (s *DiffScanService) git(ctx context.Context, dir string, args ...string) (string, error) {
+	output, err := runExec(ctx, s.execTimeout, "git", append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)...)
+	if err != nil {
+		return "", gitError(err)
+	}
+	return string(output), nil
+}
+
+// parseGitRange validates a from..to or from...to range and returns its to revision
+func parseGitRange(gitRange string) (string, error) {
+	gitRange = strings.TrimSpace(gitRange)
+	from, to, found := strings.Cut(gitRange, "..")
+	to = strings.TrimPrefix(to, ".")
+	if !found || from == "" || strings.HasPrefix(gitRange, "-") || strings.ContainsAny(gitRange, " \t\n:") || strings.Contains(to, "..") {
+		return "", fmt.Errorf("invalid range %q, expected from..to or from...to such as main..HEAD", gitRange)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return to, nil
+}
+
+// parseUnifiedDiff splits a unified diff, such as the output of git diff or diff -u, into its
+// files, leaving out deleted ones. The hunks are read by their line counts, so that removed and
+// added lines that look like file headers are not taken for them.
+func parseUnifiedDiff(diff string) []diffFile {
+	var files []diffFile
+	current := -1
+	oldLeft, newLeft, line := 0, 0, 0
+	for _, text := range strings.Split(diff, "\n") {
+		text = strings.TrimSuffix(text, "\r")
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if current >= 0 {
+					files[current].added[line] = true
+				}
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, `\`):
+				// "\ No newline at end of file"
+			default:
+				line++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			current = -1
+			if path := diffPath(strings.TrimPrefix(text, "+++ ")); path != "" {
+				files = append(files, diffFile{path: path, added: make(map[int]bool)})
+				current = len(files) - 1
+			}
+		case strings.HasPrefix(text, "@@ "):
+			if matches := hunkRangePattern.FindStringSubmatch(text); matches != nil {
+				oldLeft, newLeft = hunkCount(matches[1]), hunkCount(matches[3])
+				line, _ = strconv.Atoi(matches[2])
+			}
+		}
+	}
+	return files
+}
+
+// hunkCount reads the line count of a hunk range, which is 1 when it is left out
+func hunkCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// diffPath reads the path of a +++ line of a diff, which git quotes when it has unusual
+// characters, returning "" for /dev/null. Timestamps that diff -u appends are dropped.
+func diffPath(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+	} else if tab := strings.IndexByte(path, '\t'); tab >= 0 {
+		path = path[:tab]
+	}
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, "b/")
+}
+
+// lineFindings returns the uses of deprecated APIs in code, the contents of the file at path, that
+// are on the 1-based lines in lines
+func (d *DeprecationService) lineFindings(path string, code string, lines map[int]bool, target string) []models.LineFinding {
+	positions := newDartPositions(code)
+	var findings []models.LineFinding
+	for _, use := range d.deprecatedUses(code, target) {
+		line, column := positions.lineColumn(use.start)
+		if lines[line] {
+			findings = append(findings, models.LineFinding{Path: path, Line: line, Column: column, Deprecation: use.dep})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := "diff --git a/lib/a.dart b/lib/a.dart\nindex 1..2 100644\n--- a/lib/a.dart\n+++ b/lib/a.dart\n@@ -2 +2 @@\n-old\n+new\n@@ -9,0 +10,2 @@\n+++counter;\n+more\n" +
+		"diff --git a/lib/gone.dart b/lib/gone.dart\ndeleted file mode 100644\n--- a/lib/gone.dart\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n" +
+		"diff --git \"a/lib/caf\\303\\251 x.dart\" \"b/lib/caf\\303\\251 x.dart\"\nnew file mode 100644\n--- /dev/null\n+++ \"b/lib/caf\\303\\251 x.dart\"\n@@ -0,0 +1 @@\n+hi\n"
+	files := parseUnifiedDiff(diff)
+	expected := []diffFile{
+		{path: "lib/a.dart", added: map[int]bool{2: true, 10: true, 11: true}},
+		{path: "lib/café x.dart", added: map[int]bool{1: true}},
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, files)
+	}
+
+	// diff -u has no git headers, appends timestamps and keeps context lines; the removed "-- note"
+	// and added "++ x" lines look like file headers
+	plain := "--- lib/a.dart\t2026-10-01 10:00:00\r\n+++ lib/a.dart\t2026-10-02 10:00:00\r\n@@ -1,3 +1,3 @@\r\n keep\r\n--- note\r\n+++ x\r\n keep\r\n"
+	if files := parseUnifiedDiff(plain); !reflect.DeepEqual(files, []diffFile{{path: "lib/a.dart", added: map[int]bool{2: true}}}) {
+		t.Errorf("Expected line 2 of lib/a.dart, got %+v", files)
+	}
+}
+
+func TestParseGitRange(t *testing.T) {
+	for gitRange, expected := range map[string]string{
+		"main..HEAD":          "HEAD",
+		" origin/main...feat": "feat",
+		"v1.0.0..":            "HEAD",
+		"HEAD~3..HEAD~1":      "HEAD~1",
+	} {
+		if to, err := parseGitRange(gitRange); err != nil || to != expected {
+			t.Errorf("parseGitRange(%q): expected %q, got %q (%v)", gitRange, expected, to, err)
+		}
+	}
+	for _, invalid := range []string{"", "main", "..HEAD", "--output=x..HEAD", "a..b..c", "main..HEAD:lib", "main ..HEAD"} {
+		if _, err := parseGitRange(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestScanStaged(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	service := NewDiffScanService(depService)
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	project := filepath.Join(repo, "app")
+	writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: app\n")
+	writeFile(t, filepath.Join(project, "lib", "main.dart"), "void main() {\n  FlatButton();\n}\n")
+	writeFile(t, filepath.Join(project, config.PROJECT_IGNORE_FILE), "lib/legacy/\n")
+	writeFile(t, filepath.Join(repo, "tool", "gen.dart"), "RaisedButton()\n")
+	writeFile(t, filepath.Join(project, "lib", "old_home.dart"), "class Home {\n  final a = FlatButton();\n  final b = FlatButton();\n  final c = FlatButton();\n}\n")
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	// The staged version adds RaisedButton and moves old_home.dart; the working tree has moved on to
+	// another deprecation
+	writeFile(t, filepath.Join(project, "lib", "main.dart"), "void main() {\n  FlatButton();\n  RaisedButton();\n}\n")
+	writeFile(t, filepath.Join(project, "lib", "theme.dart"), "final c = Color.red.withOpacity(0.5);\n")
+	writeFile(t, filepath.Join(project, "lib", "theme.g.dart"), "RaisedButton()\n")
+	writeFile(t, filepath.Join(project, "lib", "legacy", "old.dart"), "RaisedButton()\n")
+	writeFile(t, filepath.Join(repo, "tool", "gen.dart"), "RaisedButton()\nFlatButton()\n")
+	runGit(t, repo, "mv", filepath.Join(project, "lib", "old_home.dart"), filepath.Join(project, "lib", "home.dart"))
+	runGit(t, repo, "add", "-A")
+	writeFile(t, filepath.Join(project, "lib", "main.dart"), "void main() {\n  FlatButton();\n  RaisedButton();\n  OutlineButton();\n}\n")
+	writeFile(t, filepath.Join(project, "lib", "unstaged.dart"), "RaisedButton()\n")
+
+	findings := func(result *models.DiffScanResult) []string {
+		var found []string
+		for _, finding := range result.Findings {
+			found = append(found, fmt.Sprintf("%s:%d:%d %s", finding.Path, finding.Line, finding.Column, finding.Deprecation.API))
+		}
+		return found
+	}
+
+	t.Run("Checks only the staged lines", func(t *testing.T) {
+		result, err := service.ScanStaged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanStaged failed: %v", err)
+		}
+		expected := []string{"lib/main.dart:3:3 RaisedButton", "lib/theme.dart:1:11 Color.withOpacity"}
+		if result.Files != 2 || !reflect.DeepEqual(findings(result), expected) {
+			t.Errorf("Expected %v in 2 files, got %v in %d", expected, findings(result), result.Files)
+		}
+	})
+
+	t.Run("Applies the filter", func(t *testing.T) {
+		result, err := service.ScanStaged(ctx, project, "", models.PathFilter{Exclude: []string{"lib/theme.dart"}})
+		if err != nil {
+			t.Fatalf("ScanStaged failed: %v", err)
+		}
+		if result.Files != 1 || len(result.Findings) != 1 {
+			t.Errorf("Expected theme.dart to be left out, got %v", findings(result))
+		}
+	})
+
+	t.Run("Checks a supplied diff against the working tree", func(t *testing.T) {
+		diff := "--- a/lib/main.dart\n+++ b/lib/main.dart\n@@ -3,0 +4 @@\n+  OutlineButton();\n--- /dev/null\n+++ b/lib/unstaged.dart\n@@ -0,0 +1 @@\n+RaisedButton()\n"
+		result, err := service.ScanDiff(ctx, project, diff, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanDiff failed: %v", err)
+		}
+		if expected := []string{"lib/main.dart:4:3 OutlineButton", "lib/unstaged.dart:1:1 RaisedButton"}; !reflect.DeepEqual(findings(result), expected) {
+			t.Errorf("Expected %v, got %v", expected, findings(result))
+		}
+
+		if _, err := service.ScanDiff(ctx, project, "--- a/lib/x.dart\n+++ b/lib/missing.dart\n@@ -0,0 +1 @@\n+x\n", "", models.PathFilter{}); err == nil || !strings.Contains(err.Error(), "reading lib/missing.dart") {
+			t.Errorf("Expected a missing file to fail, got %v", err)
+		}
+		if _, err := service.ScanDiff(ctx, project, " \n", "", models.PathFilter{}); err == nil {
+			t.Error("Expected an empty diff to fail")
+		}
+	})
+
+	t.Run("Reports nothing without staged changes", func(t *testing.T) {
+		runGit(t, repo, "commit", "-q", "-m", "changes")
+		result, err := service.ScanStaged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanStaged failed: %v", err)
+		}
+		if result.Files != 0 || len(result.Findings) != 0 {
+			t.Errorf("Expected nothing staged, got %+v", result)
+		}
+	})
+
+	t.Run("Checks a range of commits", func(t *testing.T) {
+		result, err := service.ScanRange(ctx, project, "HEAD~1..HEAD", "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanRange failed: %v", err)
+		}
+		expected := []string{"lib/main.dart:3:3 RaisedButton", "lib/theme.dart:1:11 Color.withOpacity"}
+		if result.Change != "changes in HEAD~1..HEAD" || !reflect.DeepEqual(findings(result), expected) {
+			t.Errorf("Expected %v in the committed files, got %v (%s)", expected, findings(result), result.Change)
+		}
+
+		result, err = service.ScanRange(ctx, project, "HEAD..", "", models.PathFilter{})
+		if err != nil || len(result.Findings) != 0 {
+			t.Errorf("Expected an empty range to find nothing, got %+v (%v)", result, err)
+		}
+		if _, err := service.ScanRange(ctx, project, "nope..HEAD", "", models.PathFilter{}); err == nil || !strings.Contains(err.Error(), "reading the changes in nope..HEAD") {
+			t.Errorf("Expected an unknown revision to fail, got %v", err)
+		}
+	})
+
+	t.Run("Needs a git repository", func(t *testing.T) {
+		if _, err := service.ScanStaged(ctx, t.TempDir(), "", models.PathFilter{}); err == nil || !strings.Contains(err.Error(), "reading the staged changes") {
+			t.Errorf("Expected a git error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// challengeParam matches one key="value" pair of a WWW-Authenticate challenge
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// RegistryCredential is the login used for a registry host
+type RegistryCredential struct {
+	Username string
+	Password string
+}
+
+// DockerRegistries configures where the image checks look: Mirrors maps a registry such as
+// docker.io or ghcr.io to the base URL of the registry that proxies it, including a path prefix
+// such as the repository key of Artifactory or the project of Harbor, and Credentials holds the
+// login of each host
+type DockerRegistries struct {
+	Mirrors     map[string]string
+	Credentials map[string]RegistryCredential
+}
+
+// LoadDockerRegistries builds the registry configuration from a comma separated list of
+// registry=mirror pairs, the registry logins of a docker CLI configuration file and a login
+// from the environment that applies to mirrors the file has no entry for. An empty dockerConfig
+// reads $DOCKER_CONFIG/config.json or ~/.docker/config.json when it exists.
+func LoadDockerRegistries(mirrors string, dockerConfig string, username string, password string) (DockerRegistries, error) {
+	registries := DockerRegistries{
+		Mirrors:     make(map[string]string),
+		Credentials: make(map[string]RegistryCredential),
+	}
+
+	for _, part := range strings.Split(mirrors, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		registry, mirror, ok := strings.Cut(part, "=")
+		base, err := mirrorURL(mirror)
+		if !ok || strings.TrimSpace(registry) == "" || err != nil {
+			return registries, fmt.Errorf("invalid registry mirror %q (expected registry=mirror such as docker.io=artifactory.example.com/docker-remote)", part)
+		}
+		registries.Mirrors[normalizeRegistry(registry)] = base
+	}
+
+	explicit := dockerConfig != ""
+	if !explicit {
+		dockerConfig = defaultDockerConfig()
+	}
+	if dockerConfig != "" {
+		credentials, err := readDockerCredentials(dockerConfig)
+		if err != nil && (explicit || !os.IsNotExist(err)) {
+			return registries, fmt.Errorf("reading %s: %w", dockerConfig, err)
+		}
+		for host, credential := range credentials {
+			registries.Credentials[host] = credential
+		}
+	}
+
+	if username != "" {
+		for _, mirror := range registries.Mirrors {
+			host := normalizeRegistry(mirror)
+			if _, ok := registries.Credentials[host]; !ok {
+				registries.Credentials[host] = RegistryCredential{Username: username, Password: password}
+			}
+		}
+	}
+	return registries, nil
+}
+
+// MirrorHosts lists the hosts, with their ports, that the registry mirrors are served from
+func (r DockerRegistries) MirrorHosts() []string {
+	hosts := make([]string, 0, len(r.Mirrors))
+	for _, mirror := range r.Mirrors {
+		hosts = append(hosts, registryHostOf(mirror))
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// defaultDockerConfig is the configuration file the docker CLI stores its logins in
+func defaultDockerConfig() string {
+	if dir := os.Getenv(config.DOCKER_CONFIG_ENV); dir != "" {
+		return filepath.Join(dir, config.DOCKER_CONFIG_FILE)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", config.DOCKER_CONFIG_FILE)
+}
+
+// readDockerCredentials reads the registry logins of a docker CLI configuration file. Logins kept
+// by a credential helper are not in the file and have to come from the environment instead.
+func readDockerCredentials(path string) (map[string]RegistryCredential, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	credentials := make(map[string]RegistryCredential)
+	for key, entry := range file.Auths {
+		credential := RegistryCredential{Username: entry.Username, Password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for %s: %w", key, err)
+			}
+			credential.Username, credential.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		if credential.Username != "" {
+			credentials[normalizeRegistry(key)] = credential
+		}
+	}
+	return credentials, nil
+}
+
+// mirrorURL turns a mirror such as harbor.example.com/dockerhub-proxy into a base URL, using
+// https unless the mirror names its scheme
+func mirrorURL(mirror string) (string, error) {
+	mirror = strings.TrimSpace(mirror)
+	if !strings.Contains(mirror, "://") {
+		mirror = "https://" + mirror
+	}
+	parsed, err := url.Parse(mirror)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return "", fmt.Errorf("invalid mirror %q", mirror)
+	}
+	return parsed.Scheme + "://" + parsed.Host + strings.TrimRight(parsed.Path, "/"), nil
+}
+
+// normalizeRegistry reduces a registry name, login key or URL to its host, with every name of
+// Docker Hub mapped to docker.io
+func normalizeRegistry(registry string) string {
+	host := registryHostOf(strings.TrimSpace(registry))
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com", "hub.docker.com":
+		return config.DOCKER_HUB_REGISTRY
+	}
+	return host
+}
+
+// registryHostOf returns the host of a registry URL or name, dropping its scheme and path
+func registryHostOf(registry string) string {
+	if _, rest, ok := strings.Cut(registry, "://"); ok {
+		registry = rest
+	}
+	host, _, _ := strings.Cut(registry, "/")
+	return strings.ToLower(host)
+}
+
+// imageRegistry separates the registry of an image reference from its repository. References
+// without a registry host are on Docker Hub, where official images live under library/.
+func imageRegistry(image string) (string, string) {
+	first, rest, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return normalizeRegistry(first), rest
+	}
+	if !ok {
+		return config.DOCKER_HUB_REGISTRY, "library/" + image
+	}
+	return config.DOCKER_HUB_REGISTRY, image
+}
+
+// checkRegistryImageExists asks the registry API at base for the manifest of repository:tag,
+// answering the token or basic auth challenge of the registry with its credential
+func (f *FlutterAPIService) checkRegistryImageExists(ctx context.Context, base string, repository string, tag string) bool {
+	manifest, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	prefix := strings.Trim(manifest.Path, "/")
+	manifest.Path = "/v2/" + strings.TrimPrefix(prefix+"/"+repository, "/") + "/manifests/" + tag
+
+	credential, hasCredential := f.registries.Credentials[normalizeRegistry(manifest.Host)]
+	resp, err := f.headManifest(ctx, manifest.String(), "")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		var authorization string
+		switch scheme, _, _ := strings.Cut(challenge, " "); strings.ToLower(scheme) {
+		case "bearer":
+			token, err := f.registryToken(ctx, challenge, credential, hasCredential)
+			if err != nil {
+				return false
+			}
+			authorization = "Bearer " + token
+		case "basic":
+			if !hasCredential {
+				return false
+			}
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(credential.Username+":"+credential.Password))
+		default:
+			return false
+		}
+
+		if resp, err = f.headManifest(ctx, manifest.String(), authorization); err != nil {
+			return false
+		}
+		resp.Body.Close()
+	}
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// headManifest requests a manifest without downloading it
+func (f *FlutterAPIService) headManifest(ctx context.Context, manifestURL string, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", config.DOCKER_MANIFEST_ACCEPT)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return f.do(req, UpstreamDockerRegistry)
+}
+
+// registryToken fetches a pull token from the realm of a Bearer challenge, anonymously unless
+// the registry has a credential
+func (f *FlutterAPIService) registryToken(ctx context.Context, challenge string, credential RegistryCredential, hasCredential bool) (string, error) {
+	params := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("registry challenge without a token realm: %s", challenge)
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasCredential {
+		req.SetBasicAuth(credential.Username, credential.Password)
+	}
+	resp, err := f.do(req, UpstreamDockerRegistry)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token endpoint returned status %d", resp.StatusCode)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("registry token endpoint returned no token")
+	}
+	return token.Token, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// newTokenRegistry serves the manifests of tags on repository behind a Bearer challenge, handing
+// out tokens to requests that pass the login check
+func newTokenRegistry(t *testing.T, repository string, tags []string, login func(r *http.Request) bool) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:"+repository+":pull" || !login(r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token": "pull-token"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:`+repository+`:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodHead || r.Header.Get("Accept") != config.DOCKER_MANIFEST_ACCEPT {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, tag := range tags {
+			if r.URL.Path == "/v2/"+repository+"/manifests/"+tag {
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDockerRegistries(t *testing.T) {
+	// Keep the logins of the machine running the tests out of the default configuration
+	t.Setenv(config.DOCKER_CONFIG_ENV, t.TempDir())
+	ctx := context.Background()
+
+	t.Run("Checks images on an authenticated mirror", func(t *testing.T) {
+		server := newTokenRegistry(t, "dockerhub-proxy/instrumentisto/flutter", []string{"3.27.1"}, func(r *http.Request) bool {
+			user, password, ok := r.BasicAuth()
+			return ok && user == "ci" && password == "secret"
+		})
+
+		registries, err := LoadDockerRegistries(" docker.io = "+server.URL+"/dockerhub-proxy/ ", "", "ci", "secret")
+		if err != nil {
+			t.Fatalf("LoadDockerRegistries failed: %v", err)
+		}
+
+		service := NewFlutterAPIService()
+		service.SetDockerRegistries(registries)
+		if !service.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_INSTRUMENTISTO, "3.27.1") {
+			t.Error("Expected 3.27.1 to exist on the mirror")
+		}
+		if service.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_INSTRUMENTISTO, "3.27.2") {
+			t.Error("Expected 3.27.2 to be missing on the mirror")
+		}
+
+		anonymous, _ := LoadDockerRegistries("docker.io="+server.URL+"/dockerhub-proxy", "", "", "")
+		service.SetDockerRegistries(anonymous)
+		if service.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_INSTRUMENTISTO, "3.27.1") {
+			t.Error("Expected the mirror to refuse a check without a login")
+		}
+	})
+
+	t.Run("Asks GHCR for the tag with an anonymous token", func(t *testing.T) {
+		server := newTokenRegistry(t, "cirruslabs/flutter", []string{"3.27.1"}, func(r *http.Request) bool {
+			_, _, ok := r.BasicAuth()
+			return !ok
+		})
+		service := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+
+		if !service.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_CIRRUSLABS, "3.27.1") {
+			t.Error("Expected 3.27.1 to exist on GHCR")
+		}
+		if service.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_CIRRUSLABS, "1.0.0") {
+			t.Error("Expected a tag GHCR does not have to be missing")
+		}
+	})
+
+	t.Run("Answers basic auth challenges with the docker config login", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, password, ok := r.BasicAuth(); !ok || user != "robot$ci" || password != "harbor-token" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Harbor"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path != "/v2/flutter/sdk/manifests/3.24.5" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		// The docker CLI stores base64 encoded logins keyed by registry host or URL
+		dockerConfig := filepath.Join(t.TempDir(), "config.json")
+		writeFile(t, dockerConfig, `{"auths": {
+			"`+server.URL+`": {"auth": "cm9ib3QkY2k6aGFyYm9yLXRva2Vu"},
+			"https://index.docker.io/v1/": {"username": "hub-user", "password": "hub-token"}
+		}}`)
+
+		registries, err := LoadDockerRegistries("registry.example.com="+server.URL, dockerConfig, "", "")
+		if err != nil {
+			t.Fatalf("LoadDockerRegistries failed: %v", err)
+		}
+		if hub := registries.Credentials[config.DOCKER_HUB_REGISTRY]; hub.Username != "hub-user" || hub.Password != "hub-token" {
+			t.Errorf("Expected the Docker Hub login under docker.io, got %+v", registries.Credentials)
+		}
+
+		service := NewFlutterAPIService()
+		service.SetDockerRegistries(registries)
+		if !service.CheckDockerImageExists(ctx, "registry.example.com/flutter/sdk", "3.24.5") {
+			t.Error("Expected the basic auth login to be sent")
+		}
+		if service.CheckDockerImageExists(ctx, "registry.example.com/flutter/sdk", "3.27.1") {
+			t.Error("Expected a missing tag to be reported as missing")
+		}
+	})
+
+	t.Run("Rejects invalid mirrors and config files", func(t *testing.T) {
+		if _, err := LoadDockerRegistries("", filepath.Join(t.TempDir(), "missing.json"), "", ""); err == nil {
+			t.Error("Expected an error for a missing --docker-config file")
+		}
+		for _, mirrors := range []string{"docker.io", "=harbor.example.com", "ghcr.io=ftp://mirror.example.com"} {
+			if _, err := LoadDockerRegistries(mirrors, "", "", ""); err == nil {
+				t.Errorf("Expected an error for %q", mirrors)
+			}
+		}
+	})
+}
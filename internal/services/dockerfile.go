@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// dockerTarget describes how one kind of Flutter build is produced inside a container
+type dockerTarget struct {
+	command  string
+	output   string
+	packages string
+}
+
+// dockerTargets lists the supported build targets and where their artifacts end up
+var dockerTargets = map[string]dockerTarget{
+	"web":       {command: "flutter build web --release", output: "build/web"},
+	"apk":       {command: "flutter build apk --release", output: "build/app/outputs/flutter-apk/app-release.apk"},
+	"appbundle": {command: "flutter build appbundle --release", output: "build/app/outputs/bundle/release/app-release.aab"},
+	"linux": {
+		command:  "flutter config --enable-linux-desktop && flutter build linux --release",
+		output:   "build/linux/x64/release/bundle",
+		packages: "clang cmake ninja-build pkg-config libgtk-3-dev liblzma-dev",
+	},
+}
+
+// dockerImages maps the image names accepted by generate_dockerfile to registry images
+var dockerImages = map[string]string{
+	"instrumentisto": config.DOCKER_IMAGE_INSTRUMENTISTO,
+	"cirruslabs":     config.DOCKER_IMAGE_CIRRUSLABS,
+}
+
+// dockerignore keeps local build output and tool state out of the build context
+const dockerignore = `.dart_tool/
+.packages
+build/
+.flutter-plugins
+.flutter-plugins-dependencies
+.idea/
+.vscode/
+`
+
+// DockerfileService writes Dockerfiles that build a Flutter app on a published base image
+type DockerfileService struct {
+	apiService FlutterAPIServiceInterface
+}
+
+// NewDockerfileService creates a new Dockerfile service instance
+func NewDockerfileService(apiService FlutterAPIServiceInterface) *DockerfileService {
+	return &DockerfileService{apiService: apiService}
+}
+
+// Generate renders a Dockerfile for target at the given Flutter version (the latest stable release
+// when empty), using the preferred base image if it publishes that tag and the other image otherwise
+func (d *DockerfileService) Generate(ctx context.Context, version string, target string, image string) (*models.DockerfileResult, error) {
+	target = strings.ToLower(strings.TrimSpace(target))
+	if target == "" {
+		target = "web"
+	}
+	build, ok := dockerTargets[target]
+	if !ok {
+		return nil, fmt.Errorf("unsupported target %q (expected web, apk, appbundle or linux)", target)
+	}
+
+	candidates := []string{config.DOCKER_IMAGE_INSTRUMENTISTO, config.DOCKER_IMAGE_CIRRUSLABS}
+	if image = strings.ToLower(strings.TrimSpace(image)); image != "" {
+		preferred, ok := dockerImages[image]
+		if !ok {
+			return nil, fmt.Errorf("unknown image %q (expected instrumentisto or cirruslabs)", image)
+		}
+		if preferred != candidates[0] {
+			candidates[0], candidates[1] = candidates[1], candidates[0]
+		}
+	}
+
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" {
+		latest, err := d.apiService.GetLatestStableVersion(ctx)
+		if err == nil && latest == "" {
+			err = fmt.Errorf("no stable release found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the latest stable Flutter version: %v", err)
+		}
+		version = latest
+	} else if !stableVersionPattern.MatchString(version) {
+		return nil, fmt.Errorf("invalid version %q: image tags need a full release such as 3.27.1", version)
+	}
+
+	result := &models.DockerfileResult{Version: version, Target: target, Dockerignore: dockerignore}
+	for _, candidate := range candidates {
+		if d.apiService.CheckDockerImageExists(ctx, candidate, version) {
+			result.Image = candidate + ":" + version
+			break
+		}
+		result.Unavailable = append(result.Unavailable, candidate+":"+version)
+	}
+	if result.Image == "" {
+		return nil, fmt.Errorf("neither %s nor %s publishes a %s tag", config.DOCKER_IMAGE_INSTRUMENTISTO, config.DOCKER_IMAGE_CIRRUSLABS, version)
+	}
+
+	result.Dockerfile = dockerfile(result.Image, build, target)
+	if target == "web" {
+		result.Compose = webCompose
+		result.Usage = "docker build -t flutter-web . && docker run --rm -p 8080:80 flutter-web"
+	} else {
+		result.Usage = "DOCKER_BUILDKIT=1 docker build --output type=local,dest=out ."
+	}
+	return result, nil
+}
+
+// dockerfile renders a multi-stage Dockerfile: the app is built on the Flutter image, then web
+// builds are served by nginx and other targets are exported as a bare artifact stage
+func dockerfile(image string, build dockerTarget, target string) string {
+	var b strings.Builder
+	b.WriteString("# syntax=docker/dockerfile:1\n")
+	fmt.Fprintf(&b, "FROM %s AS build\n", image)
+	if build.packages != "" {
+		fmt.Fprintf(&b, "RUN apt-get update \\\n    && apt-get install -y --no-install-recommends %s \\\n    && rm -rf /var/lib/apt/lists/*\n", build.packages)
+	}
+	b.WriteString("WORKDIR /app\n\n")
+	b.WriteString("# Resolve dependencies first so they are cached until pubspec changes\n")
+	b.WriteString("COPY pubspec.yaml pubspec.lock* ./\n")
+	b.WriteString("RUN flutter pub get\n\n")
+	b.WriteString("COPY . .\n")
+	fmt.Fprintf(&b, "RUN %s\n\n", build.command)
+
+	if target == "web" {
+		b.WriteString("FROM nginx:alpine\n")
+		fmt.Fprintf(&b, "COPY --from=build /app/%s /usr/share/nginx/html\n", build.output)
+		b.WriteString("EXPOSE 80\n")
+		return b.String()
+	}
+
+	b.WriteString("# Export the artifact with: docker build --output type=local,dest=out .\n")
+	b.WriteString("FROM scratch AS artifact\n")
+	fmt.Fprintf(&b, "COPY --from=build /app/%s /\n", build.output)
+	return b.String()
+}
+
+// webCompose serves the web build produced by the generated Dockerfile
+const webCompose = `services:
+  web:
+    build: .
+    ports:
+      - "8080:80"
+    restart: unless-stopped
+`
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestDockerfileService(t *testing.T) {
+	apiService := &MockFlutterAPIService{
+		dockerResults: map[string]bool{
+			config.DOCKER_IMAGE_INSTRUMENTISTO + ":3.27.1": true,
+			config.DOCKER_IMAGE_CIRRUSLABS + ":3.27.1":     true,
+			config.DOCKER_IMAGE_CIRRUSLABS + ":3.29.0":     true,
+		},
+	}
+	service := NewDockerfileService(apiService)
+	ctx := context.Background()
+
+	t.Run("web build on the preferred image", func(t *testing.T) {
+		result, err := service.Generate(ctx, "v3.27.1", "", "")
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if result.Image != "instrumentisto/flutter:3.27.1" || result.Target != "web" {
+			t.Errorf("Unexpected image %s for target %s", result.Image, result.Target)
+		}
+		for _, expected := range []string{"FROM instrumentisto/flutter:3.27.1 AS build", "RUN flutter build web --release", "COPY --from=build /app/build/web /usr/share/nginx/html"} {
+			if !strings.Contains(result.Dockerfile, expected) {
+				t.Errorf("Expected Dockerfile to contain %q:\n%s", expected, result.Dockerfile)
+			}
+		}
+		if !strings.Contains(result.Compose, `"8080:80"`) {
+			t.Errorf("Expected a compose service for web, got %q", result.Compose)
+		}
+
+		result, _ = service.Generate(ctx, "3.27.1", "web", "cirruslabs")
+		if result.Image != "ghcr.io/cirruslabs/flutter:3.27.1" {
+			t.Errorf("Expected the cirruslabs image to be preferred, got %s", result.Image)
+		}
+	})
+
+	t.Run("falls back to the image that publishes the tag", func(t *testing.T) {
+		result, err := service.Generate(ctx, "3.29.0", "linux", "instrumentisto")
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if result.Image != "ghcr.io/cirruslabs/flutter:3.29.0" || len(result.Unavailable) != 1 {
+			t.Errorf("Expected a fallback to cirruslabs, got %s (unavailable %v)", result.Image, result.Unavailable)
+		}
+		if !strings.Contains(result.Dockerfile, "libgtk-3-dev") || !strings.Contains(result.Dockerfile, "FROM scratch AS artifact") {
+			t.Errorf("Expected a linux build exported as an artifact:\n%s", result.Dockerfile)
+		}
+		if result.Compose != "" {
+			t.Error("Expected no compose service for an artifact build")
+		}
+	})
+
+	t.Run("rejects unusable input", func(t *testing.T) {
+		testCases := []struct{ version, target, image string }{
+			{"3.30.0", "web", ""},
+			{"3.27", "web", ""},
+			{"3.27.1", "ios", ""},
+			{"3.27.1", "web", "ubuntu"},
+			{"", "web", ""},
+		}
+		for _, tc := range testCases {
+			if _, err := service.Generate(ctx, tc.version, tc.target, tc.image); err == nil {
+				t.Errorf("Expected an error for %+v", tc)
+			}
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// execTimeoutError reports an external command that did not finish in time, such as a hung
+// wrapper script, as opposed to one that is missing or failed
+type execTimeoutError struct {
+	command string
+	timeout time.Duration
+}
+
+func (e *execTimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.command, e.timeout)
+}
+
+// runExec runs an external command and returns its standard output, killing it once timeout has
+// passed. A zero timeout leaves the command to ctx alone. The timeout starts once the command got
+// one of the exec slots.
+func runExec(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if err := execSlots.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer execSlots.release()
+
+	execCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(execCtx, name, args...)
+	// Killing a wrapper script leaves its children holding the output pipe, so stop waiting for them
+	cmd.WaitDelay = config.EXEC_WAIT_DELAY
+	output, err := cmd.Output()
+	if err != nil && ctx.Err() == nil && errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+		timeoutErr := &execTimeoutError{command: strings.Join(append([]string{name}, args...), " "), timeout: timeout}
+		slog.Warn("External command timed out", "command", timeoutErr.command, "timeout", timeout)
+		return output, timeoutErr
+	}
+	return output, err
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// LoadFindingsBaseline reads the findings baseline at path
+func LoadFindingsBaseline(path string) (*models.FindingsBaseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var baseline models.FindingsBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("%s is not a findings baseline: %v", path, err)
+	}
+	return &baseline, nil
+}
+
+// WriteFindingsBaseline writes a findings baseline to path, indented so that it reviews well in
+// version control
+func WriteFindingsBaseline(path string, baseline *models.FindingsBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// FindingsBaseline records the findings of a project scan with the number of uses of each API
+// per file, reading the files again to count them. target selects the rules the scan applied.
+func (d *DeprecationService) FindingsBaseline(result *models.ProjectScanResult, target string) (*models.FindingsBaseline, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &models.FindingsBaseline{Findings: []models.BaselineFinding{}}
+	for _, pkg := range result.Packages {
+		for _, finding := range pkg.Findings {
+			if finding.Error != "" {
+				continue
+			}
+			for api, count := range d.useCounts(finding, target) {
+				baseline.Findings = append(baseline.Findings, models.BaselineFinding{Path: finding.Name, API: api, Count: count})
+			}
+		}
+	}
+	sort.Slice(baseline.Findings, func(i, j int) bool {
+		a, b := baseline.Findings[i], baseline.Findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.API < b.API
+	})
+	return baseline, nil
+}
+
+// ApplyFindingsBaseline leaves the findings of baseline out of a project scan and counts them in
+// its Baselined. An API a file uses more often than the baseline records stays in, as one of its
+// uses is new. Files are matched by their path in the project, so moving a file reports its
+// findings again.
+func (d *DeprecationService) ApplyFindingsBaseline(result *models.ProjectScanResult, baseline *models.FindingsBaseline, target string) error {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[[2]string]int, len(baseline.Findings))
+	for _, finding := range baseline.Findings {
+		allowed[[2]string{finding.Path, finding.API}] += finding.Count
+	}
+
+	for i := range result.Packages {
+		pkg := &result.Packages[i]
+		findings := pkg.Findings[:0]
+		for _, finding := range pkg.Findings {
+			if finding.Error == "" {
+				counts := d.useCounts(finding, target)
+				var deprecations []models.Deprecation
+				for _, dep := range finding.Deprecations {
+					if counts[dep.API] > allowed[[2]string{finding.Name, dep.API}] {
+						deprecations = append(deprecations, dep)
+					} else {
+						result.Baselined++
+					}
+				}
+				if len(deprecations) == 0 {
+					continue
+				}
+				finding.Deprecations = deprecations
+			}
+			findings = append(findings, finding)
+		}
+		pkg.Findings = findings
+	}
+	return nil
+}
+
+// useCounts counts the uses of each API a scan found in a file, reading it again. An API that
+// matched without a use it can be located at counts once.
+func (d *DeprecationService) useCounts(finding models.FileCheckResult, target string) map[string]int {
+	counts := make(map[string]int, len(finding.Deprecations))
+	for _, dep := range finding.Deprecations {
+		counts[dep.API] = 0
+	}
+	if code, err := readCheckFile(finding.Path, ""); err == nil {
+		for _, use := range d.deprecatedUses(code, target) {
+			if _, found := counts[use.dep.API]; found {
+				counts[use.dep.API]++
+			}
+		}
+	}
+	for api, count := range counts {
+		counts[api] = max(count, 1)
+	}
+	return counts
+}
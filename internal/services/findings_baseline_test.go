@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestFindingsBaseline(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+	scans := NewProjectScanService(depService)
+	ctx := context.Background()
+
+	project := t.TempDir()
+	writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: app\n")
+	writeFile(t, filepath.Join(project, "lib", "main.dart"), "void main() {\n  RaisedButton();\n  RaisedButton();\n  FlatButton();\n}\n")
+	writeFile(t, filepath.Join(project, "lib", "theme.dart"), "final c = Color.red.withOpacity(0.5);\n")
+
+	result, err := scans.Scan(ctx, project, "", models.PathFilter{})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	baseline, err := depService.FindingsBaseline(result, "")
+	if err != nil {
+		t.Fatalf("FindingsBaseline failed: %v", err)
+	}
+	expected := []models.BaselineFinding{
+		{Path: "lib/main.dart", API: "FlatButton", Count: 1},
+		{Path: "lib/main.dart", API: "RaisedButton", Count: 2},
+		{Path: "lib/theme.dart", API: "Color.withOpacity", Count: 1},
+	}
+	if !reflect.DeepEqual(baseline.Findings, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, baseline.Findings)
+	}
+
+	path := filepath.Join(project, "baseline.json")
+	if err := WriteFindingsBaseline(path, baseline); err != nil {
+		t.Fatalf("WriteFindingsBaseline failed: %v", err)
+	}
+	loaded, err := LoadFindingsBaseline(path)
+	if err != nil || !reflect.DeepEqual(loaded, baseline) {
+		t.Fatalf("Expected the written baseline back, got %+v (%v)", loaded, err)
+	}
+
+	t.Run("Leaves out the recorded findings", func(t *testing.T) {
+		result, _ := scans.Scan(ctx, project, "", models.PathFilter{})
+		if err := depService.ApplyFindingsBaseline(result, loaded, ""); err != nil {
+			t.Fatalf("ApplyFindingsBaseline failed: %v", err)
+		}
+		if len(result.Packages[0].Findings) != 0 || result.Baselined != 3 {
+			t.Errorf("Expected every finding to be baselined, got %+v", result)
+		}
+	})
+
+	t.Run("Reports new uses", func(t *testing.T) {
+		writeFile(t, filepath.Join(project, "lib", "main.dart"), "void main() {\n  RaisedButton();\n  RaisedButton();\n  RaisedButton();\n}\n")
+		writeFile(t, filepath.Join(project, "lib", "home.dart"), "FlatButton()\n")
+		result, _ := scans.Scan(ctx, project, "", models.PathFilter{})
+		if err := depService.ApplyFindingsBaseline(result, loaded, ""); err != nil {
+			t.Fatalf("ApplyFindingsBaseline failed: %v", err)
+		}
+		var found []string
+		for _, finding := range result.Packages[0].Findings {
+			for _, dep := range finding.Deprecations {
+				found = append(found, finding.Name+" "+dep.API)
+			}
+		}
+		// The third RaisedButton and FlatButton in a file that did not use it are new
+		if expected := []string{"lib/home.dart FlatButton", "lib/main.dart RaisedButton"}; !reflect.DeepEqual(found, expected) || result.Baselined != 1 {
+			t.Errorf("Expected %v with theme.dart baselined, got %v (%d baselined)", expected, found, result.Baselined)
+		}
+	})
+
+	t.Run("Rejects invalid files", func(t *testing.T) {
+		writeFile(t, path, "not json")
+		if _, err := LoadFindingsBaseline(path); err == nil {
+			t.Error("Expected an error for a file that is not a baseline")
+		}
+		if _, err := LoadFindingsBaseline(filepath.Join(project, "missing.json")); err == nil {
+			t.Error("Expected an error for a missing file")
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// fixDataGlobs locate the data-driven fixes of the packages of a Flutter SDK, relative to its root
+var fixDataGlobs = []string{
+	filepath.Join("packages", "*", "lib", "fix_data.yaml"),
+	filepath.Join("packages", "*", "lib", "fix_data", "*.yaml"),
+}
+
+// fixDataFile is the part of a fix_data.yaml file the provider reads: the transforms dart fix
+// applies to the uses of deprecated elements
+type fixDataFile struct {
+	Transforms []struct {
+		Title   string         `yaml:"title"`
+		Element map[string]any `yaml:"element"`
+		Changes []struct {
+			Kind    string `yaml:"kind"`
+			NewName string `yaml:"newName"`
+		} `yaml:"changes"`
+	} `yaml:"transforms"`
+}
+
+// fixDataRuleProvider supplies the elements the data-driven fixes of a Flutter SDK migrate
+type fixDataRuleProvider struct {
+	sdkRoot string
+
+	mu      sync.Mutex
+	loaded  bool
+	entries []models.Deprecation
+}
+
+// NewFixDataRuleProvider creates the provider of the deprecated elements the fix_data.yaml files of
+// the Flutter SDK at sdkRoot migrate, or of the flutter on PATH when sdkRoot is empty. They only
+// apply to Flutter code.
+func NewFixDataRuleProvider(sdkRoot string) RuleProvider {
+	return &fixDataRuleProvider{sdkRoot: sdkRoot}
+}
+
+// Name implements RuleProvider
+func (f *fixDataRuleProvider) Name() string {
+	return config.RULE_PROVIDER_FIX_DATA
+}
+
+// Rules implements RuleProvider
+func (f *fixDataRuleProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	if target == config.TARGET_DART {
+		return nil, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.loaded {
+		f.load()
+	}
+	return nil, f.entries
+}
+
+// Refresh implements RuleRefresher, reading the fix data again in case the SDK was upgraded
+func (f *fixDataRuleProvider) Refresh(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.load()
+	return nil
+}
+
+// load reads the fix data of the SDK; without an SDK there are no entries
+func (f *fixDataRuleProvider) load() {
+	f.loaded = true
+	f.entries = nil
+
+	root := f.sdkRoot
+	if root == "" {
+		flutter := locateExecutable("flutter")
+		if !filepath.IsAbs(flutter) {
+			return
+		}
+		var err error
+		if root, err = ResolveFlutterSDK(flutter); err != nil {
+			return
+		}
+	}
+
+	var paths []string
+	for _, glob := range fixDataGlobs {
+		matches, _ := filepath.Glob(filepath.Join(root, glob))
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			slog.Warn("Failed to read fix data", "path", path, "error", err)
+			continue
+		}
+		entries, err := parseFixData(data)
+		if err != nil {
+			slog.Warn("Failed to parse fix data", "path", path, "error", err)
+			continue
+		}
+		for _, dep := range entries {
+			if !seen[dep.API] {
+				seen[dep.API] = true
+				f.entries = append(f.entries, dep)
+			}
+		}
+	}
+	slog.Debug("Loaded fix data", "sdk", root, "files", len(paths), "deprecations", len(f.entries))
+}
+
+// fixDataMembers are the element kinds of members, which the fix data places in a container
+var fixDataMembers = []string{"method", "getter", "setter", "field", "constant", "constructor"}
+
+// fixDataTopLevel are the element kinds of top-level declarations
+var fixDataTopLevel = []string{"class", "mixin", "enum", "extension", "function", "variable", "typedef"}
+
+// fixDataContainers are the keys naming the container of a member
+var fixDataContainers = []string{"inClass", "inEnum", "inMixin", "inExtension"}
+
+// parseFixData turns the transforms of a fix_data.yaml file into entries named like the ones
+// scanned from the Flutter sources, Class.member for members. Unnamed constructors are left out,
+// since their uses cannot be told apart from the class name.
+func parseFixData(data []byte) ([]models.Deprecation, error) {
+	var file fixDataFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid fix data: %v", err)
+	}
+
+	var deprecations []models.Deprecation
+	for _, transform := range file.Transforms {
+		element := func(key string) string {
+			value, _ := transform.Element[key].(string)
+			return strings.TrimSpace(value)
+		}
+
+		var api, container string
+		for _, key := range fixDataContainers {
+			if container = element(key); container != "" {
+				break
+			}
+		}
+		if container != "" {
+			for _, kind := range fixDataMembers {
+				if member := element(kind); member != "" {
+					api = container + "." + member
+					break
+				}
+			}
+		} else {
+			for _, kind := range fixDataTopLevel {
+				if api = element(kind); api != "" {
+					break
+				}
+			}
+		}
+		if api == "" {
+			continue
+		}
+
+		dep := models.Deprecation{
+			API:         api,
+			Description: strings.TrimSpace(transform.Title),
+			Severity:    config.SEVERITY_WARNING,
+			Source:      config.DEPRECATION_SOURCE_FIX_DATA,
+		}
+		for _, change := range transform.Changes {
+			if change.Kind == "rename" && change.NewName != "" {
+				dep.Replacement = change.NewName
+				if container != "" {
+					dep.Replacement = container + "." + change.NewName
+				}
+				break
+			}
+		}
+		if uris, ok := transform.Element["uris"].([]any); ok && len(uris) > 0 {
+			if uri, ok := uris[0].(string); ok {
+				dep.Category = strings.TrimSuffix(filepath.Base(uri), ".dart")
+			}
+		}
+		if dep.Description == "" {
+			dep.Description = fmt.Sprintf("%s is deprecated", api)
+		}
+		dep.DocURL = apiDocumentationURL(dep)
+		deprecations = append(deprecations, dep)
+	}
+	return deprecations, nil
+}
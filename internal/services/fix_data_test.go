@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestParseFixData(t *testing.T) {
+	entries, err := parseFixData([]byte(`version: 1
+transforms:
+  - title: "Rename to 'ElevatedButton'"
+    date: 2020-09-24
+    element:
+      uris: [ 'material.dart' ]
+      class: 'RaisedButton'
+    changes:
+      - kind: 'rename'
+        newName: 'ElevatedButton'
+  - title: "Migrate to 'withValues'"
+    element:
+      uris: [ 'painting.dart', 'dart:ui' ]
+      method: 'withOpacity'
+      inClass: 'Color'
+    changes:
+      - kind: 'replacedBy'
+  - title: "Remove 'dragStartBehavior'"
+    element:
+      uris: [ 'widgets.dart' ]
+      constructor: ''
+      inClass: 'Scrollable'
+    changes: []
+  - title: "Rename to 'fromSwatch'"
+    element:
+      uris: [ 'material.dart' ]
+      constructor: 'fromSwatchOld'
+      inClass: 'ColorScheme'
+    changes:
+      - kind: 'rename'
+        newName: 'fromSwatch'
+`))
+	if err != nil {
+		t.Fatalf("parseFixData failed: %v", err)
+	}
+
+	expected := []models.Deprecation{
+		{API: "RaisedButton", Replacement: "ElevatedButton", Description: "Rename to 'ElevatedButton'", Category: "material"},
+		{API: "Color.withOpacity", Description: "Migrate to 'withValues'", Category: "painting"},
+		{API: "ColorScheme.fromSwatchOld", Replacement: "ColorScheme.fromSwatch", Description: "Rename to 'fromSwatch'", Category: "material"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries without the unnamed constructor, got %+v", len(expected), entries)
+	}
+	for i, dep := range entries {
+		want := expected[i]
+		if dep.API != want.API || dep.Replacement != want.Replacement || dep.Description != want.Description || dep.Category != want.Category {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, want, dep)
+		}
+		if dep.Source != config.DEPRECATION_SOURCE_FIX_DATA || dep.Severity != config.SEVERITY_WARNING || dep.DocURL == "" {
+			t.Errorf("Entry %d: expected a fix data warning with a documentation link, got %+v", i, dep)
+		}
+	}
+
+	if _, err := parseFixData([]byte("transforms: {")); err == nil {
+		t.Error("Expected invalid YAML to fail")
+	}
+}
+
+func TestFixDataRuleProvider(t *testing.T) {
+	sdk := t.TempDir()
+	writeFile(t, filepath.Join(sdk, "packages", "flutter", "lib", "fix_data", "fix_material.yaml"), "transforms:\n  - title: Rename\n    element:\n      uris: ['material.dart']\n      class: 'RaisedButton'\n    changes:\n      - kind: rename\n        newName: ElevatedButton\n")
+	writeFile(t, filepath.Join(sdk, "packages", "flutter_test", "lib", "fix_data.yaml"), "transforms:\n  - title: Use pump\n    element:\n      uris: ['flutter_test.dart']\n      method: 'pumpOld'\n      inClass: 'WidgetTester'\n    changes: []\n")
+
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	provider := NewFixDataRuleProvider(sdk)
+	service.SetRuleProviders(provider)
+
+	if found := service.CheckCodeForDeprecations("RaisedButton(); tester.pumpOld(); WidgetTester.pumpOld"); len(found) != 2 {
+		t.Errorf("Expected the fixes of both packages, got %+v", found)
+	}
+	if migrated := service.MigrateCode("RaisedButton()").Code; migrated != "ElevatedButton()" {
+		t.Errorf("Expected the fix data rename to be applied, got %q", migrated)
+	}
+	if found := service.CheckDartCode("RaisedButton()"); len(found) != 0 {
+		t.Errorf("Expected fix data to be skipped for Dart code, got %+v", found)
+	}
+
+	// The data is read once; a refresh picks up an upgraded SDK
+	if err := os.RemoveAll(filepath.Join(sdk, "packages", "flutter_test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, entries := provider.Rules(config.TARGET_FLUTTER); len(entries) != 2 {
+		t.Errorf("Expected the loaded data to be kept, got %+v", entries)
+	}
+	if err := provider.(RuleRefresher).Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, entries := provider.Rules(config.TARGET_FLUTTER); len(entries) != 1 {
+		t.Errorf("Expected the refresh to reread the SDK, got %+v", entries)
+	}
+}
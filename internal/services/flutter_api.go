@@ -2,47 +2,250 @@ package services
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
-	"os/exec"
+	"net/url"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
 	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
+// Patterns used while scanning Dart source, compiled once rather than per file
+var (
+	// Start of a @Deprecated annotation; the message can span several lines
+	deprecatedPattern = regexp.MustCompile(`@[Dd]eprecated\s*\(`)
+
+	// Version Flutter appends to deprecation messages: "This feature was deprecated after v3.18.0-0.1.pre."
+	deprecatedAfterPattern = regexp.MustCompile(`(?i)deprecated\s+after\s+(v?\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)`)
+
+	// More comprehensive patterns for different Dart constructs
+	classPattern       = regexp.MustCompile(`(?:abstract\s+)?(?:class|enum|mixin)\s+(\w+)`)
+	methodPattern      = regexp.MustCompile(`(?:(?:static|final|const)\s+)*(?:[\w<>?]+\s+)?(\w+)\s*\(`)
+	constructorPattern = regexp.MustCompile(`(\w+)\s*\.\s*(\w+)\s*\(`)
+	propertyPattern    = regexp.MustCompile(`(?:(?:static|final|const)\s+)*(?:[\w<>?]+\s+)+(get\s+)?(\w+)(?:\s*[;=]|\s*=>)`)
+	getterPattern      = regexp.MustCompile(`(?:[\w<>?]+\s+)?get\s+(\w+)\s*(?:=>|{)`)
+	setterPattern      = regexp.MustCompile(`set\s+(\w+)\s*\(`)
+
+	// Replacement hints inside deprecation messages, tried in order
+	replacementPatterns = []*regexp.Regexp{
+		// Pattern 1: "Use X instead"
+		regexp.MustCompile(`(?i)use\s+([A-Za-z0-9_.()]+)(?:\s+instead)?`),
+		// Pattern 2: "Replaced by X"
+		regexp.MustCompile(`(?i)replaced\s+by\s+([A-Za-z0-9_.()]+)`),
+		// Pattern 3: "Use X() method"
+		regexp.MustCompile(`(?i)use\s+(?:the\s+)?([A-Za-z0-9_.()]+)\s+method`),
+		// Pattern 4: "Prefer X"
+		regexp.MustCompile(`(?i)prefer\s+([A-Za-z0-9_.()]+)`),
+	}
+
+	// stableVersionPattern matches a pure semantic version with no suffixes
+	stableVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+)
+
+// inferredReplacement maps a lowercase API fragment to its suggested replacement
+type inferredReplacement struct {
+	pattern     string
+	replacement string
+}
+
+// inferredReplacements are checked in order, so more specific fragments come first
+var inferredReplacements = []inferredReplacement{
+	// Color patterns
+	{"color.withopacity", "color.withValues(alpha: value)"},
+	{"withopacity", "withValues(alpha: value)"},
+
+	// Button patterns
+	{"raisedbutton", "ElevatedButton"},
+	{"flatbutton", "TextButton"},
+	{"outlinebutton", "OutlinedButton"},
+	{"materialbutton", "ElevatedButton, TextButton, or OutlinedButton"},
+
+	// Material patterns
+	{"floatingactionbutton.mini", "FloatingActionButton(mini: true)"},
+
+	// Navigator patterns
+	{"navigator.of(context).push", "Navigator.push(context, route)"},
+	{"navigator.of(context).pop", "Navigator.pop(context)"},
+
+	// Scaffold patterns
+	{"scaffold.of(context).showsnackbar", "ScaffoldMessenger.of(context).showSnackBar"},
+
+	// Text patterns
+	{"text.overflow", "Text with overflow parameter"},
+	{"textstyle.height", "TextStyle.height or TextHeightBehavior"},
+
+	// Widget patterns
+	{"wrap.direction", "Wrap.direction parameter"},
+	{"flex.direction", "Flex.direction parameter"},
+
+	// Animation patterns
+	{"animationcontroller.reset", "AnimationController.reset() alternative"},
+	{"tween.animate", "Tween.animate() or AnimatedBuilder"},
+
+	// Layout patterns
+	{"positioned.fill", "Positioned.fill() constructor"},
+	{"expanded.flex", "Expanded(flex: value)"},
+	{"flexible.flex", "Flexible(flex: value)"},
+}
+
 // FlutterAPIService handles Flutter API interactions
-type FlutterAPIService struct{}
+type FlutterAPIService struct {
+	client      *http.Client
+	stats       StatsRecorder
+	registries  DockerRegistries
+	githubToken string
+	execTimeout time.Duration
+
+	// maxScanFileSize is the size above which source files are not scanned, or 0 for MAX_SCAN_FILE_SIZE
+	maxScanFileSize int64
+
+	// previewChannel is the beta or master branch scanned for upcoming deprecations, if any
+	previewChannel string
+
+	rateMu    sync.Mutex
+	rateLimit *models.RateLimitStatus
+
+	// flights shares the releases and Docker image lookups that concurrent tool calls ask for at once
+	flights flightGroup
+}
 
 // NewFlutterAPIService creates a new Flutter API service instance
 func NewFlutterAPIService() *FlutterAPIService {
-	return &FlutterAPIService{}
+	return &FlutterAPIService{client: http.DefaultClient, execTimeout: config.DEFAULT_EXEC_TIMEOUT}
+}
+
+// SetStatsRecorder reports the latency of every upstream call to recorder
+func (f *FlutterAPIService) SetStatsRecorder(recorder StatsRecorder) {
+	f.stats = recorder
+}
+
+// SetTransport sends the upstream calls through transport, such as one that trusts a corporate CA
+func (f *FlutterAPIService) SetTransport(transport http.RoundTripper) {
+	f.client = &http.Client{Transport: transport}
+}
+
+// SetExecTimeout kills external tools such as fvm that run longer than timeout
+func (f *FlutterAPIService) SetExecTimeout(timeout time.Duration) {
+	f.execTimeout = timeout
+}
+
+// SetMaxScanFileSize skips source files over size bytes in the deprecation scans
+func (f *FlutterAPIService) SetMaxScanFileSize(size int64) {
+	f.maxScanFileSize = size
+}
+
+// SetDockerRegistries routes the Docker image checks through mirrors and authenticates them
+func (f *FlutterAPIService) SetDockerRegistries(registries DockerRegistries) {
+	f.registries = registries
+}
+
+// get issues a GET request that is cancelled along with ctx
+func (f *FlutterAPIService) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := f.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return f.do(req, upstreamForHost(req.URL.Host))
 }
 
-// FetchReleases fetches Flutter releases from GitHub API
-func (f *FlutterAPIService) FetchReleases() ([]models.FlutterRelease, error) {
-	resp, err := http.Get(config.FLUTTER_API_URL + fmt.Sprintf("?per_page=%d", config.MAX_RELEASES))
+// newRequest builds a request that is cancelled along with ctx, authenticated with the GitHub token
+// when it goes to the GitHub API
+func (f *FlutterAPIService) newRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if f.githubToken != "" && req.URL.Host == "api.github.com" {
+		req.Header.Set("Authorization", "Bearer "+f.githubToken)
+	}
+	return req, nil
+}
+
+// do sends a request and records it under upstream
+func (f *FlutterAPIService) do(req *http.Request, upstream string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := f.client.Do(req)
+	failed := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	f.observe(upstream, start, failed)
+	if err == nil && req.URL.Host == "api.github.com" {
+		f.observeRateLimit(resp.Header)
+	}
+	return resp, err
+}
+
+// runCommand runs an external tool and returns its standard output
+func (f *FlutterAPIService) runCommand(ctx context.Context, upstream string, name string, args ...string) ([]byte, error) {
+	start := time.Now()
+	output, err := runExec(ctx, f.execTimeout, locateExecutable(name), args...)
+	f.observe(upstream, start, err != nil)
+	return output, err
+}
+
+// observe records the duration of an upstream call when a recorder is set
+func (f *FlutterAPIService) observe(upstream string, start time.Time, failed bool) {
+	if f.stats != nil {
+		f.stats.RecordUpstream(upstream, time.Since(start), failed)
+	}
+}
+
+// upstreamForHost maps a request host to the upstream category used in statistics
+func upstreamForHost(host string) string {
+	switch host {
+	case "api.github.com":
+		return UpstreamGitHub
+	case "raw.githubusercontent.com":
+		return UpstreamGitHubRaw
+	case "storage.googleapis.com":
+		return UpstreamFlutterReleases
+	case "hub.docker.com":
+		return UpstreamDockerHub
+	case "pub.dev":
+		return UpstreamPubDev
+	default:
+		return UpstreamOther
+	}
+}
+
+// FetchReleases fetches Flutter releases from GitHub API. Concurrent calls share one request.
+func (f *FlutterAPIService) FetchReleases(ctx context.Context) ([]models.FlutterRelease, error) {
+	value, err := f.flights.do(ctx, config.FLUTTER_API_URL, func(ctx context.Context) (interface{}, error) {
+		return f.fetchReleases(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.FlutterRelease(nil), value.([]models.FlutterRelease)...), nil
+}
+
+// fetchReleases requests the releases from the GitHub API, newest first
+func (f *FlutterAPIService) fetchReleases(ctx context.Context) ([]models.FlutterRelease, error) {
+	resp, err := f.get(ctx, config.FLUTTER_API_URL+fmt.Sprintf("?per_page=%d", config.MAX_RELEASES))
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// Check for rate limiting
-	if resp.StatusCode == 403 || resp.StatusCode == 401 || resp.StatusCode == 200 {
+	if resp.StatusCode == 403 || resp.StatusCode == 401 {
 		body, _ := ioutil.ReadAll(resp.Body)
 		var errorResp struct {
 			Message string `json:"message"`
 		}
 		if json.Unmarshal(body, &errorResp) == nil && strings.Contains(errorResp.Message, "API rate limit exceeded") {
-			return nil, fmt.Errorf("GitHub API rate limit exceeded. Please wait before retrying or authenticate with a GitHub token")
+			return nil, &RateLimitError{Reset: rateLimitReset(resp.Header)}
 		}
-		return nil, fmt.Errorf("GitHub API access forbidden (403): %s", errorResp.Message)
+		return nil, fmt.Errorf("GitHub API access forbidden (%d): %s", resp.StatusCode, errorResp.Message)
 	}
 
 	if resp.StatusCode != 200 {
@@ -73,9 +276,23 @@ func (f *FlutterAPIService) FetchReleases() ([]models.FlutterRelease, error) {
 	return releases, nil
 }
 
-// FetchOfficialReleases fetches Flutter releases from the official Google Storage API
-func (f *FlutterAPIService) FetchOfficialReleases() (*models.FlutterReleasesResponse, error) {
-	resp, err := http.Get(config.FLUTTER_RELEASES_URL)
+// FetchOfficialReleases fetches Flutter releases from the official Google Storage API. Concurrent
+// calls share one request.
+func (f *FlutterAPIService) FetchOfficialReleases(ctx context.Context) (*models.FlutterReleasesResponse, error) {
+	value, err := f.flights.do(ctx, config.FLUTTER_RELEASES_URL, func(ctx context.Context) (interface{}, error) {
+		return f.fetchOfficialReleases(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	releases := *value.(*models.FlutterReleasesResponse)
+	releases.Releases = append([]models.FlutterOfficialRelease(nil), releases.Releases...)
+	return &releases, nil
+}
+
+// fetchOfficialReleases requests the releases JSON of the official Google Storage API
+func (f *FlutterAPIService) fetchOfficialReleases(ctx context.Context) (*models.FlutterReleasesResponse, error) {
+	resp, err := f.get(ctx, config.FLUTTER_RELEASES_URL)
 	if err != nil {
 		return nil, err
 	}
@@ -98,50 +315,49 @@ func (f *FlutterAPIService) FetchOfficialReleases() (*models.FlutterReleasesResp
 	return &releases, nil
 }
 
+// FetchWebsiteFile fetches a file from the flutter/website repository, e.g. a breaking change guide
+func (f *FlutterAPIService) FetchWebsiteFile(ctx context.Context, path string) (string, error) {
+	resp, err := f.get(ctx, config.FLUTTER_WEBSITE_RAW_URL+path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("flutter/website returned status %d for %s", resp.StatusCode, path)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
 // ParseVersionFromRelease extracts version string from release tag
 func (f *FlutterAPIService) ParseVersionFromRelease(release models.FlutterRelease) string {
 	version := strings.TrimPrefix(release.TagName, "v")
 	return version
 }
 
-// GetLatestStableVersion finds the latest stable Flutter version using official releases API
-func (f *FlutterAPIService) GetLatestStableVersion() (string, error) {
+// GetLatestStableVersion finds the latest stable Flutter version using the official releases API,
+// falling back to the GitHub releases when it is unavailable
+func (f *FlutterAPIService) GetLatestStableVersion(ctx context.Context) (string, error) {
 	// Try official Flutter releases API first (more reliable and faster)
-	officialReleases, err := f.FetchOfficialReleases()
+	officialReleases, err := f.FetchOfficialReleases(ctx)
 	if err == nil {
-		// Find the latest stable release
-		for _, release := range officialReleases.Releases {
-			if release.Channel == "stable" {
-				return release.Version, nil
-			}
+		if release, ok := latestOfficialStable(officialReleases); ok {
+			return release.Version, nil
 		}
 	}
 
 	// Fallback to GitHub API if official API fails
-	releases, err := f.FetchReleases()
+	releases, err := f.FetchReleases(ctx)
 	if err != nil {
 		return "", err
 	}
-
-	// Find latest stable version
-	for _, release := range releases {
-		tagLower := strings.ToLower(release.TagName)
-		version := f.ParseVersionFromRelease(release)
-
-		// Check if this is a stable release
-		isStable := !release.Prerelease &&
-			!strings.Contains(tagLower, "beta") &&
-			!strings.Contains(tagLower, "dev") &&
-			!strings.Contains(tagLower, "pre") &&
-			!strings.Contains(tagLower, "rc") &&
-			!strings.Contains(tagLower, "alpha") &&
-			!strings.Contains(tagLower, "hotfix") &&
-			!strings.Contains(version, "-") &&
-			regexp.MustCompile(`^\d+\.\d+\.\d+$`).MatchString(version)
-
-		if isStable {
-			return version, nil
-		}
+	if version, ok := latestGitHubStable(releases); ok {
+		return version, nil
 	}
 
 	// If no stable release found, return the latest release regardless
@@ -153,19 +369,18 @@ func (f *FlutterAPIService) GetLatestStableVersion() (string, error) {
 }
 
 // CheckFVMInstalled checks if FVM is installed on the system
-func (f *FlutterAPIService) CheckFVMInstalled() bool {
-	cmd := exec.Command("fvm", "--version")
-	return cmd.Run() == nil
+func (f *FlutterAPIService) CheckFVMInstalled(ctx context.Context) bool {
+	_, err := f.runCommand(ctx, UpstreamExecFVM, "fvm", "--version")
+	return err == nil
 }
 
 // CheckFVMVersionExists checks if a specific Flutter version exists in FVM
-func (f *FlutterAPIService) CheckFVMVersionExists(version string) bool {
-	if !f.CheckFVMInstalled() {
+func (f *FlutterAPIService) CheckFVMVersionExists(ctx context.Context, version string) bool {
+	if !f.CheckFVMInstalled(ctx) {
 		return false
 	}
 
-	cmd := exec.Command("fvm", "list")
-	output, err := cmd.Output()
+	output, err := f.runCommand(ctx, UpstreamExecFVM, "fvm", "list")
 	if err != nil {
 		return false
 	}
@@ -173,22 +388,38 @@ func (f *FlutterAPIService) CheckFVMVersionExists(version string) bool {
 	return strings.Contains(string(output), version)
 }
 
-// CheckDockerImageExists checks if a Docker image exists for a specific tag
-func (f *FlutterAPIService) CheckDockerImageExists(image string, tag string) bool {
-	// Handle different registries
-	if strings.HasPrefix(image, "ghcr.io/") {
-		// GitHub Container Registry
-		return f.checkGHCRImageExists(image, tag)
-	} else {
-		// Docker Hub
-		return f.checkDockerHubImageExists(image, tag)
+// CheckDockerImageExists checks if a Docker image exists for a specific tag. Images of a
+// registry with a configured mirror are looked up on the mirror, because that is where they are
+// pulled from; other registries are asked through their registry API. Concurrent checks of the same
+// image and tag share one lookup.
+func (f *FlutterAPIService) CheckDockerImageExists(ctx context.Context, image string, tag string) bool {
+	exists, err := f.flights.do(ctx, "docker:"+image+":"+tag, func(ctx context.Context) (interface{}, error) {
+		return f.checkDockerImageExists(ctx, image, tag), nil
+	})
+	return err == nil && exists.(bool)
+}
+
+// checkDockerImageExists looks image:tag up on its registry or the registry's mirror
+func (f *FlutterAPIService) checkDockerImageExists(ctx context.Context, image string, tag string) bool {
+	registry, repository := imageRegistry(image)
+	if mirror, ok := f.registries.Mirrors[registry]; ok {
+		return f.checkRegistryImageExists(ctx, mirror, repository, tag)
+	}
+
+	if registry != config.DOCKER_HUB_REGISTRY {
+		return f.checkRegistryImageExists(ctx, "https://"+registry, repository, tag)
 	}
+	// The Hub API needs no token for public images; private ones need the registry API and a login
+	if _, ok := f.registries.Credentials[config.DOCKER_HUB_REGISTRY]; ok {
+		return f.checkRegistryImageExists(ctx, config.DOCKER_HUB_REGISTRY_API, repository, tag)
+	}
+	return f.checkDockerHubImageExists(ctx, repository, tag)
 }
 
 // checkDockerHubImageExists checks Docker Hub for image availability
-func (f *FlutterAPIService) checkDockerHubImageExists(image string, tag string) bool {
+func (f *FlutterAPIService) checkDockerHubImageExists(ctx context.Context, image string, tag string) bool {
 	url := fmt.Sprintf("https://hub.docker.com/v2/repositories/%s/tags/%s", image, tag)
-	resp, err := http.Get(url)
+	resp, err := f.get(ctx, url)
 	if err != nil {
 		return false
 	}
@@ -197,73 +428,268 @@ func (f *FlutterAPIService) checkDockerHubImageExists(image string, tag string)
 	return resp.StatusCode == 200
 }
 
-// checkGHCRImageExists checks GitHub Container Registry for image availability
-func (f *FlutterAPIService) checkGHCRImageExists(image string, tag string) bool {
-	// For GHCR, we'll try to check the GitHub repository instead
-	// ghcr.io/cirruslabs/flutter -> check cirruslabs/docker-images-flutter repo
-	if image == "ghcr.io/cirruslabs/flutter" {
-		// Check if the package exists in GitHub packages
-		url := "https://api.github.com/users/cirruslabs/packages/container/flutter/versions"
-		resp, err := http.Get(url)
+// frameworkDirectories are the libraries of the Flutter framework searched for deprecations
+var frameworkDirectories = []string{
+	"widgets/",
+	"material/",
+	"cupertino/",
+	"services/",
+	"rendering/",
+	"foundation/",
+	"painting/",
+	"gestures/",
+	"animation/",
+}
+
+// SetPreviewChannel also scans the framework on the beta or master branch, adding the deprecations
+// that have not reached stable yet as upcoming. An empty channel scans stable only.
+func (f *FlutterAPIService) SetPreviewChannel(channel string) {
+	f.previewChannel = channel
+}
+
+// FetchFlutterSourceDeprecations fetches @Deprecated annotations from the Flutter framework and
+// the first-party plugins on GitHub
+func (f *FlutterAPIService) FetchFlutterSourceDeprecations(ctx context.Context) ([]models.Deprecation, error) {
+	return f.fetchSourceDeprecations(ctx, frameworkDirectories, flutterPackages)
+}
+
+// FetchSourceDeprecationsIn fetches the @Deprecated annotations of the named framework libraries,
+// such as material, and first-party plugins, such as go_router, leaving the others unscanned
+func (f *FlutterAPIService) FetchSourceDeprecationsIn(ctx context.Context, libraries []string) ([]models.Deprecation, error) {
+	directories, packages, err := parseSourceLibraries(libraries)
+	if err != nil {
+		return nil, err
+	}
+	return f.fetchSourceDeprecations(ctx, directories, packages)
+}
+
+// fetchSourceDeprecations scans the framework directories, on the preview channel too when one is
+// set, and the plugins
+func (f *FlutterAPIService) fetchSourceDeprecations(ctx context.Context, directories []string, packages []flutterPackage) ([]models.Deprecation, error) {
+	var deprecations []models.Deprecation
+	if len(directories) > 0 {
+		stable, scanned, err := f.scanFramework(ctx, config.FLUTTER_CHANNEL_STABLE, directories)
 		if err != nil {
-			return false
+			return nil, err
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != 200 {
-			return false
+		deprecations = stable
+		if f.previewChannel != "" {
+			preview, _, err := f.scanFramework(ctx, f.previewChannel, scanned)
+			if err != nil {
+				return nil, err
+			}
+			deprecations = mergeUpcoming(deprecations, preview)
 		}
-
-		// For simplicity, if the package exists, assume the tag exists
-		// In a real implementation, you'd parse the JSON and check for the specific tag
-		return true
 	}
 
-	return false
+	packageDeprecations, err := f.fetchPackageDeprecations(ctx, packages, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(deprecations, packageDeprecations...), nil
 }
 
-// FetchFlutterSourceDeprecations fetches @Deprecated annotations from Flutter source on GitHub
-func (f *FlutterAPIService) FetchFlutterSourceDeprecations() ([]models.Deprecation, error) {
-	// Base URL for Flutter source code on GitHub
-	baseURL := "https://raw.githubusercontent.com/flutter/flutter/master/packages/flutter/lib/src/"
+// SourceLibraries returns the names of the framework libraries and first-party plugins that are
+// scanned for deprecations
+func SourceLibraries() []string {
+	libraries := make([]string, 0, len(frameworkDirectories)+len(flutterPackages))
+	for _, dir := range frameworkDirectories {
+		libraries = append(libraries, strings.TrimSuffix(dir, "/"))
+	}
+	for _, pkg := range flutterPackages {
+		libraries = append(libraries, pkg.name)
+	}
+	return libraries
+}
 
-	// Key directories to search for deprecations
-	directories := []string{
-		"widgets/",
-		"material/",
-		"cupertino/",
-		"services/",
-		"rendering/",
-		"foundation/",
-		"painting/",
-		"gestures/",
-		"animation/",
+// parseSourceLibraries splits the names of SourceLibraries into the framework directories and
+// plugins to scan
+func parseSourceLibraries(libraries []string) ([]string, []flutterPackage, error) {
+	var directories []string
+	var packages []flutterPackage
+	for _, library := range libraries {
+		name := strings.Trim(strings.ToLower(strings.TrimSpace(library)), "/")
+		found := false
+		for _, dir := range frameworkDirectories {
+			if dir == name+"/" {
+				directories = append(directories, dir)
+				found = true
+			}
+		}
+		for _, pkg := range flutterPackages {
+			if pkg.name == name {
+				packages = append(packages, pkg)
+				found = true
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("unknown library %q, expected one of: %s", library, strings.Join(SourceLibraries(), ", "))
+		}
 	}
+	return directories, packages, nil
+}
+
+// scanFramework scans the given framework directories on a flutter/flutter branch, tagging each
+// result with the branch. It also returns the directories that could be scanned.
+func (f *FlutterAPIService) scanFramework(ctx context.Context, channel string, directories []string) ([]models.Deprecation, []string, error) {
+	baseURL := fmt.Sprintf(config.FLUTTER_FRAMEWORK_RAW_URL, channel)
 
 	var deprecations []models.Deprecation
+	var scanned []string
 
 	// For each directory, we'll fetch a directory listing and then scan files
 	for _, dir := range directories {
-		dirDeprecations, err := f.scanDirectoryForDeprecations(baseURL + dir)
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		dirDeprecations, err := f.scanDirectoryForDeprecations(ctx, baseURL+dir)
+		if isRateLimited(err) {
+			// The other listings would be refused too
+			return nil, nil, err
+		}
 		if err != nil {
 			// Log error but continue with other directories
-			fmt.Printf("Warning: Failed to scan directory %s: %v\n", dir, err)
+			slog.Warn("Failed to scan directory", "directory", dir, "channel", channel, "error", err)
 			continue
 		}
-		deprecations = append(deprecations, dirDeprecations...)
+		deprecations = append(deprecations, withChannel(dirDeprecations, channel)...)
+		scanned = append(scanned, dir)
+	}
+
+	return deprecations, scanned, nil
+}
+
+// withChannel records the branch the deprecations were scanned from
+func withChannel(deprecations []models.Deprecation, channel string) []models.Deprecation {
+	for i := range deprecations {
+		deprecations[i].Channel = channel
+	}
+	return deprecations
+}
+
+// mergeUpcoming adds the deprecations of a preview branch scan that stable does not have yet,
+// marked upcoming. The preview scan only covers the directories the stable scan could read, so a
+// failed listing does not make a whole library look upcoming.
+func mergeUpcoming(stable []models.Deprecation, preview []models.Deprecation) []models.Deprecation {
+	known := make(map[string]bool, len(stable))
+	for _, dep := range stable {
+		known[seenKey(dep)] = true
+	}
+	for _, dep := range preview {
+		if known[seenKey(dep)] {
+			continue
+		}
+		known[seenKey(dep)] = true
+		dep.Upcoming = true
+		stable = append(stable, dep)
+	}
+	return stable
+}
+
+// flutterPackage is a first-party plugin from flutter/packages, with its directory in the repo
+type flutterPackage struct {
+	name string
+	dir  string
+}
+
+// flutterPackages are the app-facing plugins whose deprecations are scanned next to the framework.
+// Federated plugins keep the app-facing package in a subdirectory of the same name.
+var flutterPackages = []flutterPackage{
+	{"camera", "camera/camera"},
+	{"go_router", "go_router"},
+	{"google_maps_flutter", "google_maps_flutter/google_maps_flutter"},
+	{"image_picker", "image_picker/image_picker"},
+	{"in_app_purchase", "in_app_purchase/in_app_purchase"},
+	{"local_auth", "local_auth/local_auth"},
+	{"shared_preferences", "shared_preferences/shared_preferences"},
+	{"url_launcher", "url_launcher/url_launcher"},
+	{"video_player", "video_player/video_player"},
+	{"webview_flutter", "webview_flutter/webview_flutter"},
+}
+
+// errDirectoryNotFound is returned when a scanned source directory does not exist
+var errDirectoryNotFound = errors.New("directory not found")
+
+// packageSourceDirectories are scanned in every package: the public libraries and their sources
+var packageSourceDirectories = []string{"lib/", "lib/src/"}
+
+// fetchPackageDeprecations scans the given first-party plugins for @Deprecated annotations and tags
+// each result with its package. progressCallback may be nil.
+func (f *FlutterAPIService) fetchPackageDeprecations(ctx context.Context, packages []flutterPackage, progressCallback func(string)) ([]models.Deprecation, error) {
+	var deprecations []models.Deprecation
+
+	for i, pkg := range packages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf("📦 Scanning package %d/%d: %s", i+1, len(packages), pkg.name))
+		}
+
+		for _, dir := range packageSourceDirectories {
+			baseURL := config.FLUTTER_PACKAGES_RAW_URL + pkg.dir + "/" + dir
+
+			var dirDeprecations []models.Deprecation
+			var err error
+			if progressCallback != nil {
+				dirDeprecations, err = f.scanDirectoryForDeprecationsWithProgress(ctx, baseURL, progressCallback)
+			} else {
+				dirDeprecations, err = f.scanDirectoryForDeprecations(ctx, baseURL)
+			}
+			if errors.Is(err, errDirectoryNotFound) {
+				// Not every package has a lib/src directory
+				continue
+			}
+			if isRateLimited(err) {
+				return nil, err
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				slog.Warn("Failed to scan package directory", "package", pkg.name, "directory", dir, "error", err)
+				continue
+			}
+
+			for _, dep := range dirDeprecations {
+				dep.Package = pkg.name
+				dep.Category = pkg.name
+				dep.Source = config.DEPRECATION_SOURCE_PACKAGES
+				dep.DocURL = apiDocumentationURL(dep)
+				deprecations = append(deprecations, dep)
+			}
+		}
 	}
 
 	return deprecations, nil
 }
 
-// scanDirectoryForDeprecations scans a directory for Dart files and extracts @Deprecated annotations
-func (f *FlutterAPIService) scanDirectoryForDeprecations(baseURL string) ([]models.Deprecation, error) {
-	// Since we can't easily list directory contents via GitHub raw URLs,
-	// we'll use the GitHub API to get directory contents first
-	apiURL := strings.Replace(baseURL, "https://raw.githubusercontent.com/", "https://api.github.com/repos/", 1)
-	apiURL = strings.Replace(apiURL, "/master/", "/contents/", 1)
+// contentsAPIURL turns a raw.githubusercontent.com directory URL into the GitHub contents API URL
+// that lists it on the same branch
+func contentsAPIURL(rawURL string) string {
+	rest, found := strings.CutPrefix(rawURL, "https://raw.githubusercontent.com/")
+	if !found {
+		return rawURL
+	}
+	parts := strings.SplitN(rest, "/", 4)
+	if len(parts) < 4 {
+		return rawURL
+	}
+	return "https://api.github.com/repos/" + parts[0] + "/" + parts[1] + "/contents/" + parts[3] + "?ref=" + url.QueryEscape(parts[2])
+}
+
+// contentsEntry is a file or directory in a GitHub contents API listing
+type contentsEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
 
-	resp, err := http.Get(apiURL)
+// listDirectory fetches a GitHub contents API directory listing, returning errDirectoryNotFound
+// when the directory does not exist
+func (f *FlutterAPIService) listDirectory(ctx context.Context, apiURL string) ([]contentsEntry, error) {
+	resp, err := f.get(ctx, apiURL)
 	if err != nil {
 		return nil, err
 	}
@@ -276,11 +702,14 @@ func (f *FlutterAPIService) scanDirectoryForDeprecations(baseURL string) ([]mode
 			Message string `json:"message"`
 		}
 		if json.Unmarshal(body, &errorResp) == nil && strings.Contains(errorResp.Message, "API rate limit exceeded") {
-			return nil, fmt.Errorf("GitHub API rate limit exceeded. Please wait before retrying or authenticate with a GitHub token")
+			return nil, &RateLimitError{Reset: rateLimitReset(resp.Header)}
 		}
-		return nil, fmt.Errorf("GitHub API access forbidden (403): %s", errorResp.Message)
+		return nil, fmt.Errorf("GitHub API access forbidden (%d): %s", resp.StatusCode, errorResp.Message)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errDirectoryNotFound
+	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("failed to fetch directory listing: %d", resp.StatusCode)
 	}
@@ -290,12 +719,19 @@ func (f *FlutterAPIService) scanDirectoryForDeprecations(baseURL string) ([]mode
 		return nil, err
 	}
 
-	var files []struct {
-		Name string `json:"name"`
-		Type string `json:"type"`
+	var files []contentsEntry
+	if err := json.Unmarshal(body, &files); err != nil {
+		return nil, err
 	}
+	return files, nil
+}
 
-	if err := json.Unmarshal(body, &files); err != nil {
+// scanDirectoryForDeprecations scans a directory for Dart files and extracts @Deprecated annotations
+func (f *FlutterAPIService) scanDirectoryForDeprecations(ctx context.Context, baseURL string) ([]models.Deprecation, error) {
+	// Since we can't easily list directory contents via GitHub raw URLs,
+	// we'll use the GitHub API to get directory contents first
+	files, err := f.listDirectory(ctx, contentsAPIURL(baseURL))
+	if err != nil {
 		return nil, err
 	}
 
@@ -303,11 +739,15 @@ func (f *FlutterAPIService) scanDirectoryForDeprecations(baseURL string) ([]mode
 
 	// Process each Dart file
 	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		if file.Type == "file" && strings.HasSuffix(file.Name, ".dart") {
 			fileURL := baseURL + file.Name
-			fileDeprecations, err := f.ScanFileForDeprecations(fileURL)
+			fileDeprecations, err := f.ScanFileForDeprecations(ctx, fileURL)
 			if err != nil {
-				fmt.Printf("Warning: Failed to scan file %s: %v\n", file.Name, err)
+				slog.Warn("Failed to scan file", "file", file.Name, "error", err)
 				continue
 			}
 			deprecations = append(deprecations, fileDeprecations...)
@@ -318,8 +758,8 @@ func (f *FlutterAPIService) scanDirectoryForDeprecations(baseURL string) ([]mode
 }
 
 // ScanFileForDeprecations scans a single Dart file for @Deprecated annotations (exported for testing)
-func (f *FlutterAPIService) ScanFileForDeprecations(fileURL string) ([]models.Deprecation, error) {
-	resp, err := http.Get(fileURL)
+func (f *FlutterAPIService) ScanFileForDeprecations(ctx context.Context, fileURL string) ([]models.Deprecation, error) {
+	resp, err := f.get(ctx, fileURL)
 	if err != nil {
 		return nil, err
 	}
@@ -329,152 +769,305 @@ func (f *FlutterAPIService) ScanFileForDeprecations(fileURL string) ([]models.De
 		return nil, fmt.Errorf("failed to fetch file: %d", resp.StatusCode)
 	}
 
+	limit := f.scanFileLimit()
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("file is %d bytes, larger than the %d byte scan limit", resp.ContentLength, limit)
+	}
+	body := &limitedReader{r: resp.Body, remaining: limit}
+	deprecations, err := f.scanDeprecations(body, libraryFromSourceURL(fileURL), repositoryPath(fileURL))
+	if body.exceeded {
+		return nil, fmt.Errorf("file is larger than the %d byte scan limit", limit)
+	}
+	return deprecations, err
+}
+
+// scanFileLimit returns the size above which source files are not scanned
+func (f *FlutterAPIService) scanFileLimit() int64 {
+	if f.maxScanFileSize > 0 {
+		return f.maxScanFileSize
+	}
+	return config.MAX_SCAN_FILE_SIZE
+}
+
+// limitedReader reads at most remaining bytes and records whether the source had more, unlike
+// io.LimitReader, which cannot tell a file of exactly the limit from a longer one
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		if n, _ := l.r.Read(probe[:]); n > 0 {
+			l.exceeded = true
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// Lines around an annotation that parseDeprecationAt looks at: the class it is declared in above
+// it, and the rest of the annotation and the declaration it deprecates below it
+const (
+	maxClassLookBehind   = 50
+	maxDeclarationLines  = 10
+	deprecationLookAhead = maxAnnotationLines + maxDeclarationLines
+)
+
+// scanDeprecations extracts the @Deprecated annotations of the Dart source read from r. Only a
+// sliding window of the lines around the line being parsed is kept, so the memory a scan takes is
+// bounded by the line length rather than the file size; lines may be up to MAX_SCAN_LINE_SIZE long.
+func (f *FlutterAPIService) scanDeprecations(r io.Reader, library string, sourceFile string) ([]models.Deprecation, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), config.MAX_SCAN_LINE_SIZE)
+
 	var deprecations []models.Deprecation
-	scanner := bufio.NewScanner(resp.Body)
+	var window []string
+	first, next := 0, 0 // the file lines of window[0] and of the next line to parse, from 0
+	parse := func() {
+		if dep, ok := f.parseDeprecationAt(window, next-first, library, sourceFile); ok {
+			dep.SourceLine = next + 1
+			deprecations = append(deprecations, dep)
+		}
+		next++
+		if drop := next - first - maxClassLookBehind; drop > 0 {
+			window = window[drop:]
+			first += drop
+		}
+	}
 
-	var lines []string
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+		window = append(window, scanner.Text())
+		for next-first+deprecationLookAhead < len(window) {
+			parse()
+		}
 	}
 	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("a line is longer than the %d byte scan limit", config.MAX_SCAN_LINE_SIZE)
+		}
 		return nil, err
 	}
+	for next-first < len(window) {
+		parse()
+	}
+	return deprecations, nil
+}
 
-	// Enhanced pattern matching for @Deprecated annotations
-	deprecatedPattern := regexp.MustCompile(`@[Dd]eprecated\s*\(\s*['"](.+?)['"]`)
-	
-	// More comprehensive patterns for different Dart constructs
-	classPattern := regexp.MustCompile(`(?:abstract\s+)?(?:class|enum|mixin)\s+(\w+)`)
-	methodPattern := regexp.MustCompile(`(?:(?:static|final|const)\s+)*(?:[\w<>?]+\s+)?(\w+)\s*\(`)
-	constructorPattern := regexp.MustCompile(`(\w+)\s*\.\s*(\w+)\s*\(`)
-	propertyPattern := regexp.MustCompile(`(?:(?:static|final|const)\s+)*(?:[\w<>?]+\s+)+(get\s+)?(\w+)(?:\s*[;=]|\s*=>)`)
-	getterPattern := regexp.MustCompile(`(?:[\w<>?]+\s+)?get\s+(\w+)\s*(?:=>|{)`)
-	setterPattern := regexp.MustCompile(`set\s+(\w+)\s*\(`)
-
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-
-		// Look for @Deprecated annotation
-		if matches := deprecatedPattern.FindStringSubmatch(line); len(matches) > 1 {
-			description := matches[1]
-			
-			// Look ahead for the deprecated item (next few lines)
-			var apiName string
-			var className string
-			
-			// Get current class context by looking backward
-			for j := i - 1; j >= 0 && j >= i-50; j-- {
-				if classMatches := classPattern.FindStringSubmatch(strings.TrimSpace(lines[j])); len(classMatches) > 1 {
-					className = classMatches[1]
-					break
-				}
-			}
-			
-			// Look ahead for the deprecated item
-			for j := i + 1; j < len(lines) && j <= i+10; j++ {
-				nextLine := strings.TrimSpace(lines[j])
-				
-				// Skip empty lines, comments, and annotations
-				if nextLine == "" || strings.HasPrefix(nextLine, "//") || 
-				   strings.HasPrefix(nextLine, "/*") || strings.HasPrefix(nextLine, "@") {
-					continue
-				}
+// parseDeprecationAt extracts the deprecation annotated on lines[i], naming the deprecated API from
+// the declaration that follows the annotation. SourceLine is left to the caller, which knows where
+// lines starts in the file.
+func (f *FlutterAPIService) parseDeprecationAt(lines []string, i int, library string, sourceFile string) (models.Deprecation, bool) {
+	line := strings.TrimSpace(lines[i])
+
+	// Look for @Deprecated annotation
+	if !deprecatedPattern.MatchString(line) {
+		return models.Deprecation{}, false
+	}
+	description, end := annotationMessage(lines, i)
+	if description == "" {
+		return models.Deprecation{}, false
+	}
 
-				// Try to match different constructs
-				if matches := classPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
-					apiName = matches[1]
-					break
-				} else if matches := constructorPattern.FindStringSubmatch(nextLine); len(matches) > 2 {
-					apiName = matches[1] + "." + matches[2]
-					break
-				} else if matches := getterPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
-					if className != "" {
-						apiName = className + "." + matches[1]
-					} else {
-						apiName = matches[1]
-					}
-					break
-				} else if matches := setterPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
-					if className != "" {
-						apiName = className + "." + matches[1]
-					} else {
-						apiName = matches[1]
-					}
-					break
-				} else if matches := methodPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
-					methodName := matches[1]
-					// Filter out common non-method words
-					if methodName != "if" && methodName != "for" && methodName != "while" && 
-					   methodName != "switch" && methodName != "return" && methodName != "throw" {
-						if className != "" && methodName != className {
-							apiName = className + "." + methodName
-						} else {
-							apiName = methodName
-						}
-						break
-					}
-				} else if matches := propertyPattern.FindStringSubmatch(nextLine); len(matches) > 2 {
-					propertyName := matches[2]
-					if className != "" {
-						apiName = className + "." + propertyName
-					} else {
-						apiName = propertyName
-					}
-					break
+	// Look ahead for the deprecated item (next few lines)
+	var apiName string
+	var className string
+
+	// Get current class context by looking backward
+	for j := i - 1; j >= 0 && j >= i-maxClassLookBehind; j-- {
+		if classMatches := classPattern.FindStringSubmatch(strings.TrimSpace(lines[j])); len(classMatches) > 1 {
+			className = classMatches[1]
+			break
+		}
+	}
+
+	// Look ahead for the deprecated item
+	for j := end + 1; j < len(lines) && j <= end+maxDeclarationLines; j++ {
+		nextLine := strings.TrimSpace(lines[j])
+
+		// Skip empty lines, comments, and annotations
+		if nextLine == "" || strings.HasPrefix(nextLine, "//") ||
+			strings.HasPrefix(nextLine, "/*") || strings.HasPrefix(nextLine, "@") {
+			continue
+		}
+
+		// Try to match different constructs
+		if matches := classPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
+			apiName = matches[1]
+			break
+		} else if matches := constructorPattern.FindStringSubmatch(nextLine); len(matches) > 2 {
+			apiName = matches[1] + "." + matches[2]
+			break
+		} else if matches := getterPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
+			if className != "" {
+				apiName = className + "." + matches[1]
+			} else {
+				apiName = matches[1]
+			}
+			break
+		} else if matches := setterPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
+			if className != "" {
+				apiName = className + "." + matches[1]
+			} else {
+				apiName = matches[1]
+			}
+			break
+		} else if matches := methodPattern.FindStringSubmatch(nextLine); len(matches) > 1 {
+			methodName := matches[1]
+			// Filter out common non-method words
+			if methodName != "if" && methodName != "for" && methodName != "while" &&
+				methodName != "switch" && methodName != "return" && methodName != "throw" {
+				if className != "" && methodName != className {
+					apiName = className + "." + methodName
+				} else {
+					apiName = methodName
 				}
+				break
 			}
+		} else if matches := propertyPattern.FindStringSubmatch(nextLine); len(matches) > 2 {
+			propertyName := matches[2]
+			if className != "" {
+				apiName = className + "." + propertyName
+			} else {
+				apiName = propertyName
+			}
+			break
+		}
+	}
 
-			if apiName != "" {
-				deprecation := models.Deprecation{
-					API:         apiName,
-					Description: description,
-				}
+	if apiName == "" {
+		return models.Deprecation{}, false
+	}
+	deprecation := models.Deprecation{
+		API:         apiName,
+		Description: description,
+		Version:     deprecatedAfterVersion(description),
+		Category:    library,
+		Severity:    config.SEVERITY_WARNING,
+		Source:      config.DEPRECATION_SOURCE_FLUTTER,
+		SourceFile:  sourceFile,
+	}
 
-				// Enhanced replacement extraction
-				replacement := f.extractReplacement(description)
-				if replacement != "" {
-					deprecation.Replacement = replacement
-				}
+	// Enhanced replacement extraction
+	replacement := f.extractReplacement(description)
+	if replacement != "" {
+		deprecation.Replacement = replacement
+	}
 
-				// Try to infer better replacement based on context
-				if deprecation.Replacement == "" {
-					deprecation.Replacement = f.InferReplacement(apiName, description)
-				}
+	// Try to infer better replacement based on context
+	if deprecation.Replacement == "" {
+		deprecation.Replacement = f.InferReplacement(apiName, description)
+	}
+	deprecation.DocURL = apiDocumentationURL(deprecation)
+
+	return deprecation, true
+}
+
+// maxAnnotationLines bounds how far a @Deprecated message is followed across lines
+const maxAnnotationLines = 8
+
+// annotationMessage joins the string literals of the @Deprecated annotation starting on line start,
+// such as 'Use surface instead. ' 'This feature was deprecated after v3.18.0-0.1.pre.', and returns
+// the message with the line that closes the annotation. The message is empty when the annotation
+// does not close within maxAnnotationLines or passes a constant instead of a literal.
+func annotationMessage(lines []string, start int) (string, int) {
+	var message strings.Builder
+	depth := 0
+	for j := start; j < len(lines) && j <= start+maxAnnotationLines; j++ {
+		line := lines[j]
+		k := 0
+		if j == start {
+			k = deprecatedPattern.FindStringIndex(line)[1] - 1
+		}
 
-				deprecations = append(deprecations, deprecation)
+		// Dart string literals only span lines when triple-quoted, which messages do not use
+		var quote byte
+		for ; k < len(line); k++ {
+			c := line[k]
+			switch {
+			case quote != 0 && c == '\\' && k+1 < len(line):
+				k++
+				message.WriteByte(line[k])
+			case quote != 0 && c == quote:
+				quote = 0
+			case quote != 0:
+				message.WriteByte(c)
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+				if depth == 0 {
+					return strings.TrimSpace(message.String()), j
+				}
+			case c == '/' && strings.HasPrefix(line[k:], "//"):
+				k = len(line)
 			}
 		}
 	}
+	return "", start
+}
 
-	return deprecations, nil
+// deprecatedAfterVersion returns the Flutter version a deprecation message says the API was
+// deprecated after, normalized like 3.18.0-0.1.pre, or "" when the message does not say
+func deprecatedAfterVersion(message string) string {
+	matches := deprecatedAfterPattern.FindStringSubmatch(message)
+	if matches == nil {
+		return ""
+	}
+	version, ok := parseVersion(matches[1])
+	if !ok {
+		return ""
+	}
+	return version.String()
+}
+
+// repositoryPath returns the path of a scanned file within its repository, such as
+// packages/flutter/lib/src/material/color_scheme.dart
+func repositoryPath(fileURL string) string {
+	if rest, found := strings.CutPrefix(fileURL, "https://raw.githubusercontent.com/"); found {
+		// owner/repo/branch/path
+		if parts := strings.SplitN(rest, "/", 4); len(parts) == 4 {
+			return parts[3]
+		}
+		return ""
+	}
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(parsed.Path, "/")
+}
+
+// libraryFromSourceURL returns the Flutter library (material, widgets, ...) a source file belongs to
+func libraryFromSourceURL(fileURL string) string {
+	_, rest, found := strings.Cut(fileURL, "/lib/src/")
+	if !found {
+		return ""
+	}
+	library, _, found := strings.Cut(rest, "/")
+	if !found {
+		return ""
+	}
+	return library
 }
 
 // extractReplacement tries to extract replacement suggestions from deprecation messages
 func (f *FlutterAPIService) extractReplacement(description string) string {
-	// Pattern 1: "Use X instead"
-	useInsteadPattern := regexp.MustCompile(`(?i)use\s+([A-Za-z0-9_.()]+)(?:\s+instead)?`)
-	if matches := useInsteadPattern.FindStringSubmatch(description); len(matches) > 1 {
-		return matches[1]
-	}
-	
-	// Pattern 2: "Replaced by X"
-	replacedByPattern := regexp.MustCompile(`(?i)replaced\s+by\s+([A-Za-z0-9_.()]+)`)
-	if matches := replacedByPattern.FindStringSubmatch(description); len(matches) > 1 {
-		return matches[1]
-	}
-	
-	// Pattern 3: "Use X() method"
-	useMethodPattern := regexp.MustCompile(`(?i)use\s+(?:the\s+)?([A-Za-z0-9_.()]+)\s+method`)
-	if matches := useMethodPattern.FindStringSubmatch(description); len(matches) > 1 {
-		return matches[1]
-	}
-	
-	// Pattern 4: "Prefer X"
-	preferPattern := regexp.MustCompile(`(?i)prefer\s+([A-Za-z0-9_.()]+)`)
-	if matches := preferPattern.FindStringSubmatch(description); len(matches) > 1 {
-		return matches[1]
-	}
-	
+	for _, pattern := range replacementPatterns {
+		if matches := pattern.FindStringSubmatch(description); len(matches) > 1 {
+			return matches[1]
+		}
+	}
+
 	return ""
 }
 
@@ -482,54 +1075,14 @@ func (f *FlutterAPIService) extractReplacement(description string) string {
 func (f *FlutterAPIService) InferReplacement(apiName, description string) string {
 	desc := strings.ToLower(description)
 	api := strings.ToLower(apiName)
-	
-	// Enhanced pattern-based replacements with contextual understanding
-	patterns := map[string]string{
-		// Color patterns
-		"withopacity":        "withValues(alpha: value)",
-		"color.withopacity":  "color.withValues(alpha: value)",
-		
-		// Button patterns  
-		"raisedbutton":       "ElevatedButton",
-		"flatbutton":         "TextButton", 
-		"outlinebutton":      "OutlinedButton",
-		"materialbutton":     "ElevatedButton, TextButton, or OutlinedButton",
-		
-		// Material patterns
-		"floatingactionbutton.mini": "FloatingActionButton(mini: true)",
-		
-		// Navigator patterns
-		"navigator.of(context).push": "Navigator.push(context, route)",
-		"navigator.of(context).pop":  "Navigator.pop(context)",
-		
-		// Scaffold patterns
-		"scaffold.of(context).showsnackbar": "ScaffoldMessenger.of(context).showSnackBar",
-		
-		// Text patterns
-		"text.overflow":      "Text with overflow parameter",
-		"textstyle.height":   "TextStyle.height or TextHeightBehavior",
-		
-		// Widget patterns
-		"wrap.direction":     "Wrap.direction parameter",
-		"flex.direction":     "Flex.direction parameter",
-		
-		// Animation patterns
-		"animationcontroller.reset": "AnimationController.reset() alternative",
-		"tween.animate":             "Tween.animate() or AnimatedBuilder",
-		
-		// Layout patterns
-		"positioned.fill":    "Positioned.fill() constructor",
-		"expanded.flex":      "Expanded(flex: value)",
-		"flexible.flex":      "Flexible(flex: value)",
-	}
-	
+
 	// Check direct API patterns
-	for pattern, replacement := range patterns {
-		if strings.Contains(api, pattern) {
-			return replacement
+	for _, inferred := range inferredReplacements {
+		if strings.Contains(api, inferred.pattern) {
+			return inferred.replacement
 		}
 	}
-	
+
 	// Analyze description for contextual clues
 	if strings.Contains(desc, "will lead to bugs") || strings.Contains(desc, "causes issues") {
 		if strings.Contains(api, "jump") || strings.Contains(api, "scroll") {
@@ -537,133 +1090,114 @@ func (f *FlutterAPIService) InferReplacement(apiName, description string) string
 		}
 		return "Alternative implementation recommended - see Flutter documentation"
 	}
-	
+
 	if strings.Contains(desc, "performance") {
 		return "More efficient alternative available - check Flutter performance guide"
 	}
-	
+
 	if strings.Contains(desc, "accessibility") {
 		return "Use semantically improved alternative for better accessibility"
 	}
-	
+
 	// Method-specific patterns
 	if strings.HasSuffix(api, "withoutsettling") {
 		return "Use standard navigation/animation methods that properly settle"
 	}
-	
+
 	if strings.Contains(api, "copywidth") || strings.Contains(api, "copyheight") {
 		return "Use copyWith() with specific dimension parameters"
 	}
-	
+
 	// Generic fallbacks based on API type
 	if strings.Contains(api, "button") {
 		return "Use Material 3 button alternatives (ElevatedButton, TextButton, OutlinedButton)"
 	}
-	
+
 	if strings.Contains(api, "color") {
 		return "Use updated Color API with values() constructor"
 	}
-	
+
 	if strings.Contains(api, "theme") {
 		return "Use Material 3 ThemeData with updated color scheme"
 	}
-	
+
 	return ""
 }
 
 // FetchFlutterSourceDeprecationsWithProgress fetches @Deprecated annotations with progress reporting
-func (f *FlutterAPIService) FetchFlutterSourceDeprecationsWithProgress(progressCallback func(string), verbose bool) ([]models.Deprecation, error) {
-	// Base URL for Flutter source code on GitHub
-	baseURL := "https://raw.githubusercontent.com/flutter/flutter/master/packages/flutter/lib/src/"
-
-	// Key directories to search for deprecations
-	directories := []string{
-		"widgets/",
-		"material/",
-		"cupertino/",
-		"services/",
-		"rendering/",
-		"foundation/",
-		"painting/",
-		"gestures/",
-		"animation/",
+func (f *FlutterAPIService) FetchFlutterSourceDeprecationsWithProgress(ctx context.Context, progressCallback func(string)) ([]models.Deprecation, error) {
+	deprecations, scanned, err := f.scanFrameworkWithProgress(ctx, config.FLUTTER_CHANNEL_STABLE, frameworkDirectories, progressCallback)
+	if err != nil {
+		return nil, err
+	}
+	if f.previewChannel != "" {
+		progressCallback(fmt.Sprintf("🔮 Scanning the %s channel for upcoming deprecations...", f.previewChannel))
+		preview, _, err := f.scanFrameworkWithProgress(ctx, f.previewChannel, scanned, progressCallback)
+		if err != nil {
+			return nil, err
+		}
+		before := len(deprecations)
+		deprecations = mergeUpcoming(deprecations, preview)
+		progressCallback(fmt.Sprintf("🔮 Found %d upcoming deprecations on %s", len(deprecations)-before, f.previewChannel))
+	}
+
+	packageDeprecations, err := f.fetchPackageDeprecations(ctx, flutterPackages, progressCallback)
+	if err != nil {
+		return nil, err
 	}
+	progressCallback(fmt.Sprintf("✅ Completed scanning %d packages", len(flutterPackages)))
+	return append(deprecations, packageDeprecations...), nil
+}
+
+// scanFrameworkWithProgress is scanFramework with progress reporting
+func (f *FlutterAPIService) scanFrameworkWithProgress(ctx context.Context, channel string, directories []string, progressCallback func(string)) ([]models.Deprecation, []string, error) {
+	baseURL := fmt.Sprintf(config.FLUTTER_FRAMEWORK_RAW_URL, channel)
 
 	var deprecations []models.Deprecation
+	var scanned []string
 
 	// For each directory, we'll fetch a directory listing and then scan files
 	for i, dir := range directories {
-		progressCallback(fmt.Sprintf("📂 Scanning directory %d/%d: %s", i+1, len(directories), dir))
-		if verbose {
-			log.Printf("Scanning directory: %s", dir)
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
 		}
 
-		dirDeprecations, err := f.scanDirectoryForDeprecationsWithProgress(baseURL+dir, progressCallback, verbose)
+		progressCallback(fmt.Sprintf("📂 Scanning directory %d/%d on %s: %s", i+1, len(directories), channel, dir))
+		slog.Debug("Scanning directory", "directory", dir, "channel", channel)
+
+		dirDeprecations, err := f.scanDirectoryForDeprecationsWithProgress(ctx, baseURL+dir, progressCallback)
+		if isRateLimited(err) {
+			// The other listings would be refused too
+			return nil, nil, err
+		}
 		if err != nil {
 			// Log error but continue with other directories
-			if verbose {
-				log.Printf("Warning: Failed to scan directory %s: %v", dir, err)
-			}
+			slog.Warn("Failed to scan directory", "directory", dir, "channel", channel, "error", err)
 			progressCallback(fmt.Sprintf("⚠️ Warning: Failed to scan directory %s", dir))
 			continue
 		}
-		deprecations = append(deprecations, dirDeprecations...)
+		deprecations = append(deprecations, withChannel(dirDeprecations, channel)...)
+		scanned = append(scanned, dir)
 
-		if verbose {
-			log.Printf("Found %d deprecations in directory %s", len(dirDeprecations), dir)
-		}
+		slog.Debug("Scanned directory", "directory", dir, "deprecations", len(dirDeprecations))
 	}
 
-	progressCallback(fmt.Sprintf("✅ Completed scanning %d directories", len(directories)))
-	return deprecations, nil
+	progressCallback(fmt.Sprintf("✅ Completed scanning %d directories on %s", len(directories), channel))
+	return deprecations, scanned, nil
 }
 
 // scanDirectoryForDeprecationsWithProgress scans a directory with progress reporting
-func (f *FlutterAPIService) scanDirectoryForDeprecationsWithProgress(baseURL string, progressCallback func(string), verbose bool) ([]models.Deprecation, error) {
+func (f *FlutterAPIService) scanDirectoryForDeprecationsWithProgress(ctx context.Context, baseURL string, progressCallback func(string)) ([]models.Deprecation, error) {
 	// Since we cannot easily list directory contents via GitHub raw URLs,
 	// we'll use the GitHub API to get directory contents first
-	apiURL := strings.Replace(baseURL, "https://raw.githubusercontent.com/", "https://api.github.com/repos/", 1)
-	apiURL = strings.Replace(apiURL, "/master/", "/contents/", 1)
+	apiURL := contentsAPIURL(baseURL)
 
-	if verbose {
-		log.Printf("Fetching directory listing from: %s", apiURL)
-	}
+	slog.Debug("Fetching directory listing", "url", apiURL)
 
-	resp, err := http.Get(apiURL)
+	files, err := f.listDirectory(ctx, apiURL)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Check for rate limiting
-	if resp.StatusCode == 403 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		var errorResp struct {
-			Message string `json:"message"`
-		}
-		if json.Unmarshal(body, &errorResp) == nil && strings.Contains(errorResp.Message, "API rate limit exceeded") {
-			return nil, fmt.Errorf("GitHub API rate limit exceeded. Please wait before retrying or authenticate with a GitHub token")
-		}
-		return nil, fmt.Errorf("GitHub API access forbidden (403): %s", errorResp.Message)
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to fetch directory listing: %d", resp.StatusCode)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var files []struct {
-		Name string `json:"name"`
-		Type string `json:"type"`
-	}
-
-	if err := json.Unmarshal(body, &files); err != nil {
-		return nil, err
-	}
 
 	var deprecations []models.Deprecation
 	dartFiles := make([]string, 0)
@@ -681,16 +1215,16 @@ func (f *FlutterAPIService) scanDirectoryForDeprecationsWithProgress(baseURL str
 
 	// Process each Dart file
 	for i, fileName := range dartFiles {
-		if verbose {
-			log.Printf("Scanning file %d/%d: %s", i+1, len(dartFiles), fileName)
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
+		slog.Debug("Scanning file", "index", i+1, "total", len(dartFiles), "file", fileName)
+
 		fileURL := baseURL + fileName
-		fileDeprecations, err := f.ScanFileForDeprecations(fileURL)
+		fileDeprecations, err := f.ScanFileForDeprecations(ctx, fileURL)
 		if err != nil {
-			if verbose {
-				log.Printf("Warning: Failed to scan file %s: %v", fileName, err)
-			}
+			slog.Debug("Failed to scan file", "file", fileName, "error", err)
 			continue
 		}
 		deprecations = append(deprecations, fileDeprecations...)
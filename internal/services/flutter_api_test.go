@@ -1,13 +1,17 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 func TestFlutterAPIService(t *testing.T) {
@@ -128,14 +132,293 @@ func TestFlutterAPIService(t *testing.T) {
 		}
 	})
 
+	t.Run("ScanFileForDeprecations honors cancellation", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("@Deprecated('Use NewWidget instead')\nclass OldWidget {}\n"))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := apiService.ScanFileForDeprecations(ctx, server.URL); err == nil {
+			t.Error("Expected an error for a cancelled context")
+		}
+	})
+
+	t.Run("ScanFileForDeprecations records provenance", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("@Deprecated('Use NewWidget instead')\nclass OldWidget {}\n"))
+		}))
+		defer server.Close()
+
+		deprecations, err := apiService.ScanFileForDeprecations(context.Background(), server.URL+"/packages/flutter/lib/src/material/old_widget.dart")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(deprecations) != 1 {
+			t.Fatalf("Expected 1 deprecation, got %d", len(deprecations))
+		}
+
+		dep := deprecations[0]
+		if dep.Category != "material" || dep.Source != "flutter_source" || dep.Severity != "warning" {
+			t.Errorf("Expected material/flutter_source/warning, got %s/%s/%s", dep.Category, dep.Source, dep.Severity)
+		}
+		if dep.DocURL != "https://api.flutter.dev/flutter/material/OldWidget-class.html" {
+			t.Errorf("Expected the api.flutter.dev link, got %s", dep.DocURL)
+		}
+	})
+
+	t.Run("ScanFileForDeprecations reads the deprecated after version", func(t *testing.T) {
+		source := "class ColorScheme {\n" +
+			"  @Deprecated(\n" +
+			"    'Use surface instead. '\n" +
+			"    'This feature was deprecated after v3.18.0-0.1.pre.',\n" +
+			"  )\n" +
+			"  final Color background;\n\n" +
+			"  @Deprecated('Don\\'t use this. This feature was deprecated after v3.22.0.')\n" +
+			"  Color get onBackground => onSurface;\n\n" +
+			"  @Deprecated('Use primary instead')\n" +
+			"  final Color accent;\n" +
+			"}\n"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(source))
+		}))
+		defer server.Close()
+
+		deprecations, err := apiService.ScanFileForDeprecations(context.Background(), server.URL+"/packages/flutter/lib/src/material/color_scheme.dart")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(deprecations) != 3 {
+			t.Fatalf("Expected 3 deprecations, got %+v", deprecations)
+		}
+
+		expected := []models.Deprecation{
+			{API: "ColorScheme.background", Version: "3.18.0-0.1.pre", Description: "Use surface instead. This feature was deprecated after v3.18.0-0.1.pre.", SourceLine: 2},
+			{API: "ColorScheme.onBackground", Version: "3.22.0", Description: "Don't use this. This feature was deprecated after v3.22.0.", SourceLine: 8},
+			{API: "ColorScheme.accent", Version: "", Description: "Use primary instead", SourceLine: 11},
+		}
+		for i, want := range expected {
+			got := deprecations[i]
+			if got.API != want.API || got.Version != want.Version || got.Description != want.Description {
+				t.Errorf("Expected %s/%s/%q, got %s/%s/%q", want.API, want.Version, want.Description, got.API, got.Version, got.Description)
+			}
+			if got.SourceFile != "packages/flutter/lib/src/material/color_scheme.dart" || got.SourceLine != want.SourceLine {
+				t.Errorf("Expected %s at line %d, got %s:%d", want.API, want.SourceLine, got.SourceFile, got.SourceLine)
+			}
+		}
+	})
+
+	t.Run("ScanFileForDeprecations streams long files", func(t *testing.T) {
+		var source strings.Builder
+		source.WriteString("class Ancient {\n  @Deprecated('Use Modern instead')\n  void early() {}\n}\n")
+		for i := 0; i < 100000; i++ {
+			source.WriteString("  // filler\n")
+		}
+		source.WriteString("final table = '" + strings.Repeat("x", 200<<10) + "';\n")
+		source.WriteString("class LateWidget {\n")
+		for i := 0; i < 40; i++ {
+			source.WriteString("  final int field;\n")
+		}
+		source.WriteString("  @Deprecated(\n    'Use child instead'\n  )\n\n  Widget get body => child;\n}\n@Deprecated('Use NewEnd instead')\nclass OldEnd {}")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(source.String()))
+		}))
+		defer server.Close()
+
+		deprecations, err := apiService.ScanFileForDeprecations(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := map[string]int{"Ancient.early": 2, "LateWidget.body": 100047, "OldEnd": 100053}
+		if len(deprecations) != len(expected) {
+			t.Fatalf("Expected %d deprecations, got %+v", len(expected), deprecations)
+		}
+		for _, dep := range deprecations {
+			if line, ok := expected[dep.API]; !ok || dep.SourceLine != line {
+				t.Errorf("Expected %v, got %s at line %d", expected, dep.API, dep.SourceLine)
+			}
+		}
+	})
+
+	t.Run("ScanFileForDeprecations skips files over the size limit", func(t *testing.T) {
+		source := "@Deprecated('Use NewWidget instead')\nclass OldWidget {}\n"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Flushing first sends the file chunked, without a Content-Length
+			if r.URL.Query().Get("chunked") != "" {
+				w.(http.Flusher).Flush()
+			}
+			w.Write([]byte(source))
+		}))
+		defer server.Close()
+
+		limited := &FlutterAPIService{client: http.DefaultClient}
+		limited.SetMaxScanFileSize(int64(len(source)))
+		if deprecations, err := limited.ScanFileForDeprecations(context.Background(), server.URL); err != nil || len(deprecations) != 1 {
+			t.Errorf("Expected a file of exactly the limit to be scanned, got %+v, %v", deprecations, err)
+		}
+
+		limited.SetMaxScanFileSize(int64(len(source)) - 1)
+		for _, query := range []string{"", "?chunked=1"} {
+			if _, err := limited.ScanFileForDeprecations(context.Background(), server.URL+query); err == nil || !strings.Contains(err.Error(), "scan limit") {
+				t.Errorf("Expected the file%s to exceed the limit, got %v", query, err)
+			}
+		}
+
+		long := strings.NewReader("final s = '" + strings.Repeat("x", config.MAX_SCAN_LINE_SIZE) + "';\n")
+		if _, err := limited.scanDeprecations(long, "", ""); err == nil || !strings.Contains(err.Error(), "line is longer") {
+			t.Errorf("Expected an overlong line to fail, got %v", err)
+		}
+	})
+
+	t.Run("fetchPackageDeprecations tags the plugin", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/repos/flutter/packages/contents/packages/go_router/lib/src/":
+				w.Write([]byte(`[{"name": "state.dart", "type": "file"}, {"name": "misc", "type": "dir"}]`))
+			case r.URL.Path == "/flutter/packages/main/packages/go_router/lib/src/state.dart":
+				w.Write([]byte("class GoRouterState {\n  @Deprecated('Use uri instead')\n  String get location;\n}\n"))
+			case strings.HasPrefix(r.URL.Path, "/repos/"):
+				w.Write([]byte(`[]`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		packagesService := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+		deprecations, err := packagesService.fetchPackageDeprecations(context.Background(), flutterPackages, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(deprecations) != 1 {
+			t.Fatalf("Expected 1 deprecation, got %+v", deprecations)
+		}
+
+		dep := deprecations[0]
+		if dep.API != "GoRouterState.location" || dep.Package != "go_router" || dep.Category != "go_router" || dep.Source != "flutter_packages" {
+			t.Errorf("Expected a tagged go_router entry, got %+v", dep)
+		}
+	})
+
+	t.Run("FetchFlutterSourceDeprecations marks preview deprecations upcoming", func(t *testing.T) {
+		app := map[string]string{
+			"stable": "class App {\n  @Deprecated('Use home instead')\n  Widget? get root;\n}\n",
+			"beta":   "class App {\n  @Deprecated('Use home instead')\n  Widget? get root;\n  @Deprecated('Use theme instead')\n  Color? get color;\n}\n",
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ref := r.URL.Query().Get("ref")
+			switch {
+			case r.URL.Path == "/repos/flutter/flutter/contents/packages/flutter/lib/src/material/":
+				w.Write([]byte(`[{"name": "app.dart", "type": "file"}]`))
+			case r.URL.Path == "/repos/flutter/flutter/contents/packages/flutter/lib/src/cupertino/" && ref == "stable":
+				w.WriteHeader(http.StatusInternalServerError)
+			case r.URL.Path == "/repos/flutter/flutter/contents/packages/flutter/lib/src/cupertino/":
+				w.Write([]byte(`[{"name": "app.dart", "type": "file"}]`))
+			case strings.HasPrefix(r.URL.Path, "/repos/"):
+				w.Write([]byte(`[]`))
+			case strings.HasPrefix(r.URL.Path, "/flutter/flutter/"):
+				branch := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/flutter/flutter/"), "/", 2)[0]
+				w.Write([]byte(app[branch]))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		previewService := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+		deprecations, err := previewService.FetchFlutterSourceDeprecations(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(deprecations) != 1 || deprecations[0].Channel != "stable" || deprecations[0].Upcoming {
+			t.Fatalf("Expected only the stable deprecation without a preview channel, got %+v", deprecations)
+		}
+
+		previewService.SetPreviewChannel("beta")
+		deprecations, err = previewService.FetchFlutterSourceDeprecations(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		// The cupertino listing failed on stable, so its beta deprecations are left out
+		if len(deprecations) != 2 {
+			t.Fatalf("Expected the stable and one upcoming deprecation, got %+v", deprecations)
+		}
+		if root := deprecations[0]; root.API != "App.root" || root.Channel != "stable" || root.Upcoming {
+			t.Errorf("Expected App.root from stable, got %+v", root)
+		}
+		if color := deprecations[1]; color.API != "App.color" || color.Channel != "beta" || !color.Upcoming || color.Category != "material" {
+			t.Errorf("Expected App.color to be upcoming on beta, got %+v", color)
+		}
+		if got := SourceURL(deprecations[1]); got != "https://github.com/flutter/flutter/blob/beta/packages/flutter/lib/src/material/app.dart#L4" {
+			t.Errorf("Expected the annotation on the beta branch, got %s", got)
+		}
+	})
+
+	t.Run("parseSourceLibraries", func(t *testing.T) {
+		directories, packages, err := parseSourceLibraries([]string{"Material", "widgets/", "go_router"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(directories) != 2 || directories[0] != "material/" || directories[1] != "widgets/" || len(packages) != 1 || packages[0].dir != "go_router" {
+			t.Errorf("Expected material, widgets and go_router, got %v and %+v", directories, packages)
+		}
+		if _, _, err := parseSourceLibraries([]string{"scheduler"}); err == nil || !strings.Contains(err.Error(), `unknown library "scheduler"`) {
+			t.Errorf("Expected an unknown library error, got %v", err)
+		}
+	})
+
+	t.Run("repositoryPath", func(t *testing.T) {
+		testCases := map[string]string{
+			"https://raw.githubusercontent.com/flutter/flutter/master/packages/flutter/lib/src/material/app.dart": "packages/flutter/lib/src/material/app.dart",
+			"https://raw.githubusercontent.com/flutter/packages/main/packages/go_router/lib/src/route.dart":       "packages/go_router/lib/src/route.dart",
+			"http://127.0.0.1:8080/packages/flutter/lib/src/widgets/basic.dart":                                   "packages/flutter/lib/src/widgets/basic.dart",
+		}
+		for input, expected := range testCases {
+			if got := repositoryPath(input); got != expected {
+				t.Errorf("repositoryPath(%s): expected %s, got %s", input, expected, got)
+			}
+		}
+	})
+
+	t.Run("contentsAPIURL", func(t *testing.T) {
+		testCases := map[string]string{
+			"https://raw.githubusercontent.com/flutter/flutter/master/packages/flutter/lib/src/material/": "https://api.github.com/repos/flutter/flutter/contents/packages/flutter/lib/src/material/?ref=master",
+			"https://raw.githubusercontent.com/flutter/packages/main/packages/camera/camera/lib/":         "https://api.github.com/repos/flutter/packages/contents/packages/camera/camera/lib/?ref=main",
+		}
+		for input, expected := range testCases {
+			if got := contentsAPIURL(input); got != expected {
+				t.Errorf("contentsAPIURL(%s): expected %s, got %s", input, expected, got)
+			}
+		}
+	})
+
 	t.Run("CheckFVMInstalled", func(t *testing.T) {
 		// This test depends on system state, so we'll just check it doesn't panic
-		result := apiService.CheckFVMInstalled()
+		result := apiService.CheckFVMInstalled(context.Background())
 		// Result can be true or false depending on system, just ensure no panic
 		_ = result
 	})
 }
 
+// redirectTransport sends every request to the test server, keeping the path
+func redirectTransport(serverURL string) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme = "http"
+		r.URL.Host = strings.TrimPrefix(serverURL, "http://")
+		return http.DefaultTransport.RoundTrip(r)
+	})
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
 func containsPreReleaseMarkers(tagName, version string) bool {
 	markers := []string{"-", ".pre", ".rc", ".beta", ".alpha", "beta", "dev", "pre", "rc", "alpha", "hotfix"}
 	for _, marker := range markers {
@@ -158,3 +441,37 @@ func containsInMiddle(s, substr string) bool {
 	}
 	return false
 }
+
+// generateDeprecatedDartSource builds a Dart file with n deprecated members spread over classes
+func generateDeprecatedDartSource(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "class Generated%d extends StatelessWidget {\n", i/10)
+		}
+		fmt.Fprintf(&b, "  /// Documentation for member %d.\n", i)
+		fmt.Fprintf(&b, "  @Deprecated('Use replacement%d instead. This feature was deprecated after v3.%d.0-0.1.pre.')\n", i, i%30)
+		fmt.Fprintf(&b, "  final double member%d;\n\n", i)
+		if i%10 == 9 {
+			b.WriteString("}\n\n")
+		}
+	}
+	return b.String()
+}
+
+func BenchmarkScanFileForDeprecations(b *testing.B) {
+	source := generateDeprecatedDartSource(200)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(source))
+	}))
+	defer server.Close()
+
+	apiService := NewFlutterAPIService()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := apiService.ScanFileForDeprecations(context.Background(), server.URL); err != nil {
+			b.Fatalf("Expected no error, got %v", err)
+		}
+	}
+}
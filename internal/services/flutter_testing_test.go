@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestFlutterTestRules(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+
+	t.Run("Detects deprecated testing APIs", func(t *testing.T) {
+		code := "import 'package:flutter_driver/driver_extension.dart';\n" +
+			"testWidgets('fits', (tester) async {\n" +
+			"  tester.binding.window.physicalSizeTestValue = const Size(400, 800);\n" +
+			"  tester.binding.window.devicePixelRatioTestValue = 1.0;\n" +
+			"  tester.binding.window.textScaleFactorTestValue = 2.0;\n" +
+			"  tester.binding.window.viewInsetsTestValue = FakeViewPadding.zero;\n" +
+			"  channel.setMockMethodCallHandler((call) async => null);\n" +
+			"  expect(find.text('Hi').precache(), isTrue);\n" +
+			"  addTearDown(tester.binding.window.clearPhysicalSizeTestValue);\n" +
+			"});\n" +
+			"class ByTooltip extends MatchFinder {\n" +
+			"  Iterable<Element> apply(Iterable<Element> candidates) => candidates;\n" +
+			"}\n"
+
+		found := make(map[string]bool)
+		for _, dep := range depService.CheckCodeForDeprecations(code) {
+			found[dep.API] = true
+		}
+		for _, api := range []string{
+			"flutter_driver",
+			"TestWindow.physicalSizeTestValue",
+			"TestWindow.devicePixelRatioTestValue",
+			"TestWindow.clearPhysicalSizeTestValue",
+			"TestWindow.textScaleFactorTestValue",
+			"TestWindow",
+			"MethodChannel.setMockMethodCallHandler",
+			"Finder.precache",
+			"Finder.apply",
+		} {
+			if !found[api] {
+				t.Errorf("Expected %q to be detected, got %v", api, found)
+			}
+		}
+
+		modern := "import 'package:integration_test/integration_test.dart';\n" +
+			"IntegrationTestWidgetsFlutterBinding.ensureInitialized();\n" +
+			"tester.view.physicalSize = const Size(400, 800);\n" +
+			"addTearDown(tester.view.resetPhysicalSize);\n" +
+			"tester.platformDispatcher.textScaleFactorTestValue = 2.0;\n" +
+			"TestDefaultBinaryMessengerBinding.instance.defaultBinaryMessenger.setMockMethodCallHandler(channel, handler);\n" +
+			"expect(find.text('Hi').tryEvaluate(), isTrue);\n"
+		if result := depService.CheckCodeForDeprecations(modern); len(result) != 0 {
+			t.Errorf("Expected the current testing APIs to pass, got %+v", result)
+		}
+	})
+
+	t.Run("Migrates what can be rewritten", func(t *testing.T) {
+		code := "tester.binding.window.physicalSizeTestValue = size;\n" +
+			"tester.binding.window.platformBrightnessTestValue = Brightness.dark;\n" +
+			"addTearDown(tester.binding.window.clearDevicePixelRatioTestValue);\n" +
+			"tester.binding.window.clearAllTestValues();\n" +
+			"channel.setMockMethodCallHandler(null);\n" +
+			"E2EWidgetsFlutterBinding.ensureInitialized();\n"
+
+		result := depService.MigrateCode(code)
+
+		expected := "tester.view.physicalSize = size;\n" +
+			"tester.platformDispatcher.platformBrightnessTestValue = Brightness.dark;\n" +
+			"addTearDown(tester.view.resetDevicePixelRatio);\n" +
+			"tester.binding.window.clearAllTestValues();\n" +
+			"TestDefaultBinaryMessengerBinding.instance.defaultBinaryMessenger.setMockMethodCallHandler(channel, null);\n" +
+			"IntegrationTestWidgetsFlutterBinding.ensureInitialized();\n"
+		if result.Code != expected {
+			t.Errorf("Expected rewritten code:\n%s\ngot:\n%s", expected, result.Code)
+		}
+
+		manual := make(map[string]int)
+		for _, pending := range result.Manual {
+			manual[pending.API] = pending.Line
+		}
+		if manual["TestWindow"] != 4 || len(result.Manual) != 1 {
+			t.Errorf("Expected clearAllTestValues to need a manual migration, got %+v", result.Manual)
+		}
+	})
+
+	t.Run("Explains the flutter_driver migration with a template", func(t *testing.T) {
+		result := depService.MigrateCode("final driver = await FlutterDriver.connect();\n")
+		if len(result.Manual) != 1 || len(result.Templates) != 1 || result.Templates[0].Replacement != "integration_test" {
+			t.Errorf("Expected the integration_test template, got %+v and %+v", result.Manual, result.Templates)
+		}
+	})
+}
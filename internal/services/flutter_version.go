@@ -1,75 +1,147 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"os/exec"
-	"regexp"
-	"strings"
+	"os"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 // FlutterVersionService handles getting Flutter version directly from Flutter CLI
-type FlutterVersionService struct{}
+type FlutterVersionService struct {
+	stats StatsRecorder
+	// executable is the flutter launcher to run; empty runs the flutter on PATH
+	executable string
+	timeout    time.Duration
+	// sdks finds the SDK FVM links to when no flutter is on PATH, for the project in projectDir
+	// (the working directory when empty)
+	sdks       *LocalSDKService
+	projectDir string
+}
 
 // NewFlutterVersionService creates a new Flutter version service
 func NewFlutterVersionService() *FlutterVersionService {
-	return &FlutterVersionService{}
+	return &FlutterVersionService{timeout: config.DEFAULT_EXEC_TIMEOUT, sdks: NewLocalSDKService()}
 }
 
-// GetInstalledFlutterVersion gets the Flutter version from the installed Flutter CLI
-func (f *FlutterVersionService) GetInstalledFlutterVersion() (string, error) {
-	cmd := exec.Command("flutter", "--version")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
+// SetStatsRecorder reports the latency of every flutter invocation to recorder
+func (f *FlutterVersionService) SetStatsRecorder(recorder StatsRecorder) {
+	f.stats = recorder
+}
 
-	// Parse output like: "Flutter 3.32.0 • channel stable • https://github.com/flutter/flutter.git"
-	lines := strings.Split(string(output), "\n")
-	if len(lines) == 0 {
-		return "", fmt.Errorf("no output from flutter --version")
+// SetFlutterSDK runs the flutter launcher of the SDK at root instead of the one on PATH
+func (f *FlutterVersionService) SetFlutterSDK(root string) {
+	f.executable = ""
+	if root != "" {
+		f.executable = flutterLauncher(root)
 	}
+}
 
-	firstLine := lines[0]
-	
-	// Extract version using regex: "Flutter X.Y.Z"
-	versionRegex := regexp.MustCompile(`Flutter (\d+\.\d+\.\d+)`)
-	matches := versionRegex.FindStringSubmatch(firstLine)
-	
-	if len(matches) < 2 {
-		return "", fmt.Errorf("could not parse version from: %s", firstLine)
+// SetExecTimeout kills flutter invocations that run longer than timeout
+func (f *FlutterVersionService) SetExecTimeout(timeout time.Duration) {
+	f.timeout = timeout
+}
+
+// launcher returns the flutter to run and, unless it is the one on PATH, what selected it: the
+// --flutter-sdk flag, or for FVM-only setups the project's .fvm/flutter_sdk or the global default link
+func (f *FlutterVersionService) launcher() (string, string) {
+	if f.executable != "" {
+		return f.executable, config.SDK_SOURCE_FLAG
 	}
+	if f.sdks != nil && !f.sdks.flutterOnPath() {
+		projectDir := f.projectDir
+		if projectDir == "" {
+			projectDir, _ = os.Getwd()
+		}
+		if link, root := f.sdks.fvmLinkedSDK(projectDir); root != "" {
+			if launcher := flutterLauncher(root); launcher != "" {
+				return launcher, "FVM link " + link
+			}
+		}
+	}
+	return "flutter", ""
+}
 
-	return matches[1], nil
+// runFlutter runs the flutter CLI and returns its standard output
+func (f *FlutterVersionService) runFlutter(ctx context.Context, args ...string) ([]byte, error) {
+	executable, _ := f.launcher()
+	start := time.Now()
+	output, err := runExec(ctx, f.timeout, executable, args...)
+	if f.stats != nil {
+		f.stats.RecordUpstream(UpstreamExecFlutter, time.Since(start), err != nil)
+	}
+	return output, err
 }
 
-// IsFlutterInstalled checks if Flutter CLI is available
-func (f *FlutterVersionService) IsFlutterInstalled() bool {
-	cmd := exec.Command("flutter", "--version")
-	return cmd.Run() == nil
+// flutterMachineVersion is what flutter --version --machine reports about the SDK it belongs to
+type flutterMachineVersion struct {
+	FrameworkVersion string `json:"frameworkVersion"`
+	Channel          string `json:"channel"`
+	DartSDKVersion   string `json:"dartSdkVersion"`
+	EngineRevision   string `json:"engineRevision"`
 }
 
-// GetFlutterChannel gets the Flutter channel (stable, beta, dev)
-func (f *FlutterVersionService) GetFlutterChannel() (string, error) {
-	cmd := exec.Command("flutter", "--version")
-	output, err := cmd.Output()
+// machineVersion runs flutter --version --machine, whose JSON stays the same across Flutter
+// releases and locales, unlike the banner of flutter --version
+func (f *FlutterVersionService) machineVersion(ctx context.Context) (*flutterMachineVersion, error) {
+	output, err := f.runFlutter(ctx, "--version", "--machine")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return parseMachineVersion(output)
+}
 
-	lines := strings.Split(string(output), "\n")
-	if len(lines) == 0 {
-		return "", fmt.Errorf("no output from flutter --version")
+// parseMachineVersion decodes the output of flutter --version --machine. Notices such as "Waiting
+// for another flutter command to release the startup lock..." can come before the JSON.
+func parseMachineVersion(output []byte) (*flutterMachineVersion, error) {
+	start := bytes.IndexByte(output, '{')
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON in the output of flutter --version --machine")
 	}
+	var version flutterMachineVersion
+	if err := json.NewDecoder(bytes.NewReader(output[start:])).Decode(&version); err != nil {
+		return nil, fmt.Errorf("could not parse the output of flutter --version --machine: %v", err)
+	}
+	if version.FrameworkVersion == "" {
+		return nil, fmt.Errorf("flutter --version --machine reported no frameworkVersion")
+	}
+	return &version, nil
+}
 
-	firstLine := lines[0]
-	
-	// Extract channel using regex: "• channel stable •"
-	channelRegex := regexp.MustCompile(`• channel (\w+) •`)
-	matches := channelRegex.FindStringSubmatch(firstLine)
-	
-	if len(matches) < 2 {
-		return "unknown", nil
+// GetInstalledFlutterVersion gets the Flutter version from the installed Flutter CLI
+func (f *FlutterVersionService) GetInstalledFlutterVersion(ctx context.Context) (string, error) {
+	version, err := f.machineVersion(ctx)
+	if err != nil {
+		return "", err
 	}
+	return version.FrameworkVersion, nil
+}
+
+// IsFlutterInstalled checks if Flutter CLI is available
+func (f *FlutterVersionService) IsFlutterInstalled(ctx context.Context) bool {
+	_, err := f.runFlutter(ctx, "--version", "--machine")
+	return err == nil
+}
+
+// GetFlutterChannel gets the Flutter channel (stable, beta, master)
+func (f *FlutterVersionService) GetFlutterChannel(ctx context.Context) (string, error) {
+	_, channel, err := f.GetInstalledVersionAndChannel(ctx)
+	return channel, err
+}
 
-	return matches[1], nil
-}
\ No newline at end of file
+// GetInstalledVersionAndChannel gets the version and channel of the installed Flutter CLI from a
+// single flutter --version --machine
+func (f *FlutterVersionService) GetInstalledVersionAndChannel(ctx context.Context) (string, string, error) {
+	version, err := f.machineVersion(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if version.Channel == "" {
+		return version.FrameworkVersion, "unknown", nil
+	}
+	return version.FrameworkVersion, version.Channel, nil
+}
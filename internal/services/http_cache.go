@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// httpCacheTransport keeps the GET responses that carry an ETag or Last-Modified validator on disk
+// and revalidates them on the next request, so unchanged raw source files and API responses are
+// answered with a body-less 304 instead of being downloaded again. 304s from the GitHub API do not
+// count against its rate limit either.
+type httpCacheTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+// httpCacheEntry is the metadata line at the start of a cache file; the body follows it
+type httpCacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+}
+
+// NewHTTPCacheTransport wraps base with a disk cache in dir. Wrap the mirror transport rather than
+// be wrapped by it, so entries stay keyed by the upstream URL whichever mirror served them.
+func NewHTTPCacheTransport(base http.RoundTripper, dir string) http.RoundTripper {
+	return &httpCacheTransport{base: base, dir: dir}
+}
+
+// ClearHTTPCache removes the cached upstream responses kept in cacheDir
+func ClearHTTPCache(cacheDir string) error {
+	return os.RemoveAll(filepath.Join(cacheDir, config.HTTP_CACHE_DIR))
+}
+
+// RoundTrip implements http.RoundTripper
+func (h *httpCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Leave requests alone that cannot be cached or that bring their own validators
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return h.base.RoundTrip(req)
+	}
+
+	path := h.entryPath(req)
+	entry, body, cached := h.load(path)
+	if cached {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := h.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// The 304 carries the current values of headers such as the rate limit, so they win
+		header := entry.Header.Clone()
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       resp.Request,
+		}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Cache-Control") == "no-store" {
+		return resp, nil
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return resp, nil
+	}
+
+	// Read one byte past the limit to tell an oversized body apart, and hand it on uncached
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, config.HTTP_CACHE_MAX_ENTRY_SIZE+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(data) > config.HTTP_CACHE_MAX_ENTRY_SIZE {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	entry = httpCacheEntry{URL: req.URL.String(), ETag: etag, LastModified: lastModified, Header: resp.Header.Clone()}
+	if err := h.store(path, entry, data); err != nil {
+		slog.Debug("Could not cache upstream response", "url", entry.URL, "error", err)
+	}
+	return resp, nil
+}
+
+// entryPath names the cache file of a request after its URL and the representation it accepts
+func (h *httpCacheTransport) entryPath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return filepath.Join(h.dir, config.HTTP_CACHE_DIR, hex.EncodeToString(sum[:]))
+}
+
+// load reads a cache file; unreadable or corrupt files count as a miss
+func (h *httpCacheTransport) load(path string) (httpCacheEntry, []byte, bool) {
+	var entry httpCacheEntry
+	file, err := os.Open(path)
+	if err != nil {
+		return entry, nil, false
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadBytes('\n')
+	if err != nil || json.Unmarshal(line, &entry) != nil {
+		return entry, nil, false
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return entry, nil, false
+	}
+	return entry, body, true
+}
+
+// store writes a cache file through a temporary file, so concurrent readers never see half of one
+func (h *httpCacheTransport) store(path string, entry httpCacheEntry, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(append(append(meta, '\n'), body...))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestHTTPCacheTransport(t *testing.T) {
+	content := "# Guide"
+	var downloads, revalidations int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + content + `"`
+		w.Header().Set("X-RateLimit-Remaining", "59")
+		if r.Header.Get("If-None-Match") == etag {
+			revalidations++
+			w.Header().Set("X-RateLimit-Remaining", "58")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		if !strings.Contains(r.URL.Path, "uncacheable") {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	newService := func() *FlutterAPIService {
+		service := NewFlutterAPIService()
+		service.SetTransport(NewHTTPCacheTransport(redirectTransport(server.URL), dir))
+		return service
+	}
+
+	t.Run("Revalidates unchanged files instead of downloading them", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			// A new service each time, as on the next run of the server
+			if got, err := newService().FetchWebsiteFile(ctx, "index.md"); err != nil || got != "# Guide" {
+				t.Fatalf("Expected the guide, got %q and %v", got, err)
+			}
+		}
+		if downloads != 1 || revalidations != 2 {
+			t.Errorf("Expected 1 download and 2 revalidations, got %d and %d", downloads, revalidations)
+		}
+
+		content = "# Updated guide"
+		if got, err := newService().FetchWebsiteFile(ctx, "index.md"); err != nil || got != "# Updated guide" {
+			t.Errorf("Expected the changed guide, got %q and %v", got, err)
+		}
+		if downloads != 2 {
+			t.Errorf("Expected the changed guide to be downloaded, got %d downloads", downloads)
+		}
+	})
+
+	t.Run("Keeps the current headers of a revalidated response", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/headers", nil)
+		transport := NewHTTPCacheTransport(http.DefaultTransport, dir)
+		for _, want := range []string{"59", "58"} {
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("X-RateLimit-Remaining") != want {
+				t.Errorf("Expected status 200 with %s remaining, got %d with %s", want, resp.StatusCode, resp.Header.Get("X-RateLimit-Remaining"))
+			}
+		}
+	})
+
+	t.Run("Skips responses without a validator", func(t *testing.T) {
+		before := downloads
+		for i := 0; i < 2; i++ {
+			if _, err := newService().FetchWebsiteFile(ctx, "uncacheable.md"); err != nil {
+				t.Fatalf("FetchWebsiteFile failed: %v", err)
+			}
+		}
+		if downloads != before+2 {
+			t.Errorf("Expected both requests to download, got %d", downloads-before)
+		}
+	})
+
+	t.Run("Clears the cached responses", func(t *testing.T) {
+		if err := ClearHTTPCache(dir); err != nil {
+			t.Fatalf("ClearHTTPCache failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, config.HTTP_CACHE_DIR)); !os.IsNotExist(err) {
+			t.Errorf("Expected the cache directory to be removed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// NewHTTPTransport returns a transport for the upstream calls that trusts the certificates of
+// caFile, a PEM bundle such as the root of a TLS-intercepting corporate proxy, in addition to the
+// system roots. insecure turns certificate verification off entirely and is meant for diagnosing
+// a proxy, not for everyday use. Proxy settings from the environment are kept.
+func NewHTTPTransport(caFile string, insecure bool) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile == "" && !insecure {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	tlsConfig.InsecureSkipVerify = insecure
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPTransport(t *testing.T) {
+	// httptest signs the server certificate with a CA no system trusts, like an intercepting proxy
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "proxy-ca.pem")
+	writeFile(t, caFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+
+	fetch := func(transport http.RoundTripper) error {
+		service := NewFlutterAPIService()
+		service.SetTransport(transport)
+		_, err := service.FetchWebsiteFile(context.Background(), "")
+		return err
+	}
+	// FetchWebsiteFile goes to the flutter/website host, so send every request to the test server
+	redirect := func(transport *http.Transport) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			r.URL.Host = server.Listener.Addr().String()
+			return transport.RoundTrip(r)
+		})
+	}
+
+	t.Run("Trusts the CA file in addition to the system roots", func(t *testing.T) {
+		plain, err := NewHTTPTransport("", false)
+		if err != nil {
+			t.Fatalf("NewHTTPTransport failed: %v", err)
+		}
+		if err := fetch(redirect(plain)); err == nil {
+			t.Error("Expected the untrusted certificate to be rejected")
+		}
+
+		trusting, err := NewHTTPTransport(caFile, false)
+		if err != nil {
+			t.Fatalf("NewHTTPTransport failed: %v", err)
+		}
+		if err := fetch(redirect(trusting)); err != nil {
+			t.Errorf("Expected the CA file to be trusted, got %v", err)
+		}
+	})
+
+	t.Run("Skips verification when asked to", func(t *testing.T) {
+		insecure, err := NewHTTPTransport("", true)
+		if err != nil {
+			t.Fatalf("NewHTTPTransport failed: %v", err)
+		}
+		if err := fetch(redirect(insecure)); err != nil {
+			t.Errorf("Expected verification to be skipped, got %v", err)
+		}
+	})
+
+	t.Run("Rejects unusable CA files", func(t *testing.T) {
+		if _, err := NewHTTPTransport(filepath.Join(t.TempDir(), "missing.pem"), false); err == nil {
+			t.Error("Expected an error for a missing CA file")
+		}
+		notPEM := filepath.Join(t.TempDir(), "ca.der")
+		writeFile(t, notPEM, "not a certificate")
+		if _, err := NewHTTPTransport(notPEM, false); err == nil {
+			t.Error("Expected an error for a file without PEM certificates")
+		}
+	})
+}
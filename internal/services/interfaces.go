@@ -1,6 +1,11 @@
 package services
 
-import "github.com/jger/mcp-flutter-deprecations-server/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
 
 // CacheServiceInterface defines the cache service contract
 type CacheServiceInterface interface {
@@ -8,34 +13,240 @@ type CacheServiceInterface interface {
 	Save(cache *models.DeprecationCache) error
 }
 
+// CacheClearServiceInterface defines the contract of resetting the deprecations cache
+type CacheClearServiceInterface interface {
+	ClearAll() error
+}
+
+// CacheStatusServiceInterface defines the contract of reporting the state of the deprecations cache
+type CacheStatusServiceInterface interface {
+	Status() (*models.CacheStatus, error)
+}
+
 // FlutterAPIServiceInterface defines the Flutter API service contract
 type FlutterAPIServiceInterface interface {
-	FetchReleases() ([]models.FlutterRelease, error)
-	FetchOfficialReleases() (*models.FlutterReleasesResponse, error)
+	FetchReleases(ctx context.Context) ([]models.FlutterRelease, error)
+	FetchOfficialReleases(ctx context.Context) (*models.FlutterReleasesResponse, error)
 	ParseVersionFromRelease(release models.FlutterRelease) string
-	GetLatestStableVersion() (string, error)
-	CheckFVMInstalled() bool
-	CheckFVMVersionExists(version string) bool
-	CheckDockerImageExists(image string, tag string) bool
-	FetchFlutterSourceDeprecations() ([]models.Deprecation, error)
-	FetchFlutterSourceDeprecationsWithProgress(progressCallback func(string), verbose bool) ([]models.Deprecation, error)
+	GetLatestStableVersion(ctx context.Context) (string, error)
+	CheckFVMInstalled(ctx context.Context) bool
+	CheckFVMVersionExists(ctx context.Context, version string) bool
+	CheckDockerImageExists(ctx context.Context, image string, tag string) bool
+	FetchWebsiteFile(ctx context.Context, path string) (string, error)
+	FetchFlutterSourceDeprecations(ctx context.Context) ([]models.Deprecation, error)
+	FetchFlutterSourceDeprecationsWithProgress(ctx context.Context, progressCallback func(string)) ([]models.Deprecation, error)
+	FetchSourceDeprecationsIn(ctx context.Context, libraries []string) ([]models.Deprecation, error)
 }
 
 // DeprecationServiceInterface defines the deprecation service contract
 type DeprecationServiceInterface interface {
 	CheckCodeForDeprecations(code string) []models.Deprecation
-	UpdateCache() error
+	CheckDartCode(code string) []models.Deprecation
+	RulesFingerprint(target string) string
+	CheckFiles(ctx context.Context, entries []models.CheckFileEntry, projectPath string, target string) ([]models.FileCheckResult, error)
+	FindDeprecations(api string) []models.Deprecation
+	SearchDeprecations(query string, limit int) []models.DeprecationMatch
+	MigrateCode(code string) models.MigrationResult
+	AnalyzerFixes(file string, code string, target string) (*models.AnalyzerFixesResult, error)
+	ProjectDiagnostics(result *models.ProjectScanResult, target string, hidden map[string]bool) ([]models.PublishDiagnosticsParams, error)
+	FindingsBaseline(result *models.ProjectScanResult, target string) (*models.FindingsBaseline, error)
+	ApplyFindingsBaseline(result *models.ProjectScanResult, baseline *models.FindingsBaseline, target string) error
+	CheckCodeAgainstVersion(code string, target string, current string) (*models.VersionCheckResult, error)
+	VersionMatrix(ctx context.Context, code string, projectPath string, filter models.PathFilter, targets []string) (*models.VersionMatrix, error)
+	TestRules(ctx context.Context, target string, samplesPath string, code string, filter string) (*models.RuleTestResult, error)
+	AddManualDeprecation(dep models.Deprecation) (bool, error)
+	DeprecationStats(recent int) (*models.DeprecationStats, error)
+	UpdateCache(ctx context.Context) error
+	UpdateCacheScoped(ctx context.Context, directories []string, sources []string) error
 	ExtractDeprecationsFromReleaseNotes(releases []models.FlutterRelease) []models.Deprecation
 }
 
+// SamplerInterface asks the language model of the connected MCP client for a completion, returning
+// its text and the model that wrote it
+type SamplerInterface interface {
+	SamplingSupported() bool
+	CreateMessage(ctx context.Context, systemPrompt string, prompt string, maxTokens int) (string, string, error)
+}
+
+// ConfirmerInterface asks the user of the connected MCP client whether the server may go ahead
+// with a change to local state
+type ConfirmerInterface interface {
+	ElicitationSupported() bool
+	Confirm(ctx context.Context, message string) (bool, error)
+}
+
+// RootListerInterface lists the local directories the connected MCP client exposes as roots
+type RootListerInterface interface {
+	RootsSupported() bool
+	Roots(ctx context.Context) ([]string, error)
+}
+
+// ProjectRootServiceInterface defines the active project discovery contract
+type ProjectRootServiceInterface interface {
+	ActiveProject(ctx context.Context) (string, error)
+}
+
+// ReplacementDraftServiceInterface defines the replacement drafting contract
+type ReplacementDraftServiceInterface interface {
+	DraftReplacements(ctx context.Context, api string, limit int) (*models.ReplacementDrafts, error)
+}
+
+// MigrationGuideServiceInterface defines the migration guide service contract
+type MigrationGuideServiceInterface interface {
+	ExplainDeprecation(ctx context.Context, api string) (*models.DeprecationExplanation, error)
+	BreakingChangesBetween(ctx context.Context, from string, to string) (*models.BreakingChangesResult, error)
+}
+
+// WhatsNewServiceInterface defines the Flutter release digest contract
+type WhatsNewServiceInterface interface {
+	WhatsNew(ctx context.Context, version string) (*models.ReleaseDigest, error)
+}
+
+// UpgradeDigestServiceInterface defines the upgrade digest contract
+type UpgradeDigestServiceInterface interface {
+	UpgradeDigest(ctx context.Context, from string, projectPath string) (*models.UpgradeDigest, error)
+}
+
+// ReleaseHistoryServiceInterface defines the stable release history contract
+type ReleaseHistoryServiceInterface interface {
+	StableReleases(ctx context.Context, limit int, since string) (*models.ReleaseHistory, error)
+}
+
+// ProjectScanServiceInterface defines the local project and workspace scanning contract
+type ProjectScanServiceInterface interface {
+	Scan(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.ProjectScanResult, error)
+	ScanChanged(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.ProjectScanResult, error)
+}
+
+// DependencyAuditServiceInterface defines the contract of auditing a project's locked packages
+type DependencyAuditServiceInterface interface {
+	Audit(ctx context.Context, projectPath string) (*models.DependencyAudit, error)
+}
+
+// RepoScanServiceInterface defines the GitHub repository scanning contract
+type RepoScanServiceInterface interface {
+	Scan(ctx context.Context, repo string, ref string, dir string, pkg string) (*models.RepoScanResult, error)
+	Save(result *models.RepoScanResult) (int, error)
+}
+
+// DiffScanServiceInterface defines the contract for checking the lines a change adds
+type DiffScanServiceInterface interface {
+	ScanStaged(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.DiffScanResult, error)
+	ScanRange(ctx context.Context, projectPath string, gitRange string, target string, filter models.PathFilter) (*models.DiffScanResult, error)
+	ScanDiff(ctx context.Context, projectPath string, diff string, target string, filter models.PathFilter) (*models.DiffScanResult, error)
+}
+
+// PRReviewServiceInterface defines the GitHub pull request review contract
+type PRReviewServiceInterface interface {
+	Review(ctx context.Context, repo string, number int, target string, hidden map[string]bool, post bool) (*models.PRReviewResult, error)
+}
+
+// SDKScanServiceInterface defines the offline per-version deprecations contract
+type SDKScanServiceInterface interface {
+	Versions(ctx context.Context) ([]models.SDKDeprecations, error)
+	Compare(ctx context.Context, from string, to string) (*models.VersionComparison, error)
+	IntroducedIn(ctx context.Context, version string) (*models.IntroducedDeprecations, error)
+}
+
+// MinimumVersionServiceInterface defines the minimum Flutter version inference contract
+type MinimumVersionServiceInterface interface {
+	InferFromCode(code string) *models.MinimumVersionResult
+	InferFromProject(ctx context.Context, projectPath string, filter models.PathFilter) (*models.MinimumVersionResult, error)
+}
+
+// SDKConstraintServiceInterface defines the pubspec.yaml SDK constraint suggestion contract
+type SDKConstraintServiceInterface interface {
+	Suggest(ctx context.Context, content string, projectPath string, target string) (*models.SDKConstraintSuggestion, error)
+}
+
+// PinRecommendationServiceInterface defines the dependency based Flutter pin recommendation contract
+type PinRecommendationServiceInterface interface {
+	Recommend(ctx context.Context, content string, projectPath string) (*models.PinRecommendation, error)
+}
+
+// SuppressionServiceInterface defines the deprecation suppression contract
+type SuppressionServiceInterface interface {
+	Suppress(api string, reason string, projectPath string) (models.Suppression, error)
+	Unsuppress(api string, projectPath string) (bool, error)
+	Suppressions(projectPath string) ([]models.Suppression, error)
+}
+
+// TeamSyncServiceInterface defines the team database synchronisation contract
+type TeamSyncServiceInterface interface {
+	Pull(ctx context.Context) (*models.TeamDatabase, error)
+	Push(ctx context.Context) error
+}
+
+// DockerfileServiceInterface defines the Dockerfile generation contract
+type DockerfileServiceInterface interface {
+	Generate(ctx context.Context, version string, target string, image string) (*models.DockerfileResult, error)
+}
+
+// CIWorkflowServiceInterface defines the CI configuration checking contract
+type CIWorkflowServiceInterface interface {
+	CheckPath(ctx context.Context, path string) (*models.CIWorkflowReport, error)
+	CheckContent(ctx context.Context, content string) (*models.CIWorkflowReport, error)
+}
+
+// WebCheckServiceInterface defines the web setup checking contract
+type WebCheckServiceInterface interface {
+	CheckPath(ctx context.Context, path string, version string) (*models.WebCheckReport, error)
+	CheckContent(ctx context.Context, content string, version string) (*models.WebCheckReport, error)
+}
+
+// RunnerTemplateServiceInterface defines the desktop runner template comparison contract
+type RunnerTemplateServiceInterface interface {
+	Check(ctx context.Context, projectPath string, version string, platforms []string) (*models.RunnerDriftReport, error)
+}
+
+// LocalSDKServiceInterface defines the local Flutter SDK detection contract
+type LocalSDKServiceInterface interface {
+	Detect(ctx context.Context, projectPath string) (*models.LocalSDKReport, error)
+}
+
+// RateLimitServiceInterface defines the GitHub API quota contract
+type RateLimitServiceInterface interface {
+	RateLimitStatus(ctx context.Context) (*models.RateLimitStatus, error)
+}
+
+// SecurityAdvisoryServiceInterface defines the Flutter and Dart SDK security advisory contract
+type SecurityAdvisoryServiceInterface interface {
+	SecurityAdvisories(ctx context.Context, flutterVersion string, dartVersion string) (*models.AdvisoryCheck, error)
+}
+
+// UpstreamCheckServiceInterface defines the contract of probing the upstream hosts
+type UpstreamCheckServiceInterface interface {
+	CheckUpstreams(ctx context.Context) []models.UpstreamCheck
+}
+
 // VersionInfoServiceInterface defines the version info service contract
 type VersionInfoServiceInterface interface {
-	GetFlutterVersionInfo() (*models.FlutterVersionInfo, error)
+	GetFlutterVersionInfo(ctx context.Context) (*models.FlutterVersionInfo, error)
+}
+
+// StatsRecorder receives latency measurements for upstream calls
+type StatsRecorder interface {
+	RecordUpstream(name string, duration time.Duration, failed bool)
+}
+
+// CacheRecorder receives the loads of the deprecations cache, with the last full update of the data
+// they returned, and the outcome of its updates
+type CacheRecorder interface {
+	RecordCacheLoad(result string, lastUpdated time.Time)
+	RecordCacheUpdate(result string)
+}
+
+// StatsServiceInterface defines the usage statistics contract
+type StatsServiceInterface interface {
+	StatsRecorder
+	CacheRecorder
+	RecordTool(name string, duration time.Duration, failed bool)
+	Snapshot() models.ServerStats
 }
 
 // FlutterVersionServiceInterface defines the Flutter version detection contract
 type FlutterVersionServiceInterface interface {
-	GetInstalledFlutterVersion() (string, error)
-	IsFlutterInstalled() bool
-	GetFlutterChannel() (string, error)
+	GetInstalledFlutterVersion(ctx context.Context) (string, error)
+	IsFlutterInstalled(ctx context.Context) bool
+	GetFlutterChannel(ctx context.Context) (string, error)
 }
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// flutterExecutables are the names of the flutter launcher in an SDK's bin directory, on any system
+var flutterExecutables = []string{"flutter", "flutter.bat"}
+
+// LocalSDKService finds the Flutter SDKs installed on the machine: those on PATH, in the FVM
+// cache, in puro environments and in the usual manual install locations
+type LocalSDKService struct {
+	home     string
+	path     string
+	fvmCache string
+	puroRoot string
+	// goos selects the system's SDK locations; empty means a Unix-like one
+	goos         string
+	localAppData string
+	// sdk is the SDK chosen with --flutter-sdk, which is active instead of the flutter on PATH
+	sdk string
+	// installPaths overrides the common install locations derived from home
+	installPaths []string
+}
+
+// NewLocalSDKService creates a detector for the current user's PATH, home and SDK stores
+func NewLocalSDKService() *LocalSDKService {
+	home, _ := os.UserHomeDir()
+	return &LocalSDKService{
+		home:         home,
+		path:         os.Getenv("PATH"),
+		fvmCache:     os.Getenv(config.FVM_CACHE_ENV),
+		puroRoot:     os.Getenv(config.PURO_ROOT_ENV),
+		goos:         runtime.GOOS,
+		localAppData: os.Getenv(config.LOCAL_APPDATA_ENV),
+	}
+}
+
+// SetFlutterSDK makes the SDK at root the active one instead of the first flutter on PATH; an
+// empty root restores the PATH lookup
+func (l *LocalSDKService) SetFlutterSDK(root string) {
+	l.sdk = root
+}
+
+// Detect lists every Flutter SDK with its version and channel, marks the one that runs as
+// flutter, and warns when it differs from the version the project at projectPath pins
+func (l *LocalSDKService) Detect(ctx context.Context, projectPath string) (*models.LocalSDKReport, error) {
+	report := &models.LocalSDKReport{ProjectPath: projectPath}
+	index := make(map[string]int)
+
+	add := func(root string, source string, name string) *models.LocalFlutterSDK {
+		if !isFlutterSDK(root) {
+			return nil
+		}
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		if i, exists := index[root]; exists {
+			sdk := &report.SDKs[i]
+			if !containsString(sdk.Sources, source) {
+				sdk.Sources = append(sdk.Sources, source)
+			}
+			if sdk.Name == "" {
+				sdk.Name = name
+			}
+			return sdk
+		}
+
+		version, channel := readSDKVersion(root)
+		index[root] = len(report.SDKs)
+		report.SDKs = append(report.SDKs, models.LocalFlutterSDK{
+			Path:    root,
+			Version: version,
+			Channel: channel,
+			Name:    name,
+			Sources: []string{source},
+		})
+		return &report.SDKs[len(report.SDKs)-1]
+	}
+
+	// An SDK chosen with --flutter-sdk is the one that runs, whatever PATH holds
+	active := -1
+	if l.sdk != "" {
+		if sdk := add(l.sdk, config.SDK_SOURCE_FLAG, ""); sdk != nil {
+			active = index[sdk.Path]
+		}
+	}
+
+	// Otherwise the first flutter on PATH is the one that runs; later ones are shadowed by it
+	pathActive := -1
+	for _, dir := range filepath.SplitList(l.path) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, executable := range flutterExecutables {
+			launcher, err := filepath.EvalSymlinks(filepath.Join(dir, executable))
+			if err != nil {
+				continue
+			}
+			sdk := add(filepath.Dir(filepath.Dir(launcher)), config.SDK_SOURCE_PATH, "")
+			if sdk == nil {
+				continue
+			}
+			if pathActive < 0 {
+				pathActive = index[sdk.Path]
+			} else if index[sdk.Path] != pathActive {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s on PATH is shadowed by %s", sdk.Path, report.SDKs[pathActive].Path))
+			}
+			break
+		}
+	}
+	if active < 0 {
+		active = pathActive
+	}
+
+	// Without flutter on PATH, FVM-only setups run the SDK FVM links for the project or globally
+	if active < 0 {
+		if link, root := l.fvmLinkedSDK(projectPath); root != "" {
+			if sdk := add(root, config.SDK_SOURCE_FVM, ""); sdk != nil {
+				active = index[sdk.Path]
+				report.Warnings = append(report.Warnings, fmt.Sprintf("No flutter on PATH; using the SDK FVM links at %s, so run it with `fvm flutter`", link))
+			}
+		}
+	}
+
+	for _, store := range l.fvmStores() {
+		for _, name := range subdirectories(store) {
+			add(filepath.Join(store, name), config.SDK_SOURCE_FVM, name)
+		}
+	}
+
+	if puroHome := l.puroHome(); puroHome != "" {
+		puroEnvs := filepath.Join(puroHome, "envs")
+		for _, name := range subdirectories(puroEnvs) {
+			add(filepath.Join(puroEnvs, name, "flutter"), config.SDK_SOURCE_PURO, name)
+		}
+	}
+
+	for _, root := range l.commonInstallPaths() {
+		add(root, config.SDK_SOURCE_INSTALL, "")
+	}
+
+	if active >= 0 {
+		report.SDKs[active].Active = true
+	}
+	if len(report.SDKs) == 0 {
+		report.Warnings = append(report.Warnings, "No Flutter SDK found on PATH, in FVM, in puro or in the common install locations")
+	} else if active < 0 {
+		report.Warnings = append(report.Warnings, "No flutter executable on PATH; the SDKs below are installed but not active")
+	}
+
+	if projectPath == "" {
+		return report, nil
+	}
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", projectPath)
+	}
+	l.checkPin(report, projectPath, active)
+	return report, nil
+}
+
+// checkPin records the project's pinned version and warns when the active SDK does not match it
+func (l *LocalSDKService) checkPin(report *models.LocalSDKReport, projectPath string, active int) {
+	pin := readProjectPin(projectPath)
+	if pin.version == "" && pin.puroEnv == "" {
+		return
+	}
+	report.PinnedBy = pin.file
+
+	// A puro pin names an environment; its version is whatever that environment has installed
+	var pinned *models.LocalFlutterSDK
+	for i := range report.SDKs {
+		sdk := &report.SDKs[i]
+		if pin.puroEnv != "" && sdk.Name == pin.puroEnv && containsString(sdk.Sources, config.SDK_SOURCE_PURO) {
+			pinned = sdk
+			pin.version = sdk.Version
+			break
+		}
+	}
+	if pin.puroEnv != "" && pinned == nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s selects the puro environment %q, which is not installed; run `puro create %s`", pin.file, pin.puroEnv, pin.puroEnv))
+		return
+	}
+	report.PinnedVersion = pin.version
+	if pinned == nil {
+		for i := range report.SDKs {
+			if sdkMatches(report.SDKs[i], pin.version) {
+				pinned = &report.SDKs[i]
+				break
+			}
+		}
+	}
+
+	if active >= 0 && sdkMatches(report.SDKs[active], pin.version) {
+		return
+	}
+
+	activeDescription := "No flutter on PATH"
+	if active >= 0 {
+		sdk := report.SDKs[active]
+		version := sdk.Version
+		if version == "" {
+			version = "an unknown version"
+		}
+		activeDescription = fmt.Sprintf("The active flutter (%s at %s)", version, sdk.Path)
+	}
+	warning := fmt.Sprintf("%s differs from the %s pinned in %s", activeDescription, pin.version, pin.file)
+	switch {
+	case pinned != nil && l.sdk != "":
+		warning += fmt.Sprintf("; pass --flutter-sdk %s", pinned.Path)
+	case pinned != nil && pin.puroEnv != "":
+		warning += fmt.Sprintf("; run it with `puro flutter` or use the SDK at %s", pinned.Path)
+	case pinned != nil && containsString(pinned.Sources, config.SDK_SOURCE_FVM):
+		warning += fmt.Sprintf("; run it with `fvm flutter` or use the SDK at %s", pinned.Path)
+	case pinned != nil:
+		warning += fmt.Sprintf("; put %s first on PATH", filepath.Join(pinned.Path, "bin"))
+	default:
+		warning += fmt.Sprintf("; no installed SDK matches, install it with `fvm install %s`", pin.version)
+	}
+	report.Warnings = append(report.Warnings, warning)
+}
+
+// flutterOnPath reports whether a flutter launcher is on PATH
+func (l *LocalSDKService) flutterOnPath() bool {
+	for _, dir := range filepath.SplitList(l.path) {
+		for _, executable := range flutterExecutables {
+			if info, err := os.Stat(filepath.Join(dir, executable)); err == nil && !info.IsDir() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fvmLinkedSDK finds the SDK FVM links to: the project's .fvm/flutter_sdk, which `fvm use`
+// creates, else the default link of `fvm global` next to the versions directory. It returns the
+// link and the SDK root it points at, or empty strings when neither link leads to an SDK.
+func (l *LocalSDKService) fvmLinkedSDK(projectPath string) (string, string) {
+	var links []string
+	if projectPath != "" {
+		links = append(links, filepath.Join(projectPath, ".fvm", "flutter_sdk"))
+	}
+	for _, store := range l.fvmStores() {
+		links = append(links, filepath.Join(filepath.Dir(store), "default"))
+	}
+	for _, link := range links {
+		if root, err := filepath.EvalSymlinks(link); err == nil && isFlutterSDK(root) {
+			return link, root
+		}
+	}
+	return "", ""
+}
+
+// fvmStores returns the directories FVM keeps its SDK versions in, newest layout first
+func (l *LocalSDKService) fvmStores() []string {
+	if l.fvmCache != "" {
+		return []string{filepath.Join(l.fvmCache, "versions")}
+	}
+	var stores []string
+	// FVM 3 keeps its SDKs in %LOCALAPPDATA%\fvm on Windows
+	if l.goos == "windows" && l.localAppData != "" {
+		stores = append(stores, filepath.Join(l.localAppData, "fvm", "versions"))
+	}
+	if l.home != "" {
+		stores = append(stores, filepath.Join(l.home, "fvm", "versions"), filepath.Join(l.home, ".fvm", "versions"))
+	}
+	return stores
+}
+
+// puroHome returns the directory puro keeps its environments in
+func (l *LocalSDKService) puroHome() string {
+	if l.puroRoot != "" || l.home == "" {
+		return l.puroRoot
+	}
+	return filepath.Join(l.home, ".puro")
+}
+
+// commonInstallPaths lists where the Flutter install guides and package managers put the SDK
+func (l *LocalSDKService) commonInstallPaths() []string {
+	if l.installPaths != nil {
+		return l.installPaths
+	}
+	paths := []string{"/opt/flutter", "/usr/local/flutter", "/usr/lib/flutter"}
+	if l.goos == "windows" {
+		// The Windows install guide's C:\src\flutter, a bare C:\flutter, and where Chocolatey and Scoop install it
+		paths = []string{`C:\src\flutter`, `C:\flutter`, `C:\tools\flutter`}
+		if l.home != "" {
+			paths = append(paths, filepath.Join(l.home, "scoop", "apps", "flutter", "current"))
+		}
+	}
+	if l.home != "" {
+		paths = append([]string{
+			filepath.Join(l.home, "flutter"),
+			filepath.Join(l.home, "development", "flutter"),
+			filepath.Join(l.home, "sdk", "flutter"),
+			filepath.Join(l.home, "snap", "flutter", "common", "flutter"),
+		}, paths...)
+	}
+	return paths
+}
+
+// ResolveFlutterSDK checks a --flutter-sdk value and returns the absolute SDK root. The value may
+// be the SDK directory, its bin directory or the flutter launcher itself.
+func ResolveFlutterSDK(path string) (string, error) {
+	root, err := filepath.Abs(strings.TrimSpace(path))
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	if filepath.Base(root) == "bin" && !isFlutterSDK(root) {
+		root = filepath.Dir(root)
+	}
+	if !isFlutterSDK(root) {
+		return "", fmt.Errorf("%s is not a Flutter SDK (no bin/flutter launcher)", path)
+	}
+	return root, nil
+}
+
+// isFlutterSDK reports whether root looks like a Flutter SDK checkout
+func isFlutterSDK(root string) bool {
+	for _, executable := range flutterExecutables {
+		if info, err := os.Stat(filepath.Join(root, "bin", executable)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// flutterLauncher returns the path of the flutter launcher in the SDK at root that this system
+// runs (flutter.bat on Windows), or "" without one
+func flutterLauncher(root string) string {
+	return findExecutable(runtime.GOOS, filepath.Join(root, "bin"), "flutter")
+}
+
+// readSDKVersion reads an SDK's version and channel from its files, without running flutter.
+// Current SDKs record both in bin/cache/flutter.version.json; older ones only have a version
+// file, in which case the channel comes from the checked out git branch.
+func readSDKVersion(root string) (string, string) {
+	var version, channel string
+	if data, err := os.ReadFile(filepath.Join(root, "bin", "cache", "flutter.version.json")); err == nil {
+		var state struct {
+			FrameworkVersion string `json:"frameworkVersion"`
+			Channel          string `json:"channel"`
+		}
+		if json.Unmarshal(data, &state) == nil {
+			version, channel = state.FrameworkVersion, state.Channel
+		}
+	}
+	if version == "" {
+		if data, err := os.ReadFile(filepath.Join(root, "version")); err == nil {
+			version = strings.TrimSpace(string(data))
+		}
+	}
+	if channel == "" {
+		if data, err := os.ReadFile(filepath.Join(root, ".git", "HEAD")); err == nil {
+			if branch, found := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/heads/"); found {
+				channel = branch
+			}
+		}
+	}
+	return version, channel
+}
+
+// projectPin is the Flutter version a project selects through a version manager or its pubspec
+type projectPin struct {
+	file    string
+	version string
+	puroEnv string
+}
+
+// readProjectPin looks for the project's pinned Flutter version in .fvmrc, the FVM 2 config,
+// .puro.json and finally an exact environment.flutter constraint in pubspec.yaml
+func readProjectPin(projectPath string) projectPin {
+	sources := []struct {
+		file string
+		key  string
+	}{
+		{".fvmrc", "flutter"},
+		{filepath.Join(".fvm", "fvm_config.json"), "flutterSdkVersion"},
+		{".puro.json", "env"},
+	}
+	for _, source := range sources {
+		data, err := os.ReadFile(filepath.Join(projectPath, source.file))
+		if err != nil {
+			continue
+		}
+		var values map[string]interface{}
+		if json.Unmarshal(data, &values) != nil {
+			continue
+		}
+		value, _ := values[source.key].(string)
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if source.key == "env" {
+			return projectPin{file: source.file, puroEnv: value}
+		}
+		// FVM accepts version@channel to install a release from a specific channel
+		version, _, _ := strings.Cut(value, "@")
+		return projectPin{file: source.file, version: strings.TrimPrefix(version, "v")}
+	}
+
+	if spec, err := readPubspec(projectPath); err == nil {
+		if constraint := strings.TrimSpace(spec.Environment["flutter"]); stableVersionPattern.MatchString(constraint) {
+			return projectPin{file: "pubspec.yaml", version: constraint}
+		}
+	}
+	return projectPin{}
+}
+
+// sdkMatches reports whether an SDK satisfies a pin, which is either a version or a channel name
+func sdkMatches(sdk models.LocalFlutterSDK, pin string) bool {
+	if cmp, ok := CompareVersions(sdk.Version, pin); ok {
+		return cmp == 0
+	}
+	return sdk.Channel != "" && sdk.Channel == pin
+}
+
+// subdirectories lists the directory names inside dir, following symlinks
+func subdirectories(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if info, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil && info.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
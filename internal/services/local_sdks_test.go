@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// writeFakeSDK creates the files of a Flutter SDK that LocalSDKService reads
+func writeFakeSDK(t *testing.T, root string, version string, channel string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, "bin", "cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "bin", "flutter"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	state := `{"frameworkVersion": "` + version + `", "channel": "` + channel + `"}`
+	if err := os.WriteFile(filepath.Join(root, "bin", "cache", "flutter.version.json"), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalSDKService(t *testing.T) {
+	home := t.TempDir()
+	manual := filepath.Join(home, "development", "flutter")
+	writeFakeSDK(t, manual, "3.24.5", "stable")
+	writeFakeSDK(t, filepath.Join(home, "fvm", "versions", "3.27.1"), "3.27.1", "stable")
+	writeFakeSDK(t, filepath.Join(home, ".puro", "envs", "beta", "flutter"), "3.28.0-0.1.pre", "beta")
+
+	// An old SDK without flutter.version.json, shadowed on PATH by the manual install
+	legacy := filepath.Join(home, "flutter")
+	if err := os.MkdirAll(filepath.Join(legacy, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(legacy, "bin", "flutter"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(legacy, "version"), []byte("3.7.12\n"), 0644)
+	os.MkdirAll(filepath.Join(legacy, ".git"), 0755)
+	os.WriteFile(filepath.Join(legacy, ".git", "HEAD"), []byte("ref: refs/heads/stable\n"), 0644)
+
+	service := &LocalSDKService{
+		home:         home,
+		path:         strings.Join([]string{filepath.Join(home, "empty"), filepath.Join(manual, "bin"), filepath.Join(legacy, "bin")}, string(os.PathListSeparator)),
+		installPaths: []string{legacy, manual},
+	}
+	ctx := context.Background()
+
+	t.Run("finds every SDK once", func(t *testing.T) {
+		report, err := service.Detect(ctx, "")
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(report.SDKs) != 4 {
+			t.Fatalf("Expected 4 SDKs, got %+v", report.SDKs)
+		}
+
+		active := report.SDKs[0]
+		if !active.Active || active.Version != "3.24.5" || strings.Join(active.Sources, ",") != config.SDK_SOURCE_PATH+","+config.SDK_SOURCE_INSTALL {
+			t.Errorf("Expected the first PATH entry to be active, got %+v", active)
+		}
+		if legacySDK := report.SDKs[1]; legacySDK.Version != "3.7.12" || legacySDK.Channel != "stable" || legacySDK.Active {
+			t.Errorf("Expected the legacy SDK version from its version file, got %+v", legacySDK)
+		}
+		if fvm := report.SDKs[2]; fvm.Name != "3.27.1" || fvm.Sources[0] != config.SDK_SOURCE_FVM {
+			t.Errorf("Expected the FVM SDK, got %+v", fvm)
+		}
+		if puro := report.SDKs[3]; puro.Name != "beta" || puro.Channel != "beta" {
+			t.Errorf("Expected the puro environment, got %+v", puro)
+		}
+		if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "is shadowed by") {
+			t.Errorf("Expected a shadowed PATH warning, got %v", report.Warnings)
+		}
+	})
+
+	t.Run("warns when the project pins another version", func(t *testing.T) {
+		project := t.TempDir()
+		os.WriteFile(filepath.Join(project, ".fvmrc"), []byte(`{"flutter": "3.27.1"}`), 0644)
+
+		report, err := service.Detect(ctx, project)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if report.PinnedVersion != "3.27.1" || report.PinnedBy != ".fvmrc" {
+			t.Errorf("Expected the .fvmrc pin, got %q from %q", report.PinnedVersion, report.PinnedBy)
+		}
+		last := report.Warnings[len(report.Warnings)-1]
+		if !strings.Contains(last, "The active flutter (3.24.5") || !strings.Contains(last, "fvm flutter") {
+			t.Errorf("Expected a pin mismatch warning, got %q", last)
+		}
+	})
+
+	t.Run("matching pins and puro environments", func(t *testing.T) {
+		project := t.TempDir()
+		os.WriteFile(filepath.Join(project, "pubspec.yaml"), []byte("name: app\nenvironment:\n  flutter: 3.24.5\n"), 0644)
+		report, _ := service.Detect(ctx, project)
+		if report.PinnedBy != "pubspec.yaml" || len(report.Warnings) != 1 {
+			t.Errorf("Expected the active SDK to match the pubspec pin, got %v", report.Warnings)
+		}
+
+		os.WriteFile(filepath.Join(project, ".puro.json"), []byte(`{"env": "beta"}`), 0644)
+		report, _ = service.Detect(ctx, project)
+		if report.PinnedVersion != "3.28.0-0.1.pre" || !strings.Contains(report.Warnings[len(report.Warnings)-1], "puro flutter") {
+			t.Errorf("Expected the puro environment pin, got %q: %v", report.PinnedVersion, report.Warnings)
+		}
+
+		os.WriteFile(filepath.Join(project, ".puro.json"), []byte(`{"env": "missing"}`), 0644)
+		report, _ = service.Detect(ctx, project)
+		if !strings.Contains(report.Warnings[len(report.Warnings)-1], "puro create missing") {
+			t.Errorf("Expected a missing environment warning, got %v", report.Warnings)
+		}
+	})
+
+	t.Run("an explicit SDK replaces the PATH lookup", func(t *testing.T) {
+		ci := filepath.Join(t.TempDir(), "flutter-3.27")
+		writeFakeSDK(t, ci, "3.27.4", "stable")
+		root, err := ResolveFlutterSDK(filepath.Join(ci, "bin"))
+		if err != nil {
+			t.Fatalf("ResolveFlutterSDK failed: %v", err)
+		}
+
+		explicit := *service
+		explicit.SetFlutterSDK(root)
+		project := t.TempDir()
+		os.WriteFile(filepath.Join(project, ".fvmrc"), []byte(`{"flutter": "3.27.1"}`), 0644)
+		report, err := explicit.Detect(ctx, project)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+
+		if sdk := report.SDKs[0]; !sdk.Active || sdk.Version != "3.27.4" || sdk.Sources[0] != config.SDK_SOURCE_FLAG {
+			t.Errorf("Expected the --flutter-sdk SDK to be active, got %+v", sdk)
+		}
+		for _, sdk := range report.SDKs[1:] {
+			if sdk.Active {
+				t.Errorf("Expected the PATH SDK to be inactive, got %+v", sdk)
+			}
+		}
+		last := report.Warnings[len(report.Warnings)-1]
+		if !strings.Contains(last, "The active flutter (3.27.4") || !strings.Contains(last, "pass --flutter-sdk") {
+			t.Errorf("Expected the pin mismatch to suggest another --flutter-sdk, got %q", last)
+		}
+
+		if _, err := ResolveFlutterSDK(t.TempDir()); err == nil || !strings.Contains(err.Error(), "not a Flutter SDK") {
+			t.Errorf("Expected an error for a directory without a launcher, got %v", err)
+		}
+	})
+
+	t.Run("FVM links select the SDK without flutter on PATH", func(t *testing.T) {
+		fvmHome := t.TempDir()
+		versions := filepath.Join(fvmHome, "fvm", "versions")
+		writeFakeSDK(t, filepath.Join(versions, "3.24.5"), "3.24.5", "stable")
+		writeFakeSDK(t, filepath.Join(versions, "3.27.1"), "3.27.1", "stable")
+		if err := os.Symlink(filepath.Join(versions, "3.24.5"), filepath.Join(fvmHome, "fvm", "default")); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+		project := t.TempDir()
+		os.MkdirAll(filepath.Join(project, ".fvm"), 0755)
+		if err := os.Symlink(filepath.Join(versions, "3.27.1"), filepath.Join(project, ".fvm", "flutter_sdk")); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(project, ".fvmrc"), `{"flutter": "3.27.1"}`)
+		fvmOnly := &LocalSDKService{home: fvmHome, installPaths: []string{}}
+
+		activeVersion := func(report *models.LocalSDKReport) string {
+			for _, sdk := range report.SDKs {
+				if sdk.Active {
+					return sdk.Version
+				}
+			}
+			return ""
+		}
+
+		report, err := fvmOnly.Detect(ctx, project)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(report.SDKs) != 2 || activeVersion(report) != "3.27.1" {
+			t.Errorf("Expected the project's .fvm/flutter_sdk to be active, got %+v", report.SDKs)
+		}
+		if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], filepath.Join(project, ".fvm", "flutter_sdk")) {
+			t.Errorf("Expected only a note about the FVM link, got %v", report.Warnings)
+		}
+
+		report, err = fvmOnly.Detect(ctx, "")
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if activeVersion(report) != "3.24.5" {
+			t.Errorf("Expected the fvm global default to be active, got %+v", report.SDKs)
+		}
+
+		// The links only stand in for a missing flutter on PATH
+		if fvmOnly.flutterOnPath() || !service.flutterOnPath() {
+			t.Error("Expected flutter on PATH only for the service with PATH entries")
+		}
+	})
+
+	t.Run("no SDKs", func(t *testing.T) {
+		report, err := (&LocalSDKService{home: t.TempDir(), installPaths: []string{}}).Detect(ctx, "")
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(report.SDKs) != 0 || len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "No Flutter SDK found") {
+			t.Errorf("Expected a single not found warning, got %+v", report)
+		}
+	})
+}
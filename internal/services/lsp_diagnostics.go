@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// lspSeverities maps the severities of deprecations to LSP diagnostic severities
+var lspSeverities = map[string]int{
+	config.SEVERITY_ERROR:   1,
+	config.SEVERITY_WARNING: 2,
+	config.SEVERITY_INFO:    3,
+}
+
+// LSPSeverity returns the LSP diagnostic severity of a deprecation severity, treating entries
+// without one as warnings
+func LSPSeverity(severity string) int {
+	if lsp, found := lspSeverities[severity]; found {
+		return lsp
+	}
+	return lspSeverities[config.SEVERITY_WARNING]
+}
+
+// ParseOutputFormat normalizes the output format of a project scan, defaulting to text
+func ParseOutputFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", config.OUTPUT_FORMAT_TEXT:
+		return config.OUTPUT_FORMAT_TEXT, nil
+	case config.OUTPUT_FORMAT_JSON, config.OUTPUT_FORMAT_LSP:
+		return strings.ToLower(strings.TrimSpace(format)), nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected text, json or lsp", format)
+	}
+}
+
+// ProjectDiagnostics converts the findings of a project scan into the LSP diagnostics of each
+// affected file, reading the files again to locate every use of the APIs the scan reports in
+// them. The APIs in hidden, such as the suppressed ones, are left out, and so are the files that
+// are left without diagnostics or cannot be read.
+func (d *DeprecationService) ProjectDiagnostics(result *models.ProjectScanResult, target string, hidden map[string]bool) ([]models.PublishDiagnosticsParams, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []models.PublishDiagnosticsParams{}
+	for _, pkg := range result.Packages {
+		for _, finding := range pkg.Findings {
+			if finding.Error != "" {
+				continue
+			}
+			code, err := readCheckFile(finding.Path, "")
+			if err != nil {
+				continue
+			}
+			listed := make(map[string]bool, len(finding.Deprecations))
+			for _, dep := range finding.Deprecations {
+				listed[dep.API] = !hidden[dep.API]
+			}
+			if diagnostics := d.fileDiagnostics(code, target, listed); len(diagnostics) > 0 {
+				files = append(files, models.PublishDiagnosticsParams{URI: fileURI(finding.Path), Diagnostics: diagnostics})
+			}
+		}
+	}
+	return files, nil
+}
+
+// fileDiagnostics returns a diagnostic for every use of a deprecated API in code that listed includes
+func (d *DeprecationService) fileDiagnostics(code string, target string, listed map[string]bool) []models.Diagnostic {
+	positions := newDartPositions(code)
+	var diagnostics []models.Diagnostic
+	for _, use := range d.deprecatedUses(code, target) {
+		if !listed[use.dep.API] {
+			continue
+		}
+		diagnostic := models.Diagnostic{
+			Range:    positions.lspRange(use.start, use.end),
+			Severity: LSPSeverity(use.dep.Severity),
+			Code:     use.dep.API,
+			Source:   config.LSP_DIAGNOSTIC_SOURCE,
+			Message:  strings.TrimSpace(deprecatedUseMessage(use.dep) + " " + replacementHint(use.dep)),
+			Tags:     []int{config.LSP_TAG_DEPRECATED},
+		}
+		if link := DocumentationURL(use.dep); link != "" {
+			diagnostic.CodeDescription = &models.CodeDescription{Href: link}
+		}
+		if source := SourceURL(use.dep); source != "" {
+			// The fragment already names the line; the range keeps it for clients that ignore fragments
+			line := max(use.dep.SourceLine-1, 0)
+			diagnostic.RelatedInformation = []models.DiagnosticRelatedInformation{{
+				Location: models.Location{URI: source, Range: models.Range{Start: models.Position{Line: line}, End: models.Position{Line: line}}},
+				Message:  fmt.Sprintf("%s is marked @Deprecated here", use.dep.API),
+			}}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// lspRange returns the 0-based LSP range of the bytes start to end
+func (p *dartPositions) lspRange(start int, end int) models.Range {
+	startLine, startColumn := p.lineColumn(start)
+	endLine, endColumn := p.lineColumn(end)
+	return models.Range{
+		Start: models.Position{Line: startLine - 1, Character: startColumn - 1},
+		End:   models.Position{Line: endLine - 1, Character: endColumn - 1},
+	}
+}
+
+// fileURI returns the file: URI of a path
+func fileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows drive letters
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestProjectDiagnostics(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	if err := cacheService.Save(&models.DeprecationCache{Deprecations: []models.Deprecation{
+		{API: "LegacyCard", Replacement: "AppCard", Severity: config.SEVERITY_INFO, SourceFile: "packages/flutter/lib/src/material/card.dart", SourceLine: 42},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+
+	project := t.TempDir()
+	main := filepath.Join(project, "lib", "main.dart")
+	writeFile(t, main, "// ✨\n  RaisedButton(child: LegacyCard());\n")
+	theme := filepath.Join(project, "lib", "theme.dart")
+	writeFile(t, theme, "FlatButton()\n")
+	result := &models.ProjectScanResult{ProjectPath: project, Packages: []models.PackageScanResult{{Name: "app", Path: ".", Files: 3, Findings: []models.FileCheckResult{
+		{Name: "lib/main.dart", Path: main, Deprecations: []models.Deprecation{{API: "RaisedButton"}, {API: "LegacyCard"}}},
+		{Name: "lib/theme.dart", Path: theme, Deprecations: []models.Deprecation{{API: "FlatButton"}}},
+		{Name: "lib/huge.dart", Path: filepath.Join(project, "lib", "huge.dart"), Error: "file too large"},
+	}}}}
+
+	files, err := depService.ProjectDiagnostics(result, "", map[string]bool{"FlatButton": true})
+	if err != nil {
+		t.Fatalf("ProjectDiagnostics failed: %v", err)
+	}
+	if len(files) != 1 || files[0].URI != "file://"+filepath.ToSlash(main) {
+		t.Fatalf("Expected the diagnostics of main.dart only, got %+v", files)
+	}
+
+	diagnostics := files[0].Diagnostics
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %+v", diagnostics)
+	}
+	button := diagnostics[0]
+	expected := models.Range{Start: models.Position{Line: 1, Character: 2}, End: models.Position{Line: 1, Character: 14}}
+	if button.Range != expected || button.Code != "RaisedButton" || button.Severity != 1 || button.Source != config.LSP_DIAGNOSTIC_SOURCE {
+		t.Errorf("Unexpected RaisedButton diagnostic %+v", button)
+	}
+	if len(button.Tags) != 1 || button.Tags[0] != config.LSP_TAG_DEPRECATED || button.CodeDescription == nil || button.CodeDescription.Href == "" {
+		t.Errorf("Expected the deprecated tag and a documentation link, got %+v", button)
+	}
+	if button.Message != "'RaisedButton' is deprecated and shouldn't be used. Try replacing the use of the deprecated member with ElevatedButton." {
+		t.Errorf("Unexpected message %q", button.Message)
+	}
+
+	card := diagnostics[1]
+	if card.Code != "LegacyCard" || card.Severity != 3 || card.Range.Start.Character != 22 {
+		t.Errorf("Unexpected LegacyCard diagnostic %+v", card)
+	}
+	if len(card.RelatedInformation) != 1 || card.RelatedInformation[0].Location.Range.Start.Line != 41 || card.RelatedInformation[0].Location.URI != SourceURL(models.Deprecation{SourceFile: "packages/flutter/lib/src/material/card.dart", SourceLine: 42}) {
+		t.Errorf("Expected the declaration as related information, got %+v", card.RelatedInformation)
+	}
+
+	if _, err := depService.ProjectDiagnostics(result, "kotlin", nil); err == nil {
+		t.Error("Expected an error for an unknown target")
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for input, expected := range map[string]string{"": "text", "TEXT": "text", "Json": "json", " lsp ": "lsp"} {
+		if got, err := ParseOutputFormat(input); err != nil || got != expected {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v", input, got, err)
+		}
+	}
+	if _, err := ParseOutputFormat("sarif"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// identifierPattern matches a bare Dart type name such as RaisedButton
+var identifierPattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+// MigrateCode applies every mechanical replacement known for the code and reports the
+// deprecations that still need a manual migration
+func (d *DeprecationService) MigrateCode(code string) models.MigrationResult {
+	result := models.MigrationResult{Code: code}
+	handled := make(map[string]bool)
+
+	patterns, entries := d.rules(config.TARGET_FLUTTER)
+	for _, rule := range patterns {
+		if rule.rewrite == nil {
+			continue
+		}
+		before := result.Code
+		result.Code = d.applyRewrite(&result, rule.deprecation.API, rule.rewrite, rule.template)
+		if result.Code == before {
+			continue
+		}
+		handled[rule.deprecation.API] = true
+
+		if rule.followUpPattern != nil {
+			for _, loc := range rule.followUpPattern.FindAllStringIndex(before, -1) {
+				result.Manual = append(result.Manual, models.PendingMigration{
+					API:    rule.deprecation.API,
+					Line:   lineAt(before, loc[0]),
+					Reason: rule.followUp,
+					DocURL: DocumentationURL(rule.deprecation),
+				})
+			}
+		}
+	}
+
+	// Renames of whole classes are safe to apply; member renames are not, since the receiver's
+	// type cannot be known from a snippet. Drafted replacements are unverified and only reported.
+	for _, dep := range append(entries, patternDeprecations(patterns)...) {
+		if handled[dep.API] || dep.ReplacementDraftedBy != "" || !identifierPattern.MatchString(dep.API) || !identifierPattern.MatchString(dep.Replacement) {
+			continue
+		}
+		if !strings.Contains(result.Code, dep.API) {
+			continue
+		}
+		pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(dep.API) + `\b`)
+		before := result.Code
+		result.Code = d.applyRewrite(&result, dep.API, pattern, dep.Replacement)
+		if result.Code != before {
+			handled[dep.API] = true
+		}
+	}
+
+	// Anything still detected in the rewritten code has to be migrated by hand
+	reported := make(map[string]bool)
+	for _, dep := range d.CheckCodeForDeprecations(result.Code) {
+		if reported[dep.API] {
+			continue
+		}
+		reported[dep.API] = true
+		result.Manual = append(result.Manual, models.PendingMigration{
+			API:         dep.API,
+			Line:        lineOfDeprecation(result.Code, dep, patterns),
+			Replacement: dep.Replacement,
+			Reason:      dep.Description,
+			DocURL:      DocumentationURL(dep),
+		})
+	}
+
+	sort.SliceStable(result.Changes, func(i, j int) bool {
+		return result.Changes[i].Line < result.Changes[j].Line
+	})
+	sort.SliceStable(result.Manual, func(i, j int) bool {
+		return result.Manual[i].Line < result.Manual[j].Line
+	})
+	result.Previews = fixPreviews(code, result.Code, result.Changes)
+
+	// A renamed class keeps its old arguments, so structural migrations come with a template
+	templates := make(map[string]bool)
+	addTemplate := func(api string) {
+		template := MigrationTemplateFor(api)
+		if template == nil || templates[template.API] {
+			return
+		}
+		templates[template.API] = true
+		result.Templates = append(result.Templates, *template)
+	}
+	for _, change := range result.Changes {
+		addTemplate(change.API)
+	}
+	for _, pending := range result.Manual {
+		addTemplate(pending.API)
+	}
+
+	return result
+}
+
+// applyRewrite replaces every match of pattern in the current code, recording one change per match
+func (d *DeprecationService) applyRewrite(result *models.MigrationResult, api string, pattern *regexp.Regexp, template string) string {
+	code := result.Code
+	matches := pattern.FindAllStringSubmatchIndex(code, -1)
+	if len(matches) == 0 {
+		return code
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		replacement := string(pattern.ExpandString(nil, template, code, match))
+		b.WriteString(code[last:match[0]])
+		b.WriteString(replacement)
+		result.Changes = append(result.Changes, models.MigrationChange{
+			API:    api,
+			Line:   lineAt(code, match[0]),
+			Before: code[match[0]:match[1]],
+			After:  replacement,
+		})
+		last = match[1]
+	}
+	b.WriteString(code[last:])
+	return b.String()
+}
+
+// fixPreviews pairs every rewritten line of the code with the original one. Changes spanning
+// several lines are shown as one block; all rewrites keep the line count, so a line number means
+// the same line in both versions.
+func fixPreviews(original string, rewritten string, changes []models.MigrationChange) []models.FixPreview {
+	before := strings.Split(original, "\n")
+	after := strings.Split(rewritten, "\n")
+	if len(before) != len(after) {
+		return nil
+	}
+
+	var previews []models.FixPreview
+	for _, change := range changes {
+		first := change.Line
+		last := first + strings.Count(change.Before, "\n")
+		if first < 1 || last > len(before) {
+			continue
+		}
+		if n := len(previews) - 1; n >= 0 && first <= previews[n].Line+strings.Count(previews[n].Before, "\n") {
+			// Overlaps the previous block, which changes are sorted after
+			preview := &previews[n]
+			if !containsString(preview.APIs, change.API) {
+				preview.APIs = append(preview.APIs, change.API)
+			}
+			if end := preview.Line + strings.Count(preview.Before, "\n"); last > end {
+				preview.Before = strings.Join(before[preview.Line-1:last], "\n")
+				preview.After = strings.Join(after[preview.Line-1:last], "\n")
+			}
+			continue
+		}
+		previews = append(previews, models.FixPreview{
+			Line:   first,
+			APIs:   []string{change.API},
+			Before: strings.Join(before[first-1:last], "\n"),
+			After:  strings.Join(after[first-1:last], "\n"),
+		})
+	}
+	return previews
+}
+
+// lineOfDeprecation returns the 1-based line where a detected deprecation first occurs
+func lineOfDeprecation(code string, dep models.Deprecation, patterns []deprecationRule) int {
+	for _, rule := range patterns {
+		if rule.deprecation.API == dep.API {
+			if loc := rule.pattern.FindStringIndex(code); loc != nil {
+				return lineAt(code, loc[0])
+			}
+		}
+	}
+	if i := strings.Index(code, dep.API); i >= 0 {
+		return lineAt(code, i)
+	}
+	return 0
+}
+
+// lineAt converts a byte offset into a 1-based line number
+func lineAt(code string, offset int) int {
+	return strings.Count(code[:offset], "\n") + 1
+}
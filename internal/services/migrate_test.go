@@ -0,0 +1,131 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestMigrateCode(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+
+	t.Run("Rewrites mechanical replacements", func(t *testing.T) {
+		code := "final c = Colors.red.withOpacity(0.5);\n" +
+			"Scaffold.of(ctx).showSnackBar(snackBar);\n" +
+			"RaisedButton(onPressed: save, child: Text('Save'))"
+
+		result := depService.MigrateCode(code)
+
+		expected := "final c = Colors.red.withValues(alpha: 0.5);\n" +
+			"ScaffoldMessenger.of(ctx).showSnackBar(snackBar);\n" +
+			"ElevatedButton(onPressed: save, child: Text('Save'))"
+		if result.Code != expected {
+			t.Errorf("Expected rewritten code:\n%s\ngot:\n%s", expected, result.Code)
+		}
+		if len(result.Changes) != 3 {
+			t.Errorf("Expected 3 changes, got %+v", result.Changes)
+		}
+		if len(result.Manual) != 0 {
+			t.Errorf("Expected nothing left to migrate, got %+v", result.Manual)
+		}
+		if result.Changes[1].Line != 2 {
+			t.Errorf("Expected the showSnackBar change on line 2, got %d", result.Changes[1].Line)
+		}
+		if len(result.Previews) != 3 || result.Previews[1].Before != "Scaffold.of(ctx).showSnackBar(snackBar);" || result.Previews[1].After != "ScaffoldMessenger.of(ctx).showSnackBar(snackBar);" {
+			t.Errorf("Expected one preview per rewritten line, got %+v", result.Previews)
+		}
+	})
+
+	t.Run("Previews fixes on the user's lines", func(t *testing.T) {
+		code := "Widget build(BuildContext context) {\n" +
+			"  Scaffold.of(context).showSnackBar(SnackBar(backgroundColor: Colors.red.withOpacity(\n    0.5)));\n" +
+			"  return const SizedBox();\n}"
+		result := depService.MigrateCode(code)
+
+		if len(result.Previews) != 1 {
+			t.Fatalf("Expected the two fixes on one statement to share a preview, got %+v", result.Previews)
+		}
+		preview := result.Previews[0]
+		if preview.Line != 2 || strings.Join(preview.APIs, ",") != "Color.withOpacity,Scaffold.of(context).showSnackBar" {
+			t.Errorf("Expected line 2 with both APIs, got %+v", preview)
+		}
+		expectedAfter := "  ScaffoldMessenger.of(context).showSnackBar(SnackBar(backgroundColor: Colors.red.withValues(alpha: \n    0.5)));"
+		if !strings.HasPrefix(preview.Before, "  Scaffold.of(context)") || preview.After != expectedAfter {
+			t.Errorf("Expected the whole statement before and after, got %q and %q", preview.Before, preview.After)
+		}
+	})
+
+	t.Run("Flags button styling for review", func(t *testing.T) {
+		result := depService.MigrateCode("FlatButton(\n  textColor: Colors.blue,\n  onPressed: save,\n)")
+
+		if !strings.HasPrefix(result.Code, "TextButton(") {
+			t.Errorf("Expected FlatButton to be renamed, got %s", result.Code)
+		}
+		if len(result.Manual) != 1 || result.Manual[0].Line != 2 || result.Manual[0].DocURL != "https://docs.flutter.dev/release/breaking-changes/buttons" {
+			t.Errorf("Expected a follow-up for textColor on line 2 linking the buttons guide, got %+v", result.Manual)
+		}
+		if len(result.Templates) != 1 || result.Templates[0].API != "FlatButton" || !strings.Contains(result.Templates[0].After, "TextButton.styleFrom(") {
+			t.Errorf("Expected the FlatButton template once, got %+v", result.Templates)
+		}
+	})
+
+	t.Run("Reports what cannot be rewritten", func(t *testing.T) {
+		err := cacheService.Save(&models.DeprecationCache{
+			LastUpdated: time.Now(),
+			Deprecations: []models.Deprecation{
+				{API: "OldPicker", Replacement: "NewPicker", Description: "Use NewPicker"},
+				{API: "ThemeData.accentColor", Replacement: "colorScheme.secondary", Description: "Use colorScheme.secondary"},
+				{API: "GuessedPicker", Replacement: "DraftPicker", Description: "Going away", ReplacementDraftedBy: "test-model"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error saving cache, got %v", err)
+		}
+		defer cacheService.Clear()
+
+		result := depService.MigrateCode("OldPicker()\nFloatingActionButton(child: icon)\nThemeData.accentColor\nGuessedPicker()")
+
+		if !strings.HasPrefix(result.Code, "NewPicker()") {
+			t.Errorf("Expected cached class rename to be applied, got %s", result.Code)
+		}
+		if !strings.HasSuffix(result.Code, "GuessedPicker()") {
+			t.Errorf("Expected the drafted replacement not to be applied, got %s", result.Code)
+		}
+
+		manual := make(map[string]int)
+		for _, pending := range result.Manual {
+			manual[pending.API] = pending.Line
+		}
+		if manual["FloatingActionButton(child:"] != 2 || manual["ThemeData.accentColor"] != 3 || manual["GuessedPicker"] != 4 {
+			t.Errorf("Expected FloatingActionButton, ThemeData.accentColor and GuessedPicker as manual items, got %+v", result.Manual)
+		}
+		if len(result.Templates) != 1 || result.Templates[0].Replacement != "ColorScheme.secondary" {
+			t.Errorf("Expected the theme accent template, got %+v", result.Templates)
+		}
+	})
+}
+
+func TestMigrationTemplates(t *testing.T) {
+	for api, template := range migrationTemplates {
+		class, _, _ := strings.Cut(api, ".")
+		replacement, _, _ := strings.Cut(template.Replacement, ".")
+		// Package migrations such as flutter_driver import the package rather than construct it
+		uses := strings.Contains(template.Before, class+"(") || strings.Contains(template.Before, "package:"+api+"/")
+		if !uses || !strings.Contains(template.After, replacement) {
+			t.Errorf("Expected the %s template to use %s before and %s after", api, class, replacement)
+		}
+		if len(template.Parameters) == 0 {
+			t.Errorf("Expected the %s template to map its parameters", api)
+		}
+	}
+
+	if MigrationTemplateFor("Color.withOpacity") != nil {
+		t.Error("Expected no template for a mechanical replacement")
+	}
+	if template := MigrationTemplateFor("ThemeData.accentIconTheme"); template == nil || template.API != "ThemeData.accentColor" {
+		t.Errorf("Expected the accent properties to share one template, got %+v", template)
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// curatedGuides maps APIs to the flutter/website breaking change page that documents their migration
+var curatedGuides = map[string]string{
+	"Color.withOpacity":                 "wide-gamut-framework",
+	"RaisedButton":                      "buttons",
+	"FlatButton":                        "buttons",
+	"OutlineButton":                     "buttons",
+	"Scaffold.of(context).showSnackBar": "scaffold-messenger",
+	"ThemeData.accentColor":             "theme-data-accent-properties",
+	"WillPopScope":                      "android-predictive-back",
+}
+
+// Patterns used to read breaking change pages from flutter/website
+var (
+	guideLinkPattern  = regexp.MustCompile(`\[([^\]]+)\](?:\(|:\s*)/release/breaking-changes/([a-z0-9-]+)`)
+	guideTitlePattern = regexp.MustCompile(`(?m)^title:\s*["']?(.+?)["']?\s*$`)
+	guideCodePattern  = regexp.MustCompile("(?s)```dart\\s*\\n(.*?)```")
+	guideBeforeMarker = regexp.MustCompile(`(?i)before\s+migration`)
+	guideAfterMarker  = regexp.MustCompile(`(?i)after\s+migration`)
+)
+
+// MigrationGuideService assembles migration context for deprecated APIs from flutter/website
+type MigrationGuideService struct {
+	apiService         FlutterAPIServiceInterface
+	deprecationService DeprecationServiceInterface
+
+	mu     sync.Mutex
+	guides map[string]string
+}
+
+// NewMigrationGuideService creates a new migration guide service instance
+func NewMigrationGuideService(apiService FlutterAPIServiceInterface, deprecationService DeprecationServiceInterface) *MigrationGuideService {
+	return &MigrationGuideService{
+		apiService:         apiService,
+		deprecationService: deprecationService,
+		guides:             make(map[string]string),
+	}
+}
+
+// ExplainDeprecation combines the deprecation entry for api with its migration guide and a
+// before/after example. A missing guide is reported in GuideError rather than failing the call.
+func (m *MigrationGuideService) ExplainDeprecation(ctx context.Context, api string) (*models.DeprecationExplanation, error) {
+	deprecations := m.deprecationService.FindDeprecations(api)
+	if len(deprecations) == 0 {
+		return nil, fmt.Errorf("no deprecation found for %q", api)
+	}
+
+	explanation := &models.DeprecationExplanation{Deprecation: deprecations[0]}
+	dep := explanation.Deprecation
+	explanation.Template = MigrationTemplateFor(dep.API)
+
+	slug, err := m.findGuide(ctx, dep.API)
+	if err != nil {
+		explanation.GuideError = err.Error()
+	} else if slug == "" {
+		explanation.GuideError = "no flutter/website migration guide mentions this API"
+	} else if content, err := m.guide(ctx, slug); err != nil {
+		explanation.GuideError = err.Error()
+	} else {
+		explanation.GuideURL = config.BREAKING_CHANGES_DOCS_URL + slug
+		explanation.GuideTitle = slug
+		if matches := guideTitlePattern.FindStringSubmatch(content); len(matches) > 1 {
+			explanation.GuideTitle = matches[1]
+		}
+		explanation.GuideExcerpt = guideExcerpt(content)
+		explanation.Before, explanation.After = guideExample(content)
+	}
+
+	// Fall back to the entry's own example when the guide has no code
+	if explanation.Before == "" && dep.Example != "" {
+		if before, after, found := strings.Cut(dep.Example, " → "); found {
+			explanation.Before, explanation.After = before, after
+		}
+	}
+
+	return explanation, nil
+}
+
+// findGuide returns the breaking change page for api: the curated page if there is one,
+// otherwise the first page in the breaking changes index whose title mentions the API
+func (m *MigrationGuideService) findGuide(ctx context.Context, api string) (string, error) {
+	if slug, exists := curatedGuides[api]; exists {
+		return slug, nil
+	}
+
+	index, err := m.guide(ctx, "index")
+	if err != nil {
+		return "", err
+	}
+
+	links := guideLinkPattern.FindAllStringSubmatch(index, -1)
+	for _, term := range guideSearchTerms(api) {
+		for _, link := range links {
+			if strings.Contains(link[1], term) {
+				return link[2], nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// guide returns the markdown of a breaking change page, fetching it once per process
+func (m *MigrationGuideService) guide(ctx context.Context, slug string) (string, error) {
+	m.mu.Lock()
+	content, cached := m.guides[slug]
+	m.mu.Unlock()
+	if cached {
+		return content, nil
+	}
+
+	content, err := m.apiService.FetchWebsiteFile(ctx, config.BREAKING_CHANGES_SOURCE_DIR+slug+".md")
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	m.guides[slug] = content
+	m.mu.Unlock()
+	return content, nil
+}
+
+// guideSearchTerms lists the names to look for in guide titles, most specific first
+func guideSearchTerms(api string) []string {
+	name := api
+	if i := strings.IndexAny(name, "( "); i >= 0 {
+		name = name[:i]
+	}
+	terms := []string{name}
+	if class, member, found := strings.Cut(name, "."); found {
+		if len(member) >= 5 {
+			terms = append(terms, member)
+		}
+		terms = append(terms, class)
+	}
+	return terms
+}
+
+// guideExcerpt returns the Summary and Migration guide sections of a page, truncated to the excerpt limit
+func guideExcerpt(content string) string {
+	var parts []string
+	for _, heading := range []string{"Summary", "Migration guide"} {
+		if section := markdownSection(content, heading); section != "" {
+			parts = append(parts, "### "+heading+"\n\n"+section)
+		}
+	}
+
+	excerpt := strings.Join(parts, "\n\n")
+	if len(excerpt) > config.MAX_GUIDE_EXCERPT {
+		excerpt = strings.TrimSpace(excerpt[:config.MAX_GUIDE_EXCERPT]) + "\n\n…"
+	}
+	return excerpt
+}
+
+// markdownSection returns the body of the level-2 section with the given heading
+func markdownSection(content string, heading string) string {
+	marker := "## " + heading
+	start := strings.Index(content, "\n"+marker+"\n")
+	if start < 0 {
+		return ""
+	}
+	body := content[start+len(marker)+2:]
+	if end := strings.Index(body, "\n## "); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
+
+// guideExample extracts the first "before migration" and "after migration" Dart snippets
+func guideExample(content string) (string, string) {
+	return codeAfter(content, guideBeforeMarker), codeAfter(content, guideAfterMarker)
+}
+
+// codeAfter returns the first Dart code block following the marker text
+func codeAfter(content string, marker *regexp.Regexp) string {
+	loc := marker.FindStringIndex(content)
+	if loc == nil {
+		return ""
+	}
+	if matches := guideCodePattern.FindStringSubmatch(content[loc[1]:]); len(matches) > 1 {
+		return strings.TrimRight(matches[1], "\n")
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+const testButtonsGuide = `---
+title: New Buttons and Button Themes
+description: The basic material button classes have been replaced.
+---
+
+## Summary
+
+A new set of basic material button widgets and themes have been added to Flutter.
+
+## Migration guide
+
+Code before migration:
+
+` + "```dart\nRaisedButton(onPressed: save, child: Text('Save'))\n```" + `
+
+Code after migration:
+
+` + "```dart\nElevatedButton(onPressed: save, child: Text('Save'))\n```" + `
+
+## Timeline
+
+Landed in version: 1.20.0-0.0.pre
+`
+
+const testBreakingChangesIndex = `
+* [Deprecated ThemeData accentColor][]
+* [Removed ` + "`ThemeData.toggleableActiveColor`" + `][]
+
+[Deprecated ThemeData accentColor]: /release/breaking-changes/theme-data-accent-properties
+[Removed ` + "`ThemeData.toggleableActiveColor`" + `]: /release/breaking-changes/toggleable-active-color
+`
+
+func TestMigrationGuideService(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+	mockAPI := &MockFlutterAPIService{
+		websiteFiles: map[string]string{
+			"src/content/release/breaking-changes/buttons.md":                 testButtonsGuide,
+			"src/content/release/breaking-changes/index.md":                   testBreakingChangesIndex,
+			"src/content/release/breaking-changes/toggleable-active-color.md": "---\ntitle: Removed toggleableActiveColor\n---\n\n## Summary\n\nUse colorScheme.secondary.\n",
+		},
+	}
+	guideService := NewMigrationGuideService(mockAPI, depService)
+
+	t.Run("Curated guide with before/after example", func(t *testing.T) {
+		explanation, err := guideService.ExplainDeprecation(context.Background(), "RaisedButton")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if explanation.GuideTitle != "New Buttons and Button Themes" {
+			t.Errorf("Expected guide title from front matter, got %q", explanation.GuideTitle)
+		}
+		if explanation.GuideURL != "https://docs.flutter.dev/release/breaking-changes/buttons" {
+			t.Errorf("Unexpected guide URL %s", explanation.GuideURL)
+		}
+		if !strings.Contains(explanation.GuideExcerpt, "### Summary") || strings.Contains(explanation.GuideExcerpt, "Timeline") {
+			t.Errorf("Expected Summary and Migration guide sections only, got %s", explanation.GuideExcerpt)
+		}
+		if !strings.HasPrefix(explanation.Before, "RaisedButton(") || !strings.HasPrefix(explanation.After, "ElevatedButton(") {
+			t.Errorf("Expected before/after example from the guide, got %q / %q", explanation.Before, explanation.After)
+		}
+		if explanation.Template == nil || !strings.Contains(explanation.Template.After, "ElevatedButton.styleFrom(") {
+			t.Errorf("Expected the ElevatedButton template, got %+v", explanation.Template)
+		}
+	})
+
+	t.Run("Guide found through the breaking changes index", func(t *testing.T) {
+		err := cacheService.Save(&models.DeprecationCache{
+			LastUpdated:  time.Now(),
+			Deprecations: []models.Deprecation{{API: "ThemeData.toggleableActiveColor", Description: "No longer used"}},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error saving cache, got %v", err)
+		}
+		defer cacheService.Clear()
+
+		explanation, err := guideService.ExplainDeprecation(context.Background(), "ThemeData.toggleableActiveColor")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.HasSuffix(explanation.GuideURL, "/toggleable-active-color") {
+			t.Errorf("Expected toggleable-active-color guide, got %q (%s)", explanation.GuideURL, explanation.GuideError)
+		}
+	})
+
+	t.Run("Missing guide falls back to the entry example", func(t *testing.T) {
+		explanation, err := guideService.ExplainDeprecation(context.Background(), "Color.withOpacity")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if explanation.GuideError == "" {
+			t.Error("Expected a guide error for the unavailable page")
+		}
+		if explanation.After != "Color.red.withValues(alpha: 0.5)" {
+			t.Errorf("Expected example from the built-in entry, got %q", explanation.After)
+		}
+	})
+
+	t.Run("Unknown API", func(t *testing.T) {
+		if _, err := guideService.ExplainDeprecation(context.Background(), "Nothing.here"); err == nil {
+			t.Error("Expected error for unknown API")
+		}
+	})
+}
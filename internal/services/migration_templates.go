@@ -0,0 +1,254 @@
+package services
+
+import (
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// legacyButtonParameters is where the styling arguments shared by the old material buttons go
+var legacyButtonParameters = []models.ParameterMapping{
+	{Old: "textColor", New: "style: styleFrom(foregroundColor: ...)"},
+	{Old: "disabledTextColor", New: "style: styleFrom(disabledForegroundColor: ...)"},
+	{Old: "padding", New: "style: styleFrom(padding: ...)"},
+	{Old: "shape", New: "style: styleFrom(shape: ...)"},
+	{Old: "splashColor, highlightColor, hoverColor, focusColor", New: "style: styleFrom(...).copyWith(overlayColor: WidgetStateProperty.resolveWith(...)), one color per WidgetState"},
+	{Old: "colorBrightness", New: "no equivalent: foregroundColor sets the text and icon color directly"},
+	{Old: "onPressed, onLongPress, child, focusNode, autofocus, clipBehavior", New: "unchanged"},
+}
+
+// themeAccentTemplate moves the accent properties of ThemeData into its ColorScheme
+var themeAccentTemplate = models.MigrationTemplate{
+	API:         "ThemeData.accentColor",
+	Replacement: "ColorScheme.secondary",
+	Before: `ThemeData(
+  primaryColor: Colors.indigo,
+  accentColor: Colors.amber,
+  accentColorBrightness: Brightness.light,
+  accentTextTheme: accentTextTheme,
+  accentIconTheme: const IconThemeData(color: Colors.black),
+)
+
+final accent = Theme.of(context).accentColor;`,
+	After: `ThemeData(
+  colorScheme: ColorScheme.fromSeed(seedColor: Colors.indigo).copyWith(
+    secondary: Colors.amber,
+    onSecondary: Colors.black,
+  ),
+)
+
+final accent = Theme.of(context).colorScheme.secondary;`,
+	Parameters: []models.ParameterMapping{
+		{Old: "primaryColor", New: "colorScheme: ColorScheme.fromSeed(seedColor: ...), or colorScheme.primary"},
+		{Old: "accentColor", New: "colorScheme.secondary"},
+		{Old: "accentColorBrightness", New: "colorScheme.onSecondary: a color that contrasts with secondary (ThemeData.estimateBrightnessForColor helps choose it)"},
+		{Old: "accentTextTheme", New: "textTheme.apply(bodyColor: colorScheme.onSecondary) where text is drawn on the secondary color"},
+		{Old: "accentIconTheme", New: "IconThemeData(color: colorScheme.onSecondary) where icons are drawn on the secondary color"},
+		{Old: "Theme.of(context).accentColor", New: "Theme.of(context).colorScheme.secondary"},
+	},
+}
+
+// migrationTemplates are the complete rewrites of structural migrations, which need more than a
+// new class name: the old constructor arguments move into a ButtonStyle or a ColorScheme, or a
+// flutter_driver test becomes an integration_test one
+var migrationTemplates = map[string]models.MigrationTemplate{
+	"RaisedButton": {
+		API:         "RaisedButton",
+		Replacement: "ElevatedButton",
+		Before: `RaisedButton(
+  color: Colors.blue,
+  textColor: Colors.white,
+  disabledColor: Colors.grey,
+  disabledTextColor: Colors.black38,
+  splashColor: Colors.white24,
+  elevation: 4,
+  padding: const EdgeInsets.all(16),
+  shape: RoundedRectangleBorder(borderRadius: BorderRadius.circular(8)),
+  onPressed: onPressed,
+  child: const Text('Save'),
+)`,
+		After: `ElevatedButton(
+  style: ElevatedButton.styleFrom(
+    backgroundColor: Colors.blue,
+    foregroundColor: Colors.white,
+    disabledBackgroundColor: Colors.grey,
+    disabledForegroundColor: Colors.black38,
+    elevation: 4,
+    padding: const EdgeInsets.all(16),
+    shape: RoundedRectangleBorder(borderRadius: BorderRadius.circular(8)),
+  ).copyWith(
+    overlayColor: WidgetStateProperty.resolveWith(
+      (states) => states.contains(WidgetState.pressed) ? Colors.white24 : null,
+    ),
+  ),
+  onPressed: onPressed,
+  child: const Text('Save'),
+)`,
+		Parameters: append([]models.ParameterMapping{
+			{Old: "color", New: "style: ElevatedButton.styleFrom(backgroundColor: ...)"},
+			{Old: "disabledColor", New: "style: ElevatedButton.styleFrom(disabledBackgroundColor: ...)"},
+			{Old: "elevation, highlightElevation, disabledElevation", New: "style: ElevatedButton.styleFrom(elevation: ...), or ButtonStyle(elevation: WidgetStateProperty.resolveWith(...)) per state"},
+		}, legacyButtonParameters...),
+	},
+	"FlatButton": {
+		API:         "FlatButton",
+		Replacement: "TextButton",
+		Before: `FlatButton(
+  color: Colors.transparent,
+  textColor: Colors.blue,
+  disabledTextColor: Colors.grey,
+  splashColor: Colors.blue.shade100,
+  padding: const EdgeInsets.symmetric(horizontal: 16),
+  shape: const StadiumBorder(),
+  onPressed: onPressed,
+  child: const Text('Cancel'),
+)`,
+		After: `TextButton(
+  style: TextButton.styleFrom(
+    backgroundColor: Colors.transparent,
+    foregroundColor: Colors.blue,
+    disabledForegroundColor: Colors.grey,
+    padding: const EdgeInsets.symmetric(horizontal: 16),
+    shape: const StadiumBorder(),
+  ).copyWith(
+    overlayColor: WidgetStateProperty.resolveWith(
+      (states) => states.contains(WidgetState.pressed) ? Colors.blue.shade100 : null,
+    ),
+  ),
+  onPressed: onPressed,
+  child: const Text('Cancel'),
+)`,
+		Parameters: append([]models.ParameterMapping{
+			{Old: "color", New: "style: TextButton.styleFrom(backgroundColor: ...)"},
+			{Old: "disabledColor", New: "style: TextButton.styleFrom(disabledBackgroundColor: ...)"},
+		}, legacyButtonParameters...),
+	},
+	"OutlineButton": {
+		API:         "OutlineButton",
+		Replacement: "OutlinedButton",
+		Before: `OutlineButton(
+  textColor: Colors.teal,
+  borderSide: const BorderSide(color: Colors.teal, width: 2),
+  highlightedBorderColor: Colors.tealAccent,
+  disabledBorderColor: Colors.grey,
+  padding: const EdgeInsets.all(12),
+  shape: RoundedRectangleBorder(borderRadius: BorderRadius.circular(4)),
+  onPressed: onPressed,
+  child: const Text('Details'),
+)`,
+		After: `OutlinedButton(
+  style: OutlinedButton.styleFrom(
+    foregroundColor: Colors.teal,
+    padding: const EdgeInsets.all(12),
+    shape: RoundedRectangleBorder(borderRadius: BorderRadius.circular(4)),
+  ).copyWith(
+    side: WidgetStateProperty.resolveWith((states) {
+      if (states.contains(WidgetState.disabled)) {
+        return const BorderSide(color: Colors.grey, width: 2);
+      }
+      if (states.contains(WidgetState.pressed)) {
+        return const BorderSide(color: Colors.tealAccent, width: 2);
+      }
+      return const BorderSide(color: Colors.teal, width: 2);
+    }),
+  ),
+  onPressed: onPressed,
+  child: const Text('Details'),
+)`,
+		Parameters: append([]models.ParameterMapping{
+			{Old: "borderSide", New: "style: OutlinedButton.styleFrom(side: ...)"},
+			{Old: "highlightedBorderColor, disabledBorderColor", New: "style: ButtonStyle(side: WidgetStateProperty.resolveWith(...)) returning one BorderSide per WidgetState"},
+			{Old: "color", New: "style: OutlinedButton.styleFrom(backgroundColor: ...)"},
+		}, legacyButtonParameters...),
+	},
+	"ButtonBar": {
+		API:         "ButtonBar",
+		Replacement: "OverflowBar",
+		Before: `ButtonBar(
+  alignment: MainAxisAlignment.end,
+  buttonPadding: const EdgeInsets.symmetric(horizontal: 8),
+  overflowDirection: VerticalDirection.down,
+  children: buttons,
+)`,
+		After: `OverflowBar(
+  alignment: MainAxisAlignment.end,
+  spacing: 8,
+  overflowSpacing: 8,
+  overflowAlignment: OverflowBarAlignment.end,
+  overflowDirection: VerticalDirection.down,
+  children: buttons,
+)`,
+		Parameters: []models.ParameterMapping{
+			{Old: "alignment", New: "alignment"},
+			{Old: "buttonPadding", New: "spacing (horizontal gap) and overflowSpacing (vertical gap when the buttons wrap)"},
+			{Old: "overflowButtonSpacing", New: "overflowSpacing"},
+			{Old: "overflowDirection", New: "overflowDirection"},
+			{Old: "buttonTextTheme, buttonMinWidth, buttonHeight, layoutBehavior", New: "no equivalent: style the buttons themselves"},
+			{Old: "children", New: "children"},
+		},
+	},
+	"flutter_driver": {
+		API:         "flutter_driver",
+		Replacement: "integration_test",
+		Before: `// test_driver/app.dart
+import 'package:flutter_driver/driver_extension.dart';
+
+void main() {
+  enableFlutterDriverExtension();
+  app.main();
+}
+
+// test_driver/app_test.dart
+import 'package:flutter_driver/flutter_driver.dart';
+import 'package:test/test.dart';
+
+void main() {
+  late FlutterDriver driver;
+  setUpAll(() async => driver = await FlutterDriver.connect());
+  tearDownAll(() async => driver.close());
+
+  test('increments the counter', () async {
+    await driver.tap(find.byValueKey('increment'));
+    expect(await driver.getText(find.byValueKey('counter')), '1');
+  });
+}`,
+		After: `// integration_test/app_test.dart
+import 'package:flutter/widgets.dart';
+import 'package:flutter_test/flutter_test.dart';
+import 'package:integration_test/integration_test.dart';
+
+void main() {
+  IntegrationTestWidgetsFlutterBinding.ensureInitialized();
+
+  testWidgets('increments the counter', (tester) async {
+    app.main();
+    await tester.pumpAndSettle();
+    await tester.tap(find.byKey(const ValueKey('increment')));
+    await tester.pumpAndSettle();
+    expect(find.text('1'), findsOneWidget);
+  });
+}`,
+		Parameters: []models.ParameterMapping{
+			{Old: "enableFlutterDriverExtension() in test_driver/app.dart", New: "IntegrationTestWidgetsFlutterBinding.ensureInitialized() at the top of the test's main"},
+			{Old: "FlutterDriver.connect() and driver.close()", New: "no equivalent: testWidgets provides a WidgetTester and the test starts the app itself"},
+			{Old: "driver.tap, driver.enterText, driver.scroll", New: "tester.tap, tester.enterText, tester.drag followed by tester.pumpAndSettle()"},
+			{Old: "find.byValueKey('id')", New: "find.byKey(const ValueKey('id'))"},
+			{Old: "await driver.getText(finder)", New: "expect(find.text(...), findsOneWidget), or read the Text widget with tester.widget<Text>(finder).data"},
+			{Old: "driver.waitFor(finder)", New: "await tester.pumpAndSettle(), then expect(finder, findsOneWidget)"},
+			{Old: "package:flutter_driver and package:test in dev_dependencies", New: "integration_test and flutter_test, both with sdk: flutter"},
+			{Old: "flutter drive --target=test_driver/app.dart", New: "flutter test integration_test"},
+		},
+	},
+	"ThemeData.accentColor":           themeAccentTemplate,
+	"ThemeData.accentColorBrightness": themeAccentTemplate,
+	"ThemeData.accentTextTheme":       themeAccentTemplate,
+	"ThemeData.accentIconTheme":       themeAccentTemplate,
+}
+
+// MigrationTemplateFor returns the complete rewrite template of a structural migration, or nil
+// when the deprecated API only needs its name replaced
+func MigrationTemplateFor(api string) *models.MigrationTemplate {
+	template, exists := migrationTemplates[api]
+	if !exists {
+		return nil
+	}
+	return &template
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// apiIntroduction records the first stable Flutter release that ships an API
+type apiIntroduction struct {
+	api     string
+	version string
+	pattern *regexp.Regexp
+}
+
+// apiIntroductions lists APIs whose use raises the minimum supported Flutter version
+var apiIntroductions = []apiIntroduction{
+	{"ElevatedButton / TextButton / OutlinedButton", "1.22.0", regexp.MustCompile(`\b(?:ElevatedButton|TextButton|OutlinedButton)\b`)},
+	{"ScaffoldMessenger", "2.0.0", regexp.MustCompile(`\bScaffoldMessenger\b`)},
+	{"NavigationBar", "2.5.0", regexp.MustCompile(`\bNavigationBar\s*\(`)},
+	{"ColorScheme.fromSeed", "2.10.0", regexp.MustCompile(`\bColorScheme\.fromSeed\b`)},
+	{"Non-nullable binding instances", bindingsNonNullableFlutter, regexp.MustCompile(bindingInstance + `\.`)},
+	{"FilledButton", "3.7.0", regexp.MustCompile(`\bFilledButton\b`)},
+	{"SegmentedButton", "3.7.0", regexp.MustCompile(`\bSegmentedButton\b`)},
+	{"MenuAnchor", "3.7.0", regexp.MustCompile(`\bMenuAnchor\b`)},
+	{"Badge", "3.7.0", regexp.MustCompile(`\bBadge\s*\(`)},
+	{"NavigationDrawer", "3.7.0", regexp.MustCompile(`\bNavigationDrawer\b`)},
+	{"Dart 3 class modifiers (sealed, base, final, interface)", "3.10.0", regexp.MustCompile(`(?m)^\s*(?:abstract\s+)?(?:sealed|base|final|interface)\s+class\s`)},
+	{"MediaQuery.sizeOf", "3.10.0", regexp.MustCompile(`\bMediaQuery\.sizeOf\b`)},
+	{"ListenableBuilder", "3.10.0", regexp.MustCompile(`\bListenableBuilder\b`)},
+	{"SliverMainAxisGroup", "3.13.0", regexp.MustCompile(`\bSliverMainAxisGroup\b`)},
+	{"PopScope", "3.16.0", regexp.MustCompile(`\bPopScope\b`)},
+	{"TextScaler", "3.16.0", regexp.MustCompile(`\b(?:TextScaler|textScalerOf)\b`)},
+	{"WidgetState / WidgetStateProperty", "3.22.0", regexp.MustCompile(`\bWidgetState(?:Property|Color|MouseCursor|BorderSide|OutlinedBorder)?\b`)},
+	{"ColorScheme.surfaceContainer*", "3.22.0", regexp.MustCompile(`\bsurfaceContainer(?:Lowest|Low|High|Highest)?\b`)},
+	{"CarouselView", "3.24.0", regexp.MustCompile(`\bCarouselView\b`)},
+	{"Color.withValues", "3.27.0", regexp.MustCompile(`\.withValues\s*\(`)},
+	{"Color.from", "3.27.0", regexp.MustCompile(`\bColor\.from\s*\(`)},
+}
+
+// apiAlternatives are what code that also runs on releases before an API's introduction uses instead
+var apiAlternatives = map[string]string{
+	"Non-nullable binding instances": "WidgetsBinding.instance! before " + bindingsNonNullableFlutter + ", or " + bindingsCompatible + " to support both",
+}
+
+// dartForFlutter maps Flutter releases to the Dart SDK they bundle, newest first
+var dartForFlutter = []struct{ flutter, dart string }{
+	{"3.27.0", "3.6.0"},
+	{"3.24.0", "3.5.0"},
+	{"3.22.0", "3.4.0"},
+	{"3.19.0", "3.3.0"},
+	{"3.16.0", "3.2.0"},
+	{"3.13.0", "3.1.0"},
+	{"3.10.0", "3.0.0"},
+	{"3.7.0", "2.19.0"},
+	{"3.3.0", "2.18.0"},
+	{"3.0.0", "2.17.0"},
+	{"2.10.0", "2.16.0"},
+	{"2.8.0", "2.15.0"},
+	{"2.5.0", "2.14.0"},
+	{"2.2.0", "2.13.0"},
+	{"2.0.0", "2.12.0"},
+	{"1.22.0", "2.10.0"},
+}
+
+// MinimumVersionService infers the oldest Flutter release that supports the APIs a codebase uses
+type MinimumVersionService struct{}
+
+// NewMinimumVersionService creates a new minimum version service instance
+func NewMinimumVersionService() *MinimumVersionService {
+	return &MinimumVersionService{}
+}
+
+// InferFromCode reports the minimum Flutter version for a code snippet
+func (m *MinimumVersionService) InferFromCode(code string) *models.MinimumVersionResult {
+	result := &models.MinimumVersionResult{}
+	m.collect(result, "", code)
+	m.finish(result)
+	return result
+}
+
+// InferFromProject reports the minimum Flutter version for every Dart file in a project that
+// filter selects and includes the SDK constraints currently declared in its pubspec.yaml
+func (m *MinimumVersionService) InferFromProject(ctx context.Context, projectPath string, filter models.PathFilter) (*models.MinimumVersionResult, error) {
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", projectPath)
+	}
+
+	paths, err := newPathFilter(projectPath, filter)
+	if err != nil {
+		return nil, err
+	}
+	files, err := walkDartFiles(ctx, projectPath, walkOptions{ignore: newProjectIgnore(projectPath), filter: paths})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.MinimumVersionResult{FilesScanned: len(files)}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		relative, err := filepath.Rel(projectPath, file)
+		if err != nil {
+			relative = file
+		}
+		m.collect(result, relative, string(data))
+	}
+
+	if spec, err := readPubspec(projectPath); err == nil {
+		result.DeclaredSDK = spec.Environment["sdk"]
+		result.DeclaredFlutter = spec.Environment["flutter"]
+	}
+
+	m.finish(result)
+	return result, nil
+}
+
+// collect records the first usage of every versioned API found in code
+func (m *MinimumVersionService) collect(result *models.MinimumVersionResult, file string, code string) {
+	seen := make(map[string]bool, len(result.Requirements))
+	for _, requirement := range result.Requirements {
+		seen[requirement.API] = true
+	}
+
+	for _, intro := range apiIntroductions {
+		if seen[intro.api] {
+			continue
+		}
+		if loc := intro.pattern.FindStringIndex(code); loc != nil {
+			result.Requirements = append(result.Requirements, models.APIRequirement{
+				API:         intro.api,
+				Version:     intro.version,
+				File:        file,
+				Line:        lineAt(code, loc[0]),
+				Alternative: apiAlternatives[intro.api],
+			})
+		}
+	}
+}
+
+// finish orders the requirements newest first and derives the minimum Flutter and Dart versions
+func (m *MinimumVersionService) finish(result *models.MinimumVersionResult) {
+	sort.SliceStable(result.Requirements, func(i, j int) bool {
+		cmp, _ := CompareVersions(result.Requirements[i].Version, result.Requirements[j].Version)
+		return cmp > 0
+	})
+
+	if len(result.Requirements) == 0 {
+		return
+	}
+	result.MinimumFlutter = result.Requirements[0].Version
+	for _, pair := range dartForFlutter {
+		if cmp, ok := CompareVersions(result.MinimumFlutter, pair.flutter); ok && cmp >= 0 {
+			result.MinimumDart = pair.dart
+			break
+		}
+	}
+}
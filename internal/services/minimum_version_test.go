@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestMinimumVersionService(t *testing.T) {
+	service := NewMinimumVersionService()
+
+	t.Run("InferFromCode", func(t *testing.T) {
+		result := service.InferFromCode("ScaffoldMessenger.of(context);\nfinal c = color.withValues(alpha: 0.5);\nPopScope(child: w)")
+
+		if result.MinimumFlutter != "3.27.0" || result.MinimumDart != "3.6.0" {
+			t.Errorf("Expected Flutter 3.27.0 / Dart 3.6.0, got %s / %s", result.MinimumFlutter, result.MinimumDart)
+		}
+		if len(result.Requirements) != 3 {
+			t.Fatalf("Expected 3 requirements, got %+v", result.Requirements)
+		}
+		if result.Requirements[0].API != "Color.withValues" || result.Requirements[0].Line != 2 {
+			t.Errorf("Expected withValues on line 2 first, got %+v", result.Requirements[0])
+		}
+		if result.Requirements[2].API != "ScaffoldMessenger" {
+			t.Errorf("Expected ScaffoldMessenger last, got %+v", result.Requirements[2])
+		}
+	})
+
+	t.Run("InferFromCode without versioned APIs", func(t *testing.T) {
+		if result := service.InferFromCode("Text('hello')"); result.MinimumFlutter != "" {
+			t.Errorf("Expected no minimum version, got %s", result.MinimumFlutter)
+		}
+	})
+
+	t.Run("InferFromProject", func(t *testing.T) {
+		project := t.TempDir()
+		writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: demo\nenvironment:\n  sdk: \">=2.17.0 <4.0.0\"\n  flutter: \">=3.0.0\"\n")
+		writeFile(t, filepath.Join(project, "lib", "main.dart"), "void main() {\n  runApp(PopScope(child: app));\n}\n")
+		writeFile(t, filepath.Join(project, "lib", "model.g.dart"), "CarouselView()")
+		writeFile(t, filepath.Join(project, "build", "generated.dart"), "CarouselView()")
+
+		result, err := service.InferFromProject(context.Background(), project, models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.FilesScanned != 1 {
+			t.Errorf("Expected generated and build files to be skipped, scanned %d", result.FilesScanned)
+		}
+		if result.MinimumFlutter != "3.16.0" {
+			t.Errorf("Expected Flutter 3.16.0, got %s", result.MinimumFlutter)
+		}
+		if result.Requirements[0].File != filepath.Join("lib", "main.dart") || result.Requirements[0].Line != 2 {
+			t.Errorf("Expected lib/main.dart:2, got %+v", result.Requirements[0])
+		}
+		if result.DeclaredFlutter != ">=3.0.0" || result.DeclaredSDK != ">=2.17.0 <4.0.0" {
+			t.Errorf("Expected declared constraints from pubspec, got %q / %q", result.DeclaredSDK, result.DeclaredFlutter)
+		}
+	})
+
+	t.Run("InferFromProject with include and exclude globs", func(t *testing.T) {
+		project := t.TempDir()
+		writeFile(t, filepath.Join(project, "lib", "main.dart"), "runApp(PopScope(child: app));\n")
+		writeFile(t, filepath.Join(project, "lib", "legacy", "carousel.dart"), "CarouselView()")
+		writeFile(t, filepath.Join(project, "tool", "carousel.dart"), "CarouselView()")
+
+		result, err := service.InferFromProject(context.Background(), project, models.PathFilter{Include: []string{"lib/**"}, Exclude: []string{"lib/legacy/**"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.FilesScanned != 1 || result.MinimumFlutter != "3.16.0" {
+			t.Errorf("Expected only lib/main.dart to be scanned, got %d files and %s", result.FilesScanned, result.MinimumFlutter)
+		}
+
+		if _, err := service.InferFromProject(context.Background(), project, models.PathFilter{Exclude: []string{"lib/[a"}}); err == nil {
+			t.Error("Expected an error for an invalid glob")
+		}
+	})
+
+	t.Run("InferFromProject with missing directory", func(t *testing.T) {
+		if _, err := service.InferFromProject(context.Background(), filepath.Join(t.TempDir(), "missing"), models.PathFilter{}); err == nil {
+			t.Error("Expected error for missing project")
+		}
+	})
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Expected no error creating directory, got %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error writing file, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// DecodeCode returns the code of a check tool argument sent with encoding: plain text when empty,
+// base64, or gzip+base64 for large files. The payload is decoded as a stream and refused once it
+// exceeds MAX_CODE_SIZE, so a small compressed payload cannot expand without bound.
+func DecodeCode(code string, encoding string) (string, error) {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if encoding == "" {
+		if len(code) > config.MAX_CODE_SIZE {
+			return "", fmt.Errorf("code is %d bytes, larger than the %d byte limit", len(code), config.MAX_CODE_SIZE)
+		}
+		return code, nil
+	}
+
+	// The decoder skips the line breaks of wrapped base64 output
+	var reader io.Reader = base64.NewDecoder(base64.StdEncoding, strings.NewReader(strings.TrimSpace(code)))
+	switch encoding {
+	case config.CODE_ENCODING_BASE64:
+	case config.CODE_ENCODING_GZIP_BASE64:
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", fmt.Errorf("invalid gzip+base64 code: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	default:
+		return "", fmt.Errorf("unknown encoding %q (expected %s or %s)", encoding, config.CODE_ENCODING_GZIP_BASE64, config.CODE_ENCODING_BASE64)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, config.MAX_CODE_SIZE+1))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s code: %v", encoding, err)
+	}
+	if len(data) > config.MAX_CODE_SIZE {
+		return "", fmt.Errorf("decoded code is larger than the %d byte limit", config.MAX_CODE_SIZE)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// gzipBase64 compresses and encodes code the way a client sends a large file
+func gzipBase64(t *testing.T, code string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(code)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDecodeCode(t *testing.T) {
+	code := "RaisedButton(onPressed: save)\n"
+
+	t.Run("Decodes each encoding", func(t *testing.T) {
+		if got, err := DecodeCode(code, ""); err != nil || got != code {
+			t.Errorf("Expected plain code unchanged, got %q (%v)", got, err)
+		}
+		if got, err := DecodeCode(base64.StdEncoding.EncodeToString([]byte(code)), "base64"); err != nil || got != code {
+			t.Errorf("Expected base64 code decoded, got %q (%v)", got, err)
+		}
+
+		// Wrapped output of tools such as base64(1) keeps its line breaks
+		encoded := gzipBase64(t, code)
+		wrapped := encoded[:10] + "\n" + encoded[10:] + "\n"
+		if got, err := DecodeCode(wrapped, " GZIP+Base64 "); err != nil || got != code {
+			t.Errorf("Expected gzip+base64 code decoded, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("Rejects invalid payloads", func(t *testing.T) {
+		if _, err := DecodeCode(code, "brotli"); err == nil || !strings.Contains(err.Error(), "unknown encoding") {
+			t.Errorf("Expected an unknown encoding error, got %v", err)
+		}
+		if _, err := DecodeCode("not base64!", config.CODE_ENCODING_BASE64); err == nil {
+			t.Error("Expected an error for invalid base64")
+		}
+		if _, err := DecodeCode(base64.StdEncoding.EncodeToString([]byte(code)), config.CODE_ENCODING_GZIP_BASE64); err == nil {
+			t.Error("Expected an error for base64 that is not gzip")
+		}
+	})
+
+	t.Run("Stops decompressing at the size limit", func(t *testing.T) {
+		bomb := gzipBase64(t, strings.Repeat("a", config.MAX_CODE_SIZE+1))
+		if len(bomb) > config.MAX_CODE_SIZE/100 {
+			t.Fatalf("Expected the payload to compress well, got %d bytes", len(bomb))
+		}
+		if _, err := DecodeCode(bomb, config.CODE_ENCODING_GZIP_BASE64); err == nil || !strings.Contains(err.Error(), "byte limit") {
+			t.Errorf("Expected the size limit error, got %v", err)
+		}
+		if _, err := DecodeCode(strings.Repeat("a", config.MAX_CODE_SIZE+1), ""); err == nil {
+			t.Error("Expected oversized plain code to be refused")
+		}
+	})
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// pinFetchWorkers is how many pub.dev packages a pin recommendation looks up at once
+const pinFetchWorkers = 8
+
+var (
+	// pubPackageNamePattern matches a valid pub package name
+	pubPackageNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// constraintPartPattern matches one bound of a version constraint such as ^1.2.0, >=2.12.0 or 3.0.0
+	constraintPartPattern = regexp.MustCompile(`(\^|>=|<=|>|<)?\s*(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)`)
+)
+
+// pubPackage is the part of a pub.dev package listing a pin recommendation reads
+type pubPackage struct {
+	Versions []struct {
+		Version   string `json:"version"`
+		Retracted bool   `json:"retracted"`
+		Pubspec   struct {
+			Environment map[string]string `json:"environment"`
+		} `json:"pubspec"`
+	} `json:"versions"`
+}
+
+// pinCandidate is a stable Flutter release a pin recommendation considers and the Dart SDK it bundles
+type pinCandidate struct {
+	flutter        string
+	flutterVersion semanticVersion
+	dart           string
+	dartVersion    semanticVersion
+}
+
+// versionConstraint is a pub version constraint; a nil bound leaves that side open
+type versionConstraint struct {
+	min, max                   *semanticVersion
+	minInclusive, maxInclusive bool
+}
+
+// PinRecommendationService works out the newest stable Flutter release the pub.dev dependencies of
+// a project support, from the SDK constraints of their published versions
+type PinRecommendationService struct {
+	apiService *FlutterAPIService
+}
+
+// NewPinRecommendationService creates a new pin recommendation service instance
+func NewPinRecommendationService(apiService *FlutterAPIService) *PinRecommendationService {
+	return &PinRecommendationService{apiService: apiService}
+}
+
+// Recommend checks the dependencies and dev_dependencies of a pubspec.yaml, given as content or
+// read from projectPath, against the stable Flutter releases. A dependency supports a release when
+// a published version within its constraint accepts the Dart SDK of the release and does not need
+// a newer Flutter. Transitive dependencies are not checked.
+func (p *PinRecommendationService) Recommend(ctx context.Context, content string, projectPath string) (*models.PinRecommendation, error) {
+	if content == "" {
+		if projectPath == "" {
+			return nil, fmt.Errorf("no pubspec.yaml given")
+		}
+		data, err := os.ReadFile(filepath.Join(projectPath, "pubspec.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		content = string(data)
+	}
+	var spec pubspec
+	if err := yaml.Unmarshal([]byte(content), &spec); err != nil {
+		return nil, fmt.Errorf("invalid pubspec.yaml: %v", err)
+	}
+
+	candidates, notes, err := p.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.PinRecommendation{
+		CurrentFlutter: spec.Environment["flutter"],
+		LatestStable:   candidates[0].flutter,
+		LatestDart:     candidates[0].dart,
+		Dependencies:   []models.DependencySupport{},
+		Notes:          notes,
+	}
+
+	var deps []models.DependencySupport
+	for _, dependencies := range []struct {
+		entries map[string]any
+		dev     bool
+	}{{spec.Dependencies, false}, {spec.DevDependencies, true}} {
+		names := make([]string, 0, len(dependencies.entries))
+		for name := range dependencies.entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			constraint, source := pubDependency(dependencies.entries[name])
+			if source != "" {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%s)", name, source))
+				continue
+			}
+			deps = append(deps, models.DependencySupport{Package: name, Constraint: constraint, Dev: dependencies.dev})
+		}
+	}
+	if len(deps) > config.MAX_PIN_DEPENDENCIES {
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d of %d pub.dev dependencies were checked", config.MAX_PIN_DEPENDENCIES, len(deps)))
+		deps = deps[:config.MAX_PIN_DEPENDENCIES]
+	}
+
+	usable := make([][]bool, len(deps))
+	workers := make(chan struct{}, pinFetchWorkers)
+	var wg sync.WaitGroup
+	for i := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			usable[i] = p.checkDependency(ctx, &deps[i], candidates)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, candidate := range candidates {
+		supported := true
+		for j := range deps {
+			if usable[j] != nil && !usable[j][i] {
+				supported = false
+				break
+			}
+		}
+		if supported {
+			result.Recommended, result.RecommendedDart = candidate.flutter, candidate.dart
+			break
+		}
+	}
+	result.Dependencies = append(result.Dependencies, deps...)
+	return result, nil
+}
+
+// checkDependency looks dep up on pub.dev and fills in the newest release it supports, returning
+// for each candidate whether a version within its constraint runs there, or nil when it cannot be
+// checked
+func (p *PinRecommendationService) checkDependency(ctx context.Context, dep *models.DependencySupport, candidates []pinCandidate) []bool {
+	if !pubPackageNamePattern.MatchString(dep.Package) {
+		dep.Error = "invalid package name"
+		return nil
+	}
+	allowed, ok := parseVersionConstraint(dep.Constraint)
+	if !ok {
+		dep.Error = fmt.Sprintf("unsupported constraint %q", dep.Constraint)
+		return nil
+	}
+	pkg, err := p.fetchPackage(ctx, dep.Package)
+	if err != nil {
+		dep.Error = err.Error()
+		return nil
+	}
+
+	usable := make([]bool, len(candidates))
+	newest := -1
+	matched := false
+	var best, newestWithin semanticVersion
+	var upgrades []string
+	for _, published := range pkg.Versions {
+		version, ok := parsePackageVersion(published.Version)
+		// pub only picks pre-releases for constraints that ask for one
+		if !ok || published.Retracted || (version.Prerelease != "" && (allowed.min == nil || allowed.min.Prerelease == "")) {
+			continue
+		}
+		sdk, hasSDK := published.Pubspec.Environment["sdk"]
+		if !hasSDK || strings.TrimSpace(sdk) == "" {
+			// pub treats packages without an SDK constraint as Dart 1 packages
+			sdk = "<2.0.0"
+		}
+		sdkConstraint, sdkOK := parseVersionConstraint(sdk)
+		flutterConstraint, flutterOK := parseVersionConstraint(published.Pubspec.Environment["flutter"])
+		if !sdkOK || !flutterOK {
+			continue
+		}
+		runsOn := func(candidate pinCandidate) bool {
+			// pub ignores the upper bound of flutter constraints
+			return sdkConstraint.allowsSDK(candidate.dartVersion) && (flutterConstraint.min == nil || candidate.flutterVersion.Compare(*flutterConstraint.min) >= 0)
+		}
+
+		if !allowed.allows(version) {
+			if runsOn(candidates[0]) {
+				upgrades = append(upgrades, published.Version)
+			}
+			continue
+		}
+		matched = true
+		if version.Compare(newestWithin) > 0 {
+			newestWithin = version
+		}
+		first := -1
+		for i, candidate := range candidates {
+			if runsOn(candidate) {
+				usable[i] = true
+				if first == -1 {
+					first = i
+				}
+			}
+		}
+		// The candidates are newest first, so the version that runs on the earliest one goes furthest
+		if first >= 0 && (newest == -1 || first < newest || (first == newest && version.Compare(best) > 0)) {
+			newest, best = first, version
+			dep.Version, dep.SDK = published.Version, sdk
+		}
+	}
+
+	if !matched {
+		dep.Error = fmt.Sprintf("no published version matches %s", dep.Constraint)
+		return nil
+	}
+	if newest >= 0 {
+		dep.NewestFlutter = candidates[newest].flutter
+	}
+	if newest != 0 {
+		dep.Blocking = true
+		var oldest semanticVersion
+		for _, upgrade := range upgrades {
+			version, _ := parsePackageVersion(upgrade)
+			if version.Compare(newestWithin) > 0 && (dep.Upgrade == "" || version.Compare(oldest) < 0) {
+				dep.Upgrade, oldest = upgrade, version
+			}
+		}
+	}
+	return usable
+}
+
+// fetchPackage fetches the versions of a package from the pub.dev API
+func (p *PinRecommendationService) fetchPackage(ctx context.Context, name string) (*pubPackage, error) {
+	resp, err := p.apiService.get(ctx, config.PUB_API_PACKAGES_URL+url.PathEscape(name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("not found on pub.dev")
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("pub.dev returned status %d", resp.StatusCode)
+	}
+
+	var pkg pubPackage
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("invalid pub.dev response: %v", err)
+	}
+	return &pkg, nil
+}
+
+// candidates lists the stable Flutter releases newest first from the official releases API or,
+// when it cannot be reached, the first release of each line in the release table
+func (p *PinRecommendationService) candidates(ctx context.Context) ([]pinCandidate, []string, error) {
+	var candidates []pinCandidate
+	var order []semanticVersion
+	releases, err := p.apiService.FetchOfficialReleases(ctx)
+	if err == nil {
+		seen := make(map[string]bool)
+		for _, release := range releases.Releases {
+			version := strings.TrimPrefix(release.Version, "v")
+			stable, stableOK := parseStableVersion(version)
+			flutterVersion, flutterOK := parseVersion(version)
+			dart, dartOK := parseVersion(release.DartSDKVersion)
+			if release.Channel != config.FLUTTER_CHANNEL_STABLE || seen[version] || !stableOK || !flutterOK || !dartOK {
+				continue
+			}
+			seen[version] = true
+			candidates = append(candidates, pinCandidate{flutter: version, flutterVersion: flutterVersion, dart: dart.String(), dartVersion: dart})
+			order = append(order, stable)
+		}
+		sort.Sort(candidatesByRelease{candidates, order})
+	}
+	if len(candidates) > 0 {
+		return candidates, nil, nil
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+
+	for _, pair := range dartForFlutter {
+		flutterVersion, _ := parseVersion(pair.flutter)
+		dartVersion, _ := parseVersion(pair.dart)
+		candidates = append(candidates, pinCandidate{flutter: pair.flutter, flutterVersion: flutterVersion, dart: pair.dart, dartVersion: dartVersion})
+	}
+	reason := "it lists no stable releases"
+	if err != nil {
+		reason = err.Error()
+	}
+	return candidates, []string{fmt.Sprintf("The official releases list is unavailable (%s); only the first release of each Flutter line was checked", reason)}, nil
+}
+
+// candidatesByRelease sorts candidates newest first by their stable versions, hotfixes included
+type candidatesByRelease struct {
+	candidates []pinCandidate
+	order      []semanticVersion
+}
+
+func (c candidatesByRelease) Len() int { return len(c.candidates) }
+
+func (c candidatesByRelease) Less(i, j int) bool { return c.order[i].Compare(c.order[j]) > 0 }
+
+func (c candidatesByRelease) Swap(i, j int) {
+	c.candidates[i], c.candidates[j] = c.candidates[j], c.candidates[i]
+	c.order[i], c.order[j] = c.order[j], c.order[i]
+}
+
+// pubDependency reads the constraint of a dependency entry, or the source of one that does not
+// come from pub.dev: sdk, path, git or the URL of another package repository
+func pubDependency(entry any) (constraint string, source string) {
+	switch value := entry.(type) {
+	case nil:
+		return "any", ""
+	case string:
+		return value, ""
+	case map[string]any:
+		for _, key := range []string{"sdk", "path", "git"} {
+			if _, ok := value[key]; ok {
+				return "", key
+			}
+		}
+		if hosted, ok := value["hosted"]; ok {
+			hostedURL, _ := hosted.(string)
+			if details, ok := hosted.(map[string]any); ok {
+				hostedURL, _ = details["url"].(string)
+			}
+			if hostedURL != "" && strings.TrimRight(hostedURL, "/") != "https://pub.dev" && strings.TrimRight(hostedURL, "/") != "https://pub.dartlang.org" {
+				return "", hostedURL
+			}
+		}
+		if version, ok := value["version"].(string); ok {
+			return version, ""
+		}
+		return "any", ""
+	default:
+		return fmt.Sprint(value), ""
+	}
+}
+
+// parsePackageVersion parses a published package version, dropping build metadata such as +1
+func parsePackageVersion(s string) (semanticVersion, bool) {
+	version, _, _ := strings.Cut(strings.TrimSpace(s), "+")
+	return parseVersion(version)
+}
+
+// parseVersionConstraint parses a pub version constraint: any or empty, an exact version, a caret
+// constraint or a range of >=, >, <= and < bounds
+func parseVersionConstraint(s string) (versionConstraint, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"'`)
+	if s == "" || s == "any" {
+		return versionConstraint{}, true
+	}
+	parts := constraintPartPattern.FindAllStringSubmatch(s, -1)
+	if parts == nil || strings.TrimSpace(constraintPartPattern.ReplaceAllString(s, "")) != "" {
+		return versionConstraint{}, false
+	}
+
+	var c versionConstraint
+	for _, part := range parts {
+		version, ok := parsePackageVersion(part[2])
+		if !ok {
+			return versionConstraint{}, false
+		}
+		switch part[1] {
+		case "^":
+			upper := semanticVersion{Major: version.Major + 1}
+			switch {
+			case version.Major == 0 && version.Minor == 0:
+				upper = semanticVersion{Patch: version.Patch + 1}
+			case version.Major == 0:
+				upper = semanticVersion{Minor: version.Minor + 1}
+			}
+			c.min, c.minInclusive, c.max, c.maxInclusive = &version, true, &upper, false
+		case ">=", ">":
+			c.min, c.minInclusive = &version, part[1] == ">="
+		case "<=", "<":
+			c.max, c.maxInclusive = &version, part[1] == "<="
+		default:
+			c.min, c.minInclusive, c.max, c.maxInclusive = &version, true, &version, true
+		}
+	}
+	return c, true
+}
+
+// allows reports whether version satisfies the constraint
+func (c versionConstraint) allows(version semanticVersion) bool {
+	if c.min != nil {
+		if cmp := version.Compare(*c.min); cmp < 0 || (cmp == 0 && !c.minInclusive) {
+			return false
+		}
+	}
+	if c.max != nil {
+		if cmp := version.Compare(*c.max); cmp > 0 || (cmp == 0 && !c.maxInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsSDK reports whether the Dart SDK dart satisfies an sdk constraint. Like pub, Dart 3 reads
+// an upper bound of <3.0.0 as <4.0.0 for packages that require null safety (2.12.0 or newer).
+func (c versionConstraint) allowsSDK(dart semanticVersion) bool {
+	nullSafe := semanticVersion{Major: 2, Minor: 12}
+	dart3 := semanticVersion{Major: 3}
+	if dart.Major >= 3 && c.min != nil && c.min.Compare(nullSafe) >= 0 && c.max != nil && !c.maxInclusive && c.max.Compare(dart3) == 0 {
+		widened := c
+		widened.max = &semanticVersion{Major: 4}
+		return widened.allows(dart)
+	}
+	return c.allows(dart)
+}
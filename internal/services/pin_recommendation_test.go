@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	for constraint, expected := range map[string]map[string]bool{
+		"^1.2.0":            {"1.2.0": true, "1.9.9": true, "2.0.0": false, "1.1.9": false},
+		"^0.3.1":            {"0.3.1": true, "0.3.9": true, "0.4.0": false},
+		">=2.12.0 <3.0.0":   {"2.12.0": true, "2.19.6": true, "3.0.0": false},
+		"'>2.0.0 <=2.5.0'":  {"2.0.0": false, "2.5.0": true},
+		"1.4.0":             {"1.4.0": true, "1.4.1": false},
+		"any":               {"0.0.1": true, "9.0.0": true},
+		">=3.10.0-0.0.pre":  {"3.10.0": true, "3.7.12": false},
+		"^2.0.0-nullsafety": {"2.0.0": true, "3.0.0": false},
+	} {
+		c, ok := parseVersionConstraint(constraint)
+		if !ok {
+			t.Errorf("Expected %q to parse", constraint)
+			continue
+		}
+		for version, allowed := range expected {
+			v, _ := parseVersion(version)
+			if c.allows(v) != allowed {
+				t.Errorf("%q allows %s: expected %v", constraint, version, allowed)
+			}
+		}
+	}
+	for _, invalid := range []string{"latest", "^1.2", ">=1.0.0 || <0.5.0"} {
+		if _, ok := parseVersionConstraint(invalid); ok {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+
+	// Dart 3 reads <3.0.0 as <4.0.0 for null safe packages only
+	dart3, _ := parseVersion("3.5.0")
+	nullSafe, _ := parseVersionConstraint(">=2.12.0 <3.0.0")
+	legacy, _ := parseVersionConstraint(">=2.7.0 <3.0.0")
+	if !nullSafe.allowsSDK(dart3) || legacy.allowsSDK(dart3) {
+		t.Error("Expected only the null safe constraint to allow Dart 3")
+	}
+}
+
+func TestPubDependency(t *testing.T) {
+	for name, test := range map[string]struct {
+		entry      any
+		constraint string
+		source     string
+	}{
+		"string":      {"^1.0.0", "^1.0.0", ""},
+		"empty":       {nil, "any", ""},
+		"sdk":         {map[string]any{"sdk": "flutter"}, "", "sdk"},
+		"path":        {map[string]any{"path": "../core"}, "", "path"},
+		"git":         {map[string]any{"git": map[string]any{"url": "https://github.com/acme/ui"}}, "", "git"},
+		"hosted":      {map[string]any{"hosted": "https://pub.example.com", "version": "^2.0.0"}, "", "https://pub.example.com"},
+		"pub.dev":     {map[string]any{"hosted": map[string]any{"url": "https://pub.dev/"}, "version": "^2.0.0"}, "^2.0.0", ""},
+		"version map": {map[string]any{"version": ">=1.0.0 <2.0.0"}, ">=1.0.0 <2.0.0", ""},
+	} {
+		constraint, source := pubDependency(test.entry)
+		if constraint != test.constraint || source != test.source {
+			t.Errorf("%s: expected %q from %q, got %q from %q", name, test.constraint, test.source, constraint, source)
+		}
+	}
+}
+
+func TestPinRecommendationService(t *testing.T) {
+	packages := map[string]string{
+		// 1.x predates null safety or stops at Dart 3.4; 2.0.0 needs a major upgrade but runs on the latest release
+		"charts": `{"versions": [
+			{"version": "1.0.0", "pubspec": {"environment": {"sdk": ">=2.7.0 <3.0.0"}}},
+			{"version": "1.5.0", "pubspec": {"environment": {"sdk": ">=3.0.0 <3.5.0"}}},
+			{"version": "1.6.0", "retracted": true, "pubspec": {"environment": {"sdk": ">=3.0.0 <4.0.0"}}},
+			{"version": "2.0.0-dev.1", "pubspec": {"environment": {"sdk": ">=3.0.0 <4.0.0"}}},
+			{"version": "2.0.0", "pubspec": {"environment": {"sdk": ">=3.0.0 <4.0.0", "flutter": ">=3.10.0"}}},
+			{"version": "2.1.0", "pubspec": {"environment": {"sdk": ">=3.0.0 <4.0.0"}}}
+		]}`,
+		"http": `{"versions": [
+			{"version": "0.13.6", "pubspec": {"environment": {"sdk": ">=2.19.0 <3.0.0"}}},
+			{"version": "1.2.2", "pubspec": {"environment": {"sdk": "^3.4.0"}}}
+		]}`,
+		"lints": `{"versions": [{"version": "5.0.0", "pubspec": {"environment": {"sdk": "^3.6.0"}}}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/flutter_infra_release/releases/releases_linux.json":
+			w.Write([]byte(`{"releases": [
+				{"version": "3.27.0-0.1.pre", "channel": "beta", "dart_sdk_version": "3.6.0 (build 3.6.0-216.1.beta)"},
+				{"version": "3.24.5", "channel": "stable", "dart_sdk_version": "3.5.4"},
+				{"version": "v3.27.1", "channel": "stable", "dart_sdk_version": "3.6.0"},
+				{"version": "3.22.3", "channel": "stable", "dart_sdk_version": "3.4.4"},
+				{"version": "3.22.3", "channel": "stable", "dart_sdk_version": "3.4.4"},
+				{"version": "3.19.6", "channel": "stable", "dart_sdk_version": "3.3.4"}
+			]}`))
+		case strings.HasPrefix(r.URL.Path, "/api/packages/"):
+			name := strings.TrimPrefix(r.URL.Path, "/api/packages/")
+			if body, ok := packages[name]; ok {
+				w.Write([]byte(body))
+				return
+			}
+			http.NotFound(w, r)
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	service := NewPinRecommendationService(&FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}})
+	ctx := context.Background()
+	pubspec := `name: app
+environment:
+  sdk: ^3.3.0
+  flutter: ">=3.19.0"
+dependencies:
+  flutter:
+    sdk: flutter
+  charts: ^1.0.0
+  core:
+    path: ../core
+  http: ">=0.13.0 <2.0.0"
+  missing_pkg: ^1.0.0
+dev_dependencies:
+  lints: ^5.0.0
+`
+
+	t.Run("Recommends the newest release every dependency supports", func(t *testing.T) {
+		result, err := service.Recommend(ctx, pubspec, "")
+		if err != nil {
+			t.Fatalf("Recommend failed: %v", err)
+		}
+		if result.LatestStable != "3.27.1" || result.LatestDart != "3.6.0" || result.CurrentFlutter != ">=3.19.0" {
+			t.Errorf("Expected the latest stable 3.27.1 and the current constraint, got %+v", result)
+		}
+		// charts 1.5.0 stops at Dart 3.4 and lints 5.0.0 needs Dart 3.6.0, so nothing runs on all of them
+		if result.Recommended != "" {
+			t.Errorf("Expected no release to satisfy charts and lints together, got %s", result.Recommended)
+		}
+		if !reflect.DeepEqual(result.Skipped, []string{"core (path)", "flutter (sdk)"}) {
+			t.Errorf("Expected the SDK and path dependencies to be skipped, got %v", result.Skipped)
+		}
+
+		byName := make(map[string]models.DependencySupport)
+		for _, dep := range result.Dependencies {
+			byName[dep.Package] = dep
+		}
+		charts := byName["charts"]
+		if charts.Version != "1.5.0" || charts.NewestFlutter != "3.22.3" || !charts.Blocking || charts.Upgrade != "2.0.0" {
+			t.Errorf("Expected charts 1.5.0 to stop at 3.22.3 with 2.0.0 as the upgrade, got %+v", charts)
+		}
+		if http := byName["http"]; http.Version != "1.2.2" || http.NewestFlutter != "3.27.1" || http.Blocking {
+			t.Errorf("Expected http 1.2.2 to run on the latest release, got %+v", http)
+		}
+		if lints := byName["lints"]; !lints.Dev || lints.NewestFlutter != "3.27.1" {
+			t.Errorf("Expected the dev dependency lints to run on the latest release, got %+v", lints)
+		}
+		if missing := byName["missing_pkg"]; missing.Error != "not found on pub.dev" {
+			t.Errorf("Expected missing_pkg to fail, got %+v", missing)
+		}
+	})
+
+	t.Run("Pins below the dependency that holds the project back", func(t *testing.T) {
+		result, err := service.Recommend(ctx, "name: app\ndependencies:\n  charts: ^1.0.0\n  http: any\n", "")
+		if err != nil {
+			t.Fatalf("Recommend failed: %v", err)
+		}
+		if result.Recommended != "3.22.3" || result.RecommendedDart != "3.4.4" {
+			t.Errorf("Expected Flutter 3.22.3 with Dart 3.4.4, got %s with %s", result.Recommended, result.RecommendedDart)
+		}
+	})
+
+	t.Run("Reads the pubspec.yaml of a project", func(t *testing.T) {
+		project := t.TempDir()
+		writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: app\ndependencies:\n  charts: ^2.0.0\n")
+		result, err := service.Recommend(ctx, "", project)
+		if err != nil {
+			t.Fatalf("Recommend failed: %v", err)
+		}
+		if result.Recommended != "3.27.1" || len(result.Dependencies) != 1 || result.Dependencies[0].Version != "2.1.0" {
+			t.Errorf("Expected charts 2.1.0 to allow the latest release, got %+v", result)
+		}
+	})
+
+	t.Run("Rejects invalid input", func(t *testing.T) {
+		if _, err := service.Recommend(ctx, "", ""); err == nil {
+			t.Error("Expected an error without a pubspec.yaml")
+		}
+		if _, err := service.Recommend(ctx, "dependencies: [", ""); err == nil || !strings.Contains(err.Error(), "invalid pubspec.yaml") {
+			t.Errorf("Expected invalid YAML to fail, got %v", err)
+		}
+		result, err := service.Recommend(ctx, "name: app\ndependencies:\n  charts: latest\n", "")
+		if err != nil || len(result.Dependencies) != 1 || !strings.Contains(result.Dependencies[0].Error, "unsupported constraint") {
+			t.Errorf("Expected an unsupported constraint to be reported, got %+v (%v)", result, err)
+		}
+	})
+}
+
+func TestPinRecommendationFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/packages/legacy" {
+			w.Write([]byte(`{"versions": [{"version": "1.0.0", "pubspec": {"environment": {"sdk": ">=2.7.0 <3.0.0"}}}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	service := NewPinRecommendationService(&FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}})
+	result, err := service.Recommend(context.Background(), "name: app\ndependencies:\n  legacy: ^1.0.0\n", "")
+	if err != nil {
+		t.Fatalf("Recommend failed: %v", err)
+	}
+	// Without null safety the package cannot move to Dart 3, which Flutter 3.10 brought
+	if result.LatestStable != dartForFlutter[0].flutter || result.Recommended != "3.7.0" || len(result.Notes) != 1 {
+		t.Errorf("Expected the release table and a pin on 3.7.0, got %+v", result)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// executableNames lists the file names a command is installed under on goos, in the order that
+// system runs them. Windows launches batch files and executables; the extensionless shell scripts
+// shipped beside them, as in the Flutter SDK's bin directory, are for other systems.
+func executableNames(goos string, name string) []string {
+	if goos == "windows" {
+		return []string{name + ".bat", name + ".exe", name + ".cmd"}
+	}
+	return []string{name}
+}
+
+// findExecutable returns the path of the first of a command's executables in dir, or ""
+func findExecutable(goos string, dir string, name string) string {
+	for _, executable := range executableNames(goos, name) {
+		path := filepath.Join(dir, executable)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// pubCacheBin returns the directory `dart pub global activate` installs tools such as fvm into
+func pubCacheBin(goos string, home string, getenv func(string) string) string {
+	if pubCache := getenv(config.PUB_CACHE_ENV); pubCache != "" {
+		return filepath.Join(pubCache, "bin")
+	}
+	if goos == "windows" {
+		if localAppData := getenv(config.LOCAL_APPDATA_ENV); localAppData != "" {
+			return filepath.Join(localAppData, "Pub", "Cache", "bin")
+		}
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".pub-cache", "bin")
+}
+
+// locateExecutable finds a command on PATH (trying name.bat and name.exe on Windows) and falls
+// back to the pub cache, which is often missing from the PATH of processes an editor starts.
+// Without a match it returns name, so running it reports the command as not found.
+func locateExecutable(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	if bin := pubCacheBin(runtime.GOOS, home, os.Getenv); bin != "" {
+		if path := findExecutable(runtime.GOOS, bin, name); path != "" {
+			return path
+		}
+	}
+	return name
+}
+
+// defaultCacheDir returns where the server keeps its cache and state files: ~/.flutter-deprecations,
+// or on Windows %APPDATA%\flutter-deprecations unless an existing ~/.flutter-deprecations is still in use
+func defaultCacheDir(goos string, home string, getenv func(string) string) string {
+	legacy := filepath.Join(home, "."+config.CACHE_DIR_NAME)
+	if goos != "windows" {
+		return legacy
+	}
+	appData := getenv(config.APPDATA_ENV)
+	if appData == "" {
+		return legacy
+	}
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return legacy
+	}
+	return filepath.Join(appData, config.CACHE_DIR_NAME)
+}
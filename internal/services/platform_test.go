@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestWindowsEnvironment(t *testing.T) {
+	t.Run("Prefers the batch launchers", func(t *testing.T) {
+		bin := t.TempDir()
+		writeFile(t, filepath.Join(bin, "flutter"), "#!/usr/bin/env bash\n")
+		writeFile(t, filepath.Join(bin, "flutter.bat"), "@ECHO off\r\n")
+
+		if got := findExecutable("windows", bin, "flutter"); got != filepath.Join(bin, "flutter.bat") {
+			t.Errorf("Expected flutter.bat on Windows, got %q", got)
+		}
+		if got := findExecutable("linux", bin, "flutter"); got != filepath.Join(bin, "flutter") {
+			t.Errorf("Expected the shell script elsewhere, got %q", got)
+		}
+		if got := findExecutable("windows", bin, "fvm"); got != "" {
+			t.Errorf("Expected no fvm, got %q", got)
+		}
+	})
+
+	t.Run("Finds fvm in the pub cache", func(t *testing.T) {
+		env := map[string]string{config.LOCAL_APPDATA_ENV: `C:\Users\dev\AppData\Local`}
+		getenv := func(key string) string { return env[key] }
+
+		if got := pubCacheBin("windows", `C:\Users\dev`, getenv); got != filepath.Join(`C:\Users\dev\AppData\Local`, "Pub", "Cache", "bin") {
+			t.Errorf("Expected the pub cache below LOCALAPPDATA, got %q", got)
+		}
+		if got := pubCacheBin("linux", "/home/dev", getenv); got != filepath.Join("/home/dev", ".pub-cache", "bin") {
+			t.Errorf("Expected ~/.pub-cache elsewhere, got %q", got)
+		}
+		env[config.PUB_CACHE_ENV] = `D:\pub`
+		if got := pubCacheBin("windows", `C:\Users\dev`, getenv); got != filepath.Join(`D:\pub`, "bin") {
+			t.Errorf("Expected PUB_CACHE to win, got %q", got)
+		}
+	})
+
+	t.Run("Keeps the cache in APPDATA", func(t *testing.T) {
+		home := t.TempDir()
+		appData := filepath.Join(home, "AppData", "Roaming")
+		getenv := func(key string) string {
+			if key == config.APPDATA_ENV {
+				return appData
+			}
+			return ""
+		}
+
+		if got := defaultCacheDir("windows", home, getenv); got != filepath.Join(appData, "flutter-deprecations") {
+			t.Errorf("Expected the cache in APPDATA, got %q", got)
+		}
+		if got := defaultCacheDir("linux", home, getenv); got != filepath.Join(home, ".flutter-deprecations") {
+			t.Errorf("Expected ~/.flutter-deprecations elsewhere, got %q", got)
+		}
+
+		// A cache created before Windows had its own location stays in use
+		if err := os.MkdirAll(filepath.Join(home, ".flutter-deprecations"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if got := defaultCacheDir("windows", home, getenv); got != filepath.Join(home, ".flutter-deprecations") {
+			t.Errorf("Expected the existing cache to be kept, got %q", got)
+		}
+	})
+
+	t.Run("Parses flutter --version --machine with CRLF line endings", func(t *testing.T) {
+		output := "Waiting for another flutter command to release the startup lock...\r\n" +
+			"{\r\n  \"frameworkVersion\": \"3.27.1\",\r\n  \"channel\": \"stable\",\r\n" +
+			"  \"dartSdkVersion\": \"3.6.0\",\r\n  \"engineRevision\": \"cb4b5fff73\"\r\n}\r\n"
+
+		version, err := parseMachineVersion([]byte(output))
+		if err != nil {
+			t.Fatalf("parseMachineVersion failed: %v", err)
+		}
+		if version.FrameworkVersion != "3.27.1" || version.Channel != "stable" || version.DartSDKVersion != "3.6.0" || version.EngineRevision != "cb4b5fff73" {
+			t.Errorf("Expected 3.27.1 on stable with Dart 3.6.0, got %+v", version)
+		}
+		for _, invalid := range []string{"\r\n", "Flutter 3.27.1 • channel stable\r\n", `{"channel": "stable"}`} {
+			if _, err := parseMachineVersion([]byte(invalid)); err == nil {
+				t.Errorf("Expected an error for %q", invalid)
+			}
+		}
+	})
+
+	t.Run("Detects SDKs in the Windows locations", func(t *testing.T) {
+		home := t.TempDir()
+		localAppData := filepath.Join(home, "AppData", "Local")
+		writeFakeSDK(t, filepath.Join(localAppData, "fvm", "versions", "3.27.1"), "3.27.1", "stable")
+
+		service := &LocalSDKService{home: home, goos: "windows", localAppData: localAppData, installPaths: []string{}}
+		report, err := service.Detect(context.Background(), "")
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if len(report.SDKs) != 1 || report.SDKs[0].Name != "3.27.1" || report.SDKs[0].Sources[0] != config.SDK_SOURCE_FVM {
+			t.Errorf("Expected the FVM SDK below LOCALAPPDATA, got %+v", report.SDKs)
+		}
+
+		paths := strings.Join((&LocalSDKService{home: home, goos: "windows"}).commonInstallPaths(), ",")
+		if !strings.Contains(paths, `C:\src\flutter`) || strings.Contains(paths, "/opt/flutter") {
+			t.Errorf("Expected the Windows install locations, got %s", paths)
+		}
+	})
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// Patterns used to read pull request references, diffs and the comments of earlier reviews
+var (
+	pullRequestPattern = regexp.MustCompile(`^(?:(?:https?://)?(?:www\.)?github\.com/)?([\w.-]+/[\w.-]+?)(?:#|/pulls?/)(\d+)/?$`)
+	hunkPattern        = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+	reviewMarkerRegex  = regexp.MustCompile(`<!-- flutter-deprecations: (\S+) -->`)
+)
+
+// ParsePullRequest reads a pull request given as owner/name#number or its github.com URL
+func ParsePullRequest(ref string) (string, int, error) {
+	matches := pullRequestPattern.FindStringSubmatch(strings.TrimSpace(ref))
+	if matches == nil {
+		return "", 0, fmt.Errorf("invalid pull request %q, expected owner/name#number or a github.com pull request URL", ref)
+	}
+	number, err := strconv.Atoi(matches[2])
+	if err != nil || number < 1 {
+		return "", 0, fmt.Errorf("invalid pull request number in %q", ref)
+	}
+	return matches[1], number, nil
+}
+
+// pullRequestFile is a file changed by a pull request, as the pull request files API lists it.
+// Patch is missing for binary files and diffs too large for the API.
+type pullRequestFile struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"`
+	Patch    string `json:"patch"`
+}
+
+// reviewComment is a comment of a pull request review on a line of the new version of a file
+type reviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// PRReviewService turns the deprecated APIs a GitHub pull request adds into review comments on the
+// added lines, so the server can run as a deprecation review bot
+type PRReviewService struct {
+	apiService         *FlutterAPIService
+	deprecationService *DeprecationService
+}
+
+// NewPRReviewService creates a new pull request review service instance
+func NewPRReviewService(apiService *FlutterAPIService, deprecationService *DeprecationService) *PRReviewService {
+	return &PRReviewService{apiService: apiService, deprecationService: deprecationService}
+}
+
+// Review checks the Dart files pull request number of repo changes at its head commit and returns a
+// comment for each added line that uses a deprecated API, leaving out the APIs in hidden and the
+// uses earlier reviews already commented on. With post the comments are published as a review,
+// which needs the GitHub token. target selects the Flutter (default) or Dart rules.
+func (p *PRReviewService) Review(ctx context.Context, repo string, number int, target string, hidden map[string]bool, post bool) (*models.PRReviewResult, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseGitHubRepo(repo, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 {
+		return nil, fmt.Errorf("invalid pull request number %d", number)
+	}
+	if post && p.apiService.githubToken == "" {
+		return nil, fmt.Errorf("posting a review needs a GitHub token in $%s", config.GITHUB_TOKEN_ENV)
+	}
+
+	pullURL := fmt.Sprintf(config.GITHUB_PULL_URL, r.name, number)
+	var pull struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := p.getJSON(ctx, pullURL, &pull); err != nil {
+		return nil, err
+	}
+	files, err := githubPages[pullRequestFile](ctx, p, pullURL+"/files")
+	if err != nil {
+		return nil, err
+	}
+	comments, err := githubPages[struct {
+		Path string `json:"path"`
+		Line int    `json:"line"`
+		Body string `json:"body"`
+	}](ctx, p, pullURL+"/comments")
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	for _, comment := range comments {
+		for _, match := range reviewMarkerRegex.FindAllStringSubmatch(comment.Body, -1) {
+			existing[reviewKey(comment.Path, comment.Line, match[1])] = true
+		}
+	}
+
+	r.ref = pull.Head.SHA
+	result := &models.PRReviewResult{Repository: r.name, Number: number, HeadSHA: pull.Head.SHA, Comments: []models.PRReviewComment{}}
+	for _, file := range files {
+		if file.Status == "removed" || file.Patch == "" || !strings.HasSuffix(file.Filename, ".dart") || isGeneratedDart(path.Base(file.Filename)) {
+			continue
+		}
+		if result.Files == config.MAX_PR_REVIEW_FILES {
+			slog.Warn("Pull request review stopped at the file limit", "repository", r.name, "number", number, "limit", config.MAX_PR_REVIEW_FILES)
+			break
+		}
+		result.Files++
+		code, err := p.fileContents(ctx, r, file.Filename)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			slog.Warn("Failed to fetch pull request file", "repository", r.name, "number", number, "file", file.Filename, "error", err)
+			continue
+		}
+		fileComments, commented := p.deprecationService.reviewComments(file.Filename, code, addedLines(file.Patch), target, hidden, existing)
+		result.Comments = append(result.Comments, fileComments...)
+		result.Existing += commented
+	}
+	if len(result.Comments) > config.MAX_PR_REVIEW_COMMENTS {
+		result.Omitted = len(result.Comments) - config.MAX_PR_REVIEW_COMMENTS
+		result.Comments = result.Comments[:config.MAX_PR_REVIEW_COMMENTS]
+	}
+
+	if post && len(result.Comments) > 0 {
+		if result.ReviewURL, err = p.postReview(ctx, pullURL, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// reviewKey identifies the comment on an API at a line of a file
+func reviewKey(file string, line int, api string) string {
+	return fmt.Sprintf("%s:%d:%s", file, line, api)
+}
+
+// addedLines returns the lines of the new version of a file that a unified diff patch adds
+func addedLines(patch string) map[int]bool {
+	added := make(map[int]bool)
+	line := 0
+	for _, text := range strings.Split(patch, "\n") {
+		if matches := hunkPattern.FindStringSubmatch(text); matches != nil {
+			line, _ = strconv.Atoi(matches[1])
+			continue
+		}
+		switch {
+		case line == 0, strings.HasPrefix(text, "-"), strings.HasPrefix(text, `\`):
+			// Before the first hunk, removed lines and "\ No newline at end of file"
+		case strings.HasPrefix(text, "+"):
+			added[line] = true
+			line++
+		default:
+			line++
+		}
+	}
+	return added
+}
+
+// reviewComments returns a comment for every line in added that uses a deprecated API in code,
+// suggesting the rewrite of the line when the uses have mechanical replacements. The APIs in
+// hidden are left out, and so are the uses existing lists, whose number is returned.
+func (d *DeprecationService) reviewComments(file string, code string, added map[int]bool, target string, hidden map[string]bool, existing map[string]bool) ([]models.PRReviewComment, int) {
+	positions := newDartPositions(code)
+	uses := make(map[int][]deprecatedUse)
+	var lines []int
+	commented := 0
+	for _, use := range d.deprecatedUses(code, target) {
+		line, _ := positions.lineColumn(use.start)
+		if hidden[use.dep.API] || !added[line] {
+			continue
+		}
+		if existing[reviewKey(file, line, use.dep.API)] {
+			commented++
+			continue
+		}
+		if len(uses[line]) == 0 {
+			lines = append(lines, line)
+		}
+		uses[line] = append(uses[line], use)
+	}
+
+	comments := make([]models.PRReviewComment, 0, len(lines))
+	for _, line := range lines {
+		comment := models.PRReviewComment{Path: file, Line: line}
+		var body strings.Builder
+		for _, use := range uses[line] {
+			if containsString(comment.APIs, use.dep.API) {
+				continue
+			}
+			comment.APIs = append(comment.APIs, use.dep.API)
+			fmt.Fprintf(&body, config.PR_REVIEW_MARKER+"\n", use.dep.API)
+			body.WriteString(strings.TrimSpace(deprecatedUseMessage(use.dep) + " " + replacementHint(use.dep)))
+			if link := DocumentationURL(use.dep); link != "" {
+				fmt.Fprintf(&body, " [Migration guide](%s)", link)
+			}
+			body.WriteString("\n\n")
+		}
+		if suggestion, ok := positions.suggestLine(line, uses[line]); ok {
+			fmt.Fprintf(&body, "```suggestion\n%s\n```\n", suggestion)
+		}
+		comment.Body = strings.TrimSpace(body.String())
+		comments = append(comments, comment)
+	}
+	return comments, commented
+}
+
+// suggestLine returns the 1-based line rewritten with the replacements of uses that lie within it
+func (p *dartPositions) suggestLine(line int, uses []deprecatedUse) (string, bool) {
+	start := p.lineStarts[line-1]
+	end := len(p.code)
+	if line < len(p.lineStarts) {
+		end = p.lineStarts[line] - 1
+	}
+	end = start + len(strings.TrimRight(p.code[start:end], "\r"))
+
+	var edits []analyzerEdit
+	for _, use := range uses {
+		for _, edit := range use.edits {
+			if edit.start >= start && edit.end <= end {
+				edits = append(edits, edit)
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return "", false
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	var rewritten strings.Builder
+	from := start
+	for _, edit := range edits {
+		if edit.start < from {
+			// Overlaps an edit already applied, such as the same rewrite matched by two uses
+			continue
+		}
+		rewritten.WriteString(p.code[from:edit.start])
+		rewritten.WriteString(edit.replacement)
+		from = edit.end
+	}
+	rewritten.WriteString(p.code[from:end])
+	return rewritten.String(), rewritten.String() != p.code[start:end]
+}
+
+// fileContents fetches a file of repo at its ref through the contents API, which also serves
+// private repositories
+func (p *PRReviewService) fileContents(ctx context.Context, repo githubRepo, file string) (string, error) {
+	req, err := p.apiService.newRequest(ctx, http.MethodGet, repo.contentsURL(file), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.raw+json")
+	resp, err := p.apiService.do(req, UpstreamGitHub)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", githubStatusError(resp)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, config.MAX_CHECK_FILE_SIZE+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > config.MAX_CHECK_FILE_SIZE {
+		return "", fmt.Errorf("%s is larger than the %d byte limit", file, config.MAX_CHECK_FILE_SIZE)
+	}
+	return string(data), nil
+}
+
+// getJSON decodes the GitHub API response to a GET of apiURL into v
+func (p *PRReviewService) getJSON(ctx context.Context, apiURL string, v any) error {
+	resp, err := p.apiService.get(ctx, apiURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return githubStatusError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// githubPages fetches every page of a GitHub API list, up to the 3,000 entries the pull request
+// files API returns at most
+func githubPages[T any](ctx context.Context, p *PRReviewService, apiURL string) ([]T, error) {
+	const perPage, maxPages = 100, 30
+	var all []T
+	for page := 1; page <= maxPages; page++ {
+		var entries []T
+		if err := p.getJSON(ctx, fmt.Sprintf("%s?per_page=%d&page=%d", apiURL, perPage, page), &entries); err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+		if len(entries) < perPage {
+			break
+		}
+	}
+	return all, nil
+}
+
+// postReview publishes the comments of result as a review of the head commit and returns its URL
+func (p *PRReviewService) postReview(ctx context.Context, pullURL string, result *models.PRReviewResult) (string, error) {
+	summary := fmt.Sprintf("Found %d line(s) using deprecated APIs in the changes of this pull request.", len(result.Comments))
+	if result.Omitted > 0 {
+		summary += fmt.Sprintf(" %d more are not commented on; run a project scan to see them all.", result.Omitted)
+	}
+	review := struct {
+		CommitID string          `json:"commit_id"`
+		Event    string          `json:"event"`
+		Body     string          `json:"body"`
+		Comments []reviewComment `json:"comments"`
+	}{CommitID: result.HeadSHA, Event: "COMMENT", Body: summary}
+	for _, comment := range result.Comments {
+		review.Comments = append(review.Comments, reviewComment{Path: comment.Path, Line: comment.Line, Side: "RIGHT", Body: comment.Body})
+	}
+	data, err := json.Marshal(review)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := p.apiService.newRequest(ctx, http.MethodPost, pullURL+"/reviews", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.apiService.do(req, UpstreamGitHub)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", githubStatusError(resp)
+	}
+
+	var posted struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&posted); err != nil {
+		return "", err
+	}
+	return posted.HTMLURL, nil
+}
+
+// githubStatusError describes an unsuccessful GitHub API response with the message it carries
+func githubStatusError(resp *http.Response) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("GitHub rejected the token in %s (401)", config.GITHUB_TOKEN_ENV)
+	case strings.Contains(body.Message, "API rate limit exceeded"):
+		return fmt.Errorf("GitHub API rate limit exceeded. Please wait before retrying or set GITHUB_TOKEN")
+	case body.Message != "":
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, body.Message)
+	default:
+		return fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+}
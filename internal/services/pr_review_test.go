@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePullRequest(t *testing.T) {
+	for ref, expected := range map[string]struct {
+		repo   string
+		number int
+	}{
+		"acme/shop#42":                              {"acme/shop", 42},
+		"https://github.com/acme/shop/pull/42":      {"acme/shop", 42},
+		"github.com/acme/shop.app/pull/7/":          {"acme/shop.app", 7},
+		" https://www.github.com/acme/shop/pulls/3": {"acme/shop", 3},
+	} {
+		repo, number, err := ParsePullRequest(ref)
+		if err != nil || repo != expected.repo || number != expected.number {
+			t.Errorf("ParsePullRequest(%q): expected %v, got %s #%d (%v)", ref, expected, repo, number, err)
+		}
+	}
+
+	for _, invalid := range []string{"", "acme/shop", "acme/shop#0", "https://github.com/acme/shop/issues/4"} {
+		if _, _, err := ParsePullRequest(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	patch := "@@ -1,3 +1,4 @@\n import 'package:flutter/material.dart';\n-final a = 1;\n+final a = 2;\n+final b = 3;\n \n@@ -10,2 +11,2 @@ class Shop {\n-  old();\n+  renamed();\n }\n\\ No newline at end of file"
+	expected := map[int]bool{2: true, 3: true, 11: true}
+	if got := addedLines(patch); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected added lines %v, got %v", expected, got)
+	}
+}
+
+func TestPRReviewService(t *testing.T) {
+	code := "import 'package:flutter/material.dart';\n\nWidget build() {\n  return RaisedButton(onPressed: save);\n}\nfinal old = FlatButton();\nfinal c = Color.red.withOpacity(0.5);\n"
+	patch := "@@ -1,4 +1,7 @@\n import 'package:flutter/material.dart';\n \n Widget build() {\n+  return RaisedButton(onPressed: save);\n+}\n final old = FlatButton();\n+final c = Color.red.withOpacity(0.5);"
+
+	var posted struct {
+		CommitID string          `json:"commit_id"`
+		Event    string          `json:"event"`
+		Comments []reviewComment `json:"comments"`
+	}
+	var postedAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/acme/shop/pulls/42":
+			w.Write([]byte(`{"head": {"sha": "0123456789abcdef0123456789abcdef01234567"}}`))
+		case r.URL.Path == "/repos/acme/shop/pulls/42/files":
+			files := []pullRequestFile{
+				{Filename: "lib/shop.dart", Status: "modified", Patch: patch},
+				{Filename: "lib/shop.g.dart", Status: "added", Patch: "@@ -0,0 +1 @@\n+RaisedButton()"},
+				{Filename: "lib/gone.dart", Status: "removed", Patch: "@@ -1 +0,0 @@\n-RaisedButton()"},
+				{Filename: "README.md", Status: "modified", Patch: "@@ -1 +1 @@\n+RaisedButton"},
+			}
+			json.NewEncoder(w).Encode(files)
+		case r.URL.Path == "/repos/acme/shop/pulls/42/comments":
+			w.Write([]byte(`[{"path": "lib/shop.dart", "line": 7, "body": "<!-- flutter-deprecations: Color.withOpacity -->\nAlready reported"}]`))
+		case r.URL.Path == "/repos/acme/shop/contents/lib/shop.dart":
+			if r.URL.Query().Get("ref") != "0123456789abcdef0123456789abcdef01234567" || !strings.Contains(r.Header.Get("Accept"), "raw") {
+				t.Errorf("Expected the raw file at the head commit, got %s with %s", r.URL.RawQuery, r.Header.Get("Accept"))
+			}
+			w.Write([]byte(code))
+		case r.URL.Path == "/repos/acme/shop/pulls/42/reviews" && r.Method == http.MethodPost:
+			postedAuth = r.Header.Get("Authorization")
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"id": 1, "html_url": "https://github.com/acme/shop/pull/42#pullrequestreview-1"}`))
+		case r.URL.Path == "/repos/acme/shop/pulls/7":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	apiService := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, apiService)
+	reviews := NewPRReviewService(apiService, depService)
+	ctx := context.Background()
+
+	t.Run("Comments on the added lines only", func(t *testing.T) {
+		result, err := reviews.Review(ctx, "acme/shop", 42, "", nil, false)
+		if err != nil {
+			t.Fatalf("Review failed: %v", err)
+		}
+		if result.Files != 1 || result.Existing != 1 || result.ReviewURL != "" {
+			t.Errorf("Expected one checked file and one earlier comment, got %+v", result)
+		}
+		if len(result.Comments) != 1 {
+			t.Fatalf("Expected a comment on RaisedButton only, got %+v", result.Comments)
+		}
+		comment := result.Comments[0]
+		if comment.Path != "lib/shop.dart" || comment.Line != 4 || !reflect.DeepEqual(comment.APIs, []string{"RaisedButton"}) {
+			t.Errorf("Expected RaisedButton on line 4, got %+v", comment)
+		}
+		for _, part := range []string{"<!-- flutter-deprecations: RaisedButton -->", "'RaisedButton' is deprecated", "```suggestion\n  return ElevatedButton(onPressed: save);\n```"} {
+			if !strings.Contains(comment.Body, part) {
+				t.Errorf("Expected the comment to contain %q, got %s", part, comment.Body)
+			}
+		}
+		if posted.CommitID != "" {
+			t.Error("Expected nothing to be posted without post")
+		}
+	})
+
+	t.Run("Leaves out hidden APIs", func(t *testing.T) {
+		result, err := reviews.Review(ctx, "acme/shop", 42, "", map[string]bool{"RaisedButton": true}, false)
+		if err != nil {
+			t.Fatalf("Review failed: %v", err)
+		}
+		if len(result.Comments) != 0 {
+			t.Errorf("Expected the suppressed RaisedButton to be left out, got %+v", result.Comments)
+		}
+	})
+
+	t.Run("Posts the review with the token", func(t *testing.T) {
+		if _, err := reviews.Review(ctx, "acme/shop", 42, "", nil, true); err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+			t.Errorf("Expected posting without a token to fail, got %v", err)
+		}
+
+		apiService.SetGitHubToken("ghp_test")
+		defer apiService.SetGitHubToken("")
+		result, err := reviews.Review(ctx, "https://github.com/acme/shop", 42, "", nil, true)
+		if err != nil {
+			t.Fatalf("Review failed: %v", err)
+		}
+		if result.ReviewURL != "https://github.com/acme/shop/pull/42#pullrequestreview-1" {
+			t.Errorf("Expected the URL of the posted review, got %q", result.ReviewURL)
+		}
+		if postedAuth != "Bearer ghp_test" || posted.Event != "COMMENT" || posted.CommitID != result.HeadSHA {
+			t.Errorf("Expected an authenticated comment review of the head commit, got %+v with %q", posted, postedAuth)
+		}
+		if len(posted.Comments) != 1 || posted.Comments[0].Side != "RIGHT" || posted.Comments[0].Line != 4 || posted.Comments[0].Body != result.Comments[0].Body {
+			t.Errorf("Expected the comment on the new line 4, got %+v", posted.Comments)
+		}
+	})
+
+	t.Run("Reports GitHub errors", func(t *testing.T) {
+		_, err := reviews.Review(ctx, "acme/shop", 7, "", nil, false)
+		if err == nil || !strings.Contains(err.Error(), "404: Not Found") {
+			t.Errorf("Expected a missing pull request to fail, got %v", err)
+		}
+		if _, err := reviews.Review(ctx, "acme/shop", 42, "kotlin", nil, false); err == nil {
+			t.Error("Expected an error for an unknown target")
+		}
+	})
+}
+
+func TestReviewCommentsSuggestions(t *testing.T) {
+	depService := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	code := "Row(children: [FlatButton(), RaisedButton()]);\r\nlegacy();\n"
+	comments, _ := depService.reviewComments("lib/row.dart", code, map[int]bool{1: true, 2: true}, "", nil, nil)
+	if len(comments) != 1 {
+		t.Fatalf("Expected one comment for line 1, got %+v", comments)
+	}
+	if !reflect.DeepEqual(comments[0].APIs, []string{"FlatButton", "RaisedButton"}) {
+		t.Errorf("Expected both buttons in one comment, got %v", comments[0].APIs)
+	}
+	if !strings.Contains(comments[0].Body, "```suggestion\nRow(children: [TextButton(), ElevatedButton()]);\n```") {
+		t.Errorf("Expected both rewrites in one suggestion without the carriage return, got %s", comments[0].Body)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// skippedProjectDirs are directories that never contain hand-written project sources
+var skippedProjectDirs = map[string]bool{
+	"build":        true,
+	".dart_tool":   true,
+	".git":         true,
+	".idea":        true,
+	".fvm":         true,
+	"node_modules": true,
+}
+
+// pubspec holds the parts of a pubspec.yaml the server inspects
+type pubspec struct {
+	Name        string            `yaml:"name"`
+	Environment map[string]string `yaml:"environment"`
+	Workspace   []string          `yaml:"workspace"`
+
+	// The dependencies map names to a constraint string or to a map describing their source
+	Dependencies    map[string]any `yaml:"dependencies"`
+	DevDependencies map[string]any `yaml:"dev_dependencies"`
+}
+
+// readPubspec parses the pubspec.yaml in a project directory
+func readPubspec(projectPath string) (*pubspec, error) {
+	data, err := os.ReadFile(filepath.Join(projectPath, "pubspec.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var spec pubspec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// dartFiles lists the Dart sources of a project, skipping build output, tool directories and the
+// paths its ignore files list
+func dartFiles(ctx context.Context, projectPath string) ([]string, error) {
+	return walkDartFiles(ctx, projectPath, walkOptions{ignore: newProjectIgnore(projectPath)})
+}
+
+// walkOptions select the files walkDartFiles lists. The ignore files and filter globs are those
+// of the project root, which may be a parent of the walked directory such as a workspace root.
+type walkOptions struct {
+	ignore *projectIgnore
+	filter *pathFilter
+	// skip reports directories left to another walk, such as the other packages of a workspace
+	skip func(dir string) bool
+}
+
+// walkDartFiles lists the Dart sources below projectPath like dartFiles, with the ignore files,
+// filter and skipped directories of opts
+func walkDartFiles(ctx context.Context, projectPath string, opts walkOptions) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(projectPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != projectPath && (skippedProjectDirs[entry.Name()] || opts.skips(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ".dart") && !isGeneratedDart(entry.Name()) && opts.lists(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// skips reports whether the walk leaves out a directory
+func (o walkOptions) skips(dir string) bool {
+	return o.skip != nil && o.skip(dir) || o.ignore != nil && o.ignore.ignored(dir, true) || o.filter.excludesDir(dir)
+}
+
+// lists reports whether the walk lists a Dart file
+func (o walkOptions) lists(file string) bool {
+	return (o.ignore == nil || !o.ignore.ignored(file, false)) && o.filter.allows(file)
+}
+
+// selects reports whether a walk of root would list file, a path below it, so that a single file
+// such as one git reports as changed is picked like a full walk picks it
+func (o walkOptions) selects(root string, file string) bool {
+	name := filepath.Base(file)
+	if !strings.HasSuffix(name, ".dart") || isGeneratedDart(name) {
+		return false
+	}
+	for dir := filepath.Dir(file); len(dir) > len(root); dir = filepath.Dir(dir) {
+		if skippedProjectDirs[filepath.Base(dir)] || o.skips(dir) {
+			return false
+		}
+	}
+	return o.lists(file)
+}
+
+// isGeneratedDart reports whether a file name follows a code generator naming convention
+func isGeneratedDart(name string) bool {
+	for _, suffix := range []string{".g.dart", ".freezed.dart", ".mocks.dart", ".gr.dart"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathFilter matches the paths below a project root against the globs of a models.PathFilter
+type pathFilter struct {
+	root    string
+	include []string
+	exclude []string
+}
+
+// newPathFilter validates the globs of filter, returning nil when it has none
+func newPathFilter(root string, filter models.PathFilter) (*pathFilter, error) {
+	f := &pathFilter{root: root}
+	for _, list := range []struct {
+		globs []string
+		into  *[]string
+	}{{filter.Include, &f.include}, {filter.Exclude, &f.exclude}} {
+		for _, glob := range list.globs {
+			glob = strings.TrimSpace(filepath.ToSlash(glob))
+			if glob == "" {
+				continue
+			}
+			if !validGlob(glob) {
+				return nil, fmt.Errorf("invalid glob %q", glob)
+			}
+			*list.into = append(*list.into, glob)
+		}
+	}
+	if len(f.include) == 0 && len(f.exclude) == 0 {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// allows reports whether a file matches an include glob, if there are any, and no exclude glob
+func (f *pathFilter) allows(file string) bool {
+	if f == nil {
+		return true
+	}
+	relative := relativeSlash(f.root, file)
+	if len(f.include) > 0 && !matchesAnyGlob(f.include, relative) {
+		return false
+	}
+	return !matchesAnyGlob(f.exclude, relative)
+}
+
+// excludesDir reports whether an exclude glob names a directory or everything below it, as
+// lib/generated or lib/generated/** do, so that the walk need not enter it
+func (f *pathFilter) excludesDir(dir string) bool {
+	if f == nil {
+		return false
+	}
+	relative := relativeSlash(f.root, dir)
+	for _, glob := range f.exclude {
+		if matchGlob(glob, relative) || matchGlob(strings.TrimSuffix(glob, "/**"), relative) {
+			return true
+		}
+	}
+	return false
+}
+
+// validGlob reports whether every segment of a glob is a valid path.Match pattern
+func validGlob(glob string) bool {
+	for _, segment := range strings.Split(glob, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAnyGlob reports whether a relative path matches one of the glob patterns
+func matchesAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// relativeSlash returns path relative to root with forward slashes, as shown in reports
+func relativeSlash(root string, path string) string {
+	relative, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relative)
+}
+
+// matchGlob reports whether a slash-separated relative path matches a glob pattern in which **
+// matches any number of directories and the other segments follow path.Match. As in .gitignore, a
+// trailing ** only matches what is inside a directory.
+func matchGlob(pattern string, name string) bool {
+	return matchSegments(strings.Split(strings.Trim(strings.TrimPrefix(pattern, "./"), "/"), "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// layoutFiles are the files that decide which packages a scan finds and which files it checks. A
+// change to any of them leaves the files of the last full scan unreliable.
+var layoutFiles = map[string]bool{
+	"pubspec.yaml":             true,
+	config.MELOS_FILE:          true,
+	config.GITIGNORE_FILE:      true,
+	config.PROJECT_IGNORE_FILE: true,
+}
+
+// scannedFile is what a scan found in one file of the package with index Package
+type scannedFile struct {
+	Package      int                  `json:"package"`
+	Deprecations []models.Deprecation `json:"deprecations,omitempty"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// scanBaseline is a full scan of a git project at Commit, with every file it checked by its path
+// relative to the project root. Packages only hold the names and paths of the packages. Like the
+// scan cache it is only valid for the rules the fingerprint identifies.
+type scanBaseline struct {
+	Commit      string                     `json:"commit"`
+	Fingerprint string                     `json:"fingerprint"`
+	Workspace   string                     `json:"workspace,omitempty"`
+	Packages    []models.PackageScanResult `json:"packages"`
+	Files       map[string]scannedFile     `json:"files"`
+}
+
+// baselineKey names the stored baseline of a project, which is kept per target and scope
+func baselineKey(projectPath string, target string, filter *pathFilter) string {
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		projectPath = abs
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\n", projectPath, target)
+	if filter != nil {
+		fmt.Fprintf(h, "%s\x00%s\n", strings.Join(filter.include, ","), strings.Join(filter.exclude, ","))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadScanBaseline reads the baseline at path, returning nil when it is missing, unreadable or
+// was scanned with other rules than fingerprint identifies
+func loadScanBaseline(path string, fingerprint string) *scanBaseline {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var baseline scanBaseline
+	if json.Unmarshal(data, &baseline) != nil || baseline.Fingerprint != fingerprint || baseline.Commit == "" || baseline.Files == nil {
+		return nil
+	}
+	return &baseline
+}
+
+// storeScanBaseline writes a baseline to path through a temporary file
+func storeScanBaseline(path string, baseline *scanBaseline) error {
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// report builds the scan result of the project at projectPath from the baseline's files
+func (b *scanBaseline) report(projectPath string) *models.ProjectScanResult {
+	result := &models.ProjectScanResult{ProjectPath: projectPath, Workspace: b.Workspace}
+	result.Packages = make([]models.PackageScanResult, len(b.Packages))
+	for i, pkg := range b.Packages {
+		result.Packages[i] = models.PackageScanResult{Name: pkg.Name, Path: pkg.Path}
+	}
+
+	names := make([]string, 0, len(b.Files))
+	for name := range b.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scanned := b.Files[name]
+		pkg := &result.Packages[scanned.Package]
+		pkg.Files++
+		if scanned.Error == "" && len(scanned.Deprecations) == 0 {
+			continue
+		}
+		pkg.Findings = append(pkg.Findings, models.FileCheckResult{
+			Name:         name,
+			Path:         filepath.Join(projectPath, filepath.FromSlash(name)),
+			Deprecations: scanned.Deprecations,
+			Error:        scanned.Error,
+		})
+	}
+	return result
+}
+
+// packageOf returns the index of the package a file belongs to: the one with the longest path
+// containing it, or -1 when there is none
+func (b *scanBaseline) packageOf(name string) int {
+	found := -1
+	for i, pkg := range b.Packages {
+		if pkg.Path != "." && !strings.HasPrefix(name, pkg.Path+"/") {
+			continue
+		}
+		if found < 0 || b.Packages[found].Path == "." || len(pkg.Path) > len(b.Packages[found].Path) {
+			found = i
+		}
+	}
+	return found
+}
+
+// gitHead returns the commit checked out in the git repository holding dir
+func gitHead(ctx context.Context, timeout time.Duration, dir string) (string, error) {
+	output, err := runExec(ctx, timeout, "git", "-C", dir, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return "", gitError(err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitChangedFiles lists the files below dir, relative to it, that differ in the working tree
+// from commit, staged or not, and those git does not track and does not ignore. Renames are
+// listed as a deletion and an addition.
+func gitChangedFiles(ctx context.Context, timeout time.Duration, dir string, commit string) ([]string, error) {
+	diff, err := runExec(ctx, timeout, "git", "-C", dir, "diff", "--name-only", "--no-renames", "--relative", "-z", commit, "--")
+	if err != nil {
+		return nil, gitError(err)
+	}
+	untracked, err := runExec(ctx, timeout, "git", "-C", dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(diff)+string(untracked), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// gitError adds what git printed to the error of a failed git command
+func gitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if message := strings.TrimSpace(string(exitErr.Stderr)); message != "" {
+			return fmt.Errorf("%s", message)
+		}
+	}
+	return err
+}
+
+// ShortCommit abbreviates a commit hash for messages
+func ShortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// runGit runs git in dir, skipping the test when git is not installed
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
+
+func TestIncrementalScan(t *testing.T) {
+	cache := &CacheService{dir: t.TempDir()}
+	deprecations := &countingDeprecationService{DeprecationService: NewDeprecationService(cache, NewFlutterAPIService())}
+	service := NewProjectScanService(deprecations)
+	service.SetBaselineDir(filepath.Join(cache.dir, config.PROJECT_SCANS_DIR))
+	ctx := context.Background()
+
+	project := t.TempDir()
+	writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: shop\n")
+	writeFile(t, filepath.Join(project, "melos.yaml"), "packages:\n  - packages/*\n")
+	writeFile(t, filepath.Join(project, "packages", "ui", "pubspec.yaml"), "name: shop_ui\n")
+	writeFile(t, filepath.Join(project, "packages", "ui", "lib", "button.dart"), "RaisedButton(onPressed: buy)\n")
+	writeFile(t, filepath.Join(project, "packages", "ui", "lib", "card.dart"), "Text('fine')\n")
+	writeFile(t, filepath.Join(project, "packages", "core", "pubspec.yaml"), "name: shop_core\n")
+	writeFile(t, filepath.Join(project, "packages", "core", "lib", "theme.dart"), "Color.red.withOpacity(0.5)\n")
+	writeFile(t, filepath.Join(project, "packages", "core", "lib", "old.dart"), "FlatButton()\n")
+	runGit(t, project, "init", "-q")
+	runGit(t, project, "add", "-A")
+	runGit(t, project, "commit", "-q", "-m", "initial")
+
+	findings := func(result *models.ProjectScanResult) map[string]int {
+		found := make(map[string]int)
+		for _, pkg := range result.Packages {
+			for _, finding := range pkg.Findings {
+				found[finding.Name] = len(finding.Deprecations)
+			}
+		}
+		return found
+	}
+
+	t.Run("Scans everything without an earlier full scan", func(t *testing.T) {
+		result, err := service.ScanChanged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if result.FullScanReason == "" || result.BaseCommit != "" || deprecations.checks != 4 {
+			t.Errorf("Expected a full scan with a reason, got %+v after %d checks", result, deprecations.checks)
+		}
+	})
+
+	t.Run("Only checks the changed files", func(t *testing.T) {
+		deprecations.checks = 0
+		writeFile(t, filepath.Join(project, "packages", "ui", "lib", "card.dart"), "Color.red.withOpacity(0.5)\n")
+		writeFile(t, filepath.Join(project, "packages", "ui", "lib", "new.dart"), "FlatButton()\n")
+		writeFile(t, filepath.Join(project, "packages", "ui", "lib", "new.g.dart"), "FlatButton()\n")
+		if err := os.Remove(filepath.Join(project, "packages", "core", "lib", "old.dart")); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := service.ScanChanged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if result.FullScanReason != "" || len(result.BaseCommit) != 40 || result.Changed != 2 || deprecations.checks != 2 {
+			t.Fatalf("Expected card.dart and new.dart to be checked again, got %+v after %d checks", result, deprecations.checks)
+		}
+
+		found := findings(result)
+		for name, count := range map[string]int{
+			"packages/ui/lib/button.dart":  1,
+			"packages/ui/lib/card.dart":    1,
+			"packages/ui/lib/new.dart":     1,
+			"packages/core/lib/theme.dart": 1,
+		} {
+			if found[name] != count {
+				t.Errorf("Expected %d deprecations in %s, got %v", count, name, found)
+			}
+		}
+		if _, ok := found["packages/core/lib/old.dart"]; ok {
+			t.Errorf("Expected the deleted old.dart to be dropped, got %v", found)
+		}
+		files := map[string]int{}
+		for _, pkg := range result.Packages {
+			files[pkg.Name] = pkg.Files
+		}
+		if files["shop_ui"] != 3 || files["shop_core"] != 1 {
+			t.Errorf("Expected 3 files in shop_ui and 1 in shop_core, got %v", files)
+		}
+	})
+
+	t.Run("Builds on the last full scan only", func(t *testing.T) {
+		deprecations.checks = 0
+		if _, err := service.ScanChanged(ctx, project, "", models.PathFilter{}); err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if deprecations.checks != 2 {
+			t.Errorf("Expected the same changed files to be checked against the full scan, got %d checks", deprecations.checks)
+		}
+
+		if _, err := service.Scan(ctx, project, "", models.PathFilter{}); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		deprecations.checks = 0
+		result, err := service.ScanChanged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if result.Changed != 2 || deprecations.checks != 2 {
+			t.Errorf("Expected the uncommitted changes to be checked again, got %+v after %d checks", result, deprecations.checks)
+		}
+
+		runGit(t, project, "add", "-A")
+		runGit(t, project, "commit", "-q", "-m", "changes")
+		if _, err := service.Scan(ctx, project, "", models.PathFilter{}); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		deprecations.checks = 0
+		result, err = service.ScanChanged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if result.Changed != 0 || deprecations.checks != 0 || len(findings(result)) != 4 {
+			t.Errorf("Expected nothing to check after the commit, got %+v after %d checks", result, deprecations.checks)
+		}
+	})
+
+	t.Run("Keeps a baseline per scope", func(t *testing.T) {
+		result, err := service.ScanChanged(ctx, project, "", models.PathFilter{Include: []string{"packages/ui/**"}})
+		if err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if result.FullScanReason == "" {
+			t.Errorf("Expected another scope to need its own full scan, got %+v", result)
+		}
+	})
+
+	t.Run("Scans everything when the layout changes", func(t *testing.T) {
+		writeFile(t, filepath.Join(project, "packages", "ui", "pubspec.yaml"), "name: shop_widgets\n")
+		result, err := service.ScanChanged(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("ScanChanged failed: %v", err)
+		}
+		if !strings.Contains(result.FullScanReason, "packages/ui/pubspec.yaml changed") {
+			t.Errorf("Expected the changed pubspec.yaml to force a full scan, got %q", result.FullScanReason)
+		}
+		if result.Packages[1].Name != "shop_widgets" {
+			t.Errorf("Expected the renamed package, got %+v", result.Packages)
+		}
+	})
+
+	t.Run("Needs a git repository", func(t *testing.T) {
+		plain := t.TempDir()
+		writeFile(t, filepath.Join(plain, "lib", "main.dart"), "Text('fine')\n")
+		if _, err := service.ScanChanged(ctx, plain, "", models.PathFilter{}); err == nil || !strings.Contains(err.Error(), "need a git repository") {
+			t.Errorf("Expected a git repository error, got %v", err)
+		}
+		if _, err := service.Scan(ctx, plain, "", models.PathFilter{}); err != nil {
+			t.Errorf("Expected a full scan outside git to work, got %v", err)
+		}
+	})
+}
+
+func TestScanBaselinePackageOf(t *testing.T) {
+	baseline := &scanBaseline{Packages: []models.PackageScanResult{{Path: "."}, {Path: "packages/a"}, {Path: "packages/app"}}}
+	for name, expected := range map[string]int{
+		"lib/main.dart":              0,
+		"packages/a/lib/a.dart":      1,
+		"packages/app/lib/main.dart": 2,
+		"packages/apps/lib/x.dart":   0,
+	} {
+		if got := baseline.packageOf(name); got != expected {
+			t.Errorf("packageOf(%q) = %d, want %d", name, got, expected)
+		}
+	}
+	if got := (&scanBaseline{Packages: []models.PackageScanResult{{Path: "packages/a"}}}).packageOf("tool/x.dart"); got != -1 {
+		t.Errorf("Expected a file outside every package to have none, got %d", got)
+	}
+}
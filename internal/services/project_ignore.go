@@ -0,0 +1,104 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ignoreRule is one pattern of an ignore file, relative to the directory holding the file
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// projectIgnore matches paths below a project root against the .gitignore and
+// .flutter-deprecations-ignore files of the root and its subdirectories, which share the
+// .gitignore syntax. The files of a directory are read the first time a path below it is matched.
+type projectIgnore struct {
+	root  string
+	rules map[string][]ignoreRule
+}
+
+// newProjectIgnore creates the ignore matcher of the project at root
+func newProjectIgnore(root string) *projectIgnore {
+	return &projectIgnore{root: root, rules: make(map[string][]ignoreRule)}
+}
+
+// ignored reports whether path, a file or a directory below the root, is ignored. As in git, the
+// last matching rule wins, the rules of a subdirectory come after those of its parents and a
+// ! rule re-includes what an earlier one ignored.
+func (p *projectIgnore) ignored(path string, dir bool) bool {
+	relative, err := filepath.Rel(p.root, path)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(relative), "/")
+
+	ignored := false
+	base := p.root
+	for i := range segments {
+		name := strings.Join(segments[i:], "/")
+		for _, rule := range p.load(base) {
+			if rule.dirOnly && !dir {
+				continue
+			}
+			if matchGlob(rule.pattern, name) {
+				ignored = !rule.negate
+			}
+		}
+		base = filepath.Join(base, segments[i])
+	}
+	return ignored
+}
+
+// load returns the rules of the ignore files in dir
+func (p *projectIgnore) load(dir string) []ignoreRule {
+	if rules, ok := p.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	for _, file := range []string{config.GITIGNORE_FILE, config.PROJECT_IGNORE_FILE} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, parseIgnoreRules(string(data))...)
+	}
+	p.rules[dir] = rules
+	return rules
+}
+
+// parseIgnoreRules parses the lines of an ignore file. A pattern without a slash before its end
+// matches at any depth, like build/ or *.g.dart; one with a slash is anchored to the file's directory.
+func parseIgnoreRules(content string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		rule.pattern = strings.TrimPrefix(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestProjectIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "# Generated\n/coverage/\n*.pb.dart\n!keep.pb.dart\nlib/l10n/gen/\ntmp\n")
+	writeFile(t, filepath.Join(root, ".flutter-deprecations-ignore"), "lib/legacy/**\n")
+	writeFile(t, filepath.Join(root, "linux", ".gitignore"), "flutter/ephemeral\n")
+
+	ignore := newProjectIgnore(root)
+	for _, tc := range []struct {
+		path string
+		dir  bool
+		want bool
+	}{
+		{"coverage", true, true},
+		{"lib/coverage", true, false},
+		{"coverage", false, false},
+		{"lib/api/user.pb.dart", false, true},
+		{"lib/api/keep.pb.dart", false, false},
+		{"lib/l10n/gen", true, true},
+		{"lib/tmp", false, true},
+		{"lib/legacy/old_page.dart", false, true},
+		{"lib/legacy", true, false},
+		{"linux/flutter/ephemeral", true, true},
+		{"flutter/ephemeral", true, false},
+		{"lib/main.dart", false, false},
+	} {
+		if got := ignore.ignored(filepath.Join(root, filepath.FromSlash(tc.path)), tc.dir); got != tc.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tc.path, tc.dir, got, tc.want)
+		}
+	}
+
+	t.Run("Skips ignored paths when listing Dart files", func(t *testing.T) {
+		for _, file := range []string{"lib/main.dart", "lib/api/user.pb.dart", "lib/api/keep.pb.dart", "lib/l10n/gen/messages.dart", "lib/legacy/old_page.dart", "linux/flutter/ephemeral/generated.dart"} {
+			writeFile(t, filepath.Join(root, filepath.FromSlash(file)), "RaisedButton()\n")
+		}
+
+		files, err := dartFiles(context.Background(), root)
+		if err != nil {
+			t.Fatalf("dartFiles failed: %v", err)
+		}
+		var names []string
+		for _, file := range files {
+			names = append(names, relativeSlash(root, file))
+		}
+		sort.Strings(names)
+		if strings.Join(names, ",") != "lib/api/keep.pb.dart,lib/main.dart" {
+			t.Errorf("Expected only the files that are not ignored, got %v", names)
+		}
+	})
+
+	t.Run("Applies the workspace root rules to its packages", func(t *testing.T) {
+		workspace := t.TempDir()
+		writeFile(t, filepath.Join(workspace, ".gitignore"), "packages/*/lib/gen/\nscratch/\n")
+		writeFile(t, filepath.Join(workspace, "packages", "ui", "pubspec.yaml"), "name: ui\n")
+		writeFile(t, filepath.Join(workspace, "packages", "ui", "lib", "ui.dart"), "Text('fine')\n")
+		writeFile(t, filepath.Join(workspace, "packages", "ui", "lib", "gen", "icons.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(workspace, "packages", "scratch", "pubspec.yaml"), "name: scratch\n")
+
+		cache := &CacheService{dir: t.TempDir()}
+		result, err := NewProjectScanService(NewDeprecationService(cache, NewFlutterAPIService())).Scan(context.Background(), workspace, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if len(result.Packages) != 1 || result.Packages[0].Name != "ui" || result.Packages[0].Files != 1 || len(result.Packages[0].Findings) != 0 {
+			t.Errorf("Expected the ui package without its generated files and no scratch package, got %+v", result.Packages)
+		}
+	})
+}
+
+func TestParseIgnoreRules(t *testing.T) {
+	rules := parseIgnoreRules("\n# comment\nbuild/\n!/lib/keep.dart\n\\#notes.dart\ndocs/*.dart  \r\n/\n")
+	want := []ignoreRule{
+		{pattern: "**/build", dirOnly: true},
+		{pattern: "lib/keep.dart", negate: true},
+		{pattern: "**/#notes.dart"},
+		{pattern: "docs/*.dart"},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("Expected %d rules, got %+v", len(want), rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("Rule %d: expected %+v, got %+v", i, want[i], rules[i])
+		}
+	}
+}
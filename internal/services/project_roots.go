@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ProjectRootService finds the Flutter project the user works on under the roots the MCP client
+// exposes, so that project tools can default to it
+type ProjectRootService struct {
+	lister RootListerInterface
+}
+
+// NewProjectRootService creates a new project root service instance
+func NewProjectRootService(lister RootListerInterface) *ProjectRootService {
+	return &ProjectRootService{lister: lister}
+}
+
+// ActiveProject returns the first Flutter project or workspace found under the roots of the
+// client, taking the roots in the order the client lists them and, within a root, the project
+// nearest to it
+func (p *ProjectRootService) ActiveProject(ctx context.Context) (string, error) {
+	if !p.lister.RootsSupported() {
+		return "", fmt.Errorf("the connected MCP client does not expose roots")
+	}
+	roots, err := p.lister.Roots(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(roots) == 0 {
+		return "", fmt.Errorf("the MCP client exposes no file roots")
+	}
+	for _, root := range roots {
+		if project := findFlutterProject(ctx, root, config.ROOT_SEARCH_DEPTH); project != "" {
+			return project, nil
+		}
+	}
+	return "", fmt.Errorf("no Flutter project found under the roots of the client (%s)", strings.Join(roots, ", "))
+}
+
+// findFlutterProject searches root and up to depth levels of directories below it, level by
+// level, for a Flutter project or workspace, returning "" when there is none
+func findFlutterProject(ctx context.Context, root string, depth int) string {
+	level := []string{root}
+	for i := 0; i <= depth && len(level) > 0 && ctx.Err() == nil; i++ {
+		var next []string
+		for _, dir := range level {
+			if isFlutterProject(dir) {
+				return dir
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if entry.IsDir() && !skippedProjectDirs[entry.Name()] && !strings.HasPrefix(entry.Name(), ".") {
+					next = append(next, filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+		sort.Strings(next)
+		level = next
+	}
+	return ""
+}
+
+// isFlutterProject reports whether dir holds a pubspec.yaml that depends on Flutter or declares a
+// pub workspace, or a melos workspace
+func isFlutterProject(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, config.MELOS_FILE)); err == nil {
+		return true
+	}
+	spec, err := readPubspec(dir)
+	if err != nil {
+		return false
+	}
+	_, flutter := spec.Dependencies["flutter"]
+	return flutter || len(spec.Workspace) > 0
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// staticRoots exposes a fixed list of roots
+type staticRoots struct {
+	supported bool
+	roots     []string
+	err       error
+}
+
+func (s *staticRoots) RootsSupported() bool { return s.supported }
+
+func (s *staticRoots) Roots(ctx context.Context) ([]string, error) { return s.roots, s.err }
+
+func TestProjectRootService(t *testing.T) {
+	ctx := context.Background()
+	flutterApp := "name: app\ndependencies:\n  flutter:\n    sdk: flutter\n"
+
+	t.Run("Finds the Flutter project nearest to the roots", func(t *testing.T) {
+		empty, repo := t.TempDir(), t.TempDir()
+		writeFile(t, filepath.Join(repo, "pubspec.yaml"), "name: tool\ndependencies:\n  args: ^2.0.0\n")
+		writeFile(t, filepath.Join(repo, "apps", "z_app", "pubspec.yaml"), flutterApp)
+		writeFile(t, filepath.Join(repo, "apps", "z_app", "example", "pubspec.yaml"), flutterApp)
+		writeFile(t, filepath.Join(repo, "b_app", "pubspec.yaml"), flutterApp)
+		writeFile(t, filepath.Join(repo, ".dart_tool", "pubspec.yaml"), flutterApp)
+
+		project, err := NewProjectRootService(&staticRoots{supported: true, roots: []string{empty, repo}}).ActiveProject(ctx)
+		if err != nil || project != filepath.Join(repo, "b_app") {
+			t.Errorf("Expected the shallowest Flutter project of the second root, got %q, %v", project, err)
+		}
+	})
+
+	t.Run("Takes workspace roots as projects", func(t *testing.T) {
+		melos, pub := t.TempDir(), t.TempDir()
+		writeFile(t, filepath.Join(melos, "melos.yaml"), "name: mono\npackages:\n  - packages/*\n")
+		writeFile(t, filepath.Join(melos, "packages", "a", "pubspec.yaml"), flutterApp)
+		writeFile(t, filepath.Join(pub, "pubspec.yaml"), "name: root\nworkspace:\n  - packages/a\n")
+
+		for _, root := range []string{melos, pub} {
+			if project, err := NewProjectRootService(&staticRoots{supported: true, roots: []string{root}}).ActiveProject(ctx); err != nil || project != root {
+				t.Errorf("Expected the workspace root %s, got %q, %v", root, project, err)
+			}
+		}
+	})
+
+	t.Run("Explains why no project was found", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "a", "b", "c", "d", "pubspec.yaml"), flutterApp)
+		for _, lister := range []*staticRoots{
+			{supported: false},
+			{supported: true, err: fmt.Errorf("roots request failed")},
+			{supported: true, roots: []string{}},
+			{supported: true, roots: []string{root}},
+		} {
+			if project, err := NewProjectRootService(lister).ActiveProject(ctx); err == nil || project != "" {
+				t.Errorf("Expected no project for %+v, got %q", lister, project)
+			}
+		}
+		if _, err := NewProjectRootService(&staticRoots{supported: true, roots: []string{root}}).ActiveProject(ctx); err == nil || !strings.Contains(err.Error(), root) {
+			t.Errorf("Expected the searched roots to be named, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// melosConfig holds the parts of a melos.yaml that select the packages of a workspace
+type melosConfig struct {
+	Packages []string `yaml:"packages"`
+	Ignore   []string `yaml:"ignore"`
+}
+
+// ProjectScanService checks every Dart file of a local project for deprecated APIs. A melos or pub
+// workspace, or a monorepo with a packages directory, is split into its packages so that each
+// gets its own results.
+type ProjectScanService struct {
+	deprecations DeprecationServiceInterface
+	mu           sync.Mutex
+	resultsPath  string
+	baselineDir  string
+	execTimeout  time.Duration
+}
+
+// NewProjectScanService creates a new project scan service instance. The scan cache is keyed by the
+// fingerprint of the rules deprecations checks with.
+func NewProjectScanService(deprecations DeprecationServiceInterface) *ProjectScanService {
+	return &ProjectScanService{deprecations: deprecations, execTimeout: config.DEFAULT_EXEC_TIMEOUT}
+}
+
+// SetScanCache keeps the results of each scanned file at path, keyed by the file's contents, so that
+// later scans only check the files that changed. An empty path, the default, disables the cache.
+func (p *ProjectScanService) SetScanCache(path string) {
+	p.resultsPath = path
+}
+
+// SetBaselineDir keeps the last full scan of each git project in dir, which ScanChanged builds on.
+// An empty dir, the default, makes every ScanChanged a full scan.
+func (p *ProjectScanService) SetBaselineDir(dir string) {
+	p.baselineDir = dir
+}
+
+// SetExecTimeout kills git commands that run longer than timeout
+func (p *ProjectScanService) SetExecTimeout(timeout time.Duration) {
+	p.execTimeout = timeout
+}
+
+// Scan checks the Dart files of the project at projectPath, package by package. target selects
+// the Flutter (default) or Dart checks and filter the files checked. The root package is only
+// reported in a workspace when it has Dart files of its own.
+func (p *ProjectScanService) Scan(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.ProjectScanResult, error) {
+	return p.scan(ctx, projectPath, target, filter, false)
+}
+
+// ScanChanged scans a git project like Scan, but only checks the Dart files git reports as
+// modified, added or untracked since the last full scan, reusing its results for the others.
+// Without a full scan to build on, or when the workspace layout or ignore files changed, it
+// checks every file and says why in FullScanReason.
+func (p *ProjectScanService) ScanChanged(ctx context.Context, projectPath string, target string, filter models.PathFilter) (*models.ProjectScanResult, error) {
+	return p.scan(ctx, projectPath, target, filter, true)
+}
+
+func (p *ProjectScanService) scan(ctx context.Context, projectPath string, target string, filter models.PathFilter, incremental bool) (*models.ProjectScanResult, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := newPathFilter(projectPath, filter)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", projectPath)
+	}
+
+	// Only git projects get a baseline, as only git can tell an incremental scan what changed
+	commit, gitErr := gitHead(ctx, p.execTimeout, projectPath)
+	if incremental && gitErr != nil {
+		return nil, fmt.Errorf("incremental scans need a git repository: %v", gitErr)
+	}
+	baselinePath := ""
+	if p.baselineDir != "" && gitErr == nil {
+		baselinePath = filepath.Join(p.baselineDir, baselineKey(projectPath, target, paths)+".json")
+	}
+
+	opts := walkOptions{ignore: newProjectIgnore(projectPath), filter: paths}
+	checker := p.newFileChecker(target)
+	var baseline *scanBaseline
+	reason := ""
+	if incremental {
+		baseline, reason = p.rescanChanged(ctx, projectPath, baselinePath, opts, checker)
+	}
+	if baseline == nil {
+		if baseline, err = p.scanAll(ctx, projectPath, opts, checker); err != nil {
+			return nil, err
+		}
+		baseline.Commit = commit
+		if baselinePath != "" {
+			p.mu.Lock()
+			err := storeScanBaseline(baselinePath, baseline)
+			p.mu.Unlock()
+			if err != nil {
+				slog.Warn("Could not save the project scan baseline", "path", baselinePath, "error", err)
+			}
+		}
+	}
+	p.saveFileChecker(checker)
+
+	result := baseline.report(projectPath)
+	result.Cached = checker.cached
+	if incremental {
+		if reason != "" {
+			result.FullScanReason = reason
+		} else {
+			result.BaseCommit, result.Changed = baseline.Commit, checker.checked
+		}
+	}
+	return result, nil
+}
+
+// scanAll checks every Dart file of the project, package by package
+func (p *ProjectScanService) scanAll(ctx context.Context, projectPath string, opts walkOptions, checker *fileChecker) (*scanBaseline, error) {
+	workspace, packageDirs, err := discoverPackages(ctx, projectPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool, len(packageDirs))
+	for _, dir := range packageDirs {
+		members[dir] = true
+	}
+	opts.skip = func(dir string) bool { return members[dir] }
+
+	baseline := &scanBaseline{Fingerprint: checker.fingerprint, Workspace: workspace, Files: make(map[string]scannedFile)}
+	for _, dir := range append([]string{projectPath}, packageDirs...) {
+		files, err := walkDartFiles(ctx, dir, opts)
+		if err != nil {
+			return nil, err
+		}
+		if dir == projectPath && workspace != "" && len(files) == 0 {
+			continue
+		}
+
+		pkg := models.PackageScanResult{Name: filepath.Base(dir), Path: relativeSlash(projectPath, dir)}
+		if spec, err := readPubspec(dir); err == nil && spec.Name != "" {
+			pkg.Name = spec.Name
+		}
+		for _, file := range files {
+			baseline.Files[relativeSlash(projectPath, file)] = checker.checkFile(ctx, file, len(baseline.Packages))
+		}
+		baseline.Packages = append(baseline.Packages, pkg)
+	}
+	return baseline, nil
+}
+
+// rescanChanged loads the stored full scan of the project and checks the files changed since
+// its commit again. It returns no baseline, and why, when a full scan is needed instead.
+func (p *ProjectScanService) rescanChanged(ctx context.Context, projectPath string, baselinePath string, opts walkOptions, checker *fileChecker) (*scanBaseline, string) {
+	if baselinePath == "" {
+		return nil, "full scan results are not kept on this server"
+	}
+	p.mu.Lock()
+	baseline := loadScanBaseline(baselinePath, checker.fingerprint)
+	p.mu.Unlock()
+	if baseline == nil {
+		return nil, "no earlier full scan with the current rules and scope"
+	}
+
+	changed, err := gitChangedFiles(ctx, p.execTimeout, projectPath, baseline.Commit)
+	if err != nil {
+		return nil, fmt.Sprintf("git could not list the changes since %s: %v", ShortCommit(baseline.Commit), err)
+	}
+	for _, name := range changed {
+		if layoutFiles[path.Base(name)] {
+			return nil, fmt.Sprintf("%s changed since the last full scan", name)
+		}
+	}
+
+	for _, name := range changed {
+		delete(baseline.Files, name)
+		file := filepath.Join(projectPath, filepath.FromSlash(name))
+		if !opts.selects(projectPath, file) {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			// Deleted since the last full scan
+			continue
+		}
+		pkg := baseline.packageOf(name)
+		if pkg < 0 {
+			return nil, fmt.Sprintf("%s is outside the packages of the last full scan", name)
+		}
+		baseline.Files[name] = checker.checkFile(ctx, file, pkg)
+	}
+	return baseline, ""
+}
+
+// fileChecker checks the files of one scan, reusing the results of the scan cache
+type fileChecker struct {
+	check       func(code string) []models.Deprecation
+	fingerprint string
+	results     *scanCache
+	used        map[string][]models.Deprecation
+	checked     int
+	cached      int
+}
+
+// newFileChecker creates the checker of a scan for target, loading the scan cache when it is enabled
+func (p *ProjectScanService) newFileChecker(target string) *fileChecker {
+	checker := &fileChecker{check: p.deprecations.CheckCodeForDeprecations, used: make(map[string][]models.Deprecation)}
+	if target == config.TARGET_DART {
+		checker.check = p.deprecations.CheckDartCode
+	}
+	checker.fingerprint = p.deprecations.RulesFingerprint(target)
+	if p.resultsPath != "" {
+		p.mu.Lock()
+		checker.results = loadScanCache(p.resultsPath, checker.fingerprint)
+		p.mu.Unlock()
+	}
+	return checker
+}
+
+// saveFileChecker adds the results of a scan to the scan cache when it is enabled
+func (p *ProjectScanService) saveFileChecker(checker *fileChecker) {
+	if checker.results == nil {
+		return
+	}
+	p.mu.Lock()
+	err := storeScanCache(p.resultsPath, checker.results, checker.used)
+	p.mu.Unlock()
+	if err != nil {
+		slog.Warn("Could not save the scan cache", "path", p.resultsPath, "error", err)
+	}
+}
+
+// checkFile checks one file of the package with index pkg
+func (c *fileChecker) checkFile(ctx context.Context, file string, pkg int) scannedFile {
+	c.checked++
+	scanned := scannedFile{Package: pkg}
+	if err := fileScanSlots.acquire(ctx); err != nil {
+		scanned.Error = err.Error()
+		return scanned
+	}
+	defer fileScanSlots.release()
+
+	code, err := readCheckFile(file, "")
+	if err != nil {
+		scanned.Error = err.Error()
+		return scanned
+	}
+
+	key := contentHash(code)
+	deprecations, cached := c.results.lookup(key)
+	if cached {
+		c.cached++
+	} else {
+		deprecations = c.check(code)
+	}
+	c.used[key] = deprecations
+	scanned.Deprecations = deprecations
+	return scanned
+}
+
+// discoverPackages finds the package directories of a workspace rooted at projectPath, sorted by
+// path, and how they were found. A project that is not a workspace has none, and packages in
+// directories that opts skips are left out.
+func discoverPackages(ctx context.Context, projectPath string, opts walkOptions) (string, []string, error) {
+	workspace, patterns, excluded := "", []string(nil), []string(nil)
+	if data, err := os.ReadFile(filepath.Join(projectPath, config.MELOS_FILE)); err == nil {
+		var melos melosConfig
+		if err := yaml.Unmarshal(data, &melos); err != nil {
+			return "", nil, fmt.Errorf("invalid %s: %v", config.MELOS_FILE, err)
+		}
+		workspace, patterns, excluded = config.WORKSPACE_MELOS, melos.Packages, melos.Ignore
+	}
+	if len(patterns) == 0 {
+		if spec, err := readPubspec(projectPath); err == nil && len(spec.Workspace) > 0 {
+			workspace, patterns = config.WORKSPACE_PUB, spec.Workspace
+		}
+	}
+	if len(patterns) == 0 {
+		workspace, patterns = config.WORKSPACE_PACKAGES, []string{config.DEFAULT_WORKSPACE_PACKAGES}
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(projectPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.IsDir() || path == projectPath {
+			return nil
+		}
+		if skippedProjectDirs[entry.Name()] || opts.skips(path) {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, "pubspec.yaml")); err != nil {
+			return nil
+		}
+		relative := relativeSlash(projectPath, path)
+		if matchesAnyGlob(patterns, relative) && !matchesAnyGlob(excluded, relative) {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(dirs) == 0 && workspace == config.WORKSPACE_PACKAGES {
+		workspace = ""
+	}
+	sort.Strings(dirs)
+	return workspace, dirs, nil
+}
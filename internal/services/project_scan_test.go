@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestProjectScan(t *testing.T) {
+	cache := &CacheService{dir: t.TempDir()}
+	service := NewProjectScanService(NewDeprecationService(cache, NewFlutterAPIService()))
+	ctx := context.Background()
+
+	packageNames := func(result *models.ProjectScanResult) []string {
+		var names []string
+		for _, pkg := range result.Packages {
+			names = append(names, pkg.Name+"@"+pkg.Path)
+		}
+		return names
+	}
+
+	t.Run("Splits a melos workspace into its packages", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "melos.yaml"), "name: shop\npackages:\n  - apps/*\n  - packages/**\nignore:\n  - packages/**/example\n")
+		writeFile(t, filepath.Join(root, "pubspec.yaml"), "name: shop_workspace\n")
+		writeFile(t, filepath.Join(root, "apps", "shop", "pubspec.yaml"), "name: shop\n")
+		writeFile(t, filepath.Join(root, "apps", "shop", "lib", "main.dart"), "RaisedButton(onPressed: buy)\n")
+		writeFile(t, filepath.Join(root, "apps", "shop", "build", "old.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "pubspec.yaml"), "name: shop_ui\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "lib", "theme.dart"), "Color.red.withOpacity(0.5)\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "lib", "fine.dart"), "Text('fine')\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "example", "pubspec.yaml"), "name: example\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "example", "main.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(root, "packages", "core", "pubspec.yaml"), "name: core\n")
+		writeFile(t, filepath.Join(root, "packages", "core", "lib", "core.dart"), "void main() {}\n")
+
+		result, err := service.Scan(ctx, root, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if result.Workspace != config.WORKSPACE_MELOS {
+			t.Errorf("Expected a melos workspace, got %q", result.Workspace)
+		}
+		names := packageNames(result)
+		if len(names) != 3 || names[0] != "shop@apps/shop" || names[1] != "core@packages/core" || names[2] != "shop_ui@packages/ui" {
+			t.Fatalf("Expected the three packages without the root and the ignored example, got %v", names)
+		}
+
+		shop, ui := result.Packages[0], result.Packages[2]
+		if shop.Files != 1 || len(shop.Findings) != 1 || shop.Findings[0].Name != "apps/shop/lib/main.dart" || shop.Findings[0].Deprecations[0].API != "RaisedButton" {
+			t.Errorf("Expected RaisedButton in apps/shop/lib/main.dart only, got %+v", shop)
+		}
+		// The ignored example is no package of its own, so its files count for the package around it
+		if ui.Files != 3 || len(ui.Findings) != 2 {
+			t.Errorf("Expected 3 files with 2 findings in shop_ui, got %+v", ui)
+		}
+		if result.Packages[1].Files != 1 || len(result.Packages[1].Findings) != 0 {
+			t.Errorf("Expected no findings in core, got %+v", result.Packages[1])
+		}
+	})
+
+	t.Run("Reads the members of a pub workspace", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "pubspec.yaml"), "name: app\nworkspace:\n  - ./packages/api\n")
+		writeFile(t, filepath.Join(root, "lib", "main.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(root, "packages", "api", "pubspec.yaml"), "name: api\n")
+		writeFile(t, filepath.Join(root, "packages", "api", "lib", "api.dart"), "Color.red.withOpacity(0.5)\n")
+		writeFile(t, filepath.Join(root, "packages", "legacy", "pubspec.yaml"), "name: legacy\n")
+
+		result, err := service.Scan(ctx, root, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		names := packageNames(result)
+		if result.Workspace != config.WORKSPACE_PUB || len(names) != 2 || names[0] != "app@." || names[1] != "api@packages/api" {
+			t.Fatalf("Expected the root and the api member, got %q and %v", result.Workspace, names)
+		}
+		if result.Packages[0].Files != 1 || result.Packages[1].Findings[0].Deprecations[0].API != "Color.withOpacity" {
+			t.Errorf("Expected each file in its own package, got %+v", result.Packages)
+		}
+	})
+
+	t.Run("Falls back to the packages directory and single packages", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "packages", "a", "pubspec.yaml"), "name: a\n")
+		writeFile(t, filepath.Join(root, "packages", "b", "pubspec.yaml"), "name: b\n")
+		result, err := service.Scan(ctx, root, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if names := packageNames(result); result.Workspace != config.WORKSPACE_PACKAGES || len(names) != 2 {
+			t.Errorf("Expected packages a and b, got %q and %v", result.Workspace, names)
+		}
+
+		single := t.TempDir()
+		writeFile(t, filepath.Join(single, "pubspec.yaml"), "name: solo\n")
+		writeFile(t, filepath.Join(single, "example", "pubspec.yaml"), "name: solo_example\n")
+		writeFile(t, filepath.Join(single, "example", "lib", "main.dart"), "RaisedButton()\n")
+		result, err = service.Scan(ctx, single, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if names := packageNames(result); result.Workspace != "" || len(names) != 1 || names[0] != "solo@." || result.Packages[0].Files != 1 {
+			t.Errorf("Expected one package including its example, got %q and %+v", result.Workspace, result.Packages)
+		}
+
+		if _, err := service.Scan(ctx, filepath.Join(single, "pubspec.yaml"), "", models.PathFilter{}); err == nil {
+			t.Error("Expected an error for a file")
+		}
+		if _, err := service.Scan(ctx, single, "kotlin", models.PathFilter{}); err == nil {
+			t.Error("Expected an error for an unknown target")
+		}
+	})
+
+	t.Run("Scopes the scan with include and exclude globs", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "packages", "ui", "pubspec.yaml"), "name: ui\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "lib", "ui.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "lib", "generated", "icons.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(root, "packages", "ui", "test", "ui_test.dart"), "RaisedButton()\n")
+		writeFile(t, filepath.Join(root, "packages", "legacy", "pubspec.yaml"), "name: legacy\n")
+		writeFile(t, filepath.Join(root, "packages", "legacy", "lib", "old.dart"), "RaisedButton()\n")
+
+		result, err := service.Scan(ctx, root, "", models.PathFilter{
+			Include: []string{"packages/*/lib/**"},
+			Exclude: []string{"**/lib/generated/**", "packages/legacy"},
+		})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if names := packageNames(result); len(names) != 1 || result.Packages[0].Files != 1 || len(result.Packages[0].Findings) != 1 {
+			t.Errorf("Expected packages/ui/lib/ui.dart only, got %+v", result.Packages)
+		}
+
+		if _, err := service.Scan(ctx, root, "", models.PathFilter{Include: []string{"lib/[a"}}); err == nil {
+			t.Error("Expected an error for an invalid glob")
+		}
+	})
+
+	t.Run("Matches workspace globs", func(t *testing.T) {
+		for _, tc := range []struct {
+			pattern string
+			name    string
+			want    bool
+		}{
+			{"packages/*", "packages/ui", true},
+			{"packages/*", "packages/ui/example", false},
+			{"packages/**", "packages/ui/example", true},
+			{"packages/**", "packages", false},
+			{"**/example", "packages/ui/example", true},
+			{"./apps/shop/", "apps/shop", true},
+			{"apps/sh?p", "apps/shop", true},
+			{"apps/[", "apps/shop", false},
+		} {
+			if got := matchGlob(tc.pattern, tc.name); got != tc.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// Where a rate limit status was read from
+const (
+	RateLimitSourceEndpoint = "rate_limit endpoint"
+	RateLimitSourceResponse = "last GitHub API response"
+)
+
+// SetGitHubToken authenticates the GitHub API calls, raising their quota
+func (f *FlutterAPIService) SetGitHubToken(token string) {
+	f.githubToken = token
+}
+
+// RateLimitStatus reports the GitHub API quota. It asks the rate_limit endpoint, which does not
+// count against the quota, and falls back to the headers of the last GitHub API response when the
+// endpoint cannot be reached; the error is returned only when neither is available.
+func (f *FlutterAPIService) RateLimitStatus(ctx context.Context) (*models.RateLimitStatus, error) {
+	status, err := f.fetchRateLimit(ctx)
+	if err == nil {
+		return status, nil
+	}
+
+	f.rateMu.Lock()
+	defer f.rateMu.Unlock()
+	if f.rateLimit == nil {
+		return nil, err
+	}
+	last := *f.rateLimit
+	last.Error = err.Error()
+	return &last, nil
+}
+
+// fetchRateLimit reads the core quota from the rate_limit endpoint
+func (f *FlutterAPIService) fetchRateLimit(ctx context.Context) (*models.RateLimitStatus, error) {
+	resp, err := f.get(ctx, config.GITHUB_RATE_LIMIT_URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("GitHub rejected the token in %s (401)", config.GITHUB_TOKEN_ENV)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub rate_limit endpoint returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Used      int   `json:"used"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	core := body.Resources.Core
+	return &models.RateLimitStatus{
+		Authenticated: f.githubToken != "",
+		Limit:         core.Limit,
+		Remaining:     core.Remaining,
+		Used:          core.Used,
+		Reset:         time.Unix(core.Reset, 0),
+		Source:        RateLimitSourceEndpoint,
+		ObservedAt:    time.Now(),
+	}, nil
+}
+
+// observeRateLimit remembers the quota a GitHub API response reports in its headers
+func (f *FlutterAPIService) observeRateLimit(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	f.rateMu.Lock()
+	defer f.rateMu.Unlock()
+	f.rateLimit = &models.RateLimitStatus{
+		Authenticated: f.githubToken != "",
+		Limit:         limit,
+		Remaining:     remaining,
+		Used:          used,
+		Reset:         time.Unix(reset, 0),
+		Source:        RateLimitSourceResponse,
+		ObservedAt:    time.Now(),
+	}
+}
+
+// RateLimitError is returned when GitHub refuses a request because the API quota is used up. Reset
+// is when the quota resets, zero when the response did not say.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return "GitHub API rate limit exceeded. Please wait before retrying or set GITHUB_TOKEN"
+}
+
+// isRateLimited reports whether err comes from a request refused for the exhausted quota
+func isRateLimited(err error) bool {
+	var limited *RateLimitError
+	return errors.As(err, &limited)
+}
+
+// rateLimitReset reads the X-RateLimit-Reset header, returning zero when it is missing
+func rateLimitReset(header http.Header) time.Time {
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(reset, 0)
+}
+
+// RateLimitBackoffError is returned instead of running an automatic update while an earlier one
+// waits for the GitHub quota to reset
+type RateLimitBackoffError struct {
+	Until time.Time
+}
+
+func (e *RateLimitBackoffError) Error() string {
+	return fmt.Sprintf("skipped until the GitHub rate limit resets at %s", e.Until.Format("2006-01-02 15:04:05"))
+}
+
+// rateLimitBackoff is the content of config.RATE_LIMIT_BACKOFF_FILE
+type rateLimitBackoff struct {
+	Until      time.Time `json:"until"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// UpdateUnlessRateLimited runs an automatic update, such as the one at startup, unless an earlier
+// one ran into the GitHub rate limit and the quota has not reset yet, in which case it returns a
+// RateLimitBackoffError. When update is rate limited, the reset time is recorded in dir so that
+// the next server started before it does not try again; a successful update clears it.
+func UpdateUnlessRateLimited(ctx context.Context, dir string, update func(context.Context) error) error {
+	path := filepath.Join(dir, config.RATE_LIMIT_BACKOFF_FILE)
+	if data, err := os.ReadFile(path); err == nil {
+		var backoff rateLimitBackoff
+		if json.Unmarshal(data, &backoff) == nil && time.Now().Before(backoff.Until) {
+			return &RateLimitBackoffError{Until: backoff.Until}
+		}
+	}
+
+	err := update(ctx)
+	var limited *RateLimitError
+	switch {
+	case errors.As(err, &limited):
+		now := time.Now()
+		backoff := rateLimitBackoff{Until: limited.Reset, RecordedAt: now}
+		if !backoff.Until.After(now) {
+			backoff.Until = now.Add(config.RATE_LIMIT_BACKOFF)
+		}
+		data, _ := json.MarshalIndent(backoff, "", "  ")
+		if err := os.MkdirAll(dir, 0755); err == nil {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				slog.Warn("Failed to record the rate limit backoff", "error", err)
+			}
+		}
+	case err == nil:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to clear the rate limit backoff", "error", err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestRateLimitStatus(t *testing.T) {
+	endpointUp := true
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/rate_limit" && endpointUp:
+			w.Write([]byte(`{"resources": {"core": {"limit": 5000, "remaining": 4990, "used": 10, "reset": 1792000000}, "search": {"limit": 30}}}`))
+		case r.URL.Path == "/repos/flutter/flutter/releases":
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Used", "60")
+			w.Header().Set("X-RateLimit-Reset", "1792000000")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded for 10.0.0.1."}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	t.Run("Reads the quota from the rate_limit endpoint", func(t *testing.T) {
+		service := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+		service.SetGitHubToken("ghp_test")
+
+		status, err := service.RateLimitStatus(ctx)
+		if err != nil {
+			t.Fatalf("RateLimitStatus failed: %v", err)
+		}
+		if !status.Authenticated || status.Limit != 5000 || status.Remaining != 4990 || status.Used != 10 || status.Reset.Unix() != 1792000000 {
+			t.Errorf("Expected the core quota of an authenticated client, got %+v", status)
+		}
+		if status.Source != RateLimitSourceEndpoint || authorization != "Bearer ghp_test" {
+			t.Errorf("Expected the token to be sent to the endpoint, got %q from %s", authorization, status.Source)
+		}
+	})
+
+	t.Run("Falls back to the last response headers", func(t *testing.T) {
+		endpointUp = false
+		defer func() { endpointUp = true }()
+		service := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+
+		if _, err := service.RateLimitStatus(ctx); err == nil {
+			t.Error("Expected an error before any GitHub response was seen")
+		}
+
+		_, err := service.FetchReleases(ctx)
+		var limited *RateLimitError
+		if !errors.As(err, &limited) || limited.Reset.Unix() != 1792000000 {
+			t.Fatalf("Expected the exhausted quota to fail the releases call with its reset time, got %v", err)
+		}
+		if authorization != "" {
+			t.Errorf("Expected no token without GITHUB_TOKEN, got %q", authorization)
+		}
+		status, err := service.RateLimitStatus(ctx)
+		if err != nil {
+			t.Fatalf("RateLimitStatus failed: %v", err)
+		}
+		if status.Authenticated || status.Limit != 60 || status.Remaining != 0 || status.Source != RateLimitSourceResponse || status.Error == "" {
+			t.Errorf("Expected the exhausted anonymous quota from the headers, got %+v", status)
+		}
+	})
+}
+
+func TestUpdateUnlessRateLimited(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	updates := 0
+	limited := func(ctx context.Context) error {
+		updates++
+		return fmt.Errorf("failed to fetch source deprecations: %w", &RateLimitError{Reset: reset})
+	}
+	succeeding := func(ctx context.Context) error {
+		updates++
+		return nil
+	}
+
+	if err := UpdateUnlessRateLimited(ctx, dir, limited); !isRateLimited(err) {
+		t.Fatalf("Expected the rate limit error, got %v", err)
+	}
+
+	// The next run, such as after a restart, waits for the reset
+	err := UpdateUnlessRateLimited(ctx, dir, succeeding)
+	var backoff *RateLimitBackoffError
+	if !errors.As(err, &backoff) || !backoff.Until.Equal(reset) || updates != 1 {
+		t.Fatalf("Expected the update to be skipped until %s, got %v after %d updates", reset, err, updates)
+	}
+
+	// Once the quota has reset the update runs again and a success clears the backoff
+	writeFile(t, filepath.Join(dir, config.RATE_LIMIT_BACKOFF_FILE), `{"until": "2020-01-01T00:00:00Z"}`)
+	if err := UpdateUnlessRateLimited(ctx, dir, succeeding); err != nil || updates != 2 {
+		t.Fatalf("Expected the update to run after the reset, got %v after %d updates", err, updates)
+	}
+	if _, err := os.Stat(filepath.Join(dir, config.RATE_LIMIT_BACKOFF_FILE)); !os.IsNotExist(err) {
+		t.Errorf("Expected the backoff to be cleared, got %v", err)
+	}
+
+	// Without a reset time the default backoff applies
+	UpdateUnlessRateLimited(ctx, dir, func(ctx context.Context) error { return &RateLimitError{} })
+	if err := UpdateUnlessRateLimited(ctx, dir, succeeding); !errors.As(err, &backoff) || backoff.Until.Before(time.Now().Add(config.RATE_LIMIT_BACKOFF-time.Minute)) {
+		t.Errorf("Expected the default backoff, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ReleaseHistoryService lists the past stable Flutter releases with their release dates and Dart
+// SDK versions, from the official releases API or, when it is unavailable, the GitHub releases
+type ReleaseHistoryService struct {
+	apiService FlutterAPIServiceInterface
+}
+
+// NewReleaseHistoryService creates a new release history service instance
+func NewReleaseHistoryService(apiService FlutterAPIServiceInterface) *ReleaseHistoryService {
+	return &ReleaseHistoryService{apiService: apiService}
+}
+
+// StableReleases returns the limit most recent stable releases, newest first. A since version
+// such as 3.22 leaves out the releases before it; limit 0 lists every release.
+func (r *ReleaseHistoryService) StableReleases(ctx context.Context, limit int, since string) (*models.ReleaseHistory, error) {
+	var from semanticVersion
+	if since = strings.TrimSpace(since); since != "" {
+		parsed, ok := parseVersion(since)
+		if !ok {
+			return nil, fmt.Errorf("invalid version %q", since)
+		}
+		from = semanticVersion{Major: parsed.Major, Minor: parsed.Minor, Patch: parsed.Patch}
+	}
+
+	history, officialErr := r.officialHistory(ctx)
+	if officialErr != nil {
+		var githubErr error
+		if history, githubErr = r.githubHistory(ctx); githubErr != nil {
+			return nil, fmt.Errorf("official releases API: %v; GitHub: %v", officialErr, githubErr)
+		}
+	}
+
+	releases := history.Releases[:0]
+	for _, release := range history.Releases {
+		version, ok := parseStableVersion(release.Version)
+		if ok && from.Compare(version) <= 0 {
+			releases = append(releases, release)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].ReleaseDate.After(releases[j].ReleaseDate)
+	})
+
+	history.Total = len(releases)
+	if limit > 0 && len(releases) > limit {
+		releases = releases[:limit]
+	}
+	history.Releases = releases
+	return history, nil
+}
+
+// officialHistory reads the stable releases from the official releases API, keeping the first
+// entry of a version that is listed more than once
+func (r *ReleaseHistoryService) officialHistory(ctx context.Context) (*models.ReleaseHistory, error) {
+	official, err := r.apiService.FetchOfficialReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.ReleaseHistory{Source: config.VERSION_SOURCE_OFFICIAL}
+	if latest, ok := latestOfficialStable(official); ok {
+		history.Latest = latest.Version
+	}
+	seen := make(map[string]bool)
+	for _, release := range official.Releases {
+		if release.Channel != config.FLUTTER_CHANNEL_STABLE || seen[release.Version] {
+			continue
+		}
+		seen[release.Version] = true
+		date, _ := time.Parse(time.RFC3339, release.ReleaseDate)
+		history.Releases = append(history.Releases, models.StableRelease{
+			Version:        release.Version,
+			ReleaseDate:    date,
+			DartSDKVersion: release.DartSDKVersion,
+			Hash:           release.Hash,
+		})
+	}
+	if len(history.Releases) == 0 {
+		return nil, fmt.Errorf("no stable releases listed")
+	}
+	return history, nil
+}
+
+// githubHistory reads the stable releases from the GitHub releases, dated by their publication
+func (r *ReleaseHistoryService) githubHistory(ctx context.Context) (*models.ReleaseHistory, error) {
+	releases, err := r.apiService.FetchReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.ReleaseHistory{Source: config.VERSION_SOURCE_GITHUB}
+	history.Latest, _ = latestGitHubStable(releases)
+	for _, release := range releases {
+		if release.Prerelease {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, release.PublishedAt)
+		history.Releases = append(history.Releases, models.StableRelease{
+			Version:     r.apiService.ParseVersionFromRelease(release),
+			ReleaseDate: date,
+		})
+	}
+	return history, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestReleaseHistoryService(t *testing.T) {
+	official := &models.FlutterReleasesResponse{Releases: []models.FlutterOfficialRelease{
+		{Hash: "d4", Channel: "beta", Version: "3.28.0-0.1.pre", ReleaseDate: "2024-12-20T10:00:00.000Z"},
+		{Hash: "c3", Channel: "stable", Version: "3.27.1", DartSDKVersion: "3.6.0", ReleaseDate: "2024-12-17T18:13:35.923987Z"},
+		{Hash: "b2", Channel: "stable", Version: "3.27.0", DartSDKVersion: "3.6.0", ReleaseDate: "2024-12-11T10:00:00.000Z"},
+		{Hash: "a1", Channel: "stable", Version: "3.24.5", DartSDKVersion: "3.5.4", ReleaseDate: "2024-11-18T10:00:00.000Z"},
+	}}
+	official.CurrentRelease.Stable = "c3"
+	ctx := context.Background()
+
+	t.Run("Lists the official stable releases newest first", func(t *testing.T) {
+		service := NewReleaseHistoryService(&MockFlutterAPIService{officialReleases: official})
+		history, err := service.StableReleases(ctx, 2, "")
+		if err != nil {
+			t.Fatalf("StableReleases failed: %v", err)
+		}
+		if history.Source != "official" || history.Latest != "3.27.1" || history.Total != 3 || len(history.Releases) != 2 {
+			t.Fatalf("Expected 2 of 3 official stable releases, got %+v", history)
+		}
+		if first := history.Releases[0]; first.Version != "3.27.1" || first.DartSDKVersion != "3.6.0" || first.ReleaseDate.Format("2006-01-02") != "2024-12-17" {
+			t.Errorf("Expected 3.27.1 with its Dart SDK and date, got %+v", first)
+		}
+
+		history, err = service.StableReleases(ctx, 0, "3.27")
+		if err != nil {
+			t.Fatalf("StableReleases failed: %v", err)
+		}
+		if history.Total != 2 || history.Releases[1].Version != "3.27.0" {
+			t.Errorf("Expected the 3.27 releases only, got %+v", history.Releases)
+		}
+		if _, err := service.StableReleases(ctx, 0, "latest"); err == nil {
+			t.Error("Expected an error for an invalid since version")
+		}
+	})
+
+	t.Run("Falls back to the GitHub releases", func(t *testing.T) {
+		service := NewReleaseHistoryService(&MockFlutterAPIService{releases: []models.FlutterRelease{
+			{TagName: "3.28.0-0.1.pre", Prerelease: true, PublishedAt: "2024-12-20T10:00:00Z"},
+			{TagName: "v3.27.1", PublishedAt: "2024-12-17T10:00:00Z"},
+			{TagName: "3.27.0-rc.2", PublishedAt: "2024-12-05T10:00:00Z"},
+		}})
+		history, err := service.StableReleases(ctx, 0, "")
+		if err != nil {
+			t.Fatalf("StableReleases failed: %v", err)
+		}
+		if history.Source != "github" || history.Latest != "3.27.1" || len(history.Releases) != 1 || history.Releases[0].DartSDKVersion != "" {
+			t.Errorf("Expected the one stable GitHub release without a Dart SDK, got %+v", history)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// replacementSystemPrompt tells the client model what a drafted replacement has to look like
+const replacementSystemPrompt = "You help migrate Dart and Flutter code off deprecated APIs. Given a deprecated API, " +
+	"its deprecation message and the source around its @Deprecated annotation, answer with only the API to use " +
+	"instead, such as ElevatedButton or ColorScheme.surface, without explanation or formatting. Answer NONE when " +
+	"the message and the source do not point to a replacement."
+
+// draftedByUnknownModel records a draft of a client that did not name its model
+const draftedByUnknownModel = "MCP client model"
+
+// ReplacementDraftService asks the language model of the MCP client, through sampling, to draft
+// the replacements of scanned deprecations whose annotation names none
+type ReplacementDraftService struct {
+	cacheService CacheServiceInterface
+	apiService   *FlutterAPIService
+	sampler      SamplerInterface
+}
+
+// NewReplacementDraftService creates a new replacement draft service instance. apiService fetches
+// the source around the annotations and may be nil, in which case only the deprecation messages
+// are sent.
+func NewReplacementDraftService(cacheService CacheServiceInterface, apiService *FlutterAPIService, sampler SamplerInterface) *ReplacementDraftService {
+	return &ReplacementDraftService{cacheService: cacheService, apiService: apiService, sampler: sampler}
+}
+
+// DraftReplacements asks the client model about up to limit scanned deprecations without a
+// replacement, or only about api, and stores each replacement it drafts in the cache flagged with
+// the model that drafted it. A request the client fails is recorded in the draft instead of
+// failing the call.
+func (r *ReplacementDraftService) DraftReplacements(ctx context.Context, api string, limit int) (*models.ReplacementDrafts, error) {
+	if !r.sampler.SamplingSupported() {
+		return nil, fmt.Errorf("the connected MCP client does not support sampling")
+	}
+	if limit <= 0 {
+		limit = config.DEFAULT_REPLACEMENT_DRAFTS
+	}
+	limit = min(limit, config.MAX_REPLACEMENT_DRAFTS)
+	api = strings.TrimSpace(api)
+
+	cache, err := r.cacheService.Load()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.Deprecation
+	for _, dep := range cache.Deprecations {
+		if needsReplacementDraft(dep) && (api == "" || dep.API == api) {
+			candidates = append(candidates, dep)
+		}
+	}
+	if api != "" && len(candidates) == 0 {
+		return nil, fmt.Errorf("%s is not a scanned deprecation without a replacement", api)
+	}
+
+	result := &models.ReplacementDrafts{Drafts: []models.ReplacementDraft{}}
+	drafted := make(map[string]models.Deprecation)
+	for _, dep := range candidates[:min(limit, len(candidates))] {
+		draft := models.ReplacementDraft{API: dep.API, Package: dep.Package, Version: dep.Version, Description: dep.Description}
+		text, model, err := r.sampler.CreateMessage(ctx, replacementSystemPrompt, r.replacementPrompt(ctx, dep), config.SAMPLING_MAX_TOKENS)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			draft.Error = err.Error()
+			result.Drafts = append(result.Drafts, draft)
+			continue
+		}
+		if model == "" {
+			model = draftedByUnknownModel
+		}
+		if result.Model == "" {
+			result.Model = model
+		}
+		draft.Replacement = parseDraftedReplacement(text)
+		if draft.Replacement != "" {
+			drafted[seenKey(dep)] = models.Deprecation{Replacement: draft.Replacement, ReplacementDraftedBy: model}
+		}
+		result.Drafts = append(result.Drafts, draft)
+	}
+
+	// Load again so that entries a refresh stored while the model answered are kept
+	if len(drafted) > 0 {
+		if cache, err = r.cacheService.Load(); err != nil {
+			return nil, err
+		}
+		for i, dep := range cache.Deprecations {
+			if draft, ok := drafted[seenKey(dep)]; ok && needsReplacementDraft(dep) {
+				cache.Deprecations[i].Replacement = draft.Replacement
+				cache.Deprecations[i].ReplacementDraftedBy = draft.ReplacementDraftedBy
+			}
+		}
+		if err := r.cacheService.Save(cache); err != nil {
+			return nil, err
+		}
+	}
+	for _, dep := range cache.Deprecations {
+		if needsReplacementDraft(dep) {
+			result.Pending++
+		}
+	}
+	return result, nil
+}
+
+// needsReplacementDraft reports whether dep is a scanned deprecation still in use upstream whose
+// annotation names no replacement and that has no drafted one yet
+func needsReplacementDraft(dep models.Deprecation) bool {
+	return isScanned(dep) && !dep.Removed && dep.Replacement == ""
+}
+
+// replacementPrompt describes dep to the client model, with the source around its annotation when
+// it can be fetched
+func (r *ReplacementDraftService) replacementPrompt(ctx context.Context, dep models.Deprecation) string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Deprecated API: %s\n", dep.API)
+	if dep.Package != "" {
+		fmt.Fprintf(&prompt, "Package: %s\n", dep.Package)
+	} else if dep.Category != "" {
+		fmt.Fprintf(&prompt, "Flutter library: %s\n", dep.Category)
+	}
+	if dep.Version != "" {
+		fmt.Fprintf(&prompt, "Deprecated in: %s\n", dep.Version)
+	}
+	fmt.Fprintf(&prompt, "Deprecation message: %s\n", dep.Description)
+	if source := r.sourceContext(ctx, dep); source != "" {
+		fmt.Fprintf(&prompt, "\nSource around the annotation (%s):\n```dart\n%s\n```\n", SourceURL(dep), source)
+	}
+	return prompt.String()
+}
+
+// sourceContext returns the lines around the @Deprecated annotation of dep, or "" when the source
+// is unknown or cannot be fetched
+func (r *ReplacementDraftService) sourceContext(ctx context.Context, dep models.Deprecation) string {
+	link, _, _ := strings.Cut(SourceURL(dep), "#")
+	if r.apiService == nil || dep.SourceLine <= 0 || !strings.HasPrefix(link, config.GITHUB_URL) {
+		return ""
+	}
+	raw := config.GITHUB_RAW_URL + strings.Replace(strings.TrimPrefix(link, config.GITHUB_URL), "/blob/", "/", 1)
+	resp, err := r.apiService.get(ctx, raw)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	first := max(dep.SourceLine-config.DRAFT_CONTEXT_LINES/2, 1)
+	last := dep.SourceLine + config.DRAFT_CONTEXT_LINES
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for line := 1; line <= last && scanner.Scan(); line++ {
+		if line >= first {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseDraftedReplacement takes the API out of the answer of the client model, or "" when it
+// found none
+func parseDraftedReplacement(text string) string {
+	answer, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	answer = strings.Trim(strings.TrimSpace(answer), "`'\".")
+	if answer == "" || strings.EqualFold(answer, "none") || len(answer) > 120 || strings.Count(answer, " ") > 3 {
+		return ""
+	}
+	return answer
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// scriptedSampler answers sampling requests with the reply of the first API the prompt names
+type scriptedSampler struct {
+	unsupported bool
+	replies     map[string]string
+	prompts     []string
+}
+
+func (s *scriptedSampler) SamplingSupported() bool {
+	return !s.unsupported
+}
+
+func (s *scriptedSampler) CreateMessage(ctx context.Context, systemPrompt string, prompt string, maxTokens int) (string, string, error) {
+	s.prompts = append(s.prompts, prompt)
+	for api, reply := range s.replies {
+		if strings.Contains(prompt, "Deprecated API: "+api+"\n") {
+			return reply, "test-model", nil
+		}
+	}
+	return "", "", fmt.Errorf("the user rejected the request")
+}
+
+func TestReplacementDrafts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/flutter/flutter/stable/packages/flutter/lib/src/material/app.dart" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("class App {\n  @Deprecated('Prefer the themed color')\n  Color get color => theme.primary;\n}\n"))
+	}))
+	defer server.Close()
+	api := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+
+	newCache := func(t *testing.T) *CacheService {
+		cacheService := &CacheService{dir: t.TempDir()}
+		if err := cacheService.Save(&models.DeprecationCache{Deprecations: []models.Deprecation{
+			{API: "App.color", Description: "Prefer the themed color", Source: "flutter_source", Category: "material", Channel: "stable", SourceFile: "packages/flutter/lib/src/material/app.dart", SourceLine: 2},
+			{API: "App.title", Description: "Not needed anymore", Source: "flutter_source", Category: "material"},
+			{API: "App.rejected", Description: "Gone soon", Source: "flutter_source", Category: "material"},
+			{API: "App.named", Description: "Use App.label", Source: "flutter_source", Replacement: "App.label"},
+			{API: "RaisedButton", Description: "Use ElevatedButton", Source: "builtin"},
+		}}); err != nil {
+			t.Fatalf("Failed to save cache: %v", err)
+		}
+		return cacheService
+	}
+
+	t.Run("Stores the drafted replacements flagged with the model", func(t *testing.T) {
+		cacheService := newCache(t)
+		sampler := &scriptedSampler{replies: map[string]string{"App.color": "`ColorScheme.primary`\n\nThe getter moved to the scheme.", "App.title": "NONE"}}
+		result, err := NewReplacementDraftService(cacheService, api, sampler).DraftReplacements(context.Background(), "", 0)
+		if err != nil {
+			t.Fatalf("DraftReplacements failed: %v", err)
+		}
+		if len(result.Drafts) != 3 || result.Model != "test-model" || result.Pending != 2 {
+			t.Fatalf("Expected three drafts leaving two pending, got %+v", result)
+		}
+		if result.Drafts[0].Replacement != "ColorScheme.primary" || result.Drafts[1].Replacement != "" || !strings.Contains(result.Drafts[2].Error, "rejected") {
+			t.Errorf("Unexpected drafts %+v", result.Drafts)
+		}
+		if !strings.Contains(sampler.prompts[0], "Color get color => theme.primary;") || strings.Contains(sampler.prompts[1], "```dart") {
+			t.Errorf("Expected the source around the annotation in the prompt of App.color only, got %q", sampler.prompts)
+		}
+
+		cache, _ := cacheService.Load()
+		if color := cache.Deprecations[0]; color.Replacement != "ColorScheme.primary" || color.ReplacementDraftedBy != "test-model" {
+			t.Errorf("Expected the draft to be stored flagged with its model, got %+v", color)
+		}
+	})
+
+	t.Run("Drafts one API on request", func(t *testing.T) {
+		sampler := &scriptedSampler{replies: map[string]string{"App.title": "App.label"}}
+		result, err := NewReplacementDraftService(newCache(t), nil, sampler).DraftReplacements(context.Background(), "App.title", 0)
+		if err != nil || len(result.Drafts) != 1 || result.Drafts[0].Replacement != "App.label" {
+			t.Errorf("Expected only App.title to be drafted, got %+v, %v", result, err)
+		}
+		if _, err := NewReplacementDraftService(newCache(t), nil, sampler).DraftReplacements(context.Background(), "App.named", 0); err == nil {
+			t.Error("Expected an error for an API that already has a replacement")
+		}
+	})
+
+	t.Run("Needs a client that supports sampling", func(t *testing.T) {
+		if _, err := NewReplacementDraftService(newCache(t), nil, &scriptedSampler{unsupported: true}).DraftReplacements(context.Background(), "", 0); err == nil || !strings.Contains(err.Error(), "sampling") {
+			t.Errorf("Expected a sampling error, got %v", err)
+		}
+	})
+}
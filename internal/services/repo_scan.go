@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// Patterns used to locate and name a Dart package on GitHub
+var (
+	githubRepoPattern  = regexp.MustCompile(`^(?:(?:https?://)?(?:www\.)?github\.com/)?([\w.-]+)/([\w.-]+?)(?:\.git)?(?:/tree/([^/]+)(?:/(.*))?)?/?$`)
+	pubspecNamePattern = regexp.MustCompile(`(?m)^name:\s*['"]?([a-zA-Z_]\w*)`)
+)
+
+// githubRepo is a directory of a GitHub repository at a ref; an empty ref means the default branch
+type githubRepo struct {
+	name string
+	ref  string
+	path string
+}
+
+// parseGitHubRepo reads owner/name or a github.com URL, which may point into a tree. Explicit ref
+// and dir arguments take precedence over the ones in the URL.
+func parseGitHubRepo(repo string, ref string, dir string) (githubRepo, error) {
+	matches := githubRepoPattern.FindStringSubmatch(strings.TrimSpace(repo))
+	if matches == nil {
+		return githubRepo{}, fmt.Errorf("invalid GitHub repository %q, expected owner/name or a github.com URL", repo)
+	}
+
+	target := githubRepo{name: matches[1] + "/" + matches[2], ref: matches[3], path: matches[4]}
+	if ref = strings.TrimSpace(ref); ref != "" {
+		target.ref = ref
+	}
+	if dir = strings.TrimSpace(dir); dir != "" {
+		target.path = dir
+	}
+	target.path = strings.Trim(target.path, "/")
+	return target, nil
+}
+
+// rawURL returns the raw.githubusercontent.com URL of a file in the repository
+func (r githubRepo) rawURL(file string) string {
+	ref := r.ref
+	if ref == "" {
+		ref = config.DEFAULT_REPO_SCAN_REF
+	}
+	return config.GITHUB_RAW_URL + r.name + "/" + ref + "/" + file
+}
+
+// contentsURL returns the GitHub contents API URL that lists a directory of the repository
+func (r githubRepo) contentsURL(dir string) string {
+	apiURL := fmt.Sprintf(config.GITHUB_CONTENTS_URL, r.name, dir)
+	if r.ref != "" {
+		apiURL += "?ref=" + url.QueryEscape(r.ref)
+	}
+	return apiURL
+}
+
+// String formats the repository as owner/name@ref
+func (r githubRepo) String() string {
+	if r.ref == "" {
+		return r.name + "@" + config.DEFAULT_REPO_SCAN_REF
+	}
+	return r.name + "@" + r.ref
+}
+
+// RepoScanService scans the GitHub repositories of Dart packages, such as a company's fork of a
+// plugin, for @Deprecated annotations and stores the entries next to the manual ones
+type RepoScanService struct {
+	apiService   *FlutterAPIService
+	cacheService CacheServiceInterface
+}
+
+// NewRepoScanService creates a new repository scan service instance
+func NewRepoScanService(apiService *FlutterAPIService, cacheService CacheServiceInterface) *RepoScanService {
+	return &RepoScanService{apiService: apiService, cacheService: cacheService}
+}
+
+// Scan reads the @Deprecated annotations in the lib directory of the package at dir in repo (owner/name
+// or a GitHub URL) at ref, the default branch when empty. The entries are tagged with pkg, which
+// defaults to the name in the package's pubspec.yaml, so that they only match code importing it.
+func (r *RepoScanService) Scan(ctx context.Context, repo string, ref string, dir string, pkg string) (*models.RepoScanResult, error) {
+	target, err := parseGitHubRepo(repo, ref, dir)
+	if err != nil {
+		return nil, err
+	}
+	return r.apiService.scanRepository(ctx, target, strings.TrimSpace(pkg))
+}
+
+// Save stores the entries of a scan with the manual ones, replacing those of an earlier scan of the
+// same package, and returns how many were stored
+func (r *RepoScanService) Save(result *models.RepoScanResult) (int, error) {
+	cache, err := r.cacheService.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	manual := make([]models.Deprecation, 0, len(cache.Manual)+len(result.Deprecations))
+	for _, dep := range cache.Manual {
+		if dep.Source == config.DEPRECATION_SOURCE_REPO && dep.Package == result.Package {
+			continue
+		}
+		manual = append(manual, dep)
+	}
+	cache.Manual = append(manual, result.Deprecations...)
+
+	return len(result.Deprecations), r.cacheService.Save(cache)
+}
+
+// scanRepository walks the lib directory of a package breadth first, scanning at most
+// MAX_REPO_SCAN_FILES Dart files and skipping generated ones
+func (f *FlutterAPIService) scanRepository(ctx context.Context, repo githubRepo, pkg string) (*models.RepoScanResult, error) {
+	root := "lib"
+	if repo.path != "" {
+		root = repo.path + "/lib"
+	}
+	if pkg == "" {
+		pkg = f.pubspecName(ctx, repo)
+	}
+
+	result := &models.RepoScanResult{Repository: repo.String(), Path: repo.path, Package: pkg}
+	dirs := []string{root}
+walk:
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		entries, err := f.listDirectory(ctx, repo.contentsURL(dir))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if dir != root {
+				slog.Warn("Failed to scan repository directory", "repository", result.Repository, "directory", dir, "error", err)
+				continue
+			}
+			if errors.Is(err, errDirectoryNotFound) {
+				return nil, fmt.Errorf("%s has no %s directory; is path the directory of a Dart package?", result.Repository, root)
+			}
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			file := entry.Path
+			if file == "" {
+				file = dir + "/" + entry.Name
+			}
+			switch {
+			case entry.Type == "dir":
+				dirs = append(dirs, file)
+			case entry.Type != "file" || !strings.HasSuffix(entry.Name, ".dart") || isGeneratedDart(entry.Name):
+				continue
+			case result.Files == config.MAX_REPO_SCAN_FILES:
+				result.Truncated = true
+				break walk
+			default:
+				result.Files++
+				deprecations, err := f.ScanFileForDeprecations(ctx, repo.rawURL(file))
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, ctx.Err()
+					}
+					slog.Warn("Failed to scan file", "repository", result.Repository, "file", file, "error", err)
+					continue
+				}
+				for _, dep := range deprecations {
+					dep.Package = pkg
+					dep.Category = pkg
+					dep.Source = config.DEPRECATION_SOURCE_REPO
+					dep.Repository = result.Repository
+					dep.SourceFile = file
+					dep.DocURL = SourceURL(dep)
+					result.Deprecations = append(result.Deprecations, dep)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// pubspecName returns the package name declared in the pubspec.yaml of repo, falling back to the
+// name of its directory or of the repository
+func (f *FlutterAPIService) pubspecName(ctx context.Context, repo githubRepo) string {
+	pubspec := "pubspec.yaml"
+	if repo.path != "" {
+		pubspec = repo.path + "/" + pubspec
+	}
+	if resp, err := f.get(ctx, repo.rawURL(pubspec)); err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 200 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			if matches := pubspecNamePattern.FindSubmatch(body); matches != nil {
+				return string(matches[1])
+			}
+		}
+	}
+
+	name := path.Base(repo.name)
+	if repo.path != "" {
+		name = path.Base(repo.path)
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
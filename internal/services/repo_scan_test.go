@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestParseGitHubRepo(t *testing.T) {
+	testCases := []struct {
+		repo, ref, dir string
+		expected       githubRepo
+	}{
+		{repo: "acme/design_system", expected: githubRepo{name: "acme/design_system"}},
+		{repo: "https://github.com/acme/design-system.git", ref: "v2.0.0", expected: githubRepo{name: "acme/design-system", ref: "v2.0.0"}},
+		{repo: "github.com/acme/plugins/tree/main/packages/camera/", expected: githubRepo{name: "acme/plugins", ref: "main", path: "packages/camera"}},
+		{repo: "https://github.com/acme/plugins/tree/main/packages/camera", ref: "fork", dir: "/packages/maps/", expected: githubRepo{name: "acme/plugins", ref: "fork", path: "packages/maps"}},
+	}
+	for _, tc := range testCases {
+		got, err := parseGitHubRepo(tc.repo, tc.ref, tc.dir)
+		if err != nil || got != tc.expected {
+			t.Errorf("parseGitHubRepo(%q, %q, %q): expected %+v, got %+v (%v)", tc.repo, tc.ref, tc.dir, tc.expected, got, err)
+		}
+	}
+
+	for _, invalid := range []string{"", "design_system", "https://gitlab.com/acme/design_system"} {
+		if _, err := parseGitHubRepo(invalid, "", ""); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestRepoScanService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/acme/plugins/fork/packages/maps/pubspec.yaml":
+			w.Write([]byte("name: acme_maps\nversion: 2.0.0\n"))
+		case "/repos/acme/plugins/contents/packages/maps/lib":
+			if r.URL.Query().Get("ref") != "fork" {
+				t.Errorf("Expected the listing of the fork ref, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"name": "maps.dart", "path": "packages/maps/lib/maps.dart", "type": "file"},
+				{"name": "maps.g.dart", "path": "packages/maps/lib/maps.g.dart", "type": "file"},
+				{"name": "src", "path": "packages/maps/lib/src", "type": "dir"}]`))
+		case "/repos/acme/plugins/contents/packages/maps/lib/src":
+			w.Write([]byte(`[{"name": "marker.dart", "path": "packages/maps/lib/src/marker.dart", "type": "file"}]`))
+		case "/acme/plugins/fork/packages/maps/lib/maps.dart":
+			w.Write([]byte("export 'src/marker.dart';\n"))
+		case "/acme/plugins/fork/packages/maps/lib/src/marker.dart":
+			w.Write([]byte("class Marker {\n  @Deprecated('Use icon instead')\n  String get pin;\n}\n"))
+		case "/acme/plugins/fork/packages/maps/lib/maps.g.dart":
+			t.Error("Expected generated files to be skipped")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	apiService := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+	cacheService := &CacheService{dir: t.TempDir()}
+	repoScans := NewRepoScanService(apiService, cacheService)
+
+	t.Run("Scans the lib directory recursively", func(t *testing.T) {
+		result, err := repoScans.Scan(context.Background(), "https://github.com/acme/plugins/tree/fork/packages/maps", "", "", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Repository != "acme/plugins@fork" || result.Package != "acme_maps" || result.Files != 2 || result.Truncated {
+			t.Errorf("Unexpected scan summary: %+v", result)
+		}
+		if len(result.Deprecations) != 1 {
+			t.Fatalf("Expected 1 deprecation, got %+v", result.Deprecations)
+		}
+
+		dep := result.Deprecations[0]
+		if dep.API != "Marker.pin" || dep.Package != "acme_maps" || dep.Source != config.DEPRECATION_SOURCE_REPO || dep.SourceFile != "packages/maps/lib/src/marker.dart" || dep.SourceLine != 2 {
+			t.Errorf("Expected a tagged acme_maps entry, got %+v", dep)
+		}
+		if expected := "https://github.com/acme/plugins/blob/fork/packages/maps/lib/src/marker.dart#L2"; dep.DocURL != expected || SourceURL(dep) != expected {
+			t.Errorf("Expected the annotation to be linked at %s, got %s and %s", expected, dep.DocURL, SourceURL(dep))
+		}
+	})
+
+	t.Run("Reports repositories without a lib directory", func(t *testing.T) {
+		_, err := repoScans.Scan(context.Background(), "acme/empty", "", "", "empty")
+		if err == nil || !strings.Contains(err.Error(), "no lib directory") {
+			t.Errorf("Expected a missing lib directory error, got %v", err)
+		}
+	})
+
+	t.Run("Save replaces an earlier scan of the package", func(t *testing.T) {
+		err := cacheService.Save(&models.DeprecationCache{Manual: []models.Deprecation{
+			{API: "LegacyCard", Source: config.DEPRECATION_SOURCE_MANUAL},
+			{API: "Marker.old", Package: "acme_maps", Source: config.DEPRECATION_SOURCE_REPO},
+		}})
+		if err != nil {
+			t.Fatalf("Failed to save cache: %v", err)
+		}
+
+		saved, err := repoScans.Save(&models.RepoScanResult{Package: "acme_maps", Deprecations: []models.Deprecation{
+			{API: "Marker.pin", Package: "acme_maps", Source: config.DEPRECATION_SOURCE_REPO},
+		}})
+		if err != nil || saved != 1 {
+			t.Fatalf("Expected 1 saved entry, got %d (%v)", saved, err)
+		}
+
+		cache, _ := cacheService.Load()
+		var apis []string
+		for _, dep := range cache.Manual {
+			apis = append(apis, dep.API)
+		}
+		if got := strings.Join(apis, ","); got != "LegacyCard,Marker.pin" {
+			t.Errorf("Expected the manual entry and the new scan, got %s", got)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ParseGroupBy normalizes how a project scan report groups its findings, defaulting to package
+func ParseGroupBy(groupBy string) (string, error) {
+	switch groupBy = strings.ToLower(strings.TrimSpace(groupBy)); groupBy {
+	case "":
+		return config.GROUP_BY_PACKAGE, nil
+	case config.GROUP_BY_PACKAGE, config.GROUP_BY_FILE, config.GROUP_BY_RULE, config.GROUP_BY_SEVERITY:
+		return groupBy, nil
+	default:
+		return "", fmt.Errorf("unknown grouping %q, expected package, file, rule or severity", groupBy)
+	}
+}
+
+// GroupFindings groups the findings of a project scan by package, file, deprecated API or
+// severity. Packages and files keep the order of the scan, the deprecated APIs are ranked by how
+// many findings they have, most first, and severities go from error to info. Within a group the
+// findings keep the order they are given in.
+func GroupFindings(findings []models.ProjectFinding, groupBy string) []models.FindingGroup {
+	key := func(finding models.ProjectFinding) string {
+		switch groupBy {
+		case config.GROUP_BY_FILE:
+			return finding.File
+		case config.GROUP_BY_RULE:
+			return finding.Deprecation.API
+		case config.GROUP_BY_SEVERITY:
+			return severityOf(finding.Deprecation)
+		default:
+			return finding.Package
+		}
+	}
+
+	var groups []models.FindingGroup
+	index := make(map[string]int)
+	for _, finding := range findings {
+		name := key(finding)
+		i, found := index[name]
+		if !found {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, models.FindingGroup{Name: name})
+		}
+		groups[i].Findings = append(groups[i].Findings, finding)
+	}
+
+	switch groupBy {
+	case config.GROUP_BY_RULE:
+		sort.SliceStable(groups, func(i, j int) bool {
+			return len(groups[i].Findings) > len(groups[j].Findings)
+		})
+	case config.GROUP_BY_SEVERITY:
+		sort.SliceStable(groups, func(i, j int) bool {
+			return severityRanks[groups[i].Name] > severityRanks[groups[j].Name]
+		})
+	}
+	return groups
+}
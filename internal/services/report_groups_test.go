@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	for groupBy, expected := range map[string]string{"": config.GROUP_BY_PACKAGE, " Rule ": config.GROUP_BY_RULE, "severity": config.GROUP_BY_SEVERITY} {
+		if parsed, err := ParseGroupBy(groupBy); err != nil || parsed != expected {
+			t.Errorf("ParseGroupBy(%q): expected %q, got %q (%v)", groupBy, expected, parsed, err)
+		}
+	}
+	if _, err := ParseGroupBy("author"); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+}
+
+func TestGroupFindings(t *testing.T) {
+	raised := models.Deprecation{API: "RaisedButton", Severity: config.SEVERITY_ERROR}
+	opacity := models.Deprecation{API: "Color.withOpacity", Severity: config.SEVERITY_WARNING}
+	scanned := models.Deprecation{API: "ThemeData.accentColor"}
+	hint := models.Deprecation{API: "MediaQuery.of", Severity: config.SEVERITY_INFO}
+	findings := []models.ProjectFinding{
+		{Package: "app", File: "lib/a.dart", Deprecation: raised},
+		{Package: "app", File: "lib/a.dart", Deprecation: hint},
+		{Package: "app", File: "lib/b.dart", Deprecation: opacity},
+		{Package: "core", File: "packages/core/lib/c.dart", Deprecation: opacity},
+		{Package: "core", File: "packages/core/lib/c.dart", Deprecation: scanned},
+	}
+
+	groups := func(groupBy string) []string {
+		var names []string
+		for _, group := range GroupFindings(findings, groupBy) {
+			names = append(names, fmt.Sprintf("%s (%d)", group.Name, len(group.Findings)))
+		}
+		return names
+	}
+	for groupBy, expected := range map[string][]string{
+		config.GROUP_BY_PACKAGE:  {"app (3)", "core (2)"},
+		config.GROUP_BY_FILE:     {"lib/a.dart (2)", "lib/b.dart (1)", "packages/core/lib/c.dart (2)"},
+		config.GROUP_BY_RULE:     {"Color.withOpacity (2)", "RaisedButton (1)", "MediaQuery.of (1)", "ThemeData.accentColor (1)"},
+		config.GROUP_BY_SEVERITY: {"error (1)", "warning (3)", "info (1)"},
+	} {
+		if got := groups(groupBy); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Grouping by %s: expected %v, got %v", groupBy, expected, got)
+		}
+	}
+
+	warnings := GroupFindings(findings, config.GROUP_BY_SEVERITY)[1].Findings
+	if warnings[0].File != "lib/b.dart" || warnings[2].Deprecation.API != "ThemeData.accentColor" {
+		t.Errorf("Expected the findings to keep their order, got %+v", warnings)
+	}
+	if len(GroupFindings(nil, config.GROUP_BY_RULE)) != 0 {
+		t.Error("Expected no groups without findings")
+	}
+}
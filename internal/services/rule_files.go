@@ -0,0 +1,486 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// ruleFile is the format of the built-in rules, of --rules-file and of the document at --rules-url,
+// in YAML or JSON
+type ruleFile struct {
+	Rules []ruleFileEntry `yaml:"rules"`
+}
+
+// ruleFileEntry is one rule of a rules file. A rule with a pattern is matched by that regular
+// expression; when it has a rewrite template, the matches of rewrite_pattern (the pattern itself by
+// default) are replaced with it, $1 or ${name} standing for their capture groups. A follow-up
+// pattern flags what the rewrite leaves to a manual migration. An example written as before → after
+// must be what the rewrite makes of before, and the tests list code the pattern must and must not
+// match for TestRules. A rule without a pattern is matched by its API name. Rules apply to Flutter
+// and Dart code unless target names one of them.
+type ruleFileEntry struct {
+	API             string `yaml:"api"`
+	Replacement     string `yaml:"replacement"`
+	Description     string `yaml:"description"`
+	Example         string `yaml:"example"`
+	Compatible      string `yaml:"compatible"`
+	Version         string `yaml:"version"`
+	Category        string `yaml:"category"`
+	Package         string `yaml:"package"`
+	Severity        string `yaml:"severity"`
+	Docs            string `yaml:"docs"`
+	Target          string `yaml:"target"`
+	Pattern         string `yaml:"pattern"`
+	RewritePattern  string `yaml:"rewrite_pattern"`
+	Rewrite         string `yaml:"rewrite"`
+	FollowUpPattern string `yaml:"follow_up_pattern"`
+	FollowUp        string `yaml:"follow_up"`
+	Tests           struct {
+		Match   []string `yaml:"match"`
+		NoMatch []string `yaml:"no_match"`
+	} `yaml:"tests"`
+}
+
+// templateGroup matches the capture group references of a rewrite template, and $$ for a literal $
+var templateGroup = regexp.MustCompile(`\$(?:\$|\{(\w+)\}|(\w+))`)
+
+// ruleSet holds the rules of a rules file by target
+type ruleSet struct {
+	patterns map[string][]deprecationRule
+	entries  map[string][]models.Deprecation
+}
+
+// newRuleSet creates an empty rule set
+func newRuleSet() ruleSet {
+	return ruleSet{patterns: make(map[string][]deprecationRule), entries: make(map[string][]models.Deprecation)}
+}
+
+// add appends the rules of other to the set
+func (r ruleSet) add(other ruleSet) {
+	for target, patterns := range other.patterns {
+		r.patterns[target] = append(r.patterns[target], patterns...)
+	}
+	for target, entries := range other.entries {
+		r.entries[target] = append(r.entries[target], entries...)
+	}
+}
+
+// rules returns the pattern rules and entries of the set for target
+func (r ruleSet) rules(target string) ([]deprecationRule, []models.Deprecation) {
+	return r.patterns[target], r.entries[target]
+}
+
+// parseRuleFile reads the rules of a rules file, marking their entries with source
+func parseRuleFile(data []byte, source string) (ruleSet, error) {
+	var file ruleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return ruleSet{}, fmt.Errorf("invalid rules file: %v", err)
+	}
+
+	set := newRuleSet()
+	for i, rule := range file.Rules {
+		dep := models.Deprecation{
+			API:         strings.TrimSpace(rule.API),
+			Replacement: strings.TrimSpace(rule.Replacement),
+			Version:     strings.TrimSpace(rule.Version),
+			Description: strings.TrimSpace(rule.Description),
+			Example:     strings.TrimSpace(rule.Example),
+			Compatible:  strings.TrimSpace(rule.Compatible),
+			Category:    strings.TrimSpace(rule.Category),
+			Severity:    strings.ToLower(strings.TrimSpace(rule.Severity)),
+			Package:     strings.TrimSpace(rule.Package),
+			DocURL:      strings.TrimSpace(rule.Docs),
+			Source:      source,
+		}
+		if dep.API == "" {
+			return ruleSet{}, fmt.Errorf("rule %d has no api", i+1)
+		}
+		if dep.Severity == "" {
+			dep.Severity = config.SEVERITY_WARNING
+		} else if _, found := severityRanks[dep.Severity]; !found {
+			return ruleSet{}, fmt.Errorf("rule %d (%s): unknown severity %q, expected info, warning or error", i+1, dep.API, rule.Severity)
+		}
+		if dep.Description == "" {
+			dep.Description = fmt.Sprintf("%s is deprecated", dep.API)
+			if dep.Replacement != "" {
+				dep.Description += fmt.Sprintf(", use %s instead", dep.Replacement)
+			}
+		}
+		if dep.DocURL == "" {
+			dep.DocURL = apiDocumentationURL(dep)
+		}
+
+		targets := []string{config.TARGET_FLUTTER, config.TARGET_DART}
+		if target := strings.TrimSpace(rule.Target); target != "" {
+			parsed, err := ParseTarget(target)
+			if err != nil {
+				return ruleSet{}, fmt.Errorf("rule %d (%s): %v", i+1, dep.API, err)
+			}
+			targets = []string{parsed}
+		}
+
+		if rule.Pattern == "" {
+			if rule.Rewrite != "" || rule.RewritePattern != "" || rule.FollowUpPattern != "" {
+				return ruleSet{}, fmt.Errorf("rule %d (%s): a rewrite or follow-up needs a pattern", i+1, dep.API)
+			}
+			if len(rule.Tests.Match) > 0 || len(rule.Tests.NoMatch) > 0 {
+				return ruleSet{}, fmt.Errorf("rule %d (%s): tests need a pattern", i+1, dep.API)
+			}
+			for _, target := range targets {
+				set.entries[target] = append(set.entries[target], dep)
+			}
+			continue
+		}
+
+		compiled, err := compileRule(rule, dep)
+		if err != nil {
+			return ruleSet{}, fmt.Errorf("rule %d (%s): %v", i+1, dep.API, err)
+		}
+		for _, target := range targets {
+			set.patterns[target] = append(set.patterns[target], compiled)
+		}
+	}
+	return set, nil
+}
+
+// compileRule compiles the patterns of a rule that has one and checks its rewrite template
+func compileRule(rule ruleFileEntry, dep models.Deprecation) (deprecationRule, error) {
+	pattern, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return deprecationRule{}, fmt.Errorf("invalid pattern: %v", err)
+	}
+	compiled := deprecationRule{pattern: pattern, deprecation: dep, matches: rule.Tests.Match, nonMatches: rule.Tests.NoMatch}
+
+	if rule.RewritePattern != "" && rule.Rewrite == "" {
+		return deprecationRule{}, fmt.Errorf("rewrite_pattern needs a rewrite template")
+	}
+	if rule.Rewrite != "" {
+		compiled.rewrite = pattern
+		if rule.RewritePattern != "" {
+			if compiled.rewrite, err = regexp.Compile(rule.RewritePattern); err != nil {
+				return deprecationRule{}, fmt.Errorf("invalid rewrite_pattern: %v", err)
+			}
+		}
+		compiled.template = rule.Rewrite
+		if err := checkTemplate(compiled.rewrite, rule.Rewrite); err != nil {
+			return deprecationRule{}, err
+		}
+
+		// An example written as before → after doubles as a test of the rewrite
+		if before, after, found := strings.Cut(dep.Example, " → "); found {
+			if !pattern.MatchString(before) {
+				return deprecationRule{}, fmt.Errorf("the pattern does not match the example %q", before)
+			}
+			if rewritten := compiled.rewrite.ReplaceAllString(before, compiled.template); rewritten != after {
+				return deprecationRule{}, fmt.Errorf("the rewrite turns the example %q into %q rather than %q", before, rewritten, after)
+			}
+		}
+	}
+
+	if (rule.FollowUpPattern == "") != (rule.FollowUp == "") {
+		return deprecationRule{}, fmt.Errorf("follow_up_pattern and follow_up go together")
+	}
+	if rule.FollowUpPattern != "" {
+		if compiled.followUpPattern, err = regexp.Compile(rule.FollowUpPattern); err != nil {
+			return deprecationRule{}, fmt.Errorf("invalid follow_up_pattern: %v", err)
+		}
+		compiled.followUp = rule.FollowUp
+	}
+	return compiled, nil
+}
+
+// checkTemplate reports the capture groups a rewrite template refers to that pattern does not
+// have. Like regexp, it reads $1x as the group 1x, which is why templates write ${1}x.
+func checkTemplate(pattern *regexp.Regexp, template string) error {
+	names := make(map[string]bool)
+	for _, name := range pattern.SubexpNames() {
+		if name != "" {
+			names[name] = true
+		}
+	}
+	for _, match := range templateGroup.FindAllStringSubmatch(template, -1) {
+		group := match[1] + match[2]
+		if group == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(group); err == nil {
+			if n > pattern.NumSubexp() {
+				return fmt.Errorf("rewrite refers to group %d, but the pattern has %d", n, pattern.NumSubexp())
+			}
+		} else if !names[group] {
+			return fmt.Errorf("rewrite refers to group %q, which the pattern does not name (write ${1}x rather than $1x to follow a group with text)", group)
+		}
+	}
+	return nil
+}
+
+// ValidateRulesFile checks that the rules file at path, or every rules file in the directory at
+// path, can be read and parsed
+func ValidateRulesFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = parseRuleFile(data, config.DEPRECATION_SOURCE_CUSTOM)
+		return err
+	}
+	for _, file := range customRuleFiles([]string{path}) {
+		if err := ValidateRulesFile(file); err != nil {
+			return fmt.Errorf("%s: %v", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// customRuleExtensions are the extensions of the rules files read from a directory
+var customRuleExtensions = []string{".yaml", ".yml", ".json"}
+
+// customRuleFiles lists the rules files at paths in order, those of a directory sorted by name.
+// Missing paths are left out.
+func customRuleFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && slices.Contains(customRuleExtensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// customRuleFile is the last valid version of one rules file of the custom-yaml provider, and the
+// modification time and size of an edit the watcher waits to settle
+type customRuleFile struct {
+	modTime time.Time
+	size    int64
+	rules   ruleSet
+
+	pendingModTime time.Time
+	pendingSize    int64
+}
+
+// customRuleProvider supplies the rules of local rules files. The compiled rules of all files are
+// swapped as a whole, so a check running during a reload sees either the old or the new rules.
+type customRuleProvider struct {
+	paths    []string
+	interval time.Duration
+
+	mu    sync.Mutex
+	files map[string]*customRuleFile
+	rules atomic.Pointer[ruleSet]
+}
+
+// NewCustomRuleProvider creates the provider of the rules kept in the rules files at paths, each a
+// file or a directory of .yaml, .yml and .json files, earlier ones first. The files are read on
+// first use; Watch picks up later edits while the server runs. A missing file has no rules.
+func NewCustomRuleProvider(paths ...string) RuleProvider {
+	return &customRuleProvider{paths: paths, interval: config.RULES_WATCH_INTERVAL, files: make(map[string]*customRuleFile)}
+}
+
+// Name implements RuleProvider
+func (c *customRuleProvider) Name() string {
+	return config.RULE_PROVIDER_CUSTOM_YAML
+}
+
+// Rules implements RuleProvider
+func (c *customRuleProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	rules := c.rules.Load()
+	if rules == nil {
+		c.reload(false)
+		rules = c.rules.Load()
+	}
+	return rules.rules(target)
+}
+
+// Refresh implements RuleRefresher, reading the rules files that changed
+func (c *customRuleProvider) Refresh(ctx context.Context) error {
+	c.reload(false)
+	return nil
+}
+
+// Watch implements RuleWatcher, checking the rules files for edits until ctx is cancelled
+func (c *customRuleProvider) Watch(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.reload(true)
+		}
+	}
+}
+
+// reload reads the rules files that were added or changed since the last read and swaps in the
+// rules of all files. An invalid file keeps the rules of its last valid version, so that a
+// half-saved edit does not drop them; a deleted file takes its rules with it. With settle, a file
+// is only read once its modification time and size are the same as at the previous reload, so
+// that the watcher does not take the truncated file of an editor still saving it for an empty one.
+func (c *customRuleProvider) reload(settle bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	initial := c.rules.Load() == nil
+	changed := initial
+	paths := customRuleFiles(c.paths)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		file := c.files[path]
+		if file != nil && info.ModTime().Equal(file.modTime) && info.Size() == file.size {
+			continue
+		}
+		if file == nil {
+			file = &customRuleFile{}
+			c.files[path] = file
+		}
+		if settle && !initial && (!info.ModTime().Equal(file.pendingModTime) || info.Size() != file.pendingSize) {
+			file.pendingModTime, file.pendingSize = info.ModTime(), info.Size()
+			continue
+		}
+		file.modTime, file.size = info.ModTime(), info.Size()
+		changed = true
+
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			var rules ruleSet
+			if rules, err = parseRuleFile(data, config.DEPRECATION_SOURCE_CUSTOM); err == nil {
+				file.rules = rules
+				if !initial {
+					slog.Info("Reloaded rules file", "path", path)
+				}
+				continue
+			}
+		}
+		slog.Warn("Failed to read rules file, keeping its previous rules", "path", path, "error", err)
+	}
+	for path := range c.files {
+		if !slices.Contains(paths, path) {
+			delete(c.files, path)
+			changed = true
+			slog.Info("Rules file removed", "path", path)
+		}
+	}
+	if !changed {
+		return
+	}
+
+	rules := newRuleSet()
+	for _, path := range paths {
+		if file := c.files[path]; file != nil {
+			rules.add(file.rules)
+		}
+	}
+	c.rules.Store(&rules)
+}
+
+// remoteRuleProvider supplies the rules published at a URL
+type remoteRuleProvider struct {
+	url        string
+	path       string
+	apiService *FlutterAPIService
+
+	mu     sync.Mutex
+	loaded bool
+	rules  ruleSet
+}
+
+// NewRemoteRuleProvider creates the provider of the rules file published at url, such as the rules
+// a platform team maintains for its apps. Refresh downloads it into a copy at path, which is used
+// until the next successful refresh, also offline. Without a url the provider has no rules.
+func NewRemoteRuleProvider(url string, path string, apiService *FlutterAPIService) RuleProvider {
+	return &remoteRuleProvider{url: url, path: path, apiService: apiService}
+}
+
+// Name implements RuleProvider
+func (r *remoteRuleProvider) Name() string {
+	return config.RULE_PROVIDER_REMOTE
+}
+
+// Rules implements RuleProvider
+func (r *remoteRuleProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	if r.url == "" {
+		return nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		r.loaded = true
+		if data, err := ioutil.ReadFile(r.path); err == nil {
+			if rules, err := parseRuleFile(data, config.DEPRECATION_SOURCE_REMOTE); err == nil {
+				r.rules = rules
+			}
+		}
+	}
+	return r.rules.rules(target)
+}
+
+// Refresh implements RuleRefresher, downloading the rules again
+func (r *remoteRuleProvider) Refresh(ctx context.Context) error {
+	if r.url == "" {
+		return nil
+	}
+	resp, err := r.apiService.get(ctx, r.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rules URL returned status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, config.REMOTE_RULES_MAX_SIZE+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > config.REMOTE_RULES_MAX_SIZE {
+		return fmt.Errorf("rules URL returned more than %d bytes", config.REMOTE_RULES_MAX_SIZE)
+	}
+	rules, err := parseRuleFile(data, config.DEPRECATION_SOURCE_REMOTE)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules, r.loaded = rules, true
+	return writeFileAtomic(r.path, data)
+}
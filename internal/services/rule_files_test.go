@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestBuiltinRuleFiles(t *testing.T) {
+	set, err := loadBuiltinRules()
+	if err != nil {
+		t.Fatalf("loadBuiltinRules failed: %v", err)
+	}
+	if len(builtinRules) == 0 || len(dartRules) == 0 || len(dartRules) >= len(builtinRules) {
+		t.Fatalf("Expected the Dart rules to be a subset of the Flutter ones, got %d and %d", len(dartRules), len(builtinRules))
+	}
+	if len(set.entries[config.TARGET_FLUTTER]) != 0 {
+		t.Errorf("Expected every built-in rule to have a pattern, got %+v", set.entries[config.TARGET_FLUTTER])
+	}
+
+	for _, rule := range builtinRules {
+		dep := rule.deprecation
+		if dep.Description == "" || dep.DocURL == "" || dep.Category == "" || dep.Source != config.DEPRECATION_SOURCE_BUILTIN {
+			t.Errorf("Expected %s to have a description, docs link and category, got %+v", dep.API, dep)
+		}
+		if rule.rewrite != nil && !strings.Contains(dep.Example, " → ") {
+			t.Errorf("Expected the rewrite of %s to come with a before → after example", dep.API)
+		}
+	}
+}
+
+func TestRuleFileRewrites(t *testing.T) {
+	set, err := parseRuleFile([]byte(`rules:
+  - api: Theme.accentColor
+    pattern: '\b(?P<theme>\w+)\.accentColor\b'
+    rewrite: '${theme}.colorScheme.secondary'
+    example: 'theme.accentColor → theme.colorScheme.secondary'
+    follow_up_pattern: '\baccentColorBrightness\b'
+    follow_up: Derive the brightness from the color scheme
+  - api: OldList
+    pattern: 'OldList\(\s*\)'
+    rewrite_pattern: 'OldList\(\s*\)'
+    rewrite: 'const []'
+`), config.DEPRECATION_SOURCE_CUSTOM)
+	if err != nil {
+		t.Fatalf("parseRuleFile failed: %v", err)
+	}
+	patterns, _ := set.rules(config.TARGET_FLUTTER)
+	if len(patterns) != 2 || patterns[0].followUp == "" || patterns[1].rewrite.String() != `OldList\(\s*\)` {
+		t.Fatalf("Expected both rules with their rewrites, got %+v", patterns)
+	}
+
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	service.SetRuleProviders(staticPatternProvider(patterns))
+	result := service.MigrateCode("final c = theme.accentColor;\nfinal b = accentColorBrightness;\nfinal l = OldList();\n")
+	if result.Code != "final c = theme.colorScheme.secondary;\nfinal b = accentColorBrightness;\nfinal l = const [];\n" {
+		t.Errorf("Expected the named group and the rewrite pattern to be used, got %q", result.Code)
+	}
+	if len(result.Manual) != 1 || result.Manual[0].Line != 2 {
+		t.Errorf("Expected the follow-up on line 2, got %+v", result.Manual)
+	}
+
+	for rule, message := range map[string]string{
+		"pattern: 'a(b)'\n    rewrite: '$2'":                                "refers to group 2, but the pattern has 1",
+		"pattern: 'a(b)'\n    rewrite: '$1x'":                               `refers to group "1x"`,
+		"pattern: 'a(?P<x>b)'\n    rewrite: '${y}'":                         `refers to group "y"`,
+		"pattern: 'a'\n    rewrite_pattern: 'b'":                            "rewrite_pattern needs a rewrite template",
+		"pattern: 'a'\n    rewrite: 'c'\n    rewrite_pattern: '('":          "invalid rewrite_pattern",
+		"pattern: 'a'\n    follow_up: Check it":                             "follow_up_pattern and follow_up go together",
+		"pattern: 'a'\n    rewrite: 'b'\n    example: 'x → b'":              `the pattern does not match the example "x"`,
+		"pattern: 'a'\n    rewrite: 'b'\n    example: 'a → c'":              `turns the example "a" into "b" rather than "c"`,
+		"pattern: 'a'\n    rewrite: '$$1 ${1}'\n    rewrite_pattern: '(a)'": "",
+	} {
+		_, err := parseRuleFile([]byte("rules:\n  - api: A\n    "+rule+"\n"), config.DEPRECATION_SOURCE_CUSTOM)
+		if message == "" && err != nil {
+			t.Errorf("Expected %q to be valid, got %v", rule, err)
+		} else if message != "" && (err == nil || !strings.Contains(err.Error(), message)) {
+			t.Errorf("Expected %q for %q, got %v", message, rule, err)
+		}
+	}
+}
+
+// staticPatternProvider supplies fixed pattern rules
+type staticPatternProvider []deprecationRule
+
+func (s staticPatternProvider) Name() string {
+	return "patterns"
+}
+
+func (s staticPatternProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	return s, nil
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// RuleProvider is a source of deprecation rules, registered with the DeprecationService at startup.
+// Pattern rules are matched by their regular expression and may carry a mechanical rewrite; entries
+// are matched by API name, and those that rename a whole class are rewritten to the replacement.
+type RuleProvider interface {
+	// Name identifies the provider in --rule-providers, such as builtin
+	Name() string
+	// Rules returns the pattern rules and entries that apply to target, as returned by ParseTarget
+	Rules(target string) ([]deprecationRule, []models.Deprecation)
+}
+
+// RuleRefresher is a RuleProvider whose rules can change after startup, such as those from
+// upstream; refreshing the deprecations cache refreshes them too
+type RuleRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// RuleWatcher is a RuleProvider that picks up changes to its rules by itself while the server runs
+type RuleWatcher interface {
+	Watch(ctx context.Context)
+}
+
+// builtinRuleProvider supplies the built-in patterns
+type builtinRuleProvider struct{}
+
+// NewBuiltinRuleProvider creates the provider of the built-in patterns and their rewrites
+func NewBuiltinRuleProvider() RuleProvider {
+	return builtinRuleProvider{}
+}
+
+// Name implements RuleProvider
+func (builtinRuleProvider) Name() string {
+	return config.RULE_PROVIDER_BUILTIN
+}
+
+// Rules implements RuleProvider
+func (builtinRuleProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	if target == config.TARGET_DART {
+		return dartRules, nil
+	}
+	return builtinRules, nil
+}
+
+// cacheRuleProvider supplies the scanned or the manual entries of the deprecations cache
+type cacheRuleProvider struct {
+	cacheService CacheServiceInterface
+	manual       bool
+}
+
+// NewSourceScanRuleProvider creates the provider of the deprecations scanned from the Flutter
+// sources and release notes into the cache, which only apply to Flutter code
+func NewSourceScanRuleProvider(cacheService CacheServiceInterface) RuleProvider {
+	return &cacheRuleProvider{cacheService: cacheService}
+}
+
+// NewManualRuleProvider creates the provider of the manual entries of the cache: those added with
+// add_manual_deprecation, saved repository scans and the entries of the team database. They apply
+// to Dart code too, since they may come from Dart packages.
+func NewManualRuleProvider(cacheService CacheServiceInterface) RuleProvider {
+	return &cacheRuleProvider{cacheService: cacheService, manual: true}
+}
+
+// Name implements RuleProvider
+func (c *cacheRuleProvider) Name() string {
+	if c.manual {
+		return config.RULE_PROVIDER_MANUAL
+	}
+	return config.RULE_PROVIDER_SOURCE_SCAN
+}
+
+// Rules implements RuleProvider. The copies of the built-in rules an update stores in the cache
+// are left to the builtin provider, so that disabling it takes them out of the checks too.
+func (c *cacheRuleProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	if !c.manual && target == config.TARGET_DART {
+		return nil, nil
+	}
+	cache, err := c.cacheService.Load()
+	if err != nil {
+		return nil, nil
+	}
+	if c.manual {
+		return nil, cache.Manual
+	}
+	entries := make([]models.Deprecation, 0, len(cache.Deprecations))
+	for _, dep := range cache.Deprecations {
+		if dep.Source != config.DEPRECATION_SOURCE_BUILTIN {
+			entries = append(entries, dep)
+		}
+	}
+	return nil, entries
+}
+
+// SelectRuleProviders picks the providers named in value, a comma separated list in order of
+// precedence, from the registered ones. A list of names that all start with - instead disables
+// those and keeps the others in their registered order; an empty value keeps them all.
+func SelectRuleProviders(value string, registered ...RuleProvider) ([]RuleProvider, error) {
+	byName := make(map[string]RuleProvider, len(registered))
+	names := make([]string, 0, len(registered))
+	for _, provider := range registered {
+		byName[provider.Name()] = provider
+		names = append(names, provider.Name())
+	}
+
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return registered, nil
+	}
+
+	disabled := make(map[string]bool)
+	var selected []RuleProvider
+	for _, part := range parts {
+		name, disable := strings.CutPrefix(part, "-")
+		if disable != strings.HasPrefix(parts[0], "-") {
+			return nil, fmt.Errorf("%q mixes enabled and disabled rule providers; list the ones to use or only the ones to leave out", value)
+		}
+		provider, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule provider %q, expected %s", name, strings.Join(names, ", "))
+		}
+		if disable {
+			disabled[name] = true
+		} else if !containsProvider(selected, name) {
+			selected = append(selected, provider)
+		}
+	}
+	if len(disabled) > 0 {
+		for _, provider := range registered {
+			if !disabled[provider.Name()] {
+				selected = append(selected, provider)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// containsProvider reports whether providers holds one named name
+func containsProvider(providers []RuleProvider, name string) bool {
+	for _, provider := range providers {
+		if provider.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRuleProviders replaces the rule providers the checks draw on. Earlier providers take
+// precedence when several have an entry for the same API.
+func (d *DeprecationService) SetRuleProviders(providers ...RuleProvider) {
+	d.providers = providers
+}
+
+// RuleProviders returns the names of the rule providers the checks draw on, in order of precedence
+func (d *DeprecationService) RuleProviders() []string {
+	names := make([]string, 0, len(d.providers))
+	for _, provider := range d.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// rules merges the pattern rules and entries of the rule providers for target. A pattern rule or
+// entry for an API an earlier provider already has one for is left out, so that a rules file can
+// override what the Flutter sources say.
+func (d *DeprecationService) rules(target string) ([]deprecationRule, []models.Deprecation) {
+	var patterns []deprecationRule
+	var entries []models.Deprecation
+	patternAPIs := make(map[string]bool)
+	entryAPIs := make(map[string]bool)
+	for _, provider := range d.providers {
+		providerPatterns, providerEntries := provider.Rules(target)
+		var added []string
+		for _, rule := range providerPatterns {
+			if !patternAPIs[rule.deprecation.API] {
+				patterns = append(patterns, rule)
+				added = append(added, rule.deprecation.API)
+			}
+		}
+		for _, api := range added {
+			patternAPIs[api] = true
+		}
+
+		added = added[:0]
+		for _, dep := range providerEntries {
+			key := dep.API + "\x00" + dep.Package
+			if !entryAPIs[key] {
+				entries = append(entries, dep)
+				added = append(added, key)
+			}
+		}
+		for _, key := range added {
+			entryAPIs[key] = true
+		}
+	}
+	return patterns, entries
+}
+
+// RulesFingerprint identifies everything the checks of a target depend on besides the code: the
+// rules of every provider, so that cached scan results are dropped when any of them changes
+func (d *DeprecationService) RulesFingerprint(target string) string {
+	patterns, entries := d.rules(target)
+	return rulesFingerprint(target, patterns, entries)
+}
+
+// RefreshRuleProviders refreshes the providers whose rules can change, keeping the rules of those
+// that fail
+func (d *DeprecationService) RefreshRuleProviders(ctx context.Context) {
+	for _, provider := range d.providers {
+		if refresher, ok := provider.(RuleRefresher); ok {
+			if err := refresher.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh rule provider", "provider", provider.Name(), "error", err)
+			}
+		}
+	}
+}
+
+// WatchRuleProviders starts the watchers of the providers that have one, which stop when ctx is cancelled
+func (d *DeprecationService) WatchRuleProviders(ctx context.Context) {
+	for _, provider := range d.providers {
+		if watcher, ok := provider.(RuleWatcher); ok {
+			go watcher.Watch(ctx)
+		}
+	}
+}
+
+// rulesFingerprint hashes the target with what its pattern rules and entries find and suggest.
+// The timestamps and provenance of the entries, which every cache refresh rewrites, are left out so
+// that a refresh that changes no rule keeps the cached scan results.
+func rulesFingerprint(target string, patterns []deprecationRule, entries []models.Deprecation) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", target)
+	for _, rule := range patterns {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00", rule.pattern, rule.rewrite, rule.template, rule.followUpPattern, rule.followUp)
+		writeFindingFields(h, rule.deprecation)
+	}
+	for _, dep := range entries {
+		writeFindingFields(h, dep)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeFindingFields writes the fields of dep that decide its findings and their severity to h
+func writeFindingFields(h io.Writer, dep models.Deprecation) {
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%t\x00%t\n", dep.API, dep.Package, dep.Replacement, dep.Version, dep.Severity, dep.Removed, dep.Upcoming)
+}
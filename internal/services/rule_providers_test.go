@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// staticRuleProvider supplies fixed entries under a name
+type staticRuleProvider struct {
+	name    string
+	entries []models.Deprecation
+}
+
+func (s staticRuleProvider) Name() string {
+	return s.name
+}
+
+func (s staticRuleProvider) Rules(target string) ([]deprecationRule, []models.Deprecation) {
+	return nil, s.entries
+}
+
+func TestSelectRuleProviders(t *testing.T) {
+	registered := []RuleProvider{staticRuleProvider{name: "builtin"}, staticRuleProvider{name: "manual"}, staticRuleProvider{name: "remote"}}
+	names := func(providers []RuleProvider) []string {
+		var found []string
+		for _, provider := range providers {
+			found = append(found, provider.Name())
+		}
+		return found
+	}
+
+	for value, expected := range map[string][]string{
+		"":                 {"builtin", "manual", "remote"},
+		"remote, Builtin":  {"remote", "builtin"},
+		"manual,manual":    {"manual"},
+		"-remote":          {"builtin", "manual"},
+		"-builtin,-remote": {"manual"},
+	} {
+		providers, err := SelectRuleProviders(value, registered...)
+		if err != nil || !reflect.DeepEqual(names(providers), expected) {
+			t.Errorf("SelectRuleProviders(%q): expected %v, got %v (%v)", value, expected, names(providers), err)
+		}
+	}
+	for _, invalid := range []string{"builtin,-remote", "github"} {
+		if _, err := SelectRuleProviders(invalid, registered...); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestRuleProviderPrecedence(t *testing.T) {
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	team := staticRuleProvider{name: "team", entries: []models.Deprecation{{API: "LegacyCard", Replacement: "AppCard", Source: "team"}}}
+	scan := staticRuleProvider{name: "scan", entries: []models.Deprecation{
+		{API: "LegacyCard", Replacement: "Card", Source: "scan"},
+		{API: "LegacyList", Replacement: "AppList", Source: "scan"},
+	}}
+
+	service.SetRuleProviders(team, scan)
+	found := service.CheckCodeForDeprecations("LegacyCard(); LegacyList();")
+	if len(found) != 2 || found[0].Source != "team" || found[1].API != "LegacyList" {
+		t.Errorf("Expected the team entry to win over the scanned one, got %+v", found)
+	}
+	if migrated := service.MigrateCode("LegacyCard()").Code; migrated != "AppCard()" {
+		t.Errorf("Expected the team replacement, got %q", migrated)
+	}
+	teamFirst := service.RulesFingerprint(config.TARGET_FLUTTER)
+
+	service.SetRuleProviders(scan, team)
+	if found := service.CheckCodeForDeprecations("LegacyCard()"); len(found) != 1 || found[0].Source != "scan" {
+		t.Errorf("Expected the scanned entry to win, got %+v", found)
+	}
+	if service.RulesFingerprint(config.TARGET_FLUTTER) == teamFirst {
+		t.Error("Expected another order of providers to change the fingerprint")
+	}
+	if !reflect.DeepEqual(service.RuleProviders(), []string{"scan", "team"}) {
+		t.Errorf("Expected the providers in order, got %v", service.RuleProviders())
+	}
+}
+
+func TestRulesFingerprintIgnoresRefreshes(t *testing.T) {
+	fingerprint := func(dep models.Deprecation) string {
+		service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+		service.SetRuleProviders(staticRuleProvider{name: "scan", entries: []models.Deprecation{dep}})
+		return service.RulesFingerprint(config.TARGET_FLUTTER)
+	}
+	scanned := models.Deprecation{API: "LegacyCard", Replacement: "Card", Version: "3.10.0", Source: config.DEPRECATION_SOURCE_FLUTTER, FirstSeen: time.Unix(1, 0)}
+	before := fingerprint(scanned)
+
+	refreshed := scanned
+	refreshed.LastSeen = time.Now()
+	refreshed.SourceLine = 42
+	if fingerprint(refreshed) != before {
+		t.Error("Expected a refresh that only confirms the entry to keep the fingerprint")
+	}
+
+	refreshed.Replacement = "AppCard"
+	if fingerprint(refreshed) == before {
+		t.Error("Expected a new replacement to change the fingerprint")
+	}
+}
+
+func TestDisabledBuiltinRules(t *testing.T) {
+	cache := &CacheService{dir: t.TempDir()}
+	service := NewDeprecationService(cache, NewFlutterAPIService())
+	// An update stores copies of the built-in rules among the scanned deprecations
+	if err := cache.Save(&models.DeprecationCache{Deprecations: append(service.knownDeprecations(), models.Deprecation{API: "Ticker.muted", Source: config.DEPRECATION_SOURCE_FLUTTER})}); err != nil {
+		t.Fatal(err)
+	}
+
+	code := "FlatButton(); ticker.muted; Ticker.muted"
+	if found := service.CheckCodeForDeprecations(code); len(found) < 2 {
+		t.Fatalf("Expected the built-in and scanned deprecations, got %+v", found)
+	}
+
+	service.SetRuleProviders(NewSourceScanRuleProvider(cache))
+	found := service.CheckCodeForDeprecations(code)
+	if len(found) != 1 || found[0].API != "Ticker.muted" {
+		t.Errorf("Expected only the scanned deprecation without the builtin provider, got %+v", found)
+	}
+	if found := service.CheckDartCode("Ticker.muted"); len(found) != 0 {
+		t.Errorf("Expected scanned Flutter entries to be skipped for Dart code, got %+v", found)
+	}
+}
+
+func TestCustomRuleProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.CUSTOM_RULES_FILE)
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	provider := NewCustomRuleProvider(path)
+	service.SetRuleProviders(provider, NewBuiltinRuleProvider())
+
+	if found := service.CheckCodeForDeprecations("LegacyCard()"); len(found) != 0 {
+		t.Errorf("Expected no rules without a rules file, got %+v", found)
+	}
+
+	writeFile(t, path, `rules:
+  - api: LegacyCard
+    replacement: AppCard
+    package: acme_ui
+  - api: Theme.legacyColor
+    pattern: '\.legacyColor\b'
+    rewrite: '.primaryColor'
+    severity: error
+    target: flutter
+  - api: Logger.warn
+    target: dart
+`)
+	service.RefreshRuleProviders(context.Background())
+	code := "import 'package:acme_ui/acme_ui.dart';\nfinal c = LegacyCard(color: theme.legacyColor);\n"
+	found := service.CheckCodeForDeprecations(code)
+	if len(found) != 2 || found[0].API != "Theme.legacyColor" || found[0].Severity != config.SEVERITY_ERROR || found[1].Source != config.DEPRECATION_SOURCE_CUSTOM {
+		t.Errorf("Expected the custom pattern and entry, got %+v", found)
+	}
+	migrated := service.MigrateCode(code).Code
+	if !strings.Contains(migrated, "AppCard(color: theme.primaryColor)") {
+		t.Errorf("Expected the custom rewrite and rename, got %q", migrated)
+	}
+	if found := service.CheckDartCode("log.warn(); Logger.warn"); len(found) != 1 || found[0].API != "Logger.warn" {
+		t.Errorf("Expected only the Dart rule for Dart code, got %+v", found)
+	}
+
+	writeFile(t, path, "rules:\n  - api: [\n")
+	service.RefreshRuleProviders(context.Background())
+	if found := service.CheckCodeForDeprecations(code); len(found) != 2 {
+		t.Errorf("Expected an invalid edit to keep the last rules, got %+v", found)
+	}
+	if err := ValidateRulesFile(path); err == nil || !strings.Contains(err.Error(), "invalid rules file") {
+		t.Errorf("Expected the invalid file to be reported, got %v", err)
+	}
+}
+
+func TestCustomRuleProviderWatch(t *testing.T) {
+	dir := t.TempDir()
+	teamRules := filepath.Join(dir, "team")
+	writeFile(t, filepath.Join(teamRules, "cards.yaml"), "rules:\n  - api: LegacyCard\n    replacement: AppCard\n")
+	writeFile(t, filepath.Join(teamRules, "notes.md"), "Not a rules file")
+	appRules := filepath.Join(dir, "app.json")
+	writeFile(t, appRules, `{"rules": [{"api": "LegacyList"}]}`)
+
+	provider := NewCustomRuleProvider(teamRules, appRules, filepath.Join(dir, "missing.yaml"))
+	provider.(*customRuleProvider).interval = 10 * time.Millisecond
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	service.SetRuleProviders(provider)
+	if found := service.CheckCodeForDeprecations("LegacyCard(); LegacyList(); LegacyText()"); len(found) != 2 {
+		t.Fatalf("Expected the rules of the directory and the file, got %+v", found)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.WatchRuleProviders(ctx)
+	waitFor := func(what string, count int) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if len(service.CheckCodeForDeprecations("LegacyCard(); LegacyList(); LegacyText()")) == count {
+				return
+			}
+		}
+		t.Fatalf("Expected %s to be picked up", what)
+	}
+
+	writeFile(t, filepath.Join(teamRules, "text.yml"), "rules:\n  - api: LegacyText\n")
+	waitFor("a new file", 3)
+	if err := os.Remove(appRules); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("a deleted file", 2)
+	writeFile(t, filepath.Join(teamRules, "cards.yaml"), "rules:\n  - api: [\n")
+	time.Sleep(50 * time.Millisecond)
+	waitFor("an invalid edit keeping the rules", 2)
+
+	if err := ValidateRulesFile(teamRules); err == nil || !strings.Contains(err.Error(), "cards.yaml: invalid rules file") {
+		t.Errorf("Expected the invalid file of the directory to be named, got %v", err)
+	}
+}
+
+func TestCustomRuleProviderSettle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	writeFile(t, path, "rules:\n  - api: LegacyCard\n")
+	provider := NewCustomRuleProvider(path).(*customRuleProvider)
+	apis := func() string {
+		_, entries := provider.Rules(config.TARGET_FLUTTER)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.API)
+		}
+		return strings.Join(names, ",")
+	}
+	if found := apis(); found != "LegacyCard" {
+		t.Fatalf("Expected the rules of the file, got %q", found)
+	}
+
+	// An editor that truncates the file first leaves a half-written file that still parses
+	saved := time.Now().Add(time.Minute)
+	save := func(content string, modTime time.Time) {
+		t.Helper()
+		writeFile(t, path, content)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("rules:\n  - api: Legacy", saved)
+	provider.reload(true)
+	if found := apis(); found != "LegacyCard" {
+		t.Errorf("Expected the half-written file to wait for the next check, got %q", found)
+	}
+	save("rules:\n  - api: LegacyCard\n  - api: LegacyText\n", saved.Add(time.Second))
+	provider.reload(true)
+	if found := apis(); found != "LegacyCard" {
+		t.Errorf("Expected the finished save to wait until it is stable, got %q", found)
+	}
+	provider.reload(true)
+	if found := apis(); found != "LegacyCard,LegacyText" {
+		t.Errorf("Expected the settled file to be reloaded, got %q", found)
+	}
+	provider.reload(true)
+	if found := apis(); found != "LegacyCard,LegacyText" {
+		t.Errorf("Expected the reloaded rules to stay, got %q", found)
+	}
+}
+
+func TestParseRuleFileErrors(t *testing.T) {
+	for content, message := range map[string]string{
+		"rules:\n  - replacement: X\n":                        "rule 1 has no api",
+		"rules:\n  - api: A\n    severity: fatal\n":           "unknown severity",
+		"rules:\n  - api: A\n    target: web\n":               "unknown target",
+		"rules:\n  - api: A\n    rewrite: B\n":                "a rewrite or follow-up needs a pattern",
+		"rules:\n  - api: A\n    tests:\n      match: [A]\n":  "tests need a pattern",
+		"rules:\n  - api: A\n  - api: B\n    pattern: '(x'\n": "rule 2 (B): invalid pattern",
+	} {
+		if _, err := parseRuleFile([]byte(content), config.DEPRECATION_SOURCE_CUSTOM); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected %q for %q, got %v", message, content, err)
+		}
+	}
+
+	// JSON is YAML too
+	set, err := parseRuleFile([]byte(`{"rules": [{"api": "OldWidget", "replacement": "NewWidget"}]}`), config.DEPRECATION_SOURCE_REMOTE)
+	if _, entries := set.rules(config.TARGET_DART); err != nil || len(entries) != 1 || entries[0].Description != "OldWidget is deprecated, use NewWidget instead" {
+		t.Errorf("Expected a JSON rules file to parse, got %+v (%v)", entries, err)
+	}
+}
+
+func TestRemoteRuleProvider(t *testing.T) {
+	status := http.StatusOK
+	padding := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("rules:\n  - api: LegacyCard\n    replacement: AppCard\n"))
+		w.Write([]byte(strings.Repeat("#", padding)))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	copyPath := filepath.Join(cacheDir, config.REMOTE_RULES_FILE)
+	apiService := &FlutterAPIService{client: server.Client()}
+	provider := NewRemoteRuleProvider(server.URL+"/rules.yaml", copyPath, apiService)
+	service := NewDeprecationService(&CacheService{dir: cacheDir}, apiService)
+	service.SetRuleProviders(provider)
+
+	if found := service.CheckCodeForDeprecations("LegacyCard()"); len(found) != 0 {
+		t.Errorf("Expected no rules before the first refresh, got %+v", found)
+	}
+	service.RefreshRuleProviders(context.Background())
+	if found := service.CheckCodeForDeprecations("LegacyCard()"); len(found) != 1 || found[0].Source != config.DEPRECATION_SOURCE_REMOTE {
+		t.Errorf("Expected the downloaded rule, got %+v", found)
+	}
+
+	// A failed download keeps the rules, and a restart offline reads the stored copy
+	status = http.StatusBadGateway
+	if err := provider.(RuleRefresher).Refresh(context.Background()); err == nil || !strings.Contains(err.Error(), "status 502") {
+		t.Errorf("Expected the failed download to be reported, got %v", err)
+	}
+	status, padding = http.StatusOK, config.REMOTE_RULES_MAX_SIZE
+	if err := provider.(RuleRefresher).Refresh(context.Background()); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Errorf("Expected an oversized rules document to be refused, got %v", err)
+	}
+	offline := NewRemoteRuleProvider(server.URL+"/rules.yaml", copyPath, apiService)
+	if _, entries := offline.Rules(config.TARGET_FLUTTER); len(entries) != 1 || entries[0].Replacement != "AppCard" {
+		t.Errorf("Expected the stored copy to be used, got %+v", entries)
+	}
+	if _, entries := NewRemoteRuleProvider("", copyPath, apiService).Rules(config.TARGET_FLUTTER); len(entries) != 0 {
+		t.Errorf("Expected no rules without a URL, got %+v", entries)
+	}
+}
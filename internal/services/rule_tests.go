@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// expectComment matches the trailing comment of a sample line that names the deprecated APIs the
+// line should be flagged for, such as // expect: RaisedButton, Color.withOpacity
+var expectComment = regexp.MustCompile(`//\s*expect:([^\n]*)`)
+
+// TestRules checks the rules of the providers for target. Each pattern rule is run against the
+// fixtures of its rules file. The annotated samples, code and the Dart file or files below
+// samplesPath, are checked like any other code: a line has to be flagged for exactly the APIs its
+// trailing // expect: comment names, and a line without one for none. Only the rules whose API
+// contains filter are tested.
+func (d *DeprecationService) TestRules(ctx context.Context, target string, samplesPath string, code string, filter string) (*models.RuleTestResult, error) {
+	target, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	selected := func(api string) bool {
+		return filter == "" || strings.Contains(strings.ToLower(api), filter)
+	}
+
+	result := &models.RuleTestResult{Target: target, Failures: []models.RuleTestFailure{}}
+	fail := func(dep models.Deprecation, kind string, file string, line int, code string) {
+		result.Failures = append(result.Failures, models.RuleTestFailure{API: dep.API, Source: dep.Source, Kind: kind, File: file, Line: line, Code: code})
+	}
+
+	patterns, _ := d.rules(target)
+	for _, rule := range patterns {
+		dep := rule.deprecation
+		if !selected(dep.API) {
+			continue
+		}
+		result.Rules++
+		if len(rule.matches) == 0 && len(rule.nonMatches) == 0 {
+			result.Untested = append(result.Untested, dep.API)
+			continue
+		}
+		for _, fixture := range rule.matches {
+			if !rule.pattern.MatchString(fixture) {
+				fail(dep, config.RULE_TEST_FALSE_NEGATIVE, "", 0, fixture)
+			}
+		}
+		for _, fixture := range rule.nonMatches {
+			if rule.pattern.MatchString(fixture) {
+				fail(dep, config.RULE_TEST_FALSE_POSITIVE, "", 0, fixture)
+			}
+		}
+		result.Fixtures += len(rule.matches) + len(rule.nonMatches)
+	}
+
+	check := func(file string, sample string) {
+		result.Samples++
+		lines := strings.Split(sample, "\n")
+
+		// The expectations are blanked out so that the APIs they name are not found in them
+		expected := make(map[int]map[string]bool)
+		code := expectComment.ReplaceAllStringFunc(sample, func(comment string) string {
+			return strings.Repeat(" ", len(comment))
+		})
+		for i, line := range lines {
+			match := expectComment.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			for _, api := range strings.Split(match[1], ",") {
+				if api = strings.TrimSpace(api); api != "" && selected(api) {
+					if expected[i+1] == nil {
+						expected[i+1] = make(map[string]bool)
+					}
+					expected[i+1][api] = true
+					result.Expectations++
+				}
+			}
+		}
+		source := func(line int) string {
+			return strings.TrimSpace(expectComment.ReplaceAllString(lines[line-1], ""))
+		}
+
+		found := make(map[int]map[string]bool)
+		for _, use := range d.deprecatedUses(code, target) {
+			line := lineAt(code, use.start)
+			if !selected(use.dep.API) || found[line][use.dep.API] {
+				continue
+			}
+			if found[line] == nil {
+				found[line] = make(map[string]bool)
+			}
+			found[line][use.dep.API] = true
+			if !expected[line][use.dep.API] {
+				fail(use.dep, config.RULE_TEST_FALSE_POSITIVE, file, line, source(line))
+			}
+		}
+
+		var missing []int
+		for line := range expected {
+			missing = append(missing, line)
+		}
+		sort.Ints(missing)
+		for _, line := range missing {
+			var apis []string
+			for api := range expected[line] {
+				if !found[line][api] {
+					apis = append(apis, api)
+				}
+			}
+			sort.Strings(apis)
+			for _, api := range apis {
+				fail(models.Deprecation{API: api}, config.RULE_TEST_FALSE_NEGATIVE, file, line, source(line))
+			}
+		}
+	}
+
+	if strings.TrimSpace(code) != "" {
+		check("", code)
+	}
+	if samplesPath != "" {
+		info, err := os.Stat(samplesPath)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{samplesPath}
+		root := filepath.Dir(samplesPath)
+		if info.IsDir() {
+			if files, err = walkDartFiles(ctx, samplesPath, walkOptions{}); err != nil {
+				return nil, err
+			}
+			root = samplesPath
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			check(relativeSlash(root, file), string(data))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestBuiltinRuleFixtures(t *testing.T) {
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	service.SetRuleProviders(NewBuiltinRuleProvider())
+	for _, target := range []string{config.TARGET_FLUTTER, config.TARGET_DART} {
+		result, err := service.TestRules(context.Background(), target, "", "", "")
+		if err != nil {
+			t.Fatalf("TestRules failed: %v", err)
+		}
+		if result.Rules == 0 || result.Fixtures < 2*result.Rules || len(result.Untested) != 0 {
+			t.Errorf("Expected every %s rule to come with fixtures, got %d fixtures for %d rules, untested %v", target, result.Fixtures, result.Rules, result.Untested)
+		}
+		for _, failure := range result.Failures {
+			t.Errorf("%s fixture of %s failed: %q", failure.Kind, failure.API, failure.Code)
+		}
+	}
+}
+
+func TestRuleSamples(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "lib", "buttons.dart"), `final a = RaisedButton(onPressed: null); // expect: RaisedButton
+final b = OutlineButton(onPressed: null);
+final c = FlatButton(onPressed: null); // expect: FlatButton, Scaffold.of(context).showSnackBar
+`)
+	writeFile(t, filepath.Join(dir, "lib", "clean.dart"), "final d = ElevatedButton(onPressed: null); // expect:\n")
+
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	service.SetRuleProviders(NewBuiltinRuleProvider())
+	result, err := service.TestRules(context.Background(), "", dir, "", "")
+	if err != nil {
+		t.Fatalf("TestRules failed: %v", err)
+	}
+	if result.Samples != 2 || result.Expectations != 3 || len(result.Failures) != 2 {
+		t.Fatalf("Expected 2 samples with 3 expectations and 2 failures, got %+v", result)
+	}
+	positive, negative := result.Failures[0], result.Failures[1]
+	if positive.Kind != config.RULE_TEST_FALSE_POSITIVE || positive.API != "OutlineButton" || positive.File != "lib/buttons.dart" || positive.Line != 2 || positive.Code != "final b = OutlineButton(onPressed: null);" {
+		t.Errorf("Expected the unannotated OutlineButton on line 2, got %+v", positive)
+	}
+	if negative.Kind != config.RULE_TEST_FALSE_NEGATIVE || negative.API != "Scaffold.of(context).showSnackBar" || negative.Line != 3 {
+		t.Errorf("Expected the missing showSnackBar finding on line 3, got %+v", negative)
+	}
+
+	// The filter limits the rules, fixtures and expectations to the matching APIs
+	result, err = service.TestRules(context.Background(), config.TARGET_FLUTTER, filepath.Join(dir, "lib", "buttons.dart"), "RaisedButton(); OutlineButton(); // expect: RaisedButton", "raised")
+	if err != nil {
+		t.Fatalf("TestRules failed: %v", err)
+	}
+	if result.Rules != 1 || result.Samples != 2 || result.Expectations != 2 || len(result.Failures) != 0 {
+		t.Errorf("Expected only the RaisedButton rule to be tested, got %+v", result)
+	}
+
+	if _, err := service.TestRules(context.Background(), "web", "", "", ""); err == nil {
+		t.Error("Expected an unknown target to fail")
+	}
+	if _, err := service.TestRules(context.Background(), "", filepath.Join(dir, "missing"), "", ""); err == nil {
+		t.Error("Expected missing samples to fail")
+	}
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// desktopPlatforms are the runner folders flutter create generates for the desktop targets
+var desktopPlatforms = []string{"windows", "linux", "macos"}
+
+// runnerTemplateDirs are the template folders that hold the desktop runners, newest layout first
+var runnerTemplateDirs = []string{"app_shared", "app"}
+
+// skippedRunnerTemplates are template files that Xcode and CocoaPods rewrite in every project
+var skippedRunnerTemplates = map[string]bool{
+	"project.pbxproj": true,
+}
+
+// templateTag matches a mustache variable or section tag of a flutter create template
+var templateTag = regexp.MustCompile(`\{\{([#^/]?)\s*([\w.]+)\s*\}\}`)
+
+// staleRunnerPattern is runner code whose template changed in a Flutter release. Patterns with
+// missing set describe what the current template contains and flag files that lack it. They are
+// checked without a local SDK, so projects get the well-known fixes even when the templates of
+// the target version are not installed.
+type staleRunnerPattern struct {
+	platform string
+	file     string
+	pattern  *regexp.Regexp
+	missing  bool
+	version  string
+	api      string
+	message  string
+}
+
+// staleRunnerPatterns are the runner template changes worth regenerating for
+var staleRunnerPatterns = []staleRunnerPattern{
+	{
+		platform: "windows",
+		file:     "runner/main.cpp",
+		pattern:  regexp.MustCompile(`\.CreateAndShow\(`),
+		version:  "3.10.0",
+		api:      "Win32Window::CreateAndShow",
+		message:  "The window is created hidden and shown after the first frame to avoid a white flash: call window.Create(...) and let FlutterWindow show it",
+	},
+	{
+		platform: "windows",
+		file:     "runner/flutter_window.cpp",
+		pattern:  regexp.MustCompile(`\bSetNextFrameCallback\b`),
+		missing:  true,
+		version:  "3.10.0",
+		api:      "FlutterWindow::OnCreate",
+		message:  "OnCreate should show the window once Flutter has rendered: flutter_controller_->engine()->SetNextFrameCallback([&]() { this->Show(); });",
+	},
+	{
+		platform: "windows",
+		file:     "runner/Runner.rc",
+		pattern:  regexp.MustCompile(`\bFLUTTER_VERSION\b`),
+		missing:  true,
+		version:  "3.3.0",
+		api:      "Runner.rc version",
+		message:  "The version resource is hard-coded; the template takes it from the pubspec version through the FLUTTER_VERSION defines",
+	},
+	{
+		platform: "macos",
+		file:     "Runner/AppDelegate.swift",
+		pattern:  regexp.MustCompile(`@NSApplicationMain\b`),
+		version:  "3.24.0",
+		api:      "@NSApplicationMain",
+		message:  "@NSApplicationMain is deprecated since Swift 5.10 (Xcode 15.3); the template uses @main",
+	},
+	{
+		platform: "macos",
+		file:     "Runner/AppDelegate.swift",
+		pattern:  regexp.MustCompile(`\bapplicationSupportsSecureRestorableState\b`),
+		missing:  true,
+		version:  "3.24.0",
+		api:      "applicationSupportsSecureRestorableState",
+		message:  "macOS 14 warns that secure coding is not enabled for restorable state; the template overrides applicationSupportsSecureRestorableState to return true",
+	},
+}
+
+// RunnerTemplateService compares the desktop runners of a project with the templates that
+// flutter create generates in a Flutter version
+type RunnerTemplateService struct {
+	sdks LocalSDKServiceInterface
+}
+
+// NewRunnerTemplateService creates a new runner template service instance
+func NewRunnerTemplateService(sdks LocalSDKServiceInterface) *RunnerTemplateService {
+	return &RunnerTemplateService{sdks: sdks}
+}
+
+// Check compares the windows, linux and macos folders of a project (or the given platforms) with
+// the templates of version, which defaults to the project's pinned version and then the active
+// SDK. The templates are read from a local SDK of that version; without one only the known stale
+// patterns are checked and a warning says so.
+func (r *RunnerTemplateService) Check(ctx context.Context, projectPath string, version string, platforms []string) (*models.RunnerDriftReport, error) {
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", projectPath)
+	}
+
+	platforms, err = runnerPlatforms(projectPath, platforms)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RunnerDriftReport{ProjectPath: projectPath, Platforms: platforms}
+	if version = strings.TrimPrefix(strings.TrimSpace(version), "v"); version != "" {
+		if _, ok := parseVersion(version); !ok {
+			return nil, fmt.Errorf("invalid Flutter version %q", version)
+		}
+		report.FlutterVersion, report.VersionSource = version, "argument"
+	}
+
+	var sdk *models.LocalFlutterSDK
+	sdks, err := r.sdks.Detect(ctx, projectPath)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Local SDKs could not be listed: %v", err))
+	} else {
+		if report.FlutterVersion == "" && sdks.PinnedVersion != "" {
+			report.FlutterVersion, report.VersionSource = sdks.PinnedVersion, sdks.PinnedBy
+		}
+		for i := range sdks.SDKs {
+			if report.FlutterVersion == "" && sdks.SDKs[i].Active && sdks.SDKs[i].Version != "" {
+				report.FlutterVersion, report.VersionSource = sdks.SDKs[i].Version, "active SDK"
+			}
+		}
+		if report.FlutterVersion != "" {
+			if sdk, err = findLocalSDK(sdks.SDKs, report.FlutterVersion); err != nil {
+				report.Warnings = append(report.Warnings, err.Error()+"; only known stale template code was checked")
+			}
+		}
+	}
+	if report.FlutterVersion == "" {
+		report.Warnings = append(report.Warnings, "No Flutter version given or pinned and no active SDK found; only known stale template code was checked")
+	}
+	if sdk != nil {
+		report.SDKPath = sdk.Path
+	}
+
+	values := map[string]string{"projectName": filepath.Base(projectPath)}
+	if spec, err := readPubspec(projectPath); err == nil && spec.Name != "" {
+		values["projectName"] = spec.Name
+	}
+
+	for _, platform := range platforms {
+		if sdk != nil {
+			files, err := compareRunner(ctx, sdk.Path, projectPath, platform, values)
+			if err != nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", platform, err))
+			}
+			report.Files = append(report.Files, files...)
+		}
+		report.Issues = append(report.Issues, staleRunnerIssues(projectPath, platform, report.FlutterVersion)...)
+	}
+	return report, nil
+}
+
+// runnerPlatforms validates the requested platforms, defaulting to every runner folder present
+func runnerPlatforms(projectPath string, platforms []string) ([]string, error) {
+	if len(platforms) == 0 {
+		for _, platform := range desktopPlatforms {
+			if info, err := os.Stat(filepath.Join(projectPath, platform)); err == nil && info.IsDir() {
+				platforms = append(platforms, platform)
+			}
+		}
+		if len(platforms) == 0 {
+			return nil, fmt.Errorf("no windows, linux or macos runner folder found in %s", projectPath)
+		}
+		return platforms, nil
+	}
+
+	var selected []string
+	for _, platform := range platforms {
+		platform = strings.ToLower(strings.TrimSpace(platform))
+		if !containsString(desktopPlatforms, platform) {
+			return nil, fmt.Errorf("unknown platform %q, expected windows, linux or macos", platform)
+		}
+		if info, err := os.Stat(filepath.Join(projectPath, platform)); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("no %s runner folder found in %s", platform, projectPath)
+		}
+		if !containsString(selected, platform) {
+			selected = append(selected, platform)
+		}
+	}
+	return selected, nil
+}
+
+// findLocalSDK selects the installed SDK for version like findSDKVersion: the newest release of
+// the line for a major.minor version, an exact match otherwise
+func findLocalSDK(sdks []models.LocalFlutterSDK, version string) (*models.LocalFlutterSDK, error) {
+	wanted, ok := parseVersion(version)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	lineOnly := isLineOnly(version)
+
+	var match *models.LocalFlutterSDK
+	var matched semanticVersion
+	installed := make([]string, 0, len(sdks))
+	for i := range sdks {
+		v, ok := parseVersion(sdks[i].Version)
+		if !ok {
+			continue
+		}
+		installed = append(installed, sdks[i].Version)
+		switch {
+		case lineOnly && sameLine(v, wanted) && v.Prerelease == "":
+			if match == nil || v.Compare(matched) > 0 {
+				match, matched = &sdks[i], v
+			}
+		case !lineOnly && v.Compare(wanted) == 0:
+			return &sdks[i], nil
+		}
+	}
+	if match != nil {
+		return match, nil
+	}
+
+	if len(installed) == 0 {
+		return nil, fmt.Errorf("no local Flutter SDK found; install Flutter %s with fvm install %s", version, version)
+	}
+	return nil, fmt.Errorf("no local SDK for Flutter %s (installed: %s); install it with fvm install %s", version, strings.Join(installed, ", "), version)
+}
+
+// compareRunner renders the runner template of a platform and compares every file with the project
+func compareRunner(ctx context.Context, sdkPath string, projectPath string, platform string, values map[string]string) ([]models.RunnerFileDrift, error) {
+	var templateRoot string
+	for _, dir := range runnerTemplateDirs {
+		candidate := filepath.Join(sdkPath, filepath.FromSlash(config.SDK_TEMPLATES), dir, platform+".tmpl")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			templateRoot = candidate
+			break
+		}
+	}
+	if templateRoot == "" {
+		return nil, fmt.Errorf("the SDK at %s has no %s runner template", sdkPath, platform)
+	}
+
+	var files []models.RunnerFileDrift
+	err := filepath.WalkDir(templateRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".img.tmpl") {
+			return nil
+		}
+
+		relative, err := filepath.Rel(templateRoot, path)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		verbatim := strings.HasSuffix(relative, ".copy.tmpl")
+		relative = strings.TrimSuffix(strings.TrimSuffix(relative, ".copy.tmpl"), ".tmpl")
+		if skippedRunnerTemplates[filepath.Base(relative)] {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		expected := string(data)
+		if !verbatim && strings.HasSuffix(path, ".tmpl") {
+			expected = renderTemplate(expected, values)
+		}
+
+		drift := models.RunnerFileDrift{Platform: platform, File: platform + "/" + relative}
+		actual, err := os.ReadFile(filepath.Join(projectPath, platform, filepath.FromSlash(relative)))
+		if err != nil {
+			drift.Status = config.RUNNER_FILE_MISSING
+		} else {
+			drift.Added, drift.Removed = diffTemplateLines(expected, string(actual))
+			drift.Status = config.RUNNER_FILE_CURRENT
+			if len(drift.Added) > 0 || len(drift.Removed) > 0 {
+				drift.Status = config.RUNNER_FILE_STALE
+			}
+		}
+		files = append(files, drift)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].File < files[j].File
+	})
+	return files, nil
+}
+
+// renderTemplate fills in the known variables of a flutter create template and drops its
+// sections, which only plugin and module projects enable; inverted sections are kept. Variables
+// the server cannot know, such as the organization, keep their tags.
+func renderTemplate(content string, values map[string]string) string {
+	var out strings.Builder
+	var skipping []bool
+	last := 0
+	skip := func() bool {
+		return len(skipping) > 0 && skipping[len(skipping)-1]
+	}
+
+	for _, match := range templateTag.FindAllStringSubmatchIndex(content, -1) {
+		if !skip() {
+			out.WriteString(content[last:match[0]])
+		}
+		last = match[1]
+
+		kind, name := content[match[2]:match[3]], content[match[4]:match[5]]
+		switch kind {
+		case "#":
+			skipping = append(skipping, true)
+		case "^":
+			skipping = append(skipping, skip())
+		case "/":
+			if len(skipping) > 0 {
+				skipping = skipping[:len(skipping)-1]
+			}
+		default:
+			if skip() {
+				continue
+			}
+			if value, known := values[name]; known {
+				out.WriteString(value)
+			} else {
+				out.WriteString(content[match[0]:match[1]])
+			}
+		}
+	}
+	if !skip() {
+		out.WriteString(content[last:])
+	}
+	return out.String()
+}
+
+// diffTemplateLines compares a rendered template with a project file line by line, ignoring
+// indentation and blank lines. Template lines with unknown variables match any value. Added are
+// the template lines the file lacks, Removed the file lines the template no longer has.
+func diffTemplateLines(expected string, actual string) (added []string, removed []string) {
+	expectedLines := normalizedLines(expected)
+	actualLines := normalizedLines(actual)
+
+	present := make(map[string]bool, len(actualLines))
+	for _, line := range actualLines {
+		present[line] = true
+	}
+
+	known := make(map[string]bool, len(expectedLines))
+	var loose []*regexp.Regexp
+	for _, line := range expectedLines {
+		if !templateTag.MatchString(line) {
+			known[line] = true
+			if !present[line] {
+				added = append(added, line)
+			}
+			continue
+		}
+		pattern := regexp.MustCompile("^" + templateTag.ReplaceAllString(regexp.QuoteMeta(line), ".*") + "$")
+		loose = append(loose, pattern)
+		matched := false
+		for _, candidate := range actualLines {
+			if pattern.MatchString(candidate) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			added = append(added, line)
+		}
+	}
+
+	for _, line := range actualLines {
+		if known[line] || matchesAny(loose, line) {
+			continue
+		}
+		removed = append(removed, line)
+	}
+	return added, removed
+}
+
+// normalizedLines splits a file into trimmed, non-blank lines, each listed once
+func normalizedLines(content string) []string {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// matchesAny reports whether any of the patterns matches line
+func matchesAny(patterns []*regexp.Regexp, line string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// staleRunnerIssues applies the known stale patterns of a platform for a Flutter version, which
+// may be empty when it is unknown
+func staleRunnerIssues(projectPath string, platform string, version string) []models.RunnerIssue {
+	var issues []models.RunnerIssue
+	for _, stale := range staleRunnerPatterns {
+		if stale.platform != platform {
+			continue
+		}
+		if version != "" {
+			if cmp, ok := CompareVersions(version, stale.version); ok && cmp < 0 {
+				continue
+			}
+		}
+		data, err := os.ReadFile(filepath.Join(projectPath, platform, filepath.FromSlash(stale.file)))
+		if err != nil {
+			continue
+		}
+
+		content := string(data)
+		issue := models.RunnerIssue{
+			Platform: platform,
+			File:     platform + "/" + stale.file,
+			API:      stale.api,
+			Version:  stale.version,
+			Message:  stale.message,
+		}
+		loc := stale.pattern.FindStringIndex(content)
+		switch {
+		case stale.missing && loc == nil:
+			issues = append(issues, issue)
+		case !stale.missing && loc != nil:
+			issue.Line = lineAt(content, loc[0])
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
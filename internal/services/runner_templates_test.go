@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// writeTree writes files relative to root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(name)), content)
+	}
+}
+
+const windowsMainTemplate = `#include "flutter_window.h"
+
+int APIENTRY wWinMain(_In_ HINSTANCE instance, _In_opt_ HINSTANCE prev) {
+  FlutterWindow window(project);
+  Win32Window::Point origin(10, 10);
+  Win32Window::Size size(1280, 720);
+  if (!window.Create(L"{{projectName}}", origin, size)) {
+    return EXIT_FAILURE;
+  }
+{{#withPlatformChannelPluginHook}}
+  RegisterPluginHook(window);
+{{/withPlatformChannelPluginHook}}
+  return EXIT_SUCCESS;
+}
+`
+
+func TestRunnerTemplateService(t *testing.T) {
+	home := t.TempDir()
+	sdk := filepath.Join(home, "fvm", "versions", "3.27.1")
+	writeFakeSDK(t, sdk, "3.27.1", "stable")
+	templates := filepath.Join(sdk, filepath.FromSlash(config.SDK_TEMPLATES), "app_shared", "windows.tmpl")
+	writeTree(t, templates, map[string]string{
+		"runner/main.cpp.tmpl":                   windowsMainTemplate,
+		"runner/utils.h":                         "#ifndef RUNNER_UTILS_H_\n#define RUNNER_UTILS_H_\n#endif\n",
+		"runner/resources/app_icon.ico.img.tmpl": "",
+		"runner/runner.exe.manifest":             "<assembly/>\n",
+		"CMakeLists.txt.tmpl":                    "project({{projectName}} LANGUAGES CXX)\nset(BINARY_NAME \"{{projectName}}\")\ncmake_policy(VERSION 3.14...3.25)\n",
+	})
+
+	project := t.TempDir()
+	writeTree(t, project, map[string]string{
+		"pubspec.yaml": "name: tasks\n",
+		".fvmrc":       `{"flutter": "3.27"}`,
+		"windows/runner/main.cpp": "#include \"flutter_window.h\"\n\n" +
+			"int APIENTRY wWinMain(_In_ HINSTANCE instance, _In_opt_ HINSTANCE prev) {\n" +
+			"  FlutterWindow window(project);\n" +
+			"  Win32Window::Point origin(10, 10);\n" +
+			"  Win32Window::Size size(1280, 720);\n" +
+			"  if (!window.CreateAndShow(L\"tasks\", origin, size)) {\n" +
+			"    return EXIT_FAILURE;\n" +
+			"  }\n" +
+			"  return EXIT_SUCCESS;\n" +
+			"}\n",
+		"windows/runner/utils.h":         "#ifndef RUNNER_UTILS_H_\r\n#define RUNNER_UTILS_H_\r\n\r\n#endif\r\n",
+		"windows/CMakeLists.txt":         "project(tasks LANGUAGES CXX)\nset(BINARY_NAME \"tasks\")\ncmake_policy(SET CMP0063 NEW)\n",
+		"macos/Runner/AppDelegate.swift": "import Cocoa\nimport FlutterMacOS\n\n@NSApplicationMain\nclass AppDelegate: FlutterAppDelegate {}\n",
+	})
+
+	service := NewRunnerTemplateService(&LocalSDKService{home: home, installPaths: []string{}})
+	ctx := context.Background()
+
+	t.Run("Compares the runners with the pinned version's templates", func(t *testing.T) {
+		report, err := service.Check(ctx, project, "", nil)
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		if report.FlutterVersion != "3.27" || report.VersionSource != ".fvmrc" || report.SDKPath != sdk {
+			t.Errorf("Expected the 3.27.1 SDK for the .fvmrc pin, got %q from %q at %q", report.FlutterVersion, report.VersionSource, report.SDKPath)
+		}
+		if strings.Join(report.Platforms, ",") != "windows,macos" {
+			t.Errorf("Expected the windows and macos folders, got %v", report.Platforms)
+		}
+
+		statuses := make(map[string]string)
+		for _, file := range report.Files {
+			statuses[file.File] = file.Status
+		}
+		expected := map[string]string{
+			"windows/CMakeLists.txt":             config.RUNNER_FILE_STALE,
+			"windows/runner/main.cpp":            config.RUNNER_FILE_STALE,
+			"windows/runner/runner.exe.manifest": config.RUNNER_FILE_MISSING,
+			"windows/runner/utils.h":             config.RUNNER_FILE_CURRENT,
+		}
+		if len(statuses) != len(expected) {
+			t.Errorf("Expected %d files without the icon, got %v", len(expected), statuses)
+		}
+		for file, status := range expected {
+			if statuses[file] != status {
+				t.Errorf("Expected %s to be %s, got %q", file, status, statuses[file])
+			}
+		}
+
+		for _, file := range report.Files {
+			if file.File != "windows/runner/main.cpp" {
+				continue
+			}
+			// The project name is filled in and the plugin hook section dropped
+			if len(file.Added) != 1 || file.Added[0] != `if (!window.Create(L"tasks", origin, size)) {` {
+				t.Errorf("Expected only the Create call to be added, got %q", file.Added)
+			}
+			if len(file.Removed) != 1 || !strings.Contains(file.Removed[0], "CreateAndShow") {
+				t.Errorf("Expected only the CreateAndShow call to be removed, got %q", file.Removed)
+			}
+		}
+
+		found := make(map[string]int)
+		for _, issue := range report.Issues {
+			found[issue.API] = issue.Line
+		}
+		if found["Win32Window::CreateAndShow"] != 7 {
+			t.Errorf("Expected CreateAndShow on line 7, got %v", found)
+		}
+		if _, ok := found["@NSApplicationMain"]; !ok {
+			t.Errorf("Expected @NSApplicationMain, got %v", found)
+		}
+		if _, ok := found["FlutterWindow::OnCreate"]; ok {
+			t.Errorf("Expected no issue for a missing flutter_window.cpp, got %v", found)
+		}
+		if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "macos") {
+			t.Errorf("Expected a warning for the missing macos template, got %q", report.Warnings)
+		}
+	})
+
+	t.Run("Older target versions and missing SDKs", func(t *testing.T) {
+		report, err := service.Check(ctx, project, "3.7.12", []string{"Windows"})
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		if len(report.Files) != 0 || len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "fvm install 3.7.12") {
+			t.Errorf("Expected a warning to install 3.7.12, got %+v", report)
+		}
+		// CreateAndShow is still what flutter create generates before 3.10
+		if len(report.Issues) != 0 {
+			t.Errorf("Expected no stale code for 3.7, got %+v", report.Issues)
+		}
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		if _, err := service.Check(ctx, project, "", []string{"android"}); err == nil {
+			t.Error("Expected an error for a non-desktop platform")
+		}
+		if _, err := service.Check(ctx, project, "", []string{"linux"}); err == nil {
+			t.Error("Expected an error for a missing linux folder")
+		}
+		if _, err := service.Check(ctx, t.TempDir(), "", nil); err == nil {
+			t.Error("Expected an error for a project without desktop runners")
+		}
+		if _, err := service.Check(ctx, project, "three", nil); err == nil {
+			t.Error("Expected an error for an invalid version")
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// scanCache holds the deprecations found in the files of earlier project scans, keyed by the
+// SHA-256 of their contents, so that a repeated scan only checks the files that changed. The
+// results are only valid for the rules of the providers they were found with, which the
+// fingerprint identifies.
+type scanCache struct {
+	Fingerprint string                          `json:"fingerprint"`
+	Files       map[string][]models.Deprecation `json:"files"`
+}
+
+// ClearScanCache removes the per-file scan results and the project scan baselines kept in cacheDir
+func ClearScanCache(cacheDir string) error {
+	err := os.Remove(filepath.Join(cacheDir, config.SCAN_CACHE_FILE))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(cacheDir, config.PROJECT_SCANS_DIR))
+}
+
+// contentHash returns the key of a file's contents in the scan cache
+func contentHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookup returns the deprecations stored for the contents with key; a nil cache has none
+func (c *scanCache) lookup(key string) ([]models.Deprecation, bool) {
+	if c == nil {
+		return nil, false
+	}
+	deprecations, ok := c.Files[key]
+	return deprecations, ok
+}
+
+// loadScanCache reads the scan cache at path, returning an empty one when it is missing, unreadable
+// or was filled with other rules than fingerprint identifies
+func loadScanCache(path string, fingerprint string) *scanCache {
+	empty := &scanCache{Fingerprint: fingerprint, Files: make(map[string][]models.Deprecation)}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return empty
+	}
+
+	var cache scanCache
+	if json.Unmarshal(data, &cache) != nil || cache.Fingerprint != fingerprint || cache.Files == nil {
+		return empty
+	}
+	return &cache
+}
+
+// storeScanCache writes the results of a scan to path through a temporary file, adding them to the
+// stored ones. When that would exceed MAX_SCAN_CACHE_ENTRIES only the results of this scan are kept.
+func storeScanCache(path string, cache *scanCache, used map[string][]models.Deprecation) error {
+	files := cache.Files
+	if len(files)+len(used) > config.MAX_SCAN_CACHE_ENTRIES {
+		files = make(map[string][]models.Deprecation, len(used))
+	}
+	for key, deprecations := range used {
+		files[key] = deprecations
+	}
+
+	data, err := json.Marshal(&scanCache{Fingerprint: cache.Fingerprint, Files: files})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to path through a temporary file in the same directory, so that
+// readers never see a partly written file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// countingDeprecationService counts the files the scans actually check
+type countingDeprecationService struct {
+	*DeprecationService
+	checks int
+}
+
+func (c *countingDeprecationService) CheckCodeForDeprecations(code string) []models.Deprecation {
+	c.checks++
+	return c.DeprecationService.CheckCodeForDeprecations(code)
+}
+
+func TestScanCache(t *testing.T) {
+	cache := &CacheService{dir: t.TempDir()}
+	deprecations := &countingDeprecationService{DeprecationService: NewDeprecationService(cache, NewFlutterAPIService())}
+	service := NewProjectScanService(deprecations)
+	service.SetScanCache(filepath.Join(cache.dir, config.SCAN_CACHE_FILE))
+	ctx := context.Background()
+
+	project := t.TempDir()
+	writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: app\n")
+	writeFile(t, filepath.Join(project, "lib", "main.dart"), "RaisedButton(onPressed: save)\n")
+	writeFile(t, filepath.Join(project, "lib", "card.dart"), "LegacyCard()\n")
+	writeFile(t, filepath.Join(project, "lib", "theme.dart"), "Text('fine')\n")
+
+	scan := func() *models.ProjectScanResult {
+		t.Helper()
+		result, err := service.Scan(ctx, project, "", models.PathFilter{})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		return result
+	}
+	apis := func(result *models.ProjectScanResult) map[string]string {
+		found := make(map[string]string)
+		for _, finding := range result.Packages[0].Findings {
+			for _, dep := range finding.Deprecations {
+				found[finding.Name] = dep.API
+			}
+		}
+		return found
+	}
+
+	t.Run("Only checks the files that changed", func(t *testing.T) {
+		first := scan()
+		if first.Cached != 0 || deprecations.checks != 3 {
+			t.Fatalf("Expected all 3 files to be checked, got %d checks and %d cached", deprecations.checks, first.Cached)
+		}
+
+		second := scan()
+		if second.Cached != 3 || deprecations.checks != 3 {
+			t.Errorf("Expected the unchanged files to be reused, got %d checks and %d cached", deprecations.checks, second.Cached)
+		}
+		if found := apis(second); len(found) != 1 || found["lib/main.dart"] != "RaisedButton" {
+			t.Errorf("Expected the cached RaisedButton finding, got %v", found)
+		}
+
+		writeFile(t, filepath.Join(project, "lib", "theme.dart"), "Color.red.withOpacity(0.5)\n")
+		third := scan()
+		if third.Cached != 2 || deprecations.checks != 4 {
+			t.Errorf("Expected only theme.dart to be checked again, got %d checks and %d cached", deprecations.checks, third.Cached)
+		}
+		if found := apis(third); found["lib/theme.dart"] != "Color.withOpacity" {
+			t.Errorf("Expected the new finding in theme.dart, got %v", found)
+		}
+	})
+
+	t.Run("Checks everything again when the deprecations change", func(t *testing.T) {
+		if err := cache.Save(&models.DeprecationCache{Manual: []models.Deprecation{{API: "LegacyCard", Replacement: "AppCard", Source: "manual"}}}); err != nil {
+			t.Fatal(err)
+		}
+		before := deprecations.checks
+		result := scan()
+		if result.Cached != 0 || deprecations.checks != before+3 {
+			t.Errorf("Expected a new manual entry to invalidate the cache, got %d checks and %d cached", deprecations.checks-before, result.Cached)
+		}
+		if found := apis(result); found["lib/card.dart"] != "LegacyCard" {
+			t.Errorf("Expected the manual entry to be found, got %v", found)
+		}
+
+		if deprecations.RulesFingerprint(config.TARGET_FLUTTER) == deprecations.RulesFingerprint(config.TARGET_DART) {
+			t.Error("Expected the Flutter and Dart checks to have their own fingerprints")
+		}
+	})
+
+	t.Run("Survives a damaged cache file and clears it", func(t *testing.T) {
+		path := filepath.Join(cache.dir, config.SCAN_CACHE_FILE)
+		writeFile(t, path, "{not json")
+		before := deprecations.checks
+		if result := scan(); result.Cached != 0 || deprecations.checks != before+3 {
+			t.Errorf("Expected a damaged cache to be ignored, got %d cached", result.Cached)
+		}
+
+		if err := ClearScanCache(cache.dir); err != nil {
+			t.Fatalf("ClearScanCache failed: %v", err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected the scan cache to be removed, got %v", err)
+		}
+		if err := ClearScanCache(cache.dir); err != nil {
+			t.Errorf("Expected clearing a missing cache to succeed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// ProjectScanReport builds the machine-readable report of a project scan, leaving out the APIs in
+// hidden, such as the suppressed ones. The findings of each file are listed most urgent first and
+// fail the scan from the threshold severity on.
+func ProjectScanReport(result *models.ProjectScanResult, hidden map[string]bool, threshold string) *models.ScanReport {
+	report := &models.ScanReport{ProjectPath: result.ProjectPath, FailOn: threshold, Baselined: result.Baselined, Findings: []models.ReportFinding{}}
+	for _, pkg := range result.Packages {
+		report.Files += pkg.Files
+		for _, finding := range pkg.Findings {
+			if finding.Error != "" {
+				report.Unreadable = append(report.Unreadable, models.UnreadableFile{Path: finding.Name, Error: finding.Error})
+				continue
+			}
+			for _, dep := range ByUrgency(finding.Deprecations) {
+				addReportFinding(report, models.ReportFinding{Package: pkg.Name, Path: finding.Name, Deprecation: dep}, hidden)
+			}
+		}
+	}
+	return report
+}
+
+// DiffScanReport builds the machine-readable report of a changed line check, like
+// ProjectScanReport, keeping the findings in the order of the files and lines
+func DiffScanReport(result *models.DiffScanResult, hidden map[string]bool, threshold string) *models.ScanReport {
+	report := &models.ScanReport{ProjectPath: result.ProjectPath, Change: result.Change, Files: result.Files, FailOn: threshold, Findings: []models.ReportFinding{}}
+	for _, finding := range result.Findings {
+		addReportFinding(report, models.ReportFinding{Path: finding.Path, Line: finding.Line, Column: finding.Column, Deprecation: finding.Deprecation}, hidden)
+	}
+	return report
+}
+
+// addReportFinding adds a finding to report, or counts it as suppressed when its API is hidden
+func addReportFinding(report *models.ScanReport, finding models.ReportFinding, hidden map[string]bool) {
+	if hidden[finding.Deprecation.API] {
+		report.Suppressed++
+		return
+	}
+	finding.Age = DeprecationAge(finding.Deprecation)
+	finding.Fails = SeverityAtLeast(finding.Deprecation, report.FailOn)
+	if finding.Fails {
+		report.Failing++
+	}
+	report.Findings = append(report.Findings, finding)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestProjectScanReport(t *testing.T) {
+	raised := models.Deprecation{API: "RaisedButton", Severity: config.SEVERITY_ERROR, Version: "2.0.0"}
+	opacity := models.Deprecation{API: "Color.withOpacity", Severity: config.SEVERITY_WARNING, Version: "3.27.0"}
+	result := &models.ProjectScanResult{
+		ProjectPath: "/ws",
+		Baselined:   2,
+		Packages: []models.PackageScanResult{
+			{Name: "app", Path: ".", Files: 4, Findings: []models.FileCheckResult{
+				{Name: "lib/a.dart", Deprecations: []models.Deprecation{opacity, raised}},
+				{Name: "lib/big.dart", Error: "file too large"},
+			}},
+			{Name: "core", Path: "packages/core", Files: 2, Findings: []models.FileCheckResult{
+				{Name: "packages/core/lib/c.dart", Deprecations: []models.Deprecation{{API: "FlatButton"}}},
+			}},
+		},
+	}
+
+	report := ProjectScanReport(result, map[string]bool{"FlatButton": true}, config.SEVERITY_ERROR)
+	if report.Files != 6 || report.Suppressed != 1 || report.Baselined != 2 || report.Failing != 1 || report.FailOn != config.SEVERITY_ERROR {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if len(report.Findings) != 2 || report.Findings[0].Deprecation.API != "RaisedButton" || !report.Findings[0].Fails || report.Findings[1].Fails {
+		t.Fatalf("Expected RaisedButton to fail first and Color.withOpacity to pass, got %+v", report.Findings)
+	}
+	if report.Findings[0].Package != "app" || report.Findings[0].Age != "deprecated 14 releases ago, removal likely" {
+		t.Errorf("Expected the package and age, got %+v", report.Findings[0])
+	}
+	if len(report.Unreadable) != 1 || report.Unreadable[0].Path != "lib/big.dart" {
+		t.Errorf("Expected the unreadable file, got %+v", report.Unreadable)
+	}
+}
+
+func TestDiffScanReport(t *testing.T) {
+	result := &models.DiffScanResult{ProjectPath: "/app", Change: "staged changes", Files: 1, Findings: []models.LineFinding{
+		{Path: "lib/a.dart", Line: 3, Column: 5, Deprecation: models.Deprecation{API: "RaisedButton"}},
+	}}
+	report := DiffScanReport(result, nil, config.SEVERITY_INFO)
+	if report.Change != "staged changes" || report.Failing != 1 || len(report.Findings) != 1 || report.Findings[0].Line != 3 || report.Findings[0].Column != 5 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report := DiffScanReport(&models.DiffScanResult{ProjectPath: "/app"}, nil, config.SEVERITY_INFO); report.Findings == nil {
+		t.Error("Expected an empty list of findings rather than null")
+	}
+}
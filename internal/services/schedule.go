@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when the next background refresh runs
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// everySchedule runs at a fixed interval
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// clockSchedule runs every day at hour:minute, or every hour at minute when hour is negative
+type clockSchedule struct {
+	hour, minute int
+}
+
+func (s clockSchedule) Next(after time.Time) time.Time {
+	if s.hour < 0 {
+		next := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), s.minute, 0, 0, after.Location())
+		if !next.After(after) {
+			next = next.Add(time.Hour)
+		}
+		return next
+	}
+	next := time.Date(after.Year(), after.Month(), after.Day(), s.hour, s.minute, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// ParseSchedule reads a cron-like refresh schedule: @hourly, @daily (midnight), @every <duration>,
+// a daily time such as 03:30, or a cron expression of the form "M H * * *" or "M * * * *"
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		return clockSchedule{hour: -1, minute: 0}, nil
+	case "@daily", "@midnight":
+		return clockSchedule{hour: 0, minute: 0}, nil
+	}
+
+	if interval, found := strings.CutPrefix(spec, "@every "); found {
+		duration, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval in %q: %v", spec, err)
+		}
+		if duration < time.Minute {
+			return nil, fmt.Errorf("interval in %q must be at least one minute", spec)
+		}
+		return everySchedule{interval: duration}, nil
+	}
+
+	if hour, minute, found := strings.Cut(spec, ":"); found {
+		return clockFields(spec, minute, hour)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) == 5 && fields[2] == "*" && fields[3] == "*" && fields[4] == "*" {
+		return clockFields(spec, fields[0], fields[1])
+	}
+
+	return nil, fmt.Errorf("unsupported schedule %q (use @hourly, @daily, @every 6h, 03:30 or \"30 3 * * *\")", spec)
+}
+
+// clockFields builds a clock schedule from minute and hour fields; an hour of "*" means every hour
+func clockFields(spec string, minuteField string, hourField string) (Schedule, error) {
+	minute, err := strconv.Atoi(minuteField)
+	if err != nil || minute < 0 || minute > 59 {
+		return nil, fmt.Errorf("invalid minute in schedule %q", spec)
+	}
+	if hourField == "*" {
+		return clockSchedule{hour: -1, minute: minute}, nil
+	}
+	hour, err := strconv.Atoi(hourField)
+	if err != nil || hour < 0 || hour > 23 {
+		return nil, fmt.Errorf("invalid hour in schedule %q", spec)
+	}
+	return clockSchedule{hour: hour, minute: minute}, nil
+}
+
+// RefreshScheduler runs a refresh function in the background whenever its schedule is due
+type RefreshScheduler struct {
+	schedule Schedule
+	refresh  func(context.Context) error
+	now      func() time.Time
+}
+
+// NewRefreshScheduler creates a scheduler that calls refresh at every time the schedule produces
+func NewRefreshScheduler(schedule Schedule, refresh func(context.Context) error) *RefreshScheduler {
+	return &RefreshScheduler{schedule: schedule, refresh: refresh, now: time.Now}
+}
+
+// Run blocks, refreshing on schedule, until ctx is cancelled. Failed refreshes are logged and
+// retried at the next scheduled time.
+func (r *RefreshScheduler) Run(ctx context.Context) {
+	for {
+		next := r.schedule.Next(r.now())
+		slog.Debug("Next scheduled cache refresh", "at", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(next.Sub(r.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		if err := r.refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("Scheduled cache refresh failed", "error", err)
+			continue
+		}
+		slog.Info("Scheduled cache refresh finished", "duration", time.Since(start).Round(time.Millisecond))
+	}
+}
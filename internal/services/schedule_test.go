@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	now := time.Date(2024, 5, 10, 14, 20, 0, 0, time.UTC)
+
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"@daily", time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)},
+		{"@every 6h", time.Date(2024, 5, 10, 20, 20, 0, 0, time.UTC)},
+		{"03:30", time.Date(2024, 5, 11, 3, 30, 0, 0, time.UTC)},
+		{"18:05", time.Date(2024, 5, 10, 18, 5, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 5, 11, 3, 30, 0, 0, time.UTC)},
+		{"45 * * * *", time.Date(2024, 5, 10, 14, 45, 0, 0, time.UTC)},
+		{"20 * * * *", time.Date(2024, 5, 10, 15, 20, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule failed: %v", err)
+			}
+			if next := schedule.Next(now); !next.Equal(tc.expected) {
+				t.Errorf("Expected %s, got %s", tc.expected, next)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "weekly", "@every 5s", "25:00", "0 3 * * 1", "x 3 * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestRefreshScheduler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	scheduler := NewRefreshScheduler(everySchedule{interval: 5 * time.Millisecond}, func(ctx context.Context) error {
+		// A failing refresh is retried at the next scheduled time instead of stopping the loop
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return fmt.Errorf("upstream unavailable")
+		}
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for atomic.LoadInt32(&calls) < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("Expected at least 3 refreshes, got %d", atomic.LoadInt32(&calls))
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to return after cancellation")
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// environmentKeyPattern matches the sdk and flutter lines of the environment: block, keeping
+	// their indentation, quotes and trailing comment apart from the constraint
+	environmentKeyPattern = regexp.MustCompile(`^(\s+)(sdk|flutter)(\s*:\s*)(["']?)([^"'#]*?)(["']?)(\s*#.*)?$`)
+
+	// flutterDependencyPattern matches a dependency on the Flutter SDK, which makes a package need a flutter constraint
+	flutterDependencyPattern = regexp.MustCompile(`(?m)^\s+sdk:\s*["']?flutter\b`)
+
+	// constraintLowerBoundPattern matches the lower bound of a constraint such as ^3.6.0 or ">=3.6.0 <4.0.0"
+	constraintLowerBoundPattern = regexp.MustCompile(`^\s*(?:\^|>=\s*)?(\d+\.\d+\.\d+\S*)`)
+)
+
+// SDKConstraintService edits the environment: block of a pubspec.yaml to the sdk and flutter
+// constraints of a target Flutter release
+type SDKConstraintService struct {
+	apiService FlutterAPIServiceInterface
+}
+
+// NewSDKConstraintService creates a new SDK constraint service instance
+func NewSDKConstraintService(apiService FlutterAPIServiceInterface) *SDKConstraintService {
+	return &SDKConstraintService{apiService: apiService}
+}
+
+// Suggest raises the constraints of a pubspec.yaml, given as content or read from projectPath,
+// to the Flutter release target (the latest stable release when empty) and the Dart SDK it
+// bundles. Constraints that already require the target or newer are kept.
+func (s *SDKConstraintService) Suggest(ctx context.Context, content string, projectPath string, target string) (*models.SDKConstraintSuggestion, error) {
+	path := "pubspec.yaml"
+	if content == "" {
+		if projectPath == "" {
+			return nil, fmt.Errorf("no pubspec.yaml given")
+		}
+		data, err := os.ReadFile(filepath.Join(projectPath, path))
+		if err != nil {
+			return nil, err
+		}
+		content = string(data)
+	}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var spec pubspec
+	if err := yaml.Unmarshal([]byte(content), &spec); err != nil {
+		return nil, fmt.Errorf("invalid pubspec.yaml: %v", err)
+	}
+
+	target = strings.TrimPrefix(strings.TrimSpace(target), "v")
+	if target == "" {
+		latest, err := s.apiService.GetLatestStableVersion(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting latest stable version: %v", err)
+		}
+		target = latest
+	}
+	if _, ok := parseVersion(target); !ok {
+		return nil, fmt.Errorf("invalid version %q", target)
+	}
+
+	suggestion := &models.SDKConstraintSuggestion{
+		TargetFlutter:  target,
+		CurrentSDK:     spec.Environment["sdk"],
+		CurrentFlutter: spec.Environment["flutter"],
+	}
+	suggestion.TargetDart, suggestion.Notes = s.bundledDart(ctx, target)
+
+	suggestion.SuggestedSDK = raiseConstraint(suggestion.CurrentSDK, suggestion.TargetDart, true)
+	if suggestion.SuggestedSDK == suggestion.CurrentSDK {
+		suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("The sdk constraint already requires Dart %s or newer", suggestion.TargetDart))
+	}
+	if _, declared := spec.Environment["flutter"]; declared || flutterDependencyPattern.MatchString(content) {
+		suggestion.SuggestedFlutter = raiseConstraint(suggestion.CurrentFlutter, target, false)
+		if suggestion.SuggestedFlutter == suggestion.CurrentFlutter {
+			suggestion.Notes = append(suggestion.Notes, fmt.Sprintf("The flutter constraint already requires Flutter %s or newer", target))
+		}
+	}
+
+	before := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	after, block := editEnvironment(before, suggestion.SuggestedSDK, suggestion.SuggestedFlutter)
+	suggestion.Block = strings.Join(block, "\n") + "\n"
+	suggestion.Patch = unifiedDiff(path, before, after)
+	return suggestion, nil
+}
+
+// bundledDart returns the Dart SDK version the target Flutter release ships with, from the
+// official releases API or, when the release is not listed there, from the release table
+func (s *SDKConstraintService) bundledDart(ctx context.Context, target string) (string, []string) {
+	releases, err := s.apiService.FetchOfficialReleases(ctx)
+	if err == nil {
+		for _, release := range releases.Releases {
+			if strings.TrimPrefix(release.Version, "v") == target && release.DartSDKVersion != "" {
+				return strings.Fields(release.DartSDKVersion)[0], nil
+			}
+		}
+	}
+
+	for _, pair := range dartForFlutter {
+		if cmp, ok := CompareVersions(target, pair.flutter); ok && cmp >= 0 {
+			return pair.dart, []string{fmt.Sprintf("Flutter %s is not in the official releases list; Dart %s is the SDK of its release line", target, pair.dart)}
+		}
+	}
+	oldest := dartForFlutter[len(dartForFlutter)-1]
+	return oldest.dart, []string{fmt.Sprintf("Flutter %s predates the release table; assuming Dart %s", target, oldest.dart)}
+}
+
+// raiseConstraint returns the constraint requiring at least version, keeping the caret or range
+// style of current and current itself when it already requires version or newer. Dart constraints
+// get the upper bound of the next major version; flutter constraints, whose upper bound pub
+// ignores, get none.
+func raiseConstraint(current string, version string, upperBound bool) string {
+	if matches := constraintLowerBoundPattern.FindStringSubmatch(current); matches != nil {
+		if cmp, ok := CompareVersions(matches[1], version); ok && cmp >= 0 {
+			return current
+		}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(current), "^") {
+		return "^" + version
+	}
+	if !upperBound {
+		return ">=" + version
+	}
+	parsed, _ := parseVersion(version)
+	return fmt.Sprintf(">=%s <%d.0.0", version, parsed.Major+1)
+}
+
+// editEnvironment writes the sdk and flutter constraints into the environment: block of the
+// pubspec lines, adding the block or the flutter line when they are missing. An empty flutter
+// constraint leaves the flutter line alone. It returns the edited lines and the edited block.
+func editEnvironment(lines []string, sdk string, flutter string) ([]string, []string) {
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(stripYAMLComment(line)) == "environment:" && !strings.HasPrefix(line, " ") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		block := []string{"environment:", "  sdk: " + quoteConstraint(sdk, "")}
+		if flutter != "" {
+			block = append(block, "  flutter: "+quoteConstraint(flutter, ""))
+		}
+		edited := append(append([]string{}, lines...), "")
+		return append(edited, block...), block
+	}
+
+	end := start + 1
+	for end < len(lines) && (strings.TrimSpace(lines[end]) == "" || strings.HasPrefix(lines[end], " ") || strings.HasPrefix(lines[end], "\t")) {
+		end++
+	}
+	// Blank lines after the block belong to what follows
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	block := append([]string{}, lines[start:end]...)
+	indent, sdkLine, hasFlutter := "  ", -1, false
+	for i, line := range block {
+		matches := environmentKeyPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		indent = matches[1]
+		value := sdk
+		if matches[2] == "flutter" {
+			hasFlutter = true
+			value = flutter
+		} else {
+			sdkLine = i
+		}
+		if value == "" {
+			continue
+		}
+		block[i] = matches[1] + matches[2] + matches[3] + quoteConstraint(value, matches[4]) + matches[7]
+	}
+	if flutter != "" && !hasFlutter {
+		entry := indent + "flutter: " + quoteConstraint(flutter, "")
+		at := len(block)
+		if sdkLine >= 0 {
+			at = sdkLine + 1
+		}
+		block = append(block[:at], append([]string{entry}, block[at:]...)...)
+	}
+	if sdkLine < 0 {
+		block = append(block[:1], append([]string{indent + "sdk: " + quoteConstraint(sdk, "")}, block[1:]...)...)
+	}
+
+	edited := append(append(append([]string{}, lines[:start]...), block...), lines[end:]...)
+	return edited, block
+}
+
+// quoteConstraint quotes a constraint with the quote it had, and with double quotes when YAML
+// needs them, as for ranges starting with > or <
+func quoteConstraint(constraint string, quote string) string {
+	if quote == "" && (strings.HasPrefix(constraint, ">") || strings.HasPrefix(constraint, "<")) {
+		quote = `"`
+	}
+	return quote + constraint + quote
+}
+
+// stripYAMLComment removes a trailing comment from a YAML line
+func stripYAMLComment(line string) string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// unifiedDiff renders the change between two versions of a file as a single-hunk unified diff with
+// three lines of context, or returns "" when they are equal
+func unifiedDiff(path string, before []string, after []string) string {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	if prefix == len(before) && prefix == len(after) {
+		return ""
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	start := max(prefix-3, 0)
+	tail := min(suffix, 3)
+	oldCount := len(before) - suffix + tail - start
+	newCount := len(after) - suffix + tail - start
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n@@ -%d,%d +%d,%d @@\n", path, path, start+1, oldCount, start+1, newCount)
+	for _, line := range before[start:prefix] {
+		b.WriteString(" " + line + "\n")
+	}
+	for _, line := range before[prefix : len(before)-suffix] {
+		b.WriteString("-" + line + "\n")
+	}
+	for _, line := range after[prefix : len(after)-suffix] {
+		b.WriteString("+" + line + "\n")
+	}
+	for _, line := range before[len(before)-suffix : len(before)-suffix+tail] {
+		b.WriteString(" " + line + "\n")
+	}
+	return b.String()
+}
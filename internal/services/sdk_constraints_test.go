@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestSDKConstraintService(t *testing.T) {
+	api := &MockFlutterAPIService{
+		latestStable: "3.27.1",
+		officialReleases: &models.FlutterReleasesResponse{Releases: []models.FlutterOfficialRelease{
+			{Channel: "stable", Version: "3.27.1", DartSDKVersion: "3.6.0"},
+			{Channel: "stable", Version: "3.24.5", DartSDKVersion: "3.5.4"},
+		}},
+	}
+	service := NewSDKConstraintService(api)
+	ctx := context.Background()
+
+	app := "name: my_app\n\nenvironment:\n  sdk: '>=3.0.0 <4.0.0' # Dart 3\n\ndependencies:\n  flutter:\n    sdk: flutter\n"
+
+	t.Run("Raises the constraints to the latest stable release", func(t *testing.T) {
+		suggestion, err := service.Suggest(ctx, app, "", "")
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		if suggestion.TargetFlutter != "3.27.1" || suggestion.TargetDart != "3.6.0" {
+			t.Errorf("Expected Flutter 3.27.1 with Dart 3.6.0, got %+v", suggestion)
+		}
+		if suggestion.SuggestedSDK != ">=3.6.0 <4.0.0" || suggestion.SuggestedFlutter != ">=3.27.1" {
+			t.Errorf("Expected the range raised and a flutter constraint added, got %q and %q", suggestion.SuggestedSDK, suggestion.SuggestedFlutter)
+		}
+
+		expectedBlock := "environment:\n  sdk: '>=3.6.0 <4.0.0' # Dart 3\n  flutter: \">=3.27.1\"\n"
+		if suggestion.Block != expectedBlock {
+			t.Errorf("Expected block %q, got %q", expectedBlock, suggestion.Block)
+		}
+		expectedPatch := "--- a/pubspec.yaml\n+++ b/pubspec.yaml\n@@ -1,7 +1,8 @@\n name: my_app\n \n environment:\n" +
+			"-  sdk: '>=3.0.0 <4.0.0' # Dart 3\n+  sdk: '>=3.6.0 <4.0.0' # Dart 3\n+  flutter: \">=3.27.1\"\n \n dependencies:\n   flutter:\n"
+		if suggestion.Patch != expectedPatch {
+			t.Errorf("Expected patch %q, got %q", expectedPatch, suggestion.Patch)
+		}
+	})
+
+	t.Run("Keeps the caret style and constraints that are new enough", func(t *testing.T) {
+		pubspec := "name: my_app\nenvironment:\n  sdk: ^3.7.0\n  flutter: \">=3.24.0\"\ndependencies:\n  flutter:\n    sdk: flutter\n"
+		suggestion, err := service.Suggest(ctx, pubspec, "", "3.27.1")
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		if suggestion.SuggestedSDK != "^3.7.0" || !strings.Contains(suggestion.Block, "  flutter: \">=3.27.1\"") {
+			t.Errorf("Expected the newer sdk constraint kept and flutter raised, got %+v", suggestion)
+		}
+		if len(suggestion.Notes) != 1 || !strings.Contains(suggestion.Notes[0], "already requires Dart 3.6.0") {
+			t.Errorf("Expected a note on the kept constraint, got %v", suggestion.Notes)
+		}
+
+		suggestion, err = service.Suggest(ctx, "name: tool\nenvironment:\n  sdk: ^3.0.0\n", "", "3.24.5")
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		if suggestion.Block != "environment:\n  sdk: ^3.5.4\n" || suggestion.SuggestedFlutter != "" {
+			t.Errorf("Expected only the sdk constraint of a pure Dart package, got %+v", suggestion)
+		}
+
+		suggestion, _ = service.Suggest(ctx, "name: tool\nenvironment:\n  sdk: ^3.6.0\n", "", "3.27.1")
+		if suggestion.Patch != "" {
+			t.Errorf("Expected no patch when nothing changes, got %q", suggestion.Patch)
+		}
+	})
+
+	t.Run("Adds a missing environment block", func(t *testing.T) {
+		suggestion, err := service.Suggest(ctx, "name: tool\n", "", "3.22.2")
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		// 3.22.2 is not in the official list above, so its release line decides
+		if suggestion.TargetDart != "3.4.0" || len(suggestion.Notes) != 1 {
+			t.Errorf("Expected Dart 3.4.0 from the release table with a note, got %+v", suggestion)
+		}
+		if !strings.HasSuffix(suggestion.Patch, " name: tool\n+\n+environment:\n+  sdk: \">=3.4.0 <4.0.0\"\n") {
+			t.Errorf("Expected the block to be appended, got %q", suggestion.Patch)
+		}
+	})
+
+	t.Run("Reads the pubspec of a project", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "pubspec.yaml"), strings.ReplaceAll(app, "\n", "\r\n"))
+		suggestion, err := service.Suggest(ctx, "", dir, "3.27.1")
+		if err != nil {
+			t.Fatalf("Suggest failed: %v", err)
+		}
+		if suggestion.CurrentSDK != ">=3.0.0 <4.0.0" || suggestion.Patch == "" {
+			t.Errorf("Expected the project pubspec to be edited, got %+v", suggestion)
+		}
+		if _, err := service.Suggest(ctx, "", dir, "latest"); err == nil {
+			t.Error("Expected an error for an invalid target version")
+		}
+	})
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// SDKScanService scans the framework source of the Flutter SDKs installed on the machine, such
+// as the FVM versions, into one set of deprecations per Flutter version. The sets are stored in
+// the cache directory, so each version is read from disk once and compared without network access.
+type SDKScanService struct {
+	mu         sync.Mutex
+	apiService *FlutterAPIService
+	sdks       LocalSDKServiceInterface
+	dir        string
+}
+
+// NewSDKScanService creates a new SDK scan service that keeps the per-version deprecations in dir
+func NewSDKScanService(apiService *FlutterAPIService, sdks LocalSDKServiceInterface, dir string) *SDKScanService {
+	return &SDKScanService{apiService: apiService, sdks: sdks, dir: dir}
+}
+
+// Versions returns the deprecations of every installed Flutter version, oldest first, scanning
+// the SDKs whose version has not been scanned before. SDKs without a readable version are skipped.
+func (s *SDKScanService) Versions(ctx context.Context) ([]models.SDKDeprecations, error) {
+	report, err := s.sdks.Detect(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var versions []models.SDKDeprecations
+	seen := make(map[string]bool)
+	for _, sdk := range report.SDKs {
+		if sdk.Version == "" || seen[sdk.Version] {
+			continue
+		}
+		seen[sdk.Version] = true
+
+		scanned, err := s.load(sdk.Version)
+		if err != nil {
+			if scanned, err = s.scan(ctx, sdk); err != nil {
+				return nil, fmt.Errorf("error scanning the Flutter %s SDK at %s: %v", sdk.Version, sdk.Path, err)
+			}
+		}
+		versions = append(versions, *scanned)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		cmp, _ := CompareVersions(versions[i].Version, versions[j].Version)
+		return cmp < 0
+	})
+	return versions, nil
+}
+
+// Compare returns what changed in the deprecations between two installed Flutter versions. A
+// version without a patch level, such as 3.27, selects the newest installed release of that line.
+func (s *SDKScanService) Compare(ctx context.Context, from string, to string) (*models.VersionComparison, error) {
+	versions, err := s.Versions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before, err := findSDKVersion(versions, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := findSDKVersion(versions, to)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &models.VersionComparison{From: before.Version, To: after.Version}
+	previous := make(map[string]bool)
+	for _, dep := range before.Deprecations {
+		previous[dep.API] = true
+	}
+	current := make(map[string]bool)
+	for _, dep := range uniqueByAPI(after.Deprecations) {
+		current[dep.API] = true
+		if previous[dep.API] {
+			comparison.Unchanged++
+		} else {
+			comparison.Deprecated = append(comparison.Deprecated, dep)
+		}
+	}
+	for _, dep := range uniqueByAPI(before.Deprecations) {
+		if !current[dep.API] {
+			comparison.Removed = append(comparison.Removed, dep)
+		}
+	}
+
+	for _, list := range [][]models.Deprecation{comparison.Deprecated, comparison.Removed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].API < list[j].API })
+	}
+	return comparison, nil
+}
+
+// IntroducedIn returns the deprecations first annotated in an installed Flutter release: those
+// that none of the older installed SDKs has. An exact version is compared with every older SDK,
+// hotfixes of its line included; a major.minor version only with the SDKs of earlier lines.
+func (s *SDKScanService) IntroducedIn(ctx context.Context, version string) (*models.IntroducedDeprecations, error) {
+	versions, err := s.Versions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	release, err := findSDKVersion(versions, version)
+	if err != nil {
+		return nil, err
+	}
+	target, _ := parseVersion(release.Version)
+	lineOnly := isLineOnly(version)
+
+	introduced := &models.IntroducedDeprecations{Version: release.Version}
+	older := make(map[string]bool)
+	for _, sdk := range versions {
+		v, ok := parseVersion(sdk.Version)
+		if !ok || v.Compare(target) >= 0 || (lineOnly && sameLine(v, target)) {
+			continue
+		}
+		// versions are sorted oldest first, so the last one kept is the newest older SDK
+		introduced.Previous = sdk.Version
+		for _, dep := range sdk.Deprecations {
+			older[dep.API] = true
+		}
+	}
+	if introduced.Previous == "" {
+		return nil, fmt.Errorf("no installed Flutter version older than %s to compare with; install the release before it with fvm install", release.Version)
+	}
+
+	for _, dep := range uniqueByAPI(release.Deprecations) {
+		if !older[dep.API] {
+			introduced.Deprecations = append(introduced.Deprecations, dep)
+		}
+	}
+	sort.Slice(introduced.Deprecations, func(i, j int) bool {
+		return introduced.Deprecations[i].API < introduced.Deprecations[j].API
+	})
+	return introduced, nil
+}
+
+// isLineOnly reports whether version names a release line such as 3.27 rather than a release
+func isLineOnly(version string) bool {
+	return strings.Count(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".") == 1
+}
+
+// findSDKVersion selects the scanned SDK for version: the newest release of the line for a
+// major.minor version, an exact match otherwise
+func findSDKVersion(versions []models.SDKDeprecations, version string) (*models.SDKDeprecations, error) {
+	wanted, ok := parseVersion(version)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	lineOnly := isLineOnly(version)
+
+	var match *models.SDKDeprecations
+	installed := make([]string, 0, len(versions))
+	for i := range versions {
+		installed = append(installed, versions[i].Version)
+		v, ok := parseVersion(versions[i].Version)
+		if !ok {
+			continue
+		}
+		switch {
+		case lineOnly && sameLine(v, wanted) && v.Prerelease == "":
+			// versions are sorted oldest first, so the last match is the newest release
+			match = &versions[i]
+		case !lineOnly && v.Compare(wanted) == 0:
+			return &versions[i], nil
+		}
+	}
+	if match != nil {
+		return match, nil
+	}
+
+	if len(installed) == 0 {
+		return nil, fmt.Errorf("no local Flutter SDK found; install Flutter %s with fvm install %s", version, version)
+	}
+	return nil, fmt.Errorf("no local SDK for Flutter %s (installed: %s); install it with fvm install %s", version, strings.Join(installed, ", "), version)
+}
+
+// scan reads the @Deprecated annotations in the framework source of sdk and stores the result
+func (s *SDKScanService) scan(ctx context.Context, sdk models.LocalFlutterSDK) (*models.SDKDeprecations, error) {
+	scanned := &models.SDKDeprecations{
+		Version:   sdk.Version,
+		Channel:   sdk.Channel,
+		SDKPath:   sdk.Path,
+		ScannedAt: time.Now(),
+	}
+
+	root := filepath.Join(sdk.Path, filepath.FromSlash(config.SDK_FRAMEWORK_SOURCE))
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".dart") || isGeneratedDart(entry.Name()) {
+			return nil
+		}
+
+		relative, err := filepath.Rel(sdk.Path, path)
+		if err != nil {
+			return err
+		}
+		sourceFile := filepath.ToSlash(relative)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if limit := s.apiService.scanFileLimit(); info.Size() > limit {
+			slog.Warn("Skipping source file larger than the scan limit", "file", sourceFile, "size", info.Size(), "limit", limit)
+			return nil
+		}
+
+		if err := fileScanSlots.acquire(ctx); err != nil {
+			return err
+		}
+		defer fileScanSlots.release()
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		deprecations, err := s.apiService.scanDeprecations(file, libraryFromSourceURL("/"+sourceFile), sourceFile)
+		if err != nil {
+			slog.Warn("Skipping source file that cannot be scanned", "file", sourceFile, "error", err)
+			return nil
+		}
+		for _, dep := range deprecations {
+			dep.DocURL = apiDocumentationURL(dep)
+			scanned.Deprecations = append(scanned.Deprecations, dep)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scanned, s.save(scanned)
+}
+
+// file returns where the deprecations of a Flutter version are stored
+func (s *SDKScanService) file(version string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(version)
+	return filepath.Join(s.dir, config.SDK_DEPRECATIONS_DIR, name+".json")
+}
+
+// load reads the stored deprecations of a Flutter version
+func (s *SDKScanService) load(version string) (*models.SDKDeprecations, error) {
+	data, err := os.ReadFile(s.file(version))
+	if err != nil {
+		return nil, err
+	}
+	var scanned models.SDKDeprecations
+	if err := json.Unmarshal(data, &scanned); err != nil {
+		return nil, err
+	}
+	return &scanned, nil
+}
+
+// save stores the deprecations of a Flutter version
+func (s *SDKScanService) save(scanned *models.SDKDeprecations) error {
+	path := s.file(scanned.Version)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(scanned, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFrameworkFile adds a Dart file to the framework source of a fake SDK
+func writeFrameworkFile(t *testing.T, root string, file string, content string) {
+	t.Helper()
+	path := filepath.Join(root, "packages", "flutter", "lib", "src", filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSDKScanService(t *testing.T) {
+	home := t.TempDir()
+	older := filepath.Join(home, "fvm", "versions", "3.24.5")
+	writeFakeSDK(t, older, "3.24.5", "stable")
+	writeFrameworkFile(t, older, "material/colors.dart", "class ColorScheme {\n"+
+		"  @Deprecated('Use surface instead. This feature was deprecated after v3.18.0-0.1.pre.')\n"+
+		"  final Color background;\n"+
+		"}\n"+
+		"@Deprecated('Use OverflowBar instead.')\n"+
+		"class ButtonBar extends StatelessWidget {}\n")
+
+	newer := filepath.Join(home, "fvm", "versions", "3.27.1")
+	writeFakeSDK(t, newer, "3.27.1", "stable")
+	writeFrameworkFile(t, newer, "material/colors.dart", "class ColorScheme {\n"+
+		"  @Deprecated('Use surface instead. This feature was deprecated after v3.18.0-0.1.pre.')\n"+
+		"  final Color background;\n"+
+		"}\n")
+	writeFrameworkFile(t, newer, "painting/color.dart", "class Color {\n"+
+		"  @Deprecated('Use .a instead. This feature was deprecated after v3.27.0-0.1.pre.')\n"+
+		"  double get opacity => a;\n"+
+		"}\n")
+	writeFrameworkFile(t, newer, "painting/color.g.dart", "class Generated {\n  @Deprecated('Generated')\n  int get value;\n}\n")
+
+	cacheDir := t.TempDir()
+	sdks := &LocalSDKService{home: home, installPaths: []string{}}
+	service := NewSDKScanService(NewFlutterAPIService(), sdks, cacheDir)
+	ctx := context.Background()
+
+	t.Run("Scans each installed version once", func(t *testing.T) {
+		versions, err := service.Versions(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(versions) != 2 || versions[0].Version != "3.24.5" || versions[1].Version != "3.27.1" {
+			t.Fatalf("Expected 3.24.5 and 3.27.1 oldest first, got %+v", versions)
+		}
+		if len(versions[1].Deprecations) != 2 {
+			t.Errorf("Expected 2 deprecations in 3.27.1 without the generated file, got %+v", versions[1].Deprecations)
+		}
+		for _, dep := range versions[1].Deprecations {
+			if dep.API == "Color.opacity" && (dep.Category != "painting" || dep.SourceFile != "packages/flutter/lib/src/painting/color.dart" || dep.SourceLine != 2) {
+				t.Errorf("Expected the painting library and source location, got %+v", dep)
+			}
+		}
+
+		// Later calls read the stored scan, even when the SDK changes on disk
+		writeFrameworkFile(t, newer, "widgets/framework.dart", "@Deprecated('Later')\nclass Later {}\n")
+		versions, _ = service.Versions(ctx)
+		if len(versions[1].Deprecations) != 2 {
+			t.Errorf("Expected the stored scan to be reused, got %+v", versions[1].Deprecations)
+		}
+		if _, err := os.Stat(filepath.Join(cacheDir, "sdk_versions", "3.27.1.json")); err != nil {
+			t.Errorf("Expected the scan to be stored: %v", err)
+		}
+	})
+
+	t.Run("Compares two versions offline", func(t *testing.T) {
+		comparison, err := service.Compare(ctx, "3.24", "3.27.1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if comparison.From != "3.24.5" || comparison.To != "3.27.1" || comparison.Unchanged != 1 {
+			t.Errorf("Unexpected comparison: %+v", comparison)
+		}
+		if len(comparison.Deprecated) != 1 || comparison.Deprecated[0].API != "Color.opacity" || comparison.Deprecated[0].Version != "3.27.0-0.1.pre" {
+			t.Errorf("Expected Color.opacity to be newly deprecated, got %+v", comparison.Deprecated)
+		}
+		if len(comparison.Removed) != 1 || comparison.Removed[0].API != "ButtonBar" {
+			t.Errorf("Expected ButtonBar to be removed, got %+v", comparison.Removed)
+		}
+	})
+
+	t.Run("Lists the deprecations a release introduced", func(t *testing.T) {
+		introduced, err := service.IntroducedIn(ctx, "3.27")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if introduced.Version != "3.27.1" || introduced.Previous != "3.24.5" {
+			t.Errorf("Expected 3.27.1 compared with 3.24.5, got %+v", introduced)
+		}
+		if len(introduced.Deprecations) != 1 || introduced.Deprecations[0].API != "Color.opacity" {
+			t.Errorf("Expected only Color.opacity, got %+v", introduced.Deprecations)
+		}
+
+		if _, err := service.IntroducedIn(ctx, "3.24.5"); err == nil || !strings.Contains(err.Error(), "no installed Flutter version older than 3.24.5") {
+			t.Errorf("Expected the missing baseline to be reported, got %v", err)
+		}
+	})
+
+	t.Run("Reports versions that are not installed", func(t *testing.T) {
+		_, err := service.Compare(ctx, "3.22.0", "3.27.1")
+		if err == nil || !strings.Contains(err.Error(), "installed: 3.24.5, 3.27.1") {
+			t.Errorf("Expected the installed versions to be listed, got %v", err)
+		}
+	})
+}
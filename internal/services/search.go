@@ -0,0 +1,172 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// Relevance scores for the different ways a query can match a deprecation
+const (
+	scoreExactAPI        = 100
+	scorePrefixAPI       = 80
+	scoreSubstringAPI    = 60
+	scoreTypoAPI         = 50
+	scoreFuzzyAPI        = 40
+	scoreSubstringDetail = 30
+	scoreAllTermsDetail  = 20
+)
+
+// SearchDeprecations ranks the known deprecations against a free-text query. API names are
+// matched by exact, prefix, substring, typo-tolerant and subsequence comparison; descriptions
+// and replacements by substring. At most limit results are returned (all when limit <= 0).
+func (d *DeprecationService) SearchDeprecations(query string, limit int) []models.DeprecationMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var matches []models.DeprecationMatch
+	for _, dep := range d.allDeprecations() {
+		if score := scoreDeprecation(dep, query); score > 0 {
+			matches = append(matches, models.DeprecationMatch{Deprecation: dep, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if len(matches[i].API) != len(matches[j].API) {
+			return len(matches[i].API) < len(matches[j].API)
+		}
+		return matches[i].API < matches[j].API
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// scoreDeprecation returns how well a lowercased query matches a deprecation, 0 for no match
+func scoreDeprecation(dep models.Deprecation, query string) int {
+	api := strings.ToLower(dep.API)
+	detail := strings.ToLower(dep.Description + " " + dep.Replacement)
+
+	switch {
+	case api == query:
+		return scoreExactAPI
+	case strings.HasPrefix(api, query):
+		return scorePrefixAPI
+	case strings.Contains(api, query):
+		return scoreSubstringAPI
+	}
+
+	best := 0
+	if typoMatch(api, query) {
+		best = scoreTypoAPI
+	} else if score := subsequenceScore(api, query); score > 0 {
+		best = score
+	}
+
+	if best < scoreSubstringDetail && strings.Contains(detail, query) {
+		best = scoreSubstringDetail
+	}
+
+	if best == 0 {
+		terms := strings.Fields(query)
+		if len(terms) > 1 && allTermsIn(api+" "+detail, terms) {
+			best = scoreAllTermsDetail
+		}
+	}
+
+	return best
+}
+
+// typoMatch reports whether any segment of the API name is within a small edit distance of the query
+func typoMatch(api string, query string) bool {
+	if len(query) < 4 {
+		return false
+	}
+	allowed := 1
+	if len(query) >= 8 {
+		allowed = 2
+	}
+
+	for _, segment := range strings.FieldsFunc(api, func(r rune) bool { return r == '.' || r == '(' || r == ')' || r == ' ' }) {
+		if abs(len(segment)-len(query)) > allowed {
+			continue
+		}
+		if levenshtein(segment, query) <= allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// subsequenceScore scores queries whose characters appear in order in the API name, favouring
+// tight matches; it returns 0 when the query is not a subsequence or is spread too thin
+func subsequenceScore(api string, query string) int {
+	if len(query) < 3 {
+		return 0
+	}
+
+	start, pos := -1, 0
+	for i := 0; i < len(api) && pos < len(query); i++ {
+		if api[i] == query[pos] {
+			if start < 0 {
+				start = i
+			}
+			pos++
+			if pos == len(query) {
+				span := i - start + 1
+				if span > 2*len(query) {
+					return 0
+				}
+				return scoreFuzzyAPI * len(query) / span
+			}
+		}
+	}
+	return 0
+}
+
+// allTermsIn reports whether every term occurs in text
+func allTermsIn(text string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
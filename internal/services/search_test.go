@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestSearchDeprecations(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+
+	err := cacheService.Save(&models.DeprecationCache{
+		LastUpdated: time.Now(),
+		Deprecations: []models.Deprecation{
+			{API: "ThemeData.accentColor", Description: "Use colorScheme.secondary instead", Replacement: "colorScheme.secondary"},
+			{API: "SnackBarThemeData.disabledActionTextColor", Description: "No longer used"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error saving cache, got %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Substring in API name", "opacity", "Color.withOpacity"},
+		{"Prefix beats substring", "snackbar", "SnackBarThemeData.disabledActionTextColor"},
+		{"Case-insensitive exact match", "raisedbutton", "RaisedButton"},
+		{"Typo in a name segment", "RasedButton", "RaisedButton"},
+		{"Subsequence of API name", "accntclr", "ThemeData.accentColor"},
+		{"Description and replacement text", "secondary", "ThemeData.accentColor"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches := depService.SearchDeprecations(tc.query, 0)
+			if len(matches) == 0 {
+				t.Fatalf("Expected matches for %q, got none", tc.query)
+			}
+			if matches[0].API != tc.expected {
+				t.Errorf("Expected top match %s, got %s", tc.expected, matches[0].API)
+			}
+		})
+	}
+
+	t.Run("Respects limit", func(t *testing.T) {
+		if matches := depService.SearchDeprecations("button", 2); len(matches) != 2 {
+			t.Errorf("Expected 2 matches, got %d", len(matches))
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		if matches := depService.SearchDeprecations("zzzzqqq", 0); len(matches) != 0 {
+			t.Errorf("Expected no matches, got %+v", matches)
+		}
+	})
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// knownAdvisories are published Dart SDK vulnerabilities that are checked even when the GitHub
+// advisories cannot be read or do not list them
+var knownAdvisories = []models.SecurityAdvisory{
+	{
+		ID:       "CVE-2022-3095",
+		CVE:      "CVE-2022-3095",
+		Product:  config.ADVISORY_PRODUCT_DART,
+		Summary:  "The Uri class parses backslashes differently from the WhatWG URL standard, which lets crafted URLs get past host checks",
+		URL:      fmt.Sprintf(config.CVE_DETAILS_URL, "CVE-2022-3095"),
+		Affected: "< 2.18.2",
+		Fixed:    "2.18.2",
+	},
+	{
+		ID:       "CVE-2022-0451",
+		CVE:      "CVE-2022-0451",
+		Product:  config.ADVISORY_PRODUCT_DART,
+		Summary:  "dart:io HttpClient keeps the Authorization and Cookie headers when it follows a redirect to another host",
+		URL:      fmt.Sprintf(config.CVE_DETAILS_URL, "CVE-2022-0451"),
+		Affected: "< 2.16.0",
+		Fixed:    "2.16.0",
+	},
+}
+
+// githubAdvisory is a published security advisory of a GitHub repository
+type githubAdvisory struct {
+	GHSAID          string  `json:"ghsa_id"`
+	CVEID           string  `json:"cve_id"`
+	HTMLURL         string  `json:"html_url"`
+	Summary         string  `json:"summary"`
+	Severity        string  `json:"severity"`
+	WithdrawnAt     *string `json:"withdrawn_at"`
+	Vulnerabilities []struct {
+		VulnerableVersionRange string `json:"vulnerable_version_range"`
+		PatchedVersions        string `json:"patched_versions"`
+	} `json:"vulnerabilities"`
+}
+
+// SecurityAdvisories returns the published advisories that affect flutterVersion or its Dart SDK,
+// from the security advisories of the flutter/flutter and dart-lang/sdk repositories and a curated
+// list. Without dartVersion the Dart SDK of the release is looked up in the official releases. An
+// unreachable source is recorded in UpstreamErrors instead of failing the call.
+func (f *FlutterAPIService) SecurityAdvisories(ctx context.Context, flutterVersion string, dartVersion string) (*models.AdvisoryCheck, error) {
+	flutter, ok := parseVersion(flutterVersion)
+	if !ok {
+		return nil, fmt.Errorf("invalid Flutter version %q", flutterVersion)
+	}
+	check := &models.AdvisoryCheck{FlutterVersion: flutterVersion, DartVersion: strings.TrimSpace(dartVersion), Advisories: []models.SecurityAdvisory{}}
+
+	official, officialErr := f.FetchOfficialReleases(ctx)
+	if check.DartVersion == "" {
+		if officialErr != nil {
+			check.UpstreamErrors = append(check.UpstreamErrors, fmt.Sprintf("official releases: %v; the Dart SDK advisories were not checked", officialErr))
+		} else {
+			for _, release := range official.Releases {
+				if release.Version == flutterVersion && release.DartSDKVersion != "" {
+					check.DartVersion = release.DartSDKVersion
+					break
+				}
+			}
+			if check.DartVersion == "" {
+				check.UpstreamErrors = append(check.UpstreamErrors, fmt.Sprintf("the official releases do not list the Dart SDK of Flutter %s; the Dart SDK advisories were not checked", flutterVersion))
+			}
+		}
+	}
+	dart, dartKnown := parseVersion(check.DartVersion)
+
+	var candidates []models.SecurityAdvisory
+	seen := make(map[string]bool)
+	for _, source := range []struct{ product, url string }{
+		{config.ADVISORY_PRODUCT_FLUTTER, config.FLUTTER_SECURITY_ADVISORIES_URL},
+		{config.ADVISORY_PRODUCT_DART, config.DART_SECURITY_ADVISORIES_URL},
+	} {
+		advisories, err := f.fetchAdvisories(ctx, source.url, source.product)
+		if err != nil {
+			check.UpstreamErrors = append(check.UpstreamErrors, fmt.Sprintf("%s advisories: %v", source.product, err))
+			continue
+		}
+		for _, advisory := range advisories {
+			seen[advisory.ID] = true
+			if advisory.CVE != "" {
+				seen[advisory.CVE] = true
+			}
+		}
+		candidates = append(candidates, advisories...)
+	}
+	for _, advisory := range knownAdvisories {
+		if !seen[advisory.ID] {
+			candidates = append(candidates, advisory)
+		}
+	}
+
+	for _, advisory := range candidates {
+		switch {
+		case advisory.Product == config.ADVISORY_PRODUCT_FLUTTER && versionInRange(flutter, advisory.Affected):
+			advisory.FixedFlutter = advisory.Fixed
+		case advisory.Product == config.ADVISORY_PRODUCT_DART && dartKnown && versionInRange(dart, advisory.Affected):
+			if officialErr == nil {
+				advisory.FixedFlutter = firstFlutterWithDart(official, advisory.Fixed)
+			}
+		default:
+			continue
+		}
+		check.Advisories = append(check.Advisories, advisory)
+	}
+	sort.SliceStable(check.Advisories, func(i, j int) bool {
+		a, b := check.Advisories[i], check.Advisories[j]
+		if a.Product != b.Product {
+			return a.Product > b.Product
+		}
+		return a.ID < b.ID
+	})
+	return check, nil
+}
+
+// fetchAdvisories reads the published, not withdrawn advisories of a repository, one entry per
+// vulnerable version range
+func (f *FlutterAPIService) fetchAdvisories(ctx context.Context, url string, product string) ([]models.SecurityAdvisory, error) {
+	resp, err := f.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub returned status %d", resp.StatusCode)
+	}
+	var published []githubAdvisory
+	if err := json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return nil, err
+	}
+
+	var advisories []models.SecurityAdvisory
+	for _, advisory := range published {
+		if advisory.WithdrawnAt != nil {
+			continue
+		}
+		for _, vulnerability := range advisory.Vulnerabilities {
+			if strings.TrimSpace(vulnerability.VulnerableVersionRange) == "" {
+				continue
+			}
+			fixed := ""
+			if v, ok := parseVersion(vulnerability.PatchedVersions); ok {
+				fixed = v.String()
+			}
+			advisories = append(advisories, models.SecurityAdvisory{
+				ID:       advisory.GHSAID,
+				CVE:      advisory.CVEID,
+				Product:  product,
+				Summary:  advisory.Summary,
+				Severity: advisory.Severity,
+				URL:      advisory.HTMLURL,
+				Affected: strings.TrimSpace(vulnerability.VulnerableVersionRange),
+				Fixed:    fixed,
+			})
+		}
+	}
+	return advisories, nil
+}
+
+// versionInRange reports whether v satisfies a comma separated version range such as
+// ">= 2.0.0, < 2.18.2". A range it cannot parse matches nothing.
+func versionInRange(v semanticVersion, versionRange string) bool {
+	matched := false
+	for _, constraint := range strings.Split(versionRange, ",") {
+		constraint = strings.TrimSpace(constraint)
+		rest := strings.TrimLeft(constraint, "<>=")
+		op := constraint[:len(constraint)-len(rest)]
+		bound, ok := parseVersion(rest)
+		if !ok {
+			return false
+		}
+		cmp := v.Compare(bound)
+		switch op {
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "", "=", "==":
+			ok = cmp == 0
+		default:
+			return false
+		}
+		if !ok {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// firstFlutterWithDart returns the oldest stable Flutter release whose Dart SDK is dart or newer
+func firstFlutterWithDart(official *models.FlutterReleasesResponse, dart string) string {
+	fixed, ok := parseVersion(dart)
+	if !ok {
+		return ""
+	}
+	var first semanticVersion
+	found := ""
+	for _, release := range official.Releases {
+		if release.Channel != config.FLUTTER_CHANNEL_STABLE {
+			continue
+		}
+		sdk, sdkOK := parseVersion(release.DartSDKVersion)
+		v, versionOK := parseVersion(release.Version)
+		if sdkOK && versionOK && sdk.Compare(fixed) >= 0 && (found == "" || v.Compare(first) < 0) {
+			first, found = v, release.Version
+		}
+	}
+	return found
+}
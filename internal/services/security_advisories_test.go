@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestSecurityAdvisories(t *testing.T) {
+	advisoriesUp := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/flutter_infra_release/releases/releases_linux.json":
+			w.Write([]byte(`{"current_release": {"stable": "c"}, "releases": [
+				{"hash": "c", "channel": "stable", "version": "3.3.3", "dart_sdk_version": "2.18.2"},
+				{"hash": "b", "channel": "stable", "version": "3.3.2", "dart_sdk_version": "2.18.1"},
+				{"hash": "a", "channel": "stable", "version": "2.10.0", "dart_sdk_version": "2.16.0"}
+			]}`))
+		case !advisoriesUp:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/repos/flutter/flutter/security-advisories":
+			w.Write([]byte(`[
+				{"ghsa_id": "GHSA-aaaa", "summary": "Engine issue", "severity": "high", "html_url": "https://github.com/flutter/flutter/security/advisories/GHSA-aaaa",
+				 "vulnerabilities": [{"vulnerable_version_range": ">= 3.0.0, < 3.3.3", "patched_versions": "3.3.3"}]},
+				{"ghsa_id": "GHSA-bbbb", "summary": "Withdrawn", "withdrawn_at": "2024-01-01T00:00:00Z",
+				 "vulnerabilities": [{"vulnerable_version_range": "< 4.0.0"}]},
+				{"ghsa_id": "GHSA-cccc", "summary": "Ranges nothing", "vulnerabilities": [{"vulnerable_version_range": ""}]}
+			]`))
+		case r.URL.Path == "/repos/dart-lang/sdk/security-advisories":
+			w.Write([]byte(`[{"ghsa_id": "GHSA-dddd", "cve_id": "CVE-2022-3095", "summary": "Uri backslash parsing", "severity": "moderate", "html_url": "https://github.com/dart-lang/sdk/security/advisories/GHSA-dddd",
+				"vulnerabilities": [{"vulnerable_version_range": "< 2.18.2", "patched_versions": ">= 2.18.2"}]}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	service := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+
+	ids := func(check *models.AdvisoryCheck) string {
+		var ids []string
+		for _, advisory := range check.Advisories {
+			ids = append(ids, advisory.ID+"@"+advisory.FixedFlutter)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	t.Run("Lists the advisories affecting the SDK and its Dart version", func(t *testing.T) {
+		check, err := service.SecurityAdvisories(ctx, "3.3.2", "")
+		if err != nil {
+			t.Fatalf("SecurityAdvisories failed: %v", err)
+		}
+		if check.DartVersion != "2.18.1" || len(check.UpstreamErrors) != 0 {
+			t.Errorf("Expected the Dart SDK from the official releases, got %+v", check)
+		}
+		// The GitHub advisory replaces the curated entry of the same CVE
+		if got := ids(check); got != "GHSA-aaaa@3.3.3,GHSA-dddd@3.3.3" {
+			t.Errorf("Expected the Flutter and Dart advisories fixed in 3.3.3, got %s", got)
+		}
+
+		check, err = service.SecurityAdvisories(ctx, "3.3.3", "2.18.2")
+		if err != nil || len(check.Advisories) != 0 {
+			t.Errorf("Expected no advisories for a fixed release, got %+v, %v", check, err)
+		}
+	})
+
+	t.Run("Falls back to the curated advisories", func(t *testing.T) {
+		advisoriesUp = false
+		defer func() { advisoriesUp = true }()
+
+		check, err := service.SecurityAdvisories(ctx, "2.8.1", "2.15.1")
+		if err != nil {
+			t.Fatalf("SecurityAdvisories failed: %v", err)
+		}
+		if got := ids(check); got != "CVE-2022-0451@2.10.0,CVE-2022-3095@3.3.3" {
+			t.Errorf("Expected the curated Dart advisories, got %s", got)
+		}
+		if len(check.UpstreamErrors) != 2 || !strings.Contains(check.UpstreamErrors[0], config.ADVISORY_PRODUCT_FLUTTER+" advisories") {
+			t.Errorf("Expected both unreachable advisory sources to be reported, got %v", check.UpstreamErrors)
+		}
+	})
+
+	t.Run("Rejects invalid versions", func(t *testing.T) {
+		if _, err := service.SecurityAdvisories(ctx, "latest", ""); err == nil {
+			t.Error("Expected an error for an invalid version")
+		}
+	})
+}
+
+func TestVersionInRange(t *testing.T) {
+	for versionRange, expected := range map[string]bool{
+		"< 2.18.2":            true,
+		"<= 2.18.1":           true,
+		">= 2.0.0, < 2.18.2":  true,
+		"> 2.18.1":            false,
+		">= 2.18.2":           false,
+		"2.18.1":              true,
+		"= 2.18.0":            false,
+		"< 2.18.2, ~> 2.18.0": false,
+		"":                    false,
+		"all versions":        false,
+	} {
+		v, _ := parseVersion("2.18.1")
+		if got := versionInRange(v, versionRange); got != expected {
+			t.Errorf("Expected %q to be %v for 2.18.1, got %v", versionRange, expected, got)
+		}
+	}
+}
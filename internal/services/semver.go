@@ -0,0 +1,16 @@
+package services
+
+import "github.com/jger/mcp-flutter-deprecations-server/pkg/flutter"
+
+// semanticVersion is a parsed Flutter or Dart version, ordered as pkg/flutter orders them
+type semanticVersion = flutter.Version
+
+// parseVersion extracts the first Flutter version in s
+func parseVersion(s string) (semanticVersion, bool) {
+	return flutter.ParseVersion(s)
+}
+
+// CompareVersions compares two Flutter version strings; ok is false when either cannot be parsed
+func CompareVersions(a string, b string) (result int, ok bool) {
+	return flutter.CompareVersions(a, b)
+}
@@ -0,0 +1,39 @@
+package services
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"3.27.0", "3.27.0", 0},
+		{"v3.27.1", "3.27.0", 1},
+		{"3.27", "3.27.0", 0},
+		{"3.19.0-0.1.pre", "3.19.0", -1},
+		{"3.19.0-0.2.pre", "3.19.0-0.1.pre", 1},
+		{"3.19.0-10.0.pre", "3.19.0-9.0.pre", 1},
+		{"2.10.0", "2.9.3", 1},
+		{"1.12.13+hotfix.9", "1.12.13", 1},
+		{"1.12.13+hotfix.10", "1.12.13+hotfix.9", 1},
+		{"v3.32.0-hotfix.1", "3.32.0+hotfix.1", 0},
+		{"1.12.13+hotfix.9", "1.12.14-0.1.pre", -1},
+		{"1.12.13-0.1.pre", "1.12.13+hotfix.1", -1},
+		{"This feature was deprecated after v3.22.0-2.0.pre.", "3.24.0", -1},
+	}
+
+	for _, tc := range testCases {
+		result, ok := CompareVersions(tc.a, tc.b)
+		if !ok {
+			t.Errorf("Expected %q and %q to parse", tc.a, tc.b)
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("CompareVersions(%q, %q): expected %d, got %d", tc.a, tc.b, tc.expected, result)
+		}
+	}
+
+	if _, ok := CompareVersions("Multiple versions", "3.0.0"); ok {
+		t.Error("Expected unparseable version to be reported")
+	}
+}
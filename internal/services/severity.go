@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// severityRanks orders the severities of deprecations from the least to the most urgent
+var severityRanks = map[string]int{
+	config.SEVERITY_INFO:    0,
+	config.SEVERITY_WARNING: 1,
+	config.SEVERITY_ERROR:   2,
+}
+
+// ParseSeverity normalizes the least severity that fails a scan, defaulting to info so that every
+// finding does
+func ParseSeverity(severity string) (string, error) {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if severity == "" {
+		return config.SEVERITY_INFO, nil
+	}
+	if _, found := severityRanks[severity]; !found {
+		return "", fmt.Errorf("unknown severity %q, expected info, warning or error", severity)
+	}
+	return severity, nil
+}
+
+// SeverityAtLeast reports whether dep is at least as severe as threshold. Entries without a known
+// severity count as warnings, as in the LSP diagnostics.
+func SeverityAtLeast(dep models.Deprecation, threshold string) bool {
+	return severityRanks[severityOf(dep)] >= severityRanks[threshold]
+}
+
+// severityOf returns the severity of dep, which is warning when it has no known one
+func severityOf(dep models.Deprecation) string {
+	if _, found := severityRanks[dep.Severity]; found {
+		return dep.Severity
+	}
+	return config.SEVERITY_WARNING
+}
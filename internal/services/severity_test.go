@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for severity, expected := range map[string]string{"": config.SEVERITY_INFO, " Warning ": config.SEVERITY_WARNING, "error": config.SEVERITY_ERROR} {
+		if parsed, err := ParseSeverity(severity); err != nil || parsed != expected {
+			t.Errorf("ParseSeverity(%q): expected %q, got %q (%v)", severity, expected, parsed, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	for _, test := range []struct {
+		severity  string
+		threshold string
+		expected  bool
+	}{
+		{config.SEVERITY_ERROR, config.SEVERITY_ERROR, true},
+		{config.SEVERITY_WARNING, config.SEVERITY_ERROR, false},
+		{config.SEVERITY_INFO, config.SEVERITY_WARNING, false},
+		{config.SEVERITY_INFO, config.SEVERITY_INFO, true},
+		{"", config.SEVERITY_WARNING, true},
+		{"", config.SEVERITY_ERROR, false},
+	} {
+		if got := SeverityAtLeast(models.Deprecation{Severity: test.severity}, test.threshold); got != test.expected {
+			t.Errorf("SeverityAtLeast(%q, %q): expected %v", test.severity, test.threshold, test.expected)
+		}
+	}
+	if got := LSPSeverity(""); got != 2 {
+		t.Errorf("Expected entries without a severity to be LSP warnings, got %d", got)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup collapses concurrent calls with the same key into one, so that tool calls arriving
+// together on a shared server send a single upstream request and share its result. Its zero value
+// is ready to use.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a call in progress and the callers waiting for it
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	value   interface{}
+	err     error
+}
+
+// do runs fn once for all callers of key that arrive while it is in progress and returns its result
+// to each of them. fn is not tied to the context of the caller that started it: a caller whose ctx
+// ends returns ctx.Err() at once, and fn is only cancelled when every caller has given up.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go func() {
+			defer cancel()
+			f.value, f.err = fn(flightCtx)
+			g.forget(key, f)
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		if f.waiters--; f.waiters == 0 {
+			// Nobody wants the result any more; later callers start a new flight
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes a finished flight, unless it was abandoned and replaced already
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// waitForWaiters blocks until n callers wait for the flight of key
+func waitForWaiters(t *testing.T, g *flightGroup, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		f := g.flights[key]
+		joined := f != nil && f.waiters == n
+		g.mu.Unlock()
+		if joined {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d callers to join the flight of %s", n, key)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFlightGroup(t *testing.T) {
+	t.Run("Concurrent callers share one call", func(t *testing.T) {
+		var g flightGroup
+		var calls int32
+		release := make(chan struct{})
+		fn := func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return "result", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]interface{}, 5)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = g.do(context.Background(), "key", fn)
+			}(i)
+		}
+		waitForWaiters(t, &g, "key", len(results))
+		close(release)
+		wg.Wait()
+
+		if calls != 1 {
+			t.Errorf("Expected one call, got %d", calls)
+		}
+		for _, result := range results {
+			if result != "result" {
+				t.Errorf("Expected every caller to get the result, got %v", results)
+			}
+		}
+		if value, _ := g.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) { return "again", nil }); value != "again" || calls != 1 {
+			t.Errorf("Expected a finished call to be forgotten, got %v", value)
+		}
+	})
+
+	t.Run("A caller giving up does not cancel the others", func(t *testing.T) {
+		var g flightGroup
+		release := make(chan struct{})
+		cancelled := make(chan struct{})
+		fn := func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-release:
+				return "result", nil
+			case <-ctx.Done():
+				close(cancelled)
+				return nil, ctx.Err()
+			}
+		}
+
+		first, cancelFirst := context.WithCancel(context.Background())
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := g.do(first, "key", fn)
+			firstErr <- err
+		}()
+		waitForWaiters(t, &g, "key", 1)
+		second := make(chan interface{}, 1)
+		go func() {
+			value, _ := g.do(context.Background(), "key", fn)
+			second <- value
+		}()
+		waitForWaiters(t, &g, "key", 2)
+
+		cancelFirst()
+		if err := <-firstErr; err != context.Canceled {
+			t.Errorf("Expected the cancelled caller to return at once, got %v", err)
+		}
+		close(release)
+		if value := <-second; value != "result" {
+			t.Errorf("Expected the remaining caller to get the result, got %v", value)
+		}
+		select {
+		case <-cancelled:
+			t.Error("Expected the call to keep running for the remaining caller")
+		default:
+		}
+	})
+
+	t.Run("The call is cancelled when every caller gave up", func(t *testing.T) {
+		var g flightGroup
+		cancelled := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error, 1)
+		go func() {
+			_, err := g.do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+				<-ctx.Done()
+				close(cancelled)
+				return nil, ctx.Err()
+			})
+			errs <- err
+		}()
+		waitForWaiters(t, &g, "key", 1)
+		cancel()
+		if err := <-errs; err != context.Canceled {
+			t.Errorf("Expected the caller to be cancelled, got %v", err)
+		}
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the abandoned call to be cancelled")
+		}
+	})
+}
+
+func TestFetchReleasesSharesConcurrentRequests(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte(`[{"tag_name": "3.27.1", "published_at": "2024-12-17T00:00:00Z"}]`))
+	}))
+	defer server.Close()
+	service := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			releases, err := service.FetchReleases(context.Background())
+			if err != nil || len(releases) != 1 || releases[0].TagName != "3.27.1" {
+				t.Errorf("Expected the shared releases, got %+v, %v", releases, err)
+				return
+			}
+			// Each caller gets its own copy to change
+			releases[0].TagName = "changed"
+		}()
+	}
+	waitForWaiters(t, &service.flights, config.FLUTTER_API_URL, 3)
+	close(release)
+	wg.Wait()
+
+	if requests != 1 {
+		t.Errorf("Expected one upstream request, got %d", requests)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// stableTagPattern matches the versions of stable releases: a plain version, optionally with the
+// hotfix qualifier of a stable release patched after it shipped, such as v1.12.13+hotfix.9
+var stableTagPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:[-+]hotfix\.(\d+))?$`)
+
+// parseStableVersion parses a stable version, rejecting pre-releases and other qualifiers
+func parseStableVersion(s string) (semanticVersion, bool) {
+	matches := stableTagPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return semanticVersion{}, false
+	}
+
+	var v semanticVersion
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	v.Patch, _ = strconv.Atoi(matches[3])
+	if matches[4] != "" {
+		v.Hotfix, _ = strconv.Atoi(matches[4])
+	}
+	return v, true
+}
+
+// latestOfficialStable finds the current stable release in the official releases API. The
+// channel fields are the source of truth: the release current_release.stable names wins, and
+// without one the newest release on the stable channel.
+func latestOfficialStable(releases *models.FlutterReleasesResponse) (models.FlutterOfficialRelease, bool) {
+	if current := releases.CurrentRelease.Stable; current != "" {
+		for _, release := range releases.Releases {
+			if release.Hash == current && release.Channel == config.FLUTTER_CHANNEL_STABLE {
+				return release, true
+			}
+		}
+	}
+
+	var latest models.FlutterOfficialRelease
+	var latestVersion semanticVersion
+	found, parsed := false, false
+	for _, release := range releases.Releases {
+		if release.Channel != config.FLUTTER_CHANNEL_STABLE {
+			continue
+		}
+		version, ok := parseStableVersion(release.Version)
+		switch {
+		case ok && (!parsed || version.Compare(latestVersion) > 0):
+			latest, latestVersion, found, parsed = release, version, true, true
+		case !found:
+			// The list is newest first, so an unparseable version is still better than none
+			latest, found = release, true
+		}
+	}
+	return latest, found
+}
+
+// latestGitHubStable finds the newest stable release among the GitHub releases, hotfixes
+// included, and returns its version without the v prefix
+func latestGitHubStable(releases []models.FlutterRelease) (string, bool) {
+	var latest string
+	var latestVersion semanticVersion
+	for _, release := range releases {
+		if release.Prerelease {
+			continue
+		}
+		version, ok := parseStableVersion(release.TagName)
+		if ok && (latest == "" || version.Compare(latestVersion) > 0) {
+			latest, latestVersion = strings.TrimPrefix(release.TagName, "v"), version
+		}
+	}
+	return latest, latest != ""
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestStableReleaseSelection(t *testing.T) {
+	t.Run("Follows the current stable release of the official API", func(t *testing.T) {
+		releases := &models.FlutterReleasesResponse{Releases: []models.FlutterOfficialRelease{
+			{Hash: "c1", Channel: "beta", Version: "3.29.0-0.1.pre"},
+			{Hash: "b2", Channel: "stable", Version: "3.27.0"},
+			{Hash: "a3", Channel: "stable", Version: "3.27.1"},
+		}}
+
+		// Without current_release the newest stable version wins, whatever the list order
+		if release, ok := latestOfficialStable(releases); !ok || release.Version != "3.27.1" {
+			t.Errorf("Expected 3.27.1, got %+v", release)
+		}
+		releases.CurrentRelease.Stable = "b2"
+		if release, ok := latestOfficialStable(releases); !ok || release.Version != "3.27.0" {
+			t.Errorf("Expected the release current_release.stable names, got %+v", release)
+		}
+		releases.CurrentRelease.Stable = "c1"
+		if release, ok := latestOfficialStable(releases); !ok || release.Version != "3.27.1" {
+			t.Errorf("Expected a non-stable current release to be ignored, got %+v", release)
+		}
+		if _, ok := latestOfficialStable(&models.FlutterReleasesResponse{}); ok {
+			t.Error("Expected no stable release in an empty response")
+		}
+	})
+
+	t.Run("Picks the newest GitHub stable tag with hotfixes", func(t *testing.T) {
+		releases := []models.FlutterRelease{
+			{TagName: "3.29.0-0.1.pre", Prerelease: true},
+			{TagName: "v1.12.13+hotfix.9"},
+			{TagName: "v1.12.13+hotfix.10"},
+			{TagName: "3.30.0-beta.1"},
+			{TagName: "v1.12.13"},
+		}
+		if version, ok := latestGitHubStable(releases); !ok || version != "1.12.13+hotfix.10" {
+			t.Errorf("Expected the latest hotfix, got %q", version)
+		}
+		if _, ok := latestGitHubStable(releases[3:4]); ok {
+			t.Error("Expected a beta tag not to count as stable")
+		}
+	})
+
+	t.Run("FetchReleases accepts a successful response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"tag_name": "3.27.0", "published_at": "2024-12-11T00:00:00Z"}, {"tag_name": "3.27.1", "published_at": "2024-12-17T00:00:00Z"}]`))
+		}))
+		defer server.Close()
+
+		service := &FlutterAPIService{client: &http.Client{Transport: redirectTransport(server.URL)}}
+		releases, err := service.FetchReleases(context.Background())
+		if err != nil {
+			t.Fatalf("FetchReleases failed: %v", err)
+		}
+		if len(releases) != 2 || releases[0].TagName != "3.27.1" {
+			t.Errorf("Expected both releases newest first, got %+v", releases)
+		}
+	})
+}
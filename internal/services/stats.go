@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// Upstream categories recorded by the API and version services
+const (
+	UpstreamGitHub          = "github_api"
+	UpstreamGitHubRaw       = "github_raw"
+	UpstreamFlutterReleases = "flutter_releases"
+	UpstreamDockerHub       = "docker_hub"
+	UpstreamPubDev          = "pub_dev"
+	UpstreamDockerRegistry  = "docker_registry"
+	UpstreamExecFlutter     = "exec_flutter"
+	UpstreamExecFVM         = "exec_fvm"
+	UpstreamTeamDB          = "team_db"
+	UpstreamOther           = "other"
+)
+
+// Results of the cache loads and updates recorded by the cache and deprecation services
+const (
+	CacheLoadMemory    = "memory"
+	CacheLoadDisk      = "disk"
+	CacheLoadMissing   = "missing"
+	CacheUpdateRebuild = "rebuild"
+	CacheUpdateScoped  = "scoped"
+	CacheUpdateFresh   = "fresh"
+)
+
+// StatsService tracks per-tool and per-upstream call counts and latencies in memory
+type StatsService struct {
+	mu      sync.Mutex
+	stats   models.ServerStats
+	persist string
+	// dirty is set by every record since the last flush
+	dirty bool
+
+	// flushMu keeps flushes from writing the file at the same time
+	flushMu sync.Mutex
+}
+
+// NewStatsService creates a stats service; when persistPath is set, statistics are loaded from
+// that file and Flush saves them to it so they survive restarts
+func NewStatsService(persistPath string) *StatsService {
+	s := &StatsService{
+		stats: models.ServerStats{
+			Since:     time.Now(),
+			Tools:     make(map[string]*models.CallStats),
+			Upstreams: make(map[string]*models.CallStats),
+		},
+		persist: persistPath,
+	}
+
+	if persistPath != "" {
+		s.load()
+	}
+
+	return s
+}
+
+// RecordTool records one invocation of an MCP tool
+func (s *StatsService) RecordTool(name string, duration time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record(s.stats.Tools, name, duration, failed)
+	s.dirty = true
+}
+
+// RecordUpstream records one call to an upstream HTTP endpoint or local executable
+func (s *StatsService) RecordUpstream(name string, duration time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record(s.stats.Upstreams, name, duration, failed)
+	s.dirty = true
+}
+
+// RecordCacheLoad records one load of the deprecations cache. Loads of data past CACHE_DURATION, or
+// of a cache that was never fully updated, count as stale serves.
+func (s *StatsService) RecordCacheLoad(result string, lastUpdated time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dirty = true
+	cache := &s.stats.Cache
+	cache.Loads++
+	switch result {
+	case CacheLoadMemory:
+		cache.MemoryHits++
+	case CacheLoadDisk:
+		cache.DiskReads++
+	case CacheLoadMissing:
+		cache.Missing++
+		return
+	}
+	if lastUpdated.IsZero() {
+		cache.StaleServes++
+		return
+	}
+	age := time.Since(lastUpdated)
+	if age >= config.CACHE_DURATION {
+		cache.StaleServes++
+	}
+	if age > cache.MaxServedAge {
+		cache.MaxServedAge = age
+	}
+}
+
+// RecordCacheUpdate records the outcome of one update of the deprecations cache
+func (s *StatsService) RecordCacheUpdate(result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dirty = true
+	cache := &s.stats.Cache
+	switch result {
+	case CacheUpdateRebuild:
+		cache.Rebuilds++
+		cache.LastRebuild = time.Now()
+	case CacheUpdateScoped:
+		cache.ScopedRefreshes++
+	case CacheUpdateFresh:
+		cache.FreshSkips++
+	}
+}
+
+// Snapshot returns a copy of the statistics collected so far
+func (s *StatsService) Snapshot() models.ServerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := models.ServerStats{
+		Since:     s.stats.Since,
+		Tools:     make(map[string]*models.CallStats, len(s.stats.Tools)),
+		Upstreams: make(map[string]*models.CallStats, len(s.stats.Upstreams)),
+		Cache:     s.stats.Cache,
+	}
+	for name, stats := range s.stats.Tools {
+		copied := *stats
+		snapshot.Tools[name] = &copied
+	}
+	for name, stats := range s.stats.Upstreams {
+		copied := *stats
+		snapshot.Upstreams[name] = &copied
+	}
+	return snapshot
+}
+
+// record adds one call to the named entry of the given map
+func record(entries map[string]*models.CallStats, name string, duration time.Duration, failed bool) {
+	entry, ok := entries[name]
+	if !ok {
+		entry = &models.CallStats{}
+		entries[name] = entry
+	}
+
+	millis := float64(duration) / float64(time.Millisecond)
+	entry.Calls++
+	entry.TotalMillis += millis
+	if millis > entry.MaxMillis {
+		entry.MaxMillis = millis
+	}
+	if failed {
+		entry.Errors++
+	}
+	entry.LastCalled = time.Now()
+}
+
+// load reads persisted statistics; a missing or corrupt file starts fresh
+func (s *StatsService) load() {
+	data, err := ioutil.ReadFile(s.persist)
+	if err != nil {
+		return
+	}
+
+	var stats models.ServerStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		slog.Warn("Ignoring unreadable stats file", "path", s.persist, "error", err)
+		return
+	}
+	if stats.Tools != nil {
+		s.stats.Tools = stats.Tools
+	}
+	if stats.Upstreams != nil {
+		s.stats.Upstreams = stats.Upstreams
+	}
+	if !stats.Since.IsZero() {
+		s.stats.Since = stats.Since
+	}
+	s.stats.Cache = stats.Cache
+}
+
+// FlushEvery flushes the statistics every interval until ctx is cancelled. The caller flushes
+// once more at shutdown, for what was recorded since the last tick.
+func (s *StatsService) FlushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+// Flush writes the statistics to disk when persistence is enabled and something was recorded
+// since the last flush. The file is written without holding s.mu, so tool calls never wait for it.
+func (s *StatsService) Flush() {
+	if s.persist == "" {
+		return
+	}
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	data, err := json.MarshalIndent(s.stats, "", "  ")
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.persist), 0755); err != nil {
+		slog.Warn("Failed to create stats directory", "error", err)
+		s.markDirty()
+		return
+	}
+	if err := ioutil.WriteFile(s.persist, data, 0644); err != nil {
+		slog.Warn("Failed to persist stats", "path", s.persist, "error", err)
+		s.markDirty()
+	}
+}
+
+// markDirty makes the next flush try again after a failed write
+func (s *StatsService) markDirty() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = true
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestStatsService(t *testing.T) {
+	t.Run("Aggregates tool and upstream calls", func(t *testing.T) {
+		stats := NewStatsService("")
+		stats.RecordTool("check_flutter_deprecations", 10*time.Millisecond, false)
+		stats.RecordTool("check_flutter_deprecations", 30*time.Millisecond, true)
+		stats.RecordUpstream(UpstreamDockerHub, 200*time.Millisecond, false)
+
+		snapshot := stats.Snapshot()
+		tool := snapshot.Tools["check_flutter_deprecations"]
+		if tool == nil || tool.Calls != 2 || tool.Errors != 1 {
+			t.Fatalf("Expected 2 calls with 1 error, got %+v", tool)
+		}
+		if tool.TotalMillis != 40 || tool.MaxMillis != 30 {
+			t.Errorf("Expected 40ms total and 30ms max, got %.1f and %.1f", tool.TotalMillis, tool.MaxMillis)
+		}
+		if snapshot.Upstreams[UpstreamDockerHub].Calls != 1 {
+			t.Errorf("Expected 1 Docker Hub call, got %+v", snapshot.Upstreams[UpstreamDockerHub])
+		}
+
+		// Snapshots must not alias the live counters
+		tool.Calls = 100
+		if stats.Snapshot().Tools["check_flutter_deprecations"].Calls != 2 {
+			t.Error("Expected snapshot to be a copy")
+		}
+	})
+
+	t.Run("Persists across instances", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server_stats.json")
+		stats := NewStatsService(path)
+		stats.RecordTool("server_stats", time.Millisecond, false)
+
+		// Recording only marks the statistics dirty; the file is written when they are flushed
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected no stats file before a flush, got %v", err)
+		}
+		stats.Flush()
+		reloaded := NewStatsService(path).Snapshot()
+		if reloaded.Tools["server_stats"] == nil || reloaded.Tools["server_stats"].Calls != 1 {
+			t.Errorf("Expected persisted call count of 1, got %+v", reloaded.Tools["server_stats"])
+		}
+	})
+
+	t.Run("Flushes on a timer", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server_stats.json")
+		stats := NewStatsService(path)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			stats.FlushEvery(ctx, 10*time.Millisecond)
+			close(done)
+		}()
+
+		stats.RecordTool("server_stats", time.Millisecond, false)
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if _, err := os.Stat(path); err == nil || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+		<-done
+		if reloaded := NewStatsService(path).Snapshot(); reloaded.Tools["server_stats"] == nil {
+			t.Errorf("Expected the timer to flush the tool call, got %+v", reloaded.Tools)
+		}
+	})
+
+	t.Run("Counts cache loads and updates", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server_stats.json")
+		stats := NewStatsService(path)
+		dir := t.TempDir()
+		cache := NewCacheServiceInDir(dir)
+		cache.SetStatsRecorder(stats)
+		service := NewDeprecationService(cache, &MockFlutterAPIService{sourceDeps: []models.Deprecation{{API: "OldWidget", Category: "material"}}})
+		service.SetStatsRecorder(stats)
+		ctx := context.Background()
+
+		// No file yet, then a rebuild and a load of the fresh data it kept in memory
+		cache.Load()
+		if err := service.UpdateCache(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cache.Load()
+		if err := service.UpdateCache(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.UpdateCacheScoped(ctx, []string{"material"}, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// Another instance reads the file from disk once it has aged past the TTL
+		writer := NewCacheServiceInDir(dir)
+		old, _ := writer.Load()
+		old.LastUpdated = time.Now().Add(-48 * time.Hour)
+		if err := writer.Save(old); err != nil {
+			t.Fatal(err)
+		}
+		reader := NewCacheServiceInDir(dir)
+		reader.SetStatsRecorder(stats)
+		reader.Load()
+
+		got := stats.Snapshot().Cache
+		// The first update loads the missing cache twice, before and after its scan
+		if got.Missing != 3 || got.Rebuilds != 1 || got.FreshSkips != 1 || got.ScopedRefreshes != 1 || got.LastRebuild.IsZero() {
+			t.Errorf("Expected the missing loads, a rebuild, a fresh skip and a scoped refresh, got %+v", got)
+		}
+		if got.DiskReads != 1 || got.StaleServes != 1 || got.MaxServedAge < 47*time.Hour || got.MemoryHits == 0 {
+			t.Errorf("Expected only the aged disk read to be stale, got %+v", got)
+		}
+		if got.Loads != got.MemoryHits+got.DiskReads+got.Missing {
+			t.Errorf("Expected every load to be a hit, disk read or missing, got %+v", got)
+		}
+
+		stats.Flush()
+		if reloaded := NewStatsService(path).Snapshot().Cache; reloaded.Rebuilds != 1 || reloaded.Loads != got.Loads {
+			t.Errorf("Expected the cache statistics to be persisted, got %+v", reloaded)
+		}
+	})
+
+	t.Run("upstreamForHost", func(t *testing.T) {
+		if upstreamForHost("api.github.com") != UpstreamGitHub || upstreamForHost("example.com") != UpstreamOther {
+			t.Error("Unexpected upstream categories")
+		}
+	})
+}
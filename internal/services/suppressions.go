@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// SuppressionService persists the deprecations users have acknowledged, either for the whole
+// machine or for a single Flutter project
+type SuppressionService struct {
+	mu          sync.Mutex
+	machinePath string
+}
+
+// NewSuppressionService creates a suppression service that keeps machine-wide suppressions in machinePath
+func NewSuppressionService(machinePath string) *SuppressionService {
+	return &SuppressionService{machinePath: machinePath}
+}
+
+// Suppress records api as acknowledged, replacing the reason of an existing suppression
+func (s *SuppressionService) Suppress(api string, reason string, projectPath string) (models.Suppression, error) {
+	api = strings.TrimSpace(api)
+	if api == "" {
+		return models.Suppression{}, fmt.Errorf("an API name is required")
+	}
+
+	path, scope, err := s.file(projectPath)
+	if err != nil {
+		return models.Suppression{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := readSuppressions(path)
+	if err != nil {
+		return models.Suppression{}, err
+	}
+
+	suppression := models.Suppression{API: api, Reason: strings.TrimSpace(reason), SuppressedAt: time.Now(), Scope: scope}
+	replaced := false
+	for i, existing := range list.Suppressions {
+		if existing.API == api {
+			list.Suppressions[i] = suppression
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		list.Suppressions = append(list.Suppressions, suppression)
+	}
+
+	return suppression, writeSuppressions(path, list)
+}
+
+// Unsuppress removes the suppression for api and reports whether there was one
+func (s *SuppressionService) Unsuppress(api string, projectPath string) (bool, error) {
+	api = strings.TrimSpace(api)
+	path, _, err := s.file(projectPath)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := readSuppressions(path)
+	if err != nil {
+		return false, err
+	}
+
+	kept := list.Suppressions[:0]
+	for _, existing := range list.Suppressions {
+		if existing.API != api {
+			kept = append(kept, existing)
+		}
+	}
+	if len(kept) == len(list.Suppressions) {
+		return false, nil
+	}
+	list.Suppressions = kept
+
+	return true, writeSuppressions(path, list)
+}
+
+// Suppressions returns the machine-wide suppressions followed by those of the project, if given
+func (s *SuppressionService) Suppressions(projectPath string) ([]models.Suppression, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var suppressions []models.Suppression
+	sources := []struct{ path, scope string }{{s.machinePath, config.SUPPRESSION_SCOPE_MACHINE}}
+	if projectPath != "" {
+		sources = append(sources, struct{ path, scope string }{
+			filepath.Join(projectPath, config.PROJECT_SUPPRESSIONS_FILE), config.SUPPRESSION_SCOPE_PROJECT,
+		})
+	}
+
+	for _, source := range sources {
+		list, err := readSuppressions(source.path)
+		if err != nil {
+			return nil, err
+		}
+		for _, suppression := range list.Suppressions {
+			suppression.Scope = source.scope
+			suppressions = append(suppressions, suppression)
+		}
+	}
+	return suppressions, nil
+}
+
+// replaceMachine overwrites the machine-wide suppressions, used when syncing with a team database
+func (s *SuppressionService) replaceMachine(suppressions []models.Suppression) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return writeSuppressions(s.machinePath, &models.SuppressionList{Suppressions: suppressions})
+}
+
+// file returns the suppression file and scope for a project, or the machine-wide file
+func (s *SuppressionService) file(projectPath string) (string, string, error) {
+	if projectPath == "" {
+		return s.machinePath, config.SUPPRESSION_SCOPE_MACHINE, nil
+	}
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return "", "", err
+	}
+	if !info.IsDir() {
+		return "", "", fmt.Errorf("%s is not a directory", projectPath)
+	}
+	return filepath.Join(projectPath, config.PROJECT_SUPPRESSIONS_FILE), config.SUPPRESSION_SCOPE_PROJECT, nil
+}
+
+// readSuppressions loads a suppression file; a missing file holds no suppressions
+func readSuppressions(path string) (*models.SuppressionList, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &models.SuppressionList{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list models.SuppressionList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid suppression file %s: %v", path, err)
+	}
+	return &list, nil
+}
+
+// writeSuppressions saves a suppression file, creating its directory if needed
+func writeSuppressions(path string, list *models.SuppressionList) error {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestSuppressionService(t *testing.T) {
+	machineDir := t.TempDir()
+	projectDir := t.TempDir()
+	service := NewSuppressionService(filepath.Join(machineDir, config.SUPPRESSIONS_FILE))
+
+	if _, err := service.Suppress("RaisedButton", "legacy screens", ""); err != nil {
+		t.Fatalf("Suppress failed: %v", err)
+	}
+	if _, err := service.Suppress("RaisedButton", "removed next release", ""); err != nil {
+		t.Fatalf("Suppress failed: %v", err)
+	}
+	if _, err := service.Suppress("Color.withOpacity", "", projectDir); err != nil {
+		t.Fatalf("Suppress failed: %v", err)
+	}
+	if _, err := service.Suppress(" ", "", ""); err == nil {
+		t.Error("Expected an error for an empty API")
+	}
+	if _, err := service.Suppress("FlatButton", "", filepath.Join(projectDir, "missing")); err == nil {
+		t.Error("Expected an error for a missing project directory")
+	}
+
+	if _, err := os.Stat(filepath.Join(projectDir, config.PROJECT_SUPPRESSIONS_FILE)); err != nil {
+		t.Errorf("Expected the project suppression file to be written: %v", err)
+	}
+
+	machine, err := service.Suppressions("")
+	if err != nil {
+		t.Fatalf("Suppressions failed: %v", err)
+	}
+	if len(machine) != 1 || machine[0].Reason != "removed next release" || machine[0].Scope != config.SUPPRESSION_SCOPE_MACHINE {
+		t.Errorf("Unexpected machine suppressions %+v", machine)
+	}
+
+	// A new service instance reads the persisted files
+	all, err := NewSuppressionService(filepath.Join(machineDir, config.SUPPRESSIONS_FILE)).Suppressions(projectDir)
+	if err != nil {
+		t.Fatalf("Suppressions failed: %v", err)
+	}
+	if len(all) != 2 || all[1].API != "Color.withOpacity" || all[1].Scope != config.SUPPRESSION_SCOPE_PROJECT {
+		t.Errorf("Unexpected combined suppressions %+v", all)
+	}
+
+	removed, err := service.Unsuppress("RaisedButton", "")
+	if err != nil || !removed {
+		t.Fatalf("Expected RaisedButton to be removed, got removed=%v err=%v", removed, err)
+	}
+	removed, _ = service.Unsuppress("RaisedButton", "")
+	if removed {
+		t.Error("Expected a second removal to report nothing removed")
+	}
+	if machine, _ := service.Suppressions(""); len(machine) != 0 {
+		t.Errorf("Expected no machine suppressions, got %+v", machine)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// TeamSyncService shares the manual deprecation entries and machine-wide suppressions with a team
+// database: a single JSON document read with GET and written with PUT at a URL
+type TeamSyncService struct {
+	url          string
+	authHeader   string
+	authValue    string
+	client       *http.Client
+	cacheService CacheServiceInterface
+	suppressions *SuppressionService
+	stats        StatsRecorder
+
+	mu sync.Mutex
+}
+
+// NewTeamSyncService creates a team database client. authValue is sent in the authHeader request
+// header (Authorization when empty) and may be left empty for endpoints without authentication.
+func NewTeamSyncService(url string, authHeader string, authValue string, cacheService CacheServiceInterface, suppressions *SuppressionService) *TeamSyncService {
+	if authHeader == "" {
+		authHeader = config.TEAM_DB_AUTH_HEADER
+	}
+	return &TeamSyncService{
+		url:          url,
+		authHeader:   authHeader,
+		authValue:    authValue,
+		client:       &http.Client{Timeout: config.TEAM_DB_TIMEOUT},
+		cacheService: cacheService,
+		suppressions: suppressions,
+	}
+}
+
+// SetTransport sends the team database calls through transport, such as one that trusts a corporate CA
+func (t *TeamSyncService) SetTransport(transport http.RoundTripper) {
+	t.client.Transport = transport
+}
+
+// SetStatsRecorder reports the latency of every team database call to recorder
+func (t *TeamSyncService) SetStatsRecorder(recorder StatsRecorder) {
+	t.stats = recorder
+}
+
+// Pull replaces the local manual entries and machine-wide suppressions with the team database.
+// When the endpoint has no document yet, it is seeded with the local data instead.
+func (t *TeamSyncService) Pull(ctx context.Context) (*models.TeamDatabase, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resp, err := t.do(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		db, err := t.local()
+		if err != nil {
+			return nil, err
+		}
+		return db, t.put(ctx, db)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("team database returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var db models.TeamDatabase
+	if err := json.Unmarshal(body, &db); err != nil {
+		return nil, fmt.Errorf("invalid team database document: %v", err)
+	}
+
+	cache, err := t.cacheService.Load()
+	if err != nil {
+		return nil, err
+	}
+	cache.Manual = db.Manual
+	if err := t.cacheService.Save(cache); err != nil {
+		return nil, err
+	}
+	if err := t.suppressions.replaceMachine(db.Suppressions); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+// Push uploads the local manual entries and machine-wide suppressions to the team database
+func (t *TeamSyncService) Push(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	db, err := t.local()
+	if err != nil {
+		return err
+	}
+	return t.put(ctx, db)
+}
+
+// local collects the data shared with the team from the cache and the machine suppression file
+func (t *TeamSyncService) local() (*models.TeamDatabase, error) {
+	cache, err := t.cacheService.Load()
+	if err != nil {
+		return nil, err
+	}
+	suppressions, err := t.suppressions.Suppressions("")
+	if err != nil {
+		return nil, err
+	}
+	return &models.TeamDatabase{
+		UpdatedAt:    time.Now(),
+		Manual:       cache.Manual,
+		Suppressions: suppressions,
+	}, nil
+}
+
+// put writes db to the team database; the caller holds t.mu
+func (t *TeamSyncService) put(ctx context.Context, db *models.TeamDatabase) error {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	resp, err := t.do(ctx, http.MethodPut, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("team database rejected the update with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// do sends an authenticated request to the team database
+func (t *TeamSyncService) do(ctx context.Context, method string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.authValue != "" {
+		req.Header.Set(t.authHeader, t.authValue)
+	}
+
+	start := time.Now()
+	resp, err := t.client.Do(req)
+	if t.stats != nil {
+		t.stats.RecordUpstream(UpstreamTeamDB, time.Since(start), err != nil || resp.StatusCode >= 500)
+	}
+	return resp, err
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// teamDatabaseServer stores one JSON document and requires an API key
+type teamDatabaseServer struct {
+	mu       sync.Mutex
+	document []byte
+	puts     int
+}
+
+func (s *teamDatabaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-API-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		if s.document == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(s.document)
+	case http.MethodPut:
+		s.document, _ = ioutil.ReadAll(r.Body)
+		s.puts++
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestTeamSyncService(t *testing.T) {
+	remote := &teamDatabaseServer{}
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	newInstance := func(t *testing.T) (*TeamSyncService, *DeprecationService, *SuppressionService) {
+		cacheService := &CacheService{dir: t.TempDir()}
+		suppressions := NewSuppressionService(filepath.Join(t.TempDir(), config.SUPPRESSIONS_FILE))
+		teamSync := NewTeamSyncService(server.URL, "X-API-Key", "secret", cacheService, suppressions)
+		return teamSync, NewDeprecationService(cacheService, &MockFlutterAPIService{}), suppressions
+	}
+
+	ctx := context.Background()
+
+	// The first developer seeds the empty endpoint with their local data
+	alice, aliceDeprecations, aliceSuppressions := newInstance(t)
+	if _, err := aliceDeprecations.AddManualDeprecation(models.Deprecation{API: "LegacyCard", Replacement: "AppCard"}); err != nil {
+		t.Fatalf("AddManualDeprecation failed: %v", err)
+	}
+	if _, err := alice.Pull(ctx); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if remote.puts != 1 {
+		t.Fatalf("Expected the endpoint to be seeded, got %d PUTs", remote.puts)
+	}
+
+	if _, err := aliceSuppressions.Suppress("RaisedButton", "legacy screens", ""); err != nil {
+		t.Fatalf("Suppress failed: %v", err)
+	}
+	if err := alice.Push(ctx); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	// A second developer picks up both the manual entry and the suppression
+	bob, bobDeprecations, bobSuppressions := newInstance(t)
+	db, err := bob.Pull(ctx)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(db.Manual) != 1 || len(db.Suppressions) != 1 {
+		t.Fatalf("Unexpected team database %+v", db)
+	}
+	if found := bobDeprecations.CheckCodeForDeprecations("LegacyCard()"); len(found) != 1 {
+		t.Errorf("Expected the shared manual entry to be detected, got %+v", found)
+	}
+	if suppressed, _ := bobSuppressions.Suppressions(""); len(suppressed) != 1 || suppressed[0].Reason != "legacy screens" {
+		t.Errorf("Expected the shared suppression, got %+v", suppressed)
+	}
+
+	t.Run("rejects missing credentials", func(t *testing.T) {
+		unauthenticated := NewTeamSyncService(server.URL, "", "", &CacheService{dir: t.TempDir()}, NewSuppressionService(filepath.Join(t.TempDir(), config.SUPPRESSIONS_FILE)))
+		if _, err := unauthenticated.Pull(ctx); err == nil {
+			t.Error("Expected an error without the API key")
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// UpgradeDigestService consolidates the release notes, deprecations and breaking changes of every
+// stable release between the installed Flutter version and the latest one
+type UpgradeDigestService struct {
+	apiService FlutterAPIServiceInterface
+	history    ReleaseHistoryServiceInterface
+	sdks       LocalSDKServiceInterface
+	whatsNew   WhatsNewServiceInterface
+}
+
+// NewUpgradeDigestService creates a new upgrade digest service instance. sdks may be nil, in which
+// case the installed version has to be given.
+func NewUpgradeDigestService(apiService FlutterAPIServiceInterface, history ReleaseHistoryServiceInterface, sdks LocalSDKServiceInterface, whatsNew WhatsNewServiceInterface) *UpgradeDigestService {
+	return &UpgradeDigestService{
+		apiService: apiService,
+		history:    history,
+		sdks:       sdks,
+		whatsNew:   whatsNew,
+	}
+}
+
+// UpgradeDigest returns what upgrading from the installed version to the latest stable release
+// brings. Without from, the installed version is the one the project at projectPath pins, else
+// the active SDK's. Every release line after the installed one contributes its release digest;
+// patch releases of the installed line only their release notes. Unreachable sources are
+// recorded in UpstreamErrors instead of failing the call.
+func (u *UpgradeDigestService) UpgradeDigest(ctx context.Context, from string, projectPath string) (*models.UpgradeDigest, error) {
+	from, source := strings.TrimPrefix(strings.TrimSpace(from), "v"), "argument"
+	if from == "" {
+		var err error
+		if from, source, err = u.installedVersion(ctx, projectPath); err != nil {
+			return nil, err
+		}
+	}
+	installed, ok := parseVersion(from)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", from)
+	}
+
+	history, err := u.history.StableReleases(ctx, 0, from)
+	if err != nil {
+		return nil, fmt.Errorf("error listing stable releases: %v", err)
+	}
+
+	digest := &models.UpgradeDigest{From: from, FromSource: source, To: from, Latest: history.Latest}
+	installedStable, stable := parseStableVersion(from)
+	for i := len(history.Releases) - 1; i >= 0; i-- {
+		release := history.Releases[i]
+		if v, ok := parseStableVersion(release.Version); ok && stable && v == installedStable {
+			continue
+		}
+		digest.Releases = append(digest.Releases, models.UpgradeRelease{
+			Version:        release.Version,
+			ReleaseDate:    release.ReleaseDate,
+			DartSDKVersion: release.DartSDKVersion,
+		})
+	}
+	if len(digest.Releases) == 0 {
+		return digest, nil
+	}
+	digest.To = digest.Releases[len(digest.Releases)-1].Version
+
+	if releases, err := u.apiService.FetchReleases(ctx); err != nil {
+		digest.UpstreamErrors = append(digest.UpstreamErrors, fmt.Sprintf("GitHub releases: %v; the notes of the individual releases are missing", err))
+	} else {
+		notes := make(map[string]string)
+		for _, r := range releases {
+			if tag := u.apiService.ParseVersionFromRelease(r); notes[tag] == "" {
+				notes[tag] = strings.TrimSpace(r.Body)
+			}
+		}
+		for i := range digest.Releases {
+			digest.Releases[i].Notes = notes[digest.Releases[i].Version]
+		}
+	}
+
+	seenDeprecations := make(map[string]bool)
+	seenChanges := make(map[string]bool)
+	seenErrors := make(map[string]bool)
+	var previous semanticVersion
+	for i, release := range digest.Releases {
+		v, ok := parseVersion(release.Version)
+		line := semanticVersion{Major: v.Major, Minor: v.Minor}
+		if !ok || line.Compare(installed) <= 0 || line == previous {
+			continue
+		}
+		previous = line
+
+		lineDigest, err := u.whatsNew.WhatsNew(ctx, line.String())
+		if err != nil {
+			digest.UpstreamErrors = append(digest.UpstreamErrors, fmt.Sprintf("Flutter %s digest: %v", line, err))
+			continue
+		}
+		digest.Releases[i].ReleaseNotesURL = lineDigest.ReleaseNotesURL
+		for _, dep := range lineDigest.Deprecations {
+			if key := dep.Package + "\x00" + dep.API; !seenDeprecations[key] {
+				seenDeprecations[key] = true
+				digest.Deprecations = append(digest.Deprecations, dep)
+			}
+		}
+		for _, change := range lineDigest.BreakingChanges {
+			if key := change.Title + "\x00" + change.URL; !seenChanges[key] {
+				seenChanges[key] = true
+				digest.BreakingChanges = append(digest.BreakingChanges, change)
+			}
+		}
+		for _, upstreamError := range lineDigest.UpstreamErrors {
+			if !seenErrors[upstreamError] {
+				seenErrors[upstreamError] = true
+				digest.UpstreamErrors = append(digest.UpstreamErrors, upstreamError)
+			}
+		}
+	}
+	sort.SliceStable(digest.Deprecations, func(i, j int) bool {
+		return digest.Deprecations[i].API < digest.Deprecations[j].API
+	})
+	digest.Replacements = replacementAPIs(digest.Deprecations)
+	return digest, nil
+}
+
+// installedVersion returns the Flutter version the project at projectPath pins, else that of the
+// active SDK, with where it was found
+func (u *UpgradeDigestService) installedVersion(ctx context.Context, projectPath string) (string, string, error) {
+	if u.sdks == nil {
+		return "", "", fmt.Errorf("no Flutter version given and local SDK detection is not available")
+	}
+	report, err := u.sdks.Detect(ctx, projectPath)
+	if err != nil {
+		return "", "", fmt.Errorf("error detecting the installed Flutter SDK: %v", err)
+	}
+	if pinned := strings.TrimPrefix(report.PinnedVersion, "v"); pinned != "" {
+		if _, ok := parseVersion(pinned); ok {
+			return pinned, report.PinnedBy, nil
+		}
+	}
+	for _, sdk := range report.SDKs {
+		if sdk.Active && sdk.Version != "" {
+			return sdk.Version, "active SDK", nil
+		}
+	}
+	return "", "", fmt.Errorf("no Flutter version given and no active Flutter SDK found")
+}
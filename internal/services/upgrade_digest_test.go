@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// staticSDKs reports a fixed set of local SDKs
+type staticSDKs models.LocalSDKReport
+
+func (s *staticSDKs) Detect(ctx context.Context, projectPath string) (*models.LocalSDKReport, error) {
+	report := models.LocalSDKReport(*s)
+	return &report, nil
+}
+
+func TestUpgradeDigest(t *testing.T) {
+	mockAPI := &MockFlutterAPIService{
+		officialReleases: &models.FlutterReleasesResponse{Releases: []models.FlutterOfficialRelease{
+			{Channel: "beta", Version: "3.28.0-0.1.pre", ReleaseDate: "2025-01-10T00:00:00Z"},
+			{Channel: "stable", Version: "3.27.1", ReleaseDate: "2024-12-17T00:00:00Z"},
+			{Channel: "stable", Version: "3.27.0", ReleaseDate: "2024-12-11T00:00:00Z", DartSDKVersion: "3.6.0"},
+			{Channel: "stable", Version: "3.24.5", ReleaseDate: "2024-11-14T00:00:00Z"},
+			{Channel: "stable", Version: "3.24.3", ReleaseDate: "2024-09-11T00:00:00Z"},
+			{Channel: "stable", Version: "3.24.0", ReleaseDate: "2024-08-06T00:00:00Z"},
+		}},
+		releases: []models.FlutterRelease{
+			{TagName: "3.27.1", Body: "Fixes a crash in the engine"},
+			{TagName: "3.24.5", Body: "Deprecated: Patch.api in favor of Patched.api"},
+		},
+	}
+	cacheService := &CacheService{dir: t.TempDir()}
+	err := cacheService.Save(&models.DeprecationCache{Deprecations: []models.Deprecation{
+		{API: "Color.value", Replacement: "Color.toARGB32", Version: "3.27.0", Source: config.DEPRECATION_SOURCE_FLUTTER},
+		{API: "Mid.api", Replacement: "Later.api", Version: "3.25.0-0.1.pre", Source: config.DEPRECATION_SOURCE_FLUTTER},
+		{API: "Old.line", Replacement: "New.line", Version: "3.24.0-0.1.pre", Source: config.DEPRECATION_SOURCE_FLUTTER},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to save cache: %v", err)
+	}
+	newService := func(sdks LocalSDKServiceInterface) *UpgradeDigestService {
+		return NewUpgradeDigestService(mockAPI, NewReleaseHistoryService(mockAPI), sdks, NewWhatsNewService(mockAPI, cacheService, nil))
+	}
+
+	t.Run("Consolidates the releases after the installed version", func(t *testing.T) {
+		digest, err := newService(nil).UpgradeDigest(context.Background(), "v3.24.3", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if digest.From != "3.24.3" || digest.FromSource != "argument" || digest.To != "3.27.1" || digest.Latest != "3.27.1" {
+			t.Errorf("Expected 3.24.3 from the argument to 3.27.1, got %+v", digest)
+		}
+
+		var versions []string
+		for _, release := range digest.Releases {
+			versions = append(versions, release.Version)
+		}
+		if got := strings.Join(versions, ","); got != "3.24.5,3.27.0,3.27.1" {
+			t.Fatalf("Expected the newer stable releases oldest first, got %s", got)
+		}
+		if digest.Releases[0].Notes == "" || digest.Releases[2].Notes != "Fixes a crash in the engine" || digest.Releases[1].Notes != "" {
+			t.Errorf("Expected the GitHub release notes of each release, got %+v", digest.Releases)
+		}
+		if digest.Releases[0].ReleaseNotesURL != "" || !strings.HasSuffix(digest.Releases[1].ReleaseNotesURL, "release-notes-3.27.0") || digest.Releases[2].ReleaseNotesURL != "" {
+			t.Errorf("Expected the release notes page on the first release of the 3.27 line only, got %+v", digest.Releases)
+		}
+
+		var apis []string
+		for _, dep := range digest.Deprecations {
+			apis = append(apis, dep.API)
+		}
+		for _, api := range []string{"Color.value", "Mid.api"} {
+			if !strings.Contains(","+strings.Join(apis, ",")+",", ","+api+",") {
+				t.Errorf("Expected %s to be in the digest, got %v", api, apis)
+			}
+		}
+		for _, api := range []string{"Old.line", "Patch.api"} {
+			if strings.Contains(strings.Join(apis, ","), api) {
+				t.Errorf("Expected %s of the installed line to be left out, got %v", api, apis)
+			}
+		}
+		if len(digest.Replacements) == 0 {
+			t.Error("Expected the replacement APIs of the deprecations")
+		}
+	})
+
+	t.Run("Reads the installed version from the project pin or the active SDK", func(t *testing.T) {
+		pinned := &staticSDKs{PinnedVersion: "3.27.0", PinnedBy: ".fvmrc", SDKs: []models.LocalFlutterSDK{{Version: "3.24.3", Active: true}}}
+		digest, err := newService(pinned).UpgradeDigest(context.Background(), "", "app")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if digest.From != "3.27.0" || digest.FromSource != ".fvmrc" || len(digest.Releases) != 1 || len(digest.Deprecations) != 0 {
+			t.Errorf("Expected the pinned 3.27.0 to only need the 3.27.1 patch, got %+v", digest)
+		}
+
+		active := &staticSDKs{PinnedVersion: "stable", SDKs: []models.LocalFlutterSDK{{Version: "3.27.1", Active: true}}}
+		digest, err = newService(active).UpgradeDigest(context.Background(), "", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if digest.From != "3.27.1" || digest.FromSource != "active SDK" || len(digest.Releases) != 0 || digest.To != "3.27.1" {
+			t.Errorf("Expected the active 3.27.1 to be up to date, got %+v", digest)
+		}
+	})
+
+	t.Run("Needs a version without a local SDK", func(t *testing.T) {
+		if _, err := newService(nil).UpgradeDigest(context.Background(), "", ""); err == nil {
+			t.Error("Expected an error without a version or SDK detection")
+		}
+		if _, err := newService(&staticSDKs{}).UpgradeDigest(context.Background(), "", ""); err == nil {
+			t.Error("Expected an error without an active SDK")
+		}
+		if _, err := newService(nil).UpgradeDigest(context.Background(), "latest", ""); err == nil {
+			t.Error("Expected an error for an invalid version")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// upstreamProbe is the request check_upstreams sends to one upstream host
+type upstreamProbe struct {
+	name string
+	url  string
+}
+
+// upstreamProbes cover the hosts of config.UpstreamHosts with cheap endpoints; the GitHub
+// rate_limit endpoint does not count against the quota
+var upstreamProbes = []upstreamProbe{
+	{"GitHub API", config.GITHUB_RATE_LIMIT_URL},
+	{"GitHub raw content", config.GITHUB_RAW_URL + "flutter/flutter/" + config.FLUTTER_CHANNEL_STABLE + "/README.md"},
+	{"Flutter releases", config.FLUTTER_RELEASES_URL},
+	{"Docker Hub", "https://hub.docker.com/v2/repositories/" + config.DOCKER_IMAGE_INSTRUMENTISTO},
+	{"GitHub Container Registry", "https://ghcr.io/v2/"},
+	{"pub.dev", config.PUB_API_PACKAGES_URL + "http"},
+}
+
+// CheckUpstreams probes every upstream host concurrently with a HEAD request that may take
+// config.UPSTREAM_CHECK_TIMEOUT. The requests go through the configured transport, so mirrors, the
+// extra CA and air-gapped mode apply to them as to any other upstream call. A host that answers at
+// all, even with a 401 or 404, is reachable; a 5xx, a timeout or a connection error is not.
+func (f *FlutterAPIService) CheckUpstreams(ctx context.Context) []models.UpstreamCheck {
+	results := make([]models.UpstreamCheck, len(upstreamProbes))
+	var wg sync.WaitGroup
+	for i, probe := range upstreamProbes {
+		wg.Add(1)
+		go func(i int, probe upstreamProbe) {
+			defer wg.Done()
+			results[i] = f.checkUpstream(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+	return results
+}
+
+// checkUpstream sends one probe and times it
+func (f *FlutterAPIService) checkUpstream(ctx context.Context, probe upstreamProbe) models.UpstreamCheck {
+	result := models.UpstreamCheck{Name: probe.name, URL: probe.url}
+
+	ctx, cancel := context.WithTimeout(ctx, config.UPSTREAM_CHECK_TIMEOUT)
+	defer cancel()
+	req, err := f.newRequest(ctx, http.MethodHead, probe.url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := f.do(req, upstreamForHost(req.URL.Host))
+	result.Latency = time.Since(start)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			result.Error = "timed out after " + config.UPSTREAM_CHECK_TIMEOUT.String()
+		} else {
+			result.Error = err.Error()
+		}
+		return result
+	}
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.Reachable = resp.StatusCode < http.StatusInternalServerError
+	return result
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCheckUpstreams(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/flutter_infra_release/releases/releases_linux.json":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	redirect := redirectTransport(server.URL)
+	service := &FlutterAPIService{client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "hub.docker.com" {
+			return nil, errors.New("connection refused")
+		}
+		return redirect.RoundTrip(r)
+	})}}
+
+	checks := service.CheckUpstreams(context.Background())
+	if len(checks) != len(upstreamProbes) {
+		t.Fatalf("Expected one check per probe, got %+v", checks)
+	}
+	for _, check := range checks {
+		switch check.Name {
+		case "GitHub Container Registry":
+			if !check.Reachable || check.Status != http.StatusUnauthorized {
+				t.Errorf("Expected an authentication challenge to count as reachable, got %+v", check)
+			}
+		case "Flutter releases":
+			if check.Reachable || check.Status != http.StatusBadGateway {
+				t.Errorf("Expected a server error to count as unreachable, got %+v", check)
+			}
+		case "Docker Hub":
+			if check.Reachable || check.Error == "" {
+				t.Errorf("Expected the connection error to be reported, got %+v", check)
+			}
+		default:
+			if !check.Reachable || check.Status != http.StatusOK || check.Error != "" {
+				t.Errorf("Expected %s to be reachable, got %+v", check.Name, check)
+			}
+		}
+	}
+	for _, method := range methods {
+		if method != http.MethodHead {
+			t.Errorf("Expected only HEAD probes, got %s", method)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// mirrorTransport sends the requests for an upstream host to its mirror, keeping the path and
+// query below the mirror's base URL, so that https://raw.githubusercontent.com/flutter/flutter/...
+// mirrored at https://mirror.example.com/github-raw is fetched from
+// https://mirror.example.com/github-raw/flutter/flutter/..., without the credentials of the upstream host
+type mirrorTransport struct {
+	base      http.RoundTripper
+	mirrors   map[string]*url.URL
+	airGapped bool
+	allowed   map[string]bool
+}
+
+// ParseUpstreamMirrors parses a comma separated list of host=mirror pairs such as
+// raw.githubusercontent.com=https://mirror.example.com/github-raw
+func ParseUpstreamMirrors(value string) (map[string]*url.URL, error) {
+	mirrors := make(map[string]*url.URL)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		host, mirror, ok := strings.Cut(part, "=")
+		host = strings.ToLower(strings.TrimSpace(host))
+		parsed, err := url.Parse(strings.TrimSpace(mirror))
+		if !ok || host == "" || strings.ContainsAny(host, "/:") || err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+			return nil, fmt.Errorf("invalid upstream mirror %q (expected host=URL such as raw.githubusercontent.com=https://mirror.example.com/github-raw)", part)
+		}
+		parsed.Path = strings.TrimRight(parsed.Path, "/")
+		mirrors[host] = parsed
+	}
+	return mirrors, nil
+}
+
+// NewMirrorTransport wraps base so that requests for the hosts in mirrors go to their mirrors. In
+// air-gapped mode, requests for any other host fail straight away instead of timing out, which
+// also guarantees that nothing leaves the internal network; allowedHosts, such as the Docker
+// registry mirrors, are internal already and stay reachable.
+func NewMirrorTransport(base http.RoundTripper, mirrors map[string]*url.URL, airGapped bool, allowedHosts ...string) http.RoundTripper {
+	if len(mirrors) == 0 && !airGapped {
+		return base
+	}
+	allowed := make(map[string]bool, len(allowedHosts))
+	for _, host := range allowedHosts {
+		allowed[strings.ToLower(host)] = true
+	}
+	return &mirrorTransport{base: base, mirrors: mirrors, airGapped: airGapped, allowed: allowed}
+}
+
+// UnmirroredHosts lists the upstream hosts that have no mirror, whose features fail in air-gapped mode
+func UnmirroredHosts(mirrors map[string]*url.URL) []string {
+	var hosts []string
+	for _, host := range config.UpstreamHosts() {
+		if _, ok := mirrors[host]; !ok {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// RoundTrip implements http.RoundTripper
+func (m *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mirror, ok := m.mirrors[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		if m.airGapped && !m.allowed[strings.ToLower(req.URL.Host)] {
+			return nil, fmt.Errorf("air-gapped mode: no upstream mirror configured for %s", req.URL.Host)
+		}
+		return m.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.Scheme = mirror.Scheme
+	req.URL.Host = mirror.Host
+	req.URL.Path = mirror.Path + req.URL.Path
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = mirror.EscapedPath() + req.URL.RawPath
+	}
+	req.Host = ""
+	// The GitHub token and any cookies are meant for the upstream host, not for its mirror
+	req.Header.Del("Authorization")
+	req.Header.Del("Cookie")
+	return m.base.RoundTrip(req)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpstreamMirrors(t *testing.T) {
+	var requested []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/github-raw/flutter/website/main/"):
+			w.Write([]byte("# Guide"))
+		case r.URL.Path == "/flutter-releases/flutter_infra_release/releases/releases_linux.json":
+			w.Write([]byte(`{"releases": [{"version": "3.27.1", "channel": "stable"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	mirrors, err := ParseUpstreamMirrors("raw.githubusercontent.com=" + mirror.URL + "/github-raw/, Storage.GoogleAPIs.com=" + mirror.URL + "/flutter-releases")
+	if err != nil {
+		t.Fatalf("ParseUpstreamMirrors failed: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("Fetches mirrored hosts from their mirrors", func(t *testing.T) {
+		service := NewFlutterAPIService()
+		service.SetTransport(NewMirrorTransport(http.DefaultTransport, mirrors, true))
+
+		if content, err := service.FetchWebsiteFile(ctx, "src/release/breaking-changes/index.md"); err != nil || content != "# Guide" {
+			t.Errorf("Expected the mirrored guide, got %q and %v", content, err)
+		}
+		if version, err := service.GetLatestStableVersion(ctx); err != nil || version != "3.27.1" {
+			t.Errorf("Expected the mirrored releases, got %q and %v", version, err)
+		}
+		if len(requested) != 2 || requested[0] != "/github-raw/flutter/website/main/src/release/breaking-changes/index.md" {
+			t.Errorf("Expected the paths below the mirror prefixes, got %v", requested)
+		}
+	})
+
+	t.Run("Keeps the GitHub token from mirrors", func(t *testing.T) {
+		var authorization []string
+		githubMirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = append(authorization, r.Header.Get("Authorization"))
+			w.Write([]byte("[]"))
+		}))
+		defer githubMirror.Close()
+		apiMirrors, err := ParseUpstreamMirrors("api.github.com=" + githubMirror.URL + "/github-api")
+		if err != nil {
+			t.Fatalf("ParseUpstreamMirrors failed: %v", err)
+		}
+
+		service := NewFlutterAPIService()
+		service.githubToken = "secret"
+		service.SetTransport(NewMirrorTransport(http.DefaultTransport, apiMirrors, false))
+		if _, err := service.FetchReleases(ctx); err != nil {
+			t.Fatalf("Expected the mirrored releases, got %v", err)
+		}
+		if len(authorization) != 1 || authorization[0] != "" {
+			t.Errorf("Expected the mirror to get no Authorization header, got %q", authorization)
+		}
+	})
+
+	t.Run("Air-gapped mode refuses other hosts", func(t *testing.T) {
+		service := NewFlutterAPIService()
+		service.SetTransport(NewMirrorTransport(http.DefaultTransport, mirrors, true))
+		if _, err := service.FetchReleases(ctx); err == nil || !strings.Contains(err.Error(), "air-gapped mode") {
+			t.Errorf("Expected api.github.com to be refused, got %v", err)
+		}
+
+		// Hosts that are internal already, such as Docker registry mirrors, stay reachable
+		allowed := NewMirrorTransport(http.DefaultTransport, nil, true, strings.TrimPrefix(mirror.URL, "http://"))
+		req, _ := http.NewRequest(http.MethodGet, mirror.URL+"/anything", nil)
+		if resp, err := allowed.RoundTrip(req); err != nil {
+			t.Errorf("Expected the allowed host to be reachable, got %v", err)
+		} else {
+			resp.Body.Close()
+		}
+
+		if unmirrored := UnmirroredHosts(mirrors); strings.Join(unmirrored, ",") != "api.github.com,ghcr.io,hub.docker.com,pub.dev" {
+			t.Errorf("Expected the hosts without a mirror, got %v", unmirrored)
+		}
+	})
+
+	t.Run("Rejects invalid mirrors", func(t *testing.T) {
+		for _, value := range []string{"raw.githubusercontent.com", "https://api.github.com=https://mirror.example.com", "api.github.com=mirror.example.com"} {
+			if _, err := ParseUpstreamMirrors(value); err == nil {
+				t.Errorf("Expected an error for %q", value)
+			}
+		}
+		if transport := NewMirrorTransport(http.DefaultTransport, nil, false); transport != http.DefaultTransport {
+			t.Error("Expected the base transport without mirrors")
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+// CheckCodeAgainstVersion reports the deprecations in code that apply on the target Flutter version.
+// Entries deprecated after the target are only counted. When current is given, entries deprecated
+// between current and target are separated from the ones the code already lived with. APIs that
+// need a newer release than the target are reported as unavailable.
+func (d *DeprecationService) CheckCodeAgainstVersion(code string, target string, current string) (*models.VersionCheckResult, error) {
+	targetVersion, ok := parseVersion(target)
+	if !ok {
+		return nil, fmt.Errorf("invalid target version %q", target)
+	}
+
+	var currentVersion semanticVersion
+	hasCurrent := current != ""
+	if hasCurrent {
+		if currentVersion, ok = parseVersion(current); !ok {
+			return nil, fmt.Errorf("invalid current version %q", current)
+		}
+		if currentVersion.Compare(targetVersion) > 0 {
+			return nil, fmt.Errorf("current version %s is newer than target version %s", current, target)
+		}
+	}
+
+	result := &models.VersionCheckResult{TargetVersion: targetVersion.String()}
+	if hasCurrent {
+		result.CurrentVersion = currentVersion.String()
+	}
+
+	for _, dep := range uniqueByAPI(d.CheckCodeForDeprecations(code)) {
+		version, dated := parseVersion(dep.Version)
+		switch {
+		case !dated:
+			result.Undated = append(result.Undated, dep)
+		case version.Compare(targetVersion) > 0:
+			result.NotYet++
+		case hasCurrent && version.Compare(currentVersion) <= 0:
+			result.AlreadyPresent = append(result.AlreadyPresent, dep)
+		default:
+			result.Introduced = append(result.Introduced, dep)
+		}
+	}
+
+	// Code that runs on the target cannot use APIs that first ship after it
+	for _, requirement := range NewMinimumVersionService().InferFromCode(code).Requirements {
+		if version, ok := parseVersion(requirement.Version); ok && version.Compare(targetVersion) > 0 {
+			result.Unavailable = append(result.Unavailable, requirement)
+		}
+	}
+
+	return result, nil
+}
+
+// uniqueByAPI keeps one entry per API, preferring the one with a parseable version
+func uniqueByAPI(deprecations []models.Deprecation) []models.Deprecation {
+	index := make(map[string]int)
+	var unique []models.Deprecation
+	for _, dep := range deprecations {
+		i, seen := index[dep.API]
+		if !seen {
+			index[dep.API] = len(unique)
+			unique = append(unique, dep)
+			continue
+		}
+		if _, dated := parseVersion(unique[i].Version); !dated {
+			if _, ok := parseVersion(dep.Version); ok {
+				unique[i] = dep
+			}
+		}
+	}
+	return unique
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestCheckCodeAgainstVersion(t *testing.T) {
+	cacheService := &CacheService{dir: t.TempDir()}
+	depService := NewDeprecationService(cacheService, NewFlutterAPIService())
+
+	err := cacheService.Save(&models.DeprecationCache{
+		LastUpdated: time.Now(),
+		Deprecations: []models.Deprecation{
+			{API: "ColorScheme.background", Version: "3.18.0-0.1.pre"},
+			{API: "MaterialState", Version: "3.19.0-0.3.pre"},
+			{API: "OldThing"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error saving cache, got %v", err)
+	}
+
+	code := "RaisedButton(); Color.red.withOpacity(0.5); ColorScheme.background; MaterialState; OldThing"
+
+	t.Run("Target only", func(t *testing.T) {
+		result, err := depService.CheckCodeAgainstVersion(code, "3.19.0", "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(result.Introduced) != 3 {
+			t.Errorf("Expected RaisedButton, ColorScheme.background and MaterialState, got %+v", result.Introduced)
+		}
+		if result.NotYet != 1 {
+			t.Errorf("Expected withOpacity (3.27) to be counted as not yet deprecated, got %d", result.NotYet)
+		}
+		if len(result.Undated) != 1 || result.Undated[0].API != "OldThing" {
+			t.Errorf("Expected OldThing as undated, got %+v", result.Undated)
+		}
+	})
+
+	t.Run("Upgrade from current version", func(t *testing.T) {
+		result, err := depService.CheckCodeAgainstVersion(code, "3.27", "3.16.0")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		introduced := make(map[string]bool)
+		for _, dep := range result.Introduced {
+			introduced[dep.API] = true
+		}
+		if len(introduced) != 3 || !introduced["Color.withOpacity"] || !introduced["ColorScheme.background"] || !introduced["MaterialState"] {
+			t.Errorf("Expected three newly deprecated APIs, got %+v", result.Introduced)
+		}
+		if len(result.AlreadyPresent) != 1 || result.AlreadyPresent[0].API != "RaisedButton" {
+			t.Errorf("Expected RaisedButton as already deprecated, got %+v", result.AlreadyPresent)
+		}
+	})
+
+	t.Run("Invalid versions", func(t *testing.T) {
+		if _, err := depService.CheckCodeAgainstVersion(code, "latest", ""); err == nil {
+			t.Error("Expected error for invalid target version")
+		}
+		if _, err := depService.CheckCodeAgainstVersion(code, "3.0.0", "3.10.0"); err == nil {
+			t.Error("Expected error when current is newer than target")
+		}
+	})
+}
@@ -1,159 +1,324 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 // VersionInfoService handles Flutter version information
 type VersionInfoService struct {
-	apiService FlutterAPIServiceInterface
+	apiService            FlutterAPIServiceInterface
+	flutterVersionService *FlutterVersionService
+	advisories            SecurityAdvisoryServiceInterface
+	sources               []string
 }
 
-// NewVersionInfoService creates a new version info service instance
-func NewVersionInfoService(apiService FlutterAPIServiceInterface) *VersionInfoService {
+// cliStatus captures what the installed Flutter CLI reported
+type cliStatus struct {
+	checked   bool
+	installed bool
+	version   string
+	channel   string
+	dart      string
+	engine    string
+	// timeout is set when flutter did not answer in time, which says nothing about whether it is installed
+	timeout time.Duration
+}
+
+// versionCheck is one external check of GetFlutterVersionInfo, run under its own timeout
+type versionCheck struct {
+	name    string
+	timeout time.Duration
+	run     func(ctx context.Context)
+}
+
+// NewVersionInfoService creates a new version info service instance. Sources are
+// consulted in the given order; when none are given the default priority is used.
+func NewVersionInfoService(apiService FlutterAPIServiceInterface, sources ...string) *VersionInfoService {
+	if len(sources) == 0 {
+		sources = config.DefaultVersionSources()
+	}
 	return &VersionInfoService{
-		apiService: apiService,
+		apiService:            apiService,
+		flutterVersionService: NewFlutterVersionService(),
+		sources:               sources,
 	}
 }
 
-// GetFlutterVersionInfo gets comprehensive Flutter version information
-func (v *VersionInfoService) GetFlutterVersionInfo() (*models.FlutterVersionInfo, error) {
-	var latestVersion string
-	var debugInfo []string
-	var flutterInstalled bool
-	var installedVersion string
-	var channel string
+// SetStatsRecorder reports the latency of local Flutter CLI calls to recorder
+func (v *VersionInfoService) SetStatsRecorder(recorder StatsRecorder) {
+	v.flutterVersionService.SetStatsRecorder(recorder)
+}
 
-	// First, try to get version from installed Flutter CLI (most reliable)
-	flutterVersionService := NewFlutterVersionService()
-	flutterInstalled = flutterVersionService.IsFlutterInstalled()
+// SetExecTimeout kills flutter invocations of the cli source that run longer than timeout
+func (v *VersionInfoService) SetExecTimeout(timeout time.Duration) {
+	v.flutterVersionService.SetExecTimeout(timeout)
+}
 
-	if flutterInstalled {
-		var err error
-		installedVersion, err = flutterVersionService.GetInstalledFlutterVersion()
-		if err == nil {
-			latestVersion = installedVersion
-			debugInfo = append(debugInfo, fmt.Sprintf("Using installed Flutter version: %s", installedVersion))
-
-			// Get channel info
-			channel, _ = flutterVersionService.GetFlutterChannel()
-			debugInfo = append(debugInfo, fmt.Sprintf("Flutter channel: %s", channel))
-		} else {
-			debugInfo = append(debugInfo, fmt.Sprintf("Error getting installed Flutter version: %v", err))
+// SetFlutterSDK makes the cli source run the SDK at root instead of the flutter on PATH
+func (v *VersionInfoService) SetFlutterSDK(root string) {
+	v.flutterVersionService.SetFlutterSDK(root)
+}
+
+// SetAdvisoryService checks the version the Flutter CLI reports against the published security
+// advisories of advisories
+func (v *VersionInfoService) SetAdvisoryService(advisories SecurityAdvisoryServiceInterface) {
+	v.advisories = advisories
+}
+
+// ParseVersionSources parses a comma separated source priority such as "official,github"
+func ParseVersionSources(value string) ([]string, error) {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		source := strings.ToLower(strings.TrimSpace(part))
+		if source == "" {
+			continue
+		}
+		switch source {
+		case config.VERSION_SOURCE_CLI, config.VERSION_SOURCE_OFFICIAL, config.VERSION_SOURCE_GITHUB:
+		default:
+			return nil, fmt.Errorf("unknown version source %q (expected %s, %s or %s)", source,
+				config.VERSION_SOURCE_CLI, config.VERSION_SOURCE_OFFICIAL, config.VERSION_SOURCE_GITHUB)
+		}
+		if !seen[source] {
+			seen[source] = true
+			sources = append(sources, source)
 		}
-	} else {
-		debugInfo = append(debugInfo, "Flutter CLI not installed, falling back to GitHub API")
 	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("at least one version source is required")
+	}
+	return sources, nil
+}
 
-	// If Flutter not installed or failed, fall back to official releases API, then GitHub API
-	if latestVersion == "" {
-		// Try official releases API first
-		officialReleases, err := v.apiService.FetchOfficialReleases()
-		if err == nil && len(officialReleases.Releases) > 0 {
-			debugInfo = append(debugInfo, "Using official Flutter releases API")
-			
-			// Find latest stable release
-			for _, release := range officialReleases.Releases {
-				if release.Channel == "stable" {
-					latestVersion = release.Version
-					debugInfo = append(debugInfo, fmt.Sprintf("Official API: Found stable version: %s", release.Version))
-					break
-				}
-			}
-		} else {
-			debugInfo = append(debugInfo, fmt.Sprintf("Official API failed: %v, falling back to GitHub API", err))
-		}
+// GetFlutterVersionInfo gets comprehensive Flutter version information
+func (v *VersionInfoService) GetFlutterVersionInfo(ctx context.Context) (*models.FlutterVersionInfo, error) {
+	var latestVersion string
+	var source string
+	var debugInfo []string
+	var failures []string
+	var cli cliStatus
 
-		// If official API failed or no stable found, fall back to GitHub API
-		if latestVersion == "" {
-			releases, err := v.apiService.FetchReleases()
-			if err != nil {
-				return nil, fmt.Errorf("failed to fetch Flutter releases from GitHub: %v", err)
-			}
+	debugInfo = append(debugInfo, fmt.Sprintf("Source priority: %s", strings.Join(v.sources, " → ")))
 
-			if len(releases) == 0 {
-				return nil, fmt.Errorf("no Flutter releases found")
-			}
+	// Whether FVM is installed does not depend on the version, so find out while the sources are consulted
+	var fvmInstalled bool
+	fvmTimeouts := make(chan []string, 1)
+	go func() {
+		fvmTimeouts <- runVersionChecks(ctx, versionCheck{name: "FVM installation", timeout: config.FVM_CHECK_TIMEOUT, run: func(ctx context.Context) {
+			fvmInstalled = v.apiService.CheckFVMInstalled(ctx)
+		}})
+	}()
 
-			debugInfo = append(debugInfo, "Falling back to GitHub API releases")
-
-			for i, release := range releases {
-				if i < 5 { // Collect debug info for first 5 releases
-					debugInfo = append(debugInfo, fmt.Sprintf("GitHub Release %d: %s (prerelease: %v)", i, release.TagName, release.Prerelease))
-				}
-
-				tagLower := strings.ToLower(release.TagName)
-				version := v.apiService.ParseVersionFromRelease(release)
-
-				// More strict stable release detection
-				isStable := !release.Prerelease &&
-					!strings.Contains(tagLower, "beta") &&
-					!strings.Contains(tagLower, "dev") &&
-					!strings.Contains(tagLower, "pre") &&
-					!strings.Contains(tagLower, "rc") &&
-					!strings.Contains(tagLower, "alpha") &&
-					!strings.Contains(tagLower, "hotfix") &&
-					!strings.Contains(version, "-") &&
-					// Ensure it's a pure semantic version (no suffixes)
-					regexp.MustCompile(`^\d+\.\d+\.\d+$`).MatchString(version) &&
-					// Additional check: tag should not contain pre-release indicators
-					!strings.Contains(release.TagName, "-") &&
-					!strings.Contains(release.TagName, ".pre") &&
-					!strings.Contains(release.TagName, ".rc") &&
-					!strings.Contains(release.TagName, ".beta") &&
-					!strings.Contains(release.TagName, ".alpha")
-
-				if isStable {
-					latestVersion = version
-					debugInfo = append(debugInfo, fmt.Sprintf("GitHub: Found stable version: %s", version))
-					break
-				}
-			}
+	for _, candidate := range v.sources {
+		var version string
+		var err error
 
-			// If no stable found, use the most recent release
-			if latestVersion == "" {
-				latestVersion = v.apiService.ParseVersionFromRelease(releases[0])
-				debugInfo = append(debugInfo, fmt.Sprintf("GitHub: No stable found, using latest: %s", latestVersion))
-			}
+		switch candidate {
+		case config.VERSION_SOURCE_CLI:
+			cli, err = v.versionFromCLI(ctx, &debugInfo)
+			version = cli.version
+		case config.VERSION_SOURCE_OFFICIAL:
+			version, err = v.versionFromOfficialAPI(ctx, &debugInfo)
+		case config.VERSION_SOURCE_GITHUB:
+			version, err = v.versionFromGitHub(ctx, &debugInfo)
+		default:
+			err = fmt.Errorf("unknown version source %q", candidate)
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", candidate, err))
+			continue
 		}
+		latestVersion = version
+		source = candidate
+		break
+	}
+
+	if latestVersion == "" {
+		return nil, fmt.Errorf("failed to determine Flutter version (%s)", strings.Join(failures, "; "))
 	}
 
+	debugInfo = append(debugInfo, fmt.Sprintf("Version answered by: %s", source))
+
 	info := &models.FlutterVersionInfo{
 		LatestVersion: latestVersion,
-		FVMInstalled:  v.apiService.CheckFVMInstalled(),
+		Source:        source,
 	}
+	fvmTimedOut := <-fvmTimeouts
+	debugInfo = append(debugInfo, fvmTimedOut...)
+	info.FVMInstalled = fvmInstalled
 
+	// The checks of the version found are independent of each other
+	checks := []versionCheck{
+		{name: "Docker image " + config.DOCKER_IMAGE_INSTRUMENTISTO, timeout: config.DOCKER_CHECK_TIMEOUT, run: func(ctx context.Context) {
+			info.DockerImages.Instrumentisto = v.apiService.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_INSTRUMENTISTO, latestVersion)
+		}},
+		{name: "Docker image " + config.DOCKER_IMAGE_CIRRUSLABS, timeout: config.DOCKER_CHECK_TIMEOUT, run: func(ctx context.Context) {
+			info.DockerImages.CirrusLabs = v.apiService.CheckDockerImageExists(ctx, config.DOCKER_IMAGE_CIRRUSLABS, latestVersion)
+		}},
+	}
 	if info.FVMInstalled {
-		info.FVMVersionExists = v.apiService.CheckFVMVersionExists(latestVersion)
+		checks = append(checks, versionCheck{name: "FVM version", timeout: config.FVM_CHECK_TIMEOUT, run: func(ctx context.Context) {
+			info.FVMVersionExists = v.apiService.CheckFVMVersionExists(ctx, latestVersion)
+		}})
+	}
+	var advisoryErr error
+	if v.advisories != nil && cli.version != "" {
+		checks = append(checks, versionCheck{name: "Security advisories", timeout: config.ADVISORY_CHECK_TIMEOUT, run: func(ctx context.Context) {
+			info.Advisories, advisoryErr = v.advisories.SecurityAdvisories(ctx, cli.version, cli.dart)
+		}})
+	}
+	debugInfo = append(debugInfo, runVersionChecks(ctx, checks...)...)
+	if advisoryErr != nil {
+		debugInfo = append(debugInfo, fmt.Sprintf("Security advisories: %v", advisoryErr))
 	}
-
-	// Check Docker images availability
-	info.DockerImages.Instrumentisto = v.apiService.CheckDockerImageExists("instrumentisto/flutter", latestVersion)
-	info.DockerImages.CirrusLabs = v.apiService.CheckDockerImageExists("ghcr.io/cirruslabs/flutter", latestVersion)
 
 	// Build details string
-	details := v.buildDetailsString(info, flutterInstalled, installedVersion, channel, debugInfo)
+	details := v.buildDetailsString(info, cli, len(fvmTimedOut) > 0, debugInfo)
 	info.Details = details
 
 	return info, nil
 }
 
+// runVersionChecks runs checks concurrently and waits for all of them. Each writes its own result,
+// and one that runs out of time counts as failed; the debug lines it returns name those.
+func runVersionChecks(ctx context.Context, checks ...versionCheck) []string {
+	timedOut := make([]bool, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, check.timeout)
+			defer cancel()
+			check.run(checkCtx)
+			timedOut[i] = errors.Is(checkCtx.Err(), context.DeadlineExceeded)
+		}()
+	}
+	wg.Wait()
+
+	var debugInfo []string
+	for i, check := range checks {
+		if timedOut[i] {
+			debugInfo = append(debugInfo, fmt.Sprintf("%s check timed out after %s", check.name, check.timeout))
+		}
+	}
+	return debugInfo
+}
+
+// versionFromCLI reads the version of the locally installed Flutter SDK
+func (v *VersionInfoService) versionFromCLI(ctx context.Context, debugInfo *[]string) (cliStatus, error) {
+	flutterVersionService := v.flutterVersionService
+	status := cliStatus{checked: true}
+	output, err := flutterVersionService.runFlutter(ctx, "--version", "--machine")
+	var timeout *execTimeoutError
+	if errors.As(err, &timeout) {
+		status.timeout = timeout.timeout
+		*debugInfo = append(*debugInfo, fmt.Sprintf("Flutter CLI: %v, trying next source", err))
+		return status, err
+	}
+	status.installed = err == nil
+	if !status.installed {
+		*debugInfo = append(*debugInfo, "Flutter CLI not installed, trying next source")
+		return status, fmt.Errorf("flutter CLI not installed")
+	}
+
+	version, err := parseMachineVersion(output)
+	if err != nil {
+		*debugInfo = append(*debugInfo, fmt.Sprintf("Error getting installed Flutter version: %v", err))
+		return status, err
+	}
+	status.version = version.FrameworkVersion
+	status.channel = version.Channel
+	status.dart = version.DartSDKVersion
+	status.engine = version.EngineRevision
+	*debugInfo = append(*debugInfo, fmt.Sprintf("Using installed Flutter version: %s", status.version))
+	*debugInfo = append(*debugInfo, fmt.Sprintf("Flutter channel: %s", status.channel))
+
+	return status, nil
+}
+
+// versionFromOfficialAPI finds the latest stable release in the official releases API
+func (v *VersionInfoService) versionFromOfficialAPI(ctx context.Context, debugInfo *[]string) (string, error) {
+	officialReleases, err := v.apiService.FetchOfficialReleases(ctx)
+	if err != nil {
+		*debugInfo = append(*debugInfo, fmt.Sprintf("Official API failed: %v", err))
+		return "", err
+	}
+
+	*debugInfo = append(*debugInfo, "Using official Flutter releases API")
+	if release, ok := latestOfficialStable(officialReleases); ok {
+		*debugInfo = append(*debugInfo, fmt.Sprintf("Official API: Found stable version: %s", release.Version))
+		return release.Version, nil
+	}
+
+	*debugInfo = append(*debugInfo, "Official API: No stable release listed")
+	return "", fmt.Errorf("no stable release in official releases API")
+}
+
+// versionFromGitHub finds the latest stable release tag, hotfixes included, via the GitHub API
+func (v *VersionInfoService) versionFromGitHub(ctx context.Context, debugInfo *[]string) (string, error) {
+	releases, err := v.apiService.FetchReleases(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch Flutter releases from GitHub: %v", err)
+	}
+
+	if len(releases) == 0 {
+		return "", fmt.Errorf("no Flutter releases found")
+	}
+
+	*debugInfo = append(*debugInfo, "Using GitHub API releases")
+
+	// Collect debug info for the first 5 releases
+	for i, release := range releases[:min(len(releases), 5)] {
+		*debugInfo = append(*debugInfo, fmt.Sprintf("GitHub Release %d: %s (prerelease: %v)", i, release.TagName, release.Prerelease))
+	}
+	if version, ok := latestGitHubStable(releases); ok {
+		*debugInfo = append(*debugInfo, fmt.Sprintf("GitHub: Found stable version: %s", version))
+		return version, nil
+	}
+
+	// If no stable found, use the most recent release
+	latestVersion := v.apiService.ParseVersionFromRelease(releases[0])
+	*debugInfo = append(*debugInfo, fmt.Sprintf("GitHub: No stable found, using latest: %s", latestVersion))
+	return latestVersion, nil
+}
+
 // buildDetailsString creates the formatted details string
-func (v *VersionInfoService) buildDetailsString(info *models.FlutterVersionInfo, flutterInstalled bool, installedVersion, channel string, debugInfo []string) string {
-	details := fmt.Sprintf("Latest Flutter Version: %s (Checked: %s)\n\n", info.LatestVersion, time.Now().Format("2006-01-02 15:04:05"))
+func (v *VersionInfoService) buildDetailsString(info *models.FlutterVersionInfo, cli cliStatus, fvmTimedOut bool, debugInfo []string) string {
+	details := fmt.Sprintf("Latest Flutter Version: %s (Checked: %s)\n", info.LatestVersion, time.Now().Format("2006-01-02 15:04:05"))
+	details += fmt.Sprintf("Version Source: %s\n\n", info.Source)
 
 	// Flutter CLI status
-	if flutterInstalled {
+	if !cli.checked {
+		details += "Flutter CLI: ⏭️ Not checked (disabled by version source priority)\n"
+	} else if cli.timeout > 0 {
+		details += fmt.Sprintf("Flutter CLI: ⏱️ Timed out (flutter --version --machine did not finish within %s)\n", cli.timeout)
+		details += "  - Check the flutter launcher for hangs, or raise --exec-timeout\n"
+	} else if cli.installed {
 		details += "Flutter CLI: ✅ Installed\n"
-		if installedVersion != "" {
-			details += fmt.Sprintf("  - Installed Version: %s\n", installedVersion)
-			if channel != "" {
-				details += fmt.Sprintf("  - Channel: %s\n", channel)
+		if launcher, selectedBy := v.flutterVersionService.launcher(); selectedBy != "" {
+			details += fmt.Sprintf("  - Launcher: %s (%s)\n", launcher, selectedBy)
+		}
+		if cli.version != "" {
+			details += fmt.Sprintf("  - Installed Version: %s\n", cli.version)
+			if cli.channel != "" {
+				details += fmt.Sprintf("  - Channel: %s\n", cli.channel)
+			}
+			if cli.dart != "" {
+				details += fmt.Sprintf("  - Dart SDK: %s\n", cli.dart)
+			}
+			if cli.engine != "" {
+				details += fmt.Sprintf("  - Engine Revision: %s\n", cli.engine)
 			}
 		}
 	} else {
@@ -171,11 +336,15 @@ func (v *VersionInfoService) buildDetailsString(info *models.FlutterVersionInfo,
 			details += fmt.Sprintf("  - Version %s: ❌ Not installed locally\n", info.LatestVersion)
 			details += fmt.Sprintf("  - Install with: fvm install %s\n", info.LatestVersion)
 		}
+	} else if fvmTimedOut {
+		details += fmt.Sprintf("FVM Status: ⏱️ Timed out (fvm --version did not finish within %s)\n", config.FVM_CHECK_TIMEOUT)
 	} else {
 		details += "FVM Status: ❌ Not installed\n"
 		details += "  - Install FVM: https://fvm.app/docs/getting_started/installation\n"
 	}
 
+	details += v.advisoryDetails(info, cli)
+
 	details += "\nDocker Images:\n"
 	if info.DockerImages.Instrumentisto {
 		details += fmt.Sprintf("  - instrumentisto/flutter:%s ✅ Available\n", info.LatestVersion)
@@ -205,3 +374,73 @@ func (v *VersionInfoService) buildDetailsString(info *models.FlutterVersionInfo,
 
 	return details
 }
+
+// advisoryDetails describes the security advisories that affect the installed SDK and the releases
+// that fix them
+func (v *VersionInfoService) advisoryDetails(info *models.FlutterVersionInfo, cli cliStatus) string {
+	if v.advisories == nil {
+		return ""
+	}
+	details := "\nSecurity Advisories:\n"
+	check := info.Advisories
+	switch {
+	case cli.version == "":
+		return details + "  - ⏭️ Not checked (the installed Flutter version is unknown)\n"
+	case check == nil:
+		return details + "  - ❓ Could not be checked (see the debug info)\n"
+	}
+
+	sdk := "Flutter " + check.FlutterVersion
+	if check.DartVersion != "" {
+		sdk += fmt.Sprintf(" (Dart %s)", check.DartVersion)
+	}
+	if len(check.Advisories) == 0 {
+		details += fmt.Sprintf("  - %s: ✅ No published advisories\n", sdk)
+	} else {
+		noun := "advisories"
+		if len(check.Advisories) == 1 {
+			noun = "advisory"
+		}
+		details += fmt.Sprintf("  - %s: ⚠️ %d published %s\n", sdk, len(check.Advisories), noun)
+	}
+	upgradeTo, allFixed := "", true
+	for _, advisory := range check.Advisories {
+		id := advisory.ID
+		if advisory.CVE != "" && advisory.CVE != id {
+			id += " / " + advisory.CVE
+		}
+		severity := ""
+		if advisory.Severity != "" {
+			severity = fmt.Sprintf("[%s] ", advisory.Severity)
+		}
+		details += fmt.Sprintf("    - %s%s: %s\n", severity, id, advisory.Summary)
+		fixed := fmt.Sprintf("%s %s", advisory.Product, advisory.Affected)
+		if advisory.Fixed != "" {
+			fixed += fmt.Sprintf(", fixed in %s %s", advisory.Product, advisory.Fixed)
+		}
+		if advisory.FixedFlutter != "" && advisory.Product != config.ADVISORY_PRODUCT_FLUTTER {
+			fixed += fmt.Sprintf(" (Flutter %s)", advisory.FixedFlutter)
+		}
+		details += fmt.Sprintf("      Affects %s: %s\n", fixed, advisory.URL)
+		if advisory.FixedFlutter == "" {
+			allFixed = false
+		} else if newer, _ := CompareVersions(advisory.FixedFlutter, upgradeTo); upgradeTo == "" || newer > 0 {
+			upgradeTo = advisory.FixedFlutter
+		}
+	}
+	if len(check.Advisories) > 0 {
+		fixes := "all of them"
+		if len(check.Advisories) == 1 {
+			fixes = "it"
+		}
+		if allFixed {
+			details += fmt.Sprintf("  - ⬆️ Upgrade recommended: Flutter %s or newer fixes %s (flutter upgrade)\n", upgradeTo, fixes)
+		} else {
+			details += "  - ⬆️ Upgrade recommended: move to the latest stable release (flutter upgrade)\n"
+		}
+	}
+	for _, upstreamError := range check.UpstreamErrors {
+		details += fmt.Sprintf("  - Note: %s\n", upstreamError)
+	}
+	return details
+}
@@ -1,10 +1,18 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
 )
 
 // MockFlutterAPIService for testing
@@ -13,30 +21,42 @@ type MockFlutterAPIService struct {
 	fvmInstalled     bool
 	fvmVersionExists bool
 	dockerResults    map[string]bool
+	officialReleases *models.FlutterReleasesResponse
+	websiteFiles     map[string]string
+	latestStable     string
+	sourceDeps       []models.Deprecation
 }
 
-func (m *MockFlutterAPIService) FetchReleases() ([]models.FlutterRelease, error) {
+func (m *MockFlutterAPIService) FetchReleases(ctx context.Context) ([]models.FlutterRelease, error) {
 	return m.releases, nil
 }
 
+func (m *MockFlutterAPIService) FetchOfficialReleases(ctx context.Context) (*models.FlutterReleasesResponse, error) {
+	if m.officialReleases == nil {
+		// Force the GitHub fallback path so the release fixtures drive the result
+		return nil, fmt.Errorf("official releases API unavailable")
+	}
+	return m.officialReleases, nil
+}
+
 func (m *MockFlutterAPIService) ParseVersionFromRelease(release models.FlutterRelease) string {
 	return strings.TrimPrefix(release.TagName, "v")
 }
 
-func (m *MockFlutterAPIService) GetLatestStableVersion() (string, error) {
+func (m *MockFlutterAPIService) GetLatestStableVersion(ctx context.Context) (string, error) {
 	// Not used in VersionInfoService, so can be empty
-	return "", nil
+	return m.latestStable, nil
 }
 
-func (m *MockFlutterAPIService) CheckFVMInstalled() bool {
+func (m *MockFlutterAPIService) CheckFVMInstalled(ctx context.Context) bool {
 	return m.fvmInstalled
 }
 
-func (m *MockFlutterAPIService) CheckFVMVersionExists(version string) bool {
+func (m *MockFlutterAPIService) CheckFVMVersionExists(ctx context.Context, version string) bool {
 	return m.fvmVersionExists
 }
 
-func (m *MockFlutterAPIService) CheckDockerImageExists(image string, tag string) bool {
+func (m *MockFlutterAPIService) CheckDockerImageExists(ctx context.Context, image string, tag string) bool {
 	key := image + ":" + tag
 	if result, exists := m.dockerResults[key]; exists {
 		return result
@@ -44,6 +64,33 @@ func (m *MockFlutterAPIService) CheckDockerImageExists(image string, tag string)
 	return false
 }
 
+func (m *MockFlutterAPIService) FetchWebsiteFile(ctx context.Context, path string) (string, error) {
+	if content, exists := m.websiteFiles[path]; exists {
+		return content, nil
+	}
+	return "", fmt.Errorf("flutter/website returned status 404 for %s", path)
+}
+
+func (m *MockFlutterAPIService) FetchFlutterSourceDeprecations(ctx context.Context) ([]models.Deprecation, error) {
+	return m.sourceDeps, nil
+}
+
+func (m *MockFlutterAPIService) FetchFlutterSourceDeprecationsWithProgress(ctx context.Context, progressCallback func(string)) ([]models.Deprecation, error) {
+	return m.sourceDeps, nil
+}
+
+func (m *MockFlutterAPIService) FetchSourceDeprecationsIn(ctx context.Context, libraries []string) ([]models.Deprecation, error) {
+	var deprecations []models.Deprecation
+	for _, dep := range m.sourceDeps {
+		for _, library := range libraries {
+			if dep.Category == library {
+				deprecations = append(deprecations, dep)
+			}
+		}
+	}
+	return deprecations, nil
+}
+
 func TestVersionInfoService(t *testing.T) {
 	t.Run("GetFlutterVersionInfo with stable version", func(t *testing.T) {
 		mockAPI := &MockFlutterAPIService{
@@ -55,13 +102,13 @@ func TestVersionInfoService(t *testing.T) {
 			fvmInstalled:     true,
 			fvmVersionExists: true,
 			dockerResults: map[string]bool{
-				"instrumentisto/flutter:3.32.0": true,
-				"cirrusci/flutter:3.32.0":       false,
+				"instrumentisto/flutter:3.32.0":     true,
+				"ghcr.io/cirruslabs/flutter:3.32.0": false,
 			},
 		}
 
 		versionService := NewVersionInfoService(mockAPI)
-		info, err := versionService.GetFlutterVersionInfo()
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -83,8 +130,8 @@ func TestVersionInfoService(t *testing.T) {
 			t.Error("Expected instrumentisto docker image to be available")
 		}
 
-		if info.DockerImages.Cirrusci {
-			t.Error("Expected cirrusci docker image to not be available")
+		if info.DockerImages.CirrusLabs {
+			t.Error("Expected cirruslabs docker image to not be available")
 		}
 
 		// Check that details contain expected information
@@ -109,7 +156,7 @@ func TestVersionInfoService(t *testing.T) {
 		}
 
 		versionService := NewVersionInfoService(mockAPI)
-		info, err := versionService.GetFlutterVersionInfo()
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -143,15 +190,15 @@ func TestVersionInfoService(t *testing.T) {
 		}
 
 		versionService := NewVersionInfoService(mockAPI)
-		info, err := versionService.GetFlutterVersionInfo()
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
 
-		// Should find 3.32.0 as the latest stable (skipping hotfix and rc versions)
-		if info.LatestVersion != "3.32.0" {
-			t.Errorf("Expected latest version to be 3.32.0, got %s", info.LatestVersion)
+		// Should find the 3.32.0 hotfix as the latest stable (skipping the rc version)
+		if info.LatestVersion != "3.32.0-hotfix.1" {
+			t.Errorf("Expected latest version to be 3.32.0-hotfix.1, got %s", info.LatestVersion)
 		}
 
 		if info.FVMVersionExists {
@@ -172,7 +219,7 @@ func TestVersionInfoService(t *testing.T) {
 		}
 
 		versionService := NewVersionInfoService(mockAPI)
-		_, err := versionService.GetFlutterVersionInfo()
+		_, err := versionService.GetFlutterVersionInfo(context.Background())
 
 		if err == nil {
 			t.Error("Expected error when no releases found")
@@ -182,4 +229,234 @@ func TestVersionInfoService(t *testing.T) {
 			t.Errorf("Expected error message about no releases, got %v", err)
 		}
 	})
+
+	t.Run("GetFlutterVersionInfo honors source priority", func(t *testing.T) {
+		mockAPI := &MockFlutterAPIService{
+			releases: []models.FlutterRelease{
+				{TagName: "3.31.0", Prerelease: false, PublishedAt: "2024-11-15T10:00:00Z"},
+			},
+			officialReleases: &models.FlutterReleasesResponse{
+				Releases: []models.FlutterOfficialRelease{
+					{Channel: "beta", Version: "3.33.0-0.1.pre"},
+					{Channel: "stable", Version: "3.32.0"},
+				},
+			},
+			dockerResults: map[string]bool{},
+		}
+
+		info, err := NewVersionInfoService(mockAPI, "github", "official").GetFlutterVersionInfo(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.LatestVersion != "3.31.0" || info.Source != "github" {
+			t.Errorf("Expected 3.31.0 from github, got %s from %s", info.LatestVersion, info.Source)
+		}
+
+		info, err = NewVersionInfoService(mockAPI, "official").GetFlutterVersionInfo(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.LatestVersion != "3.32.0" || info.Source != "official" {
+			t.Errorf("Expected 3.32.0 from official, got %s from %s", info.LatestVersion, info.Source)
+		}
+		if !strings.Contains(info.Details, "Not checked") {
+			t.Error("Expected details to show the Flutter CLI was not checked")
+		}
+	})
+
+	t.Run("CLI source runs the --flutter-sdk launcher", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the fake launcher is a shell script")
+		}
+		root := t.TempDir()
+		writeFakeSDK(t, root, "3.27.4", "stable")
+		// flutter --version --machine, answering only with the arguments the cli source passes
+		script := "#!/bin/sh\n[ \"$*\" = \"--version --machine\" ] || exit 64\n" +
+			`echo '{"frameworkVersion": "3.27.4", "channel": "stable", "dartSdkVersion": "3.6.2", "engineRevision": "82bd5b7209"}'` + "\n"
+		if err := os.WriteFile(filepath.Join(root, "bin", "flutter"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		versionService := NewVersionInfoService(&MockFlutterAPIService{dockerResults: map[string]bool{}}, "cli")
+		versionService.SetFlutterSDK(root)
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.LatestVersion != "3.27.4" || info.Source != "cli" {
+			t.Errorf("Expected 3.27.4 from the SDK's flutter, got %s from %s", info.LatestVersion, info.Source)
+		}
+		if !strings.Contains(info.Details, "Channel: stable") || !strings.Contains(info.Details, "Dart SDK: 3.6.2") || !strings.Contains(info.Details, "Engine Revision: 82bd5b7209") || !strings.Contains(info.Details, filepath.Join(root, "bin", "flutter")+" (--flutter-sdk)") {
+			t.Errorf("Expected the SDK's channel and launcher in the details, got %s", info.Details)
+		}
+	})
+
+	t.Run("Flags security advisories of the installed SDK", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the fake launcher is a shell script")
+		}
+		root := t.TempDir()
+		writeFakeSDK(t, root, "3.3.2", "stable")
+		script := "#!/bin/sh\n" + `echo '{"frameworkVersion": "3.3.2", "channel": "stable", "dartSdkVersion": "2.18.1"}'` + "\n"
+		if err := os.WriteFile(filepath.Join(root, "bin", "flutter"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		advisories := &staticAdvisories{AdvisoryCheck: models.AdvisoryCheck{Advisories: []models.SecurityAdvisory{
+			{ID: "GHSA-dddd", CVE: "CVE-2022-3095", Product: config.ADVISORY_PRODUCT_DART, Summary: "Uri backslash parsing", Severity: "moderate", URL: "https://github.com/advisories/GHSA-dddd", Affected: "< 2.18.2", Fixed: "2.18.2", FixedFlutter: "3.3.3"},
+		}}}
+		versionService := NewVersionInfoService(&MockFlutterAPIService{dockerResults: map[string]bool{}}, "cli")
+		versionService.SetFlutterSDK(root)
+		versionService.SetAdvisoryService(advisories)
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if advisories.flutter != "3.3.2" || advisories.dart != "2.18.1" || info.Advisories == nil {
+			t.Fatalf("Expected the installed 3.3.2 with Dart 2.18.1 to be checked, got %q and %q", advisories.flutter, advisories.dart)
+		}
+		for _, expected := range []string{
+			"Flutter 3.3.2 (Dart 2.18.1): ⚠️ 1 published advisory",
+			"[moderate] GHSA-dddd / CVE-2022-3095: Uri backslash parsing",
+			"Affects Dart SDK < 2.18.2, fixed in Dart SDK 2.18.2 (Flutter 3.3.3): https://github.com/advisories/GHSA-dddd",
+			"Upgrade recommended: Flutter 3.3.3 or newer fixes it",
+		} {
+			if !strings.Contains(info.Details, expected) {
+				t.Errorf("Expected details to contain %q, got %s", expected, info.Details)
+			}
+		}
+
+		advisories.Advisories = nil
+		info, _ = versionService.GetFlutterVersionInfo(context.Background())
+		if !strings.Contains(info.Details, "Flutter 3.3.2 (Dart 2.18.1): ✅ No published advisories") {
+			t.Errorf("Expected a clean advisory check, got %s", info.Details)
+		}
+
+		offline := NewVersionInfoService(&MockFlutterAPIService{releases: []models.FlutterRelease{{TagName: "3.27.1"}}, dockerResults: map[string]bool{}}, "github")
+		offline.SetAdvisoryService(advisories)
+		info, _ = offline.GetFlutterVersionInfo(context.Background())
+		if !strings.Contains(info.Details, "Not checked (the installed Flutter version is unknown)") {
+			t.Errorf("Expected the advisories to need the installed version, got %s", info.Details)
+		}
+	})
+
+	t.Run("CLI source runs the SDK FVM links to without flutter on PATH", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the fake launcher is a shell script")
+		}
+		home := t.TempDir()
+		sdk := filepath.Join(home, "fvm", "versions", "3.24.5")
+		writeFakeSDK(t, sdk, "3.24.5", "stable")
+		script := "#!/bin/sh\n" + `echo '{"frameworkVersion": "3.24.5", "channel": "stable"}'` + "\n"
+		if err := os.WriteFile(filepath.Join(sdk, "bin", "flutter"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+		project := t.TempDir()
+		os.MkdirAll(filepath.Join(project, ".fvm"), 0755)
+		if err := os.Symlink(sdk, filepath.Join(project, ".fvm", "flutter_sdk")); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+
+		versionService := NewVersionInfoService(&MockFlutterAPIService{dockerResults: map[string]bool{}}, "cli")
+		versionService.flutterVersionService.sdks = &LocalSDKService{home: home}
+		versionService.flutterVersionService.projectDir = project
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.LatestVersion != "3.24.5" || !strings.Contains(info.Details, "(FVM link "+filepath.Join(project, ".fvm", "flutter_sdk")+")") {
+			t.Errorf("Expected 3.24.5 from the FVM linked SDK, got %s with %s", info.LatestVersion, info.Details)
+		}
+	})
+
+	t.Run("CLI source reports a hung launcher as timed out", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the fake launcher is a shell script")
+		}
+		root := t.TempDir()
+		writeFakeSDK(t, root, "3.27.4", "stable")
+		// A wrapper whose child keeps the output pipe open after the wrapper is killed
+		if err := os.WriteFile(filepath.Join(root, "bin", "flutter"), []byte("#!/bin/sh\nsleep 30\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		mockAPI := &MockFlutterAPIService{
+			officialReleases: &models.FlutterReleasesResponse{Releases: []models.FlutterOfficialRelease{{Channel: "stable", Version: "3.32.0"}}},
+			dockerResults:    map[string]bool{},
+		}
+		versionService := NewVersionInfoService(mockAPI, "cli", "official")
+		versionService.SetFlutterSDK(root)
+		versionService.SetExecTimeout(100 * time.Millisecond)
+
+		start := time.Now()
+		info, err := versionService.GetFlutterVersionInfo(context.Background())
+		if err != nil {
+			t.Fatalf("Expected the official source to answer, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("Expected the hung launcher to be killed, took %s", elapsed)
+		}
+		if info.Source != "official" || !strings.Contains(info.Details, "Flutter CLI: ⏱️ Timed out (flutter --version --machine did not finish within 100ms)") {
+			t.Errorf("Expected the timeout to be reported and official to answer, got %s from %s", info.Details, info.Source)
+		}
+	})
+
+	t.Run("Runs the external checks concurrently under their own timeouts", func(t *testing.T) {
+		// Each check waits until all have started, which only happens when they run at the same time
+		var started sync.WaitGroup
+		started.Add(3)
+		concurrent := func(ctx context.Context) {
+			started.Done()
+			done := make(chan struct{})
+			go func() { started.Wait(); close(done) }()
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+		}
+		checks := []versionCheck{
+			{name: "first", timeout: time.Second, run: concurrent},
+			{name: "second", timeout: time.Second, run: concurrent},
+			{name: "third", timeout: time.Second, run: concurrent},
+		}
+		if timedOut := runVersionChecks(context.Background(), checks...); len(timedOut) != 0 {
+			t.Errorf("Expected the checks to run concurrently, got %v", timedOut)
+		}
+
+		hanging := versionCheck{name: "Docker image", timeout: 10 * time.Millisecond, run: func(ctx context.Context) { <-ctx.Done() }}
+		quick := versionCheck{name: "FVM version", timeout: time.Second, run: func(ctx context.Context) {}}
+		if timedOut := runVersionChecks(context.Background(), hanging, quick); strings.Join(timedOut, ",") != "Docker image check timed out after 10ms" {
+			t.Errorf("Expected only the hanging check to time out, got %v", timedOut)
+		}
+	})
+
+	t.Run("ParseVersionSources", func(t *testing.T) {
+		sources, err := ParseVersionSources(" Official, github,official ")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if strings.Join(sources, ",") != "official,github" {
+			t.Errorf("Expected official,github, got %v", sources)
+		}
+
+		if _, err := ParseVersionSources("cli,docker"); err == nil {
+			t.Error("Expected error for unknown source")
+		}
+		if _, err := ParseVersionSources(""); err == nil {
+			t.Error("Expected error for empty priority")
+		}
+	})
+}
+
+// staticAdvisories reports a fixed advisory check and records the versions it was asked about
+type staticAdvisories struct {
+	models.AdvisoryCheck
+	flutter, dart string
+}
+
+func (s *staticAdvisories) SecurityAdvisories(ctx context.Context, flutterVersion string, dartVersion string) (*models.AdvisoryCheck, error) {
+	s.flutter, s.dart = flutterVersion, dartVersion
+	check := s.AdvisoryCheck
+	check.FlutterVersion, check.DartVersion = flutterVersion, dartVersion
+	return &check, nil
 }
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// VersionMatrix reports on which of the target Flutter versions the APIs that code, or the Dart
+// files of the project at projectPath that filter selects, use are deprecated, and on which the
+// APIs they need are not released yet. That tells a package author supporting a range of releases
+// which migrations every target accepts and which ones have to wait until the older targets are
+// dropped. The targets are ordered oldest first.
+func (d *DeprecationService) VersionMatrix(ctx context.Context, code string, projectPath string, filter models.PathFilter, targets []string) (*models.VersionMatrix, error) {
+	versions, err := parseMatrixTargets(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &models.VersionMatrix{}
+	for _, version := range versions {
+		matrix.Targets = append(matrix.Targets, version.String())
+	}
+
+	var requirements *models.MinimumVersionResult
+	first := make(map[string]models.VersionMatrixRow)
+	var found []models.Deprecation
+	collect := func(file string, code string) {
+		lines := make(map[string]int)
+		positions := newDartPositions(code)
+		for _, use := range d.deprecatedUses(code, config.TARGET_FLUTTER) {
+			if _, seen := lines[use.dep.API]; !seen {
+				lines[use.dep.API], _ = positions.lineColumn(use.start)
+			}
+		}
+		for _, dep := range uniqueByAPI(d.CheckCodeForDeprecations(code)) {
+			if _, seen := first[dep.API]; seen {
+				continue
+			}
+			first[dep.API] = models.VersionMatrixRow{File: file, Line: lines[dep.API]}
+			found = append(found, dep)
+		}
+	}
+
+	if projectPath != "" {
+		if requirements, err = NewMinimumVersionService().InferFromProject(ctx, projectPath, filter); err != nil {
+			return nil, err
+		}
+		paths, err := newPathFilter(projectPath, filter)
+		if err != nil {
+			return nil, err
+		}
+		files, err := walkDartFiles(ctx, projectPath, walkOptions{ignore: newProjectIgnore(projectPath), filter: paths})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			collect(relativeSlash(projectPath, file), string(data))
+		}
+		matrix.FilesScanned = len(files)
+	} else {
+		requirements = NewMinimumVersionService().InferFromCode(code)
+		collect("", code)
+	}
+
+	for _, dep := range ByUrgency(found) {
+		row := first[dep.API]
+		row.API, row.Replacement, row.Compatible, row.Version = dep.API, dep.Replacement, dep.Compatible, dep.Version
+		deprecated, dated := parseVersion(dep.Version)
+		for _, target := range versions {
+			switch {
+			case !dated:
+				row.Statuses = append(row.Statuses, config.MATRIX_UNKNOWN)
+			case deprecated.Compare(target) <= 0:
+				row.Statuses = append(row.Statuses, config.MATRIX_DEPRECATED)
+			default:
+				row.Statuses = append(row.Statuses, config.MATRIX_OK)
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+
+	for _, requirement := range requirements.Requirements {
+		introduced, ok := parseVersion(requirement.Version)
+		if !ok {
+			continue
+		}
+		row := models.VersionMatrixRow{API: requirement.API, Replacement: requirement.Alternative, Version: requirement.Version, Introduced: true, File: requirement.File, Line: requirement.Line}
+		if row.File != "" {
+			row.File = filepath.ToSlash(row.File)
+		}
+		for _, target := range versions {
+			if introduced.Compare(target) > 0 {
+				row.Statuses = append(row.Statuses, config.MATRIX_UNAVAILABLE)
+			} else {
+				row.Statuses = append(row.Statuses, config.MATRIX_OK)
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix, nil
+}
+
+// parseMatrixTargets parses the target versions of a matrix, oldest first and without duplicates
+func parseMatrixTargets(targets []string) ([]semanticVersion, error) {
+	var versions []semanticVersion
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		version, ok := parseVersion(target)
+		if !ok {
+			return nil, fmt.Errorf("invalid target version %q", target)
+		}
+		if !seen[version.String()] {
+			seen[version.String()] = true
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no target versions given")
+	}
+	if len(versions) > config.MAX_MATRIX_TARGETS {
+		return nil, fmt.Errorf("%d target versions given, at most %d are compared at once", len(versions), config.MAX_MATRIX_TARGETS)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+	return versions, nil
+}
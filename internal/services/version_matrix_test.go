@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+)
+
+func TestVersionMatrix(t *testing.T) {
+	service := NewDeprecationService(&CacheService{dir: t.TempDir()}, NewFlutterAPIService())
+	ctx := context.Background()
+
+	rows := func(matrix *models.VersionMatrix) []string {
+		var found []string
+		for _, row := range matrix.Rows {
+			found = append(found, fmt.Sprintf("%s %v", row.API, row.Statuses))
+		}
+		return found
+	}
+
+	t.Run("Checks a snippet against every target", func(t *testing.T) {
+		code := "final a = RaisedButton();\nfinal b = Color.red.withOpacity(0.5);\nfinal c = PopScope();\n"
+		matrix, err := service.VersionMatrix(ctx, code, "", models.PathFilter{}, []string{"3.27", "3.10.0", "v3.16.0", "3.27.0"})
+		if err != nil {
+			t.Fatalf("VersionMatrix failed: %v", err)
+		}
+		if expected := []string{"3.10.0", "3.16.0", "3.27.0"}; !reflect.DeepEqual(matrix.Targets, expected) {
+			t.Errorf("Expected the targets oldest first without duplicates, got %v", matrix.Targets)
+		}
+		expected := []string{
+			"RaisedButton [deprecated deprecated deprecated]",
+			"Color.withOpacity [ok ok deprecated]",
+			"PopScope [unavailable ok ok]",
+		}
+		if !reflect.DeepEqual(rows(matrix), expected) {
+			t.Errorf("Expected %v, got %v", expected, rows(matrix))
+		}
+		if !matrix.Rows[2].Introduced || matrix.Rows[0].Introduced {
+			t.Errorf("Expected only PopScope to be an introduced API, got %+v", matrix.Rows)
+		}
+	})
+
+	t.Run("Locates the first uses in a project", func(t *testing.T) {
+		project := t.TempDir()
+		writeFile(t, filepath.Join(project, "pubspec.yaml"), "name: plugin\n")
+		writeFile(t, filepath.Join(project, "lib", "a.dart"), "// buttons\nfinal a = FlatButton();\n")
+		writeFile(t, filepath.Join(project, "lib", "b.dart"), "final b = FlatButton();\nfinal c = Color.red.withValues(alpha: 0.5);\n")
+		writeFile(t, filepath.Join(project, "lib", "b.g.dart"), "final d = RaisedButton();\n")
+
+		matrix, err := service.VersionMatrix(ctx, "", project, models.PathFilter{}, []string{"3.24.0", "3.27.0"})
+		if err != nil {
+			t.Fatalf("VersionMatrix failed: %v", err)
+		}
+		if expected := []string{"FlatButton [deprecated deprecated]", "Color.withValues [unavailable ok]"}; !reflect.DeepEqual(rows(matrix), expected) {
+			t.Errorf("Expected %v, got %v", expected, rows(matrix))
+		}
+		if matrix.FilesScanned != 2 || matrix.Rows[0].File != "lib/a.dart" || matrix.Rows[0].Line != 2 || matrix.Rows[1].File != "lib/b.dart" || matrix.Rows[1].Line != 2 {
+			t.Errorf("Expected the first uses in the two checked files, got %+v", matrix)
+		}
+	})
+
+	t.Run("Validates the targets", func(t *testing.T) {
+		for _, targets := range [][]string{nil, {"latest"}} {
+			if _, err := service.VersionMatrix(ctx, "RaisedButton()", "", models.PathFilter{}, targets); err == nil {
+				t.Errorf("Expected an error for %v", targets)
+			}
+		}
+		many := make([]string, 21)
+		for i := range many {
+			many[i] = fmt.Sprintf("3.%d.0", i)
+		}
+		if _, err := service.VersionMatrix(ctx, "RaisedButton()", "", models.PathFilter{}, many); err == nil {
+			t.Error("Expected an error for too many targets")
+		}
+		if _, err := service.VersionMatrix(ctx, "", filepath.Join(t.TempDir(), "missing"), models.PathFilter{}, []string{"3.27.0"}); err == nil {
+			t.Error("Expected an error for a missing project")
+		}
+	})
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// Flutter releases that changed how web apps are built and bootstrapped
+const (
+	webLoaderConfigFlutter = "3.7.0"
+	webUIWebFlutter        = "3.13.0"
+	webJSInteropFlutter    = "3.19.0"
+	webBootstrapFlutter    = "3.22.0"
+	webRendererFlutter     = "3.29.0"
+)
+
+// webRenderersDocURL is the guide to the web renderers and the build flags selecting them
+const webRenderersDocURL = "https://docs.flutter.dev/platform-integration/web/renderers"
+
+// webInitializationDocURL is the guide to the index.html bootstrap of the current releases
+const webInitializationDocURL = "https://docs.flutter.dev/platform-integration/web/initialization"
+
+// webApproach is the recommended replacement of a web pattern from a Flutter release onwards
+type webApproach struct {
+	from       string
+	suggestion string
+}
+
+// webRule is a deprecated web setup pattern in index.html, a build script or Dart code. A rule
+// with a since version is only reported for projects on that release or later, because before it
+// the pattern was the current approach. The suggestion comes from the newest approach available
+// in the project's release, so older projects are not told to use APIs they cannot have yet.
+type webRule struct {
+	pattern    *regexp.Regexp
+	api        string
+	since      string
+	severity   string
+	docURL     string
+	approaches []webApproach
+}
+
+// webRendererApproaches are the renderer choices of each release, oldest first
+var webRendererApproaches = []webApproach{
+	{suggestion: "Build with --web-renderer canvaskit, the renderer the html one is being retired for"},
+	{from: webBootstrapFlutter, suggestion: "Build with --web-renderer canvaskit, or with --wasm to use skwasm where the browser supports WebAssembly garbage collection"},
+	{from: webRendererFlutter, suggestion: "Remove the flag: CanvasKit is the default renderer and --wasm selects skwasm"},
+}
+
+// webBootstrapApproaches are the index.html bootstraps of each release, oldest first
+var webBootstrapApproaches = []webApproach{
+	{suggestion: "Load flutter.js and call _flutter.loader.loadEntrypoint({ onEntrypointLoaded: async (engineInitializer) => (await engineInitializer.initializeEngine()).runApp() })"},
+	{from: webBootstrapFlutter, suggestion: "Replace the inline bootstrap with <script src=\"flutter_bootstrap.js\" async></script>; customize it in web/flutter_bootstrap.js with {{flutter_js}} {{flutter_build_config}} and _flutter.loader.load()"},
+}
+
+// webRules detect the deprecated web renderer flags, index.html bootstraps and web libraries
+var webRules = []webRule{
+	{
+		pattern:    regexp.MustCompile(`--web-renderer(?:=|["',\s]+)html\b|\brenderer["']?\s*:\s*["']html["']|\bflutterWebRenderer\s*=\s*["']html["']`),
+		api:        "html renderer",
+		severity:   config.SEVERITY_WARNING,
+		docURL:     webRenderersDocURL,
+		approaches: webRendererApproaches,
+	},
+	{
+		pattern:    regexp.MustCompile(`--dart-define(?:=|["',\s]+)FLUTTER_WEB_(?:USE_SKIA|AUTO_DETECT)=`),
+		api:        "FLUTTER_WEB_USE_SKIA",
+		severity:   config.SEVERITY_WARNING,
+		docURL:     webRenderersDocURL,
+		approaches: webRendererApproaches,
+	},
+	{
+		pattern:    regexp.MustCompile(`--web-renderer(?:=|["',\s]+)(?:canvaskit|skwasm|auto)\b`),
+		api:        "--web-renderer",
+		since:      webRendererFlutter,
+		severity:   config.SEVERITY_ERROR,
+		docURL:     webRenderersDocURL,
+		approaches: webRendererApproaches,
+	},
+	{
+		pattern:  regexp.MustCompile(`<script[^>]+src=["']main\.dart\.js["']`),
+		api:      "main.dart.js script tag",
+		severity: config.SEVERITY_WARNING,
+		docURL:   webInitializationDocURL,
+		approaches: []webApproach{
+			{suggestion: "Load the app through flutter.js, which registers the service worker and picks the renderer: " + webBootstrapApproaches[0].suggestion},
+			webBootstrapApproaches[1],
+		},
+	},
+	{
+		pattern:  regexp.MustCompile(`\bwindow\.flutterConfiguration\s*=`),
+		api:      "window.flutterConfiguration",
+		since:    webLoaderConfigFlutter,
+		severity: config.SEVERITY_WARNING,
+		docURL:   webInitializationDocURL,
+		approaches: []webApproach{
+			{from: webLoaderConfigFlutter, suggestion: "Pass the configuration to engineInitializer.initializeEngine({ ... }) instead"},
+			{from: webBootstrapFlutter, suggestion: "Pass the configuration to _flutter.loader.load({ config: { ... } }) in web/flutter_bootstrap.js instead"},
+		},
+	},
+	{
+		pattern:    regexp.MustCompile(`\b_flutter\.loader\.loadEntrypoint\s*\(`),
+		api:        "_flutter.loader.loadEntrypoint",
+		since:      webBootstrapFlutter,
+		severity:   config.SEVERITY_WARNING,
+		docURL:     webInitializationDocURL,
+		approaches: webBootstrapApproaches,
+	},
+	{
+		pattern:  regexp.MustCompile(`\bserviceWorkerVersion\s*=`),
+		api:      "serviceWorkerVersion",
+		since:    webBootstrapFlutter,
+		severity: config.SEVERITY_WARNING,
+		docURL:   webInitializationDocURL,
+		approaches: []webApproach{
+			{from: webBootstrapFlutter, suggestion: "Remove the variable: flutter build web fills in the service worker version through the {{flutter_service_worker_version}} token of flutter_bootstrap.js"},
+		},
+	},
+	{
+		pattern:  regexp.MustCompile(`navigator\.serviceWorker\.register\(\s*['"]flutter_service_worker\.js`),
+		api:      "flutter_service_worker.js registration",
+		severity: config.SEVERITY_INFO,
+		docURL:   webInitializationDocURL,
+		approaches: []webApproach{
+			{suggestion: "Let flutter.js register the service worker: pass serviceWorker: { serviceWorkerVersion } to _flutter.loader.loadEntrypoint"},
+			{from: webBootstrapFlutter, suggestion: "Let flutter_bootstrap.js register the service worker; remove the manual registration"},
+		},
+	},
+	{
+		pattern:  regexp.MustCompile(`import\s+['"](?:dart:html|dart:js|dart:js_util|package:js/js\.dart)['"]`),
+		api:      "dart:html",
+		severity: config.SEVERITY_WARNING,
+		docURL:   "https://dart.dev/interop/js-interop/package-web",
+		approaches: []webApproach{
+			{suggestion: "Keep dart:html for now: package:web and dart:js_interop need Flutter " + webJSInteropFlutter + " (Dart 3.3) or later"},
+			{from: webJSInteropFlutter, suggestion: "Migrate to package:web 0.5 and dart:js_interop (package:web 1.0 needs Flutter " + webBootstrapFlutter + ")"},
+			{from: webBootstrapFlutter, suggestion: "Migrate to package:web ^1.0 and dart:js_interop; --wasm builds reject dart:html and dart:js"},
+		},
+	},
+	{
+		pattern:  regexp.MustCompile(`\bui\.platformViewRegistry\b`),
+		api:      "dart:ui platformViewRegistry",
+		since:    webUIWebFlutter,
+		severity: config.SEVERITY_WARNING,
+		docURL:   "https://api.flutter.dev/flutter/dart-ui_web/platformViewRegistry.html",
+		approaches: []webApproach{
+			{from: webUIWebFlutter, suggestion: "import 'dart:ui_web' as ui_web; and call ui_web.platformViewRegistry.registerViewFactory"},
+		},
+	},
+}
+
+// webScriptPatterns are the project files besides Dart sources where web builds are configured
+var webScriptPatterns = []string{
+	"web/*.html",
+	"web/*.js",
+	".github/workflows/*.yml",
+	".github/workflows/*.yaml",
+	".gitlab-ci.yml",
+	".vscode/launch.json",
+	"Makefile",
+	"Dockerfile",
+	"package.json",
+	"*.sh",
+	"scripts/*.sh",
+}
+
+// WebCheckService reports deprecated web renderer flags, index.html bootstraps and web libraries
+type WebCheckService struct {
+	apiService FlutterAPIServiceInterface
+}
+
+// NewWebCheckService creates a new web check service instance
+func NewWebCheckService(apiService FlutterAPIServiceInterface) *WebCheckService {
+	return &WebCheckService{apiService: apiService}
+}
+
+// CheckPath checks a single file, or the web directory, build scripts and Dart sources of a
+// project. Suggestions are for version, or when empty the project's pinned Flutter version.
+func (w *WebCheckService) CheckPath(ctx context.Context, path string, version string) (*models.WebCheckReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	root := filepath.Dir(path)
+	if info.IsDir() {
+		root = path
+		if files, err = webProjectFiles(ctx, path); err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no web directory, build scripts or Dart files found in %s", path)
+		}
+	}
+
+	report, err := w.newReport(ctx, version, root)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		relative, err := filepath.Rel(root, file)
+		if err != nil {
+			relative = file
+		}
+		report.Findings = append(report.Findings, checkWebContent(relative, string(data), report.FlutterVersion)...)
+	}
+	report.FilesScanned = len(files)
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		if report.Findings[i].File != report.Findings[j].File {
+			return report.Findings[i].File < report.Findings[j].File
+		}
+		return report.Findings[i].Line < report.Findings[j].Line
+	})
+	return report, nil
+}
+
+// CheckContent checks the contents of a single file such as an index.html or a build script
+func (w *WebCheckService) CheckContent(ctx context.Context, content string, version string) (*models.WebCheckReport, error) {
+	report, err := w.newReport(ctx, version, "")
+	if err != nil {
+		return nil, err
+	}
+	report.FilesScanned = 1
+	report.Findings = checkWebContent("", content, report.FlutterVersion)
+	return report, nil
+}
+
+// newReport resolves the Flutter version the suggestions are for: the argument, the project's
+// pin and finally the latest stable release. The version stays empty when none is known, and
+// every rule is then reported with the newest approach.
+func (w *WebCheckService) newReport(ctx context.Context, version string, projectPath string) (*models.WebCheckReport, error) {
+	if version = strings.TrimPrefix(strings.TrimSpace(version), "v"); version != "" {
+		if _, ok := parseVersion(version); !ok {
+			return nil, fmt.Errorf("invalid Flutter version %q", version)
+		}
+		return &models.WebCheckReport{FlutterVersion: version, VersionSource: "argument"}, nil
+	}
+
+	if projectPath != "" {
+		if pin := readProjectPin(projectPath); pin.version != "" {
+			return &models.WebCheckReport{FlutterVersion: pin.version, VersionSource: pin.file}, nil
+		}
+	}
+
+	report := &models.WebCheckReport{}
+	latest, err := w.apiService.GetLatestStableVersion(ctx)
+	if err == nil && latest == "" {
+		err = fmt.Errorf("no stable release found")
+	}
+	if err != nil {
+		report.VersionError = err.Error()
+		return report, nil
+	}
+	report.FlutterVersion = latest
+	report.VersionSource = "latest stable release"
+	return report, nil
+}
+
+// checkWebContent applies the web rules to one file for a Flutter version, which may be empty
+func checkWebContent(file string, content string, version string) []models.WebFinding {
+	var findings []models.WebFinding
+	for _, rule := range webRules {
+		if rule.since != "" && version != "" {
+			if cmp, ok := CompareVersions(version, rule.since); ok && cmp < 0 {
+				continue
+			}
+		}
+		for _, match := range rule.pattern.FindAllStringIndex(content, -1) {
+			findings = append(findings, models.WebFinding{
+				File:       file,
+				Line:       lineAt(content, match[0]),
+				API:        rule.api,
+				Match:      strings.TrimSpace(content[match[0]:match[1]]),
+				Severity:   rule.severity,
+				Suggestion: webSuggestion(rule.approaches, version),
+				DocURL:     rule.docURL,
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// webSuggestion picks the newest approach available in a Flutter version, or the newest of all
+// when the version is unknown
+func webSuggestion(approaches []webApproach, version string) string {
+	suggestion := ""
+	for _, approach := range approaches {
+		if approach.from != "" && version != "" {
+			if cmp, ok := CompareVersions(version, approach.from); ok && cmp < 0 {
+				break
+			}
+		}
+		suggestion = approach.suggestion
+	}
+	return suggestion
+}
+
+// webProjectFiles lists the web build configuration and the Dart sources of a project
+func webProjectFiles(ctx context.Context, projectPath string) ([]string, error) {
+	var files []string
+	for _, pattern := range webScriptPatterns {
+		matches, _ := filepath.Glob(filepath.Join(projectPath, pattern))
+		files = append(files, matches...)
+	}
+
+	sources, err := dartFiles(ctx, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, sources...), nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+const legacyIndexHTML = `<!DOCTYPE html>
+<html>
+<head>
+  <script>
+    var serviceWorkerVersion = null;
+  </script>
+  <script src="flutter.js" defer></script>
+</head>
+<body>
+  <script>
+    window.addEventListener('load', function(ev) {
+      _flutter.loader.loadEntrypoint({
+        serviceWorker: { serviceWorkerVersion: serviceWorkerVersion },
+        onEntrypointLoaded: async function(engineInitializer) {
+          let appRunner = await engineInitializer.initializeEngine({ renderer: "html" });
+          await appRunner.runApp();
+        }
+      });
+    });
+  </script>
+</body>
+</html>
+`
+
+func TestWebCheckService(t *testing.T) {
+	service := NewWebCheckService(&MockFlutterAPIService{latestStable: "3.29.2"})
+	ctx := context.Background()
+
+	t.Run("Legacy index.html on the latest release", func(t *testing.T) {
+		report, err := service.CheckContent(ctx, legacyIndexHTML, "")
+		if err != nil {
+			t.Fatalf("CheckContent failed: %v", err)
+		}
+		if report.FlutterVersion != "3.29.2" || report.VersionSource != "latest stable release" {
+			t.Errorf("Expected the latest stable release, got %q from %q", report.FlutterVersion, report.VersionSource)
+		}
+
+		expected := []struct {
+			api  string
+			line int
+		}{
+			{"serviceWorkerVersion", 5},
+			{"_flutter.loader.loadEntrypoint", 12},
+			{"html renderer", 15},
+		}
+		if len(report.Findings) != len(expected) {
+			t.Fatalf("Expected %d findings, got %+v", len(expected), report.Findings)
+		}
+		for i, want := range expected {
+			if finding := report.Findings[i]; finding.API != want.api || finding.Line != want.line {
+				t.Errorf("Expected %s on line %d, got %+v", want.api, want.line, finding)
+			}
+		}
+		if !strings.Contains(report.Findings[1].Suggestion, "flutter_bootstrap.js") {
+			t.Errorf("Expected the flutter_bootstrap.js bootstrap, got %q", report.Findings[1].Suggestion)
+		}
+		if !strings.HasPrefix(report.Findings[2].Suggestion, "Remove the flag") {
+			t.Errorf("Expected the renderer flag to be dropped on 3.29, got %q", report.Findings[2].Suggestion)
+		}
+	})
+
+	t.Run("Suggestions follow the Flutter version", func(t *testing.T) {
+		report, err := service.CheckContent(ctx, legacyIndexHTML, "3.16.9")
+		if err != nil {
+			t.Fatalf("CheckContent failed: %v", err)
+		}
+		// loadEntrypoint and serviceWorkerVersion are still the current bootstrap before 3.22
+		if len(report.Findings) != 1 || report.Findings[0].API != "html renderer" {
+			t.Fatalf("Expected only the html renderer, got %+v", report.Findings)
+		}
+		if suggestion := report.Findings[0].Suggestion; strings.Contains(suggestion, "--wasm") {
+			t.Errorf("Expected no --wasm suggestion before it was stable, got %q", suggestion)
+		}
+
+		script := "flutter build web --web-renderer canvaskit\nflutter run -d chrome --web-renderer=html\n"
+		if report, _ := service.CheckContent(ctx, script, "3.24.5"); len(report.Findings) != 1 {
+			t.Errorf("Expected --web-renderer canvaskit to be fine on 3.24, got %+v", report.Findings)
+		}
+		report, _ = service.CheckContent(ctx, script, "3.29.0")
+		if len(report.Findings) != 2 || report.Findings[0].API != "--web-renderer" || report.Findings[0].Severity != config.SEVERITY_ERROR {
+			t.Errorf("Expected the removed flag to be an error on 3.29, got %+v", report.Findings)
+		}
+
+		report, _ = service.CheckContent(ctx, "import 'dart:html';\n", "3.16.9")
+		if len(report.Findings) != 1 || !strings.HasPrefix(report.Findings[0].Suggestion, "Keep dart:html") {
+			t.Errorf("Expected dart:html to stay before package:web is available, got %+v", report.Findings)
+		}
+
+		if _, err := service.CheckContent(ctx, script, "three"); err == nil {
+			t.Error("Expected an error for an invalid version")
+		}
+	})
+
+	t.Run("CheckPath scans a project with its pinned version", func(t *testing.T) {
+		dir := t.TempDir()
+		files := map[string]string{
+			".fvmrc":                   `{"flutter": "3.22.3"}`,
+			"web/index.html":           legacyIndexHTML,
+			"lib/src/web_view.dart":    "import 'dart:ui' as ui;\nvoid register() => ui.platformViewRegistry.registerViewFactory('map', build);\n",
+			".vscode/launch.json":      `{"configurations": [{"args": ["--web-renderer", "html"]}]}`,
+			"build/web/main.dart.js":   "window.flutterConfiguration = {};",
+			"lib/generated/api.g.dart": "import 'dart:html';",
+		}
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		report, err := service.CheckPath(ctx, dir, "")
+		if err != nil {
+			t.Fatalf("CheckPath failed: %v", err)
+		}
+		if report.FlutterVersion != "3.22.3" || report.VersionSource != ".fvmrc" {
+			t.Errorf("Expected the .fvmrc pin, got %q from %q", report.FlutterVersion, report.VersionSource)
+		}
+		if report.FilesScanned != 3 {
+			t.Errorf("Expected index.html, launch.json and one Dart file, got %d files", report.FilesScanned)
+		}
+
+		found := make(map[string]bool)
+		for _, finding := range report.Findings {
+			found[finding.API+" in "+finding.File] = true
+		}
+		for api, file := range map[string]string{
+			"html renderer":                  ".vscode/launch.json",
+			"_flutter.loader.loadEntrypoint": filepath.Join("web", "index.html"),
+			"dart:ui platformViewRegistry":   filepath.Join("lib", "src", "web_view.dart"),
+		} {
+			if !found[api+" in "+file] {
+				t.Errorf("Expected %s in %s, got %v", api, file, found)
+			}
+		}
+
+		if _, err := service.CheckPath(ctx, t.TempDir(), ""); err == nil {
+			t.Error("Expected an error for a directory without web files")
+		}
+	})
+
+	t.Run("unknown latest release", func(t *testing.T) {
+		report, err := NewWebCheckService(&MockFlutterAPIService{}).CheckContent(ctx, legacyIndexHTML, "")
+		if err != nil {
+			t.Fatalf("CheckContent failed: %v", err)
+		}
+		if report.VersionError == "" || len(report.Findings) != 3 {
+			t.Errorf("Expected every rule with the newest approach, got %+v", report)
+		}
+	})
+}
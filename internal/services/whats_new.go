@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+// replacementAPIPattern matches replacements that name an API, possibly as a call such as
+// Color.withValues(alpha: $1), rather than describe a migration
+var replacementAPIPattern = regexp.MustCompile(`^([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*)(?:\(.*\))?$`)
+
+// WhatsNewService summarizes what a Flutter release deprecated, broke and introduced, from the
+// deprecations cache, the GitHub release notes and the breaking changes index
+type WhatsNewService struct {
+	apiService   FlutterAPIServiceInterface
+	cacheService CacheServiceInterface
+	guideService MigrationGuideServiceInterface
+}
+
+// NewWhatsNewService creates a new release digest service instance. guideService may be nil, in
+// which case the digest has no breaking changes.
+func NewWhatsNewService(apiService FlutterAPIServiceInterface, cacheService CacheServiceInterface, guideService MigrationGuideServiceInterface) *WhatsNewService {
+	return &WhatsNewService{
+		apiService:   apiService,
+		cacheService: cacheService,
+		guideService: guideService,
+	}
+}
+
+// WhatsNew returns the digest of the release line of version (the latest stable release when
+// empty), so 3.27.1 reports what the 3.27 releases brought. Entries dated after the previous stable
+// release, including the pre-releases leading up to the line, count as introduced by it.
+// Unreachable sources are recorded in UpstreamErrors instead of failing the call.
+func (w *WhatsNewService) WhatsNew(ctx context.Context, version string) (*models.ReleaseDigest, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		latest, err := w.apiService.GetLatestStableVersion(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting latest stable version: %v", err)
+		}
+		version = latest
+	}
+	parsed, ok := parseVersion(version)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	release := semanticVersion{Major: parsed.Major, Minor: parsed.Minor}
+
+	digest := &models.ReleaseDigest{
+		Version:         release.String(),
+		ReleaseNotesURL: fmt.Sprintf(config.RELEASE_NOTES_DOCS_URL, release.String()),
+	}
+	previous, err := w.previousStable(ctx, release)
+	if err != nil {
+		digest.UpstreamErrors = append(digest.UpstreamErrors, fmt.Sprintf("official releases: %v; comparing with %s", err, previous))
+	}
+	digest.Previous = previous.String()
+
+	introduced := func(dep models.Deprecation) bool {
+		v, dated := parseVersion(dep.Version)
+		return dated && v.Compare(previous) > 0 && (v.Compare(release) <= 0 || sameLine(v, release))
+	}
+
+	var candidates []models.Deprecation
+	for _, rule := range builtinRules {
+		candidates = append(candidates, rule.deprecation)
+	}
+	if cache, err := w.cacheService.Load(); err == nil {
+		candidates = append(candidates, cache.Deprecations...)
+		candidates = append(candidates, cache.Manual...)
+	}
+	if releases, err := w.apiService.FetchReleases(ctx); err != nil {
+		digest.UpstreamErrors = append(digest.UpstreamErrors, fmt.Sprintf("GitHub releases: %v", err))
+	} else {
+		for _, r := range releases {
+			tag := w.apiService.ParseVersionFromRelease(r)
+			if v, ok := parseVersion(tag); ok && sameLine(v, release) {
+				candidates = append(candidates, releaseNoteDeprecations(tag, r.Body)...)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, dep := range candidates {
+		key := dep.Package + "\x00" + dep.API
+		if seen[key] || !introduced(dep) {
+			continue
+		}
+		seen[key] = true
+		if dep.DocURL == "" {
+			dep.DocURL = DocumentationURL(dep)
+		}
+		digest.Deprecations = append(digest.Deprecations, dep)
+	}
+	sort.SliceStable(digest.Deprecations, func(i, j int) bool {
+		return digest.Deprecations[i].API < digest.Deprecations[j].API
+	})
+	digest.Replacements = replacementAPIs(digest.Deprecations)
+
+	if w.guideService != nil {
+		changes, err := w.guideService.BreakingChangesBetween(ctx, previous.String(), release.String())
+		if err != nil {
+			digest.UpstreamErrors = append(digest.UpstreamErrors, fmt.Sprintf("breaking changes: %v", err))
+		} else {
+			if changes.UpstreamError != "" {
+				digest.UpstreamErrors = append(digest.UpstreamErrors, fmt.Sprintf("flutter/website: %s; using the curated breaking changes", changes.UpstreamError))
+			}
+			digest.BreakingChanges = changes.Changes
+		}
+	}
+
+	return digest, nil
+}
+
+// previousStable returns the .0 release of the stable line before release. Without the official
+// release list it falls back to the previous minor version, which Flutter sometimes skips.
+func (w *WhatsNewService) previousStable(ctx context.Context, release semanticVersion) (semanticVersion, error) {
+	fallback := semanticVersion{Major: release.Major, Minor: release.Minor - 1}
+	if release.Minor == 0 {
+		fallback = semanticVersion{Major: release.Major - 1}
+	}
+
+	releases, err := w.apiService.FetchOfficialReleases(ctx)
+	if err != nil {
+		return fallback, err
+	}
+
+	var previous semanticVersion
+	found := false
+	for _, r := range releases.Releases {
+		if r.Channel != "stable" {
+			continue
+		}
+		v, ok := parseVersion(r.Version)
+		if !ok {
+			continue
+		}
+		line := semanticVersion{Major: v.Major, Minor: v.Minor}
+		if line.Compare(release) < 0 && (!found || line.Compare(previous) > 0) {
+			previous, found = line, true
+		}
+	}
+	if !found {
+		return fallback, fmt.Errorf("no stable release before %s", release)
+	}
+	return previous, nil
+}
+
+// sameLine reports whether two versions belong to the same major.minor release line
+func sameLine(a semanticVersion, b semanticVersion) bool {
+	return a.Major == b.Major && a.Minor == b.Minor
+}
+
+// replacementAPIs groups the deprecated APIs by the API that replaces them, skipping replacements
+// that describe a migration instead of naming an API
+func replacementAPIs(deprecations []models.Deprecation) []models.ReplacementAPI {
+	index := make(map[string]int)
+	var replacements []models.ReplacementAPI
+	for _, dep := range deprecations {
+		matches := replacementAPIPattern.FindStringSubmatch(strings.TrimSpace(dep.Replacement))
+		if matches == nil {
+			continue
+		}
+		api := matches[1]
+		i, ok := index[api]
+		if !ok {
+			i = len(replacements)
+			index[api] = i
+			replacements = append(replacements, models.ReplacementAPI{API: api})
+		}
+		replacements[i].Replaces = append(replacements[i].Replaces, dep.API)
+	}
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].API < replacements[j].API
+	})
+	return replacements
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/models"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+)
+
+func TestWhatsNew(t *testing.T) {
+	officialReleases := &models.FlutterReleasesResponse{Releases: []models.FlutterOfficialRelease{
+		{Channel: "beta", Version: "3.28.0-0.1.pre"},
+		{Channel: "stable", Version: "3.27.1"},
+		{Channel: "stable", Version: "3.27.0"},
+		{Channel: "stable", Version: "3.24.5"},
+		{Channel: "stable", Version: "3.24.0"},
+		{Channel: "stable", Version: "3.22.0"},
+	}}
+
+	newCache := func(t *testing.T) *CacheService {
+		cacheService := &CacheService{dir: t.TempDir()}
+		err := cacheService.Save(&models.DeprecationCache{
+			Deprecations: []models.Deprecation{
+				{API: "Color.opacity", Replacement: "Color.a", Version: "3.26.0-0.1.pre", Source: config.DEPRECATION_SOURCE_FLUTTER},
+				{API: "Color.value", Replacement: "Color.toARGB32", Version: "3.27.0", Source: config.DEPRECATION_SOURCE_FLUTTER},
+				{API: "ButtonBar", Replacement: "Use OverflowBar instead", Version: "3.26.0-0.1.pre", Source: config.DEPRECATION_SOURCE_FLUTTER},
+				{API: "Old.line", Replacement: "New.line", Version: "3.24.0-0.1.pre", Source: config.DEPRECATION_SOURCE_FLUTTER},
+				{API: "Next.line", Replacement: "Later.line", Version: "3.28.0-0.1.pre", Source: config.DEPRECATION_SOURCE_FLUTTER},
+			},
+			Manual: []models.Deprecation{
+				{API: "LegacyTile", Replacement: "ModernTile", Version: "3.25", Source: config.DEPRECATION_SOURCE_MANUAL},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to save cache: %v", err)
+		}
+		return cacheService
+	}
+
+	t.Run("Collects the deprecations and replacements of the release line", func(t *testing.T) {
+		mockAPI := &MockFlutterAPIService{
+			officialReleases: officialReleases,
+			latestStable:     "3.27.1",
+			releases: []models.FlutterRelease{
+				{TagName: "3.27.1", Body: "Deprecated: ThemeData.dialogBackgroundColor in favor of DialogThemeData.backgroundColor"},
+				{TagName: "3.24.0", Body: "Deprecated: Older.api in favor of Newer.api"},
+			},
+		}
+		digest, err := NewWhatsNewService(mockAPI, newCache(t), nil).WhatsNew(context.Background(), "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if digest.Version != "3.27.0" || digest.Previous != "3.24.0" {
+			t.Errorf("Expected 3.27.0 compared with 3.24.0, got %s and %s", digest.Version, digest.Previous)
+		}
+		if !strings.HasSuffix(digest.ReleaseNotesURL, "release-notes-3.27.0") {
+			t.Errorf("Expected the 3.27.0 release notes, got %s", digest.ReleaseNotesURL)
+		}
+		if len(digest.UpstreamErrors) != 0 {
+			t.Errorf("Expected no upstream errors, got %v", digest.UpstreamErrors)
+		}
+
+		var apis []string
+		for _, dep := range digest.Deprecations {
+			apis = append(apis, dep.API)
+			if dep.DocURL == "" {
+				t.Errorf("Expected %s to have a documentation URL", dep.API)
+			}
+		}
+		expected := "ButtonBar,Color.opacity,Color.value,Color.withOpacity,LegacyTile,ThemeData.dialogBackgroundColor"
+		if got := strings.Join(apis, ","); got != expected {
+			t.Errorf("Expected deprecations %s, got %s", expected, got)
+		}
+
+		var replacements []string
+		for _, replacement := range digest.Replacements {
+			replacements = append(replacements, replacement.API+"="+strings.Join(replacement.Replaces, "+"))
+		}
+		expectedReplacements := "Color.a=Color.opacity,Color.toARGB32=Color.value,Color.withValues=Color.withOpacity,DialogThemeData.backgroundColor=ThemeData.dialogBackgroundColor,ModernTile=LegacyTile"
+		if got := strings.Join(replacements, ","); got != expectedReplacements {
+			t.Errorf("Expected replacements %s, got %s", expectedReplacements, got)
+		}
+	})
+
+	t.Run("Adds the breaking changes since the previous release", func(t *testing.T) {
+		mockAPI := &MockFlutterAPIService{officialReleases: officialReleases}
+		guides := NewMigrationGuideService(mockAPI, NewDeprecationService(&CacheService{dir: t.TempDir()}, mockAPI))
+		digest, err := NewWhatsNewService(mockAPI, newCache(t), guides).WhatsNew(context.Background(), "3.27")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var titles []string
+		for _, change := range digest.BreakingChanges {
+			titles = append(titles, change.Title)
+		}
+		if got := strings.Join(titles, ","); got != "Deprecated API removed after v3.24,Wide gamut Color" {
+			t.Errorf("Expected the curated 3.27 breaking changes, got %s", got)
+		}
+		if len(digest.UpstreamErrors) != 1 || !strings.Contains(digest.UpstreamErrors[0], "flutter/website") {
+			t.Errorf("Expected the unreachable index to be reported, got %v", digest.UpstreamErrors)
+		}
+	})
+
+	t.Run("Falls back to the previous minor version without the release list", func(t *testing.T) {
+		digest, err := NewWhatsNewService(&MockFlutterAPIService{}, newCache(t), nil).WhatsNew(context.Background(), "3.27.1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if digest.Previous != "3.26.0" {
+			t.Errorf("Expected to compare with 3.26.0, got %s", digest.Previous)
+		}
+		if len(digest.UpstreamErrors) != 1 || !strings.Contains(digest.UpstreamErrors[0], "official releases") {
+			t.Errorf("Expected the missing release list to be reported, got %v", digest.UpstreamErrors)
+		}
+		for _, dep := range digest.Deprecations {
+			if dep.API == "Color.opacity" {
+				t.Errorf("Expected entries dated before 3.26.0 to be left out, got %+v", dep)
+			}
+		}
+	})
+
+	t.Run("Rejects invalid versions", func(t *testing.T) {
+		if _, err := NewWhatsNewService(&MockFlutterAPIService{}, newCache(t), nil).WhatsNew(context.Background(), "latest"); err == nil {
+			t.Error("Expected an error for an invalid version")
+		}
+	})
+}
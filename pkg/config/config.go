@@ -3,14 +3,370 @@ package config
 import "time"
 
 const (
-	// Cache configuration
+	// Cache configuration; the directory is ~/.flutter-deprecations, or %APPDATA%\flutter-deprecations on Windows
+	CACHE_DIR_NAME = "flutter-deprecations"
 	CACHE_FILE     = "flutter_deprecations.json"
 	CACHE_DURATION = 24 * time.Hour
+	STATS_FILE     = "server_stats.json"
+
+	// How often --persist-stats writes the statistics recorded since the last write
+	STATS_FLUSH_INTERVAL = 30 * time.Second
+
+	// When an automatic update is rate limited, the time the GitHub quota resets is kept in this file
+	// and the automatic updates wait for it; RATE_LIMIT_BACKOFF applies when GitHub does not say
+	RATE_LIMIT_BACKOFF_FILE = "rate_limit_backoff.json"
+	RATE_LIMIT_BACKOFF      = time.Hour
+
+	// Upstream responses kept in the cache directory and revalidated with their ETag, and the largest one kept
+	HTTP_CACHE_DIR            = "http_cache"
+	HTTP_CACHE_MAX_ENTRY_SIZE = 16 << 20
+
+	// Deprecations found in each file of the project scans, by content hash, and the most files kept
+	SCAN_CACHE_FILE        = "scan_cache.json"
+	MAX_SCAN_CACHE_ENTRIES = 50000
+
+	// Directory of the last full scan of each git project, which incremental scans build on
+	PROJECT_SCANS_DIR = "project_scans"
+
+	// MCP resource exposing the deprecations cache
+	CACHE_RESOURCE_URI = "flutter-deprecations://cache"
+
+	// MCP resource template, and the URI of each resource, serving the deprecations of one Flutter
+	// release (major.minor) and category
+	DEPRECATIONS_RESOURCE_TEMPLATE = "flutter-deprecations://deprecations/{version}/{category}"
+	DEPRECATIONS_RESOURCE_URI      = "flutter-deprecations://deprecations/%s/%s"
+
+	// Set to true, 1 or yes to skip the cache update at startup, like --no-auto-update
+	NO_AUTO_UPDATE_ENV = "FLUTTER_DEPRECATIONS_NO_AUTO_UPDATE"
+
+	// Default schedule of the background cache refresh in daemon mode
+	DEFAULT_REFRESH_SCHEDULE = "@daily"
+
+	// Suppression files: machine-wide in the cache directory, per project in the project root
+	SUPPRESSIONS_FILE         = "suppressions.json"
+	PROJECT_SUPPRESSIONS_FILE = ".flutter-deprecations-suppressions.json"
+	SUPPRESSION_SCOPE_MACHINE = "machine"
+	SUPPRESSION_SCOPE_PROJECT = "project"
+
+	// Ignore files with the .gitignore syntax whose paths the project scans skip, in any project directory
+	GITIGNORE_FILE      = ".gitignore"
+	PROJECT_IGNORE_FILE = ".flutter-deprecations-ignore"
 
 	// API endpoints
-	FLUTTER_API_URL = "https://api.github.com/repos/flutter/flutter/releases"
+	FLUTTER_API_URL      = "https://api.github.com/repos/flutter/flutter/releases"
 	FLUTTER_RELEASES_URL = "https://storage.googleapis.com/flutter_infra_release/releases/releases_linux.json"
 
+	// flutter/flutter sources of the framework deprecations, by branch. The stable branch is scanned,
+	// plus the beta or master branch with --preview-channel to mark what is deprecated there upcoming.
+	FLUTTER_FRAMEWORK_RAW_URL = "https://raw.githubusercontent.com/flutter/flutter/%s/packages/flutter/lib/src/"
+	FLUTTER_CHANNEL_STABLE    = "stable"
+	FLUTTER_CHANNEL_BETA      = "beta"
+	FLUTTER_CHANNEL_MASTER    = "master"
+
+	// flutter/packages sources for the deprecations of first-party plugins
+	FLUTTER_PACKAGES_RAW_URL = "https://raw.githubusercontent.com/flutter/packages/main/packages/"
+	PUB_DOCS_URL             = "https://pub.dev/documentation/"
+
+	// pub.dev API listing every published version of a package with its environment constraints,
+	// followed by the package name; and the most dependencies one pin recommendation looks up
+	PUB_API_PACKAGES_URL = "https://pub.dev/api/packages/"
+	MAX_PIN_DEPENDENCIES = 100
+
+	// Security advisories pub.dev publishes for a package, formatted with its name, which the
+	// dependency audit of check_flutter_project looks up for each hosted package a pubspec.lock
+	// locks; and the most packages one audit looks up
+	PUB_ADVISORIES_URL     = "https://pub.dev/api/packages/%s/advisories"
+	PUBSPEC_LOCK_FILE      = "pubspec.lock"
+	MAX_AUDIT_DEPENDENCIES = 500
+	ADVISORY_DETAILS_URL   = "https://osv.dev/vulnerability/%s"
+	GITHUB_ADVISORY_URL    = "https://github.com/advisories/%s"
+
+	// Browsable sources of the scanned repositories, for linking annotations; the framework one is
+	// followed by the branch an entry was scanned from
+	FLUTTER_SOURCE_BLOB_URL  = "https://github.com/flutter/flutter/blob/"
+	PACKAGES_SOURCE_BLOB_URL = "https://github.com/flutter/packages/blob/main/"
+
+	// GitHub sources of arbitrary Dart packages scanned with scan_repo_deprecations
+	GITHUB_URL            = "https://github.com/"
+	GITHUB_RAW_URL        = "https://raw.githubusercontent.com/"
+	GITHUB_CONTENTS_URL   = "https://api.github.com/repos/%s/contents/%s"
+	MAX_REPO_SCAN_FILES   = 500
+	DEFAULT_REPO_SCAN_REF = "HEAD"
+
+	// GitHub pull requests reviewed with review_pull_request, and the marker that identifies the
+	// comments of earlier reviews so that a rerun does not repeat them
+	GITHUB_PULL_URL        = "https://api.github.com/repos/%s/pulls/%d"
+	MAX_PR_REVIEW_FILES    = 300
+	MAX_PR_REVIEW_COMMENTS = 50
+	PR_REVIEW_MARKER       = "<!-- flutter-deprecations: %s -->"
+
+	// GitHub API quota, and the token sent with GitHub API calls that raises it from 60 to 5,000 requests an hour
+	GITHUB_RATE_LIMIT_URL = "https://api.github.com/rate_limit"
+	GITHUB_TOKEN_ENV      = "GITHUB_TOKEN"
+
+	// Published security advisories of the Flutter and Dart SDK repositories, checked against the
+	// installed SDK by check_flutter_version_info
+	FLUTTER_SECURITY_ADVISORIES_URL = "https://api.github.com/repos/flutter/flutter/security-advisories?state=published&per_page=100"
+	DART_SECURITY_ADVISORIES_URL    = "https://api.github.com/repos/dart-lang/sdk/security-advisories?state=published&per_page=100"
+	ADVISORY_PRODUCT_FLUTTER        = "Flutter"
+	ADVISORY_PRODUCT_DART           = "Dart SDK"
+	CVE_DETAILS_URL                 = "https://nvd.nist.gov/vuln/detail/%s"
+
+	// Limits of one check_flutter_files call
+	MAX_CHECK_FILES     = 50
+	MAX_CHECK_FILE_SIZE = MAX_CODE_SIZE
+
+	// Workspaces check_flutter_project splits into packages: a melos.yaml, the workspace: list of a
+	// pub workspace root, or else the packages directory of a monorepo
+	MELOS_FILE                 = "melos.yaml"
+	DEFAULT_WORKSPACE_PACKAGES = "packages/*"
+	WORKSPACE_MELOS            = "melos"
+	WORKSPACE_PUB              = "pub"
+	WORKSPACE_PACKAGES         = "packages"
+
+	// Largest Dart source file the deprecation scans read, and the longest line in it; larger files,
+	// such as generated localizations, are skipped
+	MAX_SCAN_FILE_SIZE = 8 << 20
+	MAX_SCAN_LINE_SIZE = 1 << 20
+
+	// Encodings of the code argument of the check tools, and the largest code accepted once decoded
+	CODE_ENCODING_GZIP_BASE64 = "gzip+base64"
+	CODE_ENCODING_BASE64      = "base64"
+	MAX_CODE_SIZE             = 8 << 20
+
+	// flutter/website sources for breaking change migration guides
+	FLUTTER_WEBSITE_RAW_URL     = "https://raw.githubusercontent.com/flutter/website/main/"
+	BREAKING_CHANGES_SOURCE_DIR = "src/content/release/breaking-changes/"
+	BREAKING_CHANGES_DOCS_URL   = "https://docs.flutter.dev/release/breaking-changes/"
+	MAX_GUIDE_EXCERPT           = 4000
+
+	// Release notes page of a Flutter release, formatted with its version such as 3.27.0
+	RELEASE_NOTES_DOCS_URL = "https://docs.flutter.dev/release/release-notes/release-notes-%s"
+
+	// Team database shared over HTTP; the auth header value can also come from the environment
+	TEAM_DB_AUTH_ENV    = "FLUTTER_DEPRECATIONS_TEAM_DB_AUTH"
+	TEAM_DB_AUTH_HEADER = "Authorization"
+	TEAM_DB_TIMEOUT     = 15 * time.Second
+
+	// How long a flutter or fvm command may run before it is killed, and how long its children then
+	// have to release its output
+	DEFAULT_EXEC_TIMEOUT = 30 * time.Second
+	EXEC_WAIT_DELAY      = time.Second
+
+	// How long each external check of get_flutter_version_info may take; they run concurrently
+	FVM_CHECK_TIMEOUT      = 10 * time.Second
+	DOCKER_CHECK_TIMEOUT   = 10 * time.Second
+	ADVISORY_CHECK_TIMEOUT = 10 * time.Second
+
+	// How long the MCP client may take to answer a sampling request, and the most tokens a drafted
+	// replacement may use
+	SAMPLING_TIMEOUT    = 60 * time.Second
+	SAMPLING_MAX_TOKENS = 200
+
+	// How long the user may take to answer a confirmation the server asks for through elicitation
+	ELICITATION_TIMEOUT = 5 * time.Minute
+
+	// How long the client may take to list its roots, and how many directory levels below each
+	// root are searched for the Flutter project that tools default to
+	ROOTS_TIMEOUT     = 10 * time.Second
+	ROOT_SEARCH_DEPTH = 3
+
+	// Deprecations draft_replacements asks the client model about by default and at most, and the
+	// lines of source around the annotation sent along
+	DEFAULT_REPLACEMENT_DRAFTS = 5
+	MAX_REPLACEMENT_DRAFTS     = 20
+	DRAFT_CONTEXT_LINES        = 12
+
+	// How long check_upstreams waits for each upstream host; they are probed concurrently
+	UPSTREAM_CHECK_TIMEOUT = 5 * time.Second
+
+	// Flutter Docker base images, in the order generate_dockerfile prefers them
+	DOCKER_IMAGE_INSTRUMENTISTO = "instrumentisto/flutter"
+	DOCKER_IMAGE_CIRRUSLABS     = "ghcr.io/cirruslabs/flutter"
+
+	// Docker registries for the image checks: Docker Hub's names and registry API, the docker CLI
+	// configuration with registry logins, and a login for mirrors from the environment
+	DOCKER_HUB_REGISTRY          = "docker.io"
+	DOCKER_HUB_REGISTRY_API      = "https://registry-1.docker.io"
+	DOCKER_CONFIG_ENV            = "DOCKER_CONFIG"
+	DOCKER_CONFIG_FILE           = "config.json"
+	DOCKER_REGISTRY_USER_ENV     = "FLUTTER_DEPRECATIONS_REGISTRY_USER"
+	DOCKER_REGISTRY_PASSWORD_ENV = "FLUTTER_DEPRECATIONS_REGISTRY_PASSWORD"
+	DOCKER_MANIFEST_ACCEPT       = "application/vnd.oci.image.index.v1+json, application/vnd.docker.distribution.manifest.list.v2+json, application/vnd.docker.distribution.manifest.v2+json, application/vnd.oci.image.manifest.v1+json"
+
+	// How a Flutter version pinned in CI compares to the latest stable release
+	CI_PIN_CURRENT     = "current"
+	CI_PIN_OUTDATED    = "outdated"
+	CI_PIN_UNAVAILABLE = "unavailable"
+	CI_PIN_FLOATING    = "floating"
+	CI_PIN_UNKNOWN     = "unknown"
+
+	// REST API limits
+	REST_READ_HEADER_TIMEOUT = 10 * time.Second
+	REST_MAX_BODY            = 8 << 20
+
+	// Where a local Flutter SDK was found
+	SDK_SOURCE_PATH    = "PATH"
+	SDK_SOURCE_FVM     = "fvm"
+	SDK_SOURCE_PURO    = "puro"
+	SDK_SOURCE_INSTALL = "install path"
+	SDK_SOURCE_FLAG    = "--flutter-sdk"
+
+	// Environment variables that move the FVM and puro SDK stores
+	FVM_CACHE_ENV = "FVM_CACHE_PATH"
+	PURO_ROOT_ENV = "PURO_ROOT"
+
+	// Windows keeps per-user application data, FVM's SDKs and the pub cache outside the home
+	// directory; PUB_CACHE moves the pub cache on every system
+	APPDATA_ENV       = "APPDATA"
+	LOCAL_APPDATA_ENV = "LOCALAPPDATA"
+	PUB_CACHE_ENV     = "PUB_CACHE"
+
+	// Per-version deprecations scanned from local SDKs, kept in the cache directory
+	SDK_DEPRECATIONS_DIR = "sdk_versions"
+	SDK_FRAMEWORK_SOURCE = "packages/flutter/lib/src"
+
+	// Where an SDK keeps the templates flutter create generates projects from
+	SDK_TEMPLATES = "packages/flutter_tools/templates"
+
+	// How a desktop runner file compares to the template of the target Flutter version
+	RUNNER_FILE_CURRENT = "current"
+	RUNNER_FILE_STALE   = "stale"
+	RUNNER_FILE_MISSING = "missing"
+
+	// Template lines shown per runner file that differs
+	RUNNER_SAMPLE_LINES = 5
+
 	// API limits
 	MAX_RELEASES = 100
-)
\ No newline at end of file
+
+	// Default number of search_deprecations results
+	DEFAULT_SEARCH_RESULTS = 10
+
+	// Default number of recently introduced deprecations listed by deprecation_stats
+	DEFAULT_STATS_RECENT = 10
+
+	// Default number of stable releases listed by list_flutter_releases
+	DEFAULT_RELEASE_HISTORY = 20
+
+	// Default number of findings a listing tool returns before counting the rest as omitted
+	DEFAULT_MAX_RESULTS = 100
+
+	// Version data sources, consulted in priority order by VersionInfoService
+	VERSION_SOURCE_CLI      = "cli"
+	VERSION_SOURCE_OFFICIAL = "official"
+	VERSION_SOURCE_GITHUB   = "github"
+
+	// Where a deprecation entry came from
+	DEPRECATION_SOURCE_BUILTIN       = "builtin"
+	DEPRECATION_SOURCE_FLUTTER       = "flutter_source"
+	DEPRECATION_SOURCE_PACKAGES      = "flutter_packages"
+	DEPRECATION_SOURCE_RELEASE_NOTES = "release_notes"
+	DEPRECATION_SOURCE_MANUAL        = "manual"
+	DEPRECATION_SOURCE_REPO          = "repo_scan"
+	DEPRECATION_SOURCE_FIX_DATA      = "fix_data"
+	DEPRECATION_SOURCE_CUSTOM        = "custom"
+	DEPRECATION_SOURCE_REMOTE        = "remote"
+
+	// Rule providers the checks draw on, in their default order of precedence: the built-in
+	// patterns, the rules file, manual entries (including scanned repositories and the team
+	// database), a remote rules URL, the scanned Flutter sources and the data-driven fixes of the
+	// local Flutter SDK. $FLUTTER_DEPRECATIONS_RULE_PROVIDERS is the default of --rule-providers.
+	RULE_PROVIDER_BUILTIN     = "builtin"
+	RULE_PROVIDER_CUSTOM_YAML = "custom-yaml"
+	RULE_PROVIDER_MANUAL      = "manual"
+	RULE_PROVIDER_REMOTE      = "remote"
+	RULE_PROVIDER_SOURCE_SCAN = "source-scan"
+	RULE_PROVIDER_FIX_DATA    = "fix-data"
+	RULE_PROVIDERS_ENV        = "FLUTTER_DEPRECATIONS_RULE_PROVIDERS"
+
+	// Rules file read by the custom-yaml provider unless --rules-file names another, and the copy
+	// of the last rules downloaded from --rules-url, both in the cache directory
+	CUSTOM_RULES_FILE = "rules.yaml"
+	REMOTE_RULES_FILE = "remote_rules.yaml"
+
+	// Largest rules document downloaded from --rules-url
+	REMOTE_RULES_MAX_SIZE = 4 << 20
+
+	// How often a running server checks the rules files of the custom-yaml provider for edits
+	RULES_WATCH_INTERVAL = 2 * time.Second
+
+	// Kinds of code the check tools analyze: Flutter apps and packages, or pure Dart packages
+	TARGET_FLUTTER = "flutter"
+	TARGET_DART    = "dart"
+
+	// How urgently a deprecation needs to be addressed
+	SEVERITY_INFO    = "info"
+	SEVERITY_WARNING = "warning"
+	SEVERITY_ERROR   = "error"
+
+	// Least severity that fails a --scan when --fail-on is not given
+	FAIL_ON_ENV = "FLUTTER_DEPRECATIONS_FAIL_ON"
+
+	// Languages of the reports and command line messages, and the default when --language is not given
+	LANGUAGE_ENGLISH = "en"
+	LANGUAGE_GERMAN  = "de"
+	LANGUAGE_SPANISH = "es"
+	LANGUAGE_ENV     = "FLUTTER_DEPRECATIONS_LANGUAGE"
+
+	// Stable releases after which a deprecated Flutter API is likely to be removed: Flutter removes
+	// deprecations about a year, some four stable releases, after they ship
+	REMOVAL_LIKELY_RELEASES = 4
+
+	// Error code, error type and fix priority of the analyzer plugin protocol output, and the id
+	// of its replacement fixes
+	ANALYZER_ERROR_CODE   = "flutter_deprecated_api"
+	ANALYZER_ERROR_TYPE   = "HINT"
+	ANALYZER_FIX_PRIORITY = 50
+	ANALYZER_FIX_ID       = "flutter_deprecations.replace"
+
+	// Source and deprecated tag of the LSP diagnostics output
+	LSP_DIAGNOSTIC_SOURCE = "flutter-deprecations"
+	LSP_TAG_DEPRECATED    = 2
+
+	// Output formats of project scans: a report for people, a JSON report for scripts, or LSP
+	// diagnostics for editors
+	OUTPUT_FORMAT_TEXT = "text"
+	OUTPUT_FORMAT_JSON = "json"
+	OUTPUT_FORMAT_LSP  = "lsp"
+
+	// Groupings of the findings in project scan reports: by package (default), file, deprecated
+	// API or severity
+	GROUP_BY_PACKAGE  = "package"
+	GROUP_BY_FILE     = "file"
+	GROUP_BY_RULE     = "rule"
+	GROUP_BY_SEVERITY = "severity"
+
+	// Status of an API the code uses on one target of a version matrix: deprecated there, fine,
+	// not released yet, or deprecated in an unknown version; and the most targets of one matrix
+	MATRIX_DEPRECATED  = "deprecated"
+	MATRIX_OK          = "ok"
+	MATRIX_UNAVAILABLE = "unavailable"
+	MATRIX_UNKNOWN     = "unknown"
+	MAX_MATRIX_TARGETS = 20
+
+	// Failures of a rule test: a finding where none is expected, or an expected one that is missing
+	RULE_TEST_FALSE_POSITIVE = "false_positive"
+	RULE_TEST_FALSE_NEGATIVE = "false_negative"
+)
+
+// Languages returns the languages the reports and command line messages are available in
+func Languages() []string {
+	return []string{LANGUAGE_ENGLISH, LANGUAGE_GERMAN, LANGUAGE_SPANISH}
+}
+
+// UpstreamHosts returns the hosts the server downloads Flutter data from, which an air-gapped
+// installation points at internal mirrors
+func UpstreamHosts() []string {
+	return []string{"api.github.com", "raw.githubusercontent.com", "storage.googleapis.com", "hub.docker.com", "ghcr.io", "pub.dev"}
+}
+
+// DefaultRuleProviders returns the rule providers in their default order of precedence
+func DefaultRuleProviders() []string {
+	return []string{RULE_PROVIDER_BUILTIN, RULE_PROVIDER_CUSTOM_YAML, RULE_PROVIDER_MANUAL, RULE_PROVIDER_REMOTE, RULE_PROVIDER_SOURCE_SCAN, RULE_PROVIDER_FIX_DATA}
+}
+
+// DefaultVersionSources returns the default version source priority
+func DefaultVersionSources() []string {
+	return []string{VERSION_SOURCE_CLI, VERSION_SOURCE_OFFICIAL, VERSION_SOURCE_GITHUB}
+}
@@ -0,0 +1,117 @@
+// Package deprecations detects deprecated Flutter APIs and outdated Dart syntax in source code and
+// migrates what can be rewritten mechanically. It is the engine behind the MCP server's tools, for
+// Go programs such as linters, bots and CI plugins that do not speak MCP.
+//
+//	engine := deprecations.New(deprecations.Options{})
+//	if err := engine.Update(ctx); err != nil {
+//		log.Printf("using the cached deprecations: %v", err)
+//	}
+//	for _, dep := range engine.Check(code) {
+//		fmt.Println(dep.API, "→", dep.Replacement)
+//	}
+package deprecations
+
+import (
+	"context"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/flutter"
+)
+
+type (
+	// Deprecation is a deprecated API with its replacement and the release that deprecated it
+	Deprecation = flutter.Deprecation
+	// Cache is the on-disk deprecations cache, shared with the MCP server
+	Cache = flutter.DeprecationCache
+	// Match is a search result ranked by relevance
+	Match = flutter.DeprecationMatch
+	// Migration is code with the mechanical replacements applied and what is left to migrate by hand
+	Migration = flutter.MigrationResult
+	// VersionCheck splits the deprecations in code by whether an upgrade introduces them
+	VersionCheck = flutter.VersionCheckResult
+)
+
+// Options configures an Engine
+type Options struct {
+	// CacheDir holds the deprecations cache. The default, ~/.flutter-deprecations or
+	// %APPDATA%\flutter-deprecations on Windows, is shared with the server.
+	CacheDir string
+}
+
+// Engine checks and migrates code against the built-in rules and the cached Flutter deprecations
+type Engine struct {
+	service *services.DeprecationService
+	cache   *services.CacheService
+}
+
+// New creates an engine. It works offline with the built-in rules and whatever is cached; call
+// Update to fetch the deprecations from the Flutter sources.
+func New(opts Options) *Engine {
+	cache := services.NewCacheService()
+	if opts.CacheDir != "" {
+		cache = services.NewCacheServiceInDir(opts.CacheDir)
+	}
+	return &Engine{
+		service: services.NewDeprecationService(cache, services.NewFlutterAPIService()),
+		cache:   cache,
+	}
+}
+
+// Check returns the deprecations used in code
+func (e *Engine) Check(code string) []Deprecation {
+	return e.service.CheckCodeForDeprecations(code)
+}
+
+// CheckDart returns the deprecated Dart SDK APIs and syntax used in code of a pure Dart package,
+// skipping the Flutter rules
+func (e *Engine) CheckDart(code string) []Deprecation {
+	return e.service.CheckDartCode(code)
+}
+
+// CheckAgainstVersion reports which deprecations in code an upgrade from current (optional) to
+// target introduces
+func (e *Engine) CheckAgainstVersion(code string, target string, current string) (*VersionCheck, error) {
+	return e.service.CheckCodeAgainstVersion(code, target, current)
+}
+
+// Migrate applies every mechanical replacement to code
+func (e *Engine) Migrate(code string) Migration {
+	return e.service.MigrateCode(code)
+}
+
+// Find returns the known entries for an API name
+func (e *Engine) Find(api string) []Deprecation {
+	return e.service.FindDeprecations(api)
+}
+
+// Search ranks the known deprecations against a free-text query, returning at most limit results
+// (all when limit <= 0)
+func (e *Engine) Search(query string, limit int) []Match {
+	return e.service.SearchDeprecations(query, limit)
+}
+
+// AddManual stores a project-specific deprecation next to the fetched ones and reports whether
+// it replaced an existing manual entry
+func (e *Engine) AddManual(dep Deprecation) (bool, error) {
+	return e.service.AddManualDeprecation(dep)
+}
+
+// Update fetches the deprecations from the Flutter sources when the cache is missing or stale
+func (e *Engine) Update(ctx context.Context) error {
+	return e.service.UpdateCache(ctx)
+}
+
+// Refresh fetches the deprecations from the Flutter sources regardless of the cache age
+func (e *Engine) Refresh(ctx context.Context) error {
+	return e.service.RefreshCache(ctx)
+}
+
+// Cache returns the cached deprecations
+func (e *Engine) Cache() (*Cache, error) {
+	return e.cache.Load()
+}
+
+// DocumentationURL returns the API documentation page for a deprecation, or "" when unknown
+func DocumentationURL(dep Deprecation) string {
+	return services.DocumentationURL(dep)
+}
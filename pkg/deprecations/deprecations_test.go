@@ -0,0 +1,35 @@
+package deprecations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngine(t *testing.T) {
+	engine := New(Options{CacheDir: t.TempDir()})
+
+	found := engine.Check("RaisedButton(onPressed: save)")
+	if len(found) == 0 || found[0].API != "RaisedButton" {
+		t.Fatalf("Expected RaisedButton to be detected, got %+v", found)
+	}
+
+	migration := engine.Migrate("Colors.red.withOpacity(0.5)")
+	if migration.Code != "Colors.red.withValues(alpha: 0.5)" {
+		t.Errorf("Expected the withOpacity rewrite, got %q", migration.Code)
+	}
+
+	if _, err := engine.AddManual(Deprecation{API: "LegacyCard", Replacement: "AppCard"}); err != nil {
+		t.Fatalf("AddManual failed: %v", err)
+	}
+	cache, err := engine.Cache()
+	if err != nil || len(cache.Manual) != 1 {
+		t.Fatalf("Expected the manual entry in the cache directory, got %+v (%v)", cache, err)
+	}
+	if len(engine.Find("LegacyCard")) != 1 {
+		t.Error("Expected the manual entry to be found")
+	}
+
+	if url := DocumentationURL(found[0]); !strings.Contains(url, "breaking-changes/buttons") {
+		t.Errorf("Unexpected documentation URL %s", url)
+	}
+}
@@ -0,0 +1,152 @@
+// Package flutter defines the data of the public API: deprecations, the deprecations cache,
+// migrations, Flutter releases and how their versions sort. pkg/deprecations and pkg/flutterver return these types, and the
+// server's internal packages are built on them, so a change to them is a change to the public API.
+package flutter
+
+import "time"
+
+// Deprecation represents a deprecated Flutter API. Package names the first-party plugin from
+// flutter/packages (or the package scanned with scan_repo_deprecations) that deprecated it and is
+// empty for the framework. DocURL links to the API reference or migration guide. SourceFile and
+// SourceLine locate the @Deprecated annotation of scanned entries in their repository, which
+// Repository names as owner/name@ref for entries scanned from an arbitrary GitHub repository.
+// FirstSeen and LastSeen record when a scan first found and last confirmed the annotation; Removed
+// marks entries whose annotation is gone upstream, which usually means the API itself was removed.
+// Compatible is the form that also works on releases before Version, for code that has to support
+// both sides of the change. Channel is the flutter/flutter branch a framework entry was scanned
+// from (empty for entries scanned from master before the stable branch was scanned); Upcoming
+// marks entries deprecated on the preview branch but not yet on stable. ReplacementDraftedBy names
+// the model of the MCP client that drafted Replacement for a scanned entry without one; such a
+// replacement is AI-generated and unverified.
+type Deprecation struct {
+	API                  string    `json:"api"`
+	Replacement          string    `json:"replacement"`
+	Version              string    `json:"version"`
+	Description          string    `json:"description"`
+	Example              string    `json:"example,omitempty"`
+	Compatible           string    `json:"compatible,omitempty"`
+	Category             string    `json:"category,omitempty"`
+	Severity             string    `json:"severity,omitempty"`
+	Source               string    `json:"source,omitempty"`
+	Package              string    `json:"package,omitempty"`
+	DocURL               string    `json:"doc_url,omitempty"`
+	SourceFile           string    `json:"source_file,omitempty"`
+	SourceLine           int       `json:"source_line,omitempty"`
+	Repository           string    `json:"repository,omitempty"`
+	FirstSeen            time.Time `json:"first_seen,omitzero"`
+	LastSeen             time.Time `json:"last_seen,omitzero"`
+	Removed              bool      `json:"removed,omitempty"`
+	Channel              string    `json:"channel,omitempty"`
+	Upcoming             bool      `json:"upcoming,omitempty"`
+	ReplacementDraftedBy string    `json:"replacement_drafted_by,omitempty"`
+}
+
+// DeprecationCache represents the local cache structure. Manual entries are added by users, by
+// hand or by scanning a repository, and are kept when the scanned deprecations are refreshed.
+// LastChanges is what the latest refresh changed.
+type DeprecationCache struct {
+	LastUpdated  time.Time     `json:"last_updated"`
+	Deprecations []Deprecation `json:"deprecations"`
+	Manual       []Deprecation `json:"manual,omitempty"`
+	LastChanges  *CacheChanges `json:"last_changes,omitempty"`
+}
+
+// CacheChanges is the difference between the deprecations before and after a cache refresh. From
+// is zero when the refresh filled an empty cache, in which case nothing is listed as added, and To
+// is zero as well when no refresh was recorded.
+type CacheChanges struct {
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Total    int                 `json:"total"`
+	Added    []Deprecation       `json:"added"`
+	Removed  []Deprecation       `json:"removed"`
+	Modified []DeprecationChange `json:"modified"`
+}
+
+// DeprecationChange is an entry whose details changed in a refresh
+type DeprecationChange struct {
+	Before Deprecation `json:"before"`
+	After  Deprecation `json:"after"`
+	Fields []string    `json:"fields"`
+}
+
+// DeprecationMatch is a search result ranked by relevance
+type DeprecationMatch struct {
+	Deprecation
+	Score int `json:"score"`
+}
+
+// VersionCheckResult groups the deprecations found in code relative to a target Flutter version.
+// Unavailable lists the APIs the code uses that first ship after the target.
+type VersionCheckResult struct {
+	TargetVersion  string           `json:"target_version"`
+	CurrentVersion string           `json:"current_version,omitempty"`
+	Introduced     []Deprecation    `json:"introduced"`
+	AlreadyPresent []Deprecation    `json:"already_present"`
+	Undated        []Deprecation    `json:"undated"`
+	NotYet         int              `json:"not_yet"`
+	Unavailable    []APIRequirement `json:"unavailable,omitempty"`
+}
+
+// APIRequirement is an API usage that needs a minimum Flutter version. Alternative, when set, is
+// what to write instead on older releases.
+type APIRequirement struct {
+	API         string `json:"api"`
+	Version     string `json:"version"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line"`
+	Alternative string `json:"alternative,omitempty"`
+}
+
+// MigrationResult contains the rewritten code and what is left to migrate by hand. Previews
+// show each rewritten line next to the original, and templates how to carry over the arguments
+// of the structural migrations among the changes and the manual ones.
+type MigrationResult struct {
+	Code      string              `json:"code"`
+	Changes   []MigrationChange   `json:"changes"`
+	Manual    []PendingMigration  `json:"manual"`
+	Previews  []FixPreview        `json:"previews,omitempty"`
+	Templates []MigrationTemplate `json:"templates,omitempty"`
+}
+
+// MigrationChange is a replacement applied by the migrate_code tool
+type MigrationChange struct {
+	API    string `json:"api"`
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// PendingMigration is a deprecated usage the migrate_code tool could not rewrite automatically
+type PendingMigration struct {
+	API         string `json:"api"`
+	Line        int    `json:"line"`
+	Replacement string `json:"replacement,omitempty"`
+	Reason      string `json:"reason"`
+	DocURL      string `json:"doc_url,omitempty"`
+}
+
+// FixPreview shows lines of the user's code before and after the mechanical replacements
+// applied to them
+type FixPreview struct {
+	Line   int      `json:"line"`
+	APIs   []string `json:"apis"`
+	Before string   `json:"before"`
+	After  string   `json:"after"`
+}
+
+// MigrationTemplate is a complete rewrite of a structural migration, such as a legacy button to
+// its Material 3 successor, with the destination of every old constructor parameter
+type MigrationTemplate struct {
+	API         string             `json:"api"`
+	Replacement string             `json:"replacement"`
+	Before      string             `json:"before"`
+	After       string             `json:"after"`
+	Parameters  []ParameterMapping `json:"parameters"`
+}
+
+// ParameterMapping tells where an argument of a deprecated constructor goes in its replacement
+type ParameterMapping struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
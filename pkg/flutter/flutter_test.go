@@ -0,0 +1,31 @@
+package flutter
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCacheFormat(t *testing.T) {
+	data := `{"last_updated": "2025-01-02T03:04:05Z", "deprecations": [{"api": "RaisedButton", "replacement": "ElevatedButton", "version": "2.0.0", "description": "Use ElevatedButton"}], "manual": [{"api": "LegacyCard"}]}`
+	var cache DeprecationCache
+	if err := json.Unmarshal([]byte(data), &cache); err != nil {
+		t.Fatalf("Expected the cache file format to parse, got %v", err)
+	}
+	if cache.LastUpdated.Year() != 2025 || len(cache.Deprecations) != 1 || cache.Deprecations[0].Replacement != "ElevatedButton" || cache.Manual[0].API != "LegacyCard" {
+		t.Errorf("Unexpected cache %+v", cache)
+	}
+}
+
+func TestNoInternalImports(t *testing.T) {
+	out, err := exec.Command("go", "list", "-deps", ".").Output()
+	if err != nil {
+		t.Skipf("go list is not available: %v", err)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		if strings.HasPrefix(dep, "github.com/jger/mcp-flutter-deprecations-server/internal/") {
+			t.Errorf("Expected the public types to depend on no internal package, got %s", dep)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package flutter
+
+// GitHubRelease is a Flutter release published on the flutter/flutter GitHub repository
+type GitHubRelease struct {
+	Name        string `json:"name"`
+	TagName     string `json:"tag_name"`
+	PublishedAt string `json:"published_at"`
+	Body        string `json:"body"`
+	Prerelease  bool   `json:"prerelease"`
+}
+
+// Release is an entry of the official Flutter releases API
+type Release struct {
+	Hash           string `json:"hash"`
+	Channel        string `json:"channel"`
+	Version        string `json:"version"`
+	DartSDKVersion string `json:"dart_sdk_version"`
+	DartSDKArch    string `json:"dart_sdk_arch"`
+	ReleaseDate    string `json:"release_date"`
+	Archive        string `json:"archive"`
+	SHA256         string `json:"sha256"`
+}
+
+// Releases is the official Flutter release list with the current release of each channel
+type Releases struct {
+	BaseURL        string `json:"base_url"`
+	CurrentRelease struct {
+		Beta   string `json:"beta"`
+		Dev    string `json:"dev"`
+		Stable string `json:"stable"`
+	} `json:"current_release"`
+	Releases []Release `json:"releases"`
+}
+
+// VersionInfo is the latest stable Flutter version with its FVM and Docker availability and the
+// security advisories that affect it
+type VersionInfo struct {
+	LatestVersion    string `json:"latest_version"`
+	Source           string `json:"source"`
+	FVMInstalled     bool   `json:"fvm_installed"`
+	FVMVersionExists bool   `json:"fvm_version_exists"`
+	DockerImages     struct {
+		Instrumentisto bool `json:"instrumentisto"`
+		CirrusLabs     bool `json:"cirruslabs"`
+	} `json:"docker_images"`
+	Advisories *AdvisoryCheck `json:"advisories,omitempty"`
+	Details    string         `json:"details"`
+}
+
+// AdvisoryCheck lists the security advisories that affect a Flutter version or its Dart SDK.
+// UpstreamErrors lists the advisory sources that could not be reached, leaving the list to the
+// curated advisories.
+type AdvisoryCheck struct {
+	FlutterVersion string             `json:"flutter_version"`
+	DartVersion    string             `json:"dart_version,omitempty"`
+	Advisories     []SecurityAdvisory `json:"advisories"`
+	UpstreamErrors []string           `json:"upstream_errors,omitempty"`
+}
+
+// SecurityAdvisory is a published vulnerability of the Flutter or Dart SDK. Affected is the
+// vulnerable version range of Product, Fixed the first Product version with the fix and
+// FixedFlutter the first stable Flutter release that ships it.
+type SecurityAdvisory struct {
+	ID           string `json:"id"`
+	CVE          string `json:"cve,omitempty"`
+	Product      string `json:"product"`
+	Summary      string `json:"summary"`
+	Severity     string `json:"severity,omitempty"`
+	URL          string `json:"url"`
+	Affected     string `json:"affected"`
+	Fixed        string `json:"fixed,omitempty"`
+	FixedFlutter string `json:"fixed_flutter,omitempty"`
+}
@@ -0,0 +1,122 @@
+package flutter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionPattern matches Flutter versions such as 3.27, v3.27.1, 3.19.0-0.1.pre and
+// 1.12.13+hotfix.9, including ones embedded in text like "deprecated after v3.19.0-0.1.pre"
+var versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?(?:[-+]([0-9A-Za-z.-]+))?`)
+
+// hotfixPattern matches the qualifier of a stable release patched after it shipped
+var hotfixPattern = regexp.MustCompile(`^hotfix\.(\d+)$`)
+
+// Version is a parsed Flutter or Dart version. Hotfix is the N of a +hotfix.N qualifier and
+// Prerelease what follows the - of a pre-release such as 3.19.0-0.1.pre.
+type Version struct {
+	Major, Minor, Patch, Hotfix int
+	Prerelease                  string
+}
+
+// ParseVersion extracts the first version in s
+func ParseVersion(s string) (Version, bool) {
+	matches := versionPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return Version{}, false
+	}
+
+	var v Version
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	if matches[3] != "" {
+		v.Patch, _ = strconv.Atoi(matches[3])
+	}
+	v.Prerelease = strings.TrimRight(matches[4], ".-")
+	if hotfix := hotfixPattern.FindStringSubmatch(v.Prerelease); hotfix != nil {
+		v.Hotfix, _ = strconv.Atoi(hotfix[1])
+		v.Prerelease = ""
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 when v is older than, equal to or newer than other. A pre-release
+// sorts before its release and a hotfix after it, so 3.19.0-0.1.pre < 3.19.0 < 3.19.0+hotfix.1.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		}
+		if diff > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease && v.Hotfix != other.Hotfix:
+		if v.Hotfix < other.Hotfix {
+			return -1
+		}
+		return 1
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.Prerelease, other.Prerelease)
+	}
+}
+
+// comparePrerelease compares dot separated pre-release identifiers, numerically where both are numbers
+func comparePrerelease(a string, b string) int {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		switch {
+		case errA == nil && errB == nil && numA != numB:
+			if numA < numB {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && partsA[i] != partsB[i]:
+			if partsA[i] < partsB[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(partsA) < len(partsB):
+		return -1
+	case len(partsA) > len(partsB):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats the version as major.minor.patch[-prerelease] or major.minor.patch+hotfix.N
+func (v Version) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Hotfix > 0 {
+		s += "+hotfix." + strconv.Itoa(v.Hotfix)
+	}
+	return s
+}
+
+// CompareVersions compares two version strings; ok is false when either cannot be parsed
+func CompareVersions(a string, b string) (result int, ok bool) {
+	va, okA := ParseVersion(a)
+	vb, okB := ParseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return va.Compare(vb), true
+}
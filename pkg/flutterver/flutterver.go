@@ -0,0 +1,102 @@
+// Package flutterver looks up Flutter releases: the latest stable version, the official release
+// list, and whether a version is available through FVM, Docker images or the local Flutter CLI.
+//
+//	client := flutterver.New()
+//	latest, err := client.LatestStable(ctx)
+//	if err == nil && client.DockerImageExists(ctx, "instrumentisto/flutter", latest) {
+//		fmt.Println("instrumentisto/flutter:" + latest)
+//	}
+package flutterver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jger/mcp-flutter-deprecations-server/internal/services"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/config"
+	"github.com/jger/mcp-flutter-deprecations-server/pkg/flutter"
+)
+
+type (
+	// Release is an entry of the official Flutter release list
+	Release = flutter.Release
+	// Releases is the official Flutter release list with the current release of each channel
+	Releases = flutter.Releases
+	// GitHubRelease is a release published on the flutter/flutter GitHub repository
+	GitHubRelease = flutter.GitHubRelease
+	// Info is the latest stable version with its FVM and Docker availability
+	Info = flutter.VersionInfo
+	// Version is a parsed Flutter version such as 3.27.1, 3.28.0-0.1.pre or 1.12.13+hotfix.9
+	Version = flutter.Version
+)
+
+// Sources for the latest stable version, in the order Info consults them by default
+const (
+	SourceCLI      = config.VERSION_SOURCE_CLI
+	SourceOfficial = config.VERSION_SOURCE_OFFICIAL
+	SourceGitHub   = config.VERSION_SOURCE_GITHUB
+)
+
+// Client fetches Flutter release data from the official releases API, GitHub and Docker registries
+type Client struct {
+	api *services.FlutterAPIService
+}
+
+// New creates a client
+func New() *Client {
+	return &Client{api: services.NewFlutterAPIService()}
+}
+
+// SetTransport sends the client's requests through transport, for example a proxy or a test server
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.api.SetTransport(transport)
+}
+
+// LatestStable returns the latest stable Flutter version, such as 3.27.1
+func (c *Client) LatestStable(ctx context.Context) (string, error) {
+	return c.api.GetLatestStableVersion(ctx)
+}
+
+// Releases returns the official Flutter release list
+func (c *Client) Releases(ctx context.Context) (*Releases, error) {
+	return c.api.FetchOfficialReleases(ctx)
+}
+
+// GitHubReleases returns the releases published on GitHub, newest first
+func (c *Client) GitHubReleases(ctx context.Context) ([]GitHubRelease, error) {
+	return c.api.FetchReleases(ctx)
+}
+
+// DockerImageExists reports whether a Docker Hub or GHCR image publishes tag
+func (c *Client) DockerImageExists(ctx context.Context, image string, tag string) bool {
+	return c.api.CheckDockerImageExists(ctx, image, tag)
+}
+
+// FVMVersionExists reports whether FVM can install version
+func (c *Client) FVMVersionExists(ctx context.Context, version string) bool {
+	return c.api.CheckFVMVersionExists(ctx, version)
+}
+
+// Info returns the latest stable version and its availability, trying sources in order (by
+// default the local CLI, then the official releases API, then GitHub)
+func (c *Client) Info(ctx context.Context, sources ...string) (*Info, error) {
+	return services.NewVersionInfoService(c.api, sources...).GetFlutterVersionInfo(ctx)
+}
+
+// Installed returns the version and channel of the flutter command on PATH
+func Installed(ctx context.Context) (string, string, error) {
+	return services.NewFlutterVersionService().GetInstalledVersionAndChannel(ctx)
+}
+
+// Parse extracts the first Flutter version in s, such as 3.19.0-0.1.pre in "deprecated after
+// v3.19.0-0.1.pre"
+func Parse(s string) (Version, bool) {
+	return flutter.ParseVersion(s)
+}
+
+// Compare compares two Flutter versions such as 3.27.1 and 3.28.0-0.1.pre, returning -1, 0 or 1;
+// a pre-release sorts before its release and a hotfix after it. ok is false when either cannot be
+// parsed.
+func Compare(a string, b string) (result int, ok bool) {
+	return flutter.CompareVersions(a, b)
+}
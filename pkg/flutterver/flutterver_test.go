@@ -0,0 +1,116 @@
+package flutterver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// officialReleases lists 3.27.1 as the current stable release, after a newer beta
+const officialReleases = `{"current_release": {"stable": "b2"}, "releases": [
+	{"hash": "c3", "channel": "beta", "version": "3.28.0-0.1.pre"},
+	{"hash": "b2", "channel": "stable", "version": "3.27.1"},
+	{"hash": "a1", "channel": "stable", "version": "3.27.0"}
+]}`
+
+// fakeUpstreams answers the official releases list and the Docker Hub tags of instrumentisto/flutter,
+// and 404 to everything else
+func fakeUpstreams() *Client {
+	client := New()
+	client.SetTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		status, body := http.StatusNotFound, "{}"
+		switch {
+		case r.URL.Host == "storage.googleapis.com":
+			status, body = http.StatusOK, officialReleases
+		case r.URL.Host == "hub.docker.com" && r.URL.Path == "/v2/repositories/instrumentisto/flutter/tags/3.27.1":
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}, Request: r}, nil
+	}))
+	return client
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	client := fakeUpstreams()
+
+	if latest, err := client.LatestStable(ctx); err != nil || latest != "3.27.1" {
+		t.Errorf("Expected the current stable release 3.27.1, got %q (%v)", latest, err)
+	}
+	releases, err := client.Releases(ctx)
+	if err != nil || len(releases.Releases) != 3 || releases.CurrentRelease.Stable != "b2" {
+		t.Errorf("Expected the official release list, got %+v (%v)", releases, err)
+	}
+	if !client.DockerImageExists(ctx, "instrumentisto/flutter", "3.27.1") {
+		t.Error("Expected the instrumentisto/flutter:3.27.1 image to exist")
+	}
+	if client.DockerImageExists(ctx, "instrumentisto/flutter", "3.28.0-0.1.pre") {
+		t.Error("Expected no image for a tag the registry does not publish")
+	}
+}
+
+func TestInfo(t *testing.T) {
+	info, err := fakeUpstreams().Info(context.Background(), SourceOfficial)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if info.LatestVersion != "3.27.1" || info.Source != SourceOfficial {
+		t.Errorf("Expected 3.27.1 from the official releases API, got %s from %s", info.LatestVersion, info.Source)
+	}
+	if !info.DockerImages.Instrumentisto || info.DockerImages.CirrusLabs {
+		t.Errorf("Expected only the instrumentisto image, got %+v", info.DockerImages)
+	}
+
+	if _, err := New().Info(context.Background(), "nowhere"); err == nil || !strings.Contains(err.Error(), "nowhere") {
+		t.Errorf("Expected an unknown source to fail, got %v", err)
+	}
+}
+
+func TestInstalled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake flutter is a shell script")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho >> " + calls + "\n" +
+		`echo '{"frameworkVersion": "3.27.1", "channel": "beta", "dartSdkVersion": "3.6.0"}'` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "flutter"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	version, channel, err := Installed(context.Background())
+	if err != nil || version != "3.27.1" || channel != "beta" {
+		t.Errorf("Expected 3.27.1 on beta, got %q on %q (%v)", version, channel, err)
+	}
+	if data, _ := os.ReadFile(calls); strings.Count(string(data), "\n") != 1 {
+		t.Errorf("Expected flutter to run once, ran %d times", strings.Count(string(data), "\n"))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	if result, ok := Compare("3.27.1", "3.28.0-0.1.pre"); !ok || result != -1 {
+		t.Errorf("Expected 3.27.1 < 3.28.0-0.1.pre, got %d (ok=%v)", result, ok)
+	}
+	if result, ok := Compare("1.12.13+hotfix.9", "1.12.13"); !ok || result != 1 {
+		t.Errorf("Expected a hotfix to sort after its release, got %d (ok=%v)", result, ok)
+	}
+	if _, ok := Compare("stable", "3.27.1"); ok {
+		t.Error("Expected a channel name not to parse as a version")
+	}
+	if v, ok := Parse("This feature was deprecated after v3.19.0-0.1.pre."); !ok || v.String() != "3.19.0-0.1.pre" {
+		t.Errorf("Expected the version in the message, got %v (ok=%v)", v, ok)
+	}
+}